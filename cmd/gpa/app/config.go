@@ -0,0 +1,31 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/pflag"
+)
+
+// EffectiveConfig returns the values of all the flags of the set as indented JSON keyed by the flag names,
+// the defaults apply to the flags which are not set.
+func EffectiveConfig(flags *pflag.FlagSet) ([]byte, error) {
+	config := map[string]string{}
+	flags.VisitAll(func(f *pflag.Flag) {
+		config[f.Name] = f.Value.String()
+	})
+	return json.MarshalIndent(config, "", "  ")
+}
@@ -0,0 +1,54 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectiveConfig(t *testing.T) {
+	options := NewServerRunOptions()
+	require.NoError(t, pflag.CommandLine.Parse([]string{"--resync=5m", "--min-scale-interval=1m",
+		"--general-pod-autoscaler-tolerance=0.2", "--election-name=gpa-test"}))
+
+	raw, err := EffectiveConfig(pflag.CommandLine)
+	require.NoError(t, err)
+	config := map[string]string{}
+	require.NoError(t, json.Unmarshal(raw, &config))
+
+	// the parsed flags
+	assert.Equal(t, 5*time.Minute, options.Resync)
+	assert.Equal(t, options.Resync.String(), config["resync"])
+	assert.Equal(t, options.MinScaleInterval.String(), config["min-scale-interval"])
+	assert.Equal(t, strconv.FormatFloat(options.GeneralPodAutoscalerTolerance, 'g', -1, 64),
+		config["general-pod-autoscaler-tolerance"])
+	assert.Equal(t, options.ElectionName, config["election-name"])
+	// the defaults
+	assert.Equal(t, options.GeneralPodAutoscalerSyncPeriod.Duration.String(), config["general-pod-autoscaler-sync-period"])
+	assert.Equal(t, strconv.Itoa(options.DecisionHistorySize), config["decision-history-size"])
+	assert.Equal(t, options.ElectionResourceLock, config["election-resource-lock"])
+	assert.Equal(t, "false", config["print-config"])
+	// every flag is dumped
+	pflag.CommandLine.VisitAll(func(f *pflag.Flag) {
+		assert.Contains(t, config, f.Name)
+	})
+}
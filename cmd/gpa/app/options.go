@@ -25,14 +25,35 @@ import (
 )
 
 type RunOptions struct {
-	KubeconfigPath       string
-	MasterUrl            string
-	QPS                  int
-	Burst                int
-	Resync               time.Duration
-	ElectionName         string
-	ElectionNamespace    string
-	ElectionResourceLock string
+	KubeconfigPath        string
+	MasterUrl             string
+	QPS                   int
+	Burst                 int
+	Resync                time.Duration
+	ElectionName          string
+	ElectionNamespace     string
+	ElectionResourceLock  string
+	DefaultsConfigMap     string
+	MinScaleInterval      time.Duration
+	AdaptiveResyncMin     time.Duration
+	AdaptiveResyncMax     time.Duration
+	ShutdownTimeout       time.Duration
+	ScaleUpdateRetries    int
+	ScaleUpdateBackoff    time.Duration
+	MaxCapacityPercent    int32
+	WatchDeployments      bool
+	WatchNodes            bool
+	WaitForMetricsAPI     bool
+	EnableDebugEndpoints  bool
+	ServeRecommendations  bool
+	GRPCBindAddress       string
+	DecisionHistorySize   int
+	PrintConfig           bool
+	LogEvents             bool
+	ObserveFirstReconcile bool
+	ResetBackoffOnChange  bool
+	ProjectedTokenDir     string
+	Selector              string
 	*v1alpha1.GPAControllerConfiguration
 }
 
@@ -51,6 +72,8 @@ func (s *RunOptions) addKubeFlags() {
 	pflag.StringVar(&s.MasterUrl, "master", "", "Master url.")
 	pflag.IntVar(&s.QPS, "qps", 100, "qps of auto scaler.")
 	pflag.IntVar(&s.Burst, "burst", 200, "burst of auto scaler.")
+	pflag.BoolVar(&s.PrintConfig, "print-config", false, "Print the values of all the flags as JSON, the defaults applied, and exit.")
+	pflag.StringVar(&s.DefaultsConfigMap, "defaults-configmap", "", "namespace/name of a ConfigMap whose defaults.yaml defines the default behavior, tolerance and sync period, it is watched for updates.")
 }
 
 func (s *RunOptions) addElectionFlags() {
@@ -73,6 +96,26 @@ func (o *RunOptions) addGPAFlags() {
 	pflag.BoolVar(&o.GeneralPodAutoscalerUseRESTClients, "general-pod-autoscaler-use-rest-clients", o.GeneralPodAutoscalerUseRESTClients, "If set to true, causes the general pod autoscaler controller to use REST clients through the kube-aggregator, instead of using the legacy metrics client through the API server proxy.  This is required for custom metrics support in the general pod autoscaler.")
 	pflag.DurationVar(&o.GeneralPodAutoscalerCPUInitializationPeriod.Duration, "general-pod-autoscaler-cpu-initialization-period", o.GeneralPodAutoscalerCPUInitializationPeriod.Duration, "The period after pod start when CPU samples might be skipped.")
 	pflag.DurationVar(&o.GeneralPodAutoscalerInitialReadinessDelay.Duration, "general-pod-autoscaler-initial-readiness-delay", o.GeneralPodAutoscalerInitialReadinessDelay.Duration, "The period after pod start during which readiness changes will be treated as initial readiness.")
+	pflag.BoolVar(&o.GeneralPodAutoscalerRequeueOnTargetChange, "general-pod-autoscaler-requeue-on-target-change", o.GeneralPodAutoscalerRequeueOnTargetChange, "If set to true, the general pod autoscaler watches Deployments, StatefulSets and ReplicaSets, and reconciles the GPA as soon as its target changed.")
+	pflag.DurationVar(&o.ShutdownTimeout, "shutdown-timeout", 30*time.Second, "How long the controller waits on SIGTERM for the reconciles in progress to write their scales and statuses before it exits, no new reconcile is started meanwhile. 0 to exit without waiting.")
+	pflag.IntVar(&o.ScaleUpdateRetries, "scale-update-retries", 3, "How many times a scale update failing transiently, e.g. on a timeout or throttling, is retried within the reconcile before the decision is dropped and the GPA requeued. 0 to disable.")
+	pflag.DurationVar(&o.ScaleUpdateBackoff, "scale-update-backoff", 200*time.Millisecond, "The wait before the first retry of a scale update failing transiently, doubled on each retry.")
+	pflag.DurationVar(&o.MinScaleInterval, "min-scale-interval", 0, "The minimum interval between two scale writes of a GPA regardless of the resync, the recomputations within the interval are coalesced into one. 0 to disable.")
+	pflag.DurationVar(&o.AdaptiveResyncMin, "adaptive-resync-min", 0, "The resync interval of a GPA once its target is scaled or it is at its max replicas, it is doubled up to --adaptive-resync-max on each reconcile the GPA is stable. Both must be set to adapt the resync intervals, 0 to resync every GPA by the sync period.")
+	pflag.DurationVar(&o.AdaptiveResyncMax, "adaptive-resync-max", 0, "The longest resync interval of a stable GPA, see --adaptive-resync-min.")
+	pflag.BoolVar(&o.WaitForMetricsAPI, "wait-for-metrics-api", false, "If set to true, the resource metrics are only queried once the resource metrics API is reachable, retrying with a backoff. Until then the GPAs scaling on resource metrics are marked ScalingActive=False, while the other GPAs are reconciled.")
+	pflag.BoolVar(&o.EnableDebugEndpoints, "enable-debug-endpoints", false, "If set to true, the last decisions of each GPA are kept in memory and served on /debug/history?gpa=namespace/name.")
+	pflag.BoolVar(&o.ServeRecommendations, "serve-recommendations", false, "If set to true, the last recommendation of each GPA is served as the gpa_desired_replicas external metric on /apis/external.metrics.k8s.io/v1beta1 of the validator port, labeled gpa=<name>, for other controllers to read it through an APIService.")
+	pflag.StringVar(&o.GRPCBindAddress, "grpc-bind-address", "", "The address the last recommendation of each GPA, along with its inputs, is served on by the gRPC service autoscaling.ocgi.io.v1alpha1.Recommendation, separate from the other ports. Empty to disable.")
+	pflag.BoolVar(&o.ObserveFirstReconcile, "observe-first-reconcile", false, "If set to true, the first reconcile of each GPA after the controller started only records the recommendation, and the target is scaled from the next reconcile. A GPA annotated with autoscaling.ocgi.io/act-on-first-reconcile=true is scaled on the first reconcile.")
+	pflag.BoolVar(&o.ResetBackoffOnChange, "reset-backoff-on-change", false, "If set to true, a change of the spec or of the annotations of a GPA, e.g. a fix of its webhook endpoint, resets its wait in the queue, so that it is reconciled at once instead of after the resync period.")
+	pflag.BoolVar(&o.LogEvents, "log-events", false, "If set to true, the events recorded for the GPAs are also logged as key=value pairs with their type, reason, object and message.")
+	pflag.IntVar(&o.DecisionHistorySize, "decision-history-size", 20, "The number of the last decisions kept for each GPA if the debug endpoints are enabled.")
+	pflag.StringVar(&o.ProjectedTokenDir, "projected-token-dir", "", "The directory of the token files projected into the controller, e.g. the workload identity tokens, the probe metrics authenticate with tokenAuth.tokenFile in it. The files are read again once rotated. Empty to disable.")
+	pflag.StringVar(&o.Selector, "selector", "", "A label selector of the GPAs the controller reconciles, e.g. to migrate some of them to another controller. The GPAs not matching it are ignored entirely, including as the sources of the mirror mode. Empty to reconcile all the GPAs.")
+	pflag.BoolVar(&o.WatchDeployments, "watch-deployments", true, "If set to true, the Deployments of the cluster are cached to defer scaling during the rollouts of the targets with freezeOnRollout and to follow their minReadySeconds. If set to false, the Deployments are not watched and both checks are skipped.")
+	pflag.BoolVar(&o.WatchNodes, "watch-nodes", true, "If set to true, the nodes of the cluster are cached to count them and their cores for the GPAs in clusterProportional mode, which are reconciled as soon as a node changes. If set to false, the clusterProportional mode fails unless the nodes are cached for --max-capacity-percent, and it is not reconciled on the changes of the nodes then.")
+	pflag.Int32Var(&o.MaxCapacityPercent, "max-capacity-percent", 0, "The percent of the allocatable resources of the ready nodes the target of a GPA may request, scale ups beyond it are capped. It can be overridden by the autoscaling.ocgi.io/max-capacity-percent annotation of a GPA. 0 to disable.")
 }
 
 func (s *RunOptions) NewConfig() (*rest.Config, error) {
@@ -0,0 +1,348 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package explain
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	autoscalinginternal "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/discovery"
+	cacheddiscovery "k8s.io/client-go/discovery/cached"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	scaleclient "k8s.io/client-go/scale"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	resourceclient "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
+	"k8s.io/metrics/pkg/client/custom_metrics"
+	"k8s.io/metrics/pkg/client/external_metrics"
+
+	"github.com/ocgi/general-pod-autoscaler/cmd/gpa/app"
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	autoscalingclient "github.com/ocgi/general-pod-autoscaler/pkg/client/clientset/versioned"
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/client/clientset/versioned/typed/autoscaling/v1alpha1"
+	autoscalinglisters "github.com/ocgi/general-pod-autoscaler/pkg/client/listers/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/metrics"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scaler"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scalercore"
+)
+
+// Explainer runs the decision pipeline of the controller once for a GPA against the live metrics, and prints
+// how its replicas are computed. Neither the GPA nor its target is updated.
+type Explainer struct {
+	gpaNamespacer   autoscalingv1alpha1.GeneralPodAutoscalersGetter
+	scaleNamespacer scaleclient.ScalesGetter
+	mapper          apimeta.RESTMapper
+	engine          *scaler.DecisionEngine
+	// recorder collects the events the engine would record for the GPA
+	recorder *record.FakeRecorder
+}
+
+// NewExplainer creates an Explainer, the engine must record its events with the recorder.
+func NewExplainer(gpaNamespacer autoscalingv1alpha1.GeneralPodAutoscalersGetter, scaleNamespacer scaleclient.ScalesGetter,
+	mapper apimeta.RESTMapper, engine *scaler.DecisionEngine, recorder *record.FakeRecorder) *Explainer {
+	return &Explainer{
+		gpaNamespacer:   gpaNamespacer,
+		scaleNamespacer: scaleNamespacer,
+		mapper:          mapper,
+		engine:          engine,
+		recorder:        recorder,
+	}
+}
+
+// Run explains the GPA given as namespace/name with the clients of the config, the tolerance, the periods, the
+// defaults and the capacity limit of the controller are taken from the options.
+func Run(options *app.RunOptions, config *rest.Config, key string, out io.Writer) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil || name == "" {
+		return fmt.Errorf("invalid GPA %q, must be namespace/name", key)
+	}
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	client := kubernetes.NewForConfigOrDie(config)
+	gpaClient := autoscalingclient.NewForConfigOrDie(config)
+	restMapper := restmapper.NewDeferredDiscoveryRESTMapper(
+		cacheddiscovery.NewMemCacheClient(discovery.NewDiscoveryClientForConfigOrDie(config)))
+	scaleClient, err := scaleclient.NewForConfig(config, restMapper, dynamic.LegacyAPIPathResolverFunc,
+		scaleclient.NewDiscoveryScaleKindResolver(client.Discovery()))
+	if err != nil {
+		return fmt.Errorf("failed to build scale client: %v", err)
+	}
+	metricsClient := metrics.NewRESTMetricsClient(
+		resourceclient.NewForConfigOrDie(config),
+		custom_metrics.NewForConfig(config, restMapper, custom_metrics.NewAvailableAPIsGetter(gpaClient.Discovery())),
+		external_metrics.NewForConfigOrDie(config),
+	)
+
+	// the pods, the nodes and the GPAs are listed once instead of watched, the decision is only made once
+	podList, err := client.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods of namespace %s: %v", namespace, err)
+	}
+	pods, err := newIndexer(podList)
+	if err != nil {
+		return err
+	}
+	nodeList, err := client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %v", err)
+	}
+	nodes, err := newIndexer(nodeList)
+	if err != nil {
+		return err
+	}
+	gpaList, err := gpaClient.AutoscalingV1alpha1().GeneralPodAutoscalers(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list gpas of namespace %s: %v", namespace, err)
+	}
+	gpas, err := newIndexer(gpaList)
+	if err != nil {
+		return err
+	}
+
+	recorder := record.NewFakeRecorder(100)
+	engine := scaler.NewDecisionEngine(
+		metricsClient,
+		corelisters.NewPodLister(pods),
+		client.CoreV1(),
+		recorder,
+		clock.RealClock{},
+		options.GeneralPodAutoscalerTolerance,
+		options.GeneralPodAutoscalerDownscaleStabilizationWindow.Duration,
+		options.GeneralPodAutoscalerCPUInitializationPeriod.Duration,
+		options.GeneralPodAutoscalerInitialReadinessDelay.Duration,
+	)
+	engine.SetConfigMapNamespacer(client.CoreV1())
+	engine.SetClusterNodeLister(corelisters.NewNodeLister(nodes))
+	engine.SetGPALister(autoscalinglisters.NewGeneralPodAutoscalerLister(gpas))
+	engine.SetMaxCapacityPercent(options.MaxCapacityPercent)
+	if len(options.ProjectedTokenDir) != 0 {
+		engine.SetProjectedTokens(scalercore.NewProjectedTokens(options.ProjectedTokenDir))
+	}
+	if len(options.DefaultsConfigMap) != 0 {
+		defaults, err := getDefaults(client, options.DefaultsConfigMap)
+		if err != nil {
+			return err
+		}
+		engine.SetDefaults(defaults)
+	}
+	return NewExplainer(gpaClient.AutoscalingV1alpha1(), scaleClient, restMapper, engine, recorder).
+		Explain(out, namespace, name)
+}
+
+// newIndexer returns an indexer of the items of the list
+func newIndexer(list runtime.Object) (cache.Indexer, error) {
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, item := range items {
+		if err := indexer.Add(item); err != nil {
+			return nil, err
+		}
+	}
+	return indexer, nil
+}
+
+// getDefaults returns the defaults of the ConfigMap given as namespace/name, in kube-system if the namespace is
+// omitted. Like the controller, nil is returned if the ConfigMap does not exist.
+func getDefaults(client kubernetes.Interface, key string) (*scaler.Defaults, error) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid defaults configmap %v: %v", key, err)
+	}
+	if len(namespace) == 0 {
+		namespace = metav1.NamespaceSystem
+	}
+	cm, err := client.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get defaults configmap %s/%s: %v", namespace, name, err)
+	}
+	return scaler.ParseDefaults(cm)
+}
+
+// Explain prints the target, the metrics, the proposed and the recommended replicas, the conditions and the
+// events of the decision of the GPA. If the decision fails, the error is printed and returned as well.
+func (e *Explainer) Explain(out io.Writer, namespace, name string) error {
+	gpa, err := e.gpaNamespacer.GeneralPodAutoscalers(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get GPA %s/%s: %v", namespace, name, err)
+	}
+	ref := gpa.Spec.ScaleTargetRef
+	targetGV, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return fmt.Errorf("invalid API version in scale target reference: %v", err)
+	}
+	mappings, err := e.mapper.RESTMappings(schema.GroupKind{Group: targetGV.Group, Kind: ref.Kind})
+	if err != nil {
+		return fmt.Errorf("unable to determine resource for scale target reference: %v", err)
+	}
+	var firstErr error
+	for _, mapping := range mappings {
+		scale, err := e.scaleNamespacer.Scales(namespace).Get(mapping.Resource.GroupResource(), ref.Name)
+		if err == nil {
+			return e.explain(out, gpa, scale)
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("unrecognized resource")
+	}
+	return fmt.Errorf("failed to query scale subresource for %s/%s/%s: %v", ref.Kind, namespace, ref.Name, firstErr)
+}
+
+func (e *Explainer) explain(out io.Writer, gpa *autoscaling.GeneralPodAutoscaler, scale *autoscalinginternal.Scale) error {
+	key := gpa.Namespace + "/" + gpa.Name
+	tolerance := e.engine.PrepareDecision(gpa)
+	minReplicas := int32(1)
+	if gpa.Spec.MinReplicas != nil {
+		minReplicas = *gpa.Spec.MinReplicas
+	}
+	fmt.Fprintf(out, "GPA:              %s\n", key)
+	fmt.Fprintf(out, "Target:           %s/%s, %d replicas, selector %q\n",
+		gpa.Spec.ScaleTargetRef.Kind, gpa.Spec.ScaleTargetRef.Name, scale.Spec.Replicas, scale.Status.Selector)
+	fmt.Fprintf(out, "Bounds:           %d to %d replicas\n", minReplicas, gpa.Spec.MaxReplicas)
+	fmt.Fprintf(out, "Tolerance:        %v\n", tolerance)
+
+	recommendation, err := e.engine.RecommendWithTolerance(gpa, key, scale, tolerance)
+	if len(recommendation.MetricStatuses) > 0 {
+		fmt.Fprintf(out, "Metrics:\n")
+		for _, status := range recommendation.MetricStatuses {
+			fmt.Fprintf(out, "  %s\n", describeMetricStatus(status))
+		}
+	}
+	if err == nil {
+		fmt.Fprintf(out, "Proposed by:      %s\n", recommendation.MetricName)
+		fmt.Fprintf(out, "Proposal:         %d replicas\n", recommendation.ProposedReplicas)
+		fmt.Fprintf(out, "Recommendation:   %d replicas\n", recommendation.DesiredReplicas)
+		desiredReplicas := e.engine.LimitDecision(gpa, scale.Status.Selector, scale.Spec.Replicas,
+			recommendation.DesiredReplicas)
+		fmt.Fprintf(out, "Desired:          %d replicas\n", desiredReplicas)
+		if recommendation.Reason != "" {
+			fmt.Fprintf(out, "Reason:           %s\n", recommendation.Reason)
+		}
+	} else {
+		fmt.Fprintf(out, "Error:            %v\n", err)
+	}
+	if len(gpa.Status.Conditions) > 0 {
+		fmt.Fprintf(out, "Conditions:\n")
+		for _, condition := range gpa.Status.Conditions {
+			fmt.Fprintf(out, "  %s=%s %s: %s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+		}
+	}
+	if events := e.drainEvents(); len(events) > 0 {
+		fmt.Fprintf(out, "Events:\n")
+		for _, event := range events {
+			fmt.Fprintf(out, "  %s\n", event)
+		}
+	}
+	fmt.Fprintf(out, "The stabilization and the scaling policies are applied without the past decisions of the controller.\n")
+	if err != nil {
+		return fmt.Errorf("failed to compute desired number of replicas for %s: %v", key, err)
+	}
+	return nil
+}
+
+func (e *Explainer) drainEvents() []string {
+	var events []string
+	for {
+		select {
+		case event := <-e.recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+// describeMetricStatus returns the type, the name and the current values of the metric
+func describeMetricStatus(status autoscaling.MetricStatus) string {
+	var name string
+	var current autoscaling.MetricValueStatus
+	switch {
+	case status.Resource != nil:
+		name, current = string(status.Resource.Name), status.Resource.Current
+	case status.ContainerResource != nil:
+		name = fmt.Sprintf("%s of container %s", status.ContainerResource.Name, status.ContainerResource.Container)
+		current = status.ContainerResource.Current
+	case status.Pods != nil:
+		name, current = status.Pods.Metric.Name, status.Pods.Current
+	case status.Object != nil:
+		name = fmt.Sprintf("%s of %s/%s", status.Object.Metric.Name, status.Object.DescribedObject.Kind,
+			status.Object.DescribedObject.Name)
+		current = status.Object.Current
+	case status.External != nil:
+		name, current = status.External.Metric.Name, status.External.Current
+	case status.Derivative != nil:
+		name, current = status.Derivative.Metric.Name, status.Derivative.Current
+		return fmt.Sprintf("%s %s: %s, projected %s", status.Type, name, describeValue(current),
+			describeValue(status.Derivative.Projected))
+	case status.Probe != nil:
+		name, current = status.Probe.URL, status.Probe.Current
+	case status.KafkaLag != nil:
+		name = fmt.Sprintf("group %s on topic %s", status.KafkaLag.ConsumerGroup, status.KafkaLag.Topic)
+		current = status.KafkaLag.Current
+	case status.CounterDelta != nil:
+		name, current = status.CounterDelta.Metric.Name, status.CounterDelta.Current
+	case status.Ratio != nil:
+		name = fmt.Sprintf("%s of %s", status.Ratio.Numerator.Name, status.Ratio.Denominator.Name)
+		current = status.Ratio.Current
+	case status.Concurrency != nil:
+		name, current = status.Concurrency.Metric.Name, status.Concurrency.Current
+	case status.Scrape != nil:
+		name, current = status.Scrape.MetricName, status.Scrape.Current
+	case status.BucketTable != nil:
+		name, current = status.BucketTable.Metric.Name, status.BucketTable.Current
+	default:
+		return fmt.Sprintf("%s: no current value", status.Type)
+	}
+	return fmt.Sprintf("%s %s: %s", status.Type, name, describeValue(current))
+}
+
+// describeValue returns the values set in the status
+func describeValue(value autoscaling.MetricValueStatus) string {
+	var values []string
+	if value.AverageUtilization != nil {
+		values = append(values, fmt.Sprintf("utilization %d%%", *value.AverageUtilization))
+	}
+	if value.AverageValue != nil {
+		values = append(values, "average value "+value.AverageValue.String())
+	}
+	if value.Value != nil {
+		values = append(values, "value "+value.Value.String())
+	}
+	if len(values) == 0 {
+		return "no current value"
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,188 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package explain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	autoscalinginternal "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	scalefake "k8s.io/client-go/scale/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/client/clientset/versioned/fake"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scaler"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scaler/scalertest"
+)
+
+func newTestExplainer(gpa *autoscaling.GeneralPodAutoscaler, queueLength int64, targetPods ...*v1.Pod) *Explainer {
+	fakeScaleClient := &scalefake.FakeScaleClient{}
+	fakeScaleClient.AddReactor("get", "deployments", func(action core.Action) (bool, runtime.Object, error) {
+		return true, &autoscalinginternal.Scale{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "default"},
+			Spec:       autoscalinginternal.ScaleSpec{Replicas: 3},
+			Status:     autoscalinginternal.ScaleStatus{Replicas: 3, Selector: "app=worker"},
+		}, nil
+	})
+	fakeScaleClient.AddReactor("update", "deployments", func(action core.Action) (bool, runtime.Object, error) {
+		panic("the target must not be scaled")
+	})
+	mapper := apimeta.NewDefaultRESTMapper([]schema.GroupVersion{{Group: "apps", Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, apimeta.RESTScopeNamespace)
+
+	fakeClock := clock.NewFakeClock(metav1.Now().Time)
+	metrics := scalertest.NewFakeMetricsClient(fakeClock)
+	metrics.SetExternalMetric("queue_length", queueLength)
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, pod := range targetPods {
+		pods.Add(pod)
+	}
+	recorder := record.NewFakeRecorder(10)
+	engine := scaler.NewDecisionEngine(metrics, corelisters.NewPodLister(pods), nil, recorder, fakeClock, 0.1, 0, 0, 0)
+	return NewExplainer(fake.NewSimpleClientset(gpa).AutoscalingV1alpha1(), fakeScaleClient, mapper, engine, recorder)
+}
+
+func queueGPA(maxReplicas int32) *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "default"},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "worker"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    maxReplicas,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.ExternalMetricSourceType,
+						External: &autoscaling.ExternalMetricSource{
+							Metric: autoscaling.MetricIdentifier{Name: "queue_length"},
+							Target: autoscaling.MetricTarget{
+								Type:         autoscaling.AverageValueMetricType,
+								AverageValue: resource.NewQuantity(30, resource.DecimalSI),
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func TestExplain(t *testing.T) {
+	var out bytes.Buffer
+	err := newTestExplainer(queueGPA(10), 180000).Explain(&out, "default", "worker")
+	assert.NoError(t, err)
+	output := out.String()
+	assert.Contains(t, output, "GPA:              default/worker\n")
+	assert.Contains(t, output, `Target:           Deployment/worker, 3 replicas, selector "app=worker"`)
+	assert.Contains(t, output, "Bounds:           1 to 10 replicas\n")
+	assert.Contains(t, output, "  External queue_length: average value 60\n")
+	assert.Contains(t, output, "Proposal:         6 replicas\n")
+	assert.Contains(t, output, "Recommendation:   6 replicas\n")
+	assert.Contains(t, output, "Desired:          6 replicas\n")
+	assert.Contains(t, output, "Reason:           external metric queue_length(nil) above target\n")
+	assert.Contains(t, output, "ScalingActive=True ValidMetricFound")
+}
+
+func TestExplainLimitedByMaxReplicas(t *testing.T) {
+	var out bytes.Buffer
+	err := newTestExplainer(queueGPA(4), 180000).Explain(&out, "default", "worker")
+	assert.NoError(t, err)
+	output := out.String()
+	assert.Contains(t, output, "Proposal:         6 replicas\n")
+	assert.Contains(t, output, "Recommendation:   4 replicas\n")
+	assert.Contains(t, output, "ScalingLimited=True TooManyReplicas")
+	assert.Contains(t, output, "Events:\n  Warning FailedRescale DesiredReplicas:6 cannot exceed the MaxReplicas: 4\n")
+}
+
+func TestExplainWithDefaults(t *testing.T) {
+	defaults, err := scaler.ParseDefaults(&v1.ConfigMap{Data: map[string]string{scaler.DefaultsConfigMapKey: `
+tolerance: 0.2
+behavior:
+  scaleUp:
+    stabilizationWindowSeconds: 0
+    selectPolicy: Max
+    policies:
+    - type: Pods
+      value: 1
+      periodSeconds: 60
+  scaleDown:
+    stabilizationWindowSeconds: 300
+    selectPolicy: Disabled
+`}})
+	assert.NoError(t, err)
+
+	// an average of 35 is within the default tolerance of the 30 target
+	var out bytes.Buffer
+	explainer := newTestExplainer(queueGPA(10), 105000)
+	explainer.engine.SetDefaults(defaults)
+	assert.NoError(t, explainer.Explain(&out, "default", "worker"))
+	assert.Contains(t, out.String(), "Tolerance:        0.2\n")
+	assert.Contains(t, out.String(), "Recommendation:   3 replicas\n")
+
+	// the default scale up policy adds a pod at a time
+	out.Reset()
+	explainer = newTestExplainer(queueGPA(10), 180000)
+	explainer.engine.SetDefaults(defaults)
+	assert.NoError(t, explainer.Explain(&out, "default", "worker"))
+	assert.Contains(t, out.String(), "Proposal:         6 replicas\n")
+	assert.Contains(t, out.String(), "Recommendation:   4 replicas\n")
+}
+
+func TestExplainWithOverrides(t *testing.T) {
+	gpa := queueGPA(10)
+	gpa.Annotations = map[string]string{
+		"autoscaling.ocgi.io/override-max":       "5",
+		"autoscaling.ocgi.io/override-tolerance": "0.3",
+	}
+	maxPendingPods := int32(1)
+	gpa.Spec.MaxPendingPods = &maxPendingPods
+	pending := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-0", Namespace: "default", Labels: map[string]string{"app": "worker"}},
+		Status:     v1.PodStatus{Phase: v1.PodPending},
+	}
+
+	var out bytes.Buffer
+	err := newTestExplainer(gpa, 180000, pending).Explain(&out, "default", "worker")
+	assert.NoError(t, err)
+	output := out.String()
+	assert.Contains(t, output, "Bounds:           1 to 5 replicas\n")
+	assert.Contains(t, output, "Tolerance:        0.3\n")
+	assert.Contains(t, output, "Recommendation:   5 replicas\n")
+	// the scale up is held by the pending pod like the controller does
+	assert.Contains(t, output, "Desired:          3 replicas\n")
+	assert.Contains(t, output, "BoundsOverridden=True")
+	assert.Contains(t, output, "TuningOverridden=True")
+	assert.Contains(t, output, "ScalingLimited=True PendingPodsLimited")
+}
+
+func TestExplainMissingGPA(t *testing.T) {
+	var out bytes.Buffer
+	err := newTestExplainer(queueGPA(10), 180000).Explain(&out, "default", "missing")
+	assert.Error(t, err)
+	assert.Empty(t, out.String())
+}
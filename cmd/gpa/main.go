@@ -18,11 +18,14 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apiserver/pkg/server"
 	"k8s.io/client-go/discovery"
@@ -32,20 +35,25 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/scale"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	componentbaseconfig "k8s.io/component-base/config"
 	"k8s.io/klog"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	resourceclient "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
 	"k8s.io/metrics/pkg/client/custom_metrics"
 	"k8s.io/metrics/pkg/client/external_metrics"
 
 	"github.com/ocgi/general-pod-autoscaler/cmd/gpa/app"
+	"github.com/ocgi/general-pod-autoscaler/cmd/gpa/explain"
 	"github.com/ocgi/general-pod-autoscaler/cmd/gpa/validator"
 	autoscalingclient "github.com/ocgi/general-pod-autoscaler/pkg/client/clientset/versioned"
 	autoscalinginformer "github.com/ocgi/general-pod-autoscaler/pkg/client/informers/externalversions"
 	"github.com/ocgi/general-pod-autoscaler/pkg/metrics"
+	"github.com/ocgi/general-pod-autoscaler/pkg/recommender"
 	"github.com/ocgi/general-pod-autoscaler/pkg/scaler"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scalercore"
 	"github.com/ocgi/general-pod-autoscaler/pkg/version"
 )
 
@@ -61,6 +69,10 @@ func main() {
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 	pflag.Parse()
 	defer klog.Flush()
+	if pflag.NArg() > 0 && pflag.Arg(0) == "explain" {
+		explainGPA(runConfig)
+		return
+	}
 	version.Print()
 
 	if options.ShowVersion {
@@ -69,17 +81,21 @@ func main() {
 	}
 	klog.Infof("Version: %s", validator.Version)
 
+	config, err := app.EffectiveConfig(pflag.CommandLine)
+	if err != nil {
+		klog.Fatalf("Failed to resolve config: %v", err)
+	}
+	if runConfig.PrintConfig {
+		fmt.Println(string(config))
+		return
+	}
+	klog.V(2).Infof("Effective config: %s", config)
+
 	klog.Infof("starting validator server.")
 	if err := options.Validate(); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
-	go func() {
-		if err := validator.Run(options); err != nil {
-			fmt.Fprintf(os.Stderr, "%v\n", err)
-			os.Exit(1)
-		}
-	}()
 	leaderElection := defaultLeaderElectionConfiguration()
 	if len(runConfig.ElectionResourceLock) != 0 {
 		leaderElection.ResourceLock = runConfig.ElectionResourceLock
@@ -97,8 +113,12 @@ func main() {
 	gpaClient := autoscalingclient.NewForConfigOrDie(kubeconfig)
 
 	coreFactory := informers.NewSharedInformerFactory(client, runConfig.Resync)
-	scalerFactory := autoscalinginformer.NewSharedInformerFactory(gpaClient, runConfig.Resync)
-
+	selectorOption, err := scaler.GPASelectorOption(runConfig.Selector)
+	if err != nil {
+		klog.Fatalf("Failed to parse selector: %v", err)
+	}
+	scalerFactory := autoscalinginformer.NewSharedInformerFactoryWithOptions(gpaClient, runConfig.Resync, selectorOption)
+	gpaLister := scalerFactory.Autoscaling().V1alpha1().GeneralPodAutoscalers().Lister()
 	cachedClient := cacheddiscovery.NewMemCacheClient(discovery.NewDiscoveryClientForConfigOrDie(kubeconfig))
 	restMapper := restmapper.NewDeferredDiscoveryRESTMapper(cachedClient)
 	go wait.Until(func() {
@@ -107,7 +127,7 @@ func main() {
 	scaleKindResolver := scale.NewDiscoveryScaleKindResolver(client.Discovery())
 	scaleClient, err := scale.NewForConfig(kubeconfig, restMapper, dynamic.LegacyAPIPathResolverFunc, scaleKindResolver)
 	if err != nil {
-		klog.Fatal("Failed to build scale client %v", err)
+		klog.Fatalf("Failed to build scale client %v", err)
 	}
 
 	apiVersionsGetter := custom_metrics.NewAvailableAPIsGetter(gpaClient.Discovery())
@@ -116,8 +136,16 @@ func main() {
 		custom_metrics.NewForConfig(kubeconfig, restMapper, apiVersionsGetter),
 		external_metrics.NewForConfigOrDie(kubeconfig),
 	)
+	if runConfig.WaitForMetricsAPI {
+		// the GPAs scaling on resource metrics are inactive until the resource metrics API is served
+		metricsClient = metrics.NewStartupMetricsClient(metricsClient, func() error {
+			_, err := client.Discovery().ServerResourcesForGroupVersion(metricsapi.SchemeGroupVersion.String())
+			return err
+		}, metrics.StartupBackoff, stop)
+	}
 
 	controller := scaler.NewGeneralController(
+		client.CoreV1(),
 		client.CoreV1(),
 		scaleClient,
 		gpaClient.AutoscalingV1alpha1(),
@@ -131,6 +159,74 @@ func main() {
 		runConfig.GeneralPodAutoscalerCPUInitializationPeriod.Duration,
 		runConfig.GeneralPodAutoscalerInitialReadinessDelay.Duration,
 	)
+	controller.SetMinScaleInterval(runConfig.MinScaleInterval)
+	controller.SetAdaptiveResync(runConfig.AdaptiveResyncMin, runConfig.AdaptiveResyncMax)
+	controller.SetShutdownTimeout(runConfig.ShutdownTimeout)
+	controller.SetScaleUpdateRetry(runConfig.ScaleUpdateRetries, runConfig.ScaleUpdateBackoff)
+	controller.SetEventLogging(runConfig.LogEvents)
+	controller.SetObserveFirstReconcile(runConfig.ObserveFirstReconcile)
+	controller.SetResetBackoffOnChange(runConfig.ResetBackoffOnChange)
+	controller.SetConfigMapNamespacer(client.CoreV1())
+	if len(runConfig.ProjectedTokenDir) != 0 {
+		controller.SetProjectedTokens(scalercore.NewProjectedTokens(runConfig.ProjectedTokenDir))
+	}
+	if runConfig.WatchNodes {
+		controller.AddNodeInformer(coreFactory.Core().V1().Nodes())
+	}
+	if runConfig.WatchDeployments {
+		controller.AddDeploymentInformer(coreFactory.Apps().V1().Deployments())
+	}
+	controller.SetImpersonatingScales(scaler.NewImpersonatingScalesFunc(kubeconfig, restMapper, scaleKindResolver))
+	if runConfig.MaxCapacityPercent > 0 {
+		controller.SetCapacityLimit(coreFactory.Core().V1().Nodes(), runConfig.MaxCapacityPercent)
+	}
+	if runConfig.GeneralPodAutoscalerRequeueOnTargetChange {
+		controller.AddTargetInformer("Deployment", coreFactory.Apps().V1().Deployments().Informer())
+		controller.AddTargetInformer("StatefulSet", coreFactory.Apps().V1().StatefulSets().Informer())
+		controller.AddTargetInformer("ReplicaSet", coreFactory.Apps().V1().ReplicaSets().Informer())
+	}
+	if len(runConfig.DefaultsConfigMap) != 0 {
+		namespace, name, err := cache.SplitMetaNamespaceKey(runConfig.DefaultsConfigMap)
+		if err != nil {
+			klog.Fatalf("Invalid defaults configmap %v: %v", runConfig.DefaultsConfigMap, err)
+		}
+		if len(namespace) == 0 {
+			namespace = metav1.NamespaceSystem
+		}
+		defaultsFactory := informers.NewSharedInformerFactoryWithOptions(client, runConfig.Resync,
+			informers.WithNamespace(namespace),
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+			}))
+		controller.AddDefaultsInformer(defaultsFactory.Core().V1().ConfigMaps().Informer())
+		defaultsFactory.Start(stop)
+	}
+	debugHandlers := map[string]http.Handler{}
+	if runConfig.EnableDebugEndpoints {
+		controller.SetDecisionHistory(runConfig.DecisionHistorySize)
+		debugHandlers["/debug/history"] = controller.HistoryHandler()
+	}
+	if runConfig.ServeRecommendations {
+		controller.SetRecommendationMetrics()
+		debugHandlers[scaler.ExternalMetricsPath] = controller.RecommendationsHandler()
+		debugHandlers[scaler.ExternalMetricsPath+"/"] = controller.RecommendationsHandler()
+	}
+	if runConfig.GRPCBindAddress != "" {
+		controller.SetRecommendationService()
+		if _, err := recommender.Serve(runConfig.GRPCBindAddress, controller, stop); err != nil {
+			klog.Fatalf("Failed to serve recommendations on %v: %v", runConfig.GRPCBindAddress, err)
+		}
+	}
+	getSecret := func(namespace, name string) (*corev1.Secret, error) {
+		return client.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+	}
+	go func() {
+		if err := validator.Run(options, gpaLister, controller.TargetPods, getSecret, debugHandlers); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}()
+
 	coreFactory.Start(stop)
 	scalerFactory.Start(stop)
 	ctx, cancel := context.WithCancel(context.TODO()) // TODO once Run() accepts a context, it should be used here
@@ -144,7 +240,12 @@ func main() {
 		}
 	}()
 
+	// leading is closed once the controller is started, and drained once it finished the reconciles in progress
+	// after it was stopped
+	leading, drained := make(chan struct{}), make(chan struct{})
 	run := func(ctx context.Context) {
+		close(leading)
+		defer close(drained)
 		controller.Run(ctx.Done())
 	}
 
@@ -152,6 +253,7 @@ func main() {
 	if err != nil {
 		klog.Fatalf("Unable to get hostname: %v", err)
 	}
+	controller.SetIdentity(id)
 
 	lock, err := resourcelock.New(
 		leaderElection.ResourceLock,
@@ -179,7 +281,20 @@ func main() {
 				run(ctx)
 			},
 			OnStoppedLeading: func() {
-				klog.Fatalf("lost master")
+				select {
+				case <-stop:
+					// the leadership is given up on shutdown, wait for the scales being written
+					select {
+					case <-leading:
+						<-drained
+					default:
+					}
+					klog.Infof("Shut down")
+					klog.Flush()
+					os.Exit(0)
+				default:
+					klog.Fatalf("lost master")
+				}
 			},
 		},
 	})
@@ -194,3 +309,20 @@ func defaultLeaderElectionConfiguration() componentbaseconfig.LeaderElectionConf
 		ResourceLock:  resourcelock.LeasesResourceLock,
 	}
 }
+
+// explainGPA prints the decision of the GPA given as `explain namespace/name` against the live metrics,
+// without scaling its target, and exits.
+func explainGPA(runConfig *app.RunOptions) {
+	if pflag.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s explain namespace/name [flags]\n", os.Args[0])
+		os.Exit(2)
+	}
+	kubeconfig, err := runConfig.NewConfig()
+	if err != nil {
+		klog.Fatalf("Failed to build config: %v", err)
+	}
+	if err := explain.Run(runConfig, kubeconfig, pflag.Arg(1), os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
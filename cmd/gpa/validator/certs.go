@@ -0,0 +1,150 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/metrics"
+)
+
+// certLoader serves the key pair from the cert and key files, the files are reloaded once they are
+// modified, so that rotated certificates are served without a restart.
+type certLoader struct {
+	certFile string
+	keyFile  string
+
+	lock    sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func newCertLoader(certFile, keyFile string) (*certLoader, error) {
+	l := &certLoader{certFile: certFile, keyFile: keyFile}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// load reads the key pair and records the expiry of the certificate
+func (l *certLoader) load() error {
+	info, err := os.Stat(l.certFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair failed: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("parse certificate failed: %v", err)
+	}
+	cert.Leaf = leaf
+
+	l.lock.Lock()
+	l.cert = &cert
+	l.modTime = info.ModTime()
+	l.lock.Unlock()
+
+	metrics.RecordCertificateExpiry(l.certFile, leaf.NotAfter)
+	klog.Infof("Loaded certificate %v, expires at %v", l.certFile, leaf.NotAfter)
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (l *certLoader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if info, err := os.Stat(l.certFile); err == nil {
+		l.lock.RLock()
+		modified := info.ModTime() != l.modTime
+		l.lock.RUnlock()
+		if modified {
+			if err := l.load(); err != nil {
+				// keep serving the last valid certificate
+				klog.Errorf("Reload certificate failed: %v", err)
+			}
+		}
+	}
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.cert, nil
+}
+
+// certSelector serves the key pair whose certificate matches the server name sent by SNI, so that the validator
+// can be served behind several DNS names with their own certificates. The first key pair is served if none
+// matches, e.g. the client sent no server name.
+type certSelector struct {
+	loaders []*certLoader
+}
+
+// newCertSelector loads the key pairs of the cert and key files given in pairs, and of the files <name>.crt and
+// <name>.key in the directory if it is set. The files added to the directory later are not loaded.
+func newCertSelector(certFiles, keyFiles []string, dir string) (*certSelector, error) {
+	if len(certFiles) != len(keyFiles) {
+		return nil, fmt.Errorf("got %d certificate files but %d key files", len(certFiles), len(keyFiles))
+	}
+	certFiles = append([]string(nil), certFiles...)
+	keyFiles = append([]string(nil), keyFiles...)
+	if dir != "" {
+		dirCerts, err := filepath.Glob(filepath.Join(dir, "*.crt"))
+		if err != nil {
+			return nil, err
+		}
+		for _, certFile := range dirCerts {
+			certFiles = append(certFiles, certFile)
+			keyFiles = append(keyFiles, strings.TrimSuffix(certFile, ".crt")+".key")
+		}
+	}
+	if len(certFiles) == 0 {
+		return nil, fmt.Errorf("no certificates were found")
+	}
+	s := &certSelector{}
+	for i := range certFiles {
+		loader, err := newCertLoader(certFiles[i], keyFiles[i])
+		if err != nil {
+			return nil, fmt.Errorf("load certificate %s failed: %v", certFiles[i], err)
+		}
+		s.loaders = append(s.loaders, loader)
+	}
+	return s, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (s *certSelector) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	var first *tls.Certificate
+	for _, loader := range s.loaders {
+		cert, err := loader.GetCertificate(hello)
+		if err != nil {
+			return nil, err
+		}
+		if first == nil {
+			first = cert
+		}
+		if hello != nil && hello.ServerName != "" && cert.Leaf.VerifyHostname(hello.ServerName) == nil {
+			return cert, nil
+		}
+	}
+	return first, nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/metrics"
+)
+
+const certExpiryMetric = "general_pod_autoscaler_validator_certificate_expiry_timestamp_seconds"
+
+func TestCertificateExpiryMetric(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validator-certs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	notAfter := time.Now().Add(time.Hour).Truncate(time.Second)
+	writeTestCert(t, certFile, keyFile, notAfter)
+	loader, err := newCertLoader(certFile, keyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(notAfter.Unix()), scrapeCertExpiry(t, certFile))
+
+	// a rotated certificate is reloaded on the next handshake
+	rotated := notAfter.Add(24 * time.Hour)
+	writeTestCert(t, certFile, keyFile, rotated)
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(certFile, future, future))
+	cert, err := loader.GetCertificate(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, rotated.UTC(), cert.Leaf.NotAfter)
+	assert.Equal(t, float64(rotated.Unix()), scrapeCertExpiry(t, certFile))
+}
+
+func TestCertificateSelectedBySNI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validator-certs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	notAfter := time.Now().Add(time.Hour)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeTestCert(t, certFile, keyFile, notAfter, "gpa-validator.kube-system.svc")
+	certDir := filepath.Join(dir, "certs")
+	assert.NoError(t, os.Mkdir(certDir, 0700))
+	writeTestCert(t, filepath.Join(certDir, "public.crt"), filepath.Join(certDir, "public.key"), notAfter,
+		"gpa.example.com")
+	writeTestCert(t, filepath.Join(certDir, "internal.crt"), filepath.Join(certDir, "internal.key"), notAfter,
+		"*.internal.example.com")
+
+	selector, err := newCertSelector([]string{certFile}, []string{keyFile}, certDir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for serverName, expected := range map[string]string{
+		"gpa-validator.kube-system.svc": "gpa-validator.kube-system.svc",
+		"gpa.example.com":               "gpa.example.com",
+		"gpa.internal.example.com":      "*.internal.example.com",
+		// the first certificate is served without a match
+		"other.example.com": "gpa-validator.kube-system.svc",
+		"":                  "gpa-validator.kube-system.svc",
+	} {
+		assert.Equal(t, []string{expected}, handshake(t, selector, serverName).DNSNames, "server name %q", serverName)
+	}
+
+	_, err = newCertSelector([]string{certFile}, nil, "")
+	assert.Error(t, err)
+}
+
+// handshake returns the certificate the selector serves to a client sending the server name by SNI
+func handshake(t *testing.T, selector *certSelector, serverName string) *x509.Certificate {
+	serverConn, clientConn := net.Pipe()
+	go func() {
+		server := tls.Server(serverConn, &tls.Config{GetCertificate: selector.GetCertificate})
+		defer server.Close()
+		if server.Handshake() == nil {
+			// read until the client closes
+			io.Copy(ioutil.Discard, server)
+		}
+	}()
+	client := tls.Client(clientConn, &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+	defer client.Close()
+	if err := client.Handshake(); err != nil {
+		t.Fatalf("handshake with server name %q failed: %v", serverName, err)
+	}
+	return client.ConnectionState().PeerCertificates[0]
+}
+
+func scrapeCertExpiry(t *testing.T, certFile string) float64 {
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	families, err := (&expfmt.TextParser{}).TextToMetricFamilies(recorder.Body)
+	if err != nil {
+		t.Fatalf("parse metrics failed: %v", err)
+	}
+	family, ok := families[certExpiryMetric]
+	if !ok {
+		t.Fatalf("metric %v not found", certExpiryMetric)
+	}
+	for _, metric := range family.GetMetric() {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == "certificate" && label.GetValue() == certFile {
+				return metric.GetGauge().GetValue()
+			}
+		}
+	}
+	t.Fatalf("metric %v of certificate %v not found", certExpiryMetric, certFile)
+	return 0
+}
+
+func writeTestCert(t *testing.T, certFile, keyFile string, notAfter time.Time, dnsNames ...string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gpa-validator"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     notAfter,
+		DNSNames:     dnsNames,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+}
@@ -17,25 +17,48 @@ package validator
 import (
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/spf13/pflag"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/validator"
 )
 
 var (
 	Version = "unknown"
 )
 
+const defaultDocsBaseURL = "https://github.com/ocgi/general-pod-autoscaler/blob/master/docs/validation-reasons.md"
+
 type ServerRunOptions struct {
-	Address              string
-	Port                 int
-	TlsCA                string
-	TlsCert              string
-	TlsKey               string
-	IgnoreLabelKeys      string
-	ShowVersion          bool
-	SrcResourceName      string
-	DstResourceName      string
-	AllowDescheduleCount int
+	Address                  string
+	Port                     int
+	TlsCA                    string
+	TlsCert                  []string
+	TlsKey                   []string
+	TlsCertDir               string
+	IgnoreLabelKeys          string
+	ShowVersion              bool
+	SrcResourceName          string
+	DstResourceName          string
+	AllowDescheduleCount     int
+	DocsBaseURL              string
+	RejectSharedTargets      bool
+	AuditWebhookURL          string
+	MetricsBindAddress       string
+	MissingRequestsPolicy    string
+	OnInternalError          string
+	MaxRequestBytes          int64
+	GzipResponses            bool
+	ReadTimeout              time.Duration
+	ReadHeaderTimeout        time.Duration
+	WriteTimeout             time.Duration
+	IdleTimeout              time.Duration
+	MaxReplicasCeiling       int32
+	MaxReplicasCeilingPolicy string
+	ValidateSecretReferences bool
+	MaxReplicasDropPolicy    string
+	MaxReplicasDropRatio     float64
 }
 
 func NewServerRunOptions() *ServerRunOptions {
@@ -47,10 +70,56 @@ func NewServerRunOptions() *ServerRunOptions {
 func (s *ServerRunOptions) addFlags() {
 	pflag.StringVar(&s.Address, "address", "0.0.0.0", "The address of scheduler manager.")
 	pflag.IntVar(&s.Port, "port", 8080, "The port of scheduler manager.")
-	pflag.StringVar(&s.TlsCert, "tlscert", "", "Path to TLS certificate file")
-	pflag.StringVar(&s.TlsKey, "tlskey", "", "Path to TLS key file")
+	pflag.StringSliceVar(&s.TlsCert, "tlscert", nil, "Path to TLS certificate file, repeat it with --tlskey for "+
+		"the certificates of several server names, the certificate is selected by SNI.")
+	pflag.StringSliceVar(&s.TlsKey, "tlskey", nil, "Path to TLS key file, one for each --tlscert in the same order.")
+	pflag.StringVar(&s.TlsCertDir, "tls-cert-dir", "", "Directory of the TLS certificate files <name>.crt and their "+
+		"key files <name>.key, served along with --tlscert by SNI.")
 	pflag.StringVar(&s.TlsCA, "CA", "", "Path to certificate file")
 	pflag.BoolVar(&s.ShowVersion, "version", false, "Show version.")
+	pflag.StringVar(&s.DocsBaseURL, "docs-base-url", defaultDocsBaseURL,
+		"Base url of the documentation of the denial reasons, the reason is appended as the anchor. Empty to omit the link.")
+	pflag.BoolVar(&s.RejectSharedTargets, "reject-shared-targets", true,
+		"Reject the GPAs scaling a target which is already scaled by another GPA, unless the GPA is annotated with "+
+			"autoscaling.ocgi.io/allow-shared-target=true.")
+	pflag.StringVar(&s.AuditWebhookURL, "audit-webhook-url", "",
+		"Url the admission decisions are posted to as JSON records in the background, failures of it never fail the admissions. Empty to disable.")
+	pflag.StringVar(&s.MetricsBindAddress, "metrics-bind-address", ":8081",
+		"The address the metrics of the controller and the validator are served on, separate from the admission port. 0 to disable.")
+	pflag.StringVar(&s.MissingRequestsPolicy, "missing-requests-policy", string(webhook.IgnoreMissingRequests),
+		"What to do with the GPAs whose utilization targets lack the resource requests in the pods of their targets: "+
+			"Ignore, Warn to annotate them with autoscaling.ocgi.io/missing-requests, or Deny.")
+	pflag.StringVar(&s.OnInternalError, "on-internal-error", string(webhook.DenyOnInternalError),
+		"Whether the requests the validator fails to handle, e.g. the objects can not be decoded, are allowed to "+
+			"fail open or denied to fail closed: allow or deny. The validation of the GPAs is not affected.")
+	pflag.Int64Var(&s.MaxRequestBytes, "max-request-bytes", webhook.DefaultMaxRequestBytes,
+		"The limit of the body of the admission reviews after they are decompressed by their Content-Encoding, "+
+			"larger bodies are rejected.")
+	pflag.BoolVar(&s.GzipResponses, "gzip-responses", false,
+		"Gzip the admission responses to the clients sending Accept-Encoding: gzip.")
+	pflag.DurationVar(&s.ReadTimeout, "read-timeout", 60*time.Second,
+		"The timeout of reading an admission request, including its body. 0 for no timeout.")
+	pflag.DurationVar(&s.ReadHeaderTimeout, "read-header-timeout", 10*time.Second,
+		"The timeout of reading the headers of an admission request, so that the slow clients can not hold the "+
+			"connections open. 0 for the read timeout.")
+	pflag.DurationVar(&s.WriteTimeout, "write-timeout", 60*time.Second,
+		"The timeout of writing an admission response, from the end of reading the headers. 0 for no timeout.")
+	pflag.DurationVar(&s.IdleTimeout, "idle-timeout", 120*time.Second,
+		"How long an idle keep-alive connection is kept open for the next request. 0 for the read timeout.")
+	pflag.Int32Var(&s.MaxReplicasCeiling, "max-replicas-ceiling", 0,
+		"The ceiling no maxReplicas of the GPAs may exceed, enforced on the GPAs created or whose spec is changed "+
+			"by --max-replicas-ceiling-policy. 0 to disable.")
+	pflag.StringVar(&s.MaxReplicasCeilingPolicy, "max-replicas-ceiling-policy", string(webhook.RejectAboveCeiling),
+		"What to do with the GPAs whose maxReplicas exceeds --max-replicas-ceiling: Reject, or Clamp to lower it to the ceiling.")
+	pflag.BoolVar(&s.ValidateSecretReferences, "validate-secret-references", false,
+		"Reject the GPAs referencing secrets, or keys of them, which do not exist when the GPAs are created or their "+
+			"spec is changed. Leave it disabled if the secrets may be created after the GPAs.")
+	pflag.StringVar(&s.MaxReplicasDropPolicy, "max-replicas-drop-policy", string(webhook.IgnoreMaxReplicasDrop),
+		"What to do with the GPAs whose maxReplicas is below --max-replicas-drop-ratio of the running pods of their "+
+			"targets: Ignore, Warn to annotate them with autoscaling.ocgi.io/max-replicas-drop, or Deny.")
+	pflag.Float64Var(&s.MaxReplicasDropRatio, "max-replicas-drop-ratio", 0.5,
+		"The ratio of the running pods of the target the maxReplicas of a GPA may not be below, checked by "+
+			"--max-replicas-drop-policy. 0 to disable.")
 }
 
 func (s *ServerRunOptions) Validate() error {
@@ -58,5 +127,43 @@ func (s *ServerRunOptions) Validate() error {
 	if address.To4() == nil {
 		return fmt.Errorf("%v is not a valid IP address\n", s.Address)
 	}
+	if len(s.TlsCert) != len(s.TlsKey) {
+		return fmt.Errorf("got %d --tlscert but %d --tlskey, they must be given in pairs", len(s.TlsCert), len(s.TlsKey))
+	}
+	switch webhook.MissingRequestsPolicy(s.MissingRequestsPolicy) {
+	case webhook.IgnoreMissingRequests, webhook.WarnMissingRequests, webhook.DenyMissingRequests:
+	default:
+		return fmt.Errorf("unknown missing requests policy %q, must be Ignore, Warn or Deny", s.MissingRequestsPolicy)
+	}
+	for name, timeout := range map[string]time.Duration{"--read-timeout": s.ReadTimeout,
+		"--read-header-timeout": s.ReadHeaderTimeout, "--write-timeout": s.WriteTimeout, "--idle-timeout": s.IdleTimeout} {
+		if timeout < 0 {
+			return fmt.Errorf("%s must be greater than or equal to 0, got %v", name, timeout)
+		}
+	}
+	if s.MaxReplicasCeiling < 0 {
+		return fmt.Errorf("--max-replicas-ceiling must be greater than or equal to 0, got %d", s.MaxReplicasCeiling)
+	}
+	switch webhook.MaxReplicasCeilingPolicy(s.MaxReplicasCeilingPolicy) {
+	case webhook.RejectAboveCeiling, webhook.ClampToCeiling:
+	default:
+		return fmt.Errorf("unknown max replicas ceiling policy %q, must be Reject or Clamp", s.MaxReplicasCeilingPolicy)
+	}
+	if s.MaxRequestBytes <= 0 {
+		return fmt.Errorf("--max-request-bytes must be greater than 0, got %d", s.MaxRequestBytes)
+	}
+	switch webhook.MaxReplicasDropPolicy(s.MaxReplicasDropPolicy) {
+	case webhook.IgnoreMaxReplicasDrop, webhook.WarnMaxReplicasDrop, webhook.DenyMaxReplicasDrop:
+	default:
+		return fmt.Errorf("unknown max replicas drop policy %q, must be Ignore, Warn or Deny", s.MaxReplicasDropPolicy)
+	}
+	if s.MaxReplicasDropRatio < 0 || s.MaxReplicasDropRatio > 1 {
+		return fmt.Errorf("--max-replicas-drop-ratio must be between 0 and 1, got %v", s.MaxReplicasDropRatio)
+	}
+	switch webhook.InternalErrorPolicy(s.OnInternalError) {
+	case webhook.AllowOnInternalError, webhook.DenyOnInternalError:
+	default:
+		return fmt.Errorf("unknown internal error policy %q, must be allow or deny", s.OnInternalError)
+	}
 	return nil
 }
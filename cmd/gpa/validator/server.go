@@ -28,42 +28,59 @@ import (
 
 	"k8s.io/klog"
 
+	listers "github.com/ocgi/general-pod-autoscaler/pkg/client/listers/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/metrics"
 	"github.com/ocgi/general-pod-autoscaler/pkg/util"
 	"github.com/ocgi/general-pod-autoscaler/pkg/validator"
 )
 
-func Run(s *ServerRunOptions) error {
+// Run runs the validator server, the existing GPAs are listed by gpaLister to reject the GPAs scaling
+// an already scaled target if it is enabled by the options, and the pods of the targets are listed by
+// targetPods to check their requests by the missing requests policy and their number by the max replicas drop
+// policy. The secrets referenced by the GPAs are got by secrets if they are checked by the options. The
+// debugHandlers are served by their paths besides the pprof endpoints.
+func Run(s *ServerRunOptions, gpaLister listers.GeneralPodAutoscalerLister, targetPods webhook.TargetPodsLister,
+	secrets webhook.SecretGetter, debugHandlers map[string]http.Handler) error {
 	stopCh := util.SetupSignalHandler()
 
-	webHook := webhook.NewWebhookServer()
-
-	// Start debug monitor.
-	mux := http.NewServeMux()
-	mux.HandleFunc("/mutate", webHook.Serve)
-	mux.HandleFunc("/debug/pprof/", pprof.Index)
-	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
-	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
-	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintf(w, "%s", "ok")
-	})
+	if !s.RejectSharedTargets {
+		gpaLister = nil
+	}
+	webHook := webhook.NewWebhookServer(s.DocsBaseURL, gpaLister)
+	if s.AuditWebhookURL != "" {
+		webHook.SetAuditWebhook(s.AuditWebhookURL, stopCh)
+	}
+	webHook.SetMissingRequestsPolicy(webhook.MissingRequestsPolicy(s.MissingRequestsPolicy), targetPods)
+	webHook.SetMaxReplicasDropPolicy(webhook.MaxReplicasDropPolicy(s.MaxReplicasDropPolicy), s.MaxReplicasDropRatio, targetPods)
+	webHook.SetInternalErrorPolicy(webhook.InternalErrorPolicy(s.OnInternalError))
+	webHook.SetCompression(s.MaxRequestBytes, s.GzipResponses)
+	webHook.SetMaxReplicasCeiling(s.MaxReplicasCeiling, webhook.MaxReplicasCeilingPolicy(s.MaxReplicasCeilingPolicy))
+	if s.ValidateSecretReferences {
+		webHook.SetSecretValidation(secrets)
+	}
 
-	server := &http.Server{
-		Addr:         net.JoinHostPort(s.Address, strconv.Itoa(s.Port)),
-		Handler:      mux,
-		ReadTimeout:  300 * time.Second,
-		WriteTimeout: 300 * time.Second,
+	if _, err := metrics.Serve(s.MetricsBindAddress, stopCh); err != nil {
+		return fmt.Errorf("failed to serve metrics on %v: %v", s.MetricsBindAddress, err)
 	}
 
+	server := newServer(s, newServeMux(webHook.Serve, debugHandlers))
+
 	klog.V(1).Infof("listening on %v", server.Addr)
-	if s.TlsCert != "" && s.TlsKey != "" {
+	if len(s.TlsCert) > 0 || s.TlsCertDir != "" {
 		klog.V(1).Infof("using HTTPS service")
 		tlsConfig, err := getTLSConfig(s)
 		if err != nil {
 			return err
 		}
+		selector, err := newCertSelector(s.TlsCert, s.TlsKey, s.TlsCertDir)
+		if err != nil {
+			return err
+		}
+		tlsConfig.GetCertificate = selector.GetCertificate
 		server.TLSConfig = tlsConfig
 		go func() {
-			klog.Fatal(server.ListenAndServeTLS(s.TlsCert, s.TlsKey))
+			// the certificates are served by the selector
+			klog.Fatal(server.ListenAndServeTLS("", ""))
 		}()
 	} else {
 		go func() {
@@ -84,6 +101,35 @@ func Run(s *ServerRunOptions) error {
 	return nil
 }
 
+// newServer returns the server of the admission port with the address and the timeouts of the options
+func newServer(s *ServerRunOptions, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              net.JoinHostPort(s.Address, strconv.Itoa(s.Port)),
+		Handler:           handler,
+		ReadTimeout:       s.ReadTimeout,
+		ReadHeaderTimeout: s.ReadHeaderTimeout,
+		WriteTimeout:      s.WriteTimeout,
+		IdleTimeout:       s.IdleTimeout,
+	}
+}
+
+// newServeMux returns the mux of the admission port, the metrics are served on their own port
+func newServeMux(mutate http.HandlerFunc, debugHandlers map[string]http.Handler) *http.ServeMux {
+	// Start debug monitor.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mutate", mutate)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	for path, handler := range debugHandlers {
+		mux.Handle(path, handler)
+	}
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s", "ok")
+	})
+	return mux
+}
+
 func getTLSConfig(s *ServerRunOptions) (*tls.Config, error) {
 	tlsConfig := &tls.Config{
 		NextProtos: []string{"http/1.1"},
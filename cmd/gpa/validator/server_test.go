@@ -0,0 +1,74 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/metrics"
+)
+
+func TestMetricsServedOnSeparatePort(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	addr, err := metrics.Serve("127.0.0.1:0", stopCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.Get("http://" + addr.String() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	main := httptest.NewServer(newServeMux(func(w http.ResponseWriter, r *http.Request) {}, nil))
+	defer main.Close()
+	res, err = http.Get(main.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	// 0 disables the metrics server
+	addr, err = metrics.Serve(metrics.DisabledBindAddress, stopCh)
+	assert.NoError(t, err)
+	assert.Nil(t, addr)
+}
+
+func TestServerTimeoutsFromFlags(t *testing.T) {
+	options := NewServerRunOptions()
+	require.NoError(t, pflag.CommandLine.Parse([]string{"--port=8443", "--read-timeout=20s", "--write-timeout=30s"}))
+	require.NoError(t, options.Validate())
+
+	server := newServer(options, http.NotFoundHandler())
+	assert.Equal(t, "0.0.0.0:8443", server.Addr)
+	assert.Equal(t, 20*time.Second, server.ReadTimeout)
+	assert.Equal(t, 30*time.Second, server.WriteTimeout)
+	// the defaults
+	assert.Equal(t, 10*time.Second, server.ReadHeaderTimeout)
+	assert.Equal(t, 120*time.Second, server.IdleTimeout)
+
+	options.IdleTimeout = -time.Second
+	assert.Error(t, options.Validate())
+}
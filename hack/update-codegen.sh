@@ -35,3 +35,6 @@ bash ${CODEGEN_PKG}/generate-groups.sh "deepcopy" \
   github.com/ocgi/general-pod-autoscaler/pkg/apis github.com/ocgi/general-pod-autoscaler/pkg/apis \
   "config:v1alpha1" \
   --go-header-file ${SCRIPT_ROOT}/hack/boilerplate.go.txt
+
+# the stubs of the recommendation service are generated by protoc-gen-go v1.3.2, the newer ones need grpc v1.27
+(cd ${SCRIPT_ROOT}; protoc --go_out=plugins=grpc,paths=source_relative:. pkg/recommender/recommendation.proto)
@@ -23,6 +23,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // +genclient
@@ -76,6 +77,135 @@ type GeneralPodAutoscalerSpec struct {
 	// If not set, the default GPAScalingRules for scale up and scale down are used.
 	// +optional
 	Behavior *GeneralPodAutoscalerBehavior `json:"behavior,omitempty" protobuf:"bytes,4,opt,name=behavior"`
+
+	// freezeOnRollout defers scaling while the target Deployment is rolling out,
+	// scaling is resumed once the rollout has completed.
+	// +optional
+	FreezeOnRollout bool `json:"freezeOnRollout,omitempty" protobuf:"varint,5,opt,name=freezeOnRollout"`
+
+	// readinessGapBuffer adds buffer replicas while the ready pods of the target stay fewer
+	// than the desired replicas, e.g. some pods are unschedulable.
+	// +optional
+	ReadinessGapBuffer *ReadinessGapBuffer `json:"readinessGapBuffer,omitempty" protobuf:"bytes,6,opt,name=readinessGapBuffer"`
+
+	// recoverFromZero scales the target up from zero replicas when minReplicas is greater than zero,
+	// e.g. the target is scaled to zero manually. If not set, scaling is disabled while the target is at zero.
+	// +optional
+	RecoverFromZero *RecoverFromZero `json:"recoverFromZero,omitempty" protobuf:"bytes,7,opt,name=recoverFromZero"`
+
+	// onTargetMissing is the policy when the scale target does not exist, one of Ignore, Error and DeleteSelf.
+	// If not set, Error is used.
+	// +optional
+	OnTargetMissing *TargetMissingPolicy `json:"onTargetMissing,omitempty" protobuf:"bytes,8,opt,name=onTargetMissing"`
+
+	// conflictPolicy resolves the replicas of the metric mode and of the time range active now when both modes
+	// are set and they differ, one of CronWins, MetricWins, Max and Min. If not set, the time mode is ignored
+	// while the metric mode is set.
+	// +optional
+	ConflictPolicy ConflictPolicy `json:"conflictPolicy,omitempty" protobuf:"bytes,9,opt,name=conflictPolicy"`
+
+	// warmupSeconds is the number of seconds after the creation of the GeneralPodAutoscaler during which
+	// the replicas are recommended, but the target is not scaled by the recommendations, so that it does
+	// not act on incomplete data. The min and max replicas are still enforced. If not set, there is no warmup.
+	// +optional
+	WarmupSeconds *int32 `json:"warmupSeconds,omitempty" protobuf:"varint,10,opt,name=warmupSeconds"`
+
+	// maxPendingPods holds the scale-ups of the target while at least this many of its pods are pending, e.g.
+	// when the cluster is out of capacity and more replicas would only be pending too. The number of the
+	// pending pods is reported in status.pendingReplicas. If not set, the pending pods are not counted.
+	// +optional
+	MaxPendingPods *int32 `json:"maxPendingPods,omitempty" protobuf:"varint,11,opt,name=maxPendingPods"`
+
+	// minReadySeconds defers the decisions until all the ready pods of the target have been ready for this
+	// many seconds, so that the replicas added by the last scale are settled before the next decision counts
+	// them. If the target is a Deployment, the larger of it and the minReadySeconds of the Deployment is used.
+	// If not set, the ready times of the pods are not checked.
+	// +optional
+	MinReadySeconds *int32 `json:"minReadySeconds,omitempty" protobuf:"varint,12,opt,name=minReadySeconds"`
+
+	// impersonateServiceAccount is the name of a service account in the namespace of the GeneralPodAutoscaler
+	// the controller impersonates when it writes the scale of the target, so that the writes are authorized by
+	// the RBAC of the service account and attributed to it in the audit logs. The controller must be allowed to
+	// impersonate the service account. If not set, the target is scaled as the controller.
+	// +optional
+	ImpersonateServiceAccount string `json:"impersonateServiceAccount,omitempty" protobuf:"bytes,13,opt,name=impersonateServiceAccount"`
+
+	// scaleHistoryLimit is the number of the last scales of the target kept in status.scaleHistory, it must be
+	// greater than or equal to 0 and less than or equal to 100.
+	// If not set, no history is kept.
+	// +optional
+	ScaleHistoryLimit *int32 `json:"scaleHistoryLimit,omitempty" protobuf:"varint,14,opt,name=scaleHistoryLimit"`
+
+	// podAgeBuffer adds buffer replicas while the oldest pod of the target is older than a max age, so that the
+	// old pods can be recycled, e.g. deleted one by one, without falling short of the desired replicas.
+	// +optional
+	PodAgeBuffer *PodAgeBuffer `json:"podAgeBuffer,omitempty" protobuf:"bytes,15,opt,name=podAgeBuffer"`
+}
+
+// ConflictPolicy is the policy resolving the replicas of the metric mode and the time mode.
+type ConflictPolicy string
+
+const (
+	// CronWinsConflictPolicy uses the replicas of the active time range.
+	CronWinsConflictPolicy ConflictPolicy = "CronWins"
+	// MetricWinsConflictPolicy uses the replicas of the metrics.
+	MetricWinsConflictPolicy ConflictPolicy = "MetricWins"
+	// MaxConflictPolicy uses the larger replicas.
+	MaxConflictPolicy ConflictPolicy = "Max"
+	// MinConflictPolicy uses the smaller replicas.
+	MinConflictPolicy ConflictPolicy = "Min"
+)
+
+// ConflictWinner is the mode whose replicas are used on a conflict.
+type ConflictWinner string
+
+const (
+	// CronConflictWinner means the replicas of the active time range are used.
+	CronConflictWinner ConflictWinner = "Cron"
+	// MetricConflictWinner means the replicas of the metrics are used.
+	MetricConflictWinner ConflictWinner = "Metric"
+)
+
+// TargetMissingPolicy is the policy when the scale target of the GPA does not exist.
+type TargetMissingPolicy string
+
+const (
+	// IgnoreTargetMissing skips the GPA quietly until the target is created.
+	IgnoreTargetMissing TargetMissingPolicy = "Ignore"
+	// ErrorTargetMissing sets the AbleToScale condition to false with the reason TargetMissing.
+	ErrorTargetMissing TargetMissingPolicy = "Error"
+	// DeleteSelfTargetMissing deletes the GPA.
+	DeleteSelfTargetMissing TargetMissingPolicy = "DeleteSelf"
+)
+
+// RecoverFromZero configures the replicas the target is scaled to from zero.
+type RecoverFromZero struct {
+	// bootstrapReplicas is the number of replicas the target is scaled to from zero.
+	// It must be within minReplicas and maxReplicas. If not set, minReplicas is used.
+	// +optional
+	BootstrapReplicas *int32 `json:"bootstrapReplicas,omitempty" protobuf:"varint,1,opt,name=bootstrapReplicas"`
+}
+
+// ReadinessGapBuffer configures the buffer replicas added when the ready pods fall behind the desired replicas.
+type ReadinessGapBuffer struct {
+	// replicas is the number of buffer replicas added to the desired replicas while the gap persists.
+	// It must be greater than zero.
+	Replicas int32 `json:"replicas" protobuf:"varint,1,opt,name=replicas"`
+	// gapSeconds is the number of seconds the ready pods must stay fewer than the desired replicas
+	// before the buffer is added. The buffer is removed once the ready pods reach the desired replicas.
+	// If not set, the default value 60 is used.
+	// +optional
+	GapSeconds *int32 `json:"gapSeconds,omitempty" protobuf:"varint,2,opt,name=gapSeconds"`
+}
+
+// PodAgeBuffer configures the buffer replicas added when the oldest pod of the target is older than a max age.
+type PodAgeBuffer struct {
+	// maxAgeSeconds is the age in seconds beyond which the pods are due to be recycled.
+	// It must be greater than zero.
+	MaxAgeSeconds int32 `json:"maxAgeSeconds" protobuf:"varint,1,opt,name=maxAgeSeconds"`
+	// replicas is the number of buffer replicas added to the desired replicas while any pod is older than
+	// maxAgeSeconds. It must be greater than zero.
+	Replicas int32 `json:"replicas" protobuf:"varint,2,opt,name=replicas"`
 }
 
 // ExternalAutoScalingDrivenMode defines the mode to trigger auto scaling
@@ -95,6 +225,73 @@ type AutoScalingDrivenMode struct {
 	// EventMode is the event driven mode
 	// +optional
 	EventMode *EventMode `json:"event,omitempty" protobuf:"bytes,4,opt,name=event"`
+
+	// ClusterProportionalMode scales the target in proportion to the size of the cluster.
+	// +optional
+	ClusterProportionalMode *ClusterProportionalMode `json:"clusterProportional,omitempty" protobuf:"bytes,5,opt,name=clusterProportional"`
+
+	// MirrorMode scales the target along with the desired replicas of another GPA.
+	// +optional
+	MirrorMode *MirrorMode `json:"mirror,omitempty" protobuf:"bytes,6,opt,name=mirror"`
+}
+
+// MirrorMode scales the target along with another GPA in the same namespace, e.g. for the paired services which
+// should scale together. The replicas are ceil(factor * status.desiredReplicas of the GPA) + offset. If the GPA
+// does not exist, the current replicas are kept.
+type MirrorMode struct {
+	// name is the name of the mirrored GPA in the namespace of this GPA.
+	Name string `json:"name" protobuf:"bytes,1,name=name"`
+	// factor multiplies the desired replicas of the mirrored GPA.
+	// If not set, it is 1.
+	// +optional
+	Factor *float64 `json:"factor,omitempty" protobuf:"fixed64,2,opt,name=factor"`
+	// offset is added to the replicas after the factor, it may be negative.
+	// +optional
+	Offset int32 `json:"offset,omitempty" protobuf:"varint,3,opt,name=offset"`
+}
+
+// ClusterProportionalMode scales the target in proportion to the size of the cluster, like the
+// cluster-proportional-autoscaler does for the system addons, e.g. the DNS. The replicas are the larger of the
+// replicas by the allocatable cores and by the number of the schedulable nodes, computed either linearly by
+// coresPerReplica and nodesPerReplica, or by the steps of the ladder.
+type ClusterProportionalMode struct {
+	// coresPerReplica is the number of the allocatable cores of the schedulable nodes per replica.
+	// If not set, the replicas are not computed by the cores linearly.
+	// +optional
+	CoresPerReplica float64 `json:"coresPerReplica,omitempty" protobuf:"fixed64,1,opt,name=coresPerReplica"`
+	// nodesPerReplica is the number of the schedulable nodes per replica.
+	// If not set, the replicas are not computed by the nodes linearly.
+	// +optional
+	NodesPerReplica float64 `json:"nodesPerReplica,omitempty" protobuf:"fixed64,2,opt,name=nodesPerReplica"`
+	// ladder maps the cores and the nodes to the replicas by steps, coresPerReplica and nodesPerReplica are
+	// ignored if it is set.
+	// +optional
+	Ladder *ClusterProportionalLadder `json:"ladder,omitempty" protobuf:"bytes,3,opt,name=ladder"`
+	// preventSinglePointFailure keeps at least 2 replicas while the cluster has more than one schedulable node.
+	// +optional
+	PreventSinglePointFailure bool `json:"preventSinglePointFailure,omitempty" protobuf:"varint,4,opt,name=preventSinglePointFailure"`
+	// includeUnschedulableNodes counts the cores and the nodes which are cordoned as well.
+	// +optional
+	IncludeUnschedulableNodes bool `json:"includeUnschedulableNodes,omitempty" protobuf:"varint,5,opt,name=includeUnschedulableNodes"`
+}
+
+// ClusterProportionalLadder maps the size of the cluster to the replicas by steps, the replicas of the largest
+// step not larger than the cluster are used. The replicas are 0 if the cluster is smaller than all the steps.
+type ClusterProportionalLadder struct {
+	// coresToReplicas are the steps of the allocatable cores.
+	// +optional
+	CoresToReplicas []ClusterProportionalStep `json:"coresToReplicas,omitempty" protobuf:"bytes,1,rep,name=coresToReplicas"`
+	// nodesToReplicas are the steps of the number of the nodes.
+	// +optional
+	NodesToReplicas []ClusterProportionalStep `json:"nodesToReplicas,omitempty" protobuf:"bytes,2,rep,name=nodesToReplicas"`
+}
+
+// ClusterProportionalStep is a step of a ClusterProportionalLadder.
+type ClusterProportionalStep struct {
+	// size is the cores or the nodes the cluster has at least for the step.
+	Size int32 `json:"size" protobuf:"varint,1,name=size"`
+	// replicas are the replicas of the step.
+	Replicas int32 `json:"replicas" protobuf:"varint,2,name=replicas"`
 }
 
 type MetricMode struct {
@@ -108,6 +305,58 @@ type MetricMode struct {
 	// If not set, the default metric will be set to 80% average CPU utilization.
 	// +optional
 	Metrics []MetricSpec `json:"metrics,omitempty" protobuf:"bytes,1,opt,name=metrics"`
+	// expression computes the desired replica count from the current values of the named
+	// metrics instead of the maximum across all metrics, e.g. `ceil(qps / 50) + 1`.
+	// The metrics are referred by their names, and `currentReplicas` is the current replicas
+	// of the target. The result is rounded up to the desired replica count.
+	// +optional
+	Expression string `json:"expression,omitempty" protobuf:"bytes,2,opt,name=expression"`
+	// window gates the metrics by a schedule, e.g. the business hours. The metrics only drive the scaling in
+	// the minutes matching the schedule, outside of it the target is pinned to the off-hours replicas.
+	// If not set, the metrics always drive the scaling.
+	// +optional
+	Window *MetricWindow `json:"window,omitempty" protobuf:"bytes,3,opt,name=window"`
+	// breachDurationSeconds is how long the metrics must keep recommending a scale in the same direction
+	// before the target is scaled, so that the blips of the metrics are ignored. The duration restarts once
+	// the metrics return within the tolerance or recommend the other direction.
+	// If not set, the target is scaled as soon as the metrics recommend it.
+	// +optional
+	BreachDurationSeconds *int32 `json:"breachDurationSeconds,omitempty" protobuf:"varint,4,opt,name=breachDurationSeconds"`
+	// blend combines the normalized utilizations of the metrics, i.e. their current values divided by their
+	// targets, into a single utilization, and the desired replica count is the current replicas multiplied by
+	// it, instead of the maximum across all metrics. It can not be set with the expression.
+	// +optional
+	Blend *MetricBlend `json:"blend,omitempty" protobuf:"bytes,5,opt,name=blend"`
+}
+
+// MetricBlend combines the normalized utilizations of the metrics by a function. Each utilization is
+// multiplied by the weight of its metric first.
+type MetricBlend struct {
+	// function combines the weighted utilizations, Max takes the largest, and Average divides their sum by the
+	// sum of the weights.
+	// If not set, the default value Max is used.
+	// +optional
+	Function MetricBlendFunction `json:"function,omitempty" protobuf:"bytes,1,opt,name=function"`
+}
+
+// MetricBlendFunction is the function combining the utilizations of a MetricBlend
+type MetricBlendFunction string
+
+const (
+	// MaxMetricBlend takes the largest weighted utilization
+	MaxMetricBlend MetricBlendFunction = "Max"
+	// AverageMetricBlend takes the weighted average of the utilizations
+	AverageMetricBlend MetricBlendFunction = "Average"
+)
+
+// MetricWindow is the window the metrics drive the scaling in
+type MetricWindow struct {
+	// schedule matches the minutes of the window in the crontab format, e.g. `* 9-17 * * 1-5` for the
+	// business hours from 9:00 to 17:59 on weekdays.
+	Schedule string `json:"schedule" protobuf:"bytes,1,opt,name=schedule"`
+	// offHoursReplicas is the replicas the target is pinned to outside of the window, still bounded by the
+	// min and max replicas.
+	OffHoursReplicas int32 `json:"offHoursReplicas" protobuf:"varint,2,opt,name=offHoursReplicas"`
 }
 
 // EventMode is the event driven mode
@@ -132,12 +381,65 @@ type WebhookMode struct {
 	*admregv1b.WebhookClientConfig `json:",inline"`
 	// Parameters are the webhook parameters
 	Parameters map[string]string `json:"parameters,omitempty" protobuf:"bytes,1,opt,name=parameters"`
+	// HMACSecretRef selects a key of a secret in the namespace of the GPA, the value is used to
+	// sign the request body with HMAC-SHA256.
+	// +optional
+	HMACSecretRef *v1.SecretKeySelector `json:"hmacSecretRef,omitempty" protobuf:"bytes,2,opt,name=hmacSecretRef"`
+	// HMACHeader is the name of the header the signature is set in, only used with HMACSecretRef.
+	// Defaults to X-GPA-Signature.
+	// +optional
+	HMACHeader string `json:"hmacHeader,omitempty" protobuf:"bytes,3,opt,name=hmacHeader"`
+	// CABundleSecretRef selects a key of a secret in the namespace of the GPA, the value is a PEM encoded
+	// CA bundle used to verify the serving certificate of the webhook, in addition to caBundle.
+	// The CA bundles are only trusted by the client of this webhook.
+	// +optional
+	CABundleSecretRef *v1.SecretKeySelector `json:"caBundleSecretRef,omitempty" protobuf:"bytes,4,opt,name=caBundleSecretRef"`
+	// Endpoints are more webhook servers queried after the one above, e.g. the fallbacks of a primary
+	// recommendation service. The endpoints and selectPolicy of the endpoints are ignored.
+	// +optional
+	Endpoints []WebhookMode `json:"endpoints,omitempty" protobuf:"bytes,5,rep,name=endpoints"`
+	// SelectPolicy is used to combine the replicas of the webhook servers.
+	// If not set, the default value FirstSuccessWebhookSelect is used.
+	// +optional
+	SelectPolicy *WebhookSelectPolicy `json:"selectPolicy,omitempty" protobuf:"bytes,6,opt,name=selectPolicy"`
 }
 
+// WebhookSelectPolicy is used to specify how the replicas of multiple webhook servers are combined
+type WebhookSelectPolicy string
+
+const (
+	// FirstSuccessWebhookSelect queries the webhook servers in order and uses the first successful one.
+	FirstSuccessWebhookSelect WebhookSelectPolicy = "FirstSuccess"
+	// MaxWebhookSelect queries all the webhook servers and uses the highest replicas of the successful ones.
+	MaxWebhookSelect WebhookSelectPolicy = "Max"
+	// MinWebhookSelect queries all the webhook servers and uses the lowest replicas of the successful ones.
+	MinWebhookSelect WebhookSelectPolicy = "Min"
+)
+
 // TimeMode is a mode allows user to define a crontab regular
 type TimeMode struct {
 	// TimeRanges defines a array that for time driven mode
 	TimeRanges []TimeRange `json:"ranges,omitempty" protobuf:"bytes,1,opt,name=ranges"`
+
+	// Exceptions are the dates the time ranges are skipped on, e.g. holidays.
+	// +optional
+	Exceptions *TimeExceptions `json:"exceptions,omitempty" protobuf:"bytes,2,opt,name=exceptions"`
+}
+
+// TimeExceptionDateLayout is the layout of the exception dates
+const TimeExceptionDateLayout = "2006-01-02"
+
+// TimeExceptions defines the dates the time ranges are skipped on, the dates are in the layout of 2006-01-02
+// and in the time zone the schedules are evaluated in.
+type TimeExceptions struct {
+	// Dates are the exception dates.
+	// +optional
+	Dates []string `json:"dates,omitempty" protobuf:"bytes,1,rep,name=dates"`
+
+	// ConfigMapKeyRef refers to a key of a ConfigMap in the namespace of the GPA, which lists an exception
+	// date per line. Empty lines and lines starting with '#' are ignored.
+	// +optional
+	ConfigMapKeyRef *v1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty" protobuf:"bytes,2,opt,name=configMapKeyRef"`
 }
 
 // TimeTimeRange is a mode allows user to define a crontab regular
@@ -167,6 +469,15 @@ type MetricSpec struct {
 	// "Pods" or "Resource", each mapping to a matching field in the object.
 	Type MetricSourceType `json:"type" protobuf:"bytes,1,name=type"`
 
+	// name is the variable name of the metric in the expression of the metric mode.
+	// +optional
+	Name string `json:"name,omitempty" protobuf:"bytes,8,opt,name=name"`
+
+	// weight is the weight of the metric in the blend of the metric mode.
+	// If not set, the default value 1 is used.
+	// +optional
+	Weight *float64 `json:"weight,omitempty" protobuf:"fixed64,13,opt,name=weight"`
+
 	// object refers to a metric describing a single kubernetes object
 	// (for example, hits-per-second on an Ingress object).
 	// +optional
@@ -198,6 +509,37 @@ type MetricSpec struct {
 	// This is an alpha feature and can be enabled by the HPAContainerMetrics feature flag.
 	// +optional
 	ContainerResource *ContainerResourceMetricSource `json:"containerResource,omitempty" protobuf:"bytes,6,opt,name=containerResource"`
+	// derivative refers to a global metric whose rate of change is used to project
+	// its value ahead, so that the target is scaled before the metric reaches the target.
+	// +optional
+	Derivative *DerivativeMetricSource `json:"derivative,omitempty" protobuf:"bytes,7,opt,name=derivative"`
+	// probe refers to the latency of an HTTP endpoint probed by the controller.
+	// +optional
+	Probe *ProbeMetricSource `json:"probe,omitempty" protobuf:"bytes,9,opt,name=probe"`
+	// kafkaLag refers to the total lag of a Kafka consumer group on a topic, read by the controller
+	// from the brokers.
+	// +optional
+	KafkaLag *KafkaLagMetricSource `json:"kafkaLag,omitempty" protobuf:"bytes,10,opt,name=kafkaLag"`
+	// counterDelta refers to a global monotonic counter, whose increase over the last interval is
+	// used to scale the target, e.g. the jobs submitted per minute.
+	// +optional
+	CounterDelta *CounterDeltaMetricSource `json:"counterDelta,omitempty" protobuf:"bytes,11,opt,name=counterDelta"`
+	// ratio refers to the ratio of two metrics describing each pod in the current scale target, e.g. the
+	// busy worker threads of the total ones, which is kept near the target utilization.
+	// +optional
+	Ratio *RatioMetricSource `json:"ratio,omitempty" protobuf:"bytes,12,opt,name=ratio"`
+	// concurrency refers to the in-flight requests of each pod in the current scale target, e.g. the active
+	// requests reported by the sidecars of a service mesh, which are kept near the target per ready pod.
+	// +optional
+	Concurrency *ConcurrencyMetricSource `json:"concurrency,omitempty" protobuf:"bytes,13,opt,name=concurrency"`
+	// scrape refers to a gauge exposed by each pod in the current scale target in the Prometheus text format,
+	// scraped by the controller from a port of the pods, for the setups without a metrics pipeline.
+	// +optional
+	Scrape *ScrapeMetricSource `json:"scrape,omitempty" protobuf:"bytes,14,opt,name=scrape"`
+	// bucketTable refers to a global metric whose value selects a bucket of a table, e.g. the qps of a load
+	// balancer, and the fixed replicas of the bucket are used to scale the target.
+	// +optional
+	BucketTable *BucketTableMetricSource `json:"bucketTable,omitempty" protobuf:"bytes,15,opt,name=bucketTable"`
 }
 
 // GeneralPodAutoscalerBehavior configures the scaling behavior of the target
@@ -216,6 +558,92 @@ type GeneralPodAutoscalerBehavior struct {
 	// the last 300sec is used).
 	// +optional
 	ScaleDown *GPAScalingRules `json:"scaleDown,omitempty" protobuf:"bytes,2,opt,name=scaleDown"`
+	// ewmaAlpha is the smoothing factor of the exponential weighted moving average applied to
+	// the recommendation before it is normalized. It must be greater than 0 and less than or equal to 1,
+	// a smaller value gives more weight to the previous recommendations.
+	// If not set, no smoothing is done.
+	// +optional
+	EWMAAlpha *float64 `json:"ewmaAlpha,omitempty" protobuf:"fixed64,3,opt,name=ewmaAlpha"`
+	// pid replaces the ratio scaling by a proportional-integral controller over the error between the
+	// recommendation and the current replicas, after the smoothing and before the normalization.
+	// If not set, the recommendation is used as is.
+	// +optional
+	PID *PIDController `json:"pid,omitempty" protobuf:"bytes,4,opt,name=pid"`
+	// scaleDownDelayAfterScaleUpSeconds is the number of seconds after the last scale-up during which
+	// the target is not scaled down, so that a dip right after a scale-up does not scale it back down.
+	// It is applied on top of the scale down stabilization window, and must be greater than or equal to
+	// zero and less than or equal to 3600 (one hour).
+	// If not set, the scale-downs are not delayed after the scale-ups.
+	// +optional
+	ScaleDownDelayAfterScaleUpSeconds *int32 `json:"scaleDownDelayAfterScaleUpSeconds,omitempty" protobuf:"varint,5,opt,name=scaleDownDelayAfterScaleUpSeconds"`
+	// peakFloor raises the min replicas to the peak of the recent recommendations, decaying over its window,
+	// so that the target is not under-provisioned when the load comes back after a lull.
+	// If not set, the min replicas are not raised.
+	// +optional
+	PeakFloor *PeakFloor `json:"peakFloor,omitempty" protobuf:"bytes,6,opt,name=peakFloor"`
+	// metric overrides scaleUp and scaleDown for the decisions of the metric mode, direction by direction.
+	// If not set, the decisions of the metric mode use scaleUp and scaleDown.
+	// +optional
+	Metric *ModeScalingRules `json:"metric,omitempty" protobuf:"bytes,7,opt,name=metric"`
+	// webhook overrides scaleUp and scaleDown for the decisions of the webhook mode, direction by direction.
+	// If not set, the decisions of the webhook mode use scaleUp and scaleDown.
+	// +optional
+	Webhook *ModeScalingRules `json:"webhook,omitempty" protobuf:"bytes,8,opt,name=webhook"`
+	// time overrides scaleUp and scaleDown for the decisions of the time mode, direction by direction.
+	// If not set, the decisions of the time mode use scaleUp and scaleDown.
+	// +optional
+	Time *ModeScalingRules `json:"time,omitempty" protobuf:"bytes,9,opt,name=time"`
+	// clusterProportional overrides scaleUp and scaleDown for the decisions of the cluster-proportional mode,
+	// direction by direction. If not set, the decisions of the cluster-proportional mode use scaleUp and scaleDown.
+	// +optional
+	ClusterProportional *ModeScalingRules `json:"clusterProportional,omitempty" protobuf:"bytes,10,opt,name=clusterProportional"`
+	// mirror overrides scaleUp and scaleDown for the decisions of the mirror mode, direction by direction.
+	// If not set, the decisions of the mirror mode use scaleUp and scaleDown.
+	// +optional
+	Mirror *ModeScalingRules `json:"mirror,omitempty" protobuf:"bytes,11,opt,name=mirror"`
+	// replicaStep quantizes the replicas to the multiples of the step, for the workloads which are only
+	// efficient at some replica counts, e.g. a multiple of their shards. The desired replicas are rounded to the
+	// nearest multiple, a half step up, then to the multiples within minReplicas and maxReplicas. It must be
+	// greater than zero, and at least one multiple must be within minReplicas and maxReplicas.
+	// If not set, the replicas are not quantized.
+	// +optional
+	ReplicaStep *int32 `json:"replicaStep,omitempty" protobuf:"varint,12,opt,name=replicaStep"`
+}
+
+// ModeScalingRules configures the scaling behavior of the decisions of a mode. A direction not set falls back to
+// the scaleUp or scaleDown of the behavior.
+type ModeScalingRules struct {
+	// scaleUp is scaling policy for scaling Up the decisions of the mode.
+	// +optional
+	ScaleUp *GPAScalingRules `json:"scaleUp,omitempty" protobuf:"bytes,1,opt,name=scaleUp"`
+	// scaleDown is scaling policy for scaling Down the decisions of the mode.
+	// +optional
+	ScaleDown *GPAScalingRules `json:"scaleDown,omitempty" protobuf:"bytes,2,opt,name=scaleDown"`
+}
+
+// PIDController configures the gains of the proportional-integral controller. The controller changes the
+// replicas by kp * error + ki * integral, where error is the recommendation minus the current replicas and
+// integral is the error integrated over minutes. kp 1 and ki 0 is the same as the ratio scaling.
+type PIDController struct {
+	// kp is the proportional gain, it must not be negative.
+	Kp float64 `json:"kp" protobuf:"fixed64,1,opt,name=kp"`
+	// ki is the integral gain per minute, it must not be negative.
+	// +optional
+	Ki float64 `json:"ki,omitempty" protobuf:"fixed64,2,opt,name=ki"`
+}
+
+// PeakFloor configures the floor of the replicas tracking the peak of the recommendations. A peak raises the
+// floor to percent of its replicas right after it is recommended, and its share decays linearly to zero at the
+// end of the window. The floor is the largest share of the peaks within the window, capped at maxReplicas.
+type PeakFloor struct {
+	// windowSeconds is how long a peak raises the floor, it must be greater than 0 and less than or equal to
+	// 604800 (one week).
+	WindowSeconds int32 `json:"windowSeconds" protobuf:"varint,1,opt,name=windowSeconds"`
+	// percent is the percent of the peak the floor is raised to right after it is recommended, it must be
+	// greater than 0 and less than or equal to 100.
+	// If not set, the floor is raised to the peak.
+	// +optional
+	Percent *int32 `json:"percent,omitempty" protobuf:"varint,2,opt,name=percent"`
 }
 
 // ScalingPolicySelect is used to specify which policy should be used while scaling in a certain direction
@@ -253,6 +681,17 @@ type GPAScalingRules struct {
 	// At least one policy must be specified, otherwise the GPAScalingRules will be discarded as invalid
 	// +optional
 	Policies []GPAScalingPolicy `json:"policies,omitempty" protobuf:"bytes,2,rep,name=policies"`
+	// maxFactor caps a single scale up to maxFactor times the current replicas, unless maxAbsolute
+	// allows more. It must be greater than or equal to 1, and is only supported for scale up.
+	// If only maxAbsolute is set, the default value 2 is used.
+	// If neither is set, a single scale up is only limited by the policies.
+	// +optional
+	MaxFactor *float64 `json:"maxFactor,omitempty" protobuf:"fixed64,4,opt,name=maxFactor"`
+	// maxAbsolute caps a single scale up to maxAbsolute replicas, unless maxFactor allows more.
+	// It must be greater than 0, and is only supported for scale up.
+	// If only maxFactor is set, the default value 4 is used.
+	// +optional
+	MaxAbsolute *int32 `json:"maxAbsolute,omitempty" protobuf:"varint,5,opt,name=maxAbsolute"`
 }
 
 // GPAScalingPolicyType is the type of the policy which could be used while making scaling decisions.
@@ -307,6 +746,30 @@ const (
 	// (for example length of queue in cloud messaging service, or
 	// QPS from loadbalancer running outside of cluster).
 	ExternalMetricSourceType MetricSourceType = "External"
+	// DerivativeMetricSourceType is a global metric like the "external" source, while the
+	// value projected from its rate of change is compared to the target value.
+	DerivativeMetricSourceType MetricSourceType = "Derivative"
+	// ProbeMetricSourceType is the latency of an HTTP endpoint probed by the controller, the target
+	// is scaled up when the latency exceeds the target latency.
+	ProbeMetricSourceType MetricSourceType = "Probe"
+	// KafkaLagMetricSourceType is the total lag of a Kafka consumer group on a topic read by the controller,
+	// the lag is divided by the target lag per pod.
+	KafkaLagMetricSourceType MetricSourceType = "KafkaLag"
+	// CounterDeltaMetricSourceType is a global monotonic counter like the "external" source, while its
+	// increase over the last interval is divided by the target increase per pod.
+	CounterDeltaMetricSourceType MetricSourceType = "CounterDelta"
+	// RatioMetricSourceType is the ratio of two metrics describing each pod in the current scale target like
+	// the "pods" source, while the ratio of their sums is compared to the target utilization.
+	RatioMetricSourceType MetricSourceType = "Ratio"
+	// ConcurrencyMetricSourceType is the in-flight requests of each pod in the current scale target like the
+	// "pods" source, while only the ready pods are counted and their sum is divided by the target per pod.
+	ConcurrencyMetricSourceType MetricSourceType = "Concurrency"
+	// ScrapeMetricSourceType is a gauge describing each pod in the current scale target like the "pods" source,
+	// while it is scraped by the controller from a port of the pods instead of read from the metrics APIs.
+	ScrapeMetricSourceType MetricSourceType = "Scrape"
+	// BucketTableMetricSourceType is a global metric like the "external" source, while its value selects a bucket
+	// of a table whose replicas are used as is.
+	BucketTableMetricSourceType MetricSourceType = "BucketTable"
 )
 
 // ObjectMetricSource indicates how to scale on a metric describing a
@@ -317,6 +780,11 @@ type ObjectMetricSource struct {
 	Target MetricTarget `json:"target" protobuf:"bytes,2,name=target"`
 	// metric identifies the target metric by name and selector
 	Metric MetricIdentifier `json:"metric" protobuf:"bytes,3,name=metric"`
+	// windowSeconds is the number of seconds the values of the metric are averaged over. The metrics APIs only
+	// serve the latest values, the average is computed from the values of the syncs of the GPA within the window.
+	// If not set, the latest value is used.
+	// +optional
+	WindowSeconds *int32 `json:"windowSeconds,omitempty" protobuf:"varint,4,opt,name=windowSeconds"`
 }
 
 // PodsMetricSource indicates how to scale on a metric describing each pod in
@@ -328,6 +796,11 @@ type PodsMetricSource struct {
 	Metric MetricIdentifier `json:"metric" protobuf:"bytes,1,name=metric"`
 	// target specifies the target value for the given metric
 	Target MetricTarget `json:"target" protobuf:"bytes,2,name=target"`
+	// windowSeconds is the number of seconds the values of the metric are averaged over. The metrics APIs only
+	// serve the latest values, the average is computed from the values of the syncs of the GPA within the window.
+	// If not set, the latest value is used.
+	// +optional
+	WindowSeconds *int32 `json:"windowSeconds,omitempty" protobuf:"varint,3,opt,name=windowSeconds"`
 }
 
 // ResourceMetricSource indicates how to scale on a resource metric known to
@@ -342,6 +815,12 @@ type ResourceMetricSource struct {
 	Name v1.ResourceName `json:"name" protobuf:"bytes,1,name=name"`
 	// target specifies the target value for the given metric
 	Target MetricTarget `json:"target" protobuf:"bytes,2,name=target"`
+	// halfLifeSeconds smooths the usage of each pod by its exponentially weighted moving average, like the load
+	// average of unix, before the utilization is computed. The weight of a usage is halved every halfLifeSeconds,
+	// so that the spikes shorter than it barely move the utilization.
+	// If not set, the latest usage is used.
+	// +optional
+	HalfLifeSeconds *int32 `json:"halfLifeSeconds,omitempty" protobuf:"varint,3,opt,name=halfLifeSeconds"`
 }
 
 // ContainerResourceMetricSource indicates how to scale on a resource metric known to
@@ -368,6 +847,225 @@ type ExternalMetricSource struct {
 	Metric MetricIdentifier `json:"metric" protobuf:"bytes,1,name=metric"`
 	// target specifies the target value for the given metric
 	Target MetricTarget `json:"target" protobuf:"bytes,2,name=target"`
+	// windowSeconds is the number of seconds the values of the metric are averaged over. The metrics APIs only
+	// serve the latest values, the average is computed from the values of the syncs of the GPA within the window.
+	// If not set, the latest value is used.
+	// +optional
+	WindowSeconds *int32 `json:"windowSeconds,omitempty" protobuf:"varint,3,opt,name=windowSeconds"`
+}
+
+// DerivativeMetricSource indicates how to scale on the projected value of a metric not
+// associated with any Kubernetes object. The slope of the metric over the window is used to
+// project its value lookaheadSeconds ahead, or the change of a season ago if seasonSeconds is set,
+// the projection is bounded by the current value divided and multiplied by 2. If smoothingSeconds
+// is set, the metric is smoothed by its moving average before it is projected.
+type DerivativeMetricSource struct {
+	// metric identifies the target metric by name and selector
+	Metric MetricIdentifier `json:"metric" protobuf:"bytes,1,name=metric"`
+	// target specifies the target value for the projected metric, only Value and AverageValue are supported
+	Target MetricTarget `json:"target" protobuf:"bytes,2,name=target"`
+	// windowSeconds is the number of seconds of the samples used to compute the slope.
+	// If not set, the default value 300 is used.
+	// +optional
+	WindowSeconds *int32 `json:"windowSeconds,omitempty" protobuf:"varint,3,opt,name=windowSeconds"`
+	// lookaheadSeconds is the number of seconds that the metric is projected ahead.
+	// If not set, the default value 180 is used.
+	// +optional
+	LookaheadSeconds *int32 `json:"lookaheadSeconds,omitempty" protobuf:"varint,4,opt,name=lookaheadSeconds"`
+	// seasonSeconds is the period of the pattern of the metric, e.g. 86400 for a daily pattern. If set, the
+	// samples are kept for a season, and once they cover one, the metric is projected by adding the change of
+	// the metric over lookaheadSeconds a season ago to the current value instead of the slope. It must be
+	// greater than lookaheadSeconds. The samples are kept in the memory of the controller only, they are lost
+	// once it restarts or another replica becomes the leader, and the slope is used until a season is covered again.
+	// +optional
+	SeasonSeconds *int32 `json:"seasonSeconds,omitempty" protobuf:"varint,5,opt,name=seasonSeconds"`
+	// smoothingSeconds is the number of seconds the samples of the metric are averaged over before its slope or
+	// its change of a season ago is computed, so that the projection follows the trend of a noisy metric instead
+	// of its blips. The current value is the moving average as well.
+	// If not set, the samples are not smoothed.
+	// +optional
+	SmoothingSeconds *int32 `json:"smoothingSeconds,omitempty" protobuf:"varint,6,opt,name=smoothingSeconds"`
+}
+
+// ProbeMetricSource indicates how to scale on the latency of an HTTP endpoint probed by the controller.
+// The endpoint is probed with GET requests on the syncs of the GPA, at most once per periodSeconds, and the
+// p95 of the latencies within windowSeconds is compared to the target latency. A probe which fails or does
+// not respond with a 2xx status counts as timeoutSeconds.
+type ProbeMetricSource struct {
+	// url is the http or https url of the endpoint
+	URL string `json:"url" protobuf:"bytes,1,name=url"`
+	// targetLatency is the target p95 latency of the endpoint, e.g. 200ms
+	TargetLatency metav1.Duration `json:"targetLatency" protobuf:"bytes,2,name=targetLatency"`
+	// periodSeconds is the minimum number of seconds between two probes.
+	// If not set, the default value 10 is used.
+	// +optional
+	PeriodSeconds *int32 `json:"periodSeconds,omitempty" protobuf:"varint,3,opt,name=periodSeconds"`
+	// timeoutSeconds is the number of seconds after which the probe times out.
+	// If not set, the default value 5 is used.
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty" protobuf:"varint,4,opt,name=timeoutSeconds"`
+	// windowSeconds is the number of seconds of the latencies used to compute the p95.
+	// If not set, the default value 60 is used.
+	// +optional
+	WindowSeconds *int32 `json:"windowSeconds,omitempty" protobuf:"varint,5,opt,name=windowSeconds"`
+	// tokenAuth authenticates the probes with a token projected into the controller, e.g. a workload
+	// identity token of a cloud metric backend.
+	// +optional
+	TokenAuth *ProjectedTokenAuth `json:"tokenAuth,omitempty" protobuf:"bytes,6,opt,name=tokenAuth"`
+}
+
+// ProjectedTokenAuth authenticates the requests of the controller with a token file projected into it, e.g. a
+// projected service account token. The file is read again once it is rotated.
+type ProjectedTokenAuth struct {
+	// tokenFile is the name of the token file in the directory of the projected tokens of the controller,
+	// the directory is set by the --projected-token-dir flag.
+	TokenFile string `json:"tokenFile" protobuf:"bytes,1,name=tokenFile"`
+	// header is the header the token is set in.
+	// If not set, the default value Authorization is used.
+	// +optional
+	Header string `json:"header,omitempty" protobuf:"bytes,2,opt,name=header"`
+	// scheme prefixes the token in the header, e.g. Bearer.
+	// If not set, Bearer is used for the Authorization header, and no scheme for the other headers.
+	// +optional
+	Scheme string `json:"scheme,omitempty" protobuf:"bytes,3,opt,name=scheme"`
+}
+
+// CounterDeltaMetricSource indicates how to scale on the increase of a monotonic counter not associated with
+// any Kubernetes object over the last interval. The counter is sampled on the syncs of the GPA, and a decrease
+// between two samples is taken as a reset of the counter, which restarted from 0.
+type CounterDeltaMetricSource struct {
+	// metric identifies the target metric by name and selector
+	Metric MetricIdentifier `json:"metric" protobuf:"bytes,1,name=metric"`
+	// target specifies the target increase per pod over the interval, only AverageValue is supported
+	Target MetricTarget `json:"target" protobuf:"bytes,2,name=target"`
+	// intervalSeconds is the number of seconds of the interval the increase is computed over.
+	// If not set, the default value 60 is used.
+	// +optional
+	IntervalSeconds *int32 `json:"intervalSeconds,omitempty" protobuf:"varint,3,opt,name=intervalSeconds"`
+}
+
+// RatioMetricSource indicates how to scale on the ratio of two metrics describing each pod in the current scale
+// target, e.g. the busy worker threads of the total ones. Both metrics are summed over the pods reporting both
+// of them, and the ratio of the sums in percent is compared to the target utilization.
+type RatioMetricSource struct {
+	// numerator identifies the metric of the used part, e.g. the busy worker threads
+	Numerator MetricIdentifier `json:"numerator" protobuf:"bytes,1,name=numerator"`
+	// denominator identifies the metric of the whole, e.g. the total worker threads
+	Denominator MetricIdentifier `json:"denominator" protobuf:"bytes,2,name=denominator"`
+	// target specifies the target ratio in percent, only Utilization is supported
+	Target MetricTarget `json:"target" protobuf:"bytes,3,name=target"`
+}
+
+// ConcurrencyMetricSource indicates how to scale on the in-flight requests of each pod in the current scale
+// target, e.g. the active requests of the Envoy sidecars served as a custom metric. Only the ready pods are
+// counted, the unready pods receive no new requests from the mesh, so neither their requests nor the pods
+// themselves are taken into account. The in-flight requests of the ready pods are summed and divided by the
+// target average value to compute the desired replicas.
+type ConcurrencyMetricSource struct {
+	// metric identifies the metric of the in-flight requests of a pod
+	Metric MetricIdentifier `json:"metric" protobuf:"bytes,1,name=metric"`
+	// target specifies the target in-flight requests per pod, only AverageValue is supported
+	Target MetricTarget `json:"target" protobuf:"bytes,2,name=target"`
+}
+
+// ScrapeMetricSource indicates how to scale on a gauge exposed by each pod in the current scale target in the
+// Prometheus text format, e.g. on /metrics. The controller scrapes the running pods on each sync of the GPA, and
+// the values of the pods are averaged and compared to the target like the "pods" source. A pod which can not be
+// scraped, e.g. it is unreachable or does not expose the gauge, is taken as a pod missing the metric.
+type ScrapeMetricSource struct {
+	// port is the number or the name of the container port of the pods serving the metrics
+	Port intstr.IntOrString `json:"port" protobuf:"bytes,1,name=port"`
+	// path is the http path of the metrics.
+	// If not set, the default value /metrics is used.
+	// +optional
+	Path string `json:"path,omitempty" protobuf:"bytes,2,opt,name=path"`
+	// metricName is the name of the gauge, the values of its series are summed if it has several
+	MetricName string `json:"metricName" protobuf:"bytes,3,name=metricName"`
+	// target specifies the target value per pod, only AverageValue is supported
+	Target MetricTarget `json:"target" protobuf:"bytes,4,name=target"`
+	// timeoutSeconds is the number of seconds after which the scrape of a pod times out.
+	// If not set, the default value 2 is used.
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty" protobuf:"varint,5,opt,name=timeoutSeconds"`
+}
+
+// BucketTableMetricSource indicates how to scale on a global metric not associated with any Kubernetes object by
+// a table of buckets, e.g. 2 replicas while the qps is in [0, 100), 5 in [100, 500) and 10 from 500. The bucket
+// containing the value of the metric is selected on each sync of the GPA, and its replicas are proposed as is.
+type BucketTableMetricSource struct {
+	// metric identifies the target metric by name and selector
+	Metric MetricIdentifier `json:"metric" protobuf:"bytes,1,name=metric"`
+	// buckets map the ranges of the value of the metric to the replicas, ordered by their ranges. The buckets must
+	// be contiguous, i.e. each bucket starts at the end of the previous one.
+	Buckets []MetricBucket `json:"buckets" protobuf:"bytes,2,rep,name=buckets"`
+}
+
+// MetricBucket maps the range [from, to) of the value of a metric to the replicas.
+type MetricBucket struct {
+	// from is the lower bound of the bucket, the value is in the bucket if it is greater than or equal to it
+	From resource.Quantity `json:"from" protobuf:"bytes,1,name=from"`
+	// to is the upper bound of the bucket, the value is in the bucket if it is less than it.
+	// If not set, the bucket has no upper bound, only the last bucket may leave it unset.
+	// +optional
+	To *resource.Quantity `json:"to,omitempty" protobuf:"bytes,2,opt,name=to"`
+	// replicas are the replicas proposed while the value of the metric is in the bucket
+	Replicas int32 `json:"replicas" protobuf:"varint,3,name=replicas"`
+}
+
+// KafkaLagMetricSource indicates how to scale on the total lag of a Kafka consumer group on a topic.
+// The lag of a partition is its newest offset minus the offset committed by the group, a partition without
+// a committed offset lags by its newest offset. The total lag of the partitions is divided by the target
+// average value to compute the desired replicas.
+type KafkaLagMetricSource struct {
+	// brokers are the host:port addresses of the bootstrap brokers
+	Brokers []string `json:"brokers" protobuf:"bytes,1,rep,name=brokers"`
+	// topic is the topic consumed by the group
+	Topic string `json:"topic" protobuf:"bytes,2,name=topic"`
+	// consumerGroup is the consumer group whose lag is scaled on
+	ConsumerGroup string `json:"consumerGroup" protobuf:"bytes,3,name=consumerGroup"`
+	// target specifies the target lag per pod, only AverageValue is supported
+	Target MetricTarget `json:"target" protobuf:"bytes,4,name=target"`
+	// tls enables TLS to the brokers.
+	// +optional
+	TLS *KafkaTLS `json:"tls,omitempty" protobuf:"bytes,5,opt,name=tls"`
+	// sasl enables SASL authentication to the brokers.
+	// +optional
+	SASL *KafkaSASL `json:"sasl,omitempty" protobuf:"bytes,6,opt,name=sasl"`
+}
+
+// KafkaTLS configures TLS to the brokers, the secrets are read from the namespace of the GPA.
+type KafkaTLS struct {
+	// caSecretRef selects a key of a secret, the value is a PEM encoded CA bundle used to verify the
+	// certificates of the brokers. If not set, the system roots are used.
+	// +optional
+	CASecretRef *v1.SecretKeySelector `json:"caSecretRef,omitempty" protobuf:"bytes,1,opt,name=caSecretRef"`
+	// certSecretRef selects a key of a secret, the value is a PEM encoded client certificate.
+	// It must be set together with keySecretRef.
+	// +optional
+	CertSecretRef *v1.SecretKeySelector `json:"certSecretRef,omitempty" protobuf:"bytes,2,opt,name=certSecretRef"`
+	// keySecretRef selects a key of a secret, the value is the PEM encoded key of the client certificate.
+	// +optional
+	KeySecretRef *v1.SecretKeySelector `json:"keySecretRef,omitempty" protobuf:"bytes,3,opt,name=keySecretRef"`
+}
+
+// KafkaSASLMechanism is the SASL mechanism used to authenticate to the brokers.
+type KafkaSASLMechanism string
+
+const (
+	// KafkaSASLPlain authenticates with the username and password in plain text, it should be used with TLS
+	KafkaSASLPlain KafkaSASLMechanism = "PLAIN"
+)
+
+// KafkaSASL configures SASL authentication to the brokers, the secrets are read from the namespace of the GPA.
+type KafkaSASL struct {
+	// mechanism is the SASL mechanism.
+	// If not set, the default value PLAIN is used.
+	// +optional
+	Mechanism KafkaSASLMechanism `json:"mechanism,omitempty" protobuf:"bytes,1,opt,name=mechanism"`
+	// usernameSecretRef selects a key of a secret, the value is the username
+	UsernameSecretRef v1.SecretKeySelector `json:"usernameSecretRef" protobuf:"bytes,2,name=usernameSecretRef"`
+	// passwordSecretRef selects a key of a secret, the value is the password
+	PasswordSecretRef v1.SecretKeySelector `json:"passwordSecretRef" protobuf:"bytes,3,name=passwordSecretRef"`
 }
 
 // MetricIdentifier defines the name and optionally selector for a metric
@@ -442,6 +1140,169 @@ type GeneralPodAutoscalerStatus struct {
 
 	// LastCronScheduleTime is the schedule time of time mode
 	LastCronScheduleTime *metav1.Time `json:"lastCronScheduleTime" protobuf:"bytes,7,rep,name=lastCronScheduleTime"`
+
+	// smoothedReplicas is the exponential weighted moving average of the recommendations,
+	// only set when spec.behavior.ewmaAlpha is set.
+	// +optional
+	SmoothedReplicas *float64 `json:"smoothedReplicas,omitempty" protobuf:"fixed64,8,opt,name=smoothedReplicas"`
+
+	// nextScheduleTime is the next time a range of the time mode is scheduled, only set in time mode.
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty" protobuf:"bytes,9,opt,name=nextScheduleTime"`
+
+	// nextScheduleReplicas is the desired replicas of the range scheduled at nextScheduleTime, the largest
+	// desired replicas if several ranges are scheduled at that time.
+	// +optional
+	NextScheduleReplicas int32 `json:"nextScheduleReplicas,omitempty" protobuf:"varint,10,opt,name=nextScheduleReplicas"`
+
+	// pid is the state of the proportional-integral controller, only set when spec.behavior.pid is set.
+	// +optional
+	PID *PIDStatus `json:"pid,omitempty" protobuf:"bytes,11,opt,name=pid"`
+
+	// conflictWinner is the mode whose replicas were used by the last recommendation, only set when
+	// spec.conflictPolicy is set and the metrics differ from a time range active at that time.
+	// +optional
+	ConflictWinner ConflictWinner `json:"conflictWinner,omitempty" protobuf:"bytes,12,opt,name=conflictWinner"`
+
+	// lastScaleUpTime is the last time the GeneralPodAutoscaler scaled up the target, the scale-downs
+	// are delayed from it by spec.behavior.scaleDownDelayAfterScaleUpSeconds.
+	// +optional
+	LastScaleUpTime *metav1.Time `json:"lastScaleUpTime,omitempty" protobuf:"bytes,13,opt,name=lastScaleUpTime"`
+
+	// pendingReplicas is the number of the pods of the target in the Pending phase, only set when
+	// spec.maxPendingPods is set.
+	// +optional
+	PendingReplicas int32 `json:"pendingReplicas,omitempty" protobuf:"varint,14,opt,name=pendingReplicas"`
+
+	// overriddenMinReplicas is the min replicas set by the override-min annotation in place of
+	// spec.minReplicas, only set while the annotation is set.
+	// +optional
+	OverriddenMinReplicas *int32 `json:"overriddenMinReplicas,omitempty" protobuf:"varint,15,opt,name=overriddenMinReplicas"`
+
+	// overriddenMaxReplicas is the max replicas set by the override-max annotation in place of
+	// spec.maxReplicas, only set while the annotation is set.
+	// +optional
+	OverriddenMaxReplicas *int32 `json:"overriddenMaxReplicas,omitempty" protobuf:"varint,16,opt,name=overriddenMaxReplicas"`
+
+	// peakRecommendations are the peaks of the recommendations within spec.behavior.peakFloor.windowSeconds,
+	// compacted to the largest recommendation of each bucket of the window. Only set when
+	// spec.behavior.peakFloor is set.
+	// +optional
+	PeakRecommendations []PeakRecommendation `json:"peakRecommendations,omitempty" protobuf:"bytes,17,rep,name=peakRecommendations"`
+
+	// metricBreach is the ongoing breach of the metrics, i.e. since when they have recommended a scale in
+	// the same direction. Only set when spec.metric.breachDurationSeconds is set.
+	// +optional
+	MetricBreach *MetricBreach `json:"metricBreach,omitempty" protobuf:"bytes,18,opt,name=metricBreach"`
+
+	// scaleHistory are the last scales of the target by the controller, the oldest first. Only set when
+	// spec.scaleHistoryLimit is set.
+	// +optional
+	ScaleHistory []ScaleRecord `json:"scaleHistory,omitempty" protobuf:"bytes,19,rep,name=scaleHistory"`
+
+	// tuningOverride is the tolerance and the stabilization windows set by the override annotations in place
+	// of those of the controller and the spec, only set while any of the annotations is set.
+	// +optional
+	TuningOverride *TuningOverride `json:"tuningOverride,omitempty" protobuf:"bytes,20,opt,name=tuningOverride"`
+
+	// drivingMetric is the metric which drove the last recommendation, with its value and its target. Only set
+	// when the GPA is annotated with autoscaling.ocgi.io/record-driving-metric and the recommendation is the
+	// largest proposal of a single metric, i.e. neither an expression nor a blend.
+	// +optional
+	DrivingMetric *DrivingMetricStatus `json:"drivingMetric,omitempty" protobuf:"bytes,21,opt,name=drivingMetric"`
+
+	// multiplier is the multiplier set by the multiplier annotations applied to the recommendations of the
+	// metrics, only set until the end of its window.
+	// +optional
+	Multiplier *MultiplierStatus `json:"multiplier,omitempty" protobuf:"bytes,22,opt,name=multiplier"`
+}
+
+// MultiplierStatus is a temporary multiplier of the recommendations, e.g. during a campaign
+type MultiplierStatus struct {
+	// factor is what the recommendations of the metrics are multiplied by
+	Factor float64 `json:"factor" protobuf:"fixed64,1,opt,name=factor"`
+	// until is the end of the window of the multiplier
+	Until metav1.Time `json:"until" protobuf:"bytes,2,opt,name=until"`
+}
+
+// DrivingMetricStatus is the value and the target of the metric which drove the last recommendation
+type DrivingMetricStatus struct {
+	// index is the index of the metric in spec.metric.metrics
+	Index int32 `json:"index" protobuf:"varint,1,opt,name=index"`
+	// name is the description of the metric, as in the ScalingActive condition
+	Name string `json:"name" protobuf:"bytes,2,opt,name=name"`
+	// current is the value of the metric as fetched in the last sync
+	// +optional
+	Current *MetricValueStatus `json:"current,omitempty" protobuf:"bytes,3,opt,name=current"`
+	// target is the target of the metric, not set for the metrics without one like the probes
+	// +optional
+	Target *MetricTarget `json:"target,omitempty" protobuf:"bytes,4,opt,name=target"`
+	// replicas is the number of replicas proposed by the metric
+	Replicas int32 `json:"replicas" protobuf:"varint,5,opt,name=replicas"`
+	// lastRecordTime is the last time the metric was recorded
+	LastRecordTime metav1.Time `json:"lastRecordTime" protobuf:"bytes,6,opt,name=lastRecordTime"`
+}
+
+// TuningOverride is the tuning of the decisions of a GPA overridden by its annotations, e.g. during an experiment
+type TuningOverride struct {
+	// tolerance is the tolerance set by the override-tolerance annotation in place of the tolerance of
+	// the controller
+	// +optional
+	Tolerance *float64 `json:"tolerance,omitempty" protobuf:"fixed64,1,opt,name=tolerance"`
+	// scaleUpStabilizationWindowSeconds is the scale up stabilization window set by the
+	// override-scale-up-stabilization-seconds annotation in place of those of the behavior
+	// +optional
+	ScaleUpStabilizationWindowSeconds *int32 `json:"scaleUpStabilizationWindowSeconds,omitempty" protobuf:"varint,2,opt,name=scaleUpStabilizationWindowSeconds"`
+	// scaleDownStabilizationWindowSeconds is the scale down stabilization window set by the
+	// override-scale-down-stabilization-seconds annotation in place of those of the behavior and of the controller
+	// +optional
+	ScaleDownStabilizationWindowSeconds *int32 `json:"scaleDownStabilizationWindowSeconds,omitempty" protobuf:"varint,3,opt,name=scaleDownStabilizationWindowSeconds"`
+}
+
+// ScaleRecord is a scale of the target by the controller
+type ScaleRecord struct {
+	// time is when the target was scaled
+	Time metav1.Time `json:"time" protobuf:"bytes,1,opt,name=time"`
+	// oldReplicas are the replicas of the target before the scale
+	OldReplicas int32 `json:"oldReplicas" protobuf:"varint,2,opt,name=oldReplicas"`
+	// newReplicas are the replicas the target was scaled to
+	NewReplicas int32 `json:"newReplicas" protobuf:"varint,3,opt,name=newReplicas"`
+	// reason is why the target was scaled
+	Reason string `json:"reason,omitempty" protobuf:"bytes,4,opt,name=reason"`
+}
+
+// MetricBreachDirection is the direction the metrics recommend to scale the target in
+type MetricBreachDirection string
+
+const (
+	// MetricBreachUp means the metrics recommend more replicas than the current ones
+	MetricBreachUp MetricBreachDirection = "Up"
+	// MetricBreachDown means the metrics recommend fewer replicas than the current ones
+	MetricBreachDown MetricBreachDirection = "Down"
+)
+
+// MetricBreach is an ongoing breach of the metrics
+type MetricBreach struct {
+	// direction is the direction the metrics recommend to scale the target in
+	Direction MetricBreachDirection `json:"direction" protobuf:"bytes,1,opt,name=direction,casttype=MetricBreachDirection"`
+	// startTime is when the metrics started to recommend the direction
+	StartTime metav1.Time `json:"startTime" protobuf:"bytes,2,opt,name=startTime"`
+}
+
+// PeakRecommendation is the largest recommendation of a bucket of the window of the peak floor
+type PeakRecommendation struct {
+	// time is the time the replicas were recommended.
+	Time metav1.Time `json:"time" protobuf:"bytes,1,opt,name=time"`
+	// replicas are the recommended replicas.
+	Replicas int32 `json:"replicas" protobuf:"varint,2,opt,name=replicas"`
+}
+
+// PIDStatus is the state of the proportional-integral controller
+type PIDStatus struct {
+	// integral is the error in replicas integrated over minutes.
+	Integral float64 `json:"integral" protobuf:"fixed64,1,opt,name=integral"`
+	// lastUpdateTime is the time the integral was last updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime" protobuf:"bytes,2,opt,name=lastUpdateTime"`
 }
 
 // GeneralPodAutoscalerConditionType are the valid conditions of
@@ -458,6 +1319,28 @@ const (
 	// ScalingLimited indicates that the calculated scale based on metrics would be above or
 	// below the range for the GPA, and has thus been capped.
 	ScalingLimited GeneralPodAutoscalerConditionType = "ScalingLimited"
+	// ScalingPausedDuringRollout indicates that scaling is deferred since the target Deployment
+	// is rolling out, only set when freezeOnRollout is enabled.
+	ScalingPausedDuringRollout GeneralPodAutoscalerConditionType = "ScalingPausedDuringRollout"
+	// Warmup indicates that the recommendations are not applied since the GPA was created less than
+	// warmupSeconds ago, only set when warmupSeconds is set.
+	Warmup GeneralPodAutoscalerConditionType = "Warmup"
+	// BoundsOverridden indicates whether the min or max replicas of the spec are overridden by the
+	// override-min and override-max annotations, it is only set once they were set.
+	BoundsOverridden GeneralPodAutoscalerConditionType = "BoundsOverridden"
+	// TuningOverridden indicates whether the tolerance or the stabilization windows are overridden by the
+	// override-tolerance and override-*-stabilization-seconds annotations, it is only set once they were set.
+	TuningOverridden GeneralPodAutoscalerConditionType = "TuningOverridden"
+	// ReadinessGapBuffered indicates whether the buffer replicas are added since the ready pods fall behind
+	// the desired replicas, only set when readinessGapBuffer is set. It is Unknown while the gap is observed
+	// but does not persist for gapSeconds yet.
+	ReadinessGapBuffered GeneralPodAutoscalerConditionType = "ReadinessGapBuffered"
+	// PodAgeBuffered indicates whether the buffer replicas are added since the oldest pod of the target is older
+	// than podAgeBuffer.maxAgeSeconds, only set when podAgeBuffer is set.
+	PodAgeBuffered GeneralPodAutoscalerConditionType = "PodAgeBuffered"
+	// PartialMetrics indicates whether the replicas are computed from a part of the metrics since the others
+	// failed, the message names the failed metrics. It is only set once a metric failed.
+	PartialMetrics GeneralPodAutoscalerConditionType = "PartialMetrics"
 )
 
 // GeneralPodAutoscalerCondition describes the state of
@@ -512,6 +1395,30 @@ type MetricStatus struct {
 	// to normal per-pod metrics using the "pods" source.
 	// +optional
 	ContainerResource *ContainerResourceMetricStatus `json:"containerResource,omitempty" protobuf:"bytes,6,opt,name=containerResource"`
+	// derivative refers to a global metric whose value is projected from its rate of change.
+	// +optional
+	Derivative *DerivativeMetricStatus `json:"derivative,omitempty" protobuf:"bytes,7,opt,name=derivative"`
+	// probe refers to the latency of an HTTP endpoint probed by the controller.
+	// +optional
+	Probe *ProbeMetricStatus `json:"probe,omitempty" protobuf:"bytes,8,opt,name=probe"`
+	// kafkaLag refers to the total lag of a Kafka consumer group on a topic.
+	// +optional
+	KafkaLag *KafkaLagMetricStatus `json:"kafkaLag,omitempty" protobuf:"bytes,9,opt,name=kafkaLag"`
+	// counterDelta refers to the increase of a global monotonic counter over the last interval.
+	// +optional
+	CounterDelta *CounterDeltaMetricStatus `json:"counterDelta,omitempty" protobuf:"bytes,10,opt,name=counterDelta"`
+	// ratio refers to the ratio of two metrics describing each pod in the current scale target.
+	// +optional
+	Ratio *RatioMetricStatus `json:"ratio,omitempty" protobuf:"bytes,11,opt,name=ratio"`
+	// concurrency refers to the in-flight requests of each pod in the current scale target.
+	// +optional
+	Concurrency *ConcurrencyMetricStatus `json:"concurrency,omitempty" protobuf:"bytes,12,opt,name=concurrency"`
+	// scrape refers to a gauge scraped by the controller from each pod in the current scale target.
+	// +optional
+	Scrape *ScrapeMetricStatus `json:"scrape,omitempty" protobuf:"bytes,13,opt,name=scrape"`
+	// bucketTable refers to the value of a global metric and the bucket of the table it selected.
+	// +optional
+	BucketTable *BucketTableMetricStatus `json:"bucketTable,omitempty" protobuf:"bytes,14,opt,name=bucketTable"`
 }
 
 // ObjectMetricStatus indicates the current value of a metric describing a
@@ -570,6 +1477,89 @@ type ExternalMetricStatus struct {
 	Current MetricValueStatus `json:"current" protobuf:"bytes,2,name=current"`
 }
 
+// DerivativeMetricStatus indicates the current and the projected value of a global metric
+// not associated with any Kubernetes object.
+type DerivativeMetricStatus struct {
+	// metric identifies the target metric by name and selector
+	Metric MetricIdentifier `json:"metric" protobuf:"bytes,1,name=metric"`
+	// current contains the current value for the given metric
+	Current MetricValueStatus `json:"current" protobuf:"bytes,2,name=current"`
+	// projected contains the value projected from the rate of change of the given metric
+	Projected MetricValueStatus `json:"projected" protobuf:"bytes,3,name=projected"`
+}
+
+// ProbeMetricStatus indicates the current latency of an HTTP endpoint probed by the controller.
+type ProbeMetricStatus struct {
+	// url is the url of the endpoint
+	URL string `json:"url" protobuf:"bytes,1,name=url"`
+	// current contains the p95 latency of the endpoint in seconds as the value
+	Current MetricValueStatus `json:"current" protobuf:"bytes,2,name=current"`
+}
+
+// CounterDeltaMetricStatus indicates the increase of a global monotonic counter over the last interval.
+type CounterDeltaMetricStatus struct {
+	// metric identifies the target metric by name and selector
+	Metric MetricIdentifier `json:"metric" protobuf:"bytes,1,name=metric"`
+	// current contains the increase over the interval as the value, and the increase per pod as the
+	// average value
+	Current MetricValueStatus `json:"current" protobuf:"bytes,2,name=current"`
+}
+
+// RatioMetricStatus indicates the current ratio of two metrics describing each pod in the current scale target.
+type RatioMetricStatus struct {
+	// numerator identifies the metric of the used part
+	Numerator MetricIdentifier `json:"numerator" protobuf:"bytes,1,name=numerator"`
+	// denominator identifies the metric of the whole
+	Denominator MetricIdentifier `json:"denominator" protobuf:"bytes,2,name=denominator"`
+	// current contains the ratio in percent as the average utilization
+	Current MetricValueStatus `json:"current" protobuf:"bytes,3,name=current"`
+}
+
+// ConcurrencyMetricStatus indicates the current in-flight requests of the ready pods in the current scale target.
+type ConcurrencyMetricStatus struct {
+	// metric identifies the metric of the in-flight requests of a pod
+	Metric MetricIdentifier `json:"metric" protobuf:"bytes,1,name=metric"`
+	// current contains the in-flight requests of the ready pods as the value, and the requests per ready pod
+	// as the average value
+	Current MetricValueStatus `json:"current" protobuf:"bytes,2,name=current"`
+	// readyPods is the number of the ready pods reporting the metric
+	ReadyPods int32 `json:"readyPods" protobuf:"varint,3,name=readyPods"`
+}
+
+// ScrapeMetricStatus indicates the current value of a gauge scraped by the controller from the pods in the current
+// scale target.
+type ScrapeMetricStatus struct {
+	// metricName is the name of the gauge
+	MetricName string `json:"metricName" protobuf:"bytes,1,name=metricName"`
+	// current contains the average value of the gauge over the scraped pods
+	Current MetricValueStatus `json:"current" protobuf:"bytes,2,name=current"`
+	// scrapedPods is the number of the pods scraped successfully
+	ScrapedPods int32 `json:"scrapedPods" protobuf:"varint,3,name=scrapedPods"`
+	// failedPods is the number of the running pods which could not be scraped
+	// +optional
+	FailedPods int32 `json:"failedPods,omitempty" protobuf:"varint,4,opt,name=failedPods"`
+}
+
+// BucketTableMetricStatus indicates the current value of a global metric and the bucket of the table it selected.
+type BucketTableMetricStatus struct {
+	// metric identifies the target metric by name and selector
+	Metric MetricIdentifier `json:"metric" protobuf:"bytes,1,name=metric"`
+	// current contains the current value of the metric
+	Current MetricValueStatus `json:"current" protobuf:"bytes,2,name=current"`
+	// bucket is the index of the bucket containing the current value
+	Bucket int32 `json:"bucket" protobuf:"varint,3,name=bucket"`
+}
+
+// KafkaLagMetricStatus indicates the current total lag of a Kafka consumer group on a topic.
+type KafkaLagMetricStatus struct {
+	// topic is the topic consumed by the group
+	Topic string `json:"topic" protobuf:"bytes,1,name=topic"`
+	// consumerGroup is the consumer group
+	ConsumerGroup string `json:"consumerGroup" protobuf:"bytes,2,name=consumerGroup"`
+	// current contains the total lag as the value and the lag per current pod as the average value
+	Current MetricValueStatus `json:"current" protobuf:"bytes,3,name=current"`
+}
+
 // MetricValueStatus holds the current value for a metric
 type MetricValueStatus struct {
 	// value is the current value of the metric (as a quantity).
@@ -22,7 +22,8 @@ package v1alpha1
 
 import (
 	v1beta1 "k8s.io/api/admissionregistration/v1beta1"
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -49,6 +50,16 @@ func (in *AutoScalingDrivenMode) DeepCopyInto(out *AutoScalingDrivenMode) {
 		*out = new(EventMode)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ClusterProportionalMode != nil {
+		in, out := &in.ClusterProportionalMode, &out.ClusterProportionalMode
+		*out = new(ClusterProportionalMode)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MirrorMode != nil {
+		in, out := &in.MirrorMode, &out.MirrorMode
+		*out = new(MirrorMode)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -62,6 +73,147 @@ func (in *AutoScalingDrivenMode) DeepCopy() *AutoScalingDrivenMode {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BucketTableMetricSource) DeepCopyInto(out *BucketTableMetricSource) {
+	*out = *in
+	in.Metric.DeepCopyInto(&out.Metric)
+	if in.Buckets != nil {
+		in, out := &in.Buckets, &out.Buckets
+		*out = make([]MetricBucket, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BucketTableMetricSource.
+func (in *BucketTableMetricSource) DeepCopy() *BucketTableMetricSource {
+	if in == nil {
+		return nil
+	}
+	out := new(BucketTableMetricSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BucketTableMetricStatus) DeepCopyInto(out *BucketTableMetricStatus) {
+	*out = *in
+	in.Metric.DeepCopyInto(&out.Metric)
+	in.Current.DeepCopyInto(&out.Current)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BucketTableMetricStatus.
+func (in *BucketTableMetricStatus) DeepCopy() *BucketTableMetricStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BucketTableMetricStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterProportionalLadder) DeepCopyInto(out *ClusterProportionalLadder) {
+	*out = *in
+	if in.CoresToReplicas != nil {
+		in, out := &in.CoresToReplicas, &out.CoresToReplicas
+		*out = make([]ClusterProportionalStep, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodesToReplicas != nil {
+		in, out := &in.NodesToReplicas, &out.NodesToReplicas
+		*out = make([]ClusterProportionalStep, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterProportionalLadder.
+func (in *ClusterProportionalLadder) DeepCopy() *ClusterProportionalLadder {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterProportionalLadder)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterProportionalMode) DeepCopyInto(out *ClusterProportionalMode) {
+	*out = *in
+	if in.Ladder != nil {
+		in, out := &in.Ladder, &out.Ladder
+		*out = new(ClusterProportionalLadder)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterProportionalMode.
+func (in *ClusterProportionalMode) DeepCopy() *ClusterProportionalMode {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterProportionalMode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterProportionalStep) DeepCopyInto(out *ClusterProportionalStep) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterProportionalStep.
+func (in *ClusterProportionalStep) DeepCopy() *ClusterProportionalStep {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterProportionalStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConcurrencyMetricSource) DeepCopyInto(out *ConcurrencyMetricSource) {
+	*out = *in
+	in.Metric.DeepCopyInto(&out.Metric)
+	in.Target.DeepCopyInto(&out.Target)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConcurrencyMetricSource.
+func (in *ConcurrencyMetricSource) DeepCopy() *ConcurrencyMetricSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ConcurrencyMetricSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConcurrencyMetricStatus) DeepCopyInto(out *ConcurrencyMetricStatus) {
+	*out = *in
+	in.Metric.DeepCopyInto(&out.Metric)
+	in.Current.DeepCopyInto(&out.Current)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConcurrencyMetricStatus.
+func (in *ConcurrencyMetricStatus) DeepCopy() *ConcurrencyMetricStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConcurrencyMetricStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContainerResourceMetricSource) DeepCopyInto(out *ContainerResourceMetricSource) {
 	*out = *in
@@ -96,6 +248,47 @@ func (in *ContainerResourceMetricStatus) DeepCopy() *ContainerResourceMetricStat
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CounterDeltaMetricSource) DeepCopyInto(out *CounterDeltaMetricSource) {
+	*out = *in
+	in.Metric.DeepCopyInto(&out.Metric)
+	in.Target.DeepCopyInto(&out.Target)
+	if in.IntervalSeconds != nil {
+		in, out := &in.IntervalSeconds, &out.IntervalSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CounterDeltaMetricSource.
+func (in *CounterDeltaMetricSource) DeepCopy() *CounterDeltaMetricSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CounterDeltaMetricSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CounterDeltaMetricStatus) DeepCopyInto(out *CounterDeltaMetricStatus) {
+	*out = *in
+	in.Metric.DeepCopyInto(&out.Metric)
+	in.Current.DeepCopyInto(&out.Current)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CounterDeltaMetricStatus.
+func (in *CounterDeltaMetricStatus) DeepCopy() *CounterDeltaMetricStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CounterDeltaMetricStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CrossVersionObjectReference) DeepCopyInto(out *CrossVersionObjectReference) {
 	*out = *in
@@ -112,6 +305,63 @@ func (in *CrossVersionObjectReference) DeepCopy() *CrossVersionObjectReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DerivativeMetricSource) DeepCopyInto(out *DerivativeMetricSource) {
+	*out = *in
+	in.Metric.DeepCopyInto(&out.Metric)
+	in.Target.DeepCopyInto(&out.Target)
+	if in.WindowSeconds != nil {
+		in, out := &in.WindowSeconds, &out.WindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.LookaheadSeconds != nil {
+		in, out := &in.LookaheadSeconds, &out.LookaheadSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SeasonSeconds != nil {
+		in, out := &in.SeasonSeconds, &out.SeasonSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SmoothingSeconds != nil {
+		in, out := &in.SmoothingSeconds, &out.SmoothingSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DerivativeMetricSource.
+func (in *DerivativeMetricSource) DeepCopy() *DerivativeMetricSource {
+	if in == nil {
+		return nil
+	}
+	out := new(DerivativeMetricSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DerivativeMetricStatus) DeepCopyInto(out *DerivativeMetricStatus) {
+	*out = *in
+	in.Metric.DeepCopyInto(&out.Metric)
+	in.Current.DeepCopyInto(&out.Current)
+	in.Projected.DeepCopyInto(&out.Projected)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DerivativeMetricStatus.
+func (in *DerivativeMetricStatus) DeepCopy() *DerivativeMetricStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DerivativeMetricStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EventMode) DeepCopyInto(out *EventMode) {
 	*out = *in
@@ -135,11 +385,43 @@ func (in *EventMode) DeepCopy() *EventMode {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrivingMetricStatus) DeepCopyInto(out *DrivingMetricStatus) {
+	*out = *in
+	if in.Current != nil {
+		in, out := &in.Current, &out.Current
+		*out = new(MetricValueStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Target != nil {
+		in, out := &in.Target, &out.Target
+		*out = new(MetricTarget)
+		(*in).DeepCopyInto(*out)
+	}
+	in.LastRecordTime.DeepCopyInto(&out.LastRecordTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrivingMetricStatus.
+func (in *DrivingMetricStatus) DeepCopy() *DrivingMetricStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DrivingMetricStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExternalMetricSource) DeepCopyInto(out *ExternalMetricSource) {
 	*out = *in
 	in.Metric.DeepCopyInto(&out.Metric)
 	in.Target.DeepCopyInto(&out.Target)
+	if in.WindowSeconds != nil {
+		in, out := &in.WindowSeconds, &out.WindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -205,6 +487,16 @@ func (in *GPAScalingRules) DeepCopyInto(out *GPAScalingRules) {
 		*out = make([]GPAScalingPolicy, len(*in))
 		copy(*out, *in)
 	}
+	if in.MaxFactor != nil {
+		in, out := &in.MaxFactor, &out.MaxFactor
+		*out = new(float64)
+		**out = **in
+	}
+	if in.MaxAbsolute != nil {
+		in, out := &in.MaxAbsolute, &out.MaxAbsolute
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -259,6 +551,56 @@ func (in *GeneralPodAutoscalerBehavior) DeepCopyInto(out *GeneralPodAutoscalerBe
 		*out = new(GPAScalingRules)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.EWMAAlpha != nil {
+		in, out := &in.EWMAAlpha, &out.EWMAAlpha
+		*out = new(float64)
+		**out = **in
+	}
+	if in.PID != nil {
+		in, out := &in.PID, &out.PID
+		*out = new(PIDController)
+		**out = **in
+	}
+	if in.ScaleDownDelayAfterScaleUpSeconds != nil {
+		in, out := &in.ScaleDownDelayAfterScaleUpSeconds, &out.ScaleDownDelayAfterScaleUpSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PeakFloor != nil {
+		in, out := &in.PeakFloor, &out.PeakFloor
+		*out = new(PeakFloor)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Metric != nil {
+		in, out := &in.Metric, &out.Metric
+		*out = new(ModeScalingRules)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(ModeScalingRules)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		*out = new(ModeScalingRules)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterProportional != nil {
+		in, out := &in.ClusterProportional, &out.ClusterProportional
+		*out = new(ModeScalingRules)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Mirror != nil {
+		in, out := &in.Mirror, &out.Mirror
+		*out = new(ModeScalingRules)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplicaStep != nil {
+		in, out := &in.ReplicaStep, &out.ReplicaStep
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -337,6 +679,46 @@ func (in *GeneralPodAutoscalerSpec) DeepCopyInto(out *GeneralPodAutoscalerSpec)
 		*out = new(GeneralPodAutoscalerBehavior)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ReadinessGapBuffer != nil {
+		in, out := &in.ReadinessGapBuffer, &out.ReadinessGapBuffer
+		*out = new(ReadinessGapBuffer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RecoverFromZero != nil {
+		in, out := &in.RecoverFromZero, &out.RecoverFromZero
+		*out = new(RecoverFromZero)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OnTargetMissing != nil {
+		in, out := &in.OnTargetMissing, &out.OnTargetMissing
+		*out = new(TargetMissingPolicy)
+		**out = **in
+	}
+	if in.WarmupSeconds != nil {
+		in, out := &in.WarmupSeconds, &out.WarmupSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxPendingPods != nil {
+		in, out := &in.MaxPendingPods, &out.MaxPendingPods
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinReadySeconds != nil {
+		in, out := &in.MinReadySeconds, &out.MinReadySeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ScaleHistoryLimit != nil {
+		in, out := &in.ScaleHistoryLimit, &out.ScaleHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PodAgeBuffer != nil {
+		in, out := &in.PodAgeBuffer, &out.PodAgeBuffer
+		*out = new(PodAgeBuffer)
+		**out = **in
+	}
 	return
 }
 
@@ -380,25 +762,240 @@ func (in *GeneralPodAutoscalerStatus) DeepCopyInto(out *GeneralPodAutoscalerStat
 		in, out := &in.LastCronScheduleTime, &out.LastCronScheduleTime
 		*out = (*in).DeepCopy()
 	}
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GeneralPodAutoscalerStatus.
-func (in *GeneralPodAutoscalerStatus) DeepCopy() *GeneralPodAutoscalerStatus {
-	if in == nil {
-		return nil
+	if in.SmoothedReplicas != nil {
+		in, out := &in.SmoothedReplicas, &out.SmoothedReplicas
+		*out = new(float64)
+		**out = **in
 	}
-	out := new(GeneralPodAutoscalerStatus)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+	if in.NextScheduleTime != nil {
+		in, out := &in.NextScheduleTime, &out.NextScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PID != nil {
+		in, out := &in.PID, &out.PID
+		*out = new(PIDStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastScaleUpTime != nil {
+		in, out := &in.LastScaleUpTime, &out.LastScaleUpTime
+		*out = (*in).DeepCopy()
+	}
+	if in.OverriddenMinReplicas != nil {
+		in, out := &in.OverriddenMinReplicas, &out.OverriddenMinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.OverriddenMaxReplicas != nil {
+		in, out := &in.OverriddenMaxReplicas, &out.OverriddenMaxReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PeakRecommendations != nil {
+		in, out := &in.PeakRecommendations, &out.PeakRecommendations
+		*out = make([]PeakRecommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MetricBreach != nil {
+		in, out := &in.MetricBreach, &out.MetricBreach
+		*out = new(MetricBreach)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScaleHistory != nil {
+		in, out := &in.ScaleHistory, &out.ScaleHistory
+		*out = make([]ScaleRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TuningOverride != nil {
+		in, out := &in.TuningOverride, &out.TuningOverride
+		*out = new(TuningOverride)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DrivingMetric != nil {
+		in, out := &in.DrivingMetric, &out.DrivingMetric
+		*out = new(DrivingMetricStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Multiplier != nil {
+		in, out := &in.Multiplier, &out.Multiplier
+		*out = new(MultiplierStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GeneralPodAutoscalerStatus.
+func (in *GeneralPodAutoscalerStatus) DeepCopy() *GeneralPodAutoscalerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GeneralPodAutoscalerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaLagMetricSource) DeepCopyInto(out *KafkaLagMetricSource) {
+	*out = *in
+	if in.Brokers != nil {
+		in, out := &in.Brokers, &out.Brokers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Target.DeepCopyInto(&out.Target)
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(KafkaTLS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SASL != nil {
+		in, out := &in.SASL, &out.SASL
+		*out = new(KafkaSASL)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaLagMetricSource.
+func (in *KafkaLagMetricSource) DeepCopy() *KafkaLagMetricSource {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaLagMetricSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaLagMetricStatus) DeepCopyInto(out *KafkaLagMetricStatus) {
+	*out = *in
+	in.Current.DeepCopyInto(&out.Current)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaLagMetricStatus.
+func (in *KafkaLagMetricStatus) DeepCopy() *KafkaLagMetricStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaLagMetricStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaSASL) DeepCopyInto(out *KafkaSASL) {
+	*out = *in
+	in.UsernameSecretRef.DeepCopyInto(&out.UsernameSecretRef)
+	in.PasswordSecretRef.DeepCopyInto(&out.PasswordSecretRef)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaSASL.
+func (in *KafkaSASL) DeepCopy() *KafkaSASL {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaSASL)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaTLS) DeepCopyInto(out *KafkaTLS) {
+	*out = *in
+	if in.CASecretRef != nil {
+		in, out := &in.CASecretRef, &out.CASecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CertSecretRef != nil {
+		in, out := &in.CertSecretRef, &out.CertSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KeySecretRef != nil {
+		in, out := &in.KeySecretRef, &out.KeySecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTLS.
+func (in *KafkaTLS) DeepCopy() *KafkaTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricBlend) DeepCopyInto(out *MetricBlend) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricBlend.
+func (in *MetricBlend) DeepCopy() *MetricBlend {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricBlend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricBreach) DeepCopyInto(out *MetricBreach) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricBreach.
+func (in *MetricBreach) DeepCopy() *MetricBreach {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricBreach)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricBucket) DeepCopyInto(out *MetricBucket) {
+	*out = *in
+	out.From = in.From.DeepCopy()
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricBucket.
+func (in *MetricBucket) DeepCopy() *MetricBucket {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricBucket)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetricIdentifier) DeepCopyInto(out *MetricIdentifier) {
 	*out = *in
 	if in.Selector != nil {
 		in, out := &in.Selector, &out.Selector
-		*out = new(v1.LabelSelector)
+		*out = new(metav1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
 	return
@@ -424,6 +1021,21 @@ func (in *MetricMode) DeepCopyInto(out *MetricMode) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(MetricWindow)
+		**out = **in
+	}
+	if in.BreachDurationSeconds != nil {
+		in, out := &in.BreachDurationSeconds, &out.BreachDurationSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Blend != nil {
+		in, out := &in.Blend, &out.Blend
+		*out = new(MetricBlend)
+		**out = **in
+	}
 	return
 }
 
@@ -440,6 +1052,11 @@ func (in *MetricMode) DeepCopy() *MetricMode {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetricSpec) DeepCopyInto(out *MetricSpec) {
 	*out = *in
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(float64)
+		**out = **in
+	}
 	if in.Object != nil {
 		in, out := &in.Object, &out.Object
 		*out = new(ObjectMetricSource)
@@ -465,6 +1082,46 @@ func (in *MetricSpec) DeepCopyInto(out *MetricSpec) {
 		*out = new(ContainerResourceMetricSource)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Derivative != nil {
+		in, out := &in.Derivative, &out.Derivative
+		*out = new(DerivativeMetricSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Probe != nil {
+		in, out := &in.Probe, &out.Probe
+		*out = new(ProbeMetricSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KafkaLag != nil {
+		in, out := &in.KafkaLag, &out.KafkaLag
+		*out = new(KafkaLagMetricSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CounterDelta != nil {
+		in, out := &in.CounterDelta, &out.CounterDelta
+		*out = new(CounterDeltaMetricSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Ratio != nil {
+		in, out := &in.Ratio, &out.Ratio
+		*out = new(RatioMetricSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Concurrency != nil {
+		in, out := &in.Concurrency, &out.Concurrency
+		*out = new(ConcurrencyMetricSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Scrape != nil {
+		in, out := &in.Scrape, &out.Scrape
+		*out = new(ScrapeMetricSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BucketTable != nil {
+		in, out := &in.BucketTable, &out.BucketTable
+		*out = new(BucketTableMetricSource)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -506,6 +1163,46 @@ func (in *MetricStatus) DeepCopyInto(out *MetricStatus) {
 		*out = new(ContainerResourceMetricStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Derivative != nil {
+		in, out := &in.Derivative, &out.Derivative
+		*out = new(DerivativeMetricStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Probe != nil {
+		in, out := &in.Probe, &out.Probe
+		*out = new(ProbeMetricStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KafkaLag != nil {
+		in, out := &in.KafkaLag, &out.KafkaLag
+		*out = new(KafkaLagMetricStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CounterDelta != nil {
+		in, out := &in.CounterDelta, &out.CounterDelta
+		*out = new(CounterDeltaMetricStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Ratio != nil {
+		in, out := &in.Ratio, &out.Ratio
+		*out = new(RatioMetricStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Concurrency != nil {
+		in, out := &in.Concurrency, &out.Concurrency
+		*out = new(ConcurrencyMetricStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Scrape != nil {
+		in, out := &in.Scrape, &out.Scrape
+		*out = new(ScrapeMetricStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BucketTable != nil {
+		in, out := &in.BucketTable, &out.BucketTable
+		*out = new(BucketTableMetricStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -581,12 +1278,97 @@ func (in *MetricValueStatus) DeepCopy() *MetricValueStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricWindow) DeepCopyInto(out *MetricWindow) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricWindow.
+func (in *MetricWindow) DeepCopy() *MetricWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MirrorMode) DeepCopyInto(out *MirrorMode) {
+	*out = *in
+	if in.Factor != nil {
+		in, out := &in.Factor, &out.Factor
+		*out = new(float64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirrorMode.
+func (in *MirrorMode) DeepCopy() *MirrorMode {
+	if in == nil {
+		return nil
+	}
+	out := new(MirrorMode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModeScalingRules) DeepCopyInto(out *ModeScalingRules) {
+	*out = *in
+	if in.ScaleUp != nil {
+		in, out := &in.ScaleUp, &out.ScaleUp
+		*out = new(GPAScalingRules)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScaleDown != nil {
+		in, out := &in.ScaleDown, &out.ScaleDown
+		*out = new(GPAScalingRules)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModeScalingRules.
+func (in *ModeScalingRules) DeepCopy() *ModeScalingRules {
+	if in == nil {
+		return nil
+	}
+	out := new(ModeScalingRules)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultiplierStatus) DeepCopyInto(out *MultiplierStatus) {
+	*out = *in
+	in.Until.DeepCopyInto(&out.Until)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiplierStatus.
+func (in *MultiplierStatus) DeepCopy() *MultiplierStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MultiplierStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectMetricSource) DeepCopyInto(out *ObjectMetricSource) {
 	*out = *in
 	out.DescribedObject = in.DescribedObject
 	in.Target.DeepCopyInto(&out.Target)
 	in.Metric.DeepCopyInto(&out.Metric)
+	if in.WindowSeconds != nil {
+		in, out := &in.WindowSeconds, &out.WindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -619,11 +1401,103 @@ func (in *ObjectMetricStatus) DeepCopy() *ObjectMetricStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PIDController) DeepCopyInto(out *PIDController) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PIDController.
+func (in *PIDController) DeepCopy() *PIDController {
+	if in == nil {
+		return nil
+	}
+	out := new(PIDController)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PIDStatus) DeepCopyInto(out *PIDStatus) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PIDStatus.
+func (in *PIDStatus) DeepCopy() *PIDStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PIDStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeakFloor) DeepCopyInto(out *PeakFloor) {
+	*out = *in
+	if in.Percent != nil {
+		in, out := &in.Percent, &out.Percent
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeakFloor.
+func (in *PeakFloor) DeepCopy() *PeakFloor {
+	if in == nil {
+		return nil
+	}
+	out := new(PeakFloor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeakRecommendation) DeepCopyInto(out *PeakRecommendation) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeakRecommendation.
+func (in *PeakRecommendation) DeepCopy() *PeakRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(PeakRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodAgeBuffer) DeepCopyInto(out *PodAgeBuffer) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodAgeBuffer.
+func (in *PodAgeBuffer) DeepCopy() *PodAgeBuffer {
+	if in == nil {
+		return nil
+	}
+	out := new(PodAgeBuffer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodsMetricSource) DeepCopyInto(out *PodsMetricSource) {
 	*out = *in
 	in.Metric.DeepCopyInto(&out.Metric)
 	in.Target.DeepCopyInto(&out.Target)
+	if in.WindowSeconds != nil {
+		in, out := &in.WindowSeconds, &out.WindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -655,10 +1529,165 @@ func (in *PodsMetricStatus) DeepCopy() *PodsMetricStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProbeMetricSource) DeepCopyInto(out *ProbeMetricSource) {
+	*out = *in
+	out.TargetLatency = in.TargetLatency
+	if in.PeriodSeconds != nil {
+		in, out := &in.PeriodSeconds, &out.PeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.WindowSeconds != nil {
+		in, out := &in.WindowSeconds, &out.WindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TokenAuth != nil {
+		in, out := &in.TokenAuth, &out.TokenAuth
+		*out = new(ProjectedTokenAuth)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbeMetricSource.
+func (in *ProbeMetricSource) DeepCopy() *ProbeMetricSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ProbeMetricSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProbeMetricStatus) DeepCopyInto(out *ProbeMetricStatus) {
+	*out = *in
+	in.Current.DeepCopyInto(&out.Current)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbeMetricStatus.
+func (in *ProbeMetricStatus) DeepCopy() *ProbeMetricStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ProbeMetricStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectedTokenAuth) DeepCopyInto(out *ProjectedTokenAuth) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectedTokenAuth.
+func (in *ProjectedTokenAuth) DeepCopy() *ProjectedTokenAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectedTokenAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RatioMetricSource) DeepCopyInto(out *RatioMetricSource) {
+	*out = *in
+	in.Numerator.DeepCopyInto(&out.Numerator)
+	in.Denominator.DeepCopyInto(&out.Denominator)
+	in.Target.DeepCopyInto(&out.Target)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RatioMetricSource.
+func (in *RatioMetricSource) DeepCopy() *RatioMetricSource {
+	if in == nil {
+		return nil
+	}
+	out := new(RatioMetricSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RatioMetricStatus) DeepCopyInto(out *RatioMetricStatus) {
+	*out = *in
+	in.Numerator.DeepCopyInto(&out.Numerator)
+	in.Denominator.DeepCopyInto(&out.Denominator)
+	in.Current.DeepCopyInto(&out.Current)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RatioMetricStatus.
+func (in *RatioMetricStatus) DeepCopy() *RatioMetricStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RatioMetricStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadinessGapBuffer) DeepCopyInto(out *ReadinessGapBuffer) {
+	*out = *in
+	if in.GapSeconds != nil {
+		in, out := &in.GapSeconds, &out.GapSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessGapBuffer.
+func (in *ReadinessGapBuffer) DeepCopy() *ReadinessGapBuffer {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadinessGapBuffer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoverFromZero) DeepCopyInto(out *RecoverFromZero) {
+	*out = *in
+	if in.BootstrapReplicas != nil {
+		in, out := &in.BootstrapReplicas, &out.BootstrapReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoverFromZero.
+func (in *RecoverFromZero) DeepCopy() *RecoverFromZero {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoverFromZero)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceMetricSource) DeepCopyInto(out *ResourceMetricSource) {
 	*out = *in
 	in.Target.DeepCopyInto(&out.Target)
+	if in.HalfLifeSeconds != nil {
+		in, out := &in.HalfLifeSeconds, &out.HalfLifeSeconds
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -689,6 +1718,23 @@ func (in *ResourceMetricStatus) DeepCopy() *ResourceMetricStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleRecord) DeepCopyInto(out *ScaleRecord) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleRecord.
+func (in *ScaleRecord) DeepCopy() *ScaleRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScaleTriggers) DeepCopyInto(out *ScaleTriggers) {
 	*out = *in
@@ -712,6 +1758,72 @@ func (in *ScaleTriggers) DeepCopy() *ScaleTriggers {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScrapeMetricSource) DeepCopyInto(out *ScrapeMetricSource) {
+	*out = *in
+	out.Port = in.Port
+	in.Target.DeepCopyInto(&out.Target)
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScrapeMetricSource.
+func (in *ScrapeMetricSource) DeepCopy() *ScrapeMetricSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ScrapeMetricSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScrapeMetricStatus) DeepCopyInto(out *ScrapeMetricStatus) {
+	*out = *in
+	in.Current.DeepCopyInto(&out.Current)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScrapeMetricStatus.
+func (in *ScrapeMetricStatus) DeepCopy() *ScrapeMetricStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScrapeMetricStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeExceptions) DeepCopyInto(out *TimeExceptions) {
+	*out = *in
+	if in.Dates != nil {
+		in, out := &in.Dates, &out.Dates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeExceptions.
+func (in *TimeExceptions) DeepCopy() *TimeExceptions {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeExceptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TimeMode) DeepCopyInto(out *TimeMode) {
 	*out = *in
@@ -720,6 +1832,11 @@ func (in *TimeMode) DeepCopyInto(out *TimeMode) {
 		*out = make([]TimeRange, len(*in))
 		copy(*out, *in)
 	}
+	if in.Exceptions != nil {
+		in, out := &in.Exceptions, &out.Exceptions
+		*out = new(TimeExceptions)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -749,6 +1866,37 @@ func (in *TimeRange) DeepCopy() *TimeRange {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TuningOverride) DeepCopyInto(out *TuningOverride) {
+	*out = *in
+	if in.Tolerance != nil {
+		in, out := &in.Tolerance, &out.Tolerance
+		*out = new(float64)
+		**out = **in
+	}
+	if in.ScaleUpStabilizationWindowSeconds != nil {
+		in, out := &in.ScaleUpStabilizationWindowSeconds, &out.ScaleUpStabilizationWindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ScaleDownStabilizationWindowSeconds != nil {
+		in, out := &in.ScaleDownStabilizationWindowSeconds, &out.ScaleDownStabilizationWindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TuningOverride.
+func (in *TuningOverride) DeepCopy() *TuningOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(TuningOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WebhookMode) DeepCopyInto(out *WebhookMode) {
 	*out = *in
@@ -764,6 +1912,28 @@ func (in *WebhookMode) DeepCopyInto(out *WebhookMode) {
 			(*out)[key] = val
 		}
 	}
+	if in.HMACSecretRef != nil {
+		in, out := &in.HMACSecretRef, &out.HMACSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CABundleSecretRef != nil {
+		in, out := &in.CABundleSecretRef, &out.CABundleSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]WebhookMode, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SelectPolicy != nil {
+		in, out := &in.SelectPolicy, &out.SelectPolicy
+		*out = new(WebhookSelectPolicy)
+		**out = **in
+	}
 	return
 }
 
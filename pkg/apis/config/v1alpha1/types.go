@@ -50,4 +50,7 @@ type GPAControllerConfiguration struct {
 	// GPA will disregard CPU samples from unready pods that had last readiness change during that
 	// period.
 	GeneralPodAutoscalerInitialReadinessDelay metav1.Duration
+	// GeneralPodAutoscalerRequeueOnTargetChange causes the GPA controller to watch the scale targets
+	// (Deployments, StatefulSets and ReplicaSets) and reconcile the GPA once its target changed.
+	GeneralPodAutoscalerRequeueOnTargetChange bool
 }
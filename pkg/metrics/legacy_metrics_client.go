@@ -18,6 +18,7 @@ package metrics
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -207,7 +208,8 @@ func collapseTimeSamples(metrics heapster.MetricResult, duration time.Duration)
 			if metricPoint.Timestamp.Add(duration).After(newest.Timestamp) {
 				intSum += int64(metricPoint.Value)
 				intSumCount++
-				if metricPoint.FloatValue != nil {
+				// a NaN or infinite sample would turn the average into garbage
+				if metricPoint.FloatValue != nil && !math.IsNaN(*metricPoint.FloatValue) && !math.IsInf(*metricPoint.FloatValue, 0) {
 					floatSum += *metricPoint.FloatValue
 					floatSumCount++
 				}
@@ -215,6 +217,10 @@ func collapseTimeSamples(metrics heapster.MetricResult, duration time.Duration)
 		}
 
 		if newest.FloatValue != nil {
+			if floatSumCount == 0 {
+				klog.Warningf("All the samples up to %v are NaN or infinite, treat them as unavailable", newest.Timestamp)
+				return 0, time.Time{}, false
+			}
 			return int64(floatSum / float64(floatSumCount) * 1000), newest.Timestamp, true
 		} else {
 			return (intSum * 1000) / int64(intSumCount), newest.Timestamp, true
@@ -19,9 +19,11 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/client-go/util/workqueue"
 )
 
 var (
@@ -62,6 +64,15 @@ var (
 		},
 		[]string{"namespace", "scaledObject"},
 	)
+	certificateExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "general_pod_autoscaler",
+			Subsystem: "validator",
+			Name:      "certificate_expiry_timestamp_seconds",
+			Help:      "The notAfter of the certificates served by the validator as a unix timestamp",
+		},
+		[]string{"certificate"},
+	)
 )
 
 // PrometheusMetricServer the type of MetricsServer
@@ -75,6 +86,21 @@ func init() {
 	registry.MustRegister(scalerMetricsValue)
 	registry.MustRegister(scalerErrors)
 	registry.MustRegister(scaledObjectErrors)
+	registry.MustRegister(certificateExpiry)
+	registry.MustRegister(workQueueDepth, workQueueAdds, workQueueLatency, workQueueWorkDuration,
+		workQueueUnfinishedWork, workQueueLongestRunning, workQueueRetries)
+	// the queues only get the metrics of the provider if they are created after it is set
+	workqueue.SetProvider(workQueueMetricsProvider{})
+}
+
+// Handler returns the http handler serving the metrics of the registry
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RecordCertificateExpiry records the notAfter of the certificate file served by the validator
+func RecordCertificateExpiry(certFile string, notAfter time.Time) {
+	certificateExpiry.WithLabelValues(certFile).Set(float64(notAfter.Unix()))
 }
 
 // NewServer creates a new http serving instance of prometheus metrics
@@ -87,7 +113,7 @@ func (metricsServer PrometheusMetricServer) NewServer(address string, pattern st
 		}
 	})
 	log.Printf("Starting metrics server at %v", address)
-	http.Handle(pattern, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	http.Handle(pattern, Handler())
 
 	// initialize the total error metric
 	_, errscaler := scalerErrorsTotal.GetMetricWith(prometheus.Labels{})
@@ -154,18 +154,30 @@ func (c *customMetricsClient) GetRawMetric(metricName string, namespace string,
 	}
 
 	res := make(PodMetricsInfo, len(metrics.Items))
+	// a pod may have several samples, e.g. one per GPU of the pod, the average of them is used
+	sums := make(map[string]int64, len(metrics.Items))
+	counts := make(map[string]int64, len(metrics.Items))
 	for _, m := range metrics.Items {
 		window := metricServerDefaultMetricWindow
 		if m.WindowSeconds != nil {
 			window = time.Duration(*m.WindowSeconds) * time.Second
 		}
-		res[m.DescribedObject.Name] = PodMetric{
+		podName := m.DescribedObject.Name
+		value, err := QuantityMilliValue(metricName, m.Value)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		sums[podName] += value
+		counts[podName]++
+		metric := PodMetric{
 			Timestamp: m.Timestamp.Time,
 			Window:    window,
-			Value:     int64(m.Value.MilliValue()),
+			Value:     sums[podName] / counts[podName],
 		}
-
-		m.Value.MilliValue()
+		if previous, ok := res[podName]; ok && previous.Timestamp.After(metric.Timestamp) {
+			metric.Timestamp = previous.Timestamp
+		}
+		res[podName] = metric
 	}
 
 	timestamp := metrics.Items[0].Timestamp.Time
@@ -192,7 +204,11 @@ func (c *customMetricsClient) GetObjectMetric(metricName string, namespace strin
 		return 0, time.Time{}, fmt.Errorf("unable to fetch metrics from custom metrics API: %v", err)
 	}
 
-	return metricValue.Value.MilliValue(), metricValue.Timestamp.Time, nil
+	value, err := QuantityMilliValue(metricName, metricValue.Value)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return value, metricValue.Timestamp.Time, nil
 }
 
 // externalMetricsClient implements the external metrics related parts of MetricsClient,
@@ -215,7 +231,11 @@ func (c *externalMetricsClient) GetExternalMetric(metricName, namespace string,
 
 	res := make([]int64, 0)
 	for _, m := range metrics.Items {
-		res = append(res, m.Value.MilliValue())
+		value, err := QuantityMilliValue(metricName, m.Value)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		res = append(res, value)
 	}
 	timestamp := metrics.Items[0].Timestamp.Time
 	return res, timestamp, nil
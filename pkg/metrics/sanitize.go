@@ -0,0 +1,59 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog"
+)
+
+// ErrInvalidMetricValue is returned for a metric value which is NaN, infinite or beyond the range of the milli
+// values, e.g. returned by a buggy query. Such a metric is treated as unavailable instead of scaling on it.
+var ErrInvalidMetricValue = errors.New("the metric value is NaN, infinite or out of range")
+
+var (
+	minMilliQuantity = resource.NewMilliQuantity(math.MinInt64+1, resource.DecimalSI)
+	maxMilliQuantity = resource.NewMilliQuantity(math.MaxInt64-1, resource.DecimalSI)
+)
+
+// IsInvalidMetricValue returns true if the err is caused by ErrInvalidMetricValue
+func IsInvalidMetricValue(err error) bool {
+	return errors.Is(err, ErrInvalidMetricValue)
+}
+
+// MilliValue returns the milli value of v, or ErrInvalidMetricValue if v is NaN, infinite or out of range.
+func MilliValue(metricName string, v float64) (int64, error) {
+	milli := v * 1000
+	if math.IsNaN(v) || math.IsInf(v, 0) || milli <= math.MinInt64 || milli >= math.MaxInt64 {
+		klog.Warningf("Invalid value %v of metric %s, treat it as unavailable", v, metricName)
+		return 0, fmt.Errorf("invalid value %v of metric %s: %w", v, metricName, ErrInvalidMetricValue)
+	}
+	return int64(milli), nil
+}
+
+// QuantityMilliValue returns the milli value of q, or ErrInvalidMetricValue if it is out of range. A quantity can
+// not be NaN or infinite, but the adapters converting them to integers serve the limits of int64 instead, while
+// the milli value of a larger quantity would overflow.
+func QuantityMilliValue(metricName string, q resource.Quantity) (int64, error) {
+	if q.Cmp(*minMilliQuantity) < 0 || q.Cmp(*maxMilliQuantity) > 0 {
+		klog.Warningf("Invalid value %s of metric %s, treat it as unavailable", q.String(), metricName)
+		return 0, fmt.Errorf("invalid value %s of metric %s: %w", q.String(), metricName, ErrInvalidMetricValue)
+	}
+	return q.MilliValue(), nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestMilliValue(t *testing.T) {
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), 1e30} {
+		_, err := MilliValue("queue_length", v)
+		assert.True(t, IsInvalidMetricValue(err), "value %v", v)
+	}
+	milli, err := MilliValue("queue_length", 1.5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1500), milli)
+}
+
+func TestQuantityMilliValue(t *testing.T) {
+	for _, q := range []resource.Quantity{
+		// a NaN converted to an integer by the adapter
+		*resource.NewMilliQuantity(math.MinInt64, resource.DecimalSI),
+		resource.MustParse("1e30"),
+		resource.MustParse("-1e30"),
+	} {
+		_, err := QuantityMilliValue("queue_length", q)
+		assert.True(t, IsInvalidMetricValue(err), "quantity %s", q.String())
+	}
+	milli, err := QuantityMilliValue("queue_length", resource.MustParse("2.5"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2500), milli)
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"k8s.io/klog"
+)
+
+// DisabledBindAddress is the bind address disabling the metrics server
+const DisabledBindAddress = "0"
+
+// Serve serves /metrics on its own listener of address in the background until stopCh is closed. It returns the
+// address listened on, or nil if the address is DisabledBindAddress.
+func Serve(address string, stopCh <-chan struct{}) (net.Addr, error) {
+	if address == DisabledBindAddress {
+		return nil, nil
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	server := &http.Server{
+		Handler:      mux,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	go func() {
+		klog.V(1).Infof("serving metrics on %v", listener.Addr())
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			klog.Errorf("Metrics server failed: %v", err)
+		}
+	}()
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			klog.Error(err)
+		}
+	}()
+	return listener.Addr(), nil
+}
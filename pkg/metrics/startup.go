@@ -0,0 +1,96 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+)
+
+// ErrMetricsUnavailable is returned while the resource metrics API is not reachable yet
+var ErrMetricsUnavailable = errors.New("the resource metrics API is not reachable yet")
+
+// StartupBackoff is the backoff of checking the resource metrics API, it is retried every minute at most.
+var StartupBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    10,
+	Cap:      time.Minute,
+}
+
+// startupMetricsClient delegates the resource metrics to the metrics client once the resource metrics API is
+// reachable, the other metrics are always delegated
+type startupMetricsClient struct {
+	MetricsClient
+	lock      sync.RWMutex
+	available bool
+}
+
+var _ MetricsClient = &startupMetricsClient{}
+
+// NewStartupMetricsClient checks the resource metrics API is reachable by check in the background, retrying by
+// the backoff until it succeeds or stopCh is closed. Until it is reachable, the resource metrics are not queried
+// and ErrMetricsUnavailable is returned, so that the controller keeps reconciling the GPAs which do not scale on
+// resource metrics. The custom and external metrics are served by other API groups, they are queried as usual.
+func NewStartupMetricsClient(client MetricsClient, check func() error, backoff wait.Backoff,
+	stopCh <-chan struct{}) MetricsClient {
+	c := &startupMetricsClient{MetricsClient: client}
+	go func() {
+		for {
+			err := check()
+			if err == nil {
+				c.lock.Lock()
+				c.available = true
+				c.lock.Unlock()
+				klog.Info("The resource metrics API is reachable")
+				return
+			}
+			delay := backoff.Step()
+			klog.Warningf("The resource metrics API is not reachable, retry in %v: %v", delay, err)
+			select {
+			case <-time.After(delay):
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// Available returns false if the client is waiting for the resource metrics API to become reachable
+func Available(client MetricsClient) bool {
+	c, ok := client.(*startupMetricsClient)
+	if !ok {
+		return true
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.available
+}
+
+func (c *startupMetricsClient) GetResourceMetric(resource v1.ResourceName, namespace string, selector labels.Selector,
+	container string) (PodMetricsInfo, time.Time, error) {
+	if !Available(c) {
+		return nil, time.Time{}, ErrMetricsUnavailable
+	}
+	return c.MetricsClient.GetResourceMetric(resource, namespace, selector, container)
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+type staticMetricsClient struct {
+	value int64
+}
+
+func (c staticMetricsClient) GetResourceMetric(resource v1.ResourceName, namespace string, selector labels.Selector,
+	container string) (PodMetricsInfo, time.Time, error) {
+	return PodMetricsInfo{"pod": {Value: c.value}}, time.Time{}, nil
+}
+
+func (c staticMetricsClient) GetRawMetric(metricName string, namespace string, selector labels.Selector,
+	metricSelector labels.Selector) (PodMetricsInfo, time.Time, error) {
+	return PodMetricsInfo{"pod": {Value: c.value}}, time.Time{}, nil
+}
+
+func (c staticMetricsClient) GetObjectMetric(metricName string, namespace string,
+	objectRef *autoscaling.CrossVersionObjectReference, metricSelector labels.Selector) (int64, time.Time, error) {
+	return c.value, time.Time{}, nil
+}
+
+func (c staticMetricsClient) GetExternalMetric(metricName string, namespace string,
+	selector labels.Selector) ([]int64, time.Time, error) {
+	return []int64{c.value}, time.Time{}, nil
+}
+
+func TestStartupMetricsClient(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	var attempts int32
+	reachable := make(chan struct{})
+	client := NewStartupMetricsClient(staticMetricsClient{value: 500}, func() error {
+		atomic.AddInt32(&attempts, 1)
+		select {
+		case <-reachable:
+			return nil
+		default:
+			return errors.New("the server could not find the requested resource")
+		}
+	}, wait.Backoff{Duration: time.Millisecond, Factor: 2, Steps: 5, Cap: 10 * time.Millisecond}, stopCh)
+
+	// the resource metrics API is unreachable, the resource metrics are not queried
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&attempts) >= 3 }, 5*time.Second, time.Millisecond)
+	assert.False(t, Available(client))
+	_, _, err := client.GetResourceMetric(v1.ResourceCPU, "default", labels.Everything(), "")
+	assert.Equal(t, ErrMetricsUnavailable, err)
+	// the custom and external metrics APIs are not gated by it
+	external, _, err := client.GetExternalMetric("queue", "default", labels.Everything())
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{500}, external)
+	raw, _, err := client.GetRawMetric("qps", "default", labels.Everything(), labels.Everything())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500), raw["pod"].Value)
+
+	// the retry succeeds once the API is reachable
+	close(reachable)
+	assert.Eventually(t, func() bool { return Available(client) }, 5*time.Second, time.Millisecond)
+	info, _, err := client.GetResourceMetric(v1.ResourceCPU, "default", labels.Everything(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500), info["pod"].Value)
+
+	// the other clients are always available
+	assert.True(t, Available(staticMetricsClient{}))
+}
@@ -50,6 +50,35 @@ func GetResourceUtilizationRatio(metrics PodMetricsInfo, requests map[string]int
 	return float64(currentUtilization) / float64(targetUtilization), currentUtilization, metricsTotal / int64(numEntries), nil
 }
 
+// GetNormalizedResourceUtilizationRatio is GetResourceUtilizationRatio, except that the actual utilization is the
+// average of the utilization of each pod against its own request, rather than the total usage against the total
+// requests, so that the pods of differing sizes weigh the same
+func GetNormalizedResourceUtilizationRatio(metrics PodMetricsInfo, requests map[string]int64, targetUtilization int32) (utilizationRatio float64, currentUtilization int32, rawAverageValue int64, err error) {
+	metricsTotal := int64(0)
+	utilizationTotal := float64(0)
+	numEntries := 0
+
+	for podName, metric := range metrics {
+		request, hasRequest := requests[podName]
+		if !hasRequest || request == 0 {
+			// we check for missing requests elsewhere, so assuming missing requests == extraneous metrics
+			continue
+		}
+
+		metricsTotal += metric.Value
+		utilizationTotal += float64(metric.Value*100) / float64(request)
+		numEntries++
+	}
+
+	if numEntries == 0 {
+		return 0, 0, 0, fmt.Errorf("no metrics returned matched known pods")
+	}
+
+	currentUtilization = int32(utilizationTotal / float64(numEntries))
+
+	return float64(currentUtilization) / float64(targetUtilization), currentUtilization, metricsTotal / int64(numEntries), nil
+}
+
 // GetMetricUtilizationRatio takes in a set of metrics and a target utilization value,
 // and calculates the ratio of desired to actual utilization
 // (returning that and the actual utilization)
@@ -0,0 +1,117 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// the metrics of the work queues, named like the ones of the Kubernetes controllers and labeled by the queue name
+var (
+	workQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "workqueue",
+			Name:      "depth",
+			Help:      "Current depth of workqueue",
+		},
+		[]string{"name"},
+	)
+	workQueueAdds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "workqueue",
+			Name:      "adds_total",
+			Help:      "Total number of adds handled by workqueue",
+		},
+		[]string{"name"},
+	)
+	workQueueLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "workqueue",
+			Name:      "queue_duration_seconds",
+			Help:      "How long in seconds an item stays in workqueue before being requested.",
+			Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+		},
+		[]string{"name"},
+	)
+	workQueueWorkDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "workqueue",
+			Name:      "work_duration_seconds",
+			Help:      "How long in seconds processing an item from workqueue takes.",
+			Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+		},
+		[]string{"name"},
+	)
+	workQueueUnfinishedWork = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "workqueue",
+			Name:      "unfinished_work_seconds",
+			Help: "How many seconds of work has been done that is in progress and hasn't been observed by " +
+				"work_duration. Large values indicate stuck threads.",
+		},
+		[]string{"name"},
+	)
+	workQueueLongestRunning = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "workqueue",
+			Name:      "longest_running_processor_seconds",
+			Help:      "How many seconds has the longest running processor for workqueue been running.",
+		},
+		[]string{"name"},
+	)
+	workQueueRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "workqueue",
+			Name:      "retries_total",
+			Help:      "Total number of retries handled by workqueue",
+		},
+		[]string{"name"},
+	)
+)
+
+// workQueueMetricsProvider provides the metrics of the named work queues, e.g. the queue of the GPA controller,
+// from the registry
+type workQueueMetricsProvider struct{}
+
+var _ workqueue.MetricsProvider = workQueueMetricsProvider{}
+
+func (workQueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return workQueueDepth.WithLabelValues(name)
+}
+
+func (workQueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return workQueueAdds.WithLabelValues(name)
+}
+
+func (workQueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return workQueueLatency.WithLabelValues(name)
+}
+
+func (workQueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return workQueueWorkDuration.WithLabelValues(name)
+}
+
+func (workQueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workQueueUnfinishedWork.WithLabelValues(name)
+}
+
+func (workQueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workQueueLongestRunning.WithLabelValues(name)
+}
+
+func (workQueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return workQueueRetries.WithLabelValues(name)
+}
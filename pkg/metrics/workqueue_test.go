@@ -0,0 +1,76 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// queueMetric returns the metric of the family labeled with the queue name, nil if there is none
+func queueMetric(t *testing.T, family, queue string) *dto.Metric {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() != family {
+			continue
+		}
+		for _, metric := range f.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "name" && label.GetValue() == queue {
+					return metric
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func TestWorkQueueMetrics(t *testing.T) {
+	// the registry outlives the test, e.g. with -count, so the counters are compared to their values before
+	adds := queueMetric(t, "workqueue_adds_total", "test-queue").GetCounter().GetValue()
+	retries := queueMetric(t, "workqueue_retries_total", "test-queue").GetCounter().GetValue()
+	waited := queueMetric(t, "workqueue_queue_duration_seconds", "test-queue").GetHistogram().GetSampleCount()
+	worked := queueMetric(t, "workqueue_work_duration_seconds", "test-queue").GetHistogram().GetSampleCount()
+
+	// the retried item is not added back during the test
+	limiter := workqueue.NewItemExponentialFailureRateLimiter(time.Hour, time.Hour)
+	queue := workqueue.NewNamedRateLimitingQueue(limiter, "test-queue")
+	defer queue.ShutDown()
+
+	queue.Add("ns/a")
+	queue.Add("ns/b")
+	require.NotNil(t, queueMetric(t, "workqueue_depth", "test-queue"))
+	assert.Equal(t, float64(2), queueMetric(t, "workqueue_depth", "test-queue").GetGauge().GetValue())
+
+	for i := 0; i < 2; i++ {
+		item, _ := queue.Get()
+		queue.Done(item)
+	}
+	assert.Equal(t, float64(0), queueMetric(t, "workqueue_depth", "test-queue").GetGauge().GetValue())
+	queue.AddRateLimited("ns/a")
+
+	assert.Equal(t, adds+2, queueMetric(t, "workqueue_adds_total", "test-queue").GetCounter().GetValue())
+	assert.Equal(t, retries+1, queueMetric(t, "workqueue_retries_total", "test-queue").GetCounter().GetValue())
+	assert.Equal(t, waited+2,
+		queueMetric(t, "workqueue_queue_duration_seconds", "test-queue").GetHistogram().GetSampleCount())
+	assert.Equal(t, worked+2,
+		queueMetric(t, "workqueue_work_duration_seconds", "test-queue").GetHistogram().GetSampleCount())
+}
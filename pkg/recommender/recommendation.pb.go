@@ -0,0 +1,440 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: pkg/recommender/recommendation.proto
+
+package recommender
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	wrappers "github.com/golang/protobuf/ptypes/wrappers"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// GetRecommendationRequest names the GPA whose recommendation is got.
+type GetRecommendationRequest struct {
+	Namespace            string   `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetRecommendationRequest) Reset()         { *m = GetRecommendationRequest{} }
+func (m *GetRecommendationRequest) String() string { return proto.CompactTextString(m) }
+func (*GetRecommendationRequest) ProtoMessage()    {}
+func (*GetRecommendationRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_151ba6a12252ccca, []int{0}
+}
+
+func (m *GetRecommendationRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetRecommendationRequest.Unmarshal(m, b)
+}
+func (m *GetRecommendationRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetRecommendationRequest.Marshal(b, m, deterministic)
+}
+func (m *GetRecommendationRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRecommendationRequest.Merge(m, src)
+}
+func (m *GetRecommendationRequest) XXX_Size() int {
+	return xxx_messageInfo_GetRecommendationRequest.Size(m)
+}
+func (m *GetRecommendationRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRecommendationRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRecommendationRequest proto.InternalMessageInfo
+
+func (m *GetRecommendationRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *GetRecommendationRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+// GetRecommendationResponse is the last recommendation computed for a GPA along with its inputs.
+type GetRecommendationResponse struct {
+	// Timestamp is when the recommendation was computed.
+	Timestamp       *timestamp.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	CurrentReplicas int32                `protobuf:"varint,2,opt,name=current_replicas,json=currentReplicas,proto3" json:"current_replicas,omitempty"`
+	MinReplicas     int32                `protobuf:"varint,3,opt,name=min_replicas,json=minReplicas,proto3" json:"min_replicas,omitempty"`
+	MaxReplicas     int32                `protobuf:"varint,4,opt,name=max_replicas,json=maxReplicas,proto3" json:"max_replicas,omitempty"`
+	// MetricName is the metric or mode which proposed the replicas.
+	MetricName string `protobuf:"bytes,5,opt,name=metric_name,json=metricName,proto3" json:"metric_name,omitempty"`
+	// MetricStatuses are the current values of the metrics in metric mode.
+	MetricStatuses []*MetricStatus `protobuf:"bytes,6,rep,name=metric_statuses,json=metricStatuses,proto3" json:"metric_statuses,omitempty"`
+	// RecommendedReplicas are the replicas recommended after the behavior is applied.
+	RecommendedReplicas int32 `protobuf:"varint,7,opt,name=recommended_replicas,json=recommendedReplicas,proto3" json:"recommended_replicas,omitempty"`
+	// DesiredReplicas are the replicas the target is scaled to, after the capacity limit.
+	DesiredReplicas int32  `protobuf:"varint,8,opt,name=desired_replicas,json=desiredReplicas,proto3" json:"desired_replicas,omitempty"`
+	Reason          string `protobuf:"bytes,9,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Error is why the replicas could not be computed, the replicas are not set then.
+	Error                string   `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetRecommendationResponse) Reset()         { *m = GetRecommendationResponse{} }
+func (m *GetRecommendationResponse) String() string { return proto.CompactTextString(m) }
+func (*GetRecommendationResponse) ProtoMessage()    {}
+func (*GetRecommendationResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_151ba6a12252ccca, []int{1}
+}
+
+func (m *GetRecommendationResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetRecommendationResponse.Unmarshal(m, b)
+}
+func (m *GetRecommendationResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetRecommendationResponse.Marshal(b, m, deterministic)
+}
+func (m *GetRecommendationResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRecommendationResponse.Merge(m, src)
+}
+func (m *GetRecommendationResponse) XXX_Size() int {
+	return xxx_messageInfo_GetRecommendationResponse.Size(m)
+}
+func (m *GetRecommendationResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRecommendationResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRecommendationResponse proto.InternalMessageInfo
+
+func (m *GetRecommendationResponse) GetTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
+}
+
+func (m *GetRecommendationResponse) GetCurrentReplicas() int32 {
+	if m != nil {
+		return m.CurrentReplicas
+	}
+	return 0
+}
+
+func (m *GetRecommendationResponse) GetMinReplicas() int32 {
+	if m != nil {
+		return m.MinReplicas
+	}
+	return 0
+}
+
+func (m *GetRecommendationResponse) GetMaxReplicas() int32 {
+	if m != nil {
+		return m.MaxReplicas
+	}
+	return 0
+}
+
+func (m *GetRecommendationResponse) GetMetricName() string {
+	if m != nil {
+		return m.MetricName
+	}
+	return ""
+}
+
+func (m *GetRecommendationResponse) GetMetricStatuses() []*MetricStatus {
+	if m != nil {
+		return m.MetricStatuses
+	}
+	return nil
+}
+
+func (m *GetRecommendationResponse) GetRecommendedReplicas() int32 {
+	if m != nil {
+		return m.RecommendedReplicas
+	}
+	return 0
+}
+
+func (m *GetRecommendationResponse) GetDesiredReplicas() int32 {
+	if m != nil {
+		return m.DesiredReplicas
+	}
+	return 0
+}
+
+func (m *GetRecommendationResponse) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *GetRecommendationResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+// MetricStatus is the current value of a metric of the GPA.
+type MetricStatus struct {
+	// Type is the type of the metric source, e.g. Resource or External.
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// Name identifies the metric within its type, e.g. the name of the resource or of the metric, or the URL of a probe.
+	Name                 string             `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Current              *MetricValueStatus `protobuf:"bytes,3,opt,name=current,proto3" json:"current,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *MetricStatus) Reset()         { *m = MetricStatus{} }
+func (m *MetricStatus) String() string { return proto.CompactTextString(m) }
+func (*MetricStatus) ProtoMessage()    {}
+func (*MetricStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_151ba6a12252ccca, []int{2}
+}
+
+func (m *MetricStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MetricStatus.Unmarshal(m, b)
+}
+func (m *MetricStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MetricStatus.Marshal(b, m, deterministic)
+}
+func (m *MetricStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetricStatus.Merge(m, src)
+}
+func (m *MetricStatus) XXX_Size() int {
+	return xxx_messageInfo_MetricStatus.Size(m)
+}
+func (m *MetricStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetricStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetricStatus proto.InternalMessageInfo
+
+func (m *MetricStatus) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *MetricStatus) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *MetricStatus) GetCurrent() *MetricValueStatus {
+	if m != nil {
+		return m.Current
+	}
+	return nil
+}
+
+// MetricValueStatus holds the current values of a metric, the quantities are in their string form.
+type MetricValueStatus struct {
+	Value                string               `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	AverageValue         string               `protobuf:"bytes,2,opt,name=average_value,json=averageValue,proto3" json:"average_value,omitempty"`
+	AverageUtilization   *wrappers.Int32Value `protobuf:"bytes,3,opt,name=average_utilization,json=averageUtilization,proto3" json:"average_utilization,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *MetricValueStatus) Reset()         { *m = MetricValueStatus{} }
+func (m *MetricValueStatus) String() string { return proto.CompactTextString(m) }
+func (*MetricValueStatus) ProtoMessage()    {}
+func (*MetricValueStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_151ba6a12252ccca, []int{3}
+}
+
+func (m *MetricValueStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MetricValueStatus.Unmarshal(m, b)
+}
+func (m *MetricValueStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MetricValueStatus.Marshal(b, m, deterministic)
+}
+func (m *MetricValueStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetricValueStatus.Merge(m, src)
+}
+func (m *MetricValueStatus) XXX_Size() int {
+	return xxx_messageInfo_MetricValueStatus.Size(m)
+}
+func (m *MetricValueStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetricValueStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetricValueStatus proto.InternalMessageInfo
+
+func (m *MetricValueStatus) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+func (m *MetricValueStatus) GetAverageValue() string {
+	if m != nil {
+		return m.AverageValue
+	}
+	return ""
+}
+
+func (m *MetricValueStatus) GetAverageUtilization() *wrappers.Int32Value {
+	if m != nil {
+		return m.AverageUtilization
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*GetRecommendationRequest)(nil), "autoscaling.ocgi.io.v1alpha1.GetRecommendationRequest")
+	proto.RegisterType((*GetRecommendationResponse)(nil), "autoscaling.ocgi.io.v1alpha1.GetRecommendationResponse")
+	proto.RegisterType((*MetricStatus)(nil), "autoscaling.ocgi.io.v1alpha1.MetricStatus")
+	proto.RegisterType((*MetricValueStatus)(nil), "autoscaling.ocgi.io.v1alpha1.MetricValueStatus")
+}
+
+func init() {
+	proto.RegisterFile("pkg/recommender/recommendation.proto", fileDescriptor_151ba6a12252ccca)
+}
+
+var fileDescriptor_151ba6a12252ccca = []byte{
+	// 539 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0x4d, 0x6f, 0xd3, 0x40,
+	0x10, 0x95, 0x69, 0x93, 0x92, 0x49, 0xe8, 0xc7, 0xb6, 0x42, 0x26, 0x54, 0xb4, 0x04, 0x0e, 0x2d,
+	0x52, 0x6d, 0x25, 0x95, 0x00, 0x89, 0x5b, 0x85, 0x84, 0x2a, 0x15, 0x0e, 0x2e, 0x70, 0xe0, 0x12,
+	0x6d, 0x9c, 0xc1, 0x5d, 0xe1, 0xfd, 0x60, 0x77, 0x1d, 0x0a, 0x37, 0x24, 0xfe, 0x03, 0x17, 0x7e,
+	0x10, 0x3f, 0x0b, 0x65, 0xbd, 0x8e, 0x93, 0xa6, 0x54, 0x70, 0x9b, 0x79, 0xf3, 0xde, 0xf8, 0xed,
+	0xec, 0xac, 0xe1, 0xb1, 0xfa, 0x94, 0xc5, 0x1a, 0x53, 0xc9, 0x39, 0x8a, 0x31, 0xea, 0x3a, 0xa6,
+	0x96, 0x49, 0x11, 0x29, 0x2d, 0xad, 0x24, 0xbb, 0xb4, 0xb0, 0xd2, 0xa4, 0x34, 0x67, 0x22, 0x8b,
+	0x64, 0x9a, 0xb1, 0x88, 0xc9, 0x68, 0xd2, 0xa7, 0xb9, 0xba, 0xa0, 0xfd, 0xee, 0x5e, 0x26, 0x65,
+	0x96, 0x63, 0xec, 0xb8, 0xa3, 0xe2, 0x63, 0x6c, 0x19, 0x47, 0x63, 0x29, 0x57, 0xa5, 0xbc, 0xfb,
+	0xe0, 0x2a, 0xe1, 0x8b, 0xa6, 0x4a, 0xa1, 0x36, 0x65, 0xbd, 0x77, 0x06, 0xe1, 0x2b, 0xb4, 0xc9,
+	0xc2, 0x97, 0x13, 0xfc, 0x5c, 0xa0, 0xb1, 0x64, 0x17, 0x5a, 0x82, 0x72, 0x34, 0x8a, 0xa6, 0x18,
+	0x06, 0xfb, 0xc1, 0x41, 0x2b, 0xa9, 0x01, 0x42, 0x60, 0x75, 0x9a, 0x84, 0xb7, 0x5c, 0xc1, 0xc5,
+	0xbd, 0xdf, 0x2b, 0x70, 0xef, 0x9a, 0x76, 0x46, 0x49, 0x61, 0x90, 0x3c, 0x87, 0xd6, 0xcc, 0x9e,
+	0xeb, 0xd7, 0x1e, 0x74, 0xa3, 0xd2, 0x5f, 0x54, 0xf9, 0x8b, 0xde, 0x56, 0x8c, 0xa4, 0x26, 0x93,
+	0x43, 0xd8, 0x4c, 0x0b, 0xad, 0x51, 0xd8, 0xa1, 0x46, 0x95, 0xb3, 0x94, 0x1a, 0xf7, 0xdd, 0x46,
+	0xb2, 0xe1, 0xf1, 0xc4, 0xc3, 0xe4, 0x21, 0x74, 0x38, 0x13, 0x35, 0x6d, 0xc5, 0xd1, 0xda, 0x9c,
+	0x89, 0x05, 0x0a, 0xbd, 0xac, 0x29, 0xab, 0x9e, 0x42, 0x2f, 0x67, 0x94, 0x3d, 0x68, 0x73, 0xb4,
+	0x9a, 0xa5, 0x43, 0x77, 0xc6, 0x86, 0x3b, 0x23, 0x94, 0xd0, 0x1b, 0xca, 0x91, 0x9c, 0xc3, 0x86,
+	0x27, 0x18, 0x4b, 0x6d, 0x61, 0xd0, 0x84, 0xcd, 0xfd, 0x95, 0x83, 0xf6, 0xe0, 0x49, 0x74, 0xd3,
+	0x85, 0x45, 0xaf, 0x9d, 0xe8, 0xdc, 0x69, 0x92, 0x75, 0x3e, 0x97, 0xa1, 0x21, 0x7d, 0xd8, 0xa9,
+	0xf7, 0x61, 0x5c, 0x1b, 0x5c, 0x73, 0x06, 0xb7, 0xe7, 0x6a, 0x33, 0xa3, 0x87, 0xb0, 0x39, 0x46,
+	0xc3, 0xf4, 0x3c, 0xfd, 0x76, 0x39, 0x19, 0x8f, 0xcf, 0xa8, 0x77, 0xa1, 0xa9, 0x91, 0x1a, 0x29,
+	0xc2, 0x96, 0x3b, 0x8e, 0xcf, 0xc8, 0x0e, 0x34, 0x50, 0x6b, 0xa9, 0x43, 0x70, 0x70, 0x99, 0xf4,
+	0xbe, 0x07, 0xd0, 0x99, 0x37, 0x3b, 0xbd, 0x6f, 0xfb, 0x55, 0x55, 0x8b, 0xe0, 0xe2, 0xeb, 0x76,
+	0x80, 0x9c, 0xc2, 0x9a, 0xbf, 0x13, 0x37, 0xfb, 0xf6, 0x20, 0xfe, 0x97, 0x89, 0xbc, 0xa7, 0x79,
+	0x81, 0x7e, 0x2c, 0x95, 0xbe, 0xf7, 0x2b, 0x80, 0xad, 0xa5, 0xf2, 0xd4, 0xef, 0x64, 0x9a, 0x7a,
+	0x27, 0x65, 0x42, 0x1e, 0xc1, 0x1d, 0x3a, 0x41, 0x4d, 0x33, 0x1c, 0x96, 0xd5, 0xd2, 0x53, 0xc7,
+	0x83, 0xae, 0x01, 0x39, 0x83, 0xed, 0x8a, 0x54, 0x58, 0x96, 0xb3, 0x6f, 0x6e, 0x41, 0xbd, 0xcf,
+	0xfb, 0x4b, 0xbb, 0x78, 0x2a, 0xec, 0xf1, 0xc0, 0x29, 0x13, 0xe2, 0x75, 0xef, 0x6a, 0xd9, 0xe0,
+	0x67, 0x00, 0xeb, 0x8b, 0xab, 0x4e, 0x7e, 0x04, 0xb0, 0xb5, 0xf4, 0x00, 0xc8, 0xd3, 0x9b, 0x27,
+	0xf0, 0xb7, 0x07, 0xd8, 0x7d, 0xf6, 0xdf, 0xba, 0xf2, 0xa5, 0x9d, 0xbc, 0xfc, 0x70, 0x92, 0x31,
+	0x7b, 0x51, 0x8c, 0xa2, 0x54, 0xf2, 0x78, 0x2a, 0x8c, 0x33, 0x14, 0xa8, 0x69, 0x7e, 0xa4, 0xe4,
+	0xf8, 0xa8, 0xea, 0x8a, 0x3a, 0xbe, 0xf2, 0x0f, 0x7a, 0x31, 0x17, 0x8f, 0x9a, 0x6e, 0x10, 0xc7,
+	0x7f, 0x06, 0x00, 0x3f, 0xe3, 0x9f, 0xa4, 0xa9, 0x04, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// RecommendationClient is the client API for Recommendation service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type RecommendationClient interface {
+	// GetRecommendation returns the last recommendation of the GPA, NotFound if the GPA does not exist or has not been
+	// reconciled yet.
+	GetRecommendation(ctx context.Context, in *GetRecommendationRequest, opts ...grpc.CallOption) (*GetRecommendationResponse, error)
+}
+
+type recommendationClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewRecommendationClient(cc *grpc.ClientConn) RecommendationClient {
+	return &recommendationClient{cc}
+}
+
+func (c *recommendationClient) GetRecommendation(ctx context.Context, in *GetRecommendationRequest, opts ...grpc.CallOption) (*GetRecommendationResponse, error) {
+	out := new(GetRecommendationResponse)
+	err := c.cc.Invoke(ctx, "/autoscaling.ocgi.io.v1alpha1.Recommendation/GetRecommendation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RecommendationServer is the server API for Recommendation service.
+type RecommendationServer interface {
+	// GetRecommendation returns the last recommendation of the GPA, NotFound if the GPA does not exist or has not been
+	// reconciled yet.
+	GetRecommendation(context.Context, *GetRecommendationRequest) (*GetRecommendationResponse, error)
+}
+
+// UnimplementedRecommendationServer can be embedded to have forward compatible implementations.
+type UnimplementedRecommendationServer struct {
+}
+
+func (*UnimplementedRecommendationServer) GetRecommendation(ctx context.Context, req *GetRecommendationRequest) (*GetRecommendationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecommendation not implemented")
+}
+
+func RegisterRecommendationServer(s *grpc.Server, srv RecommendationServer) {
+	s.RegisterService(&_Recommendation_serviceDesc, srv)
+}
+
+func _Recommendation_GetRecommendation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRecommendationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecommendationServer).GetRecommendation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/autoscaling.ocgi.io.v1alpha1.Recommendation/GetRecommendation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecommendationServer).GetRecommendation(ctx, req.(*GetRecommendationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Recommendation_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "autoscaling.ocgi.io.v1alpha1.Recommendation",
+	HandlerType: (*RecommendationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRecommendation",
+			Handler:    _Recommendation_GetRecommendation_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/recommender/recommendation.proto",
+}
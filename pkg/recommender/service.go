@@ -0,0 +1,49 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package recommender serves the recommendations of the GPAs over gRPC, so that other tools query them
+// programmatically. The service is defined by recommendation.proto, its stubs are generated into
+// recommendation.pb.go.
+package recommender
+
+import (
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"k8s.io/klog"
+)
+
+// Serve serves the service on its own listener of address in the background until stopCh is closed, along with the
+// reflection service for clients without the .proto, e.g. grpcurl. It returns the address listened on.
+func Serve(address string, srv RecommendationServer, stopCh <-chan struct{}) (net.Addr, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	server := grpc.NewServer()
+	RegisterRecommendationServer(server, srv)
+	reflection.Register(server)
+	go func() {
+		klog.V(1).Infof("serving recommendations over gRPC on %v", listener.Addr())
+		if err := server.Serve(listener); err != nil {
+			klog.Errorf("Recommendation server failed: %v", err)
+		}
+	}()
+	go func() {
+		<-stopCh
+		server.GracefulStop()
+	}()
+	return listener.Addr(), nil
+}
@@ -14,7 +14,10 @@
 
 package requests
 
-import "k8s.io/apimachinery/pkg/types"
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+)
 
 // AutoscaleRequest defines the request to webhook autoscaler endpoint
 type AutoscaleRequest struct {
@@ -28,6 +31,26 @@ type AutoscaleRequest struct {
 	Parameters map[string]string `json:"parameters"`
 	// CurrentReplicas is the current replicas
 	CurrentReplicas int32 `json:"currentReplicas"`
+	// Metrics are the latest readings of the metrics of the GPA, empty if the GPA has no metrics or they
+	// have not been read yet
+	Metrics []MetricValue `json:"metrics,omitempty"`
+}
+
+// MetricValue is the latest reading of a metric of the GPA
+type MetricValue struct {
+	// Type is the type of the metric source, e.g. Resource, Pods, External
+	Type string `json:"type"`
+	// Name is the name of the metric, the resource name for Resource and ContainerResource, the url for Probe
+	// and the topic for KafkaLag
+	Name string `json:"name"`
+	// Container is the container of a ContainerResource metric
+	Container string `json:"container,omitempty"`
+	// Value is the current value of the metric
+	Value *resource.Quantity `json:"value,omitempty"`
+	// AverageValue is the current value of the metric averaged over the pods
+	AverageValue *resource.Quantity `json:"averageValue,omitempty"`
+	// AverageUtilization is the current utilization of the resource in percent of the requests
+	AverageUtilization *int32 `json:"averageUtilization,omitempty"`
 }
 
 // AutoscaleResponse defines the response of webhook server
@@ -37,7 +60,8 @@ type AutoscaleResponse struct {
 	UID types.UID `json:"uid"`
 	// Set to false if should not do scaling
 	Scale bool `json:"scale"`
-	// Replicas is targeted replica count from the webhookServer
+	// Replicas is targeted replica count from the webhookServer, it applies to the single scale target of the
+	// GPA named in the request
 	Replicas int32 `json:"replicas"`
 }
 
@@ -0,0 +1,128 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"math"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// computeReplicasForBlend combines the normalized utilizations of the metrics which are fetched successfully by
+// the blend of the metric mode, and multiplies the current replicas by the blended utilization unless it is
+// within the tolerance. The utilization of a metric is its current value divided by its target, or its proposal
+// divided by the current replicas for the sources without a comparable target, e.g. the probes.
+func (a *DecisionEngine) computeReplicasForBlend(gpa *autoscaling.GeneralPodAutoscaler, currentReplicas int32,
+	metricSpecs []autoscaling.MetricSpec, statuses []autoscaling.MetricStatus, valid []bool, proposals []int32,
+	names []string) (int32, string) {
+	function := gpa.Spec.MetricMode.Blend.Function
+	if function == "" {
+		function = autoscaling.MaxMetricBlend
+	}
+	var blended, weights float64
+	dominant := ""
+	for i, metricSpec := range metricSpecs {
+		if !valid[i] {
+			continue
+		}
+		utilization, ok := metricUtilization(metricSpec, statuses[i])
+		if !ok {
+			utilization = float64(proposals[i]) / float64(currentReplicas)
+		}
+		weight := 1.0
+		if metricSpec.Weight != nil {
+			weight = *metricSpec.Weight
+		}
+		decisionLog(gpa, 4).Infof("GPA %s/%s %s has the normalized utilization %.3f with weight %v",
+			gpa.Namespace, gpa.Name, names[i], utilization, weight)
+		switch function {
+		case autoscaling.AverageMetricBlend:
+			blended += weight * utilization
+			weights += weight
+		default:
+			if dominant == "" || weight*utilization > blended {
+				blended = weight * utilization
+				dominant = names[i]
+			}
+		}
+	}
+	metric := fmt.Sprintf("%s blend dominated by %s", function, dominant)
+	if function == autoscaling.AverageMetricBlend {
+		if weights > 0 {
+			blended /= weights
+		}
+		metric = fmt.Sprintf("%s blend of the metrics", function)
+	}
+	decisionLog(gpa, 4).Infof("GPA %s/%s metrics blend to the utilization %.3f by %s",
+		gpa.Namespace, gpa.Name, blended, function)
+	if math.Abs(1.0-blended) <= a.replicaCalc.tolerance {
+		return currentReplicas, metric
+	}
+	return int32(math.Ceil(blended * float64(currentReplicas))), metric
+}
+
+// metricUtilization returns the current value of the metric divided by its target of the same type
+func metricUtilization(metricSpec autoscaling.MetricSpec, status autoscaling.MetricStatus) (float64, bool) {
+	target := metricSpecTarget(metricSpec)
+	current := metricStatusCurrent(status)
+	if target == nil || current == nil {
+		return 0, false
+	}
+	switch target.Type {
+	case autoscaling.UtilizationMetricType:
+		if target.AverageUtilization != nil && *target.AverageUtilization > 0 && current.AverageUtilization != nil {
+			return float64(*current.AverageUtilization) / float64(*target.AverageUtilization), true
+		}
+	case autoscaling.AverageValueMetricType:
+		if target.AverageValue != nil && target.AverageValue.MilliValue() > 0 && current.AverageValue != nil {
+			return float64(current.AverageValue.MilliValue()) / float64(target.AverageValue.MilliValue()), true
+		}
+	case autoscaling.ValueMetricType:
+		if target.Value != nil && target.Value.MilliValue() > 0 && current.Value != nil {
+			return float64(current.Value.MilliValue()) / float64(target.Value.MilliValue()), true
+		}
+	}
+	return 0, false
+}
+
+// metricSpecTarget returns the target of the metric source, or nil if the source has none
+func metricSpecTarget(metricSpec autoscaling.MetricSpec) *autoscaling.MetricTarget {
+	switch {
+	case metricSpec.Object != nil:
+		return &metricSpec.Object.Target
+	case metricSpec.Pods != nil:
+		return &metricSpec.Pods.Target
+	case metricSpec.Resource != nil:
+		return &metricSpec.Resource.Target
+	case metricSpec.ContainerResource != nil:
+		return &metricSpec.ContainerResource.Target
+	case metricSpec.External != nil:
+		return &metricSpec.External.Target
+	case metricSpec.Derivative != nil:
+		return &metricSpec.Derivative.Target
+	case metricSpec.KafkaLag != nil:
+		return &metricSpec.KafkaLag.Target
+	case metricSpec.CounterDelta != nil:
+		return &metricSpec.CounterDelta.Target
+	case metricSpec.Ratio != nil:
+		return &metricSpec.Ratio.Target
+	case metricSpec.Concurrency != nil:
+		return &metricSpec.Concurrency.Target
+	case metricSpec.Scrape != nil:
+		return &metricSpec.Scrape.Target
+	}
+	return nil
+}
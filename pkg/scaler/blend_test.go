@@ -0,0 +1,96 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func TestMetricUtilization(t *testing.T) {
+	utilization := int32(70)
+	currentUtilization := int32(105)
+	for _, c := range []struct {
+		name     string
+		spec     autoscalingv1alpha1.MetricSpec
+		status   autoscalingv1alpha1.MetricStatus
+		expected float64
+		ok       bool
+	}{
+		{
+			name: "utilization",
+			spec: autoscalingv1alpha1.MetricSpec{Resource: &autoscalingv1alpha1.ResourceMetricSource{
+				Target: autoscalingv1alpha1.MetricTarget{Type: autoscalingv1alpha1.UtilizationMetricType, AverageUtilization: &utilization},
+			}},
+			status: autoscalingv1alpha1.MetricStatus{Resource: &autoscalingv1alpha1.ResourceMetricStatus{
+				Current: autoscalingv1alpha1.MetricValueStatus{AverageUtilization: &currentUtilization},
+			}},
+			expected: 1.5,
+			ok:       true,
+		},
+		{
+			name: "average value",
+			spec: autoscalingv1alpha1.MetricSpec{Pods: &autoscalingv1alpha1.PodsMetricSource{
+				Target: autoscalingv1alpha1.MetricTarget{Type: autoscalingv1alpha1.AverageValueMetricType,
+					AverageValue: resource.NewQuantity(100, resource.DecimalSI)},
+			}},
+			status: autoscalingv1alpha1.MetricStatus{Pods: &autoscalingv1alpha1.PodsMetricStatus{
+				Current: autoscalingv1alpha1.MetricValueStatus{AverageValue: resource.NewQuantity(25, resource.DecimalSI)},
+			}},
+			expected: 0.25,
+			ok:       true,
+		},
+		{
+			name: "value",
+			spec: autoscalingv1alpha1.MetricSpec{External: &autoscalingv1alpha1.ExternalMetricSource{
+				Target: autoscalingv1alpha1.MetricTarget{Type: autoscalingv1alpha1.ValueMetricType,
+					Value: resource.NewQuantity(10, resource.DecimalSI)},
+			}},
+			status: autoscalingv1alpha1.MetricStatus{External: &autoscalingv1alpha1.ExternalMetricStatus{
+				Current: autoscalingv1alpha1.MetricValueStatus{Value: resource.NewQuantity(30, resource.DecimalSI)},
+			}},
+			expected: 3,
+			ok:       true,
+		},
+		{
+			name: "current of another type",
+			spec: autoscalingv1alpha1.MetricSpec{External: &autoscalingv1alpha1.ExternalMetricSource{
+				Target: autoscalingv1alpha1.MetricTarget{Type: autoscalingv1alpha1.ValueMetricType,
+					Value: resource.NewQuantity(10, resource.DecimalSI)},
+			}},
+			status: autoscalingv1alpha1.MetricStatus{External: &autoscalingv1alpha1.ExternalMetricStatus{
+				Current: autoscalingv1alpha1.MetricValueStatus{AverageValue: resource.NewQuantity(30, resource.DecimalSI)},
+			}},
+		},
+		{
+			name: "source without a target",
+			spec: autoscalingv1alpha1.MetricSpec{Probe: &autoscalingv1alpha1.ProbeMetricSource{}},
+			status: autoscalingv1alpha1.MetricStatus{Probe: &autoscalingv1alpha1.ProbeMetricStatus{
+				Current: autoscalingv1alpha1.MetricValueStatus{Value: resource.NewQuantity(30, resource.DecimalSI)},
+			}},
+		},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			utilization, ok := metricUtilization(c.spec, c.status)
+			assert.Equal(t, c.ok, ok)
+			assert.InDelta(t, c.expected, utilization, 1e-9)
+		})
+	}
+}
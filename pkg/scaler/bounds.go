@@ -0,0 +1,98 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+const (
+	// overrideMinKey overrides spec.minReplicas of a GPA while it is set
+	overrideMinKey = "autoscaling.ocgi.io/override-min"
+	// overrideMaxKey overrides spec.maxReplicas of a GPA while it is set
+	overrideMaxKey = "autoscaling.ocgi.io/override-max"
+)
+
+// parseOverride returns the replicas of the override annotation, nil if it is not set
+func parseOverride(gpa *autoscaling.GeneralPodAutoscaler, key string) (*int32, error) {
+	value, ok := gpa.Annotations[key]
+	if !ok {
+		return nil, nil
+	}
+	replicas, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || replicas < 0 {
+		return nil, fmt.Errorf("invalid %s %q, must be a non-negative integer", key, value)
+	}
+	override := int32(replicas)
+	return &override, nil
+}
+
+// applyBoundsOverride replaces the min and max replicas of the spec by the override-min and override-max
+// annotations, so that they can be changed during incidents without editing the spec. The overrides are
+// reported in the status and by the BoundsOverridden condition, the spec bounds apply again once the
+// annotations are removed. Invalid overrides are ignored altogether.
+func (a *DecisionEngine) applyBoundsOverride(gpa *autoscaling.GeneralPodAutoscaler) {
+	gpa.Status.OverriddenMinReplicas = nil
+	gpa.Status.OverriddenMaxReplicas = nil
+	minOverride, err := parseOverride(gpa, overrideMinKey)
+	var maxOverride *int32
+	if err == nil {
+		maxOverride, err = parseOverride(gpa, overrideMaxKey)
+	}
+	if err == nil {
+		minReplicas, maxReplicas := getMinReplicas(gpa), gpa.Spec.MaxReplicas
+		if minOverride != nil {
+			minReplicas = *minOverride
+		}
+		if maxOverride != nil {
+			maxReplicas = *maxOverride
+		}
+		if maxReplicas < 1 || minReplicas > maxReplicas {
+			err = fmt.Errorf("overridden min replicas %d and max replicas %d are invalid", minReplicas, maxReplicas)
+		}
+	}
+	if err != nil {
+		klog.Warningf("Ignore the bounds override of gpa %s/%s: %v", gpa.Namespace, gpa.Name, err)
+		a.eventRecorder.Event(gpa, v1.EventTypeWarning, "InvalidBoundsOverride", err.Error())
+		setCondition(gpa, autoscaling.BoundsOverridden, v1.ConditionFalse, "InvalidOverride",
+			"the bounds override is ignored: %v", err)
+		return
+	}
+	if minOverride == nil && maxOverride == nil {
+		// only reported once the bounds were overridden, to tell that they are restored
+		if getCondition(gpa.Status.Conditions, autoscaling.BoundsOverridden) != nil {
+			setCondition(gpa, autoscaling.BoundsOverridden, v1.ConditionFalse, "NoOverride",
+				"the min and max replicas of the spec apply")
+		}
+		return
+	}
+	if minOverride != nil {
+		gpa.Spec.MinReplicas = minOverride
+		gpa.Status.OverriddenMinReplicas = minOverride
+	}
+	if maxOverride != nil {
+		gpa.Spec.MaxReplicas = *maxOverride
+		gpa.Status.OverriddenMaxReplicas = maxOverride
+	}
+	setCondition(gpa, autoscaling.BoundsOverridden, v1.ConditionTrue, "OverriddenByAnnotations",
+		"the replicas are bounded to [%d, %d] by the %s and %s annotations",
+		getMinReplicas(gpa), gpa.Spec.MaxReplicas, overrideMinKey, overrideMaxKey)
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// holdUntilBreached keeps the current replicas until the metrics have recommended a scale in the same direction
+// for spec.metric.breachDurationSeconds, the start of the breach is recorded in status.metricBreach. The breach
+// is dropped once the metrics recommend the current replicas, i.e. they are within the tolerance, and restarted
+// once they recommend the other direction.
+func holdUntilBreached(gpa *autoscaling.GeneralPodAutoscaler, currentReplicas, proposal int32, now time.Time) int32 {
+	if gpa.Spec.MetricMode == nil || gpa.Spec.MetricMode.BreachDurationSeconds == nil || proposal == currentReplicas {
+		gpa.Status.MetricBreach = nil
+		return proposal
+	}
+	direction := autoscaling.MetricBreachUp
+	if proposal < currentReplicas {
+		direction = autoscaling.MetricBreachDown
+	}
+	if gpa.Status.MetricBreach == nil || gpa.Status.MetricBreach.Direction != direction {
+		gpa.Status.MetricBreach = &autoscaling.MetricBreach{Direction: direction, StartTime: metav1.NewTime(now)}
+	}
+	duration := time.Duration(*gpa.Spec.MetricMode.BreachDurationSeconds) * time.Second
+	remaining := gpa.Status.MetricBreach.StartTime.Add(duration).Sub(now)
+	if remaining <= 0 {
+		return proposal
+	}
+	setCondition(gpa, autoscaling.AbleToScale, v1.ConditionTrue, "BreachDurationPending",
+		"the metrics recommend %d replicas, the target is scaled once they keep recommending it for %v",
+		proposal, remaining.Round(time.Second))
+	decisionLog(gpa, 4).Infof("GPA %s/%s: recommendation %d held at %d replicas for %v until the breach lasts %v",
+		gpa.Namespace, gpa.Name, proposal, currentReplicas, remaining, duration)
+	return currentReplicas
+}
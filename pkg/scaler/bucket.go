@@ -0,0 +1,88 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// selectBucket returns the index of the bucket containing the value (as a milli-value), false if the value is
+// below the first bucket or beyond the last one
+func selectBucket(buckets []autoscaling.MetricBucket, value int64) (int, bool) {
+	for i, bucket := range buckets {
+		if value < bucket.From.MilliValue() {
+			continue
+		}
+		if bucket.To == nil || value < bucket.To.MilliValue() {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// computeStatusForBucketTableMetric computes the desired number of replicas for the specified metric of type
+// BucketTableMetricSourceType, by proposing the replicas of the bucket containing the sum of the values of the
+// external metric. A value outside of the buckets fails the metric.
+func (a *DecisionEngine) computeStatusForBucketTableMetric(metricSpec autoscaling.MetricSpec,
+	gpa *autoscaling.GeneralPodAutoscaler, status *autoscaling.MetricStatus) (replicaCountProposal int32,
+	timestampProposal time.Time, metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
+	src := metricSpec.BucketTable
+	metricSelector, err := metav1.LabelSelectorAsSelector(src.Metric.Selector)
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetBucketTableMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get bucket table metric %s: %v", src.Metric.Name, err)
+	}
+	metrics, timestamp, err := a.replicaCalc.metricsClient.GetExternalMetric(src.Metric.Name, gpa.Namespace, metricSelector)
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetBucketTableMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get bucket table metric %s: %v", src.Metric.Name, err)
+	}
+	value := int64(0)
+	for _, val := range metrics {
+		value = value + val
+	}
+	current := resource.NewMilliQuantity(value, resource.DecimalSI)
+	bucket, ok := selectBucket(src.Buckets, value)
+	if !ok {
+		err = fmt.Errorf("value %s of bucket table metric %s is outside of the buckets", current.String(), src.Metric.Name)
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetBucketTableMetric", err)
+		return 0, time.Time{}, "", condition, err
+	}
+	if timestamp.IsZero() {
+		timestamp = a.clock.Now()
+	}
+	metricNameProposal = fmt.Sprintf("bucket table metric %s(%+v)", src.Metric.Name, src.Metric.Selector)
+	replicaCountProposal = src.Buckets[bucket].Replicas
+	decisionLog(gpa, 4).Infof("GPA %s/%s %s: %s in bucket %d, replicas: %d",
+		gpa.Namespace, gpa.Name, metricNameProposal, current.String(), bucket, replicaCountProposal)
+	*status = autoscaling.MetricStatus{
+		Type: autoscaling.BucketTableMetricSourceType,
+		BucketTable: &autoscaling.BucketTableMetricStatus{
+			Metric: autoscaling.MetricIdentifier{
+				Name:     src.Metric.Name,
+				Selector: src.Metric.Selector,
+			},
+			Current: autoscaling.MetricValueStatus{Value: current},
+			Bucket:  int32(bucket),
+		},
+	}
+	return replicaCountProposal, timestamp, metricNameProposal, autoscaling.GeneralPodAutoscalerCondition{}, nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"math"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/klog"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// maxCapacityPercentKey overrides the percent of the cluster capacity the target of a GPA may request
+const maxCapacityPercentKey = "autoscaling.ocgi.io/max-capacity-percent"
+
+// SetCapacityLimit caps the desired replicas of the GPAs to the replicas whose requests fit in the percent of the
+// allocatable resources of the ready and schedulable nodes, the percent can be overridden by the
+// max-capacity-percent annotation of a GPA. It must be called before the informer is started.
+func (a *GeneralController) SetCapacityLimit(nodeInformer coreinformers.NodeInformer, percent int32) {
+	a.SetClusterNodeLister(nodeInformer.Lister())
+	a.nodeListerSynced = nodeInformer.Informer().HasSynced
+	a.SetMaxCapacityPercent(percent)
+}
+
+// capacityPercent returns the percent of the cluster capacity the target of the GPA may request, 0 if not limited
+func (a *DecisionEngine) capacityPercent(gpa *autoscaling.GeneralPodAutoscaler) int32 {
+	value, ok := gpa.Annotations[maxCapacityPercentKey]
+	if !ok {
+		return a.maxCapacityPercent
+	}
+	percent, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || percent < 0 || percent > 100 {
+		klog.Warningf("Ignore invalid %s %q of gpa %s/%s", maxCapacityPercentKey, value, gpa.Namespace, gpa.Name)
+		return a.maxCapacityPercent
+	}
+	return int32(percent)
+}
+
+// limitByCapacity caps a scale up of the target to the replicas fitting in the cluster capacity, and sets the
+// ScalingLimited condition if the desired replicas are capped. The current replicas are never scaled down by it.
+func (a *DecisionEngine) limitByCapacity(gpa *autoscaling.GeneralPodAutoscaler, selector string,
+	currentReplicas, desiredReplicas int32) int32 {
+	if a.clusterNodeLister == nil || desiredReplicas <= currentReplicas {
+		return desiredReplicas
+	}
+	percent := a.capacityPercent(gpa)
+	if percent <= 0 {
+		return desiredReplicas
+	}
+	podSelector, err := labels.Parse(selector)
+	if err != nil {
+		klog.Warningf("Parse selector of gpa %s/%s failed, ignore capacity limit: %v", gpa.Namespace, gpa.Name, err)
+		return desiredReplicas
+	}
+	pods, err := a.replicaCalc.podLister.Pods(gpa.Namespace).List(podSelector)
+	if err != nil {
+		klog.Warningf("List pods of gpa %s/%s failed, ignore capacity limit: %v", gpa.Namespace, gpa.Name, err)
+		return desiredReplicas
+	}
+	nodes, err := a.clusterNodeLister.List(labels.Everything())
+	if err != nil {
+		klog.Warningf("List nodes failed, ignore capacity limit of gpa %s/%s: %v", gpa.Namespace, gpa.Name, err)
+		return desiredReplicas
+	}
+	limit, ok := capacityReplicas(nodes, pods, percent)
+	if !ok || desiredReplicas <= limit {
+		return desiredReplicas
+	}
+	decisionLog(gpa, 2).Infof("Desired replicas %d of gpa %s/%s are capped to %d by %d%% of the cluster capacity",
+		desiredReplicas, gpa.Namespace, gpa.Name, limit, percent)
+	setCondition(gpa, autoscaling.ScalingLimited, v1.ConditionTrue, "CapacityLimited",
+		"the desired replica count %d is more than the %d replicas fitting in %d%% of the cluster capacity",
+		desiredReplicas, limit, percent)
+	return max(limit, currentReplicas)
+}
+
+// capacityReplicas estimates the replicas fitting in the percent of the allocatable resources of the ready and
+// schedulable nodes, by the average requests of the pods. The usage of other pods is not taken into account.
+// It returns false if the replicas can not be estimated, e.g. the pods request nothing.
+func capacityReplicas(nodes []*v1.Node, pods []*v1.Pod, percent int32) (int32, bool) {
+	requests := map[v1.ResourceName]float64{}
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			for name, quantity := range container.Resources.Requests {
+				requests[name] += float64(quantity.MilliValue())
+			}
+		}
+	}
+	if len(requests) == 0 {
+		return 0, false
+	}
+	allocatable := map[v1.ResourceName]float64{}
+	for _, node := range nodes {
+		if node.Spec.Unschedulable || !isNodeReady(node) {
+			continue
+		}
+		for name := range requests {
+			if quantity, ok := node.Status.Allocatable[name]; ok {
+				allocatable[name] += float64(quantity.MilliValue())
+			}
+		}
+	}
+	limit := math.MaxFloat64
+	for name, total := range requests {
+		if total <= 0 {
+			continue
+		}
+		perPod := total / float64(len(pods))
+		limit = math.Min(limit, math.Floor(allocatable[name]*float64(percent)/100/perPod))
+	}
+	if limit == math.MaxFloat64 {
+		return 0, false
+	}
+	return int32(math.Min(limit, math.MaxInt32)), true
+}
+
+// isNodeReady returns true if the Ready condition of the node is true
+func isNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func capacityNode(name, cpu, memory string, ready, unschedulable bool) *v1.Node {
+	status := v1.ConditionTrue
+	if !ready {
+		status = v1.ConditionFalse
+	}
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       v1.NodeSpec{Unschedulable: unschedulable},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse(cpu),
+				v1.ResourceMemory: resource.MustParse(memory),
+			},
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: status}},
+		},
+	}
+}
+
+func capacityPod(name string, requests v1.ResourceList) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "web"}},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "container", Resources: v1.ResourceRequirements{Requests: requests}}},
+		},
+	}
+}
+
+func TestCapacityReplicas(t *testing.T) {
+	nodes := []*v1.Node{
+		capacityNode("node-0", "8", "32Gi", true, false),
+		capacityNode("node-1", "8", "32Gi", true, false),
+		// neither of them are counted
+		capacityNode("node-2", "8", "32Gi", false, false),
+		capacityNode("node-3", "8", "32Gi", true, true),
+	}
+	cpuPods := []*v1.Pod{
+		capacityPod("web-0", v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}),
+		capacityPod("web-1", v1.ResourceList{v1.ResourceCPU: resource.MustParse("3")}),
+	}
+	for _, c := range []struct {
+		name     string
+		pods     []*v1.Pod
+		percent  int32
+		expected int32
+		limited  bool
+	}{
+		{
+			name:     "average cpu requests",
+			pods:     cpuPods,
+			percent:  50,
+			expected: 4,
+			limited:  true,
+		},
+		{
+			name: "the scarcest resource limits",
+			pods: []*v1.Pod{capacityPod("web-0", v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("500m"),
+				v1.ResourceMemory: resource.MustParse("8Gi"),
+			})},
+			percent:  100,
+			expected: 8,
+			limited:  true,
+		},
+		{
+			name:    "no requests",
+			pods:    []*v1.Pod{capacityPod("web-0", nil)},
+			percent: 100,
+		},
+		{
+			name:    "no pods",
+			percent: 100,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			replicas, limited := capacityReplicas(nodes, c.pods, c.percent)
+			assert.Equal(t, c.limited, limited)
+			assert.Equal(t, c.expected, replicas)
+		})
+	}
+}
+
+func TestLimitByCapacity(t *testing.T) {
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for i := 0; i < 2; i++ {
+		pods.Add(capacityPod(fmt.Sprintf("web-%d", i), v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}))
+	}
+	nodes := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	nodes.Add(capacityNode("node-0", "4", "16Gi", true, false))
+	nodes.Add(capacityNode("node-1", "4", "16Gi", true, false))
+	controller := &DecisionEngine{
+		replicaCalc:        &ReplicaCalculator{podLister: corelisters.NewPodLister(pods)},
+		clusterNodeLister:  corelisters.NewNodeLister(nodes),
+		maxCapacityPercent: 100,
+	}
+	gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+	}
+
+	// 8 cores fit 4 pods requesting 2 cores
+	assert.Equal(t, int32(4), controller.limitByCapacity(gpa, "app=web", 2, 10))
+	condition := gpa.Status.Conditions[0]
+	assert.Equal(t, autoscalingv1alpha1.ScalingLimited, condition.Type)
+	assert.Equal(t, v1.ConditionTrue, condition.Status)
+	assert.Equal(t, "CapacityLimited", condition.Reason)
+
+	// the annotation overrides the percent, but the current replicas are not scaled down
+	gpa.Annotations = map[string]string{maxCapacityPercentKey: "25"}
+	assert.Equal(t, int32(2), controller.limitByCapacity(gpa, "app=web", 2, 10))
+	// scale downs are not limited
+	assert.Equal(t, int32(1), controller.limitByCapacity(gpa, "app=web", 2, 1))
+	// 0 disables the limit
+	gpa.Annotations[maxCapacityPercentKey] = "0"
+	assert.Equal(t, int32(10), controller.limitByCapacity(gpa, "app=web", 2, 10))
+}
@@ -0,0 +1,52 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	v1 "k8s.io/api/core/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// actOnFirstReconcileKey acts on the recommendation of the first reconcile of a GPA even if the controller only
+// observes the first reconciles, e.g. for a GPA whose target must follow the load right after a restart
+const actOnFirstReconcileKey = "autoscaling.ocgi.io/act-on-first-reconcile"
+
+// SetObserveFirstReconcile sets whether the first reconcile of each GPA since the controller started only observes:
+// the recommendation is computed and recorded to seed the stabilization and the smoothing, while the target is not
+// scaled until the next reconcile. The replicas out of the min and max replicas are still corrected.
+func (a *GeneralController) SetObserveFirstReconcile(enabled bool) {
+	a.observeFirstReconcile = enabled
+}
+
+// observingFirstReconcile returns true if the reconcile only observes the recommendation, i.e. it is the first
+// reconcile of the GPA since the controller started and the GPA is not annotated with actOnFirstReconcileKey.
+func (a *GeneralController) observingFirstReconcile(gpa *autoscaling.GeneralPodAutoscaler, key string,
+	recommendation int32) bool {
+	if !a.observeFirstReconcile || a.reconciled[key] {
+		return false
+	}
+	if a.reconciled == nil {
+		a.reconciled = map[string]bool{}
+	}
+	a.reconciled[key] = true
+	if gpa.Annotations[actOnFirstReconcileKey] == "true" {
+		return false
+	}
+	setCondition(gpa, autoscaling.AbleToScale, v1.ConditionTrue, "ObservingFirstReconcile",
+		"the GPA is reconciled for the first time since the controller started, the recommendation of %d replicas "+
+			"is applied from the next reconcile", recommendation)
+	return true
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	metricsclient "github.com/ocgi/general-pod-autoscaler/pkg/metrics"
+)
+
+// concurrencyReplicas returns the replicas holding the in-flight requests of the ready pods at the target per pod
+// (as a milli-value), the in-flight requests of the ready pods (as a milli-value) and the number of the ready pods
+// reporting them. The unready pods are left out, the mesh sends them no new requests, and the replicas are kept if
+// the requests per ready pod are within the tolerance of the target.
+func concurrencyReplicas(metrics metricsclient.PodMetricsInfo, readyPods sets.String, currentReplicas int32,
+	targetMilli int64, tolerance float64) (int32, int64, int32, error) {
+	var total int64
+	var ready int32
+	for pod, metric := range metrics {
+		if !readyPods.Has(pod) {
+			continue
+		}
+		total += metric.Value
+		ready++
+	}
+	if ready == 0 {
+		return 0, 0, 0, fmt.Errorf("no ready pods report the in-flight requests")
+	}
+	usageRatio := float64(total) / (float64(targetMilli) * float64(ready))
+	if math.Abs(1.0-usageRatio) <= tolerance {
+		return currentReplicas, total, ready, nil
+	}
+	return int32(math.Ceil(float64(total) / float64(targetMilli))), total, ready, nil
+}
+
+// readyPodNames returns the names of the running and ready pods matching the selector, the pods being deleted are
+// not ready.
+func (c *ReplicaCalculator) readyPodNames(namespace string, selector labels.Selector) (sets.String, error) {
+	podList, err := c.podLister.Pods(namespace).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get pods while calculating replica count: %v", err)
+	}
+	ready := sets.NewString()
+	for _, pod := range podList {
+		if pod.DeletionTimestamp == nil && pod.Status.Phase == v1.PodRunning && IsPodReady(pod) {
+			ready.Insert(pod.Name)
+		}
+	}
+	return ready, nil
+}
+
+// computeStatusForConcurrencyMetric computes the desired number of replicas for the specified metric of type
+// ConcurrencyMetricSourceType, by holding the in-flight requests of the ready pods at the target per pod.
+func (a *DecisionEngine) computeStatusForConcurrencyMetric(currentReplicas int32, metricSpec autoscaling.MetricSpec,
+	gpa *autoscaling.GeneralPodAutoscaler, selector labels.Selector, status *autoscaling.MetricStatus) (replicaCountProposal int32,
+	timestampProposal time.Time, metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
+	src := metricSpec.Concurrency
+	if src.Target.AverageValue == nil || src.Target.AverageValue.MilliValue() <= 0 {
+		err = fmt.Errorf("invalid concurrency metric source: the target average value must be greater than 0")
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetConcurrencyMetric", err)
+		return 0, time.Time{}, "", condition, err
+	}
+	metricNameProposal = fmt.Sprintf("in-flight requests of pods metric %s", src.Metric.Name)
+	metricSelector, err := metav1.LabelSelectorAsSelector(src.Metric.Selector)
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetConcurrencyMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get %s: %v", metricNameProposal, err)
+	}
+	metrics, timestampProposal, err := a.replicaCalc.metricsClient.GetRawMetric(src.Metric.Name, gpa.Namespace, selector, metricSelector)
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetConcurrencyMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get %s: %v", metricNameProposal, err)
+	}
+	readyPods, err := a.replicaCalc.readyPodNames(gpa.Namespace, selector)
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetConcurrencyMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get %s: %v", metricNameProposal, err)
+	}
+	targetMilli := src.Target.AverageValue.MilliValue()
+	replicaCountProposal, total, ready, err := concurrencyReplicas(metrics, readyPods, currentReplicas, targetMilli,
+		a.replicaCalc.tolerance)
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetConcurrencyMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get %s: %v", metricNameProposal, err)
+	}
+	average := int64(math.Ceil(float64(total) / float64(ready)))
+	decisionLog(gpa, 4).Infof("GPA %s/%s %s: %dm over %d ready pods, %dm per pod, target: %dm",
+		gpa.Namespace, gpa.Name, metricNameProposal, total, ready, average, targetMilli)
+	*status = autoscaling.MetricStatus{
+		Type: autoscaling.ConcurrencyMetricSourceType,
+		Concurrency: &autoscaling.ConcurrencyMetricStatus{
+			Metric: src.Metric,
+			Current: autoscaling.MetricValueStatus{
+				Value:        resource.NewMilliQuantity(total, resource.DecimalSI),
+				AverageValue: resource.NewMilliQuantity(average, resource.DecimalSI),
+			},
+			ReadyPods: ready,
+		},
+	}
+	return replicaCountProposal, timestampProposal, metricNameProposal, autoscaling.GeneralPodAutoscalerCondition{}, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestConcurrencyReplicas(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		requests map[string]int64
+		ready    []string
+		replicas int32
+		total    int64
+		pods     int32
+		err      bool
+	}{
+		{
+			name:     "requests above the target",
+			requests: map[string]int64{"a": 20, "b": 16},
+			ready:    []string{"a", "b"},
+			replicas: 4,
+			total:    36,
+			pods:     2,
+		},
+		{
+			name:     "requests below the target",
+			requests: map[string]int64{"a": 2, "b": 3, "c": 4, "d": 1},
+			ready:    []string{"a", "b", "c", "d"},
+			replicas: 1,
+			total:    10,
+			pods:     4,
+		},
+		{
+			name:     "within the tolerance",
+			requests: map[string]int64{"a": 10, "b": 11},
+			ready:    []string{"a", "b"},
+			replicas: 2,
+			total:    21,
+			pods:     2,
+		},
+		{
+			name:     "unready pods are not counted",
+			requests: map[string]int64{"a": 15, "b": 15, "c": 40},
+			ready:    []string{"a", "b"},
+			replicas: 3,
+			total:    30,
+			pods:     2,
+		},
+		{
+			name:     "ready pods missing the metric are not counted",
+			requests: map[string]int64{"a": 25},
+			ready:    []string{"a", "b"},
+			replicas: 3,
+			total:    25,
+			pods:     1,
+		},
+		{
+			name:     "no ready pods",
+			requests: map[string]int64{"a": 10},
+			ready:    []string{"b"},
+			err:      true,
+		},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			// a target of 10 in-flight requests per pod with the current 2 replicas
+			replicas, total, pods, err := concurrencyReplicas(workers(c.requests), sets.NewString(c.ready...), 2, 10000, 0.1)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.replicas, replicas)
+			assert.Equal(t, c.total*1000, total)
+			assert.Equal(t, c.pods, pods)
+		})
+	}
+}
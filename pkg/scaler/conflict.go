@@ -0,0 +1,64 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scalercore"
+)
+
+// resolveConflict resolves the replicas of the metrics against the time range active now by spec.conflictPolicy,
+// and records the mode which won in the status. Without the policy, out of the time ranges or failing to match
+// them, the replicas of the metrics are used.
+func (a *DecisionEngine) resolveConflict(gpa *autoscaling.GeneralPodAutoscaler, metricReplicas int32,
+	metricName string) (int32, string) {
+	gpa.Status.ConflictWinner = ""
+	if gpa.Spec.ConflictPolicy == "" || gpa.Spec.MetricMode == nil || gpa.Spec.TimeMode == nil {
+		return metricReplicas, metricName
+	}
+	cron := scalercore.NewCronScalerAt(gpa.Spec.TimeMode, a.configMapNamespacer, a.clock.Now())
+	cronReplicas, active, err := cron.ActiveReplicas(gpa)
+	if err != nil {
+		decisionLog(gpa, 2).Infof("GPA %s/%s: match the time ranges failed, use the metrics: %v",
+			gpa.Namespace, gpa.Name, err)
+		return metricReplicas, metricName
+	}
+	if !active || cronReplicas == metricReplicas {
+		return metricReplicas, metricName
+	}
+
+	winner := autoscaling.MetricConflictWinner
+	switch gpa.Spec.ConflictPolicy {
+	case autoscaling.CronWinsConflictPolicy:
+		winner = autoscaling.CronConflictWinner
+	case autoscaling.MaxConflictPolicy:
+		if cronReplicas > metricReplicas {
+			winner = autoscaling.CronConflictWinner
+		}
+	case autoscaling.MinConflictPolicy:
+		if cronReplicas < metricReplicas {
+			winner = autoscaling.CronConflictWinner
+		}
+	}
+	gpa.Status.ConflictWinner = winner
+	decisionLog(gpa, 4).Infof("GPA %s/%s: %s recommends %d replicas while the time range recommends %d, %s wins by %s",
+		gpa.Namespace, gpa.Name, metricName, metricReplicas, cronReplicas, winner, gpa.Spec.ConflictPolicy)
+	if winner == autoscaling.CronConflictWinner {
+		return cronReplicas, fmt.Sprintf("%s time range", cron.ScalerName())
+	}
+	return metricReplicas, metricName
+}
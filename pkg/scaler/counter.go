@@ -0,0 +1,130 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+const defaultCounterDeltaIntervalSeconds = 60
+
+// counterIncrease returns the increase of the counter from the last sample at or before the interval, or
+// from the first sample, to the newest sample. A decrease is taken as a reset of the counter, which
+// restarted from 0. The increase is extrapolated to the interval if the samples span a shorter one, false
+// is returned if there are not two samples yet.
+func counterIncrease(samples []timestampedMetricSample, interval time.Duration) (float64, bool) {
+	if len(samples) < 2 {
+		return 0, false
+	}
+	newest := samples[len(samples)-1]
+	start := 0
+	for i, sample := range samples[:len(samples)-1] {
+		if !sample.timestamp.After(newest.timestamp.Add(-interval)) {
+			start = i
+		}
+	}
+	var increase int64
+	for i := start + 1; i < len(samples); i++ {
+		if delta := samples[i].value - samples[i-1].value; delta >= 0 {
+			increase += delta
+		} else {
+			increase += samples[i].value
+		}
+	}
+	span := newest.timestamp.Sub(samples[start].timestamp)
+	if span <= 0 {
+		return 0, false
+	}
+	return float64(increase) * interval.Seconds() / span.Seconds(), true
+}
+
+// computeStatusForCounterDeltaMetric computes the desired number of replicas for the specified metric of type
+// CounterDeltaMetricSourceType, by dividing the increase of the counter over the interval by the target
+// increase per pod. The replicas are kept until the counter is sampled twice.
+func (a *DecisionEngine) computeStatusForCounterDeltaMetric(statusReplicas int32, metricSpec autoscaling.MetricSpec,
+	gpa *autoscaling.GeneralPodAutoscaler, status *autoscaling.MetricStatus) (replicaCountProposal int32,
+	timestampProposal time.Time, metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
+	src := metricSpec.CounterDelta
+	if src.Target.AverageValue == nil || src.Target.AverageValue.MilliValue() <= 0 {
+		err = fmt.Errorf("invalid counter delta metric source: the target average value must be greater than 0")
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetCounterDeltaMetric", err)
+		return 0, time.Time{}, "", condition, err
+	}
+	metricSelector, err := metav1.LabelSelectorAsSelector(src.Metric.Selector)
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetCounterDeltaMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get counter delta metric %s: %v", src.Metric.Name, err)
+	}
+	metrics, timestamp, err := a.replicaCalc.metricsClient.GetExternalMetric(src.Metric.Name, gpa.Namespace, metricSelector)
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetCounterDeltaMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get counter delta metric %s: %v", src.Metric.Name, err)
+	}
+	counter := int64(0)
+	for _, val := range metrics {
+		counter = counter + val
+	}
+	if timestamp.IsZero() {
+		timestamp = a.clock.Now()
+	}
+
+	interval := time.Duration(defaultCounterDeltaIntervalSeconds) * time.Second
+	if src.IntervalSeconds != nil {
+		interval = time.Duration(*src.IntervalSeconds) * time.Second
+	}
+	metricNameProposal = fmt.Sprintf("counter delta metric %s(%+v)", src.Metric.Name, src.Metric.Selector)
+	// the samples are kept for two intervals, so that the one starting the interval is not dropped
+	samples := a.recordMetricSample(gpa.Namespace+"/"+gpa.Name, metricNameProposal,
+		timestampedMetricSample{value: counter, timestamp: timestamp}, 2*interval)
+	counterStatus := &autoscaling.CounterDeltaMetricStatus{
+		Metric: autoscaling.MetricIdentifier{
+			Name:     src.Metric.Name,
+			Selector: src.Metric.Selector,
+		},
+	}
+	*status = autoscaling.MetricStatus{
+		Type:         autoscaling.CounterDeltaMetricSourceType,
+		CounterDelta: counterStatus,
+	}
+	increase, ok := counterIncrease(samples, interval)
+	if !ok {
+		decisionLog(gpa, 4).Infof("GPA %s/%s %s: %d, waiting for the next sample", gpa.Namespace, gpa.Name,
+			metricNameProposal, counter)
+		return statusReplicas, timestamp, metricNameProposal, autoscaling.GeneralPodAutoscalerCondition{}, nil
+	}
+
+	target := float64(src.Target.AverageValue.MilliValue())
+	replicaCountProposal = statusReplicas
+	usageRatio := increase / (target * float64(statusReplicas))
+	if statusReplicas == 0 || math.Abs(1.0-usageRatio) > a.replicaCalc.tolerance {
+		// update number of replicas if the change is large enough
+		replicaCountProposal = int32(math.Ceil(increase / target))
+	}
+	decisionLog(gpa, 4).Infof("GPA %s/%s %s: increased by %.0f in %v, target per pod: %s",
+		gpa.Namespace, gpa.Name, metricNameProposal, increase/1000, interval, src.Target.AverageValue.String())
+	counterStatus.Current.Value = resource.NewMilliQuantity(int64(math.Ceil(increase)), resource.DecimalSI)
+	if statusReplicas != 0 {
+		counterStatus.Current.AverageValue = resource.NewMilliQuantity(
+			int64(math.Ceil(increase/float64(statusReplicas))), resource.DecimalSI)
+	}
+	return replicaCountProposal, timestamp, metricNameProposal, autoscaling.GeneralPodAutoscalerCondition{}, nil
+}
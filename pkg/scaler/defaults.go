@@ -0,0 +1,163 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// DefaultsConfigMapKey is the key of the ConfigMap data holding the defaults
+const DefaultsConfigMapKey = "defaults.yaml"
+
+// Defaults are the cluster-wide defaults, which are applied when a GPA omits them
+type Defaults struct {
+	// Behavior is the default behavior, each direction is applied separately if the GPA does not set it.
+	Behavior *autoscaling.GeneralPodAutoscalerBehavior `json:"behavior,omitempty"`
+	// Tolerance overrides the tolerance of the controller
+	Tolerance *float64 `json:"tolerance,omitempty"`
+	// SyncPeriod overrides the sync period of the controller
+	SyncPeriod *metav1.Duration `json:"syncPeriod,omitempty"`
+}
+
+// ParseDefaults parses the defaults from the ConfigMap
+func ParseDefaults(cm *v1.ConfigMap) (*Defaults, error) {
+	data, ok := cm.Data[DefaultsConfigMapKey]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found in configmap %s/%s", DefaultsConfigMapKey, cm.Namespace, cm.Name)
+	}
+	defaults := &Defaults{}
+	if err := yaml.UnmarshalStrict([]byte(data), defaults); err != nil {
+		return nil, fmt.Errorf("parse defaults of configmap %s/%s failed: %v", cm.Namespace, cm.Name, err)
+	}
+	if defaults.Tolerance != nil && *defaults.Tolerance < 0 {
+		return nil, fmt.Errorf("tolerance must not be negative: %v", *defaults.Tolerance)
+	}
+	if behavior := defaults.Behavior; behavior != nil {
+		// the controller requires both directions once the behavior is set
+		if behavior.ScaleUp == nil || behavior.ScaleUp.SelectPolicy == nil {
+			return nil, fmt.Errorf("behavior.scaleUp and its selectPolicy must be set")
+		}
+		if behavior.ScaleDown == nil || behavior.ScaleDown.SelectPolicy == nil {
+			return nil, fmt.Errorf("behavior.scaleDown and its selectPolicy must be set")
+		}
+	}
+	if defaults.SyncPeriod != nil && defaults.SyncPeriod.Duration <= 0 {
+		return nil, fmt.Errorf("syncPeriod must be greater than 0: %v", defaults.SyncPeriod.Duration)
+	}
+	return defaults, nil
+}
+
+// defaultsStore holds the defaults loaded from the ConfigMap
+type defaultsStore struct {
+	lock     sync.RWMutex
+	defaults *Defaults
+}
+
+func (s *defaultsStore) get() *Defaults {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.defaults
+}
+
+func (s *defaultsStore) set(defaults *Defaults) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.defaults = defaults
+}
+
+// AddDefaultsInformer watches the defaults ConfigMap with the informer, which should only list the
+// defaults ConfigMap. It must be called before the informer is started.
+func (a *GeneralController) AddDefaultsInformer(informer cache.SharedIndexInformer) {
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: a.updateDefaults,
+		UpdateFunc: func(old, cur interface{}) {
+			a.updateDefaults(cur)
+		},
+		DeleteFunc: func(obj interface{}) {
+			klog.Infof("Defaults configmap deleted, use the defaults of the flags")
+			a.setDefaults(nil)
+		},
+	})
+	a.defaultsListerSynced = informer.HasSynced
+}
+
+func (a *GeneralController) updateDefaults(obj interface{}) {
+	cm, ok := obj.(*v1.ConfigMap)
+	if !ok {
+		return
+	}
+	defaults, err := ParseDefaults(cm)
+	if err != nil {
+		// keep the last valid defaults
+		klog.Errorf("Ignore invalid defaults configmap: %v", err)
+		return
+	}
+	klog.Infof("Defaults configmap %s/%s updated", cm.Namespace, cm.Name)
+	a.setDefaults(defaults)
+}
+
+func (a *GeneralController) setDefaults(defaults *Defaults) {
+	a.SetDefaults(defaults)
+	syncPeriod := a.resyncPeriod
+	if defaults != nil && defaults.SyncPeriod != nil {
+		syncPeriod = defaults.SyncPeriod.Duration
+	}
+	if limiter, ok := a.rateLimiter.(interface{ SetInterval(time.Duration) }); ok {
+		limiter.SetInterval(syncPeriod)
+	}
+}
+
+// effectiveTolerance returns the tolerance of the defaults if set, or the tolerance of the engine
+func (a *DecisionEngine) effectiveTolerance() float64 {
+	if defaults := a.defaults.get(); defaults != nil && defaults.Tolerance != nil {
+		return *defaults.Tolerance
+	}
+	return a.replicaCalc.tolerance
+}
+
+// applyDefaults sets the default behavior for the directions the GPA does not set, and returns the tolerance
+// used for computing the replicas.
+func (a *DecisionEngine) applyDefaults(gpa *autoscaling.GeneralPodAutoscaler) float64 {
+	tolerance := a.effectiveTolerance()
+	defaults := a.defaults.get()
+	if defaults == nil || defaults.Behavior == nil {
+		return tolerance
+	}
+	if gpa.Spec.Behavior == nil {
+		gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{}
+	}
+	behavior := gpa.Spec.Behavior
+	if behavior.ScaleUp == nil && defaults.Behavior.ScaleUp != nil {
+		behavior.ScaleUp = defaults.Behavior.ScaleUp.DeepCopy()
+	}
+	if behavior.ScaleDown == nil && defaults.Behavior.ScaleDown != nil {
+		behavior.ScaleDown = defaults.Behavior.ScaleDown.DeepCopy()
+	}
+	if behavior.EWMAAlpha == nil && defaults.Behavior.EWMAAlpha != nil {
+		alpha := *defaults.Behavior.EWMAAlpha
+		behavior.EWMAAlpha = &alpha
+	}
+	return tolerance
+}
@@ -0,0 +1,158 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+const testDefaults = `
+tolerance: 0.2
+syncPeriod: 30s
+behavior:
+  scaleUp:
+    selectPolicy: Max
+    policies:
+    - type: Pods
+      value: 4
+      periodSeconds: 60
+  scaleDown:
+    selectPolicy: Max
+    policies:
+    - type: Pods
+      value: 1
+      periodSeconds: 60
+`
+
+const testUpdatedDefaults = `
+tolerance: 0.3
+behavior:
+  scaleUp:
+    selectPolicy: Max
+    policies:
+    - type: Pods
+      value: 8
+      periodSeconds: 60
+  scaleDown:
+    selectPolicy: Disabled
+`
+
+func TestDefaultsConfigMapUpdates(t *testing.T) {
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpa-defaults", Namespace: "kube-system"},
+		Data:       map[string]string{DefaultsConfigMapKey: testDefaults},
+	}
+	kubeClient := fake.NewSimpleClientset(cm)
+	controller := &GeneralController{
+		DecisionEngine: &DecisionEngine{replicaCalc: &ReplicaCalculator{tolerance: 0.1}, defaults: &defaultsStore{}},
+		resyncPeriod:   15 * time.Second,
+		rateLimiter:    NewDefaultGPARateLimiter(15 * time.Second),
+	}
+	factory := informers.NewSharedInformerFactory(kubeClient, 0)
+	controller.AddDefaultsInformer(factory.Core().V1().ConfigMaps().Informer())
+	stop := make(chan struct{})
+	defer close(stop)
+	factory.Start(stop)
+	cache.WaitForCacheSync(stop, controller.defaultsListerSynced)
+
+	selectMin := autoscalingv1alpha1.MinPolicySelect
+	ownScaleDown := &autoscalingv1alpha1.GPAScalingRules{SelectPolicy: &selectMin}
+	effective := func() (float64, *autoscalingv1alpha1.GeneralPodAutoscaler, *autoscalingv1alpha1.GeneralPodAutoscaler) {
+		plain := &autoscalingv1alpha1.GeneralPodAutoscaler{}
+		tolerance := controller.applyDefaults(plain)
+		overridden := &autoscalingv1alpha1.GeneralPodAutoscaler{
+			Spec: autoscalingv1alpha1.GeneralPodAutoscalerSpec{
+				Behavior: &autoscalingv1alpha1.GeneralPodAutoscalerBehavior{ScaleDown: ownScaleDown.DeepCopy()},
+			},
+		}
+		controller.applyDefaults(overridden)
+		return tolerance, plain, overridden
+	}
+
+	tolerance, plain, overridden := effective()
+	assert.Equal(t, 0.2, tolerance)
+	// the calculator shared by the GPAs keeps its tolerance
+	assert.Equal(t, 0.1, controller.replicaCalc.tolerance)
+	assert.Equal(t, 30*time.Second, controller.rateLimiter.When("key"))
+	assert.Equal(t, int32(4), plain.Spec.Behavior.ScaleUp.Policies[0].Value)
+	assert.Equal(t, int32(1), plain.Spec.Behavior.ScaleDown.Policies[0].Value)
+	assert.Equal(t, int32(4), overridden.Spec.Behavior.ScaleUp.Policies[0].Value)
+	assert.Equal(t, ownScaleDown, overridden.Spec.Behavior.ScaleDown)
+
+	// update the defaults, the GPAs without overrides follow them
+	updated := cm.DeepCopy()
+	updated.Data[DefaultsConfigMapKey] = testUpdatedDefaults
+	_, err := kubeClient.CoreV1().ConfigMaps("kube-system").Update(updated)
+	assert.NoError(t, err)
+	err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return controller.effectiveTolerance() == 0.3, nil
+	})
+	assert.NoError(t, err)
+
+	tolerance, plain, overridden = effective()
+	assert.Equal(t, 0.3, tolerance)
+	assert.Equal(t, 15*time.Second, controller.rateLimiter.When("key"))
+	assert.Equal(t, int32(8), plain.Spec.Behavior.ScaleUp.Policies[0].Value)
+	assert.Equal(t, autoscalingv1alpha1.DisabledPolicySelect, *plain.Spec.Behavior.ScaleDown.SelectPolicy)
+	assert.Equal(t, int32(8), overridden.Spec.Behavior.ScaleUp.Policies[0].Value)
+	assert.Equal(t, ownScaleDown, overridden.Spec.Behavior.ScaleDown)
+
+	// an invalid update keeps the last valid defaults
+	invalid := updated.DeepCopy()
+	invalid.Data[DefaultsConfigMapKey] = "tolerance: -1"
+	controller.updateDefaults(invalid)
+	assert.Equal(t, 0.3, controller.effectiveTolerance())
+
+	// deleting the configmap falls back to the flags
+	assert.NoError(t, kubeClient.CoreV1().ConfigMaps("kube-system").Delete("gpa-defaults", nil))
+	err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return controller.effectiveTolerance() == 0.1, nil
+	})
+	assert.NoError(t, err)
+	tolerance, plain, _ = effective()
+	assert.Equal(t, 0.1, tolerance)
+	assert.Nil(t, plain.Spec.Behavior)
+}
+
+func TestParseDefaults(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		data  map[string]string
+		valid bool
+	}{
+		{name: "valid", data: map[string]string{DefaultsConfigMapKey: testDefaults}, valid: true},
+		{name: "missing key", data: map[string]string{}},
+		{name: "unknown field", data: map[string]string{DefaultsConfigMapKey: "foo: bar"}},
+		{name: "negative tolerance", data: map[string]string{DefaultsConfigMapKey: "tolerance: -0.1"}},
+		{name: "zero sync period", data: map[string]string{DefaultsConfigMapKey: "syncPeriod: 0s"}},
+		{name: "partial behavior", data: map[string]string{DefaultsConfigMapKey: "behavior:\n  scaleUp:\n    selectPolicy: Max"}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ParseDefaults(&v1.ConfigMap{Data: c.data})
+			assert.Equal(t, c.valid, err == nil, "unexpected error: %v", err)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// delayScaleDownAfterScaleUp holds the current replicas instead of scaling down until
+// spec.behavior.scaleDownDelayAfterScaleUpSeconds elapses since the last scale-up in the status. The replicas
+// above the max replicas are still scaled down.
+func delayScaleDownAfterScaleUp(gpa *autoscaling.GeneralPodAutoscaler, currentReplicas, desiredReplicas int32,
+	now time.Time) int32 {
+	if desiredReplicas >= currentReplicas || gpa.Spec.Behavior == nil ||
+		gpa.Spec.Behavior.ScaleDownDelayAfterScaleUpSeconds == nil || gpa.Status.LastScaleUpTime == nil {
+		return desiredReplicas
+	}
+	delay := time.Duration(*gpa.Spec.Behavior.ScaleDownDelayAfterScaleUpSeconds) * time.Second
+	remaining := gpa.Status.LastScaleUpTime.Add(delay).Sub(now)
+	if remaining <= 0 {
+		return desiredReplicas
+	}
+	held := max(desiredReplicas, min(currentReplicas, gpa.Spec.MaxReplicas))
+	if held != desiredReplicas {
+		setCondition(gpa, autoscaling.AbleToScale, v1.ConditionTrue, "ScaleDownDelayedAfterScaleUp",
+			"the target was scaled up recently, scaling down is delayed for %v", remaining.Round(time.Second))
+		decisionLog(gpa, 4).Infof("GPA %s/%s: scale down to %d delayed for %v after the last scale-up",
+			gpa.Namespace, gpa.Name, desiredReplicas, remaining)
+	}
+	return held
+}
+
+// recordScaleUp records the time of the scale-up in the status, which the scale-downs are delayed from
+func recordScaleUp(gpa *autoscaling.GeneralPodAutoscaler, prevReplicas, newReplicas int32, now time.Time) {
+	if newReplicas > prevReplicas {
+		scaleUpTime := metav1.NewTime(now)
+		gpa.Status.LastScaleUpTime = &scaleUpTime
+	}
+}
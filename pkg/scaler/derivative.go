@@ -0,0 +1,247 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+const (
+	defaultDerivativeWindowSeconds    = 300
+	defaultDerivativeLookaheadSeconds = 180
+	// maxProjectionFactor bounds the projection to [current/maxProjectionFactor, current*maxProjectionFactor]
+	maxProjectionFactor = 2.0
+)
+
+type timestampedMetricSample struct {
+	value     int64
+	timestamp time.Time
+}
+
+// recordMetricSample appends the sample to the samples of the metric, and drops the samples out of the window.
+func (a *DecisionEngine) recordMetricSample(key, metricName string, sample timestampedMetricSample,
+	window time.Duration) []timestampedMetricSample {
+	if a.metricSamples[key] == nil {
+		a.metricSamples[key] = map[string][]timestampedMetricSample{}
+	}
+	samples := a.metricSamples[key][metricName]
+	if n := len(samples); n > 0 && !sample.timestamp.After(samples[n-1].timestamp) {
+		// the metric is not refreshed since last sync
+		samples[n-1] = sample
+	} else {
+		samples = append(samples, sample)
+	}
+	cutoff := sample.timestamp.Add(-window)
+	first := 0
+	for first < len(samples) && samples[first].timestamp.Before(cutoff) {
+		first++
+	}
+	samples = samples[first:]
+	a.metricSamples[key][metricName] = samples
+	return samples
+}
+
+// projectMetric projects the latest value of the samples lookahead ahead with the least squares slope
+// of the samples, the projection is bounded by maxProjectionFactor.
+func projectMetric(samples []timestampedMetricSample, lookahead time.Duration) float64 {
+	current := float64(samples[len(samples)-1].value)
+	if len(samples) < 2 {
+		return current
+	}
+	start := samples[0].timestamp
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range samples {
+		x := sample.timestamp.Sub(start).Seconds()
+		y := float64(sample.value)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return current
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	projected := current + slope*lookahead.Seconds()
+	projected = math.Min(projected, current*maxProjectionFactor)
+	projected = math.Max(projected, current/maxProjectionFactor)
+	return math.Max(projected, 0)
+}
+
+// projectSeasonalMetric projects the latest value of the samples lookahead ahead by adding the change of the
+// samples over lookahead a season ago, the projection is bounded by maxProjectionFactor. It returns false if the
+// samples do not cover a season yet.
+func projectSeasonalMetric(samples []timestampedMetricSample, season, lookahead time.Duration) (float64, bool) {
+	latest := samples[len(samples)-1]
+	base, ok := sampleAt(samples, latest.timestamp.Add(-season))
+	if !ok {
+		return 0, false
+	}
+	ahead, _ := sampleAt(samples, latest.timestamp.Add(lookahead-season))
+	current := float64(latest.value)
+	projected := current + float64(ahead.value-base.value)
+	projected = math.Min(projected, current*maxProjectionFactor)
+	projected = math.Max(projected, current/maxProjectionFactor)
+	return math.Max(projected, 0), true
+}
+
+// smoothMetricSamples returns the moving averages of the samples, the average of each sample is of the samples
+// taken within smoothing before it, including itself.
+func smoothMetricSamples(samples []timestampedMetricSample, smoothing time.Duration) []timestampedMetricSample {
+	smoothed := make([]timestampedMetricSample, 0, len(samples))
+	first := 0
+	var sum int64
+	for _, sample := range samples {
+		sum += sample.value
+		cutoff := sample.timestamp.Add(-smoothing)
+		for samples[first].timestamp.Before(cutoff) {
+			sum -= samples[first].value
+			first++
+		}
+		smoothed = append(smoothed, timestampedMetricSample{
+			value:     sum / int64(len(smoothed)+1-first),
+			timestamp: sample.timestamp,
+		})
+	}
+	return smoothed
+}
+
+// sampleAt returns the last of the samples taken at or before the time, it returns false if all the samples
+// were taken after it.
+func sampleAt(samples []timestampedMetricSample, at time.Time) (timestampedMetricSample, bool) {
+	i := sort.Search(len(samples), func(i int) bool { return samples[i].timestamp.After(at) })
+	if i == 0 {
+		return timestampedMetricSample{}, false
+	}
+	return samples[i-1], true
+}
+
+// computeStatusForDerivativeMetric computes the desired number of replicas for the specified metric of type
+// DerivativeMetricSourceType, by comparing the projected value of the metric to the target.
+func (a *DecisionEngine) computeStatusForDerivativeMetric(specReplicas, statusReplicas int32, metricSpec autoscaling.MetricSpec,
+	gpa *autoscaling.GeneralPodAutoscaler, selector labels.Selector, status *autoscaling.MetricStatus) (replicaCountProposal int32,
+	timestampProposal time.Time, metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
+	src := metricSpec.Derivative
+	if src.Target.Value == nil && src.Target.AverageValue == nil {
+		err = fmt.Errorf("invalid derivative metric source: neither a value target nor an average value target was set")
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetDerivativeMetric", err)
+		return 0, time.Time{}, "", condition, err
+	}
+	metricSelector, err := metav1.LabelSelectorAsSelector(src.Metric.Selector)
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetDerivativeMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get derivative metric %s: %v", src.Metric.Name, err)
+	}
+	metrics, timestamp, err := a.replicaCalc.metricsClient.GetExternalMetric(src.Metric.Name, gpa.Namespace, metricSelector)
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetDerivativeMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get derivative metric %s: %v", src.Metric.Name, err)
+	}
+	current := int64(0)
+	for _, val := range metrics {
+		current = current + val
+	}
+	if timestamp.IsZero() {
+		timestamp = a.clock.Now()
+	}
+
+	window := time.Duration(defaultDerivativeWindowSeconds) * time.Second
+	if src.WindowSeconds != nil {
+		window = time.Duration(*src.WindowSeconds) * time.Second
+	}
+	lookahead := time.Duration(defaultDerivativeLookaheadSeconds) * time.Second
+	if src.LookaheadSeconds != nil {
+		lookahead = time.Duration(*src.LookaheadSeconds) * time.Second
+	}
+	retention := window
+	var season time.Duration
+	if src.SeasonSeconds != nil {
+		season = time.Duration(*src.SeasonSeconds) * time.Second
+		if season > retention {
+			retention = season
+		}
+	}
+	var smoothing time.Duration
+	if src.SmoothingSeconds != nil {
+		// the samples before the retention are kept to smooth the first samples of it
+		smoothing = time.Duration(*src.SmoothingSeconds) * time.Second
+		retention += smoothing
+	}
+	metricNameProposal = fmt.Sprintf("derivative metric %s(%+v)", src.Metric.Name, src.Metric.Selector)
+	samples := a.recordMetricSample(gpa.Namespace+"/"+gpa.Name, metricNameProposal,
+		timestampedMetricSample{value: current, timestamp: timestamp}, retention)
+	if smoothing > 0 {
+		samples = smoothMetricSamples(samples, smoothing)
+		current = samples[len(samples)-1].value
+	}
+	projected, seasonal := 0.0, false
+	if season > 0 {
+		projected, seasonal = projectSeasonalMetric(samples, season, lookahead)
+	}
+	if !seasonal {
+		// the slope is computed over the window only, the samples may be kept longer for the season
+		first := sort.Search(len(samples), func(i int) bool { return !samples[i].timestamp.Before(timestamp.Add(-window)) })
+		projected = projectMetric(samples[first:], lookahead)
+	}
+	decisionLog(gpa, 4).Infof("GPA %s/%s %s current: %d, projected: %.0f in %v with %d samples",
+		gpa.Namespace, gpa.Name, metricNameProposal, current, projected, lookahead, len(samples))
+
+	derivativeStatus := &autoscaling.DerivativeMetricStatus{
+		Metric: autoscaling.MetricIdentifier{
+			Name:     src.Metric.Name,
+			Selector: src.Metric.Selector,
+		},
+	}
+	if src.Target.AverageValue != nil {
+		target := float64(src.Target.AverageValue.MilliValue())
+		replicaCountProposal = statusReplicas
+		usageRatio := projected / (target * float64(statusReplicas))
+		if math.Abs(1.0-usageRatio) > a.replicaCalc.tolerance {
+			// update number of replicas if the change is large enough
+			replicaCountProposal = int32(math.Ceil(projected / target))
+		}
+		if statusReplicas != 0 {
+			derivativeStatus.Current.AverageValue = resource.NewMilliQuantity(
+				int64(math.Ceil(float64(current)/float64(statusReplicas))), resource.DecimalSI)
+			derivativeStatus.Projected.AverageValue = resource.NewMilliQuantity(
+				int64(math.Ceil(projected/float64(statusReplicas))), resource.DecimalSI)
+		}
+	} else {
+		usageRatio := projected / float64(src.Target.Value.MilliValue())
+		replicaCountProposal, _, err = a.replicaCalc.getUsageRatioReplicaCount(specReplicas, usageRatio, gpa.Namespace, selector)
+		if err != nil {
+			condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetDerivativeMetric", err)
+			return 0, time.Time{}, "", condition, fmt.Errorf("failed to get derivative metric %s: %v", src.Metric.Name, err)
+		}
+		derivativeStatus.Current.Value = resource.NewMilliQuantity(current, resource.DecimalSI)
+		derivativeStatus.Projected.Value = resource.NewMilliQuantity(int64(math.Ceil(projected)), resource.DecimalSI)
+	}
+	*status = autoscaling.MetricStatus{
+		Type:       autoscaling.DerivativeMetricSourceType,
+		Derivative: derivativeStatus,
+	}
+	return replicaCountProposal, timestamp, metricNameProposal, autoscaling.GeneralPodAutoscalerCondition{}, nil
+}
@@ -0,0 +1,310 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	metricsclient "github.com/ocgi/general-pod-autoscaler/pkg/metrics"
+)
+
+// seriesMetricsClient returns the external metric value set by the test
+type seriesMetricsClient struct {
+	value     int64
+	timestamp time.Time
+}
+
+func (c *seriesMetricsClient) GetResourceMetric(v1.ResourceName, string, labels.Selector, string) (metricsclient.PodMetricsInfo, time.Time, error) {
+	return nil, time.Time{}, fmt.Errorf("not supported")
+}
+
+func (c *seriesMetricsClient) GetRawMetric(string, string, labels.Selector, labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error) {
+	return nil, time.Time{}, fmt.Errorf("not supported")
+}
+
+func (c *seriesMetricsClient) GetObjectMetric(string, string, *autoscalingv1alpha1.CrossVersionObjectReference, labels.Selector) (int64, time.Time, error) {
+	return 0, time.Time{}, fmt.Errorf("not supported")
+}
+
+func (c *seriesMetricsClient) GetExternalMetric(string, string, labels.Selector) ([]int64, time.Time, error) {
+	return []int64{c.value}, c.timestamp, nil
+}
+
+func TestDerivativeMetricScalesAhead(t *testing.T) {
+	metricsClient := &seriesMetricsClient{}
+	controller := &DecisionEngine{
+		replicaCalc:   &ReplicaCalculator{metricsClient: metricsClient, tolerance: 0.1},
+		clock:         clock.RealClock{},
+		metricSamples: map[string]map[string][]timestampedMetricSample{},
+	}
+	gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpa", Namespace: "default"},
+	}
+	target := autoscalingv1alpha1.MetricTarget{
+		Type:         autoscalingv1alpha1.AverageValueMetricType,
+		AverageValue: resource.NewMilliQuantity(100, resource.DecimalSI),
+	}
+	metric := autoscalingv1alpha1.MetricIdentifier{Name: "queue_length"}
+	external := autoscalingv1alpha1.MetricSpec{
+		Type:     autoscalingv1alpha1.ExternalMetricSourceType,
+		External: &autoscalingv1alpha1.ExternalMetricSource{Metric: metric, Target: target},
+	}
+	derivative := autoscalingv1alpha1.MetricSpec{
+		Type:       autoscalingv1alpha1.DerivativeMetricSourceType,
+		Derivative: &autoscalingv1alpha1.DerivativeMetricSource{Metric: metric, Target: target},
+	}
+
+	start := time.Now()
+	var levelReplicas, derivativeReplicas int32
+	var status autoscalingv1alpha1.MetricStatus
+	// the metric rises by 100 per minute, it is projected 180 seconds ahead
+	for i, value := range []int64{100, 200, 300, 400} {
+		metricsClient.value = value
+		metricsClient.timestamp = start.Add(time.Duration(i) * time.Minute)
+		var err error
+		levelReplicas, _, _, _, err = controller.computeReplicasForMetric(gpa, external, 4, 4, labels.Everything(),
+			&autoscalingv1alpha1.MetricStatus{})
+		assert.NoError(t, err)
+		derivativeReplicas, _, _, _, err = controller.computeReplicasForMetric(gpa, derivative, 4, 4, labels.Everything(), &status)
+		assert.NoError(t, err)
+		if i == 0 {
+			// no slope with a single sample
+			assert.Equal(t, levelReplicas, derivativeReplicas)
+		}
+	}
+	assert.Equal(t, int32(4), levelReplicas)
+	assert.Equal(t, int32(7), derivativeReplicas)
+	assert.Equal(t, autoscalingv1alpha1.DerivativeMetricSourceType, status.Type)
+	assert.Equal(t, int64(100), status.Derivative.Current.AverageValue.MilliValue())
+	assert.Equal(t, int64(175), status.Derivative.Projected.AverageValue.MilliValue())
+
+	// a spike projected further ahead is bounded to twice the current value
+	lookahead := int32(600)
+	derivative.Derivative.LookaheadSeconds = &lookahead
+	metricsClient.value = 2000
+	metricsClient.timestamp = start.Add(4 * time.Minute)
+	derivativeReplicas, _, _, _, err := controller.computeReplicasForMetric(gpa, derivative, 4, 4, labels.Everything(), &status)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(40), derivativeReplicas)
+}
+
+func TestDerivativeMetricSeasonal(t *testing.T) {
+	metricsClient := &seriesMetricsClient{}
+	controller := &DecisionEngine{
+		replicaCalc:   &ReplicaCalculator{metricsClient: metricsClient, tolerance: 0.1},
+		clock:         clock.RealClock{},
+		metricSamples: map[string]map[string][]timestampedMetricSample{},
+	}
+	gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpa", Namespace: "default"},
+	}
+	season := int32(600)
+	derivative := autoscalingv1alpha1.MetricSpec{
+		Type: autoscalingv1alpha1.DerivativeMetricSourceType,
+		Derivative: &autoscalingv1alpha1.DerivativeMetricSource{
+			Metric: autoscalingv1alpha1.MetricIdentifier{Name: "queue_length"},
+			Target: autoscalingv1alpha1.MetricTarget{
+				Type:         autoscalingv1alpha1.AverageValueMetricType,
+				AverageValue: resource.NewMilliQuantity(100, resource.DecimalSI),
+			},
+			SeasonSeconds: &season,
+		},
+	}
+
+	start := time.Now()
+	replicas := map[int]int32{}
+	// the metric rises by 100 per minute and drops back every 10 minutes
+	for i := 0; i <= 18; i++ {
+		metricsClient.value = int64(100 + 100*(i%10))
+		metricsClient.timestamp = start.Add(time.Duration(i) * time.Minute)
+		var err error
+		replicas[i], _, _, _, err = controller.computeReplicasForMetric(gpa, derivative, 4, 4, labels.Everything(),
+			&autoscalingv1alpha1.MetricStatus{})
+		assert.NoError(t, err)
+	}
+	// the samples do not cover a season yet, 1000 is projected by the slope to 1300
+	assert.Equal(t, int32(13), replicas[9])
+	// 400 is projected to 700 as a season ago
+	assert.Equal(t, int32(7), replicas[13])
+	// the drop of a season ago is foreseen while the slope still rises, 900 is bounded to half
+	assert.Equal(t, int32(5), replicas[18])
+}
+
+func TestDerivativeMetricSeasonalAfterRestart(t *testing.T) {
+	metricsClient := &seriesMetricsClient{}
+	newEngine := func() *DecisionEngine {
+		return &DecisionEngine{
+			replicaCalc:   &ReplicaCalculator{metricsClient: metricsClient, tolerance: 0.1},
+			clock:         clock.RealClock{},
+			metricSamples: map[string]map[string][]timestampedMetricSample{},
+		}
+	}
+	gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpa", Namespace: "default"},
+	}
+	season := int32(600)
+	derivative := autoscalingv1alpha1.MetricSpec{
+		Type: autoscalingv1alpha1.DerivativeMetricSourceType,
+		Derivative: &autoscalingv1alpha1.DerivativeMetricSource{
+			Metric: autoscalingv1alpha1.MetricIdentifier{Name: "queue_length"},
+			Target: autoscalingv1alpha1.MetricTarget{
+				Type:         autoscalingv1alpha1.AverageValueMetricType,
+				AverageValue: resource.NewMilliQuantity(100, resource.DecimalSI),
+			},
+			SeasonSeconds: &season,
+		},
+	}
+
+	start := time.Now()
+	// the metric rises by 100 per minute and drops back every 10 minutes
+	compute := func(engine *DecisionEngine, i int) int32 {
+		metricsClient.value = int64(100 + 100*(i%10))
+		metricsClient.timestamp = start.Add(time.Duration(i) * time.Minute)
+		replicas, _, _, _, err := engine.computeReplicasForMetric(gpa, derivative, 4, 4, labels.Everything(),
+			&autoscalingv1alpha1.MetricStatus{})
+		assert.NoError(t, err)
+		return replicas
+	}
+	leader := newEngine()
+	var replicas int32
+	for i := 0; i <= 18; i++ {
+		replicas = compute(leader, i)
+	}
+	// the drop of a season ago is foreseen, 900 is bounded to half
+	assert.Equal(t, int32(5), replicas)
+
+	// the samples of the season are lost on restart, the slope is used until a season is covered again
+	restarted := newEngine()
+	for i := 16; i <= 18; i++ {
+		replicas = compute(restarted, i)
+	}
+	// 900 is projected by the slope to 1200
+	assert.Equal(t, int32(12), replicas)
+}
+
+func TestDerivativeMetricSmoothed(t *testing.T) {
+	metricsClient := &seriesMetricsClient{}
+	controller := &DecisionEngine{
+		replicaCalc:   &ReplicaCalculator{metricsClient: metricsClient, tolerance: 0.1},
+		clock:         clock.RealClock{},
+		metricSamples: map[string]map[string][]timestampedMetricSample{},
+	}
+	rawGPA := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "raw", Namespace: "default"},
+	}
+	smoothedGPA := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "smoothed", Namespace: "default"},
+	}
+	target := autoscalingv1alpha1.MetricTarget{
+		Type:         autoscalingv1alpha1.AverageValueMetricType,
+		AverageValue: resource.NewMilliQuantity(100, resource.DecimalSI),
+	}
+	metric := autoscalingv1alpha1.MetricIdentifier{Name: "queue_length"}
+	smoothing := int32(60)
+	raw := autoscalingv1alpha1.MetricSpec{
+		Type:       autoscalingv1alpha1.DerivativeMetricSourceType,
+		Derivative: &autoscalingv1alpha1.DerivativeMetricSource{Metric: metric, Target: target},
+	}
+	smoothed := autoscalingv1alpha1.MetricSpec{
+		Type: autoscalingv1alpha1.DerivativeMetricSourceType,
+		Derivative: &autoscalingv1alpha1.DerivativeMetricSource{
+			Metric:           metric,
+			Target:           target,
+			SmoothingSeconds: &smoothing,
+		},
+	}
+
+	start := time.Now()
+	var rawReplicas, smoothedReplicas []int32
+	// the metric rises by 100 per minute with a noise of 300 flipping every minute
+	for i := 0; i < 12; i++ {
+		noise := int64(300)
+		if i%2 == 1 {
+			noise = -300
+		}
+		metricsClient.value = int64(1000+100*i) + noise
+		metricsClient.timestamp = start.Add(time.Duration(i) * time.Minute)
+		replicas, _, _, _, err := controller.computeReplicasForMetric(rawGPA, raw, 10, 10, labels.Everything(),
+			&autoscalingv1alpha1.MetricStatus{})
+		assert.NoError(t, err)
+		rawReplicas = append(rawReplicas, replicas)
+		replicas, _, _, _, err = controller.computeReplicasForMetric(smoothedGPA, smoothed, 10, 10, labels.Everything(),
+			&autoscalingv1alpha1.MetricStatus{})
+		assert.NoError(t, err)
+		smoothedReplicas = append(smoothedReplicas, replicas)
+	}
+	// the raw projection flips with the noise
+	assert.Equal(t, []int32{26, 18, 28, 20}, rawReplicas[8:])
+	// once the window is smoothed, the projection only rises, ahead of the 2100 of the trend
+	for i := 3; i < len(smoothedReplicas); i++ {
+		assert.True(t, smoothedReplicas[i] >= smoothedReplicas[i-1], "smoothed replicas dropped at %d: %v", i, smoothedReplicas)
+	}
+	assert.Equal(t, int32(24), smoothedReplicas[len(smoothedReplicas)-1])
+}
+
+func TestSmoothMetricSamples(t *testing.T) {
+	start := time.Now()
+	var samples []timestampedMetricSample
+	for i, value := range []int64{100, 300, 200, 600} {
+		samples = append(samples, timestampedMetricSample{value: value, timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+	var values []int64
+	for _, sample := range smoothMetricSamples(samples, 2*time.Minute) {
+		values = append(values, sample.value)
+	}
+	assert.Equal(t, []int64{100, 200, 200, 366}, values)
+}
+
+func TestProjectMetric(t *testing.T) {
+	start := time.Now()
+	samples := func(values ...int64) []timestampedMetricSample {
+		var result []timestampedMetricSample
+		for i, value := range values {
+			result = append(result, timestampedMetricSample{value: value, timestamp: start.Add(time.Duration(i) * time.Minute)})
+		}
+		return result
+	}
+	assert.Equal(t, 100.0, projectMetric(samples(100), 3*time.Minute))
+	assert.Equal(t, 100.0, projectMetric(samples(100, 100, 100), 3*time.Minute))
+	assert.Equal(t, 700.0, projectMetric(samples(100, 200, 300, 400), 3*time.Minute))
+	// a falling metric is bounded to half of the current value
+	assert.Equal(t, 50.0, projectMetric(samples(400, 300, 200, 100), 3*time.Minute))
+	assert.Equal(t, 0.0, projectMetric(samples(100, 0), 3*time.Minute))
+}
+
+func TestRecordMetricSampleWindow(t *testing.T) {
+	controller := &DecisionEngine{metricSamples: map[string]map[string][]timestampedMetricSample{}}
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		controller.recordMetricSample("default/gpa", "metric",
+			timestampedMetricSample{value: int64(i), timestamp: start.Add(time.Duration(i) * time.Minute)}, 5*time.Minute)
+	}
+	// a sample with the same timestamp replaces the last one
+	samples := controller.recordMetricSample("default/gpa", "metric",
+		timestampedMetricSample{value: 100, timestamp: start.Add(9 * time.Minute)}, 5*time.Minute)
+	assert.Equal(t, 6, len(samples))
+	assert.Equal(t, int64(4), samples[0].value)
+	assert.Equal(t, int64(100), samples[5].value)
+}
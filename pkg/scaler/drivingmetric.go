@@ -0,0 +1,53 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// recordDrivingMetricKey records the value and the target of the metric driving the recommendation of a GPA
+// in its status on each sync
+const recordDrivingMetricKey = "autoscaling.ocgi.io/record-driving-metric"
+
+func isRecordDrivingMetric(gpa *autoscaling.GeneralPodAutoscaler) bool {
+	return gpa != nil && gpa.Annotations != nil && gpa.Annotations[recordDrivingMetricKey] == "true"
+}
+
+// recordDrivingMetric sets the status of the metric at index driving, which proposed the replicas, if the GPA
+// is annotated with recordDrivingMetricKey. A negative index clears it, e.g. when no single metric drove the
+// recommendation.
+func (a *DecisionEngine) recordDrivingMetric(gpa *autoscaling.GeneralPodAutoscaler, driving int, name string,
+	replicas int32, metricSpecs []autoscaling.MetricSpec, statuses []autoscaling.MetricStatus) {
+	if !isRecordDrivingMetric(gpa) || driving < 0 || driving >= len(metricSpecs) || driving >= len(statuses) {
+		gpa.Status.DrivingMetric = nil
+		return
+	}
+	status := &autoscaling.DrivingMetricStatus{
+		Index:          int32(driving),
+		Name:           name,
+		Replicas:       replicas,
+		LastRecordTime: metav1.NewTime(a.clock.Now()),
+	}
+	if current := metricStatusCurrent(statuses[driving]); current != nil {
+		status.Current = current.DeepCopy()
+	}
+	if target := metricSpecTarget(metricSpecs[driving]); target != nil {
+		status.Target = target.DeepCopy()
+	}
+	gpa.Status.DrivingMetric = status
+}
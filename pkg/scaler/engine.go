@@ -0,0 +1,341 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"time"
+
+	autoscalinginternal "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	autoscalinglisters "github.com/ocgi/general-pod-autoscaler/pkg/client/listers/autoscaling/v1alpha1"
+	metricsclient "github.com/ocgi/general-pod-autoscaler/pkg/metrics"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scalercore"
+)
+
+// DecisionEngine computes the desired replicas of GPAs from their metrics, modes and behaviors.
+// It keeps the recommendations and scale events of each GPA, but never updates the GPAs or their targets,
+// so that the decisions can be tested with fake metrics and a fake clock.
+type DecisionEngine struct {
+	replicaCalc *ReplicaCalculator
+	// secretNamespacer is used by the webhook mode to get the auth secrets
+	secretNamespacer v1core.SecretsGetter
+	// configMapNamespacer is used by the time mode to get the exception dates
+	configMapNamespacer v1core.ConfigMapsGetter
+	// clusterNodeLister is used by the cluster-proportional mode to count the nodes and cores, and by the
+	// capacity limit to sum the allocatable resources
+	clusterNodeLister corelisters.NodeLister
+	// gpaLister is used by the mirror mode to get the mirrored GPAs
+	gpaLister autoscalinglisters.GeneralPodAutoscalerLister
+	// projectedTokens are used by the probe metrics to authenticate the probes
+	projectedTokens *scalercore.ProjectedTokens
+	eventRecorder   record.EventRecorder
+	clock           clock.Clock
+
+	// defaults loaded from the defaults ConfigMap, set by SetDefaults
+	defaults *defaultsStore
+	// maxCapacityPercent is the default percent of the cluster capacity the target of a GPA may request, set by
+	// SetMaxCapacityPercent
+	maxCapacityPercent int32
+
+	downscaleStabilisationWindow time.Duration
+
+	// Latest unstabilized recommendations for each autoscaler.
+	recommendations map[string][]timestampedRecommendation
+
+	// Latest autoscaler events
+	scaleUpEvents   map[string][]timestampedScaleEvent
+	scaleDownEvents map[string][]timestampedScaleEvent
+
+	// Samples of the derivative metrics within their windows for each autoscaler.
+	metricSamples map[string]map[string][]timestampedMetricSample
+
+	// Backoff of the failed Kafka brokers, shared by the autoscalers with the same brokers, and of the failed lags
+	// of the consumer groups on the topics of the brokers.
+	kafkaBackoff *flowcontrol.Backoff
+
+	// Backoff of the failed probe urls, shared by the autoscalers probing the same url.
+	probeBackoff *flowcontrol.Backoff
+}
+
+// Recommendation is the desired replicas computed by the DecisionEngine for a GPA
+type Recommendation struct {
+	// DesiredReplicas is the desired replicas after normalization
+	DesiredReplicas int32
+	// MetricName describes the metric or mode which proposed the desired replicas
+	MetricName string
+	// Mode is the mode which proposed the desired replicas, MetricDecisionMode or the name of a scaler, its
+	// scaling rules of the behavior apply
+	Mode string
+	// ProposedReplicas are the replicas proposed by the metric or mode, before the behavior is applied
+	ProposedReplicas int32
+	// Reason describes why the target should be rescaled, it is empty if the desired replicas do not change
+	Reason string
+	// MetricStatuses are the statuses of the metrics of a GPA in metric mode
+	MetricStatuses []autoscaling.MetricStatus
+}
+
+// NewDecisionEngine creates a new DecisionEngine.
+func NewDecisionEngine(
+	metricsClient metricsclient.MetricsClient,
+	podLister corelisters.PodLister,
+	secretNamespacer v1core.SecretsGetter,
+	eventRecorder record.EventRecorder,
+	clock clock.Clock,
+	tolerance float64,
+	downscaleStabilisationWindow time.Duration,
+	cpuInitializationPeriod,
+	delayOfInitialReadinessStatus time.Duration,
+) *DecisionEngine {
+	kafkaBackoff := flowcontrol.NewBackOff(kafkaBackoffInitial, kafkaBackoffMax)
+	kafkaBackoff.Clock = clock
+	probeBackoff := flowcontrol.NewBackOff(probeBackoffInitial, probeBackoffMax)
+	probeBackoff.Clock = clock
+	replicaCalc := NewReplicaCalculator(
+		metricsClient,
+		podLister,
+		tolerance,
+		cpuInitializationPeriod,
+		delayOfInitialReadinessStatus,
+	)
+	replicaCalc.clock = clock
+	return &DecisionEngine{
+		replicaCalc:                  replicaCalc,
+		secretNamespacer:             secretNamespacer,
+		eventRecorder:                eventRecorder,
+		clock:                        clock,
+		defaults:                     &defaultsStore{},
+		downscaleStabilisationWindow: downscaleStabilisationWindow,
+		recommendations:              map[string][]timestampedRecommendation{},
+		scaleUpEvents:                map[string][]timestampedScaleEvent{},
+		scaleDownEvents:              map[string][]timestampedScaleEvent{},
+		metricSamples:                map[string]map[string][]timestampedMetricSample{},
+		kafkaBackoff:                 kafkaBackoff,
+		probeBackoff:                 probeBackoff,
+	}
+}
+
+// SetClock replaces the clock all the time-based decisions are made by, e.g. the time ranges, the cooldowns and
+// the stabilization windows, so that they can be tested by advancing a fake clock. It must be called before the
+// GPAs are synced.
+func (a *DecisionEngine) SetClock(clock clock.Clock) {
+	a.clock = clock
+	a.replicaCalc.clock = clock
+	a.kafkaBackoff.Clock = clock
+	a.probeBackoff.Clock = clock
+}
+
+// SetConfigMapNamespacer sets the client the time mode gets the ConfigMaps of the exception dates with. Without it,
+// the exception dates can only be listed inline.
+func (a *DecisionEngine) SetConfigMapNamespacer(configMapNamespacer v1core.ConfigMapsGetter) {
+	a.configMapNamespacer = configMapNamespacer
+}
+
+// SetClusterNodeLister sets the lister the cluster-proportional mode counts the nodes and their allocatable
+// cores with. Without it, the GPAs in cluster-proportional mode fail to compute the replicas.
+func (a *DecisionEngine) SetClusterNodeLister(nodeLister corelisters.NodeLister) {
+	a.clusterNodeLister = nodeLister
+}
+
+// SetGPALister sets the lister the mirror mode gets the mirrored GPAs with. Without it, the GPAs in mirror mode
+// fail to compute the replicas.
+func (a *DecisionEngine) SetGPALister(gpaLister autoscalinglisters.GeneralPodAutoscalerLister) {
+	a.gpaLister = gpaLister
+}
+
+// SetProjectedTokens sets the token files the probe metrics authenticate with. Without them, the probe metrics
+// with a token auth fail.
+func (a *DecisionEngine) SetProjectedTokens(tokens *scalercore.ProjectedTokens) {
+	a.projectedTokens = tokens
+}
+
+// SetDefaults sets the defaults applied to the GPAs which omit them, nil to use the tolerance of the engine and
+// the behavior of the GPAs as is.
+func (a *DecisionEngine) SetDefaults(defaults *Defaults) {
+	a.defaults.set(defaults)
+}
+
+// SetMaxCapacityPercent caps the desired replicas of the GPAs to the replicas whose requests fit in the percent of
+// the allocatable resources of the nodes of the cluster node lister, the percent can be overridden by the
+// max-capacity-percent annotation of a GPA. 0 to disable.
+func (a *DecisionEngine) SetMaxCapacityPercent(percent int32) {
+	a.maxCapacityPercent = percent
+}
+
+// PrepareDecision applies the defaults, and the bounds and tuning overrides of the annotations to the GPA before
+// its replicas are recommended, and returns the tolerance of the decision.
+func (a *DecisionEngine) PrepareDecision(gpa *autoscaling.GeneralPodAutoscaler) float64 {
+	tolerance := a.applyDefaults(gpa)
+	a.applyBoundsOverride(gpa)
+	return a.applyTuningOverride(gpa, tolerance)
+}
+
+// LimitDecision limits a scale up of the target of the GPA from the current to the recommended replicas by the
+// cluster capacity and the pending pods of the target, and returns the replicas the target is scaled to.
+func (a *DecisionEngine) LimitDecision(gpa *autoscaling.GeneralPodAutoscaler, selector string,
+	currentReplicas, desiredReplicas int32) int32 {
+	desiredReplicas = a.limitByCapacity(gpa, selector, currentReplicas, desiredReplicas)
+	return a.limitByPendingPods(gpa, selector, currentReplicas, desiredReplicas)
+}
+
+// Recommend computes the desired replicas of the GPA for the current scale of its target, and sets the
+// conditions of the GPA accordingly. The key identifies the recommendations and scale events of the GPA,
+// the recommendation is recorded for the stabilization, while the scale events are recorded by RecordScale
+// once the target is scaled. The metrics are compared with their targets by the tolerance of the engine.
+func (a *DecisionEngine) Recommend(gpa *autoscaling.GeneralPodAutoscaler, key string,
+	scale *autoscalinginternal.Scale) (Recommendation, error) {
+	return a.RecommendWithTolerance(gpa, key, scale, a.replicaCalc.tolerance)
+}
+
+// RecommendWithTolerance is Recommend comparing the metrics with their targets by the tolerance of this
+// decision, e.g. the tolerance overridden for the GPA, the tolerance of the engine is left as is.
+func (a *DecisionEngine) RecommendWithTolerance(gpa *autoscaling.GeneralPodAutoscaler, key string,
+	scale *autoscalinginternal.Scale, tolerance float64) (Recommendation, error) {
+	if tolerance != a.replicaCalc.tolerance {
+		// the engine is copied along with its calculator, its recommendations, events and samples are shared
+		engine := *a
+		calc := *a.replicaCalc
+		calc.tolerance = tolerance
+		engine.replicaCalc = &calc
+		return engine.recommend(gpa, key, scale)
+	}
+	return a.recommend(gpa, key, scale)
+}
+
+func (a *DecisionEngine) recommend(gpa *autoscaling.GeneralPodAutoscaler, key string,
+	scale *autoscalinginternal.Scale) (Recommendation, error) {
+	var (
+		recommendation        Recommendation
+		metricDesiredReplicas int32
+		metricTimestamp       time.Time
+		err                   error
+	)
+	currentReplicas := scale.Spec.Replicas
+	minReplicas := getMinReplicas(gpa)
+	a.recordInitialRecommendation(currentReplicas, key)
+
+	recommendation.Mode = MetricDecisionMode
+	switch {
+	case gpa.Spec.MetricMode != nil && !a.inMetricWindow(gpa):
+		metricDesiredReplicas = gpa.Spec.MetricMode.Window.OffHoursReplicas
+		recommendation.MetricName = "off-hours replicas out of the metric window"
+		metricTimestamp = a.clock.Now()
+	case gpa.Spec.MetricMode != nil:
+		metricDesiredReplicas, recommendation.MetricName, recommendation.MetricStatuses, metricTimestamp, err =
+			a.computeReplicasForMetrics(gpa, scale, gpa.Spec.MetricMode.Metrics)
+		if err == nil {
+			metricDesiredReplicas = holdUntilBreached(gpa, currentReplicas, metricDesiredReplicas, a.clock.Now())
+		}
+	default:
+		metricDesiredReplicas, recommendation.MetricName, recommendation.MetricStatuses, metricTimestamp, err =
+			a.computeReplicasForSimple(gpa, scale)
+		recommendation.Mode = recommendation.MetricName
+	}
+	if err != nil {
+		return recommendation, err
+	}
+	metricDesiredReplicas, recommendation.MetricName = a.resolveConflict(gpa, metricDesiredReplicas,
+		recommendation.MetricName)
+	if gpa.Status.ConflictWinner == autoscaling.CronConflictWinner {
+		recommendation.Mode = scalercore.Cron
+	}
+	metricDesiredReplicas = a.applyMultiplier(gpa, metricDesiredReplicas)
+	recommendation.ProposedReplicas = metricDesiredReplicas
+	if floor := min(peakFloor(gpa, metricDesiredReplicas, a.clock.Now()), gpa.Spec.MaxReplicas); floor > minReplicas {
+		minReplicas = floor
+	}
+	//Record event when the metricDesiredReplicas is greater than gpa.Spec.MaxReplicas
+	if metricDesiredReplicas > gpa.Spec.MaxReplicas {
+		a.eventRecorder.Eventf(gpa, v1.EventTypeWarning, "FailedRescale", "DesiredReplicas:%v cannot exceed the MaxReplicas: %v", metricDesiredReplicas, gpa.Spec.MaxReplicas)
+	}
+	decisionLog(gpa, 4).Infof("proposing %v desired replicas (based on %s from %s) for %s/%s/%s",
+		metricDesiredReplicas, recommendation.MetricName, metricTimestamp,
+		gpa.Spec.ScaleTargetRef.Kind, gpa.Namespace, gpa.Spec.ScaleTargetRef.Name)
+
+	desiredReplicas := smoothRecommendation(gpa, metricDesiredReplicas)
+	desiredReplicas = pidRecommendation(gpa, currentReplicas, desiredReplicas, minReplicas, a.clock.Now())
+	desiredReplicas = a.bufferForReadinessGap(gpa, scale, desiredReplicas)
+	desiredReplicas = a.bufferForPodAge(gpa, scale, desiredReplicas)
+	if desiredReplicas > currentReplicas {
+		recommendation.Reason = fmt.Sprintf("%s above target", recommendation.MetricName)
+	}
+	if desiredReplicas < currentReplicas {
+		recommendation.Reason = "All metrics below target"
+	}
+	if behavior := modeBehavior(gpa.Spec.Behavior, recommendation.Mode); !hasScalingRules(behavior) {
+		desiredReplicas = a.normalizeDesiredReplicas(gpa, key, currentReplicas, desiredReplicas, minReplicas)
+	} else {
+		desiredReplicas = a.normalizeDesiredReplicasWithBehaviors(gpa, behavior, key, currentReplicas, desiredReplicas,
+			minReplicas)
+	}
+	desiredReplicas = quantizeReplicas(gpa, desiredReplicas, minReplicas)
+	desiredReplicas = delayScaleDownAfterScaleUp(gpa, currentReplicas, desiredReplicas, a.clock.Now())
+	decisionLog(gpa, 4).Infof("desire: %v, current: %v, min: %v, max: %v",
+		desiredReplicas, currentReplicas, minReplicas, gpa.Spec.MaxReplicas)
+	recommendation.DesiredReplicas = desiredReplicas
+	return recommendation, nil
+}
+
+// RecordScale records the target of the GPA is scaled from the previous replicas to the new replicas,
+// the scale events limit the later recommendations by the scaling policies of the behavior. The time of a
+// scale-up is recorded in the status of the GPA.
+func (a *DecisionEngine) RecordScale(gpa *autoscaling.GeneralPodAutoscaler, key string, prevReplicas, newReplicas int32) {
+	a.storeScaleEvent(gpa.Spec.Behavior, key, prevReplicas, newReplicas)
+	recordScaleUp(gpa, prevReplicas, newReplicas, a.clock.Now())
+}
+
+// Forget drops the recommendations, scale events and metric samples of the GPA.
+func (a *DecisionEngine) Forget(key string) {
+	delete(a.recommendations, key)
+	delete(a.scaleUpEvents, key)
+	delete(a.scaleDownEvents, key)
+	delete(a.metricSamples, key)
+}
+
+// inMetricWindow returns true if the metrics of the GPA drive the scaling now, i.e. it sets no window or now
+// matches the schedule of the window. A schedule which can not be parsed never gates the metrics.
+func (a *DecisionEngine) inMetricWindow(gpa *autoscaling.GeneralPodAutoscaler) bool {
+	window := gpa.Spec.MetricMode.Window
+	if window == nil {
+		return true
+	}
+	matches, err := scalercore.ScheduleMatches(window.Schedule, a.clock.Now())
+	if err != nil {
+		klog.Warningf("Ignore invalid metric window %q of gpa %s/%s: %v", window.Schedule, gpa.Namespace, gpa.Name, err)
+		return true
+	}
+	if !matches {
+		setCondition(gpa, autoscaling.ScalingActive, v1.ConditionTrue, "OutOfMetricWindow",
+			"the metrics are out of the window %q, the target is pinned to %d off-hours replicas",
+			window.Schedule, window.OffHoursReplicas)
+	}
+	return matches
+}
+
+// getMinReplicas returns the min replicas of the GPA, which defaults to 1
+func getMinReplicas(gpa *autoscaling.GeneralPodAutoscaler) int32 {
+	if gpa.Spec.MinReplicas != nil {
+		return *gpa.Spec.MinReplicas
+	}
+	return 1
+}
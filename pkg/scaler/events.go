@@ -0,0 +1,50 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+// SetEventLogging mirrors the events recorded by the controller into the logs as key=value pairs, so that the
+// alerts on the logs can match the reasons of the events. It is disabled by default.
+func (a *GeneralController) SetEventLogging(enabled bool) {
+	if a.eventLogWatcher != nil {
+		a.eventLogWatcher.Stop()
+		a.eventLogWatcher = nil
+	}
+	if enabled {
+		a.eventLogWatcher = logEvents(a.broadcaster, klog.Infof)
+	}
+}
+
+// logEvents logs the events of the broadcaster by logf until the returned watcher is stopped
+func logEvents(broadcaster record.EventBroadcaster, logf func(format string, args ...interface{})) watch.Interface {
+	return broadcaster.StartEventWatcher(func(event *v1.Event) {
+		logf("%s", eventLogLine(event))
+	})
+}
+
+// eventLogLine formats the event as key=value pairs, the message is quoted
+func eventLogLine(event *v1.Event) string {
+	return fmt.Sprintf("event type=%s reason=%s kind=%s namespace=%s name=%s message=%q",
+		event.Type, event.Reason, event.InvolvedObject.Kind, event.InvolvedObject.Namespace,
+		event.InvolvedObject.Name, event.Message)
+}
@@ -0,0 +1,57 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func TestLogEvents(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(autoscalingv1alpha1.SchemeGroupVersion, &autoscalingv1alpha1.GeneralPodAutoscaler{})
+	broadcaster := record.NewBroadcaster()
+	defer broadcaster.Shutdown()
+	lines := make(chan string, 1)
+	watcher := logEvents(broadcaster, func(format string, args ...interface{}) {
+		lines <- fmt.Sprintf(format, args...)
+	})
+	defer watcher.Stop()
+
+	gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		TypeMeta:   metav1.TypeMeta{Kind: "GeneralPodAutoscaler", APIVersion: "autoscaling.ocgi.dev/v1alpha1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+	}
+	recorder := broadcaster.NewRecorder(s, v1.EventSource{Component: "pod-autoscaler"})
+	recorder.Eventf(gpa, v1.EventTypeWarning, "FailedRescale", "DesiredReplicas:%v cannot exceed the MaxReplicas: %v", 12, 10)
+
+	select {
+	case line := <-lines:
+		assert.Equal(t, `event type=Warning reason=FailedRescale kind=GeneralPodAutoscaler namespace=default `+
+			`name=web message="DesiredReplicas:12 cannot exceed the MaxReplicas: 10"`, line)
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("the event was not logged")
+	}
+}
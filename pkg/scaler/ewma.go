@@ -0,0 +1,108 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	metricsclient "github.com/ocgi/general-pod-autoscaler/pkg/metrics"
+)
+
+// ewmaMetricsClient serves the resource usage of each pod smoothed by its exponentially weighted moving average
+// instead of the latest usage. The average of a pod is kept as its only metric sample, and moved towards each new
+// usage by the weight 1 - 2^(-elapsed/halfLife), so that it decays like the load average of unix.
+type ewmaMetricsClient struct {
+	metricsclient.MetricsClient
+	engine   *DecisionEngine
+	key      string
+	halfLife time.Duration
+}
+
+// ewmaReplicaCalc returns the replica calculator getting the resource metrics of the GPA smoothed with the
+// half-life, or the replica calculator of the engine if the half-life is not set.
+func (a *DecisionEngine) ewmaReplicaCalc(gpa *autoscaling.GeneralPodAutoscaler, halfLifeSeconds *int32) *ReplicaCalculator {
+	if halfLifeSeconds == nil || *halfLifeSeconds <= 0 {
+		return a.replicaCalc
+	}
+	calc := *a.replicaCalc
+	calc.metricsClient = &ewmaMetricsClient{
+		MetricsClient: a.replicaCalc.metricsClient,
+		engine:        a,
+		key:           gpa.Namespace + "/" + gpa.Name,
+		halfLife:      time.Duration(*halfLifeSeconds) * time.Second,
+	}
+	return &calc
+}
+
+// ewma moves the average of the metric towards the value by the time elapsed since the last value, the first
+// value is the average as is.
+func ewma(average, value float64, elapsed, halfLife time.Duration) float64 {
+	if elapsed <= 0 {
+		return average
+	}
+	weight := 1 - math.Pow(2, -elapsed.Seconds()/halfLife.Seconds())
+	return average + weight*(value-average)
+}
+
+// smooth records the value of the metric and returns its moving average
+func (c *ewmaMetricsClient) smooth(metricName string, value int64, timestamp time.Time) int64 {
+	if timestamp.IsZero() {
+		timestamp = c.engine.clock.Now()
+	}
+	if c.engine.metricSamples[c.key] == nil {
+		c.engine.metricSamples[c.key] = map[string][]timestampedMetricSample{}
+	}
+	average := value
+	if samples := c.engine.metricSamples[c.key][metricName]; len(samples) > 0 {
+		last := samples[len(samples)-1]
+		if !timestamp.After(last.timestamp) {
+			// the metric is not refreshed since last sync
+			return last.value
+		}
+		average = int64(math.Round(ewma(float64(last.value), float64(value), timestamp.Sub(last.timestamp), c.halfLife)))
+	}
+	c.engine.metricSamples[c.key][metricName] = []timestampedMetricSample{{value: average, timestamp: timestamp}}
+	return average
+}
+
+// GetResourceMetric implements metrics.MetricsClient, the averages of the pods which are gone are dropped
+func (c *ewmaMetricsClient) GetResourceMetric(resource v1.ResourceName, namespace string, selector labels.Selector,
+	container string) (metricsclient.PodMetricsInfo, time.Time, error) {
+	metrics, timestamp, err := c.MetricsClient.GetResourceMetric(resource, namespace, selector, container)
+	if err != nil {
+		return metrics, timestamp, err
+	}
+	prefix := fmt.Sprintf("ewma resource metric %s(%s)/", resource, container)
+	for pod, metric := range metrics {
+		metric.Value = c.smooth(prefix+pod, metric.Value, metric.Timestamp)
+		metrics[pod] = metric
+	}
+	for name := range c.engine.metricSamples[c.key] {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if _, ok := metrics[strings.TrimPrefix(name, prefix)]; !ok {
+			delete(c.engine.metricSamples[c.key], name)
+		}
+	}
+	return metrics, timestamp, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/util"
+)
+
+// computeReplicasForExpression evaluates the expression of the metric mode with the current values of the
+// named metrics which are fetched successfully, the result is rounded up to the desired replicas.
+func (a *DecisionEngine) computeReplicasForExpression(gpa *autoscaling.GeneralPodAutoscaler, currentReplicas int32,
+	metricSpecs []autoscaling.MetricSpec, statuses []autoscaling.MetricStatus, valid []bool) (int32, string, error) {
+	expression := gpa.Spec.MetricMode.Expression
+	values := map[string]float64{
+		util.ExpressionCurrentReplicas: float64(currentReplicas),
+	}
+	for i, metricSpec := range metricSpecs {
+		if metricSpec.Name == "" || !valid[i] {
+			continue
+		}
+		if value, ok := metricStatusValue(statuses[i]); ok {
+			values[metricSpec.Name] = value
+		}
+	}
+	result, err := util.EvaluateExpression(expression, values)
+	if err != nil {
+		err = fmt.Errorf("failed to evaluate expression %q: %v", expression, err)
+		setCondition(gpa, autoscaling.ScalingActive, v1.ConditionFalse, "FailedEvaluateExpression", err.Error())
+		return 0, "", err
+	}
+	decisionLog(gpa, 4).Infof("GPA %s/%s expression %q evaluates to %v with %v",
+		gpa.Namespace, gpa.Name, expression, result, values)
+	// the result is clamped before it is converted, a large result would overflow the int32
+	replicas := int32(math.Min(math.Max(math.Ceil(result), 0), math.MaxInt32))
+	return replicas, fmt.Sprintf("expression %s", expression), nil
+}
+
+// metricStatusValue returns the current value of the metric, the value is preferred over the average value,
+// and the average value is preferred over the average utilization. The projected value is returned for
+// derivative metrics.
+func metricStatusValue(status autoscaling.MetricStatus) (float64, bool) {
+	current := metricStatusCurrent(status)
+	if current == nil {
+		return 0, false
+	}
+	switch {
+	case current.Value != nil:
+		return float64(current.Value.MilliValue()) / 1000, true
+	case current.AverageValue != nil:
+		return float64(current.AverageValue.MilliValue()) / 1000, true
+	case current.AverageUtilization != nil:
+		return float64(*current.AverageUtilization), true
+	}
+	return 0, false
+}
+
+// metricStatusCurrent returns the current value of the metric of any source, the projected value for
+// derivative metrics, or nil if the status has no source.
+func metricStatusCurrent(status autoscaling.MetricStatus) *autoscaling.MetricValueStatus {
+	var current *autoscaling.MetricValueStatus
+	switch {
+	case status.Object != nil:
+		current = &status.Object.Current
+	case status.Pods != nil:
+		current = &status.Pods.Current
+	case status.Resource != nil:
+		current = &status.Resource.Current
+	case status.ContainerResource != nil:
+		current = &status.ContainerResource.Current
+	case status.External != nil:
+		current = &status.External.Current
+	case status.Derivative != nil:
+		current = &status.Derivative.Projected
+	case status.Probe != nil:
+		current = &status.Probe.Current
+	case status.KafkaLag != nil:
+		current = &status.KafkaLag.Current
+	case status.CounterDelta != nil:
+		current = &status.CounterDelta.Current
+	case status.Ratio != nil:
+		current = &status.Ratio.Current
+	case status.Concurrency != nil:
+		current = &status.Concurrency.Current
+	case status.Scrape != nil:
+		current = &status.Scrape.Current
+	case status.BucketTable != nil:
+		current = &status.BucketTable.Current
+	}
+	return current
+}
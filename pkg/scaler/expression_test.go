@@ -0,0 +1,99 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func TestComputeReplicasForExpression(t *testing.T) {
+	utilization := int32(70)
+	metricSpecs := []autoscalingv1alpha1.MetricSpec{
+		{Name: "qps", Type: autoscalingv1alpha1.ExternalMetricSourceType},
+		{Name: "cpu", Type: autoscalingv1alpha1.ResourceMetricSourceType},
+		{Name: "latency", Type: autoscalingv1alpha1.PodsMetricSourceType},
+	}
+	statuses := []autoscalingv1alpha1.MetricStatus{
+		{
+			Type: autoscalingv1alpha1.ExternalMetricSourceType,
+			External: &autoscalingv1alpha1.ExternalMetricStatus{
+				Current: autoscalingv1alpha1.MetricValueStatus{Value: resource.NewQuantity(420, resource.DecimalSI)},
+			},
+		},
+		{
+			Type: autoscalingv1alpha1.ResourceMetricSourceType,
+			Resource: &autoscalingv1alpha1.ResourceMetricStatus{
+				Current: autoscalingv1alpha1.MetricValueStatus{AverageUtilization: &utilization},
+			},
+		},
+		{
+			Type: autoscalingv1alpha1.PodsMetricSourceType,
+			Pods: &autoscalingv1alpha1.PodsMetricStatus{
+				Current: autoscalingv1alpha1.MetricValueStatus{AverageValue: resource.NewMilliQuantity(250, resource.DecimalSI)},
+			},
+		},
+	}
+	for _, c := range []struct {
+		expression string
+		valid      []bool
+		replicas   int32
+		errMsg     string
+	}{
+		{expression: "ceil(qps / 50) + 1", replicas: 10},
+		{expression: "max(ceil(qps / 100), ceil(currentReplicas * cpu / 50))", replicas: 6},
+		{expression: "latency > 0.2 ? currentReplicas + 2 : currentReplicas", replicas: 6},
+		{expression: "qps / 1000", replicas: 1},
+		{expression: "qps - 1000", replicas: 0},
+		{expression: "qps * 10000000000", replicas: math.MaxInt32},
+		{expression: "ceil(qps / 50)", valid: []bool{false, true, true}, errMsg: "undefined variable qps"},
+		{expression: "qps / 0", errMsg: "not a finite number"},
+		{expression: "qps > 100", errMsg: "not a number"},
+	} {
+		t.Run(c.expression, func(t *testing.T) {
+			gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+				Spec: autoscalingv1alpha1.GeneralPodAutoscalerSpec{
+					AutoScalingDrivenMode: autoscalingv1alpha1.AutoScalingDrivenMode{
+						MetricMode: &autoscalingv1alpha1.MetricMode{Metrics: metricSpecs, Expression: c.expression},
+					},
+				},
+			}
+			valid := c.valid
+			if valid == nil {
+				valid = []bool{true, true, true}
+			}
+			controller := &DecisionEngine{}
+			replicas, metric, err := controller.computeReplicasForExpression(gpa, 4, metricSpecs, statuses, valid)
+			if c.errMsg != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), c.errMsg)
+				}
+				cond := getCondition(gpa.Status.Conditions, autoscalingv1alpha1.ScalingActive)
+				if assert.NotNil(t, cond) {
+					assert.Equal(t, "FailedEvaluateExpression", cond.Reason)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.replicas, replicas)
+			assert.Equal(t, "expression "+c.expression, metric)
+		})
+	}
+}
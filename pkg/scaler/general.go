@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -33,15 +34,20 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	appsinformers "k8s.io/client-go/informers/apps/v1"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
 	scaleclient "k8s.io/client-go/scale"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 
@@ -52,12 +58,19 @@ import (
 	metricsclient "github.com/ocgi/general-pod-autoscaler/pkg/metrics"
 	"github.com/ocgi/general-pod-autoscaler/pkg/scalercore"
 	"github.com/ocgi/general-pod-autoscaler/pkg/util"
+	"github.com/ocgi/general-pod-autoscaler/pkg/validation"
 )
 
 var (
 	scaleUpLimitFactor  = 2.0
 	scaleUpLimitMinimum = 4.0
-	computeByLimitsKey  = "compute-by-limits"
+	computeByLimitsKey  = validation.ComputeByLimitsAnnotation
+	// normalizePerPodKey averages the resource utilization of each pod against its own request or limit
+	normalizePerPodKey = "autoscaling.ocgi.io/normalize-per-pod"
+	// debugKey enables verbose decision logging for a single GPA regardless of the global verbosity
+	debugKey = "autoscaling.ocgi.io/debug"
+	// defaultReadinessGapSeconds is the default gapSeconds of readinessGapBuffer
+	defaultReadinessGapSeconds = 60
 )
 
 type timestampedRecommendation struct {
@@ -75,15 +88,13 @@ type timestampedScaleEvent struct {
 // in the system with the actual deployments/replication controllers they
 // control.
 type GeneralController struct {
+	// DecisionEngine computes the desired replicas of the GPAs
+	*DecisionEngine
+
 	scaleNamespacer scaleclient.ScalesGetter
 	gpaNamespacer   autoscalingclient.GeneralPodAutoscalersGetter
 	mapper          apimeta.RESTMapper
 
-	replicaCalc   *ReplicaCalculator
-	eventRecorder record.EventRecorder
-
-	downscaleStabilisationWindow time.Duration
-
 	// gpaLister is able to list/get GPAs from the shared cache from the informer passed in to
 	// NewGeneralController.
 	gpaLister       autoscalinglisters.GeneralPodAutoscalerLister
@@ -94,22 +105,71 @@ type GeneralController struct {
 	podLister       corelisters.PodLister
 	podListerSynced cache.InformerSynced
 
-	// Controllers that need to be synced
-	queue workqueue.RateLimitingInterface
+	// deploymentLister is able to get the target deployments from the shared cache from the informer added by
+	// AddDeploymentInformer, to check their rollouts and minReadySeconds. The checks are skipped if it is not set.
+	deploymentLister       appslisters.DeploymentLister
+	deploymentListerSynced cache.InformerSynced
 
-	// Latest unstabilized recommendations for each autoscaler.
-	recommendations map[string][]timestampedRecommendation
+	// targetListersSynced are the synced funcs of informers added by AddTargetInformer
+	targetListersSynced []cache.InformerSynced
+	// defaultsListerSynced is the synced func of the informer added by AddDefaultsInformer
+	defaultsListerSynced cache.InformerSynced
 
-	// Latest autoscaler events
-	scaleUpEvents   map[string][]timestampedScaleEvent
-	scaleDownEvents map[string][]timestampedScaleEvent
+	// nodeListerSynced is the synced func of the node informer added by AddNodeInformer or SetCapacityLimit
+	nodeListerSynced cache.InformerSynced
+	// resyncPeriod set by flags, used if the defaults do not override it
+	resyncPeriod time.Duration
+	rateLimiter  workqueue.RateLimiter
+
+	// Controllers that need to be synced
+	queue workqueue.RateLimitingInterface
 
 	doingCron sync.Map
+
+	// identity claims the scale lease before scaling, set by SetIdentity
+	identity string
+
+	// impersonatingScales write the scales of the GPAs impersonating service accounts, set by SetImpersonatingScales
+	impersonatingScales *impersonatingScales
+
+	// minScaleInterval is the minimum interval between two scale writes of a GPA, set by SetMinScaleInterval
+	minScaleInterval time.Duration
+	// lastScaleWrites is the time of the last scale write of each GPA
+	lastScaleWrites map[string]time.Time
+
+	// observeFirstReconcile only records the recommendation of the first reconcile of each GPA, set by
+	// SetObserveFirstReconcile
+	observeFirstReconcile bool
+	// reconciled are the GPAs reconciled since the controller started, only kept with observeFirstReconcile
+	reconciled map[string]bool
+	// resetBackoffOnChange reconciles a GPA at once when its spec or annotations change, set by
+	// SetResetBackoffOnChange
+	resetBackoffOnChange bool
+
+	// history keeps the last decisions of each GPA, set by SetDecisionHistory
+	history *decisionHistory
+	// recommendationMetrics keeps the last recommendation of each GPA, set by SetRecommendationMetrics
+	recommendationMetrics *recommendationStore
+	// latestDecisions keeps the last decision of each GPA, set by SetRecommendationService
+	latestDecisions *decisionHistory
+
+	// broadcaster broadcasts the events recorded by the DecisionEngine
+	broadcaster record.EventBroadcaster
+	// eventLogWatcher logs the events of the broadcaster, set by SetEventLogging
+	eventLogWatcher watch.Interface
+
+	// shutdownTimeout is how long the reconciles in progress are waited for once stopped, set by SetShutdownTimeout
+	shutdownTimeout time.Duration
+
+	// scaleUpdateRetries and scaleUpdateBackoff retry the scale updates failing transiently, set by SetScaleUpdateRetry
+	scaleUpdateRetries int
+	scaleUpdateBackoff time.Duration
 }
 
 // NewGeneralController creates a new GeneralController.
 func NewGeneralController(
 	evtNamespacer v1core.EventsGetter,
+	secretNamespacer v1core.SecretsGetter,
 	scaleNamespacer scaleclient.ScalesGetter,
 	gpaNamespacer autoscalingclient.GeneralPodAutoscalersGetter,
 	mapper apimeta.RESTMapper,
@@ -130,17 +190,21 @@ func NewGeneralController(
 	broadcaster.StartRecordingToSink(&v1core.EventSinkImpl{Interface: evtNamespacer.Events(v1.NamespaceAll)})
 	recorder := broadcaster.NewRecorder(s, v1.EventSource{Component: "pod-autoscaler"})
 
+	rateLimiter := NewDefaultGPARateLimiter(resyncPeriod)
 	gpaController := &GeneralController{
-		eventRecorder:                recorder,
-		scaleNamespacer:              scaleNamespacer,
-		gpaNamespacer:                gpaNamespacer,
-		downscaleStabilisationWindow: downscaleStabilisationWindow,
+		scaleNamespacer: scaleNamespacer,
+		gpaNamespacer:   gpaNamespacer,
 		queue: workqueue.NewNamedRateLimitingQueue(
-			NewDefaultGPARateLimiter(resyncPeriod), "podautoscaler"),
+			rateLimiter, "podautoscaler"),
+		rateLimiter:     rateLimiter,
+		resyncPeriod:    resyncPeriod,
 		mapper:          mapper,
-		recommendations: map[string][]timestampedRecommendation{},
-		scaleUpEvents:   map[string][]timestampedScaleEvent{},
-		scaleDownEvents: map[string][]timestampedScaleEvent{},
+		lastScaleWrites: map[string]time.Time{},
+		broadcaster:     broadcaster,
+		shutdownTimeout: DefaultShutdownTimeout,
+
+		scaleUpdateRetries: DefaultScaleUpdateRetries,
+		scaleUpdateBackoff: DefaultScaleUpdateBackoff,
 	}
 
 	gpaInformer.Informer().AddEventHandlerWithResyncPeriod(
@@ -157,14 +221,18 @@ func NewGeneralController(
 	gpaController.podLister = podInformer.Lister()
 	gpaController.podListerSynced = podInformer.Informer().HasSynced
 
-	replicaCalc := NewReplicaCalculator(
+	gpaController.DecisionEngine = NewDecisionEngine(
 		metricsClient,
 		gpaController.podLister,
+		secretNamespacer,
+		recorder,
+		clock.RealClock{},
 		tolerance,
+		downscaleStabilisationWindow,
 		cpuInitializationPeriod,
 		delayOfInitialReadinessStatus,
 	)
-	gpaController.replicaCalc = replicaCalc
+	gpaController.SetGPALister(gpaController.gpaLister)
 
 	return gpaController
 }
@@ -177,18 +245,42 @@ func (a *GeneralController) Run(stopCh <-chan struct{}) {
 	klog.Infof("Starting GPA controller")
 	defer klog.Infof("Shutting down GPA controller")
 
-	if !cache.WaitForNamedCacheSync("GPA", stopCh, a.gpaListerSynced, a.podListerSynced) {
+	cacheSyncs := append([]cache.InformerSynced{a.gpaListerSynced, a.podListerSynced}, a.targetListersSynced...)
+	if a.deploymentListerSynced != nil {
+		cacheSyncs = append(cacheSyncs, a.deploymentListerSynced)
+	}
+	if a.defaultsListerSynced != nil {
+		cacheSyncs = append(cacheSyncs, a.defaultsListerSynced)
+	}
+	if a.nodeListerSynced != nil {
+		cacheSyncs = append(cacheSyncs, a.nodeListerSynced)
+	}
+	if !cache.WaitForNamedCacheSync("GPA", stopCh, cacheSyncs...) {
 		return
 	}
 
 	// start a single worker (we may wish to start more in the future)
-	go wait.Until(a.worker, time.Second, stopCh)
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		wait.Until(func() { a.worker(stopCh) }, time.Second, stopCh)
+	}()
 	<-stopCh
+	// no new GPA is taken from the queue, but the scales and the statuses being written are finished
+	a.drain(&workers)
 }
 
 // obj could be an *v1.GeneralPodAutoscaler, or a DeletionFinalStateUnknown marker item.
 func (a *GeneralController) updateGPA(old, cur interface{}) {
-	a.enqueueGPA(cur)
+	if a.resetBackoffOnChange && changedByUser(old, cur) {
+		a.resetBackoff(cur)
+	} else {
+		a.enqueueGPA(cur)
+	}
+	if desiredReplicasChanged(old, cur) {
+		a.enqueueMirroringGPAs(cur)
+	}
 }
 
 // obj could be an *v1.GeneralPodAutoscaler, or a DeletionFinalStateUnknown marker item.
@@ -214,20 +306,24 @@ func (a *GeneralController) deleteGPA(obj interface{}) {
 
 	// TODO: could we leak if we fail to get the key?
 	a.queue.Forget(key)
+	a.enqueueMirroringGPAs(obj)
 }
 
-func (a *GeneralController) worker() {
-	for a.processNextWorkItem() {
+func (a *GeneralController) worker(stopCh <-chan struct{}) {
+	for a.processNextWorkItem(stopCh) {
 	}
 	klog.Infof("general pod autoscaler controller worker shutting down")
 }
 
-func (a *GeneralController) processNextWorkItem() bool {
+func (a *GeneralController) processNextWorkItem(stopCh <-chan struct{}) bool {
 	key, quit := a.queue.Get()
 	if quit {
 		return false
 	}
 	defer a.queue.Done(key)
+	if stopping(stopCh) {
+		return false
+	}
 
 	deleted, err := a.reconcileKey(key.(string))
 	if err != nil {
@@ -251,7 +347,7 @@ func (a *GeneralController) processNextWorkItem() bool {
 // computeReplicasForMetrics computes the desired number of replicas for the metric specifications listed in the GPA,
 // returning the maximum  of the computed replica counts, a description of the associated metric, and the statuses of
 // all metrics computed.
-func (a *GeneralController) computeReplicasForMetrics(gpa *autoscaling.GeneralPodAutoscaler,
+func (a *DecisionEngine) computeReplicasForMetrics(gpa *autoscaling.GeneralPodAutoscaler,
 	scale *autoscalinginternal.Scale, metricSpecs []autoscaling.MetricSpec) (replicas int32, metric string,
 	statuses []autoscaling.MetricStatus, timestamp time.Time, err error) {
 
@@ -271,6 +367,12 @@ func (a *GeneralController) computeReplicasForMetrics(gpa *autoscaling.GeneralPo
 		return 0, "", nil, time.Time{}, fmt.Errorf(errMsg)
 	}
 
+	if usesResourceMetrics(metricSpecs) && !metricsclient.Available(a.replicaCalc.metricsClient) {
+		setCondition(gpa, autoscaling.ScalingActive, v1.ConditionFalse, "MetricsUnavailable",
+			"the GPA controller is waiting for the resource metrics API to become reachable")
+		return 0, "", nil, time.Time{}, metricsclient.ErrMetricsUnavailable
+	}
+
 	specReplicas := scale.Spec.Replicas
 	statusReplicas := scale.Status.Replicas
 	statuses = make([]autoscaling.MetricStatus, len(metricSpecs))
@@ -278,6 +380,11 @@ func (a *GeneralController) computeReplicasForMetrics(gpa *autoscaling.GeneralPo
 	invalidMetricsCount := 0
 	var invalidMetricError error
 	var invalidMetricCondition autoscaling.GeneralPodAutoscalerCondition
+	var invalidMetricMessages []string
+	valid := make([]bool, len(metricSpecs))
+	proposals := make([]int32, len(metricSpecs))
+	names := make([]string, len(metricSpecs))
+	driving := -1
 
 	for i, metricSpec := range metricSpecs {
 		replicaCountProposal, metricNameProposal, timestampProposal, condition, err := a.computeReplicasForMetric(gpa,
@@ -288,11 +395,21 @@ func (a *GeneralController) computeReplicasForMetrics(gpa *autoscaling.GeneralPo
 				invalidMetricError = err
 			}
 			invalidMetricsCount++
+			invalidMetricMessages = append(invalidMetricMessages,
+				fmt.Sprintf("%s failed: %v", metricSourceName(i, metricSpec), err))
+			decisionLog(gpa, 4).Infof("GPA %s/%s metric %d (%s) failed: %v", gpa.Namespace, gpa.Name, i, metricSpec.Type, err)
+		} else {
+			valid[i] = true
+			proposals[i] = replicaCountProposal
+			names[i] = metricNameProposal
+			decisionLog(gpa, 4).Infof("GPA %s/%s metric %d (%s) proposes %d replicas, spec replicas: %d, status replicas: %d",
+				gpa.Namespace, gpa.Name, i, metricNameProposal, replicaCountProposal, specReplicas, statusReplicas)
 		}
 		if err == nil && (replicas == 0 || replicaCountProposal > replicas) {
 			timestamp = timestampProposal
 			replicas = replicaCountProposal
 			metric = metricNameProposal
+			driving = i
 		}
 	}
 
@@ -300,9 +417,33 @@ func (a *GeneralController) computeReplicasForMetrics(gpa *autoscaling.GeneralPo
 	if invalidMetricsCount >= len(metricSpecs) {
 		setCondition(gpa, invalidMetricCondition.Type, invalidMetricCondition.Status, invalidMetricCondition.Reason,
 			invalidMetricCondition.Message)
+		a.recordDrivingMetric(gpa, -1, "", 0, metricSpecs, statuses)
 		return 0, "", statuses, time.Time{}, fmt.Errorf("invalid metrics (%v invalid out of %v), "+
 			"first error is: %v", invalidMetricsCount, len(metricSpecs), invalidMetricError)
 	}
+	// the failed metrics are skipped, while the replicas are computed from the others
+	if invalidMetricsCount > 0 {
+		setCondition(gpa, autoscaling.PartialMetrics, v1.ConditionTrue, "SomeMetricsFailed",
+			"the replicas are computed without the failed metrics (%v failed out of %v): %s", invalidMetricsCount,
+			len(metricSpecs), strings.Join(invalidMetricMessages, "; "))
+	} else if getCondition(gpa.Status.Conditions, autoscaling.PartialMetrics) != nil {
+		setCondition(gpa, autoscaling.PartialMetrics, v1.ConditionFalse, "AllMetricsSucceeded",
+			"the replicas are computed from all the metrics")
+	}
+	if gpa.Spec.MetricMode != nil && gpa.Spec.MetricMode.Expression != "" {
+		replicas, metric, err = a.computeReplicasForExpression(gpa, specReplicas, metricSpecs, statuses, valid)
+		driving = -1
+		if err != nil {
+			a.recordDrivingMetric(gpa, driving, "", 0, metricSpecs, statuses)
+			return 0, "", statuses, time.Time{}, err
+		}
+	} else if gpa.Spec.MetricMode != nil && gpa.Spec.MetricMode.Blend != nil && specReplicas > 0 {
+		// without any replica there is nothing to multiply the utilization by, the largest proposal scales
+		// the target up from zero
+		replicas, metric = a.computeReplicasForBlend(gpa, specReplicas, metricSpecs, statuses, valid, proposals, names)
+		driving = -1
+	}
+	a.recordDrivingMetric(gpa, driving, metric, replicas, metricSpecs, statuses)
 	setCondition(gpa, autoscaling.ScalingActive, v1.ConditionTrue, "ValidMetricFound",
 		"the GPA was able to successfully calculate a replica count from %s", metric)
 	return replicas, metric, statuses, timestamp, nil
@@ -311,7 +452,18 @@ func (a *GeneralController) computeReplicasForMetrics(gpa *autoscaling.GeneralPo
 // computeReplicasForSimple computes the desired number of replicas for the metric specifications listed in the GPA,
 // returning the maximum  of the computed replica counts, a description of the associated metric, and the statuses of
 // all metrics computed.
-func (a *GeneralController) computeReplicasForSimple(gpa *autoscaling.GeneralPodAutoscaler,
+// usesResourceMetrics returns true if any of the metrics is a resource or container resource metric
+func usesResourceMetrics(metricSpecs []autoscaling.MetricSpec) bool {
+	for _, metricSpec := range metricSpecs {
+		if metricSpec.Type == autoscaling.ResourceMetricSourceType ||
+			metricSpec.Type == autoscaling.ContainerResourceMetricSourceType {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *DecisionEngine) computeReplicasForSimple(gpa *autoscaling.GeneralPodAutoscaler,
 	scale *autoscalinginternal.Scale) (replicas int32, metric string, statuses []autoscaling.MetricStatus,
 	timestamp time.Time, err error) {
 	if scale.Status.Selector == "" {
@@ -341,26 +493,41 @@ func (a *GeneralController) computeReplicasForSimple(gpa *autoscaling.GeneralPod
 	}
 	replicas = replicaCountProposal
 	setCondition(gpa, autoscaling.ScalingActive, v1.ConditionTrue, "ValidMetricFound", "the GPA was able to successfully calculate a replica count from %s", metric)
-	timestamp = time.Now()
+	timestamp = a.clock.Now()
 	return replicas, modeNameProposal, statuses, timestamp, nil
 }
 
+// metricSourceName names the metric of the index in the conditions, by its name if set
+func metricSourceName(index int, metricSpec autoscaling.MetricSpec) string {
+	if metricSpec.Name != "" {
+		return fmt.Sprintf("metric %s (%s)", metricSpec.Name, metricSpec.Type)
+	}
+	return fmt.Sprintf("metric %d (%s)", index, metricSpec.Type)
+}
+
 // buildScalerChain build scaler chain for gpa scaler
-func (a *GeneralController) buildScalerChain(gpa *autoscaling.GeneralPodAutoscaler) []scalercore.Scaler {
+func (a *DecisionEngine) buildScalerChain(gpa *autoscaling.GeneralPodAutoscaler) []scalercore.Scaler {
 	var scalerChain []scalercore.Scaler
 	if gpa.Spec.WebhookMode != nil {
-		scalerChain = append(scalerChain, scalercore.NewWebhookScaler(gpa.Spec.WebhookMode))
+		scalerChain = append(scalerChain, scalercore.NewWebhookScaler(gpa.Spec.WebhookMode, a.secretNamespacer))
 	}
 	if gpa.Spec.TimeMode != nil {
-		scalerChain = append(scalerChain, scalercore.NewCronScaler(gpa.Spec.TimeMode.TimeRanges))
+		scalerChain = append(scalerChain, scalercore.NewCronScalerAt(gpa.Spec.TimeMode, a.configMapNamespacer, a.clock.Now()))
+	}
+	if gpa.Spec.ClusterProportionalMode != nil {
+		scalerChain = append(scalerChain, scalercore.NewClusterProportionalScaler(gpa.Spec.ClusterProportionalMode,
+			a.clusterNodeLister))
+	}
+	if gpa.Spec.MirrorMode != nil {
+		scalerChain = append(scalerChain, scalercore.NewMirrorScaler(gpa.Spec.MirrorMode, a.gpaLister))
 	}
 	return scalerChain
 }
 
 // Computes the desired number of replicas for a specific gpa and metric specification,
 // returning the metric status and a proposed condition to be set on the GPA object.
-func (a *GeneralController) computeStatusForResourceMetricGeneric(currentReplicas int32, target autoscaling.MetricTarget,
-	resourceName v1.ResourceName, namespace string, container string, selector labels.Selector, computeByLimits bool) (replicaCountProposal int32,
+func (a *DecisionEngine) computeStatusForResourceMetricGeneric(currentReplicas int32, target autoscaling.MetricTarget,
+	resourceName v1.ResourceName, namespace string, container string, selector labels.Selector, computeByLimits, normalizePerPod bool) (replicaCountProposal int32,
 	metricStatus *autoscaling.MetricValueStatus, timestampProposal time.Time, metricNameProposal string,
 	condition autoscaling.GeneralPodAutoscalerCondition, err error) {
 	if target.AverageValue != nil {
@@ -382,16 +549,12 @@ func (a *GeneralController) computeStatusForResourceMetricGeneric(currentReplica
 	}
 
 	targetUtilization := *target.AverageUtilization
-	replicaCountProposal, percentageProposal, rawProposal, timestampProposal, err := a.replicaCalc.GetResourceReplicas(currentReplicas, targetUtilization, resourceName, namespace, selector, container, computeByLimits)
+	replicaCountProposal, percentageProposal, rawProposal, timestampProposal, err := a.replicaCalc.GetResourceReplicas(currentReplicas, targetUtilization, resourceName, namespace, selector, container, computeByLimits, normalizePerPod)
 	if err != nil {
 		return 0, nil, time.Time{}, "", condition, fmt.Errorf("failed to get %s utilization: %v", resourceName, err)
 	}
-	computeResourceUtilizationRatioBy := "request"
-	if computeByLimits {
-		computeResourceUtilizationRatioBy = "limit"
-	}
 
-	metricNameProposal = fmt.Sprintf("%s resource utilization (percentage of %s)", resourceName, computeResourceUtilizationRatioBy)
+	metricNameProposal = fmt.Sprintf("%s resource utilization (percentage of %s)", resourceName, utilizationRatioBy(computeByLimits, normalizePerPod))
 	status := autoscaling.MetricValueStatus{
 		AverageUtilization: &percentageProposal,
 		AverageValue:       resource.NewMilliQuantity(rawProposal, resource.DecimalSI),
@@ -401,7 +564,7 @@ func (a *GeneralController) computeStatusForResourceMetricGeneric(currentReplica
 
 // Computes the desired number of replicas for a specific gpa and metric specification,
 // returning the metric status and a proposed condition to be set on the GPA object.
-func (a *GeneralController) computeReplicasForMetric(gpa *autoscaling.GeneralPodAutoscaler, spec autoscaling.MetricSpec,
+func (a *DecisionEngine) computeReplicasForMetric(gpa *autoscaling.GeneralPodAutoscaler, spec autoscaling.MetricSpec,
 	specReplicas, statusReplicas int32, selector labels.Selector, status *autoscaling.MetricStatus) (replicaCountProposal int32, metricNameProposal string,
 	timestampProposal time.Time, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
 
@@ -441,6 +604,46 @@ func (a *GeneralController) computeReplicasForMetric(gpa *autoscaling.GeneralPod
 		if err != nil {
 			return 0, "", time.Time{}, condition, err
 		}
+	case autoscaling.DerivativeMetricSourceType:
+		replicaCountProposal, timestampProposal, metricNameProposal, condition, err = a.computeStatusForDerivativeMetric(specReplicas, statusReplicas, spec, gpa, selector, status)
+		if err != nil {
+			return 0, "", time.Time{}, condition, err
+		}
+	case autoscaling.ProbeMetricSourceType:
+		replicaCountProposal, timestampProposal, metricNameProposal, condition, err = a.computeStatusForProbeMetric(specReplicas, spec, gpa, selector, status)
+		if err != nil {
+			return 0, "", time.Time{}, condition, err
+		}
+	case autoscaling.KafkaLagMetricSourceType:
+		replicaCountProposal, timestampProposal, metricNameProposal, condition, err = a.computeStatusForKafkaLagMetric(statusReplicas, spec, gpa, status)
+		if err != nil {
+			return 0, "", time.Time{}, condition, err
+		}
+	case autoscaling.CounterDeltaMetricSourceType:
+		replicaCountProposal, timestampProposal, metricNameProposal, condition, err = a.computeStatusForCounterDeltaMetric(statusReplicas, spec, gpa, status)
+		if err != nil {
+			return 0, "", time.Time{}, condition, err
+		}
+	case autoscaling.RatioMetricSourceType:
+		replicaCountProposal, timestampProposal, metricNameProposal, condition, err = a.computeStatusForRatioMetric(specReplicas, spec, gpa, selector, status)
+		if err != nil {
+			return 0, "", time.Time{}, condition, err
+		}
+	case autoscaling.ConcurrencyMetricSourceType:
+		replicaCountProposal, timestampProposal, metricNameProposal, condition, err = a.computeStatusForConcurrencyMetric(specReplicas, spec, gpa, selector, status)
+		if err != nil {
+			return 0, "", time.Time{}, condition, err
+		}
+	case autoscaling.ScrapeMetricSourceType:
+		replicaCountProposal, timestampProposal, metricNameProposal, condition, err = a.computeStatusForScrapeMetric(specReplicas, spec, gpa, selector, status)
+		if err != nil {
+			return 0, "", time.Time{}, condition, err
+		}
+	case autoscaling.BucketTableMetricSourceType:
+		replicaCountProposal, timestampProposal, metricNameProposal, condition, err = a.computeStatusForBucketTableMetric(spec, gpa, status)
+		if err != nil {
+			return 0, "", time.Time{}, condition, err
+		}
 	default:
 		errMsg := fmt.Sprintf("unknown metric source type %q", string(spec.Type))
 		err = fmt.Errorf(errMsg)
@@ -459,21 +662,31 @@ func (a *GeneralController) reconcileKey(key string) (deleted bool, err error) {
 	gpa, err := a.gpaLister.GeneralPodAutoscalers(namespace).Get(name)
 	if errors.IsNotFound(err) {
 		klog.Infof("General Pod Autoscaler %s has been deleted in %s", name, namespace)
-		delete(a.recommendations, key)
-		delete(a.scaleUpEvents, key)
-		delete(a.scaleDownEvents, key)
+		a.Forget(key)
+		delete(a.lastScaleWrites, key)
+		delete(a.reconciled, key)
+		if a.history != nil {
+			a.history.forget(key)
+		}
+		if a.recommendationMetrics != nil {
+			a.recommendationMetrics.forget(key)
+		}
+		if a.latestDecisions != nil {
+			a.latestDecisions.forget(key)
+		}
 		return true, nil
 	}
 	if err != nil {
 		return false, err
 	}
-	return false, a.reconcileAutoscaler(gpa, key)
+	// make a copy so that defaults are never applied to the shared informer cache
+	return false, a.reconcileAutoscaler(gpa.DeepCopy(), key)
 }
 
 // computeStatusForObjectMetric computes the desired number of replicas for the specified metric of type ObjectMetricSourceType.
-func (a *GeneralController) computeStatusForObjectMetric(specReplicas, statusReplicas int32, metricSpec autoscaling.MetricSpec, gpa *autoscaling.GeneralPodAutoscaler, selector labels.Selector, status *autoscaling.MetricStatus, metricSelector labels.Selector) (replicas int32, timestamp time.Time, metricName string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
+func (a *DecisionEngine) computeStatusForObjectMetric(specReplicas, statusReplicas int32, metricSpec autoscaling.MetricSpec, gpa *autoscaling.GeneralPodAutoscaler, selector labels.Selector, status *autoscaling.MetricStatus, metricSelector labels.Selector) (replicas int32, timestamp time.Time, metricName string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
 	if metricSpec.Object.Target.Type == autoscaling.ValueMetricType {
-		replicaCountProposal, utilizationProposal, timestampProposal, err := a.replicaCalc.GetObjectMetricReplicas(specReplicas, metricSpec.Object.Target.Value.MilliValue(), metricSpec.Object.Metric.Name, gpa.Namespace, &metricSpec.Object.DescribedObject, selector, metricSelector)
+		replicaCountProposal, utilizationProposal, timestampProposal, err := a.windowedReplicaCalc(gpa, metricSpec.Object.WindowSeconds).GetObjectMetricReplicas(specReplicas, metricSpec.Object.Target.Value.MilliValue(), metricSpec.Object.Metric.Name, gpa.Namespace, &metricSpec.Object.DescribedObject, selector, metricSelector)
 		if err != nil {
 			condition := a.getUnableComputeReplicaCountCondition(gpa, "FailedGetObjectMetric", err)
 			return 0, timestampProposal, "", condition, err
@@ -493,7 +706,7 @@ func (a *GeneralController) computeStatusForObjectMetric(specReplicas, statusRep
 		}
 		return replicaCountProposal, timestampProposal, fmt.Sprintf("%s metric %s", metricSpec.Object.DescribedObject.Kind, metricSpec.Object.Metric.Name), autoscaling.GeneralPodAutoscalerCondition{}, nil
 	} else if metricSpec.Object.Target.Type == autoscaling.AverageValueMetricType {
-		replicaCountProposal, utilizationProposal, timestampProposal, err := a.replicaCalc.GetObjectPerPodMetricReplicas(statusReplicas, metricSpec.Object.Target.AverageValue.MilliValue(), metricSpec.Object.Metric.Name, gpa.Namespace, &metricSpec.Object.DescribedObject, metricSelector)
+		replicaCountProposal, utilizationProposal, timestampProposal, err := a.windowedReplicaCalc(gpa, metricSpec.Object.WindowSeconds).GetObjectPerPodMetricReplicas(statusReplicas, metricSpec.Object.Target.AverageValue.MilliValue(), metricSpec.Object.Metric.Name, gpa.Namespace, &metricSpec.Object.DescribedObject, metricSelector)
 		if err != nil {
 			condition := a.getUnableComputeReplicaCountCondition(gpa, "FailedGetObjectMetric", err)
 			return 0, time.Time{}, "", condition, fmt.Errorf("failed to get %s object metric: %v", metricSpec.Object.Metric.Name, err)
@@ -519,8 +732,8 @@ func (a *GeneralController) computeStatusForObjectMetric(specReplicas, statusRep
 }
 
 // computeStatusForPodsMetric computes the desired number of replicas for the specified metric of type PodsMetricSourceType.
-func (a *GeneralController) computeStatusForPodsMetric(currentReplicas int32, metricSpec autoscaling.MetricSpec, gpa *autoscaling.GeneralPodAutoscaler, selector labels.Selector, status *autoscaling.MetricStatus, metricSelector labels.Selector) (replicaCountProposal int32, timestampProposal time.Time, metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
-	replicaCountProposal, utilizationProposal, timestampProposal, err := a.replicaCalc.GetMetricReplicas(currentReplicas, metricSpec.Pods.Target.AverageValue.MilliValue(), metricSpec.Pods.Metric.Name, gpa.Namespace, selector, metricSelector)
+func (a *DecisionEngine) computeStatusForPodsMetric(currentReplicas int32, metricSpec autoscaling.MetricSpec, gpa *autoscaling.GeneralPodAutoscaler, selector labels.Selector, status *autoscaling.MetricStatus, metricSelector labels.Selector) (replicaCountProposal int32, timestampProposal time.Time, metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
+	replicaCountProposal, utilizationProposal, timestampProposal, err := a.windowedReplicaCalc(gpa, metricSpec.Pods.WindowSeconds).GetMetricReplicas(currentReplicas, metricSpec.Pods.Target.AverageValue.MilliValue(), metricSpec.Pods.Metric.Name, gpa.Namespace, selector, metricSelector)
 	if err != nil {
 		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetPodsMetric", err)
 		return 0, timestampProposal, "", condition, err
@@ -542,10 +755,10 @@ func (a *GeneralController) computeStatusForPodsMetric(currentReplicas int32, me
 }
 
 // computeStatusForResourceMetric computes the desired number of replicas for the specified metric of type ResourceMetricSourceType.
-func (a *GeneralController) computeStatusForResourceMetric(currentReplicas int32, metricSpec autoscaling.MetricSpec, gpa *autoscaling.GeneralPodAutoscaler, selector labels.Selector, status *autoscaling.MetricStatus) (replicaCountProposal int32, timestampProposal time.Time, metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
+func (a *DecisionEngine) computeStatusForResourceMetric(currentReplicas int32, metricSpec autoscaling.MetricSpec, gpa *autoscaling.GeneralPodAutoscaler, selector labels.Selector, status *autoscaling.MetricStatus) (replicaCountProposal int32, timestampProposal time.Time, metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
 	if metricSpec.Resource.Target.AverageValue != nil {
 		var rawProposal int64
-		replicaCountProposal, rawProposal, timestampProposal, err := a.replicaCalc.GetRawResourceReplicas(currentReplicas, metricSpec.Resource.Target.AverageValue.MilliValue(), metricSpec.Resource.Name, gpa.Namespace, selector, "")
+		replicaCountProposal, rawProposal, timestampProposal, err := a.ewmaReplicaCalc(gpa, metricSpec.Resource.HalfLifeSeconds).GetRawResourceReplicas(currentReplicas, metricSpec.Resource.Target.AverageValue.MilliValue(), metricSpec.Resource.Name, gpa.Namespace, selector, "")
 		if err != nil {
 			condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetResourceMetric", err)
 			return 0, time.Time{}, "", condition, fmt.Errorf("failed to get %s utilization: %v", metricSpec.Resource.Name, err)
@@ -569,17 +782,14 @@ func (a *GeneralController) computeStatusForResourceMetric(currentReplicas int32
 		return 0, time.Time{}, "", condition, fmt.Errorf(errMsg)
 	}
 	computeByLimits := isComputeByLimits(gpa)
+	normalizePerPod := isNormalizePerPod(gpa)
 	targetUtilization := *metricSpec.Resource.Target.AverageUtilization
-	replicaCountProposal, percentageProposal, rawProposal, timestampProposal, err := a.replicaCalc.GetResourceReplicas(currentReplicas, targetUtilization, metricSpec.Resource.Name, gpa.Namespace, selector, "", computeByLimits)
+	replicaCountProposal, percentageProposal, rawProposal, timestampProposal, err := a.ewmaReplicaCalc(gpa, metricSpec.Resource.HalfLifeSeconds).GetResourceReplicas(currentReplicas, targetUtilization, metricSpec.Resource.Name, gpa.Namespace, selector, "", computeByLimits, normalizePerPod)
 	if err != nil {
 		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetResourceMetric", err)
 		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get %s utilization: %v", metricSpec.Resource.Name, err)
 	}
-	computeResourceUtilizationRatioBy := "request"
-	if computeByLimits {
-		computeResourceUtilizationRatioBy = "limit"
-	}
-	metricNameProposal = fmt.Sprintf("%s resource utilization (percentage of %s)", metricSpec.Resource.Name, computeResourceUtilizationRatioBy)
+	metricNameProposal = fmt.Sprintf("%s resource utilization (percentage of %s)", metricSpec.Resource.Name, utilizationRatioBy(computeByLimits, normalizePerPod))
 	*status = autoscaling.MetricStatus{
 		Type: autoscaling.ResourceMetricSourceType,
 		Resource: &autoscaling.ResourceMetricStatus{
@@ -595,12 +805,17 @@ func (a *GeneralController) computeStatusForResourceMetric(currentReplicas int32
 
 // computeStatusForContainerResourceMetric computes the desired number of replicas for the specified metric of
 // type ResourceMetricSourceType.
-func (a *GeneralController) computeStatusForContainerResourceMetric(currentReplicas int32,
+func (a *DecisionEngine) computeStatusForContainerResourceMetric(currentReplicas int32,
 	metricSpec autoscaling.MetricSpec, gpa *autoscaling.GeneralPodAutoscaler,
 	selector labels.Selector, status *autoscaling.MetricStatus) (replicaCountProposal int32, timestampProposal time.Time,
 	metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
+	if err := a.validateContainerOfPods(gpa.Namespace, selector, metricSpec.ContainerResource.Container); err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "InvalidContainerResourceMetric", err)
+		return 0, time.Time{}, "", condition, err
+	}
 	computeByLimits := isComputeByLimits(gpa)
-	replicaCountProposal, metricValueStatus, timestampProposal, metricNameProposal, condition, err := a.computeStatusForResourceMetricGeneric(currentReplicas, metricSpec.ContainerResource.Target, metricSpec.ContainerResource.Name, gpa.Namespace, metricSpec.ContainerResource.Container, selector, computeByLimits)
+	normalizePerPod := isNormalizePerPod(gpa)
+	replicaCountProposal, metricValueStatus, timestampProposal, metricNameProposal, condition, err := a.computeStatusForResourceMetricGeneric(currentReplicas, metricSpec.ContainerResource.Target, metricSpec.ContainerResource.Name, gpa.Namespace, metricSpec.ContainerResource.Container, selector, computeByLimits, normalizePerPod)
 	if err != nil {
 		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetContainerResourceMetric", err)
 		return replicaCountProposal, timestampProposal, metricNameProposal, condition, err
@@ -616,10 +831,35 @@ func (a *GeneralController) computeStatusForContainerResourceMetric(currentRepli
 	return replicaCountProposal, timestampProposal, metricNameProposal, condition, nil
 }
 
+// validateContainerOfPods checks the container is in the pod template of the target, i.e. in each pod of the target,
+// so that a misspelled container is reported rather than the pods missing its metrics.
+func (a *DecisionEngine) validateContainerOfPods(namespace string, selector labels.Selector, container string) error {
+	pods, err := a.replicaCalc.podLister.Pods(namespace).List(selector)
+	if err != nil {
+		return fmt.Errorf("unable to get pods while validating container %s: %v", container, err)
+	}
+	for _, pod := range pods {
+		found := false
+		var names []string
+		for _, c := range pod.Spec.Containers {
+			if c.Name == container {
+				found = true
+				break
+			}
+			names = append(names, c.Name)
+		}
+		if !found {
+			return fmt.Errorf("container %s is not in the pod template of the target, pod %s has containers %v",
+				container, pod.Name, names)
+		}
+	}
+	return nil
+}
+
 // computeStatusForExternalMetric computes the desired number of replicas for the specified metric of type ExternalMetricSourceType.
-func (a *GeneralController) computeStatusForExternalMetric(specReplicas, statusReplicas int32, metricSpec autoscaling.MetricSpec, gpa *autoscaling.GeneralPodAutoscaler, selector labels.Selector, status *autoscaling.MetricStatus) (replicaCountProposal int32, timestampProposal time.Time, metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
+func (a *DecisionEngine) computeStatusForExternalMetric(specReplicas, statusReplicas int32, metricSpec autoscaling.MetricSpec, gpa *autoscaling.GeneralPodAutoscaler, selector labels.Selector, status *autoscaling.MetricStatus) (replicaCountProposal int32, timestampProposal time.Time, metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
 	if metricSpec.External.Target.AverageValue != nil {
-		replicaCountProposal, utilizationProposal, timestampProposal, err := a.replicaCalc.GetExternalPerPodMetricReplicas(statusReplicas,
+		replicaCountProposal, utilizationProposal, timestampProposal, err := a.windowedReplicaCalc(gpa, metricSpec.External.WindowSeconds).GetExternalPerPodMetricReplicas(statusReplicas,
 			metricSpec.External.Target.AverageValue.MilliValue(), metricSpec.External.Metric.Name, gpa.Namespace, metricSpec.External.Metric.Selector)
 		if err != nil {
 			condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetExternalMetric", err)
@@ -642,7 +882,7 @@ func (a *GeneralController) computeStatusForExternalMetric(specReplicas, statusR
 			metricSpec.External.Metric.Name, metricSpec.External.Metric.Selector), autoscaling.GeneralPodAutoscalerCondition{}, nil
 	}
 	if metricSpec.External.Target.Value != nil {
-		replicaCountProposal, utilizationProposal, timestampProposal, err := a.replicaCalc.GetExternalMetricReplicas(specReplicas,
+		replicaCountProposal, utilizationProposal, timestampProposal, err := a.windowedReplicaCalc(gpa, metricSpec.External.WindowSeconds).GetExternalMetricReplicas(specReplicas,
 			metricSpec.External.Target.Value.MilliValue(), metricSpec.External.Metric.Name, gpa.Namespace, metricSpec.External.Metric.Selector, selector)
 		if err != nil {
 			condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetExternalMetric", err)
@@ -671,15 +911,16 @@ func (a *GeneralController) computeStatusForExternalMetric(specReplicas, statusR
 	return 0, time.Time{}, "", condition, fmt.Errorf(errMsg)
 }
 
-func (a *GeneralController) recordInitialRecommendation(currentReplicas int32, key string) {
+func (a *DecisionEngine) recordInitialRecommendation(currentReplicas int32, key string) {
 	if a.recommendations[key] == nil {
-		a.recommendations[key] = []timestampedRecommendation{{currentReplicas, time.Now()}}
+		a.recommendations[key] = []timestampedRecommendation{{currentReplicas, a.clock.Now()}}
 	}
 }
 
 func (a *GeneralController) reconcileAutoscaler(gpa *autoscaling.GeneralPodAutoscaler, key string) error {
 	// make a copy so that we never mutate the shared informer cache (conversion can mutate the object)
 	gpaStatusOriginal := gpa.Status.DeepCopy()
+	tolerance := a.PrepareDecision(gpa)
 
 	reference := fmt.Sprintf("%s/%s/%s", gpa.Spec.ScaleTargetRef.Kind, gpa.Namespace, gpa.Spec.ScaleTargetRef.Name)
 
@@ -711,6 +952,9 @@ func (a *GeneralController) reconcileAutoscaler(gpa *autoscaling.GeneralPodAutos
 	}
 
 	scale, targetGR, err := a.scaleForResourceMappings(gpa.Namespace, gpa.Spec.ScaleTargetRef.Name, mappings)
+	if errors.IsNotFound(err) {
+		return a.handleTargetMissing(gpaStatusOriginal, gpa, reference, err)
+	}
 	if err != nil {
 		a.eventRecorder.Event(gpa, v1.EventTypeWarning, "FailedGetScale", err.Error())
 		setCondition(gpa, autoscaling.AbleToScale, v1.ConditionFalse, "FailedGetScale",
@@ -735,28 +979,20 @@ func (a *GeneralController) reconcileAutoscaler(gpa *autoscaling.GeneralPodAutos
 	setCondition(gpa, autoscaling.AbleToScale, v1.ConditionTrue, "SucceededGetScale",
 		"the GPA controller was able to get the target's current scale")
 	currentReplicas := scale.Spec.Replicas
-	a.recordInitialRecommendation(currentReplicas, key)
 
-	var (
-		metricStatuses        []autoscaling.MetricStatus
-		metricDesiredReplicas int32
-		metricName            string
-	)
+	var metricStatuses []autoscaling.MetricStatus
 
 	desiredReplicas := int32(0)
 	rescaleReason := ""
-
-	var minReplicas int32
-
-	if gpa.Spec.MinReplicas != nil {
-		minReplicas = *gpa.Spec.MinReplicas
-	} else {
-		// Default value
-		minReplicas = 1
-	}
+	minReplicas := getMinReplicas(gpa)
 
 	rescale := true
-	if scale.Spec.Replicas == 0 && minReplicas != 0 {
+	if scale.Spec.Replicas == 0 && minReplicas != 0 && gpa.Spec.RecoverFromZero != nil {
+		rescaleReason = "Current number of replicas is zero"
+		desiredReplicas = getBootstrapReplicas(gpa, minReplicas)
+		setCondition(gpa, autoscaling.ScalingActive, v1.ConditionTrue, "RecoveringFromZero",
+			"the replica count of the target is zero, scaling it to %d bootstrap replicas", desiredReplicas)
+	} else if scale.Spec.Replicas == 0 && minReplicas != 0 {
 		// Autoscaling is disabled for this resource
 		desiredReplicas = 0
 		rescale = false
@@ -768,21 +1004,32 @@ func (a *GeneralController) reconcileAutoscaler(gpa *autoscaling.GeneralPodAutos
 	} else if currentReplicas < minReplicas {
 		rescaleReason = "Current number of replicas below Spec.MinReplicas"
 		desiredReplicas = minReplicas
+	} else if a.pausedForRollout(gpa, targetGK) {
+		desiredReplicas = currentReplicas
+		rescale = false
+	} else if remaining := a.minReadyRemaining(gpa, targetGK, scale.Status.Selector); remaining > 0 {
+		decisionLog(gpa, 2).Infof("Pods of %s are not ready for long enough, defer the decision for %v",
+			reference, remaining)
+		// decide as soon as the last ready pod has been ready for long enough
+		a.queue.AddAfter(key, remaining)
+		desiredReplicas = currentReplicas
+		rescale = false
 	} else {
-		var metricTimestamp time.Time
 		if isEmpty(gpa.Spec.AutoScalingDrivenMode) {
 			return nil
 		}
-		switch {
-		case gpa.Spec.MetricMode != nil:
-			metricDesiredReplicas, metricName, metricStatuses, metricTimestamp, err = a.computeReplicasForMetrics(gpa,
-				scale, gpa.Spec.MetricMode.Metrics)
-		default:
-			metricDesiredReplicas, metricName, metricStatuses, metricTimestamp, err = a.computeReplicasForSimple(gpa,
-				scale)
+		recommendation, err := a.RecommendWithTolerance(gpa, key, scale, tolerance)
+		decision := DecisionRecord{
+			CurrentReplicas:     currentReplicas,
+			MinReplicas:         minReplicas,
+			MaxReplicas:         gpa.Spec.MaxReplicas,
+			MetricName:          recommendation.MetricName,
+			MetricStatuses:      recommendation.MetricStatuses,
+			RecommendedReplicas: recommendation.DesiredReplicas,
 		}
-
 		if err != nil {
+			decision.Error = err.Error()
+			a.recordDecision(key, decision)
 			a.setCurrentReplicasInStatus(gpa, currentReplicas)
 			if err := a.updateStatusIfNeeded(gpaStatusOriginal, gpa); err != nil {
 				utilruntime.HandleError(err)
@@ -790,36 +1037,58 @@ func (a *GeneralController) reconcileAutoscaler(gpa *autoscaling.GeneralPodAutos
 			a.eventRecorder.Event(gpa, v1.EventTypeWarning, "FailedComputeMetricsReplicas", err.Error())
 			return fmt.Errorf("failed to compute desired number of replicas based on listed metrics for %s: %v", reference, err)
 		}
-		//Record event when the metricDesiredReplicas is greater than gpa.Spec.MaxReplicas
-		if metricDesiredReplicas > gpa.Spec.MaxReplicas {
-			a.eventRecorder.Eventf(gpa, v1.EventTypeWarning, "FailedRescale", "DesiredReplicas:%v cannot exceed the MaxReplicas: %v", metricDesiredReplicas, gpa.Spec.MaxReplicas)
-		}
-		klog.V(4).Infof("proposing %v desired replicas (based on %s from %s) for %s",
-			metricDesiredReplicas, metricName, metricTimestamp, reference)
-		rescaleMetric := ""
-		if metricDesiredReplicas > desiredReplicas {
-			desiredReplicas = metricDesiredReplicas
-			rescaleMetric = metricName
-		}
-		if desiredReplicas > currentReplicas {
-			rescaleReason = fmt.Sprintf("%s above target", rescaleMetric)
+		metricStatuses = recommendation.MetricStatuses
+		desiredReplicas = a.LimitDecision(gpa, scale.Status.Selector, currentReplicas, recommendation.DesiredReplicas)
+		rescaleReason = recommendation.Reason
+		rescale = desiredReplicas != currentReplicas
+		decision.DesiredReplicas = desiredReplicas
+		decision.Reason = rescaleReason
+		a.recordDecision(key, decision)
+		a.recordRecommendation(key, desiredReplicas)
+		if a.observingFirstReconcile(gpa, key, desiredReplicas) {
+			decisionLog(gpa, 2).Infof("GPA %s is reconciled for the first time since the controller started, "+
+				"defer scaling %s to %d", key, reference, desiredReplicas)
+			rescale = false
 		}
-		if desiredReplicas < currentReplicas {
-			rescaleReason = "All metrics below target"
+		if remaining := a.warmupRemaining(gpa, desiredReplicas); remaining > 0 {
+			decisionLog(gpa, 2).Infof("GPA %s is warming up, defer scaling %s to %d for %v",
+				key, reference, desiredReplicas, remaining)
+			// resume acting on the recommendations as soon as the warmup elapses
+			a.queue.AddAfter(key, remaining)
+			rescale = false
 		}
-		if gpa.Spec.Behavior == nil {
-			desiredReplicas = a.normalizeDesiredReplicas(gpa, key, currentReplicas, desiredReplicas, minReplicas)
-		} else {
-			desiredReplicas = a.normalizeDesiredReplicasWithBehaviors(gpa, key, currentReplicas, desiredReplicas, minReplicas)
+	}
+
+	if rescale {
+		if remaining := a.scaleIntervalRemaining(key); remaining > 0 {
+			decisionLog(gpa, 2).Infof("Target %s was scaled less than %v ago, defer scaling to %d for %v",
+				reference, a.minScaleInterval, desiredReplicas, remaining)
+			setCondition(gpa, autoscaling.AbleToScale, v1.ConditionTrue, "MinScaleIntervalNotElapsed",
+				"the target was scaled less than %v ago, the replicas are computed again once the interval elapses", a.minScaleInterval)
+			// coalesce the recomputations within the interval into a single sync once it elapses
+			a.queue.AddAfter(key, remaining)
+			rescale = false
 		}
-		klog.V(4).Infof("desire: %v, current: %v, min: %v, max: %v",
-			desiredReplicas, currentReplicas, minReplicas, gpa.Spec.MaxReplicas)
-		rescale = desiredReplicas != currentReplicas
+	}
+
+	if rescale && !a.claimScaleLease(gpa) {
+		setCondition(gpa, autoscaling.AbleToScale, v1.ConditionFalse, "ScaleLeaseNotClaimed",
+			"the GPA controller was unable to claim the scale lease, the target may be scaled by another controller")
+		rescale = false
 	}
 
 	if rescale {
-		scale.Spec.Replicas = desiredReplicas
-		_, err = a.scaleNamespacer.Scales(gpa.Namespace).Update(targetGR, scale)
+		var scales scaleclient.ScalesGetter
+		if scales, err = a.scalesFor(gpa); err == nil {
+			err = a.updateScale(scales, gpa.Namespace, targetGR, scale, currentReplicas, desiredReplicas)
+		}
+		if err == errTargetScaledConcurrently {
+			klog.Infof("Target %s is scaled concurrently, skip scaling to %d", reference, desiredReplicas)
+			setCondition(gpa, autoscaling.AbleToScale, v1.ConditionFalse, "ScaledConcurrently",
+				"the target scale was changed while the GPA controller updating it, the replicas are computed again on the next sync")
+			a.setCurrentReplicasInStatus(gpa, currentReplicas)
+			return a.updateStatusIfNeeded(gpaStatusOriginal, gpa)
+		}
 		if err != nil {
 			a.eventRecorder.Eventf(gpa, v1.EventTypeWarning, "FailedRescale",
 				"New size: %d; reason: %s; error: %v", desiredReplicas, rescaleReason, err.Error())
@@ -835,18 +1104,153 @@ func (a *GeneralController) reconcileAutoscaler(gpa *autoscaling.GeneralPodAutos
 			"SucceededRescale", "the GPA controller was able to update the target scale to %d", desiredReplicas)
 		a.eventRecorder.Eventf(gpa, v1.EventTypeNormal, "SuccessfulRescale",
 			"New size: %d; reason: %s", desiredReplicas, rescaleReason)
-		a.storeScaleEvent(gpa.Spec.Behavior, key, currentReplicas, desiredReplicas)
+		a.RecordScale(gpa, key, currentReplicas, desiredReplicas)
+		recordScaleHistory(gpa, currentReplicas, desiredReplicas, rescaleReason, a.clock.Now())
+		a.lastScaleWrites[key] = a.clock.Now()
 		klog.Infof("Successful rescale of %s, old size: %d, new size: %d, reason: %s",
 			gpa.Name, currentReplicas, desiredReplicas, rescaleReason)
 	} else {
-		klog.V(4).Infof("decided not to scale %s to %v (last scale time was %s)",
+		decisionLog(gpa, 4).Infof("decided not to scale %s to %v (last scale time was %s)",
 			reference, desiredReplicas, gpa.Status.LastScaleTime)
 		desiredReplicas = currentReplicas
 	}
+	// the GPAs at their max replicas may need more replicas at any time
+	a.adaptResync(key, rescale || desiredReplicas >= gpa.Spec.MaxReplicas)
 	a.setStatus(gpa, currentReplicas, desiredReplicas, metricStatuses, rescale)
 	return a.updateStatusIfNeeded(gpaStatusOriginal, gpa)
 }
 
+// SetMinScaleInterval sets the minimum interval between two scale writes of a GPA, regardless of how often the GPA
+// is synced, e.g. by a flapping metric. The interval is not enforced if it is 0.
+func (a *GeneralController) SetMinScaleInterval(interval time.Duration) {
+	a.minScaleInterval = interval
+}
+
+// scaleIntervalRemaining returns how long the GPA has to wait before the next scale write, 0 if it can be written now
+func (a *GeneralController) scaleIntervalRemaining(key string) time.Duration {
+	if a.minScaleInterval <= 0 {
+		return 0
+	}
+	last, ok := a.lastScaleWrites[key]
+	if !ok {
+		return 0
+	}
+	if elapsed := a.clock.Now().Sub(last); elapsed < a.minScaleInterval {
+		return a.minScaleInterval - elapsed
+	}
+	return 0
+}
+
+// getBootstrapReplicas returns the replicas the target is scaled to from zero, bounded by min and max replicas
+func getBootstrapReplicas(gpa *autoscaling.GeneralPodAutoscaler, minReplicas int32) int32 {
+	replicas := minReplicas
+	if gpa.Spec.RecoverFromZero.BootstrapReplicas != nil {
+		replicas = max(replicas, *gpa.Spec.RecoverFromZero.BootstrapReplicas)
+	}
+	return min(replicas, gpa.Spec.MaxReplicas)
+}
+
+// AddDeploymentInformer lets the GPAs check the rollouts and the minReadySeconds of their target deployments listed
+// by the informer. It must be called before the informer is started.
+func (a *GeneralController) AddDeploymentInformer(deploymentInformer appsinformers.DeploymentInformer) {
+	a.deploymentLister = deploymentInformer.Lister()
+	a.deploymentListerSynced = deploymentInformer.Informer().HasSynced
+}
+
+// pausedForRollout returns true if freezeOnRollout is enabled and the target deployment is rolling out.
+// It sets the ScalingPausedDuringRollout condition accordingly.
+func (a *GeneralController) pausedForRollout(gpa *autoscaling.GeneralPodAutoscaler, targetGK schema.GroupKind) bool {
+	if a.deploymentLister == nil || !gpa.Spec.FreezeOnRollout || targetGK.Kind != "Deployment" ||
+		(targetGK.Group != "apps" && targetGK.Group != "extensions") {
+		return false
+	}
+	deployment, err := a.deploymentLister.Deployments(gpa.Namespace).Get(gpa.Spec.ScaleTargetRef.Name)
+	if err != nil {
+		klog.Warningf("Get deployment %s/%s of gpa %v failed, ignore rollout check: %v",
+			gpa.Namespace, gpa.Spec.ScaleTargetRef.Name, gpa.Name, err)
+		return false
+	}
+	if IsDeploymentRollingOut(deployment) {
+		setCondition(gpa, autoscaling.ScalingPausedDuringRollout, v1.ConditionTrue, "RolloutInProgress",
+			"scaling is deferred until the rollout of the target deployment completes")
+		return true
+	}
+	setCondition(gpa, autoscaling.ScalingPausedDuringRollout, v1.ConditionFalse, "NoRolloutInProgress",
+		"the target deployment is not rolling out")
+	return false
+}
+
+// warmupRemaining returns how long the GPA is still warming up by warmupSeconds since its creation, during
+// which the recommended replicas are not applied. It sets the Warmup condition accordingly.
+func (a *GeneralController) warmupRemaining(gpa *autoscaling.GeneralPodAutoscaler, recommendation int32) time.Duration {
+	if gpa.Spec.WarmupSeconds == nil {
+		return 0
+	}
+	warmup := time.Duration(*gpa.Spec.WarmupSeconds) * time.Second
+	remaining := gpa.CreationTimestamp.Add(warmup).Sub(a.clock.Now())
+	if remaining > 0 {
+		setCondition(gpa, autoscaling.Warmup, v1.ConditionTrue, "WarmingUp",
+			"the GPA was created less than %v ago, the recommendation of %d replicas is not applied for %v",
+			warmup, recommendation, remaining.Round(time.Second))
+		return remaining
+	}
+	setCondition(gpa, autoscaling.Warmup, v1.ConditionFalse, "WarmupElapsed",
+		"the warmup of %v has elapsed, the recommendations are applied", warmup)
+	return 0
+}
+
+// bufferForReadinessGap counts the ready pods of the target, and adds the buffer replicas of readinessGapBuffer
+// to the desired replicas if the ready pods stay fewer than the desired replicas.
+func (a *DecisionEngine) bufferForReadinessGap(gpa *autoscaling.GeneralPodAutoscaler,
+	scale *autoscalinginternal.Scale, desiredReplicas int32) int32 {
+	if gpa.Spec.ReadinessGapBuffer == nil {
+		return desiredReplicas
+	}
+	selector, err := labels.Parse(scale.Status.Selector)
+	if err != nil {
+		klog.Warningf("Parse selector of gpa %s/%s failed, ignore readiness gap: %v", gpa.Namespace, gpa.Name, err)
+		return desiredReplicas
+	}
+	readyReplicas, err := a.replicaCalc.getReadyPodsCount(gpa.Namespace, selector)
+	if err != nil {
+		klog.Warningf("Count ready pods of gpa %s/%s failed, ignore readiness gap: %v", gpa.Namespace, gpa.Name, err)
+		return desiredReplicas
+	}
+	return applyReadinessGapBuffer(gpa, int32(readyReplicas), desiredReplicas, a.clock.Now())
+}
+
+// applyReadinessGapBuffer returns the desired replicas with the buffer replicas added once the ready replicas
+// are fewer than the desired replicas for gapSeconds, the start of the gap is recorded as the last transition
+// time of the ReadinessGapBuffered condition at now. The buffer is removed once the ready replicas catch up.
+func applyReadinessGapBuffer(gpa *autoscaling.GeneralPodAutoscaler, readyReplicas, desiredReplicas int32, now time.Time) int32 {
+	buffer := gpa.Spec.ReadinessGapBuffer
+	if readyReplicas >= desiredReplicas {
+		setConditionAt(gpa, now, autoscaling.ReadinessGapBuffered, v1.ConditionFalse, "NoReadinessGap",
+			"the ready replicas %d reach the desired replicas %d", readyReplicas, desiredReplicas)
+		return desiredReplicas
+	}
+	condition := getCondition(gpa.Status.Conditions, autoscaling.ReadinessGapBuffered)
+	if condition == nil || condition.Status == v1.ConditionFalse {
+		setConditionAt(gpa, now, autoscaling.ReadinessGapBuffered, v1.ConditionUnknown, "ReadinessGapObserved",
+			"the ready replicas %d are fewer than the desired replicas %d", readyReplicas, desiredReplicas)
+		condition = getCondition(gpa.Status.Conditions, autoscaling.ReadinessGapBuffered)
+	}
+	gapSeconds := int32(defaultReadinessGapSeconds)
+	if buffer.GapSeconds != nil {
+		gapSeconds = *buffer.GapSeconds
+	}
+	if condition.Status != v1.ConditionTrue &&
+		now.Sub(condition.LastTransitionTime.Time) < time.Duration(gapSeconds)*time.Second {
+		return desiredReplicas
+	}
+	setConditionAt(gpa, now, autoscaling.ReadinessGapBuffered, v1.ConditionTrue, "BufferApplied",
+		"added %d buffer replicas since the ready replicas %d are fewer than the desired replicas %d",
+		buffer.Replicas, readyReplicas, desiredReplicas)
+	decisionLog(gpa, 4).Infof("GPA %s/%s adds %d buffer replicas to %d desired replicas, ready replicas: %d",
+		gpa.Namespace, gpa.Name, buffer.Replicas, desiredReplicas, readyReplicas)
+	return desiredReplicas + buffer.Replicas
+}
+
 func (a *GeneralController) updateLabelsIfNeeded(gpa *autoscaling.GeneralPodAutoscaler, labelMap map[string]string) error {
 	if len(labelMap) == 0 {
 		return nil
@@ -882,11 +1286,17 @@ func (a *GeneralController) updateLabelsIfNeeded(gpa *autoscaling.GeneralPodAuto
 // stabilizeRecommendation:
 // - replaces old recommendation with the newest recommendation,
 // - returns max of recommendations that are not older than downscaleStabilisationWindow.
-func (a *GeneralController) stabilizeRecommendation(key string, prenormalizedDesiredReplicas int32) int32 {
+func (a *DecisionEngine) stabilizeRecommendation(key string, prenormalizedDesiredReplicas int32) int32 {
+	return a.stabilizeRecommendationWithin(key, prenormalizedDesiredReplicas, a.downscaleStabilisationWindow)
+}
+
+// stabilizeRecommendationWithin is stabilizeRecommendation with the window in place of downscaleStabilisationWindow.
+func (a *DecisionEngine) stabilizeRecommendationWithin(key string, prenormalizedDesiredReplicas int32,
+	window time.Duration) int32 {
 	maxRecommendation := prenormalizedDesiredReplicas
 	foundOldSample := false
 	oldSampleIndex := 0
-	cutoff := time.Now().Add(-a.downscaleStabilisationWindow)
+	cutoff := a.clock.Now().Add(-window)
 	for i, rec := range a.recommendations[key] {
 		if rec.timestamp.Before(cutoff) {
 			foundOldSample = true
@@ -897,19 +1307,22 @@ func (a *GeneralController) stabilizeRecommendation(key string, prenormalizedDes
 	}
 	if foundOldSample {
 		a.recommendations[key][oldSampleIndex] = timestampedRecommendation{
-			prenormalizedDesiredReplicas, time.Now()}
+			prenormalizedDesiredReplicas, a.clock.Now()}
 	} else {
 		a.recommendations[key] = append(a.recommendations[key], timestampedRecommendation{
-			prenormalizedDesiredReplicas, time.Now()})
+			prenormalizedDesiredReplicas, a.clock.Now()})
 	}
 	return maxRecommendation
 }
 
 // normalizeDesiredReplicas takes the metrics desired replicas value and normalizes it based on the appropriate conditions (i.e. < maxReplicas, >
 // minReplicas, etc...)
-func (a *GeneralController) normalizeDesiredReplicas(gpa *autoscaling.GeneralPodAutoscaler,
+func (a *DecisionEngine) normalizeDesiredReplicas(gpa *autoscaling.GeneralPodAutoscaler,
 	key string, currentReplicas int32, prenormalizedDesiredReplicas int32, minReplicas int32) int32 {
-	stabilizedRecommendation := a.stabilizeRecommendation(key, prenormalizedDesiredReplicas)
+	stabilizedRecommendation := a.stabilizeRecommendationWithin(key, prenormalizedDesiredReplicas,
+		a.downscaleStabilisationWindowFor(gpa))
+	decisionLog(gpa, 4).Infof("GPA %s: prenormalized desired replicas: %d, stabilized recommendation: %d",
+		key, prenormalizedDesiredReplicas, stabilizedRecommendation)
 	if stabilizedRecommendation != prenormalizedDesiredReplicas {
 		setCondition(gpa, autoscaling.AbleToScale, v1.ConditionTrue, "ScaleDownStabilized",
 			"recent recommendations were higher than current one, applying the highest recent recommendation")
@@ -947,18 +1360,21 @@ type NormalizationArg struct {
 // 3. Apply the constraints period (i.e. add no more than 4 pods per minute)
 // 4. Apply the stabilization (i.e. add no more than 4 pods per minute, and pick the smallest
 //    recommendation during last 5 minutes)
-func (a *GeneralController) normalizeDesiredReplicasWithBehaviors(gpa *autoscaling.GeneralPodAutoscaler,
-	key string, currentReplicas, prenormalizedDesiredReplicas, minReplicas int32) int32 {
-	a.maybeInitScaleDownStabilizationWindow(gpa)
+func (a *DecisionEngine) normalizeDesiredReplicasWithBehaviors(gpa *autoscaling.GeneralPodAutoscaler,
+	behavior *autoscaling.GeneralPodAutoscalerBehavior, key string, currentReplicas, prenormalizedDesiredReplicas,
+	minReplicas int32) int32 {
+	a.maybeInitScaleDownStabilizationWindow(gpa, behavior)
 	normalizationArg := NormalizationArg{
 		Key:               key,
-		ScaleUpBehavior:   gpa.Spec.Behavior.ScaleUp,
-		ScaleDownBehavior: gpa.Spec.Behavior.ScaleDown,
+		ScaleUpBehavior:   behavior.ScaleUp,
+		ScaleDownBehavior: behavior.ScaleDown,
 		MinReplicas:       minReplicas,
 		MaxReplicas:       gpa.Spec.MaxReplicas,
 		CurrentReplicas:   currentReplicas,
 		DesiredReplicas:   prenormalizedDesiredReplicas}
 	stabilizedRecommendation, reason, message := a.stabilizeRecommendationWithBehaviors(normalizationArg)
+	decisionLog(gpa, 4).Infof("GPA %s: prenormalized desired replicas: %d, stabilized recommendation: %d",
+		key, prenormalizedDesiredReplicas, stabilizedRecommendation)
 	normalizationArg.DesiredReplicas = stabilizedRecommendation
 	if stabilizedRecommendation != prenormalizedDesiredReplicas {
 		// "ScaleUpStabilized" || "ScaleDownStabilized"
@@ -977,18 +1393,18 @@ func (a *GeneralController) normalizeDesiredReplicasWithBehaviors(gpa *autoscali
 	return desiredReplicas
 }
 
-func (a *GeneralController) maybeInitScaleDownStabilizationWindow(gpa *autoscaling.GeneralPodAutoscaler) {
-	behavior := gpa.Spec.Behavior
+func (a *DecisionEngine) maybeInitScaleDownStabilizationWindow(gpa *autoscaling.GeneralPodAutoscaler,
+	behavior *autoscaling.GeneralPodAutoscalerBehavior) {
 	if behavior != nil && behavior.ScaleDown != nil && behavior.ScaleDown.StabilizationWindowSeconds == nil {
-		stabilizationWindowSeconds := (int32)(a.downscaleStabilisationWindow.Seconds())
-		gpa.Spec.Behavior.ScaleDown.StabilizationWindowSeconds = &stabilizationWindowSeconds
+		stabilizationWindowSeconds := (int32)(a.downscaleStabilisationWindowFor(gpa).Seconds())
+		behavior.ScaleDown.StabilizationWindowSeconds = &stabilizationWindowSeconds
 	}
 }
 
 // getReplicasChangePerPeriod function find all the replica changes per period
-func getReplicasChangePerPeriod(periodSeconds int32, scaleEvents []timestampedScaleEvent) int32 {
+func getReplicasChangePerPeriod(periodSeconds int32, scaleEvents []timestampedScaleEvent, now time.Time) int32 {
 	period := time.Second * time.Duration(periodSeconds)
-	cutoff := time.Now().Add(-period)
+	cutoff := now.Add(-period)
 	var replicas int32
 	for _, rec := range scaleEvents {
 		if rec.timestamp.After(cutoff) {
@@ -999,8 +1415,11 @@ func getReplicasChangePerPeriod(periodSeconds int32, scaleEvents []timestampedSc
 
 }
 
-func (a *GeneralController) getUnableComputeReplicaCountCondition(gpa *autoscaling.GeneralPodAutoscaler,
+func (a *DecisionEngine) getUnableComputeReplicaCountCondition(gpa *autoscaling.GeneralPodAutoscaler,
 	reason string, err error) (condition autoscaling.GeneralPodAutoscalerCondition) {
+	if metricsclient.IsInvalidMetricValue(err) {
+		reason = "InvalidMetricValue"
+	}
 	a.eventRecorder.Event(gpa, v1.EventTypeWarning, reason, err.Error())
 	return autoscaling.GeneralPodAutoscalerCondition{
 		Type:    autoscaling.ScalingActive,
@@ -1012,17 +1431,18 @@ func (a *GeneralController) getUnableComputeReplicaCountCondition(gpa *autoscali
 
 // storeScaleEvent stores (adds or replaces outdated) scale event.
 // outdated events to be replaced were marked as outdated in the `markScaleEventsOutdated` function
-func (a *GeneralController) storeScaleEvent(behavior *autoscaling.GeneralPodAutoscalerBehavior,
+func (a *DecisionEngine) storeScaleEvent(behavior *autoscaling.GeneralPodAutoscalerBehavior,
 	key string, prevReplicas, newReplicas int32) {
-	if behavior == nil {
+	scaleUpRules, scaleDownRules := allScalingRules(behavior, true), allScalingRules(behavior, false)
+	if len(scaleUpRules) == 0 && len(scaleDownRules) == 0 {
 		return // we should not store any event as they will not be used
 	}
 	var oldSampleIndex int
 	var longestPolicyPeriod int32
 	foundOldSample := false
 	if newReplicas > prevReplicas {
-		longestPolicyPeriod = getLongestPolicyPeriod(behavior.ScaleUp)
-		markScaleEventsOutdated(a.scaleUpEvents[key], longestPolicyPeriod)
+		longestPolicyPeriod = getLongestPolicyPeriod(scaleUpRules...)
+		markScaleEventsOutdated(a.scaleUpEvents[key], longestPolicyPeriod, a.clock.Now())
 		replicaChange := newReplicas - prevReplicas
 		for i, event := range a.scaleUpEvents[key] {
 			if event.outdated {
@@ -1030,15 +1450,15 @@ func (a *GeneralController) storeScaleEvent(behavior *autoscaling.GeneralPodAuto
 				oldSampleIndex = i
 			}
 		}
-		newEvent := timestampedScaleEvent{replicaChange, time.Now(), false}
+		newEvent := timestampedScaleEvent{replicaChange, a.clock.Now(), false}
 		if foundOldSample {
 			a.scaleUpEvents[key][oldSampleIndex] = newEvent
 		} else {
 			a.scaleUpEvents[key] = append(a.scaleUpEvents[key], newEvent)
 		}
 	} else {
-		longestPolicyPeriod = getLongestPolicyPeriod(behavior.ScaleDown)
-		markScaleEventsOutdated(a.scaleDownEvents[key], longestPolicyPeriod)
+		longestPolicyPeriod = getLongestPolicyPeriod(scaleDownRules...)
+		markScaleEventsOutdated(a.scaleDownEvents[key], longestPolicyPeriod, a.clock.Now())
 		replicaChange := prevReplicas - newReplicas
 		for i, event := range a.scaleDownEvents[key] {
 			if event.outdated {
@@ -1046,7 +1466,7 @@ func (a *GeneralController) storeScaleEvent(behavior *autoscaling.GeneralPodAuto
 				oldSampleIndex = i
 			}
 		}
-		newEvent := timestampedScaleEvent{replicaChange, time.Now(), false}
+		newEvent := timestampedScaleEvent{replicaChange, a.clock.Now(), false}
 		if foundOldSample {
 			a.scaleDownEvents[key][oldSampleIndex] = newEvent
 		} else {
@@ -1058,7 +1478,7 @@ func (a *GeneralController) storeScaleEvent(behavior *autoscaling.GeneralPodAuto
 // stabilizeRecommendationWithBehaviors:
 // - replaces old recommendation with the newest recommendation,
 // - returns {max,min} of recommendations that are not older than constraints.Scale{Up,Down}.DelaySeconds
-func (a *GeneralController) stabilizeRecommendationWithBehaviors(args NormalizationArg) (int32, string, string) {
+func (a *DecisionEngine) stabilizeRecommendationWithBehaviors(args NormalizationArg) (int32, string, string) {
 	recommendation := args.DesiredReplicas
 	foundOldSample := false
 	oldSampleIndex := 0
@@ -1080,9 +1500,9 @@ func (a *GeneralController) stabilizeRecommendationWithBehaviors(args Normalizat
 	}
 
 	maxDelaySeconds := max(*args.ScaleUpBehavior.StabilizationWindowSeconds, *args.ScaleDownBehavior.StabilizationWindowSeconds)
-	obsoleteCutoff := time.Now().Add(-time.Second * time.Duration(maxDelaySeconds))
+	obsoleteCutoff := a.clock.Now().Add(-time.Second * time.Duration(maxDelaySeconds))
 
-	cutoff := time.Now().Add(-time.Second * time.Duration(scaleDelaySeconds))
+	cutoff := a.clock.Now().Add(-time.Second * time.Duration(scaleDelaySeconds))
 	for i, rec := range a.recommendations[args.Key] {
 		if rec.timestamp.After(cutoff) {
 			recommendation = betterRecommendation(rec.recommendation, recommendation)
@@ -1093,25 +1513,29 @@ func (a *GeneralController) stabilizeRecommendationWithBehaviors(args Normalizat
 		}
 	}
 	if foundOldSample {
-		a.recommendations[args.Key][oldSampleIndex] = timestampedRecommendation{args.DesiredReplicas, time.Now()}
+		a.recommendations[args.Key][oldSampleIndex] = timestampedRecommendation{args.DesiredReplicas, a.clock.Now()}
 	} else {
-		a.recommendations[args.Key] = append(a.recommendations[args.Key], timestampedRecommendation{args.DesiredReplicas, time.Now()})
+		a.recommendations[args.Key] = append(a.recommendations[args.Key], timestampedRecommendation{args.DesiredReplicas, a.clock.Now()})
 	}
 	return recommendation, reason, message
 }
 
 // convertDesiredReplicasWithBehaviorRate performs the actual normalization, given the constraint rate
 // It doesn't consider the stabilizationWindow, it is done separately
-func (a *GeneralController) convertDesiredReplicasWithBehaviorRate(args NormalizationArg) (int32, string, string) {
+func (a *DecisionEngine) convertDesiredReplicasWithBehaviorRate(args NormalizationArg) (int32, string, string) {
 	var possibleLimitingReason, possibleLimitingMessage string
 
 	if args.DesiredReplicas > args.CurrentReplicas {
 		scaleUpLimit := calculateScaleUpLimitWithScalingRules(args.CurrentReplicas,
-			a.scaleUpEvents[args.Key], args.ScaleUpBehavior)
+			a.scaleUpEvents[args.Key], args.ScaleUpBehavior, a.clock.Now())
 		if scaleUpLimit < args.CurrentReplicas {
 			// We shouldn't scale up further until the scaleUpEvents will be cleaned up
 			scaleUpLimit = args.CurrentReplicas
 		}
+		if factorLimit, ok := calculateScaleUpLimitWithFactor(args.CurrentReplicas, args.ScaleUpBehavior); ok &&
+			factorLimit < scaleUpLimit {
+			scaleUpLimit = factorLimit
+		}
 		maximumAllowedReplicas := args.MaxReplicas
 		if maximumAllowedReplicas > scaleUpLimit {
 			maximumAllowedReplicas = scaleUpLimit
@@ -1126,7 +1550,7 @@ func (a *GeneralController) convertDesiredReplicasWithBehaviorRate(args Normaliz
 		}
 	} else if args.DesiredReplicas < args.CurrentReplicas {
 		scaleDownLimit := calculateScaleDownLimitWithBehaviors(args.CurrentReplicas,
-			a.scaleDownEvents[args.Key], args.ScaleDownBehavior)
+			a.scaleDownEvents[args.Key], args.ScaleDownBehavior, a.clock.Now())
 		if scaleDownLimit > args.CurrentReplicas {
 			// We shouldn't scale down further until the scaleDownEvents will be cleaned up
 			scaleDownLimit = args.CurrentReplicas
@@ -1147,6 +1571,29 @@ func (a *GeneralController) convertDesiredReplicasWithBehaviorRate(args Normaliz
 	return args.DesiredReplicas, "DesiredWithinRange", "the desired count is within the acceptable range"
 }
 
+// smoothRecommendation blends the recommendation with the previous smoothed value using
+// spec.behavior.ewmaAlpha, and records the new smoothed value in the status.
+func smoothRecommendation(gpa *autoscaling.GeneralPodAutoscaler, recommendation int32) int32 {
+	if gpa.Spec.Behavior == nil || gpa.Spec.Behavior.EWMAAlpha == nil {
+		gpa.Status.SmoothedReplicas = nil
+		return recommendation
+	}
+	alpha := *gpa.Spec.Behavior.EWMAAlpha
+	smoothed := float64(recommendation)
+	if gpa.Status.SmoothedReplicas != nil {
+		smoothed = alpha*float64(recommendation) + (1-alpha)*(*gpa.Status.SmoothedReplicas)
+	}
+	gpa.Status.SmoothedReplicas = &smoothed
+	decisionLog(gpa, 4).Infof("GPA %s/%s: recommendation %d smoothed to %.3f with alpha %v",
+		gpa.Namespace, gpa.Name, recommendation, smoothed, alpha)
+	return int32(math.Round(smoothed))
+}
+
+// hasScalingRules returns true if the behavior configures rules for any scaling direction
+func hasScalingRules(behavior *autoscaling.GeneralPodAutoscalerBehavior) bool {
+	return behavior != nil && (behavior.ScaleUp != nil || behavior.ScaleDown != nil)
+}
+
 // computeDesiredSize computes the new desired size of the given fleet
 func computeDesiredSize(gpa *autoscaling.GeneralPodAutoscaler,
 	scalers []scalercore.Scaler, currentReplicas int32) (int32, string, error) {
@@ -1155,7 +1602,7 @@ func computeDesiredSize(gpa *autoscaling.GeneralPodAutoscaler,
 		errs     error
 		name     string
 	)
-	klog.V(4).Infof("Scaler number of %v: %v", gpa.Name, len(scalers))
+	decisionLog(gpa, 4).Infof("Scaler number of %v: %v", gpa.Name, len(scalers))
 	for _, s := range scalers {
 		chainReplicas, err := s.GetReplicas(gpa, currentReplicas)
 		if err != nil {
@@ -1163,7 +1610,7 @@ func computeDesiredSize(gpa *autoscaling.GeneralPodAutoscaler,
 			errs = pkgerrors.Wrap(err, fmt.Sprintf("GPA: %v get replicas error when call %v", gpa.Name, s.ScalerName()))
 			continue
 		}
-		klog.V(4).Infof("GPA: %v scaler: %v, suggested replicas: %v", gpa.Name, s.ScalerName(), chainReplicas)
+		decisionLog(gpa, 4).Infof("GPA: %v scaler: %v, suggested replicas: %v", gpa.Name, s.ScalerName(), chainReplicas)
 		if chainReplicas > replicas {
 			replicas = chainReplicas
 			name = s.ScalerName()
@@ -1215,10 +1662,27 @@ func calculateScaleUpLimit(currentReplicas int32) int32 {
 	return int32(math.Max(scaleUpLimitFactor*float64(currentReplicas), scaleUpLimitMinimum))
 }
 
+// calculateScaleUpLimitWithFactor returns the maximum number of replicas of a single scale up by the maxFactor
+// and maxAbsolute of the rules, the more permissive of the two wins. The one not set defaults to the limit of
+// calculateScaleUpLimit, false is returned if neither is set.
+func calculateScaleUpLimitWithFactor(currentReplicas int32, scalingRules *autoscaling.GPAScalingRules) (int32, bool) {
+	if scalingRules == nil || (scalingRules.MaxFactor == nil && scalingRules.MaxAbsolute == nil) {
+		return 0, false
+	}
+	factor, minimum := scaleUpLimitFactor, scaleUpLimitMinimum
+	if scalingRules.MaxFactor != nil {
+		factor = *scalingRules.MaxFactor
+	}
+	if scalingRules.MaxAbsolute != nil {
+		minimum = float64(*scalingRules.MaxAbsolute)
+	}
+	return int32(math.Max(math.Floor(factor*float64(currentReplicas)), minimum)), true
+}
+
 // markScaleEventsOutdated set 'outdated=true' flag for all scale events that are not used by any GPA object
-func markScaleEventsOutdated(scaleEvents []timestampedScaleEvent, longestPolicyPeriod int32) {
+func markScaleEventsOutdated(scaleEvents []timestampedScaleEvent, longestPolicyPeriod int32, now time.Time) {
 	period := time.Second * time.Duration(longestPolicyPeriod)
-	cutoff := time.Now().Add(-period)
+	cutoff := now.Add(-period)
 	for i, event := range scaleEvents {
 		if event.timestamp.Before(cutoff) {
 			// outdated scale event are marked for later reuse
@@ -1227,11 +1691,13 @@ func markScaleEventsOutdated(scaleEvents []timestampedScaleEvent, longestPolicyP
 	}
 }
 
-func getLongestPolicyPeriod(scalingRules *autoscaling.GPAScalingRules) int32 {
+func getLongestPolicyPeriod(scalingRules ...*autoscaling.GPAScalingRules) int32 {
 	var longestPolicyPeriod int32
-	for _, policy := range scalingRules.Policies {
-		if policy.PeriodSeconds > longestPolicyPeriod {
-			longestPolicyPeriod = policy.PeriodSeconds
+	for _, rules := range scalingRules {
+		for _, policy := range rules.Policies {
+			if policy.PeriodSeconds > longestPolicyPeriod {
+				longestPolicyPeriod = policy.PeriodSeconds
+			}
 		}
 	}
 	return longestPolicyPeriod
@@ -1240,7 +1706,7 @@ func getLongestPolicyPeriod(scalingRules *autoscaling.GPAScalingRules) int32 {
 // calculateScaleUpLimitWithScalingRules returns the maximum number of pods
 // that could be added for the given GPAScalingRules
 func calculateScaleUpLimitWithScalingRules(currentReplicas int32, scaleEvents []timestampedScaleEvent,
-	scalingRules *autoscaling.GPAScalingRules) int32 {
+	scalingRules *autoscaling.GPAScalingRules, now time.Time) int32 {
 	var result int32
 	var proposed int32
 	var selectPolicyFn func(int32, int32) int32
@@ -1252,7 +1718,7 @@ func calculateScaleUpLimitWithScalingRules(currentReplicas int32, scaleEvents []
 		selectPolicyFn = max // Use the default policy otherwise to produce a highest possible change
 	}
 	for _, policy := range scalingRules.Policies {
-		replicasAddedInCurrentPeriod := getReplicasChangePerPeriod(policy.PeriodSeconds, scaleEvents)
+		replicasAddedInCurrentPeriod := getReplicasChangePerPeriod(policy.PeriodSeconds, scaleEvents, now)
 		periodStartReplicas := currentReplicas - replicasAddedInCurrentPeriod
 		if policy.Type == autoscaling.PodsScalingPolicy {
 			proposed = int32(periodStartReplicas + policy.Value)
@@ -1269,7 +1735,7 @@ func calculateScaleUpLimitWithScalingRules(currentReplicas int32, scaleEvents []
 // calculateScaleDownLimitWithBehavior returns the maximum number of pods
 // that could be deleted for the given GPAScalingRules
 func calculateScaleDownLimitWithBehaviors(currentReplicas int32, scaleEvents []timestampedScaleEvent,
-	scalingRules *autoscaling.GPAScalingRules) int32 {
+	scalingRules *autoscaling.GPAScalingRules, now time.Time) int32 {
 	var result int32 = math.MaxInt32
 	var proposed int32
 	var selectPolicyFn func(int32, int32) int32
@@ -1281,7 +1747,7 @@ func calculateScaleDownLimitWithBehaviors(currentReplicas int32, scaleEvents []t
 		selectPolicyFn = min // Use the default policy otherwise to produce a highest possible change
 	}
 	for _, policy := range scalingRules.Policies {
-		replicasDeletedInCurrentPeriod := getReplicasChangePerPeriod(policy.PeriodSeconds, scaleEvents)
+		replicasDeletedInCurrentPeriod := getReplicasChangePerPeriod(policy.PeriodSeconds, scaleEvents, now)
 		periodStartReplicas := currentReplicas + replicasDeletedInCurrentPeriod
 		if policy.Type == autoscaling.PodsScalingPolicy {
 			proposed = periodStartReplicas - policy.Value
@@ -1293,6 +1759,36 @@ func calculateScaleDownLimitWithBehaviors(currentReplicas int32, scaleEvents []t
 	return result
 }
 
+// handleTargetMissing handles the GPA whose scale target does not exist by spec.onTargetMissing.
+func (a *GeneralController) handleTargetMissing(gpaStatusOriginal *autoscaling.GeneralPodAutoscalerStatus,
+	gpa *autoscaling.GeneralPodAutoscaler, reference string, err error) error {
+	policy := autoscaling.ErrorTargetMissing
+	if gpa.Spec.OnTargetMissing != nil {
+		policy = *gpa.Spec.OnTargetMissing
+	}
+	switch policy {
+	case autoscaling.IgnoreTargetMissing:
+		klog.V(4).Infof("Scale target %s of GPA %s/%s is missing, skip it", reference, gpa.Namespace, gpa.Name)
+		return nil
+	case autoscaling.DeleteSelfTargetMissing:
+		if err := a.gpaNamespacer.GeneralPodAutoscalers(gpa.Namespace).Delete(gpa.Name, &metav1.DeleteOptions{}); err != nil &&
+			!errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete GPA whose scale target %s is missing: %v", reference, err)
+		}
+		klog.Infof("Deleted GPA %s/%s, as its scale target %s is missing", gpa.Namespace, gpa.Name, reference)
+		return nil
+	}
+	a.eventRecorder.Event(gpa, v1.EventTypeWarning, "TargetMissing", err.Error())
+	setCondition(gpa, autoscaling.AbleToScale, v1.ConditionFalse, "TargetMissing",
+		"the GPA controller was unable to find the scale target: %v", err)
+	if updateErr := a.updateStatusIfNeeded(gpaStatusOriginal, gpa); updateErr != nil {
+		klog.Error(updateErr)
+	}
+	// the condition surfaces the missing target, it is not logged as an error on every sync
+	klog.V(2).Infof("Scale target %s of GPA %s/%s is missing: %v", reference, gpa.Namespace, gpa.Name, err)
+	return nil
+}
+
 // scaleForResourceMappings attempts to fetch the scale for the
 // resource with the given name and namespace, trying each RESTMapping
 // in turn until a working one is found.  If none work, the first error
@@ -1323,6 +1819,31 @@ func (a *GeneralController) scaleForResourceMappings(namespace, name string,
 	return nil, schema.GroupResource{}, firstErr
 }
 
+// TargetPods lists the pods of the scale target referenced in the namespace by the selector of its scale,
+// no pods are listed if the scale has no selector.
+func (a *GeneralController) TargetPods(namespace string, ref autoscaling.CrossVersionObjectReference) ([]*v1.Pod, error) {
+	targetGV, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API version in scale target reference: %v", err)
+	}
+	mappings, err := a.mapper.RESTMappings(schema.GroupKind{Group: targetGV.Group, Kind: ref.Kind})
+	if err != nil {
+		return nil, err
+	}
+	scale, _, err := a.scaleForResourceMappings(namespace, ref.Name, mappings)
+	if err != nil {
+		return nil, err
+	}
+	if scale.Status.Selector == "" {
+		return nil, nil
+	}
+	selector, err := labels.Parse(scale.Status.Selector)
+	if err != nil {
+		return nil, err
+	}
+	return a.podLister.Pods(namespace).List(selector)
+}
+
 // setCurrentReplicasInStatus sets the current replica count in the status of the GPA.
 func (a *GeneralController) setCurrentReplicasInStatus(gpa *autoscaling.GeneralPodAutoscaler, currentReplicas int32) {
 	a.setStatus(gpa, currentReplicas, gpa.Status.DesiredReplicas, gpa.Status.CurrentMetrics, false)
@@ -1338,14 +1859,39 @@ func (a *GeneralController) setStatus(gpa *autoscaling.GeneralPodAutoscaler, cur
 		LastScaleTime:   gpa.Status.LastScaleTime,
 		CurrentMetrics:  metricStatuses,
 		Conditions:      gpa.Status.Conditions,
-	}
-	now := metav1.NewTime(time.Now())
+		// keep the smoothed recommendation and the state of the controller across reconciles
+		SmoothedReplicas:    gpa.Status.SmoothedReplicas,
+		PID:                 gpa.Status.PID,
+		ConflictWinner:      gpa.Status.ConflictWinner,
+		LastScaleUpTime:     gpa.Status.LastScaleUpTime,
+		PendingReplicas:     gpa.Status.PendingReplicas,
+		PeakRecommendations: gpa.Status.PeakRecommendations,
+		MetricBreach:        gpa.Status.MetricBreach,
+		ScaleHistory:        scaleHistory(gpa),
+		// set by applyBoundsOverride and applyTuningOverride on each sync
+		OverriddenMinReplicas: gpa.Status.OverriddenMinReplicas,
+		OverriddenMaxReplicas: gpa.Status.OverriddenMaxReplicas,
+		TuningOverride:        gpa.Status.TuningOverride,
+		// set by recordDrivingMetric when the metrics are computed
+		DrivingMetric: gpa.Status.DrivingMetric,
+		// set by applyMultiplier when the recommendation is computed
+		Multiplier: gpa.Status.Multiplier,
+	}
+	now := metav1.NewTime(a.clock.Now())
 	if rescale {
 		if gpa.Spec.TimeMode != nil {
 			gpa.Status.LastCronScheduleTime = &now
 		}
 		gpa.Status.LastScaleTime = &now
 	}
+	if gpa.Spec.TimeMode != nil {
+		// computed from the spec on each sync, so that it follows the changes of the schedules
+		if next, replicas := scalercore.NextSchedule(gpa.Spec.TimeMode.TimeRanges, now.Time); next != nil {
+			nextTime := metav1.NewTime(*next)
+			gpa.Status.NextScheduleTime = &nextTime
+			gpa.Status.NextScheduleReplicas = replicas
+		}
+	}
 }
 
 // updateStatusIfNeeded calls updateStatus only if the status of the new GPA is not the same as the old status
@@ -1363,9 +1909,26 @@ func (a *GeneralController) updateStatusIfNeeded(oldStatus *autoscaling.GeneralP
 	return err
 }
 
-// updateStatus actually does the update request for the status of the given GPA
+// updateStatus actually does the update request for the status of the given GPA. On conflicts the status
+// is applied to the latest GPA and the update is retried, so that the decision is not lost.
 func (a *GeneralController) updateStatus(gpa *autoscaling.GeneralPodAutoscaler) error {
-	_, err := a.gpaNamespacer.GeneralPodAutoscalers(gpa.Namespace).UpdateStatus(gpa)
+	current := gpa
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		_, err := a.gpaNamespacer.GeneralPodAutoscalers(gpa.Namespace).UpdateStatus(current)
+		if !errors.IsConflict(err) {
+			return err
+		}
+		latest, getErr := a.gpaNamespacer.GeneralPodAutoscalers(gpa.Namespace).Get(gpa.Name, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		klog.V(4).Infof("Status of gpa %s/%s conflicts, retry with resource version %s",
+			gpa.Namespace, gpa.Name, latest.ResourceVersion)
+		latest = latest.DeepCopy()
+		latest.Status = gpa.Status
+		current = latest
+		return err
+	})
 	if err != nil {
 		a.eventRecorder.Event(gpa, v1.EventTypeWarning, "FailedUpdateStatus", err.Error())
 		return fmt.Errorf("failed to update status for %s: %v", gpa.Name, err)
@@ -1391,13 +1954,22 @@ func (a *GeneralController) pathStatus(gpa *autoscaling.GeneralPodAutoscaler, pa
 // not present.
 func setCondition(gpa *autoscaling.GeneralPodAutoscaler, conditionType autoscaling.GeneralPodAutoscalerConditionType,
 	status v1.ConditionStatus, reason, message string, args ...interface{}) {
-	gpa.Status.Conditions = setConditionInList(gpa.Status.Conditions, conditionType, status, reason, message, args...)
+	setConditionAt(gpa, time.Now(), conditionType, status, reason, message, args...)
+}
+
+// setConditionAt is setCondition with a transition stamped at now, for the conditions whose last transition time
+// is compared with the clock of the engine.
+func setConditionAt(gpa *autoscaling.GeneralPodAutoscaler, now time.Time,
+	conditionType autoscaling.GeneralPodAutoscalerConditionType, status v1.ConditionStatus, reason, message string,
+	args ...interface{}) {
+	gpa.Status.Conditions = setConditionInList(gpa.Status.Conditions, now, conditionType, status, reason, message,
+		args...)
 }
 
 // setConditionInList sets the specific condition type on the given GPA to the specified value with the given
 // reason and message.  The message and args are treated like a format string.  The condition will be added if
-// it is not present.  The new list will be returned.
-func setConditionInList(inputList []autoscaling.GeneralPodAutoscalerCondition,
+// it is not present, a transition is stamped at now.  The new list will be returned.
+func setConditionInList(inputList []autoscaling.GeneralPodAutoscalerCondition, now time.Time,
 	conditionType autoscaling.GeneralPodAutoscalerConditionType, status v1.ConditionStatus, reason, message string,
 	args ...interface{}) []autoscaling.GeneralPodAutoscalerCondition {
 	resList := inputList
@@ -1418,7 +1990,7 @@ func setConditionInList(inputList []autoscaling.GeneralPodAutoscalerCondition,
 	}
 
 	if existingCond.Status != status {
-		existingCond.LastTransitionTime = metav1.Now()
+		existingCond.LastTransitionTime = metav1.NewTime(now)
 	}
 
 	existingCond.Status = status
@@ -1428,6 +2000,17 @@ func setConditionInList(inputList []autoscaling.GeneralPodAutoscalerCondition,
 	return resList
 }
 
+// getCondition returns the condition of the type in the list, or nil if not found.
+func getCondition(conditions []autoscaling.GeneralPodAutoscalerCondition,
+	conditionType autoscaling.GeneralPodAutoscalerConditionType) *autoscaling.GeneralPodAutoscalerCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
 func max(a, b int32) int32 {
 	if a >= b {
 		return a
@@ -1443,7 +2026,8 @@ func min(a, b int32) int32 {
 }
 
 func isEmpty(a autoscaling.AutoScalingDrivenMode) bool {
-	return a.MetricMode == nil && a.EventMode == nil && a.TimeMode == nil && a.WebhookMode == nil
+	return a.MetricMode == nil && a.EventMode == nil && a.TimeMode == nil && a.WebhookMode == nil &&
+		a.ClusterProportionalMode == nil && a.MirrorMode == nil
 }
 
 func isComputeByLimits(gpa *autoscaling.GeneralPodAutoscaler) bool {
@@ -1453,3 +2037,32 @@ func isComputeByLimits(gpa *autoscaling.GeneralPodAutoscaler) bool {
 	}
 	return computeByLimits
 }
+
+func isNormalizePerPod(gpa *autoscaling.GeneralPodAutoscaler) bool {
+	return gpa != nil && gpa.Annotations != nil && gpa.Annotations[normalizePerPodKey] == "true"
+}
+
+// utilizationRatioBy describes what the resource utilization is the percentage of
+func utilizationRatioBy(computeByLimits, normalizePerPod bool) string {
+	by := "request"
+	if computeByLimits {
+		by = "limit"
+	}
+	if normalizePerPod {
+		by += " per pod"
+	}
+	return by
+}
+
+func isDebugEnabled(gpa *autoscaling.GeneralPodAutoscaler) bool {
+	return gpa != nil && gpa.Annotations != nil && gpa.Annotations[debugKey] == "true"
+}
+
+// decisionLog returns a verbose logger for the decision details of the given GPA. It is enabled when
+// the global verbosity is at least level, or always when the GPA is annotated with debugKey.
+func decisionLog(gpa *autoscaling.GeneralPodAutoscaler, level klog.Level) klog.Verbose {
+	if isDebugEnabled(gpa) {
+		return klog.Verbose(true)
+	}
+	return klog.V(level)
+}
@@ -16,8 +16,11 @@
 package scaler
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
 	"math"
+	"os"
 	"strconv"
 	"sync"
 	"testing"
@@ -26,18 +29,24 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalinginternal "k8s.io/api/autoscaling/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	core "k8s.io/client-go/testing"
 
 	scalefake "k8s.io/client-go/scale/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	cmapi "k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
 	emapi "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
 	metricsapi "k8s.io/metrics/pkg/apis/metrics/v1beta1"
@@ -46,6 +55,8 @@ import (
 	emfake "k8s.io/metrics/pkg/client/external_metrics/fake"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/klog"
 
 	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling"
 	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
@@ -65,7 +76,7 @@ func statusOkWithOverrides(overrides ...autoscalingv1alpha1.GeneralPodAutoscaler
 	resv2 := make([]autoscalingv1alpha1.GeneralPodAutoscalerCondition, len(statusOk))
 	copy(resv2, statusOk)
 	for _, override := range overrides {
-		resv2 = setConditionInList(resv2, override.Type, override.Status, override.Reason, override.Message)
+		resv2 = setConditionInList(resv2, time.Now(), override.Type, override.Status, override.Reason, override.Message)
 	}
 
 	// copy to a v1 slice
@@ -134,6 +145,13 @@ type testCase struct {
 	testScaleClient   *scalefake.FakeScaleClient
 
 	recommendations []timestampedRecommendation
+
+	// modifyGPA customizes the GPA returned by the fake client
+	modifyGPA func(gpa *autoscalingv1alpha1.GeneralPodAutoscaler)
+	// verifyStatus verifies the status written by the controller
+	verifyStatus func(t *testing.T, status *autoscalingv1alpha1.GeneralPodAutoscalerStatus)
+	// deployment is listed by the deployment informer of the controller
+	deployment *appsv1.Deployment
 }
 
 // Needs to be called under a lock.
@@ -262,6 +280,20 @@ func (tc *testCase) prepareTestClient(t *testing.T) (*fake.Clientset, *metricsfa
 				},
 			}
 		}
+		if tc.modifyGPA != nil {
+			tc.modifyGPA(&obj.Items[0])
+		}
+		return true, obj, nil
+	})
+
+	fakeClient.AddReactor("list", "deployments", func(action core.Action) (handled bool, ret runtime.Object, err error) {
+		tc.Lock()
+		defer tc.Unlock()
+
+		obj := &appsv1.DeploymentList{}
+		if tc.deployment != nil {
+			obj.Items = append(obj.Items, *tc.deployment.DeepCopy())
+		}
 		return true, obj, nil
 	})
 
@@ -363,6 +395,9 @@ func (tc *testCase) prepareTestClient(t *testing.T) (*fake.Clientset, *metricsfa
 			assert.Equal(t, namespace, obj.Namespace, "the GPA namespace should be as expected")
 			assert.Equal(t, gpaName, obj.Name, "the GPA name should be as expected")
 			assert.Equal(t, tc.expectedDesiredReplicas, obj.Status.DesiredReplicas, "the desired replica count reported in the object status should be as expected")
+			if tc.verifyStatus != nil {
+				tc.verifyStatus(t, &obj.Status)
+			}
 			// Every time we reconcile GPA object we are updating status.
 			tc.statusUpdated = true
 			return true, obj, nil
@@ -683,6 +718,7 @@ func (tc *testCase) setupController(t *testing.T) (*GeneralController, informers
 
 	defaultDownscalestabilizationWindow := 5 * time.Minute
 	gpaController := NewGeneralController(
+		eventClient.CoreV1(),
 		eventClient.CoreV1(),
 		testScaleClient,
 		testGPAClient.AutoscalingV1alpha1(),
@@ -697,6 +733,7 @@ func (tc *testCase) setupController(t *testing.T) (*GeneralController, informers
 		defaultTestingDelayOfInitialReadinessStatus,
 	)
 	gpaController.gpaListerSynced = alwaysReady
+	gpaController.AddDeploymentInformer(informerFactory.Apps().V1().Deployments())
 	if tc.recommendations != nil {
 		gpaController.recommendations["test-namespace/test-gpa"] = tc.recommendations
 	}
@@ -754,6 +791,105 @@ func TestScaleUp(t *testing.T) {
 	tc.runTest(t)
 }
 
+func TestScaleHistory(t *testing.T) {
+	limit := int32(2)
+	earlier := metav1.NewTime(time.Now().Add(-time.Hour))
+	tc := testCase{
+		minReplicas:             2,
+		maxReplicas:             6,
+		specReplicas:            3,
+		statusReplicas:          3,
+		expectedDesiredReplicas: 5,
+		CPUTarget:               30,
+		reportedLevels:          []uint64{300, 500, 700},
+		reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+		useMetricsAPI:           true,
+		modifyGPA: func(gpa *autoscalingv1alpha1.GeneralPodAutoscaler) {
+			gpa.Spec.ScaleHistoryLimit = &limit
+			gpa.Status.ScaleHistory = []autoscalingv1alpha1.ScaleRecord{
+				{Time: earlier, OldReplicas: 1, NewReplicas: 2, Reason: "first"},
+				{Time: earlier, OldReplicas: 2, NewReplicas: 3, Reason: "second"},
+			}
+		},
+		verifyStatus: func(t *testing.T, status *autoscalingv1alpha1.GeneralPodAutoscalerStatus) {
+			// the scale is appended and the oldest one is dropped
+			if assert.Len(t, status.ScaleHistory, 2) {
+				assert.Equal(t, "second", status.ScaleHistory[0].Reason)
+				last := status.ScaleHistory[1]
+				assert.Equal(t, int32(3), last.OldReplicas)
+				assert.Equal(t, int32(5), last.NewReplicas)
+				assert.Equal(t, "cpu resource utilization (percentage of request) above target", last.Reason)
+				assert.True(t, last.Time.After(earlier.Time))
+			}
+		},
+	}
+	tc.runTest(t)
+}
+
+// TestScaleSubresourceOfCustomResource scales a custom resource whose scale subresource maps the replicas to
+// spec.size, while its spec.replicas means something else. Both reads and writes go through the subresource.
+func TestScaleSubresourceOfCustomResource(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "game.example.com", Version: "v1", Kind: "GameServerSet"}
+	spec := struct{ replicas, size int32 }{replicas: 10, size: 3}
+	fakeScaleClient := &scalefake.FakeScaleClient{}
+	fakeScaleClient.AddReactor("get", "gameserversets", func(action core.Action) (bool, runtime.Object, error) {
+		return true, &autoscalinginternal.Scale{
+			ObjectMeta: metav1.ObjectMeta{Name: "game", Namespace: "test-namespace"},
+			Spec:       autoscalinginternal.ScaleSpec{Replicas: spec.size},
+			Status:     autoscalinginternal.ScaleStatus{Replicas: spec.size, Selector: "name=test-pod"},
+		}, nil
+	})
+	fakeScaleClient.AddReactor("update", "gameserversets", func(action core.Action) (bool, runtime.Object, error) {
+		obj := action.(core.UpdateAction).GetObject().(*autoscalinginternal.Scale)
+		spec.size = obj.Spec.Replicas
+		return true, obj, nil
+	})
+	tc := testCase{
+		minReplicas:             2,
+		maxReplicas:             6,
+		specReplicas:            3,
+		statusReplicas:          3,
+		expectedDesiredReplicas: 5,
+		CPUTarget:               30,
+		reportedLevels:          []uint64{300, 500, 700},
+		reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+		useMetricsAPI:           true,
+		resource:                &fakeResource{name: "game", apiVersion: gvk.GroupVersion().String(), kind: gvk.Kind},
+		testScaleClient:         fakeScaleClient,
+	}
+	gpaController, informerFactory, scalerFactory := tc.setupController(t)
+	mapper := apimeta.NewDefaultRESTMapper([]schema.GroupVersion{gvk.GroupVersion()})
+	mapper.Add(gvk, apimeta.RESTScopeNamespace)
+	gpaController.mapper = mapper
+
+	stop := make(chan struct{})
+	defer close(stop)
+	scalerFactory.Start(stop)
+	informerFactory.Start(stop)
+	if !cache.WaitForCacheSync(stop, scalerFactory.Autoscaling().V1alpha1().GeneralPodAutoscalers().Informer().HasSynced,
+		gpaController.podListerSynced) {
+		t.Fatal("failed to sync the informers")
+	}
+	gpa, err := gpaController.gpaLister.GeneralPodAutoscalers("test-namespace").Get("test-gpa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, gpaController.reconcileAutoscaler(gpa.DeepCopy(), "test-namespace/test-gpa"))
+
+	var writes []core.UpdateAction
+	for _, action := range fakeScaleClient.Actions() {
+		if update, ok := action.(core.UpdateAction); ok {
+			writes = append(writes, update)
+		}
+	}
+	if assert.Len(t, writes, 1) {
+		assert.Equal(t, "gameserversets", writes[0].GetResource().Resource)
+		assert.Equal(t, "game.example.com", writes[0].GetResource().Group)
+	}
+	assert.Equal(t, int32(5), spec.size)
+	assert.Equal(t, int32(10), spec.replicas)
+}
+
 func TestScaleUpUnreadyLessScale(t *testing.T) {
 	tc := testCase{
 		minReplicas:             2,
@@ -876,6 +1012,201 @@ func TestScaleUpIgnoresDeletionPods(t *testing.T) {
 	tc.runTest(t)
 }
 
+func TestBoundsOverriddenByAnnotations(t *testing.T) {
+	overriddenMax := int32(6)
+	for _, c := range []struct {
+		name        string
+		annotations map[string]string
+		replicas    int32
+		status      v1.ConditionStatus
+		overridden  *int32
+	}{
+		{
+			name:        "override max",
+			annotations: map[string]string{overrideMaxKey: "6"},
+			replicas:    5,
+			status:      v1.ConditionTrue,
+			overridden:  &overriddenMax,
+		},
+		{name: "override cleared", replicas: 4, status: v1.ConditionFalse},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			tc := testCase{
+				minReplicas:             2,
+				maxReplicas:             4,
+				specReplicas:            3,
+				statusReplicas:          3,
+				expectedDesiredReplicas: c.replicas,
+				CPUTarget:               30,
+				reportedLevels:          []uint64{300, 500, 700},
+				reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+				useMetricsAPI:           true,
+				modifyGPA: func(gpa *autoscalingv1alpha1.GeneralPodAutoscaler) {
+					gpa.Annotations = c.annotations
+					// overridden on the previous sync
+					gpa.Status.OverriddenMaxReplicas = &overriddenMax
+					gpa.Status.Conditions = []autoscalingv1alpha1.GeneralPodAutoscalerCondition{
+						{Type: autoscalingv1alpha1.BoundsOverridden, Status: v1.ConditionTrue, Reason: "OverriddenByAnnotations"},
+					}
+				},
+				verifyStatus: func(t *testing.T, status *autoscalingv1alpha1.GeneralPodAutoscalerStatus) {
+					assert.Equal(t, c.overridden, status.OverriddenMaxReplicas)
+					assert.Nil(t, status.OverriddenMinReplicas)
+					cond := getCondition(status.Conditions, autoscalingv1alpha1.BoundsOverridden)
+					if assert.NotNil(t, cond) {
+						assert.Equal(t, c.status, cond.Status)
+					}
+				},
+			}
+			tc.runTest(t)
+		})
+	}
+}
+
+func TestTuningOverriddenByAnnotations(t *testing.T) {
+	tolerance := 0.2
+	window := int32(30)
+	for _, c := range []struct {
+		name        string
+		annotations map[string]string
+		levels      []uint64
+		replicas    int32
+		status      v1.ConditionStatus
+		reason      string
+		overridden  *autoscalingv1alpha1.TuningOverride
+	}{
+		{
+			// 46% of the 40% target is within the overridden tolerance
+			name:        "override tolerance",
+			annotations: map[string]string{overrideToleranceKey: "0.2"},
+			levels:      []uint64{460, 460, 460},
+			replicas:    3,
+			status:      v1.ConditionTrue,
+			reason:      "OverriddenByAnnotations",
+			overridden:  &autoscalingv1alpha1.TuningOverride{Tolerance: &tolerance},
+		},
+		{
+			name:     "tolerance override cleared",
+			levels:   []uint64{460, 460, 460},
+			replicas: 4,
+			status:   v1.ConditionFalse,
+			reason:   "NoOverride",
+		},
+		{
+			name:        "invalid tolerance override",
+			annotations: map[string]string{overrideToleranceKey: "1.5"},
+			levels:      []uint64{460, 460, 460},
+			replicas:    4,
+			status:      v1.ConditionFalse,
+			reason:      "InvalidOverride",
+		},
+		{
+			// the recommendation of 3 replicas a minute ago is out of the overridden window
+			name:        "override scale down stabilization",
+			annotations: map[string]string{overrideScaleDownStabilizationKey: "30"},
+			levels:      []uint64{80, 80, 80},
+			replicas:    2,
+			status:      v1.ConditionTrue,
+			reason:      "OverriddenByAnnotations",
+			overridden:  &autoscalingv1alpha1.TuningOverride{ScaleDownStabilizationWindowSeconds: &window},
+		},
+		{
+			name:     "scale down stabilization override cleared",
+			levels:   []uint64{80, 80, 80},
+			replicas: 3,
+			status:   v1.ConditionFalse,
+			reason:   "NoOverride",
+		},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			tc := testCase{
+				minReplicas:             2,
+				maxReplicas:             6,
+				specReplicas:            3,
+				statusReplicas:          3,
+				expectedDesiredReplicas: c.replicas,
+				CPUTarget:               40,
+				reportedLevels:          c.levels,
+				reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+				useMetricsAPI:           true,
+				recommendations:         []timestampedRecommendation{{3, time.Now().Add(-time.Minute)}},
+				modifyGPA: func(gpa *autoscalingv1alpha1.GeneralPodAutoscaler) {
+					gpa.Annotations = c.annotations
+					// overridden on the previous sync
+					gpa.Status.TuningOverride = &autoscalingv1alpha1.TuningOverride{Tolerance: &tolerance}
+					gpa.Status.Conditions = []autoscalingv1alpha1.GeneralPodAutoscalerCondition{
+						{Type: autoscalingv1alpha1.TuningOverridden, Status: v1.ConditionTrue, Reason: "OverriddenByAnnotations"},
+					}
+				},
+				verifyStatus: func(t *testing.T, status *autoscalingv1alpha1.GeneralPodAutoscalerStatus) {
+					assert.Equal(t, c.overridden, status.TuningOverride)
+					cond := getCondition(status.Conditions, autoscalingv1alpha1.TuningOverridden)
+					if assert.NotNil(t, cond) {
+						assert.Equal(t, c.status, cond.Status)
+						assert.Equal(t, c.reason, cond.Reason)
+					}
+				},
+			}
+			tc.runTest(t)
+		})
+	}
+}
+
+func TestToleranceOverrideKeptPerDecision(t *testing.T) {
+	engine := &DecisionEngine{replicaCalc: &ReplicaCalculator{tolerance: 0.1}}
+	overridden := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{overrideToleranceKey: "0.2"}},
+	}
+	plain := &autoscalingv1alpha1.GeneralPodAutoscaler{}
+
+	assert.Equal(t, 0.2, engine.applyTuningOverride(overridden, 0.1))
+	// the override of a GPA never leaks into the decisions of the others
+	assert.Equal(t, 0.1, engine.applyTuningOverride(plain, 0.1))
+	assert.Equal(t, 0.1, engine.replicaCalc.tolerance)
+}
+
+func TestScaleUpHeldByPendingPods(t *testing.T) {
+	for _, c := range []struct {
+		name           string
+		maxPendingPods int32
+		replicas       int32
+		limited        bool
+	}{
+		{name: "held", maxPendingPods: 2, replicas: 4, limited: true},
+		{name: "not held", maxPendingPods: 3, replicas: 6},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			tc := testCase{
+				minReplicas:             2,
+				maxReplicas:             8,
+				specReplicas:            4,
+				statusReplicas:          4,
+				expectedDesiredReplicas: c.replicas,
+				CPUTarget:               30,
+				reportedLevels:          []uint64{900, 900},
+				reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+				reportedPodReadiness:    []v1.ConditionStatus{v1.ConditionTrue, v1.ConditionTrue, v1.ConditionFalse, v1.ConditionFalse},
+				reportedPodPhase:        []v1.PodPhase{v1.PodRunning, v1.PodRunning, v1.PodPending, v1.PodPending},
+				useMetricsAPI:           true,
+				modifyGPA: func(gpa *autoscalingv1alpha1.GeneralPodAutoscaler) {
+					gpa.Spec.MaxPendingPods = &c.maxPendingPods
+				},
+				verifyStatus: func(t *testing.T, status *autoscalingv1alpha1.GeneralPodAutoscalerStatus) {
+					assert.Equal(t, int32(2), status.PendingReplicas)
+					cond := getCondition(status.Conditions, autoscalingv1alpha1.ScalingLimited)
+					if c.limited && assert.NotNil(t, cond) {
+						assert.Equal(t, "PendingPodsLimited", cond.Reason)
+					}
+				},
+			}
+			tc.runTest(t)
+		})
+	}
+}
+
 func TestScaleUpDeployment(t *testing.T) {
 	tc := testCase{
 		minReplicas:             2,
@@ -897,6 +1228,280 @@ func TestScaleUpDeployment(t *testing.T) {
 	tc.runTest(t)
 }
 
+func TestScaleDeferredDuringRollout(t *testing.T) {
+	replicas := int32(3)
+	tc := testCase{
+		minReplicas:             2,
+		maxReplicas:             6,
+		specReplicas:            3,
+		statusReplicas:          3,
+		expectedDesiredReplicas: 3,
+		CPUTarget:               30,
+		reportedLevels:          []uint64{300, 500, 700},
+		reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+		useMetricsAPI:           true,
+		resource: &fakeResource{
+			name:       "test-dep",
+			apiVersion: "apps/v1",
+			kind:       "Deployment",
+		},
+		modifyGPA: func(gpa *autoscalingv1alpha1.GeneralPodAutoscaler) {
+			gpa.Spec.FreezeOnRollout = true
+		},
+		deployment: &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-dep", Namespace: "test-namespace", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status: appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Replicas:           4,
+				UpdatedReplicas:    1,
+				AvailableReplicas:  3,
+			},
+		},
+		verifyStatus: func(t *testing.T, status *autoscalingv1alpha1.GeneralPodAutoscalerStatus) {
+			cond := getCondition(status.Conditions, autoscalingv1alpha1.ScalingPausedDuringRollout)
+			if assert.NotNil(t, cond) {
+				assert.Equal(t, v1.ConditionTrue, cond.Status)
+				assert.Equal(t, "RolloutInProgress", cond.Reason)
+			}
+		},
+	}
+	tc.runTest(t)
+}
+
+func TestScaleUpAfterRollout(t *testing.T) {
+	replicas := int32(3)
+	tc := testCase{
+		minReplicas:             2,
+		maxReplicas:             6,
+		specReplicas:            3,
+		statusReplicas:          3,
+		expectedDesiredReplicas: 5,
+		CPUTarget:               30,
+		reportedLevels:          []uint64{300, 500, 700},
+		reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+		useMetricsAPI:           true,
+		resource: &fakeResource{
+			name:       "test-dep",
+			apiVersion: "apps/v1",
+			kind:       "Deployment",
+		},
+		modifyGPA: func(gpa *autoscalingv1alpha1.GeneralPodAutoscaler) {
+			gpa.Spec.FreezeOnRollout = true
+		},
+		deployment: &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-dep", Namespace: "test-namespace", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status: appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Replicas:           3,
+				UpdatedReplicas:    3,
+				AvailableReplicas:  3,
+			},
+		},
+		verifyStatus: func(t *testing.T, status *autoscalingv1alpha1.GeneralPodAutoscalerStatus) {
+			cond := getCondition(status.Conditions, autoscalingv1alpha1.ScalingPausedDuringRollout)
+			if assert.NotNil(t, cond) {
+				assert.Equal(t, v1.ConditionFalse, cond.Status)
+			}
+		},
+	}
+	tc.runTest(t)
+}
+
+func TestScaleDuringRolloutWithoutDeployments(t *testing.T) {
+	replicas := int32(3)
+	tc := testCase{
+		minReplicas:             2,
+		maxReplicas:             6,
+		specReplicas:            3,
+		statusReplicas:          3,
+		expectedDesiredReplicas: 5,
+		CPUTarget:               30,
+		reportedLevels:          []uint64{300, 500, 700},
+		reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+		useMetricsAPI:           true,
+		resource: &fakeResource{
+			name:       "test-dep",
+			apiVersion: "apps/v1",
+			kind:       "Deployment",
+		},
+		modifyGPA: func(gpa *autoscalingv1alpha1.GeneralPodAutoscaler) {
+			gpa.Spec.FreezeOnRollout = true
+		},
+		deployment: &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-dep", Namespace: "test-namespace", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status: appsv1.DeploymentStatus{
+				ObservedGeneration: 2,
+				Replicas:           4,
+				UpdatedReplicas:    1,
+				AvailableReplicas:  3,
+			},
+		},
+		verifyStatus: func(t *testing.T, status *autoscalingv1alpha1.GeneralPodAutoscalerStatus) {
+			assert.Nil(t, getCondition(status.Conditions, autoscalingv1alpha1.ScalingPausedDuringRollout),
+				"the rollout is not checked without the deployments")
+		},
+	}
+	gpaController, informerFactory, scalerFactory := tc.setupController(t)
+	// the deployments are not listed by the controller
+	gpaController.deploymentLister = nil
+	gpaController.deploymentListerSynced = nil
+	tc.runTestWithController(t, gpaController, informerFactory, scalerFactory)
+}
+
+func TestScaleDeferredUntilPodsReadyForMinReadySeconds(t *testing.T) {
+	for _, c := range []struct {
+		name                      string
+		minReadySeconds           int32
+		deploymentMinReadySeconds int32
+		podReadyTime              metav1.Time
+		replicas                  int32
+		deferred                  bool
+	}{
+		{name: "recently ready", minReadySeconds: 60, podReadyTime: hotCpuCreationTime(), replicas: 3, deferred: true},
+		{name: "ready for long enough", minReadySeconds: 60, podReadyTime: coolCpuCreationTime(), replicas: 5},
+		{name: "min ready seconds of the deployment", deploymentMinReadySeconds: 300, podReadyTime: coolCpuCreationTime(),
+			replicas: 3, deferred: true},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			replicas := int32(3)
+			tc := testCase{
+				minReplicas:             2,
+				maxReplicas:             6,
+				specReplicas:            3,
+				statusReplicas:          3,
+				expectedDesiredReplicas: c.replicas,
+				CPUTarget:               30,
+				reportedLevels:          []uint64{300, 500, 700},
+				reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+				reportedPodStartTime:    []metav1.Time{coolCpuCreationTime(), coolCpuCreationTime(), c.podReadyTime},
+				useMetricsAPI:           true,
+				resource: &fakeResource{
+					name:       "test-dep",
+					apiVersion: "apps/v1",
+					kind:       "Deployment",
+				},
+				modifyGPA: func(gpa *autoscalingv1alpha1.GeneralPodAutoscaler) {
+					gpa.Spec.MinReadySeconds = &c.minReadySeconds
+				},
+				deployment: &appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-dep", Namespace: "test-namespace"},
+					Spec:       appsv1.DeploymentSpec{Replicas: &replicas, MinReadySeconds: c.deploymentMinReadySeconds},
+				},
+				verifyStatus: func(t *testing.T, status *autoscalingv1alpha1.GeneralPodAutoscalerStatus) {
+					cond := getCondition(status.Conditions, autoscalingv1alpha1.AbleToScale)
+					if c.deferred && assert.NotNil(t, cond) {
+						assert.Equal(t, "MinReadySecondsNotElapsed", cond.Reason)
+					}
+				},
+			}
+			tc.runTest(t)
+		})
+	}
+}
+
+func TestScaleDeferredDuringWarmup(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		created  time.Duration
+		replicas int32
+		status   v1.ConditionStatus
+	}{
+		{name: "warming up", created: time.Minute, replicas: 3, status: v1.ConditionTrue},
+		{name: "warmup elapsed", created: time.Hour, replicas: 5, status: v1.ConditionFalse},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			tc := testCase{
+				minReplicas:             2,
+				maxReplicas:             6,
+				specReplicas:            3,
+				statusReplicas:          3,
+				expectedDesiredReplicas: c.replicas,
+				CPUTarget:               30,
+				reportedLevels:          []uint64{300, 500, 700},
+				reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+				useMetricsAPI:           true,
+				modifyGPA: func(gpa *autoscalingv1alpha1.GeneralPodAutoscaler) {
+					warmup := int32(600)
+					gpa.Spec.WarmupSeconds = &warmup
+					gpa.CreationTimestamp = metav1.NewTime(time.Now().Add(-c.created))
+				},
+				verifyStatus: func(t *testing.T, status *autoscalingv1alpha1.GeneralPodAutoscalerStatus) {
+					cond := getCondition(status.Conditions, autoscalingv1alpha1.Warmup)
+					if assert.NotNil(t, cond) {
+						assert.Equal(t, c.status, cond.Status)
+					}
+				},
+			}
+			tc.runTest(t)
+		})
+	}
+}
+
+func TestScaleUpWithReadinessGapBuffer(t *testing.T) {
+	tc := testCase{
+		minReplicas:             2,
+		maxReplicas:             6,
+		specReplicas:            3,
+		statusReplicas:          3,
+		expectedDesiredReplicas: 5,
+		CPUTarget:               30,
+		reportedLevels:          []uint64{300, 300, 300},
+		reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+		reportedPodReadiness:    []v1.ConditionStatus{v1.ConditionTrue, v1.ConditionTrue, v1.ConditionFalse},
+		useMetricsAPI:           true,
+		modifyGPA: func(gpa *autoscalingv1alpha1.GeneralPodAutoscaler) {
+			gpa.Spec.ReadinessGapBuffer = &autoscalingv1alpha1.ReadinessGapBuffer{Replicas: 2}
+			// the gap has been observed for 2 minutes
+			gpa.Status.Conditions = []autoscalingv1alpha1.GeneralPodAutoscalerCondition{{
+				Type:               autoscalingv1alpha1.ReadinessGapBuffered,
+				Status:             v1.ConditionUnknown,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-2 * time.Minute)),
+			}}
+		},
+		verifyStatus: func(t *testing.T, status *autoscalingv1alpha1.GeneralPodAutoscalerStatus) {
+			cond := getCondition(status.Conditions, autoscalingv1alpha1.ReadinessGapBuffered)
+			if assert.NotNil(t, cond) {
+				assert.Equal(t, v1.ConditionTrue, cond.Status)
+				assert.Equal(t, "BufferApplied", cond.Reason)
+			}
+		},
+	}
+	tc.runTest(t)
+}
+
+func TestApplyReadinessGapBuffer(t *testing.T) {
+	gapSeconds := int32(60)
+	gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		Spec: autoscalingv1alpha1.GeneralPodAutoscalerSpec{
+			ReadinessGapBuffer: &autoscalingv1alpha1.ReadinessGapBuffer{Replicas: 2, GapSeconds: &gapSeconds},
+		},
+	}
+	start := time.Now()
+	status := func() v1.ConditionStatus {
+		return getCondition(gpa.Status.Conditions, autoscalingv1alpha1.ReadinessGapBuffered).Status
+	}
+
+	// the gap is observed but does not persist long enough
+	assert.Equal(t, int32(5), applyReadinessGapBuffer(gpa, 3, 5, start))
+	assert.Equal(t, v1.ConditionUnknown, status())
+	assert.Equal(t, int32(5), applyReadinessGapBuffer(gpa, 3, 5, start.Add(30*time.Second)))
+	// the gap persists, the buffer is added
+	assert.Equal(t, int32(7), applyReadinessGapBuffer(gpa, 3, 5, start.Add(61*time.Second)))
+	assert.Equal(t, v1.ConditionTrue, status())
+	assert.Equal(t, int32(7), applyReadinessGapBuffer(gpa, 4, 5, start.Add(90*time.Second)))
+	// the gap closes, the buffer is removed
+	assert.Equal(t, int32(5), applyReadinessGapBuffer(gpa, 5, 5, start.Add(120*time.Second)))
+	assert.Equal(t, v1.ConditionFalse, status())
+	// a new gap waits for gapSeconds again
+	assert.Equal(t, int32(5), applyReadinessGapBuffer(gpa, 4, 5, time.Now()))
+	assert.Equal(t, v1.ConditionUnknown, status())
+}
+
 func TestScaleUpReplicaSet(t *testing.T) {
 	tc := testCase{
 		minReplicas:             2,
@@ -1770,6 +2375,59 @@ func TestTolerance(t *testing.T) {
 	tc.runTest(t)
 }
 
+func TestToleranceExactTarget(t *testing.T) {
+	tc := testCase{
+		minReplicas:             1,
+		maxReplicas:             5,
+		specReplicas:            3,
+		statusReplicas:          3,
+		expectedDesiredReplicas: 3,
+		CPUTarget:               50,
+		reportedLevels:          []uint64{500, 500, 500},
+		reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+		useMetricsAPI:           true,
+		expectedConditions: statusOkWithOverrides(autoscalingv1alpha1.GeneralPodAutoscalerCondition{
+			Type:   autoscalingv1alpha1.AbleToScale,
+			Status: v1.ConditionTrue,
+			Reason: "ReadyForNewScale",
+		}),
+	}
+	gpaController, informerFactory, scalerFactory := tc.setupController(t)
+	// without any tolerance, only the exact target keeps the replicas
+	gpaController.replicaCalc.tolerance = 0
+	tc.runTestWithController(t, gpaController, informerFactory, scalerFactory)
+}
+
+func TestToleranceExactTargetCM(t *testing.T) {
+	averageValue := resource.MustParse("20.0")
+	tc := testCase{
+		minReplicas:             1,
+		maxReplicas:             5,
+		specReplicas:            3,
+		statusReplicas:          3,
+		expectedDesiredReplicas: 3,
+		metricsTarget: []autoscalingv1alpha1.MetricSpec{
+			{
+				Type: autoscalingv1alpha1.PodsMetricSourceType,
+				Pods: &autoscalingv1alpha1.PodsMetricSource{
+					Metric: autoscalingv1alpha1.MetricIdentifier{Name: "qps"},
+					Target: autoscalingv1alpha1.MetricTarget{AverageValue: &averageValue},
+				},
+			},
+		},
+		reportedLevels:      []uint64{20000, 20000, 20000},
+		reportedCPURequests: []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+		expectedConditions: statusOkWithOverrides(autoscalingv1alpha1.GeneralPodAutoscalerCondition{
+			Type:   autoscalingv1alpha1.AbleToScale,
+			Status: v1.ConditionTrue,
+			Reason: "ReadyForNewScale",
+		}),
+	}
+	gpaController, informerFactory, scalerFactory := tc.setupController(t)
+	gpaController.replicaCalc.tolerance = 0
+	tc.runTestWithController(t, gpaController, informerFactory, scalerFactory)
+}
+
 func TestToleranceCM(t *testing.T) {
 	averageValue := resource.MustParse("20.0")
 	tc := testCase{
@@ -2019,6 +2677,220 @@ func TestZeroReplicas(t *testing.T) {
 	tc.runTest(t)
 }
 
+func TestZeroReplicasRecoverToMinReplicas(t *testing.T) {
+	tc := testCase{
+		minReplicas:             1,
+		maxReplicas:             5,
+		specReplicas:            0,
+		statusReplicas:          0,
+		expectedDesiredReplicas: 1,
+		CPUTarget:               90,
+		reportedLevels:          []uint64{},
+		reportedCPURequests:     []resource.Quantity{},
+		useMetricsAPI:           true,
+		modifyGPA: func(gpa *autoscalingv1alpha1.GeneralPodAutoscaler) {
+			gpa.Spec.RecoverFromZero = &autoscalingv1alpha1.RecoverFromZero{}
+		},
+		expectedConditions: []autoscalingv1alpha1.GeneralPodAutoscalerCondition{
+			{Type: autoscalingv1alpha1.AbleToScale, Status: v1.ConditionTrue, Reason: "SucceededRescale"},
+			{Type: autoscalingv1alpha1.ScalingActive, Status: v1.ConditionTrue, Reason: "RecoveringFromZero"},
+		},
+	}
+	tc.runTest(t)
+}
+
+func TestZeroReplicasRecoverToBootstrapReplicas(t *testing.T) {
+	bootstrapReplicas := int32(4)
+	tc := testCase{
+		minReplicas:             2,
+		maxReplicas:             5,
+		specReplicas:            0,
+		statusReplicas:          0,
+		expectedDesiredReplicas: 4,
+		CPUTarget:               90,
+		reportedLevels:          []uint64{},
+		reportedCPURequests:     []resource.Quantity{},
+		useMetricsAPI:           true,
+		modifyGPA: func(gpa *autoscalingv1alpha1.GeneralPodAutoscaler) {
+			gpa.Spec.RecoverFromZero = &autoscalingv1alpha1.RecoverFromZero{BootstrapReplicas: &bootstrapReplicas}
+		},
+		expectedConditions: []autoscalingv1alpha1.GeneralPodAutoscalerCondition{
+			{Type: autoscalingv1alpha1.AbleToScale, Status: v1.ConditionTrue, Reason: "SucceededRescale"},
+			{Type: autoscalingv1alpha1.ScalingActive, Status: v1.ConditionTrue, Reason: "RecoveringFromZero"},
+		},
+	}
+	tc.runTest(t)
+}
+
+func TestMinScaleIntervalCoalescesScaleWrites(t *testing.T) {
+	tc := testCase{
+		minReplicas:             3,
+		maxReplicas:             5,
+		specReplicas:            2,
+		statusReplicas:          2,
+		expectedDesiredReplicas: 3,
+		CPUTarget:               90,
+		reportedLevels:          []uint64{},
+		reportedCPURequests:     []resource.Quantity{},
+		useMetricsAPI:           true,
+	}
+	gpaController, informerFactory, scalerFactory := tc.setupController(t)
+	gpaController.SetMinScaleInterval(time.Minute)
+	// the fake scale client always returns 2 replicas, so every sync would scale the target again
+	scaleWrites := 0
+	gpaController.scaleNamespacer.(*scalefake.FakeScaleClient).PrependReactor("update", "*",
+		func(action core.Action) (handled bool, ret runtime.Object, err error) {
+			scaleWrites++
+			return false, nil, nil
+		})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	scalerFactory.Start(stop)
+	informerFactory.Start(stop)
+	if !cache.WaitForCacheSync(stop, scalerFactory.Autoscaling().V1alpha1().GeneralPodAutoscalers().Informer().HasSynced) {
+		t.Fatal("failed to sync gpas")
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := gpaController.reconcileKey("test-namespace/test-gpa"); err != nil {
+			t.Fatalf("reconcile failed: %v", err)
+		}
+		// the deferred syncs keep the current replicas
+		tc.Lock()
+		tc.expectedDesiredReplicas = 2
+		tc.Unlock()
+	}
+	assert.Equal(t, 1, scaleWrites, "the target should be scaled once within the interval")
+
+	tc.Lock()
+	tc.expectedDesiredReplicas = 3
+	tc.Unlock()
+	gpaController.lastScaleWrites["test-namespace/test-gpa"] = time.Now().Add(-time.Minute)
+	if _, err := gpaController.reconcileKey("test-namespace/test-gpa"); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	assert.Equal(t, 2, scaleWrites, "the target should be scaled again once the interval elapsed")
+}
+
+func TestObserveFirstReconcile(t *testing.T) {
+	for _, actOnFirst := range []bool{false, true} {
+		tc := testCase{
+			minReplicas:             2,
+			maxReplicas:             6,
+			specReplicas:            3,
+			statusReplicas:          3,
+			expectedDesiredReplicas: 5,
+			CPUTarget:               30,
+			reportedLevels:          []uint64{300, 500, 700},
+			reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+			useMetricsAPI:           true,
+		}
+		if actOnFirst {
+			tc.modifyGPA = func(gpa *autoscalingv1alpha1.GeneralPodAutoscaler) {
+				gpa.Annotations = map[string]string{actOnFirstReconcileKey: "true"}
+			}
+		}
+		gpaController, informerFactory, scalerFactory := tc.setupController(t)
+		gpaController.SetObserveFirstReconcile(true)
+		scaleWrites := 0
+		gpaController.scaleNamespacer.(*scalefake.FakeScaleClient).PrependReactor("update", "*",
+			func(action core.Action) (handled bool, ret runtime.Object, err error) {
+				scaleWrites++
+				return false, nil, nil
+			})
+
+		stop := make(chan struct{})
+		scalerFactory.Start(stop)
+		informerFactory.Start(stop)
+		if !cache.WaitForCacheSync(stop, scalerFactory.Autoscaling().V1alpha1().GeneralPodAutoscalers().Informer().HasSynced) {
+			t.Fatal("failed to sync gpas")
+		}
+		if !actOnFirst {
+			// the observing reconcile keeps the current replicas
+			tc.Lock()
+			tc.expectedDesiredReplicas = 3
+			tc.Unlock()
+		}
+		if _, err := gpaController.reconcileKey("test-namespace/test-gpa"); err != nil {
+			t.Fatalf("reconcile failed: %v", err)
+		}
+		if actOnFirst {
+			assert.Equal(t, 1, scaleWrites, "the annotated GPA should be scaled on the first reconcile")
+		} else {
+			assert.Equal(t, 0, scaleWrites, "the first reconcile should only observe the recommendation")
+		}
+
+		tc.Lock()
+		tc.expectedDesiredReplicas = 5
+		tc.Unlock()
+		if _, err := gpaController.reconcileKey("test-namespace/test-gpa"); err != nil {
+			t.Fatalf("reconcile failed: %v", err)
+		}
+		if actOnFirst {
+			assert.Equal(t, 2, scaleWrites, "the annotated GPA should be scaled on every reconcile")
+		} else {
+			assert.Equal(t, 1, scaleWrites, "the target should be scaled from the second reconcile")
+		}
+		close(stop)
+	}
+}
+
+func TestAdaptiveResync(t *testing.T) {
+	key := "test-namespace/test-gpa"
+	reconcile := func(tc *testCase, stableReconciles, times int) *GeneralController {
+		gpaController, informerFactory, scalerFactory := tc.setupController(t)
+		gpaController.SetAdaptiveResync(time.Second, 8*time.Second)
+		for i := 0; i < stableReconciles; i++ {
+			gpaController.adaptResync(key, false)
+		}
+		stop := make(chan struct{})
+		defer close(stop)
+		scalerFactory.Start(stop)
+		informerFactory.Start(stop)
+		if !cache.WaitForCacheSync(stop, scalerFactory.Autoscaling().V1alpha1().GeneralPodAutoscalers().Informer().HasSynced) {
+			t.Fatal("failed to sync gpas")
+		}
+		for i := 0; i < times; i++ {
+			if _, err := gpaController.reconcileKey(key); err != nil {
+				t.Fatalf("reconcile failed: %v", err)
+			}
+		}
+		return gpaController
+	}
+
+	// the metrics stay within the tolerance, the resync slows down on each reconcile
+	stable := &testCase{
+		minReplicas:             1,
+		maxReplicas:             5,
+		specReplicas:            3,
+		statusReplicas:          3,
+		expectedDesiredReplicas: 3,
+		CPUTarget:               100,
+		reportedLevels:          []uint64{1010, 1030, 1020},
+		reportedCPURequests:     []resource.Quantity{resource.MustParse("0.9"), resource.MustParse("1.0"), resource.MustParse("1.1")},
+		useMetricsAPI:           true,
+	}
+	gpaController := reconcile(stable, 0, 1)
+	assert.Equal(t, 2*time.Second, gpaController.rateLimiter.When(key))
+	gpaController = reconcile(stable, 0, 3)
+	assert.Equal(t, 8*time.Second, gpaController.rateLimiter.When(key), "the interval is bounded by the max")
+
+	// the target is scaled after a stable period, the resync speeds up
+	scaled := &testCase{
+		minReplicas:             2,
+		maxReplicas:             6,
+		specReplicas:            3,
+		statusReplicas:          3,
+		expectedDesiredReplicas: 5,
+		CPUTarget:               30,
+		reportedLevels:          []uint64{300, 500, 700},
+		reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+		useMetricsAPI:           true,
+	}
+	gpaController = reconcile(scaled, 2, 1)
+	assert.Equal(t, time.Second, gpaController.rateLimiter.When(key), "the interval shrinks from 4s to the min")
+}
+
 func TestTooFewReplicas(t *testing.T) {
 	tc := testCase{
 		minReplicas:             3,
@@ -2515,6 +3387,97 @@ func TestConditionFailedGetScale(t *testing.T) {
 	tc.runTest(t)
 }
 
+func TestConditionTargetMissing(t *testing.T) {
+	tc := testCase{
+		minReplicas:             1,
+		maxReplicas:             100,
+		specReplicas:            3,
+		statusReplicas:          3,
+		expectedDesiredReplicas: 3,
+		CPUTarget:               10,
+		reportedLevels:          []uint64{100, 200, 300},
+		reportedCPURequests:     []resource.Quantity{resource.MustParse("0.1"), resource.MustParse("0.1"), resource.MustParse("0.1")},
+		useMetricsAPI:           true,
+		expectedConditions: []autoscalingv1alpha1.GeneralPodAutoscalerCondition{
+			{
+				Type:   autoscalingv1alpha1.AbleToScale,
+				Status: v1.ConditionFalse,
+				Reason: "TargetMissing",
+			},
+		},
+	}
+
+	_, _, _, _, testScaleClient, _ := tc.prepareTestClient(t)
+	tc.testScaleClient = testScaleClient
+
+	testScaleClient.PrependReactor("get", "replicationcontrollers", func(action core.Action) (handled bool, ret runtime.Object, err error) {
+		return true, nil, errors.NewNotFound(schema.GroupResource{Resource: "replicationcontrollers"}, "test-rc")
+	})
+
+	tc.runTest(t)
+}
+
+func TestHandleTargetMissing(t *testing.T) {
+	notFound := errors.NewNotFound(schema.GroupResource{Resource: "deployments"}, "web")
+	for _, c := range []struct {
+		name          string
+		policy        *autoscalingv1alpha1.TargetMissingPolicy
+		expectDeleted bool
+		expectReason  string
+	}{
+		{
+			name:         "default",
+			expectReason: "TargetMissing",
+		},
+		{
+			name:         "error",
+			policy:       targetMissingPolicy(autoscalingv1alpha1.ErrorTargetMissing),
+			expectReason: "TargetMissing",
+		},
+		{
+			name:   "ignore",
+			policy: targetMissingPolicy(autoscalingv1alpha1.IgnoreTargetMissing),
+		},
+		{
+			name:          "delete self",
+			policy:        targetMissingPolicy(autoscalingv1alpha1.DeleteSelfTargetMissing),
+			expectDeleted: true,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+				Spec:       autoscalingv1alpha1.GeneralPodAutoscalerSpec{OnTargetMissing: c.policy},
+			}
+			fakeClient := autoscalingfake.NewSimpleClientset(gpa.DeepCopy())
+			controller := &GeneralController{
+				gpaNamespacer:  fakeClient.AutoscalingV1alpha1(),
+				DecisionEngine: &DecisionEngine{eventRecorder: record.NewFakeRecorder(10)},
+			}
+			assert.NoError(t, controller.handleTargetMissing(gpa.Status.DeepCopy(), gpa, "Deployment/default/web", notFound))
+
+			stored, err := fakeClient.AutoscalingV1alpha1().GeneralPodAutoscalers("default").Get("web", metav1.GetOptions{})
+			if c.expectDeleted {
+				assert.True(t, errors.IsNotFound(err), "expected the GPA to be deleted, got: %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			if c.expectReason == "" {
+				assert.Empty(t, stored.Status.Conditions)
+				return
+			}
+			assert.Len(t, stored.Status.Conditions, 1)
+			assert.Equal(t, autoscalingv1alpha1.AbleToScale, stored.Status.Conditions[0].Type)
+			assert.Equal(t, v1.ConditionFalse, stored.Status.Conditions[0].Status)
+			assert.Equal(t, c.expectReason, stored.Status.Conditions[0].Reason)
+		})
+	}
+}
+
+func targetMissingPolicy(policy autoscalingv1alpha1.TargetMissingPolicy) *autoscalingv1alpha1.TargetMissingPolicy {
+	return &policy
+}
+
 func TestConditionFailedUpdateScale(t *testing.T) {
 	tc := testCase{
 		minReplicas:             1,
@@ -3069,7 +4032,8 @@ func TestNormalizeDesiredReplicas(t *testing.T) {
 		},
 	}
 	for _, tc := range tests {
-		hc := GeneralController{
+		hc := DecisionEngine{
+			clock:                        clock.RealClock{},
 			downscaleStabilisationWindow: 5 * time.Minute,
 			recommendations: map[string][]timestampedRecommendation{
 				tc.key: tc.recommendations,
@@ -3085,6 +4049,38 @@ func TestNormalizeDesiredReplicas(t *testing.T) {
 	}
 }
 
+func TestSmoothRecommendation(t *testing.T) {
+	alpha := 0.5
+	gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		Spec: autoscalingv1alpha1.GeneralPodAutoscalerSpec{
+			Behavior: &autoscalingv1alpha1.GeneralPodAutoscalerBehavior{EWMAAlpha: &alpha},
+		},
+	}
+
+	// the first recommendation initializes the smoothed value
+	assert.Equal(t, int32(2), smoothRecommendation(gpa, 2))
+	assert.Equal(t, 2.0, *gpa.Status.SmoothedReplicas)
+
+	// a step to 10 replicas is taken gradually
+	var got []int32
+	for i := 0; i < 6; i++ {
+		got = append(got, smoothRecommendation(gpa, 10))
+	}
+	assert.Equal(t, []int32{6, 8, 9, 10, 10, 10}, got)
+	assert.InDelta(t, 10, *gpa.Status.SmoothedReplicas, 0.2)
+
+	// the smoothed value keeps converging across reconciles
+	for i := 0; i < 60; i++ {
+		smoothRecommendation(gpa, 10)
+	}
+	assert.InDelta(t, 10, *gpa.Status.SmoothedReplicas, 1e-9)
+
+	// smoothing is dropped once the alpha is removed
+	gpa.Spec.Behavior.EWMAAlpha = nil
+	assert.Equal(t, int32(3), smoothRecommendation(gpa, 3))
+	assert.Nil(t, gpa.Status.SmoothedReplicas)
+}
+
 func TestScaleUpOneMetricEmpty(t *testing.T) {
 	tc := testCase{
 		minReplicas:             2,
@@ -3184,6 +4180,42 @@ func TestNoScaleDownOneMetricEmpty(t *testing.T) {
 	tc.runTest(t)
 }
 
+func TestDecisionLogDebugAnnotation(t *testing.T) {
+	fs := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(fs)
+	assert.NoError(t, fs.Set("v", "0"))
+	assert.NoError(t, fs.Set("logtostderr", "false"))
+	var buf bytes.Buffer
+	klog.SetOutput(&buf)
+	defer func() {
+		fs.Set("logtostderr", "true")
+		klog.SetOutput(os.Stderr)
+	}()
+
+	debugGPA := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "debug-gpa",
+			Namespace:   "default",
+			Annotations: map[string]string{debugKey: "true"},
+		},
+	}
+	plainGPA := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "plain-gpa",
+			Namespace: "default",
+		},
+	}
+
+	computeDesiredSize(plainGPA, nil, 2)
+	klog.Flush()
+	assert.NotContains(t, buf.String(), "plain-gpa")
+
+	computeDesiredSize(debugGPA, nil, 2)
+	klog.Flush()
+	assert.Contains(t, buf.String(), "Scaler number of debug-gpa")
+	assert.NotContains(t, buf.String(), "plain-gpa")
+}
+
 func testScheme() *runtime.Scheme {
 	s := runtime.NewScheme()
 	s.AddKnownTypes(schema.GroupVersion{
@@ -3202,3 +4234,206 @@ func testScheme() *runtime.Scheme {
 }
 
 // TODO: add more tests
+
+func TestSetStatusNextSchedule(t *testing.T) {
+	gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		Spec: autoscalingv1alpha1.GeneralPodAutoscalerSpec{
+			AutoScalingDrivenMode: autoscalingv1alpha1.AutoScalingDrivenMode{
+				TimeMode: &autoscalingv1alpha1.TimeMode{
+					TimeRanges: []autoscalingv1alpha1.TimeRange{
+						{Schedule: "0 0 1 1 *", DesiredReplicas: 3},
+						{Schedule: "0 0 * * *", DesiredReplicas: 2},
+					},
+				},
+			},
+		},
+	}
+	controller := &GeneralController{DecisionEngine: &DecisionEngine{clock: clock.RealClock{}}}
+	controller.setStatus(gpa, 2, 2, nil, false)
+	if assert.NotNil(t, gpa.Status.NextScheduleTime) {
+		next := gpa.Status.NextScheduleTime.Time
+		assert.True(t, next.After(time.Now()), "the next schedule should be in the future")
+		assert.Equal(t, 0, next.Hour()+next.Minute(), "the next schedule should be at midnight")
+		assert.True(t, next.Sub(time.Now()) <= 24*time.Hour, "the daily range should be scheduled next")
+	}
+	expected := int32(2)
+	if now := time.Now(); now.Month() == time.December && now.Day() == 31 {
+		// both ranges are scheduled at the next midnight
+		expected = 3
+	}
+	assert.Equal(t, expected, gpa.Status.NextScheduleReplicas)
+
+	// the next schedule follows the change of the schedules
+	gpa.Spec.TimeMode.TimeRanges = []autoscalingv1alpha1.TimeRange{{Schedule: "0 0 1 1 *", DesiredReplicas: 5}}
+	controller.setStatus(gpa, 2, 2, nil, false)
+	if assert.NotNil(t, gpa.Status.NextScheduleTime) {
+		next := gpa.Status.NextScheduleTime.Time
+		assert.Equal(t, time.January, next.Month())
+		assert.Equal(t, 1, next.Day())
+	}
+	assert.Equal(t, int32(5), gpa.Status.NextScheduleReplicas)
+}
+
+func TestUpdateStatusOnConflict(t *testing.T) {
+	gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", ResourceVersion: "1"},
+	}
+	latest := gpa.DeepCopy()
+	latest.ResourceVersion = "2"
+	latest.Annotations = map[string]string{"updated": "true"}
+	fakeClient := autoscalingfake.NewSimpleClientset(latest)
+	var writes []*autoscalingv1alpha1.GeneralPodAutoscaler
+	fakeClient.PrependReactor("update", "generalpodautoscalers", func(action core.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "status" {
+			return false, nil, nil
+		}
+		obj := action.(core.UpdateAction).GetObject().(*autoscalingv1alpha1.GeneralPodAutoscaler)
+		writes = append(writes, obj.DeepCopy())
+		if len(writes) == 1 {
+			return true, nil, errors.NewConflict(schema.GroupResource{Resource: "generalpodautoscalers"}, "test", nil)
+		}
+		return false, nil, nil
+	})
+	controller := &GeneralController{gpaNamespacer: fakeClient.AutoscalingV1alpha1()}
+
+	gpa.Status.DesiredReplicas = 5
+	assert.NoError(t, controller.updateStatus(gpa))
+	assert.Len(t, writes, 2)
+	// the status is applied to the latest GPA
+	assert.Equal(t, "2", writes[1].ResourceVersion)
+	assert.Equal(t, "true", writes[1].Annotations["updated"])
+	assert.Equal(t, int32(5), writes[1].Status.DesiredReplicas)
+	stored, err := fakeClient.AutoscalingV1alpha1().GeneralPodAutoscalers("default").Get("test", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), stored.Status.DesiredReplicas)
+}
+
+func TestMinScaleIntervalByFakeClock(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	controller := &GeneralController{
+		DecisionEngine:  NewDecisionEngine(nil, nil, nil, &record.FakeRecorder{}, clock.RealClock{}, 0.1, 0, 0, 0),
+		lastScaleWrites: map[string]time.Time{},
+	}
+	controller.SetClock(fakeClock)
+	controller.SetMinScaleInterval(time.Minute)
+	key := "default/web"
+	assert.Equal(t, time.Duration(0), controller.scaleIntervalRemaining(key))
+
+	controller.lastScaleWrites[key] = fakeClock.Now()
+	fakeClock.Step(20 * time.Second)
+	assert.Equal(t, 40*time.Second, controller.scaleIntervalRemaining(key))
+	fakeClock.Step(40 * time.Second)
+	assert.Equal(t, time.Duration(0), controller.scaleIntervalRemaining(key))
+}
+
+func TestComputeReplicasForMetricsUnavailable(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	metricsClient := metricsclient.NewRESTMetricsClient((&metricsfake.Clientset{}).MetricsV1beta1(),
+		&cmfake.FakeCustomMetricsClient{}, &emfake.FakeExternalMetricsClient{})
+	client := metricsclient.NewStartupMetricsClient(metricsClient, func() error {
+		return fmt.Errorf("the server could not find the requested resource")
+	}, metricsclient.StartupBackoff, stopCh)
+	engine := &DecisionEngine{
+		replicaCalc:   &ReplicaCalculator{metricsClient: client},
+		eventRecorder: record.NewFakeRecorder(10),
+	}
+	gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{}
+	scale := &autoscalinginternal.Scale{
+		Spec:   autoscalinginternal.ScaleSpec{Replicas: 2},
+		Status: autoscalinginternal.ScaleStatus{Replicas: 2, Selector: "app=web"},
+	}
+
+	_, _, _, _, err := engine.computeReplicasForMetrics(gpa, scale, []autoscalingv1alpha1.MetricSpec{{
+		Type: autoscalingv1alpha1.ResourceMetricSourceType,
+	}})
+	assert.Equal(t, metricsclient.ErrMetricsUnavailable, err)
+	assert.Len(t, gpa.Status.Conditions, 1)
+	assert.Equal(t, autoscalingv1alpha1.ScalingActive, gpa.Status.Conditions[0].Type)
+	assert.Equal(t, v1.ConditionFalse, gpa.Status.Conditions[0].Status)
+	assert.Equal(t, "MetricsUnavailable", gpa.Status.Conditions[0].Reason)
+
+	// the external metrics API is not gated by the resource metrics API
+	gpa = &autoscalingv1alpha1.GeneralPodAutoscaler{}
+	_, _, _, _, err = engine.computeReplicasForMetrics(gpa, scale, []autoscalingv1alpha1.MetricSpec{{
+		Type: autoscalingv1alpha1.ExternalMetricSourceType,
+		External: &autoscalingv1alpha1.ExternalMetricSource{
+			Metric: autoscalingv1alpha1.MetricIdentifier{Name: "queue_length"},
+			Target: autoscalingv1alpha1.MetricTarget{
+				Type:  autoscalingv1alpha1.ValueMetricType,
+				Value: resource.NewQuantity(30, resource.DecimalSI),
+			},
+		},
+	}})
+	assert.NotEqual(t, metricsclient.ErrMetricsUnavailable, err)
+	assert.NotEqual(t, "MetricsUnavailable", gpa.Status.Conditions[0].Reason)
+}
+
+func TestContainerResourceMetricCountsOnlyTheContainer(t *testing.T) {
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	podMetrics := &metricsapi.PodMetricsList{}
+	for i := 0; i < 2; i++ {
+		name := fmt.Sprintf("web-%d", i)
+		requests := v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}
+		pods.Add(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "web"}},
+			Spec: v1.PodSpec{Containers: []v1.Container{
+				{Name: "app", Resources: requests},
+				{Name: "sidecar", Resources: requests},
+			}},
+			Status: v1.PodStatus{
+				Phase:     v1.PodRunning,
+				StartTime: &metav1.Time{Time: time.Now().Add(-time.Hour)},
+				Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue,
+					LastTransitionTime: metav1.Time{Time: time.Now().Add(-time.Hour)}}},
+			},
+		})
+		// the pods are 50% utilized, while the app container is 90% utilized
+		podMetrics.Items = append(podMetrics.Items, metricsapi.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "web"}},
+			Timestamp:  metav1.Now(),
+			Window:     metav1.Duration{Duration: time.Minute},
+			Containers: []metricsapi.ContainerMetrics{
+				{Name: "app", Usage: v1.ResourceList{v1.ResourceCPU: resource.MustParse("900m")}},
+				{Name: "sidecar", Usage: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")}},
+			},
+		})
+	}
+	fakeMetricsClient := &metricsfake.Clientset{}
+	fakeMetricsClient.AddReactor("list", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		return true, podMetrics, nil
+	})
+	metricsClient := metricsclient.NewRESTMetricsClient(fakeMetricsClient.MetricsV1beta1(),
+		&cmfake.FakeCustomMetricsClient{}, &emfake.FakeExternalMetricsClient{})
+	engine := &DecisionEngine{
+		replicaCalc:   NewReplicaCalculator(metricsClient, corelisters.NewPodLister(pods), 0.1, 0, 0),
+		eventRecorder: record.NewFakeRecorder(10),
+	}
+	selector := labels.SelectorFromSet(labels.Set{"app": "web"})
+	utilization := int32(50)
+	spec := func(container string) autoscalingv1alpha1.MetricSpec {
+		return autoscalingv1alpha1.MetricSpec{
+			Type: autoscalingv1alpha1.ContainerResourceMetricSourceType,
+			ContainerResource: &autoscalingv1alpha1.ContainerResourceMetricSource{
+				Name:      v1.ResourceCPU,
+				Container: container,
+				Target: autoscalingv1alpha1.MetricTarget{
+					Type:               autoscalingv1alpha1.UtilizationMetricType,
+					AverageUtilization: &utilization,
+				},
+			},
+		}
+	}
+	gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+
+	status := autoscalingv1alpha1.MetricStatus{}
+	replicas, _, _, _, err := engine.computeStatusForContainerResourceMetric(2, spec("app"), gpa, selector, &status)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), replicas)
+	assert.Equal(t, int32(90), *status.ContainerResource.Current.AverageUtilization)
+
+	// the container is not in the pod template
+	_, _, _, condition, err := engine.computeStatusForContainerResourceMetric(2, spec("ap"), gpa, selector, &status)
+	assert.Error(t, err)
+	assert.Equal(t, "InvalidContainerResourceMetric", condition.Reason)
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// DecisionRecord records the inputs and the output of a decision of the controller for a GPA
+type DecisionRecord struct {
+	Timestamp       time.Time `json:"timestamp"`
+	CurrentReplicas int32     `json:"currentReplicas"`
+	MinReplicas     int32     `json:"minReplicas"`
+	MaxReplicas     int32     `json:"maxReplicas"`
+	// MetricName is the metric or mode which proposed the replicas
+	MetricName string `json:"metricName,omitempty"`
+	// MetricStatuses are the current values of the metrics in metric mode
+	MetricStatuses []v1alpha1.MetricStatus `json:"metricStatuses,omitempty"`
+	// RecommendedReplicas are the replicas recommended after the behavior is applied
+	RecommendedReplicas int32 `json:"recommendedReplicas"`
+	// DesiredReplicas are the replicas the target is scaled to, after the capacity limit
+	DesiredReplicas int32  `json:"desiredReplicas"`
+	Reason          string `json:"reason,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// decisionHistory keeps the last decisions of each GPA in a bounded ring
+type decisionHistory struct {
+	lock    sync.Mutex
+	size    int
+	records map[string][]DecisionRecord
+}
+
+func newDecisionHistory(size int) *decisionHistory {
+	return &decisionHistory{size: size, records: map[string][]DecisionRecord{}}
+}
+
+func (h *decisionHistory) add(key string, record DecisionRecord) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	records := append(h.records[key], record)
+	if len(records) > h.size {
+		records = records[len(records)-h.size:]
+	}
+	h.records[key] = records
+}
+
+func (h *decisionHistory) get(key string) ([]DecisionRecord, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	records, ok := h.records[key]
+	return append([]DecisionRecord(nil), records...), ok
+}
+
+func (h *decisionHistory) forget(key string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	delete(h.records, key)
+}
+
+// SetDecisionHistory keeps the last size decisions of each GPA, which are served by HistoryHandler.
+func (a *GeneralController) SetDecisionHistory(size int) {
+	a.history = newDecisionHistory(size)
+}
+
+// recordDecision records the decision of the GPA if the history or the last decision is kept
+func (a *GeneralController) recordDecision(key string, record DecisionRecord) {
+	record.Timestamp = a.clock.Now()
+	if a.history != nil {
+		a.history.add(key, record)
+	}
+	if a.latestDecisions != nil {
+		a.latestDecisions.add(key, record)
+	}
+}
+
+// HistoryHandler serves the decisions of the GPA given by the query parameter gpa=namespace/name as JSON,
+// the oldest first.
+func (a *GeneralController) HistoryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("gpa")
+		if key == "" {
+			http.Error(w, "query parameter gpa=namespace/name is required", http.StatusBadRequest)
+			return
+		}
+		if a.history == nil {
+			http.Error(w, "decision history is disabled", http.StatusNotFound)
+			return
+		}
+		records, ok := a.history.get(key)
+		if !ok {
+			http.Error(w, "no decisions of GPA "+key, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(records); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+func queryHistory(t *testing.T, controller *GeneralController, query string) (int, []DecisionRecord) {
+	recorder := httptest.NewRecorder()
+	controller.HistoryHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/history"+query, nil))
+	var records []DecisionRecord
+	if recorder.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &records))
+	}
+	return recorder.Code, records
+}
+
+func TestDecisionHistory(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	controller := &GeneralController{DecisionEngine: &DecisionEngine{clock: fakeClock}}
+	code, _ := queryHistory(t, controller, "?gpa=default/web")
+	assert.Equal(t, http.StatusNotFound, code, "the history is disabled")
+
+	controller.SetDecisionHistory(2)
+	for i := int32(1); i <= 3; i++ {
+		controller.recordDecision("default/web", DecisionRecord{CurrentReplicas: i, DesiredReplicas: i + 1})
+		fakeClock.Step(time.Minute)
+	}
+	code, records := queryHistory(t, controller, "?gpa=default/web")
+	assert.Equal(t, http.StatusOK, code)
+	// the oldest decision is dropped
+	require.Len(t, records, 2)
+	assert.Equal(t, int32(2), records[0].CurrentReplicas)
+	assert.Equal(t, int32(4), records[1].DesiredReplicas)
+	assert.True(t, records[0].Timestamp.Before(records[1].Timestamp))
+
+	code, _ = queryHistory(t, controller, "?gpa=default/other")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = queryHistory(t, controller, "")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestDecisionHistoryRecordedByReconcile(t *testing.T) {
+	tc := testCase{
+		minReplicas:             2,
+		maxReplicas:             6,
+		specReplicas:            3,
+		statusReplicas:          3,
+		expectedDesiredReplicas: 5,
+		CPUTarget:               30,
+		reportedLevels:          []uint64{300, 500, 700},
+		reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+		useMetricsAPI:           true,
+	}
+	controller, informerFactory, scalerFactory := tc.setupController(t)
+	controller.SetDecisionHistory(5)
+	tc.runTestWithController(t, controller, informerFactory, scalerFactory)
+
+	records, ok := controller.history.get("test-namespace/test-gpa")
+	require.True(t, ok)
+	// the harness may resync the gpa, but the history is bounded by its size
+	require.NotEmpty(t, records)
+	assert.True(t, len(records) <= 5)
+	last := records[len(records)-1]
+	assert.Equal(t, int32(3), last.CurrentReplicas)
+	assert.Equal(t, int32(5), last.DesiredReplicas)
+	assert.Equal(t, "cpu resource utilization (percentage of request)", last.MetricName)
+	assert.Len(t, last.MetricStatuses, 1)
+	assert.Empty(t, last.Error)
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	scaleclient "k8s.io/client-go/scale"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// ImpersonatingScalesFunc creates the scale client writing the scales as the user
+type ImpersonatingScalesFunc func(userName string) (scaleclient.ScalesGetter, error)
+
+// impersonatingScales caches the scale clients of the users impersonated by the GPAs
+type impersonatingScales struct {
+	sync.Mutex
+	newScales ImpersonatingScalesFunc
+	clients   map[string]scaleclient.ScalesGetter
+}
+
+// NewImpersonatingScalesFunc returns an ImpersonatingScalesFunc creating the scale clients of the config, with
+// the impersonation headers of the user set on their requests.
+func NewImpersonatingScalesFunc(config *rest.Config, mapper scaleclient.PreferredResourceMapper,
+	scaleKindResolver scaleclient.ScaleKindResolver) ImpersonatingScalesFunc {
+	return func(userName string) (scaleclient.ScalesGetter, error) {
+		impersonated := rest.CopyConfig(config)
+		impersonated.Impersonate = rest.ImpersonationConfig{UserName: userName}
+		return scaleclient.NewForConfig(impersonated, mapper, dynamic.LegacyAPIPathResolverFunc, scaleKindResolver)
+	}
+}
+
+// SetImpersonatingScales lets the GPAs setting spec.impersonateServiceAccount write the scales of their targets
+// as the service account, with the clients created by newScales. Without it, such GPAs fail to scale rather
+// than scaling as the controller.
+func (a *GeneralController) SetImpersonatingScales(newScales ImpersonatingScalesFunc) {
+	a.impersonatingScales = &impersonatingScales{newScales: newScales, clients: map[string]scaleclient.ScalesGetter{}}
+}
+
+// serviceAccountUserName returns the user name of the service account authenticated by its token
+func serviceAccountUserName(namespace, name string) string {
+	return fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name)
+}
+
+// scalesFor returns the scale client writing the scale of the target of the GPA, which impersonates the service
+// account of spec.impersonateServiceAccount if set.
+func (a *GeneralController) scalesFor(gpa *autoscaling.GeneralPodAutoscaler) (scaleclient.ScalesGetter, error) {
+	if gpa.Spec.ImpersonateServiceAccount == "" {
+		return a.scaleNamespacer, nil
+	}
+	if a.impersonatingScales == nil {
+		return nil, fmt.Errorf("impersonating service account %s is not enabled in the controller",
+			gpa.Spec.ImpersonateServiceAccount)
+	}
+	userName := serviceAccountUserName(gpa.Namespace, gpa.Spec.ImpersonateServiceAccount)
+	cache := a.impersonatingScales
+	cache.Lock()
+	defer cache.Unlock()
+	if client, ok := cache.clients[userName]; ok {
+		return client, nil
+	}
+	client, err := cache.newScales(userName)
+	if err != nil {
+		return nil, fmt.Errorf("create scale client impersonating %s failed: %v", userName, err)
+	}
+	cache.clients[userName] = client
+	return client, nil
+}
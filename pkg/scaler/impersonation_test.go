@@ -0,0 +1,101 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	autoscalinginternal "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	scalefake "k8s.io/client-go/scale/fake"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// scaleKindResolver resolves the scales of all the resources to autoscaling/v1
+type scaleKindResolver struct{}
+
+func (scaleKindResolver) ScaleForResource(schema.GroupVersionResource) (schema.GroupVersionKind, error) {
+	return autoscalinginternal.SchemeGroupVersion.WithKind("Scale"), nil
+}
+
+func TestImpersonatedScaleWrites(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		writes  []string
+		headers []http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		writes = append(writes, r.Method+" "+r.URL.Path)
+		headers = append(headers, r.Header)
+		lock.Unlock()
+		scale := &autoscalinginternal.Scale{}
+		if err := json.NewDecoder(r.Body).Decode(scale); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(scale)
+	}))
+	defer server.Close()
+
+	controllerScales := &scalefake.FakeScaleClient{}
+	controller := &GeneralController{scaleNamespacer: controllerScales}
+	gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       autoscalingv1alpha1.GeneralPodAutoscalerSpec{ImpersonateServiceAccount: "web-scaler"},
+	}
+
+	// the impersonation must be enabled by the controller
+	_, err := controller.scalesFor(gpa)
+	assert.Error(t, err)
+
+	controller.SetImpersonatingScales(NewImpersonatingScalesFunc(&rest.Config{Host: server.URL},
+		testrestmapper.TestOnlyStaticRESTMapper(testScheme()), scaleKindResolver{}))
+	scales, err := controller.scalesFor(gpa)
+	if !assert.NoError(t, err) {
+		return
+	}
+	scale := &autoscalinginternal.Scale{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "autoscaling/v1", Kind: "Scale"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       autoscalinginternal.ScaleSpec{Replicas: 3},
+	}
+	targetGR := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	assert.NoError(t, controller.updateScale(scales, "default", targetGR, scale, 3, 5))
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []string{"PUT /apis/apps/v1/namespaces/default/deployments/web/scale"}, writes)
+	assert.Equal(t, "system:serviceaccount:default:web-scaler", headers[0].Get("Impersonate-User"))
+
+	// the client of the service account is reused, while the GPAs without impersonation use the controller's
+	cached, err := controller.scalesFor(gpa)
+	assert.NoError(t, err)
+	assert.True(t, scales == cached)
+	gpa.Spec.ImpersonateServiceAccount = ""
+	own, err := controller.scalesFor(gpa)
+	assert.NoError(t, err)
+	assert.True(t, own == controllerScales)
+}
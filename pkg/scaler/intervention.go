@@ -0,0 +1,74 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"reflect"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// SetResetBackoffOnChange sets whether a change of the spec or of the annotations of a GPA, e.g. an operator
+// fixing its webhook endpoint, resets the wait of the GPA in the queue, so that it is reconciled at once instead
+// of after the resync period.
+func (a *GeneralController) SetResetBackoffOnChange(enabled bool) {
+	a.resetBackoffOnChange = enabled
+}
+
+// changedByUser returns true if the spec or the annotations of the GPA changed, the annotations written by the
+// controller itself are ignored
+func changedByUser(old, cur interface{}) bool {
+	oldGPA, ok := old.(*autoscaling.GeneralPodAutoscaler)
+	if !ok {
+		return false
+	}
+	curGPA, ok := cur.(*autoscaling.GeneralPodAutoscaler)
+	if !ok {
+		return false
+	}
+	if !apiequality.Semantic.DeepEqual(oldGPA.Spec, curGPA.Spec) {
+		return true
+	}
+	return !reflect.DeepEqual(userAnnotations(oldGPA), userAnnotations(curGPA))
+}
+
+// userAnnotations returns the annotations of the GPA but the scale lease renewed by the controller
+func userAnnotations(gpa *autoscaling.GeneralPodAutoscaler) map[string]string {
+	annotations := map[string]string{}
+	for key, value := range gpa.Annotations {
+		if key != scaleLeaseKey {
+			annotations[key] = value
+		}
+	}
+	return annotations
+}
+
+// resetBackoff forgets the wait of the GPA and enqueues it at once
+func (a *GeneralController) resetBackoff(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for object %+v: %v", obj, err))
+		return
+	}
+	klog.V(4).Infof("Spec or annotations of gpa %v changed, reset its backoff", key)
+	a.queue.Forget(key)
+	a.queue.Add(key)
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	autoscalinglisters "github.com/ocgi/general-pod-autoscaler/pkg/client/listers/autoscaling/v1alpha1"
+)
+
+func TestSpecChangeResetsBackoff(t *testing.T) {
+	gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       autoscalingv1alpha1.GeneralPodAutoscalerSpec{MaxReplicas: 10},
+	}
+	gpaIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	controller := &GeneralController{
+		DecisionEngine: &DecisionEngine{},
+		gpaLister:      autoscalinglisters.NewGeneralPodAutoscalerLister(gpaIndexer),
+		queue:          workqueue.NewRateLimitingQueue(NewDefaultGPARateLimiter(time.Hour)),
+	}
+	defer controller.queue.ShutDown()
+
+	// the gpa waits for the resync after a failed reconcile
+	controller.enqueueGPA(gpa)
+	assert.Equal(t, 0, controller.queue.Len())
+
+	// the changes of the status and of the scale lease written by the controller keep it waiting
+	synced := gpa.DeepCopy()
+	synced.Status.DesiredReplicas = 3
+	synced.Annotations = map[string]string{scaleLeaseKey: `{"holderIdentity":"gpa-0"}`}
+	controller.SetResetBackoffOnChange(true)
+	controller.updateGPA(gpa, synced)
+	assert.Equal(t, 0, controller.queue.Len())
+
+	// the spec changed by an operator clears the wait
+	fixed := synced.DeepCopy()
+	fixed.Spec.MaxReplicas = 20
+	controller.updateGPA(synced, fixed)
+	waitForKey(t, controller.queue, "default/web")
+
+	// so do the annotations
+	annotated := fixed.DeepCopy()
+	annotated.Annotations[overrideMaxKey] = "30"
+	controller.updateGPA(fixed, annotated)
+	waitForKey(t, controller.queue, "default/web")
+
+	// unless the reset is disabled
+	controller.SetResetBackoffOnChange(false)
+	controller.updateGPA(annotated, gpa)
+	assert.Equal(t, 0, controller.queue.Len())
+}
@@ -0,0 +1,228 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scalercore"
+)
+
+const (
+	// kafkaTimeout is the timeout of dialing, reading from and writing to the brokers
+	kafkaTimeout = 10 * time.Second
+	// kafkaBackoffInitial is the backoff of the brokers after they failed for the first time, it doubles on
+	// each failure until kafkaBackoffMax
+	kafkaBackoffInitial = 10 * time.Second
+	kafkaBackoffMax     = 5 * time.Minute
+)
+
+// kafkaLag returns the total lag of the group on the topic, the lag of a partition without a committed
+// offset is its newest offset.
+func kafkaLag(client sarama.Client, topic, group string) (int64, error) {
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		return 0, fmt.Errorf("get partitions of topic %s failed: %v", topic, err)
+	}
+	coordinator, err := client.Coordinator(group)
+	if err != nil {
+		return 0, fmt.Errorf("get coordinator of group %s failed: %v", group, err)
+	}
+	request := &sarama.OffsetFetchRequest{Version: 1, ConsumerGroup: group}
+	for _, partition := range partitions {
+		request.AddPartition(topic, partition)
+	}
+	committed, err := coordinator.FetchOffset(request)
+	if err != nil {
+		return 0, fmt.Errorf("fetch offsets of group %s failed: %v", group, err)
+	}
+	var total int64
+	for _, partition := range partitions {
+		newest, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return 0, fmt.Errorf("get newest offset of topic %s partition %d failed: %v", topic, partition, err)
+		}
+		lag := newest
+		if block := committed.GetBlock(topic, partition); block != nil {
+			if block.Err != sarama.ErrNoError {
+				return 0, fmt.Errorf("fetch offset of group %s partition %d failed: %v", group, partition, block.Err)
+			}
+			if block.Offset >= 0 {
+				lag = newest - block.Offset
+			}
+		}
+		if lag > 0 {
+			total += lag
+		}
+	}
+	return total, nil
+}
+
+// kafkaConfig returns the config of the client of the brokers of the source, the secrets of TLS and SASL are
+// read from the namespace.
+func (a *DecisionEngine) kafkaConfig(namespace string, src *autoscaling.KafkaLagMetricSource) (*sarama.Config, error) {
+	config := sarama.NewConfig()
+	config.ClientID = "general-pod-autoscaler"
+	config.Net.DialTimeout = kafkaTimeout
+	config.Net.ReadTimeout = kafkaTimeout
+	config.Net.WriteTimeout = kafkaTimeout
+	// the failures are retried by the backoff of the brokers
+	config.Metadata.Retry.Max = 0
+	config.Metadata.Full = false
+	if src.TLS != nil {
+		tlsConfig := &tls.Config{}
+		if src.TLS.CASecretRef != nil {
+			caBundle, err := scalercore.GetSecretKey(a.secretNamespacer, namespace, src.TLS.CASecretRef, "kafka ca")
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if ok := tlsConfig.RootCAs.AppendCertsFromPEM(caBundle); !ok {
+				return nil, fmt.Errorf("no certs were appended from the kafka ca secret %s/%s",
+					namespace, src.TLS.CASecretRef.Name)
+			}
+		}
+		if src.TLS.CertSecretRef != nil && src.TLS.KeySecretRef != nil {
+			cert, err := scalercore.GetSecretKey(a.secretNamespacer, namespace, src.TLS.CertSecretRef, "kafka cert")
+			if err != nil {
+				return nil, err
+			}
+			key, err := scalercore.GetSecretKey(a.secretNamespacer, namespace, src.TLS.KeySecretRef, "kafka key")
+			if err != nil {
+				return nil, err
+			}
+			certificate, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid kafka client certificate: %v", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{certificate}
+		}
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+	}
+	if src.SASL != nil {
+		mechanism := src.SASL.Mechanism
+		if mechanism == "" {
+			mechanism = autoscaling.KafkaSASLPlain
+		}
+		if mechanism != autoscaling.KafkaSASLPlain {
+			return nil, fmt.Errorf("unsupported kafka sasl mechanism %q", mechanism)
+		}
+		user, err := scalercore.GetSecretKey(a.secretNamespacer, namespace, &src.SASL.UsernameSecretRef, "kafka username")
+		if err != nil {
+			return nil, err
+		}
+		password, err := scalercore.GetSecretKey(a.secretNamespacer, namespace, &src.SASL.PasswordSecretRef, "kafka password")
+		if err != nil {
+			return nil, err
+		}
+		config.Net.SASL.Enable = true
+		config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		config.Net.SASL.User = string(user)
+		config.Net.SASL.Password = string(password)
+	}
+	return config, nil
+}
+
+// getKafkaLag reads the total lag of the source from its brokers. Once the brokers can not be connected to,
+// they are not dialed by any GPA until their backoff elapses. Once the lag of the group on the topic can not be
+// read, e.g. the topic does not exist, only the GPAs of the same topic and group are backed off.
+func (a *DecisionEngine) getKafkaLag(namespace string, src *autoscaling.KafkaLagMetricSource) (int64, error) {
+	id := strings.Join(src.Brokers, ",")
+	lagID := fmt.Sprintf("%s/%s/%s", id, src.Topic, src.ConsumerGroup)
+	now := a.clock.Now()
+	if a.kafkaBackoff.IsInBackOffSinceUpdate(id, now) {
+		return 0, fmt.Errorf("kafka brokers %s are backed off for %v after failures", id, a.kafkaBackoff.Get(id))
+	}
+	if a.kafkaBackoff.IsInBackOffSinceUpdate(lagID, now) {
+		return 0, fmt.Errorf("kafka lag of group %s on topic %s is backed off for %v after failures",
+			src.ConsumerGroup, src.Topic, a.kafkaBackoff.Get(lagID))
+	}
+	config, err := a.kafkaConfig(namespace, src)
+	if err != nil {
+		return 0, err
+	}
+	client, err := sarama.NewClient(src.Brokers, config)
+	if err != nil {
+		a.kafkaBackoff.Next(id, a.clock.Now())
+		return 0, fmt.Errorf("connect to kafka brokers %s failed: %v", id, err)
+	}
+	defer client.Close()
+	a.kafkaBackoff.Reset(id)
+	lag, err := kafkaLag(client, src.Topic, src.ConsumerGroup)
+	if err != nil {
+		a.kafkaBackoff.Next(lagID, a.clock.Now())
+		return 0, err
+	}
+	a.kafkaBackoff.Reset(lagID)
+	// drop the backoff of the brokers no longer used by any GPA
+	a.kafkaBackoff.GC()
+	return lag, nil
+}
+
+// computeStatusForKafkaLagMetric computes the desired number of replicas for the specified metric of type
+// KafkaLagMetricSourceType, by dividing the total lag of the consumer group by the target lag per pod.
+func (a *DecisionEngine) computeStatusForKafkaLagMetric(statusReplicas int32, metricSpec autoscaling.MetricSpec,
+	gpa *autoscaling.GeneralPodAutoscaler, status *autoscaling.MetricStatus) (replicaCountProposal int32,
+	timestampProposal time.Time, metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
+	src := metricSpec.KafkaLag
+	if src.Target.AverageValue == nil || src.Target.AverageValue.MilliValue() <= 0 {
+		err = fmt.Errorf("invalid kafka lag metric source: the target average value must be greater than 0")
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetKafkaLagMetric", err)
+		return 0, time.Time{}, "", condition, err
+	}
+	metricNameProposal = fmt.Sprintf("kafka lag of group %s on topic %s", src.ConsumerGroup, src.Topic)
+	lag, err := a.getKafkaLag(gpa.Namespace, src)
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetKafkaLagMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get %s: %v", metricNameProposal, err)
+	}
+	timestampProposal = a.clock.Now()
+
+	targetMilli := src.Target.AverageValue.MilliValue()
+	replicaCountProposal = statusReplicas
+	usageRatio := float64(lag*1000) / (float64(targetMilli) * float64(statusReplicas))
+	if statusReplicas == 0 || math.Abs(1.0-usageRatio) > a.replicaCalc.tolerance {
+		// update number of replicas if the change is large enough
+		replicaCountProposal = int32(math.Ceil(float64(lag*1000) / float64(targetMilli)))
+	}
+	averageLag := lag
+	if statusReplicas > 0 {
+		averageLag = int64(math.Ceil(float64(lag) / float64(statusReplicas)))
+	}
+	decisionLog(gpa, 4).Infof("GPA %s/%s %s: %d, target per pod: %s",
+		gpa.Namespace, gpa.Name, metricNameProposal, lag, src.Target.AverageValue.String())
+	*status = autoscaling.MetricStatus{
+		Type: autoscaling.KafkaLagMetricSourceType,
+		KafkaLag: &autoscaling.KafkaLagMetricStatus{
+			Topic:         src.Topic,
+			ConsumerGroup: src.ConsumerGroup,
+			Current: autoscaling.MetricValueStatus{
+				Value:        resource.NewQuantity(lag, resource.DecimalSI),
+				AverageValue: resource.NewQuantity(averageLag, resource.DecimalSI),
+			},
+		},
+	}
+	return replicaCountProposal, timestampProposal, metricNameProposal, autoscaling.GeneralPodAutoscalerCondition{}, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	autoscalinginternal "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	scaleclient "k8s.io/client-go/scale"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+const (
+	// scaleLeaseKey is the annotation of the lease claimed by the controller scaling the target of the GPA
+	scaleLeaseKey = "autoscaling.ocgi.io/scale-lease"
+	// scaleLeaseDuration is how long the lease is held after the last claim
+	scaleLeaseDuration = 15 * time.Second
+)
+
+// errTargetScaledConcurrently is returned if the target is scaled by others while the controller scaling it
+var errTargetScaledConcurrently = fmt.Errorf("the target is scaled concurrently")
+
+// scaleLease is the value of the scale lease annotation
+type scaleLease struct {
+	HolderIdentity string      `json:"holderIdentity"`
+	RenewTime      metav1.Time `json:"renewTime"`
+}
+
+// SetIdentity sets the identity claiming the scale lease of the GPAs before scaling the targets,
+// so that two controllers never scale the same target at the same time, e.g. during a rolling upgrade.
+// The scale lease is not used if the identity is empty.
+func (a *GeneralController) SetIdentity(identity string) {
+	a.identity = identity
+}
+
+// claimScaleLease claims the scale lease of the GPA, returns false if the lease is held by another controller.
+// The annotations and resource version of the GPA are updated once the lease is claimed.
+func (a *GeneralController) claimScaleLease(gpa *autoscaling.GeneralPodAutoscaler) bool {
+	if a.identity == "" {
+		return true
+	}
+	current := gpa
+	claimed := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if holder, held := leaseHeldByOthers(current, a.identity, a.clock.Now()); held {
+			klog.Infof("Scale lease of gpa %s/%s is held by %s, skip scaling", gpa.Namespace, gpa.Name, holder)
+			return nil
+		}
+		value, err := json.Marshal(scaleLease{HolderIdentity: a.identity, RenewTime: metav1.NewTime(a.clock.Now())})
+		if err != nil {
+			return err
+		}
+		// the resource version fences the claim against concurrent claims
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"resourceVersion": current.ResourceVersion,
+				"annotations":     map[string]string{scaleLeaseKey: string(value)},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		patched, err := a.gpaNamespacer.GeneralPodAutoscalers(gpa.Namespace).Patch(gpa.Name, types.MergePatchType, patch)
+		if errors.IsConflict(err) {
+			latest, getErr := a.gpaNamespacer.GeneralPodAutoscalers(gpa.Namespace).Get(gpa.Name, metav1.GetOptions{})
+			if getErr != nil {
+				return getErr
+			}
+			current = latest
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		gpa.Annotations = patched.Annotations
+		gpa.ResourceVersion = patched.ResourceVersion
+		claimed = true
+		return nil
+	})
+	if err != nil {
+		klog.Errorf("Claim scale lease of gpa %s/%s failed: %v", gpa.Namespace, gpa.Name, err)
+		return false
+	}
+	return claimed
+}
+
+// leaseHeldByOthers returns the holder if the scale lease of the GPA is held by others than the identity
+func leaseHeldByOthers(gpa *autoscaling.GeneralPodAutoscaler, identity string, now time.Time) (string, bool) {
+	value, ok := gpa.Annotations[scaleLeaseKey]
+	if !ok {
+		return "", false
+	}
+	lease := scaleLease{}
+	if err := json.Unmarshal([]byte(value), &lease); err != nil {
+		klog.Warningf("Ignore invalid scale lease of gpa %s/%s: %v", gpa.Namespace, gpa.Name, err)
+		return "", false
+	}
+	if lease.HolderIdentity == identity || now.Sub(lease.RenewTime.Time) >= scaleLeaseDuration {
+		return "", false
+	}
+	return lease.HolderIdentity, true
+}
+
+// updateScale updates the replicas of the scale to the desired replicas, and retries with the latest scale on
+// conflicts. errTargetScaledConcurrently is returned without retrying if the target is scaled by others,
+// the desired replicas will be computed again with the latest replicas on the next sync. The transient failures
+// are retried with the scale update backoff, see SetScaleUpdateRetry.
+func (a *GeneralController) updateScale(scales scaleclient.ScalesGetter, namespace string, targetGR schema.GroupResource,
+	scale *autoscalinginternal.Scale, currentReplicas, desiredReplicas int32) error {
+	attempts := 0
+	return retry.OnError(a.scaleUpdateRetry(), isTransientScaleError, func() error {
+		attempts++
+		if attempts > 1 {
+			klog.V(2).Infof("Retry updating the scale of %s %s/%s to %d, attempt %d",
+				targetGR, namespace, scale.Name, desiredReplicas, attempts)
+		}
+		return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			scale.Spec.Replicas = desiredReplicas
+			_, err := scales.Scales(namespace).Update(targetGR, scale)
+			if !errors.IsConflict(err) {
+				return err
+			}
+			latest, getErr := scales.Scales(namespace).Get(targetGR, scale.Name)
+			if getErr != nil {
+				return getErr
+			}
+			if latest.Spec.Replicas != currentReplicas {
+				return errTargetScaledConcurrently
+			}
+			*scale = *latest
+			return err
+		})
+	})
+}
@@ -0,0 +1,216 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	autoscalinginternal "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
+	scalefake "k8s.io/client-go/scale/fake"
+	core "k8s.io/client-go/testing"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	autoscalingfake "github.com/ocgi/general-pod-autoscaler/pkg/client/clientset/versioned/fake"
+)
+
+func TestUpdateScaleOnConflict(t *testing.T) {
+	targetGR := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	for _, c := range []struct {
+		name           string
+		latestReplicas int32
+		expectedErr    error
+		expectedWrites []int32
+	}{
+		{
+			name:           "retry with the latest scale",
+			latestReplicas: 3,
+			expectedWrites: []int32{5, 5},
+		},
+		{
+			name:           "scaled by others",
+			latestReplicas: 4,
+			expectedErr:    errTargetScaledConcurrently,
+			expectedWrites: []int32{5},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var writes []int32
+			fakeScaleClient := &scalefake.FakeScaleClient{}
+			fakeScaleClient.AddReactor("update", "deployments", func(action core.Action) (bool, runtime.Object, error) {
+				obj := action.(core.UpdateAction).GetObject().(*autoscalinginternal.Scale)
+				writes = append(writes, obj.Spec.Replicas)
+				if obj.ResourceVersion != "2" {
+					return true, nil, errors.NewConflict(targetGR, obj.Name, nil)
+				}
+				return true, obj, nil
+			})
+			fakeScaleClient.AddReactor("get", "deployments", func(action core.Action) (bool, runtime.Object, error) {
+				return true, &autoscalinginternal.Scale{
+					ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", ResourceVersion: "2"},
+					Spec:       autoscalinginternal.ScaleSpec{Replicas: c.latestReplicas},
+				}, nil
+			})
+			controller := &GeneralController{scaleNamespacer: fakeScaleClient}
+			scale := &autoscalinginternal.Scale{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", ResourceVersion: "1"},
+				Spec:       autoscalinginternal.ScaleSpec{Replicas: 3},
+			}
+
+			err := controller.updateScale(controller.scaleNamespacer, "default", targetGR, scale, 3, 5)
+			assert.Equal(t, c.expectedErr, err)
+			assert.Equal(t, c.expectedWrites, writes)
+		})
+	}
+}
+
+func TestUpdateScaleOnTransientFailures(t *testing.T) {
+	targetGR := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	for _, c := range []struct {
+		name             string
+		failures         []error
+		retries          int
+		expectedErr      bool
+		expectedWrites   int
+		expectedReplicas int32
+	}{
+		{
+			name: "two transient failures then success",
+			failures: []error{
+				errors.NewTimeoutError("request timed out", 1),
+				errors.NewTooManyRequests("throttled", 1),
+			},
+			retries:          3,
+			expectedWrites:   3,
+			expectedReplicas: 5,
+		},
+		{
+			name: "retries exhausted",
+			failures: []error{
+				errors.NewServiceUnavailable("unavailable"),
+				errors.NewServiceUnavailable("unavailable"),
+			},
+			retries:          1,
+			expectedErr:      true,
+			expectedWrites:   2,
+			expectedReplicas: 3,
+		},
+		{
+			name:             "not transient",
+			failures:         []error{errors.NewForbidden(targetGR, "test", nil)},
+			retries:          3,
+			expectedErr:      true,
+			expectedWrites:   1,
+			expectedReplicas: 3,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			writes := 0
+			replicas := int32(3)
+			fakeScaleClient := &scalefake.FakeScaleClient{}
+			fakeScaleClient.AddReactor("update", "deployments", func(action core.Action) (bool, runtime.Object, error) {
+				obj := action.(core.UpdateAction).GetObject().(*autoscalinginternal.Scale)
+				writes++
+				if writes <= len(c.failures) {
+					return true, nil, c.failures[writes-1]
+				}
+				replicas = obj.Spec.Replicas
+				return true, obj, nil
+			})
+			controller := &GeneralController{scaleNamespacer: fakeScaleClient}
+			controller.SetScaleUpdateRetry(c.retries, time.Millisecond)
+			scale := &autoscalinginternal.Scale{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Spec:       autoscalinginternal.ScaleSpec{Replicas: 3},
+			}
+
+			err := controller.updateScale(controller.scaleNamespacer, "default", targetGR, scale, 3, 5)
+			assert.Equal(t, c.expectedErr, err != nil)
+			assert.Equal(t, c.expectedWrites, writes)
+			assert.Equal(t, c.expectedReplicas, replicas)
+		})
+	}
+}
+
+func TestClaimScaleLease(t *testing.T) {
+	lease := func(holder string, renewTime time.Time) map[string]string {
+		value, _ := json.Marshal(scaleLease{HolderIdentity: holder, RenewTime: metav1.NewTime(renewTime)})
+		return map[string]string{scaleLeaseKey: string(value)}
+	}
+	now := time.Now()
+	for _, c := range []struct {
+		name        string
+		annotations map[string]string
+		claimed     bool
+	}{
+		{name: "no lease", claimed: true},
+		{name: "held by self", annotations: lease("gpa-0", now), claimed: true},
+		{name: "held by others", annotations: lease("gpa-1", now)},
+		{name: "expired", annotations: lease("gpa-1", now.Add(-scaleLeaseDuration)), claimed: true},
+		{name: "invalid", annotations: map[string]string{scaleLeaseKey: "foo"}, claimed: true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", Annotations: c.annotations},
+			}
+			controller := &GeneralController{
+				DecisionEngine: &DecisionEngine{clock: clock.NewFakeClock(now)},
+				gpaNamespacer:  autoscalingfake.NewSimpleClientset(gpa.DeepCopy()).AutoscalingV1alpha1(),
+				identity:       "gpa-0",
+			}
+			assert.Equal(t, c.claimed, controller.claimScaleLease(gpa))
+			holder, held := leaseHeldByOthers(gpa, "gpa-2", now)
+			if c.claimed {
+				assert.Equal(t, "gpa-0", holder)
+				assert.True(t, held)
+			} else {
+				assert.Equal(t, c.annotations, gpa.Annotations)
+			}
+		})
+	}
+}
+
+func TestClaimScaleLeaseOnConflict(t *testing.T) {
+	gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", ResourceVersion: "1"},
+	}
+	latest := gpa.DeepCopy()
+	latest.ResourceVersion = "2"
+	fakeClient := autoscalingfake.NewSimpleClientset(latest)
+	conflicts := 0
+	fakeClient.PrependReactor("patch", "generalpodautoscalers", func(action core.Action) (bool, runtime.Object, error) {
+		patch := map[string]map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(action.(core.PatchAction).GetPatch(), &patch))
+		if patch["metadata"]["resourceVersion"] != "2" {
+			conflicts++
+			return true, nil, errors.NewConflict(schema.GroupResource{Resource: "generalpodautoscalers"}, "test", nil)
+		}
+		return false, nil, nil
+	})
+	controller := &GeneralController{DecisionEngine: &DecisionEngine{clock: clock.RealClock{}},
+		gpaNamespacer: fakeClient.AutoscalingV1alpha1(), identity: "gpa-0"}
+
+	assert.True(t, controller.claimScaleLease(gpa))
+	assert.Equal(t, 1, conflicts)
+	_, held := leaseHeldByOthers(gpa, "gpa-1", time.Now())
+	assert.True(t, held)
+}
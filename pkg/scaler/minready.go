@@ -0,0 +1,89 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// podsReadyRemaining returns how long the last ready of the pods has to stay ready for minReady, 0 if all the
+// ready pods have been ready for minReady. The pods not ready or being deleted are not counted.
+func podsReadyRemaining(pods []*v1.Pod, minReady time.Duration, now time.Time) time.Duration {
+	var remaining time.Duration
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type != v1.PodReady || condition.Status != v1.ConditionTrue {
+				continue
+			}
+			if left := condition.LastTransitionTime.Add(minReady).Sub(now); left > remaining {
+				remaining = left
+			}
+		}
+	}
+	return remaining
+}
+
+// minReadyRemaining returns how long the decisions of the GPA are deferred until the ready pods of the target
+// have been ready for spec.minReadySeconds, or the minReadySeconds of the target Deployment if it is larger and
+// the deployments are listed.
+// It sets the AbleToScale condition if the decisions are deferred.
+func (a *GeneralController) minReadyRemaining(gpa *autoscaling.GeneralPodAutoscaler, targetGK schema.GroupKind,
+	selector string) time.Duration {
+	if gpa.Spec.MinReadySeconds == nil {
+		return 0
+	}
+	minReadySeconds := *gpa.Spec.MinReadySeconds
+	if a.deploymentLister != nil && targetGK.Kind == "Deployment" &&
+		(targetGK.Group == "apps" || targetGK.Group == "extensions") {
+		deployment, err := a.deploymentLister.Deployments(gpa.Namespace).Get(gpa.Spec.ScaleTargetRef.Name)
+		if err != nil {
+			klog.Warningf("Get deployment %s/%s of gpa %v failed, ignore its minReadySeconds: %v",
+				gpa.Namespace, gpa.Spec.ScaleTargetRef.Name, gpa.Name, err)
+		} else if deployment.Spec.MinReadySeconds > minReadySeconds {
+			minReadySeconds = deployment.Spec.MinReadySeconds
+		}
+	}
+	if minReadySeconds == 0 {
+		return 0
+	}
+	podSelector, err := labels.Parse(selector)
+	if err != nil {
+		klog.Warningf("Parse selector of gpa %s/%s failed, ignore ready times of pods: %v", gpa.Namespace, gpa.Name, err)
+		return 0
+	}
+	pods, err := a.podLister.Pods(gpa.Namespace).List(podSelector)
+	if err != nil {
+		klog.Warningf("List pods of gpa %s/%s failed, ignore ready times of pods: %v", gpa.Namespace, gpa.Name, err)
+		return 0
+	}
+	minReady := time.Duration(minReadySeconds) * time.Second
+	remaining := podsReadyRemaining(pods, minReady, a.clock.Now())
+	if remaining > 0 {
+		setCondition(gpa, autoscaling.AbleToScale, v1.ConditionTrue, "MinReadySecondsNotElapsed",
+			"pods of the target have been ready for less than %v, the replicas are computed again in %v",
+			minReady, remaining.Round(time.Second))
+	}
+	return remaining
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// enqueueMirroringGPAs adds the GPAs in mirror mode of the GPA to the queue immediately, obj could be an
+// *v1alpha1.GeneralPodAutoscaler, or a DeletionFinalStateUnknown marker item.
+func (a *GeneralController) enqueueMirroringGPAs(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	mirrored, ok := obj.(*autoscaling.GeneralPodAutoscaler)
+	if !ok {
+		return
+	}
+	gpas, err := a.gpaLister.GeneralPodAutoscalers(mirrored.Namespace).List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't list gpa: %v", err))
+		return
+	}
+	for _, gpa := range gpas {
+		if gpa.Spec.MirrorMode == nil || gpa.Spec.MirrorMode.Name != mirrored.Name || gpa.Name == mirrored.Name {
+			continue
+		}
+		key, err := cache.MetaNamespaceKeyFunc(gpa)
+		if err != nil {
+			utilruntime.HandleError(fmt.Errorf("couldn't get key for object %+v: %v", gpa, err))
+			continue
+		}
+		klog.V(4).Infof("Mirrored gpa %s/%s changed, enqueue gpa %v", mirrored.Namespace, mirrored.Name, key)
+		a.queue.Add(key)
+	}
+}
+
+// desiredReplicasChanged returns true if the desired replicas in the status of the GPA changed
+func desiredReplicasChanged(old, cur interface{}) bool {
+	oldGPA, ok := old.(*autoscaling.GeneralPodAutoscaler)
+	if !ok {
+		return true
+	}
+	curGPA, ok := cur.(*autoscaling.GeneralPodAutoscaler)
+	if !ok {
+		return true
+	}
+	return oldGPA.Status.DesiredReplicas != curGPA.Status.DesiredReplicas
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	scalefake "k8s.io/client-go/scale/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	autoscalingfake "github.com/ocgi/general-pod-autoscaler/pkg/client/clientset/versioned/fake"
+	autoscalinginformer "github.com/ocgi/general-pod-autoscaler/pkg/client/informers/externalversions"
+	autoscalinglisters "github.com/ocgi/general-pod-autoscaler/pkg/client/listers/autoscaling/v1alpha1"
+)
+
+func mirrorGPA(name, namespace, mirrored string) *autoscalingv1alpha1.GeneralPodAutoscaler {
+	return &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: autoscalingv1alpha1.GeneralPodAutoscalerSpec{
+			AutoScalingDrivenMode: autoscalingv1alpha1.AutoScalingDrivenMode{
+				MirrorMode: &autoscalingv1alpha1.MirrorMode{Name: mirrored},
+			},
+		},
+	}
+}
+
+func TestMirroredGPAChangeEnqueuesMirroringGPAs(t *testing.T) {
+	gpaIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	api := &autoscalingv1alpha1.GeneralPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"}}
+	for _, gpa := range []*autoscalingv1alpha1.GeneralPodAutoscaler{
+		api,
+		mirrorGPA("worker", "default", "api"),
+		mirrorGPA("cache", "default", "web"),
+		mirrorGPA("worker", "other", "api"),
+	} {
+		assert.NoError(t, gpaIndexer.Add(gpa))
+	}
+
+	controller := &GeneralController{
+		DecisionEngine: &DecisionEngine{},
+		gpaLister:      autoscalinglisters.NewGeneralPodAutoscalerLister(gpaIndexer),
+		queue:          workqueue.NewRateLimitingQueue(NewDefaultGPARateLimiter(time.Hour)),
+	}
+	defer controller.queue.ShutDown()
+
+	// the resync of the mirrored gpa enqueues nothing immediately
+	controller.updateGPA(api, api)
+	assert.Equal(t, 0, controller.queue.Len())
+
+	// the change of its desired replicas enqueues the gpa mirroring it in the same namespace only
+	scaled := api.DeepCopy()
+	scaled.Status.DesiredReplicas = 4
+	controller.updateGPA(api, scaled)
+	waitForKey(t, controller.queue, "default/worker")
+	assert.Equal(t, 0, controller.queue.Len())
+
+	// so does its removal
+	controller.deleteGPA(cache.DeletedFinalStateUnknown{Key: "default/api", Obj: scaled})
+	waitForKey(t, controller.queue, "default/worker")
+	assert.Equal(t, 0, controller.queue.Len())
+}
+
+func TestMirroredGPAStatusUpdateEnqueuesMirroringGPAs(t *testing.T) {
+	api := &autoscalingv1alpha1.GeneralPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"}}
+	web := &autoscalingv1alpha1.GeneralPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+	gpaClient := autoscalingfake.NewSimpleClientset(api, web, mirrorGPA("worker", "default", "api"),
+		mirrorGPA("cache", "default", "web"))
+	kubeClient := fake.NewSimpleClientset()
+	scalerFactory := autoscalinginformer.NewSharedInformerFactory(gpaClient, 0)
+	informerFactory := informers.NewSharedInformerFactory(kubeClient, 0)
+	// the GPAs are only enqueued by their resync an hour later, the mirrors are enqueued at once
+	controller := NewGeneralController(
+		kubeClient.CoreV1(),
+		kubeClient.CoreV1(),
+		&scalefake.FakeScaleClient{},
+		gpaClient.AutoscalingV1alpha1(),
+		testrestmapper.TestOnlyStaticRESTMapper(testScheme()),
+		nil,
+		scalerFactory.Autoscaling().V1alpha1().GeneralPodAutoscalers(),
+		informerFactory.Core().V1().Pods(),
+		time.Hour,
+		5*time.Minute,
+		defaultTestingTolerance,
+		defaultTestingCPUInitializationPeriod,
+		defaultTestingDelayOfInitialReadinessStatus,
+	)
+	defer controller.queue.ShutDown()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	scalerFactory.Start(stop)
+	cache.WaitForCacheSync(stop, controller.gpaListerSynced)
+	assert.Equal(t, 0, controller.queue.Len())
+
+	// an update of the mirrored gpa keeping its desired replicas enqueues nothing
+	labeled := api.DeepCopy()
+	labeled.Labels = map[string]string{"team": "api"}
+	_, err := gpaClient.AutoscalingV1alpha1().GeneralPodAutoscalers("default").Update(labeled)
+	assert.NoError(t, err)
+	// the updates are handled in order, once the mirror of the later one is enqueued the earlier one is handled
+	scaledWeb := web.DeepCopy()
+	scaledWeb.Status.DesiredReplicas = 2
+	_, err = gpaClient.AutoscalingV1alpha1().GeneralPodAutoscalers("default").UpdateStatus(scaledWeb)
+	assert.NoError(t, err)
+	waitForKey(t, controller.queue, "default/cache")
+	assert.Equal(t, 0, controller.queue.Len())
+
+	// the change of its desired replicas written by the controller enqueues the gpa mirroring it
+	scaledAPI := labeled.DeepCopy()
+	scaledAPI.Status.DesiredReplicas = 4
+	_, err = gpaClient.AutoscalingV1alpha1().GeneralPodAutoscalers("default").UpdateStatus(scaledAPI)
+	assert.NoError(t, err)
+	waitForKey(t, controller.queue, "default/worker")
+	assert.Equal(t, 0, controller.queue.Len())
+}
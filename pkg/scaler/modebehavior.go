@@ -0,0 +1,87 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scalercore"
+)
+
+// MetricDecisionMode is the mode of the decisions of the metric mode, the decisions of the other modes are named
+// after their scalers
+const MetricDecisionMode = "Metric"
+
+// modeScalingRules returns the scaling rules of the decisions of the mode in the behavior, nil if not set
+func modeScalingRules(behavior *autoscaling.GeneralPodAutoscalerBehavior, mode string) *autoscaling.ModeScalingRules {
+	switch mode {
+	case MetricDecisionMode:
+		return behavior.Metric
+	case scalercore.Webhook:
+		return behavior.Webhook
+	case scalercore.Cron:
+		return behavior.Time
+	case scalercore.ClusterProportional:
+		return behavior.ClusterProportional
+	case scalercore.Mirror:
+		return behavior.Mirror
+	}
+	return nil
+}
+
+// modeBehavior returns the behavior of the decisions of the mode, its scaleUp and scaleDown are replaced by those
+// of the mode where they are set.
+func modeBehavior(behavior *autoscaling.GeneralPodAutoscalerBehavior, mode string) *autoscaling.GeneralPodAutoscalerBehavior {
+	if behavior == nil {
+		return nil
+	}
+	rules := modeScalingRules(behavior, mode)
+	if rules == nil {
+		return behavior
+	}
+	merged := *behavior
+	if rules.ScaleUp != nil {
+		merged.ScaleUp = rules.ScaleUp
+	}
+	if rules.ScaleDown != nil {
+		merged.ScaleDown = rules.ScaleDown
+	}
+	return &merged
+}
+
+// allScalingRules returns the scaling rules of the direction shared by the modes and of each mode, the scale
+// events are kept for the longest period of their policies whichever mode decides the next scale.
+func allScalingRules(behavior *autoscaling.GeneralPodAutoscalerBehavior, scaleUp bool) []*autoscaling.GPAScalingRules {
+	if behavior == nil {
+		return nil
+	}
+	var all []*autoscaling.GPAScalingRules
+	direction := func(up, down *autoscaling.GPAScalingRules) {
+		rules := down
+		if scaleUp {
+			rules = up
+		}
+		if rules != nil {
+			all = append(all, rules)
+		}
+	}
+	direction(behavior.ScaleUp, behavior.ScaleDown)
+	for _, mode := range []*autoscaling.ModeScalingRules{behavior.Metric, behavior.Webhook, behavior.Time,
+		behavior.ClusterProportional, behavior.Mirror} {
+		if mode != nil {
+			direction(mode.ScaleUp, mode.ScaleDown)
+		}
+	}
+	return all
+}
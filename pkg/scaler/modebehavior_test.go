@@ -0,0 +1,53 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scalercore"
+)
+
+func TestModeBehavior(t *testing.T) {
+	shared := &autoscalingv1alpha1.GPAScalingRules{
+		Policies: []autoscalingv1alpha1.GPAScalingPolicy{{Type: autoscalingv1alpha1.PodsScalingPolicy, Value: 4, PeriodSeconds: 60}},
+	}
+	webhookUp := &autoscalingv1alpha1.GPAScalingRules{
+		Policies: []autoscalingv1alpha1.GPAScalingPolicy{{Type: autoscalingv1alpha1.PodsScalingPolicy, Value: 1, PeriodSeconds: 300}},
+	}
+	behavior := &autoscalingv1alpha1.GeneralPodAutoscalerBehavior{
+		ScaleUp:   shared,
+		ScaleDown: shared,
+		Webhook:   &autoscalingv1alpha1.ModeScalingRules{ScaleUp: webhookUp},
+	}
+
+	// the webhook overrides its scale up, and falls back to the shared scale down
+	webhook := modeBehavior(behavior, scalercore.Webhook)
+	assert.Equal(t, webhookUp, webhook.ScaleUp)
+	assert.Equal(t, shared, webhook.ScaleDown)
+	assert.Equal(t, shared, behavior.ScaleUp)
+
+	// the modes without rules use the shared behavior
+	assert.Equal(t, behavior, modeBehavior(behavior, MetricDecisionMode))
+	assert.Nil(t, modeBehavior(nil, scalercore.Webhook))
+
+	// the scale events are kept for the longest period of any mode
+	assert.Equal(t, int32(300), getLongestPolicyPeriod(allScalingRules(behavior, true)...))
+	assert.Equal(t, int32(60), getLongestPolicyPeriod(allScalingRules(behavior, false)...))
+	assert.Empty(t, allScalingRules(&autoscalingv1alpha1.GeneralPodAutoscalerBehavior{}, true))
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+const (
+	// multiplierKey multiplies the recommendations of the metrics of a GPA until multiplierUntilKey
+	multiplierKey = "autoscaling.ocgi.io/multiplier"
+	// multiplierUntilKey is the end of the window of multiplierKey, in RFC 3339
+	multiplierUntilKey = "autoscaling.ocgi.io/multiplier-until"
+)
+
+// parseMultiplier returns the factor and the end of the window of the multiplier annotations, a zero factor if
+// the multiplier is not set
+func parseMultiplier(gpa *autoscaling.GeneralPodAutoscaler) (float64, time.Time, error) {
+	value, ok := gpa.Annotations[multiplierKey]
+	if !ok {
+		return 0, time.Time{}, nil
+	}
+	factor, err := strconv.ParseFloat(value, 64)
+	if err != nil || factor <= 0 || math.IsInf(factor, 0) {
+		return 0, time.Time{}, fmt.Errorf("invalid %s %q, must be a positive number", multiplierKey, value)
+	}
+	// a multiplier without an end would outlive the campaign it was set for
+	until, err := time.Parse(time.RFC3339, gpa.Annotations[multiplierUntilKey])
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("invalid %s %q, must be a time in RFC 3339",
+			multiplierUntilKey, gpa.Annotations[multiplierUntilKey])
+	}
+	return factor, until, nil
+}
+
+// applyMultiplier multiplies the replicas recommended by the metrics by the multiplier annotation until the end
+// of its window, e.g. to get ahead of the traffic of a campaign, rounding up. The multiplier is reported in the
+// status during its window, the replicas of the metrics apply as is once it ends. An invalid multiplier is
+// ignored.
+func (a *DecisionEngine) applyMultiplier(gpa *autoscaling.GeneralPodAutoscaler, replicas int32) int32 {
+	gpa.Status.Multiplier = nil
+	factor, until, err := parseMultiplier(gpa)
+	if err != nil {
+		klog.Warningf("Ignore the multiplier of gpa %s/%s: %v", gpa.Namespace, gpa.Name, err)
+		a.eventRecorder.Event(gpa, v1.EventTypeWarning, "InvalidMultiplier", err.Error())
+		return replicas
+	}
+	if factor == 0 || !a.clock.Now().Before(until) {
+		return replicas
+	}
+	gpa.Status.Multiplier = &autoscaling.MultiplierStatus{Factor: factor, Until: metav1.NewTime(until)}
+	// the epsilon keeps e.g. 10 * 1.1 from rounding up to 12
+	multiplied := int32(math.Min(math.Ceil(float64(replicas)*factor-1e-9), math.MaxInt32))
+	decisionLog(gpa, 4).Infof("GPA %s/%s multiplies %d replicas by %v until %s: %d",
+		gpa.Namespace, gpa.Name, replicas, factor, until.Format(time.RFC3339), multiplied)
+	return multiplied
+}
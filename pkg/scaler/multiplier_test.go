@@ -0,0 +1,93 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func TestApplyMultiplier(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	until := now.Add(time.Hour).Format(time.RFC3339)
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		replicas    int32
+		expected    int32
+		active      bool
+	}{
+		{
+			name:     "no multiplier",
+			replicas: 4,
+			expected: 4,
+		},
+		{
+			name:        "doubled within the window",
+			annotations: map[string]string{multiplierKey: "2", multiplierUntilKey: until},
+			replicas:    4,
+			expected:    8,
+			active:      true,
+		},
+		{
+			name:        "rounded up",
+			annotations: map[string]string{multiplierKey: "1.5", multiplierUntilKey: until},
+			replicas:    3,
+			expected:    5,
+			active:      true,
+		},
+		{
+			name:        "exact products are not rounded up",
+			annotations: map[string]string{multiplierKey: "1.1", multiplierUntilKey: until},
+			replicas:    10,
+			expected:    11,
+			active:      true,
+		},
+		{
+			name:        "window ended",
+			annotations: map[string]string{multiplierKey: "2", multiplierUntilKey: now.Format(time.RFC3339)},
+			replicas:    4,
+			expected:    4,
+		},
+		{
+			name:        "without the end of the window",
+			annotations: map[string]string{multiplierKey: "2"},
+			replicas:    4,
+			expected:    4,
+		},
+		{
+			name:        "not positive",
+			annotations: map[string]string{multiplierKey: "0", multiplierUntilKey: until},
+			replicas:    4,
+			expected:    4,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := &DecisionEngine{clock: clock.NewFakeClock(now), eventRecorder: &record.FakeRecorder{}}
+			gpa := &autoscaling.GeneralPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Annotations: tc.annotations},
+			}
+			assert.Equal(t, tc.expected, engine.applyMultiplier(gpa, tc.replicas))
+			assert.Equal(t, tc.active, gpa.Status.Multiplier != nil)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"math"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// peakFloorBuckets is the number of the buckets the window of the peak floor is compacted to, a bucket keeps
+// its largest recommendation only
+const peakFloorBuckets = 24
+
+// peakFloor records the proposed replicas in status.peakRecommendations and returns the floor of the replicas
+// raised by the recent peaks of spec.behavior.peakFloor, 0 if it is not set. The peaks older than the window
+// are dropped.
+func peakFloor(gpa *autoscaling.GeneralPodAutoscaler, proposal int32, now time.Time) int32 {
+	if gpa.Spec.Behavior == nil || gpa.Spec.Behavior.PeakFloor == nil || gpa.Spec.Behavior.PeakFloor.WindowSeconds <= 0 {
+		gpa.Status.PeakRecommendations = nil
+		return 0
+	}
+	config := gpa.Spec.Behavior.PeakFloor
+	window := time.Duration(config.WindowSeconds) * time.Second
+	bucket := window / peakFloorBuckets
+	if bucket < time.Second {
+		bucket = time.Second
+	}
+	percent := 100.0
+	if config.Percent != nil {
+		percent = float64(*config.Percent)
+	}
+
+	var peaks []autoscaling.PeakRecommendation
+	for _, peak := range gpa.Status.PeakRecommendations {
+		if now.Sub(peak.Time.Time) < window {
+			peaks = append(peaks, peak)
+		}
+	}
+	if last := len(peaks) - 1; last >= 0 && now.Truncate(bucket).Equal(peaks[last].Time.Truncate(bucket)) {
+		// the later peak of a bucket decays later
+		if proposal >= peaks[last].Replicas {
+			peaks[last] = autoscaling.PeakRecommendation{Time: metav1.NewTime(now), Replicas: proposal}
+		}
+	} else {
+		peaks = append(peaks, autoscaling.PeakRecommendation{Time: metav1.NewTime(now), Replicas: proposal})
+	}
+	gpa.Status.PeakRecommendations = peaks
+
+	var floor int32
+	for _, peak := range peaks {
+		remaining := 1 - float64(now.Sub(peak.Time.Time))/float64(window)
+		share := int32(math.Ceil(float64(peak.Replicas) * percent / 100 * remaining))
+		if share > floor {
+			floor = share
+		}
+	}
+	decisionLog(gpa, 4).Infof("GPA %s/%s: the peaks of the last %v raise the floor to %d replicas",
+		gpa.Namespace, gpa.Name, window, floor)
+	return floor
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func peakFloorGPA(windowSeconds int32, percent *int32) *autoscalingv1alpha1.GeneralPodAutoscaler {
+	return &autoscalingv1alpha1.GeneralPodAutoscaler{
+		Spec: autoscalingv1alpha1.GeneralPodAutoscalerSpec{
+			MaxReplicas: 20,
+			Behavior: &autoscalingv1alpha1.GeneralPodAutoscalerBehavior{
+				PeakFloor: &autoscalingv1alpha1.PeakFloor{WindowSeconds: windowSeconds, Percent: percent},
+			},
+		},
+	}
+}
+
+func TestPeakFloorDecays(t *testing.T) {
+	start := time.Now().Truncate(time.Hour)
+	gpa := peakFloorGPA(4*3600, nil)
+
+	// the peak raises the floor while the recommendations drop after it
+	assert.Equal(t, int32(10), peakFloor(gpa, 10, start))
+	assert.Equal(t, int32(10), peakFloor(gpa, 2, start.Add(time.Minute)))
+	// it decays linearly over the window
+	assert.Equal(t, int32(5), peakFloor(gpa, 2, start.Add(2*time.Hour)))
+	assert.Equal(t, int32(3), peakFloor(gpa, 2, start.Add(3*time.Hour)))
+	// and is dropped once the window passes
+	assert.Equal(t, int32(2), peakFloor(gpa, 2, start.Add(4*time.Hour)))
+	for _, peak := range gpa.Status.PeakRecommendations {
+		assert.True(t, peak.Replicas <= 2, "peak %d at %v is not dropped", peak.Replicas, peak.Time)
+	}
+
+	// the state is dropped once the floor is removed
+	gpa.Spec.Behavior.PeakFloor = nil
+	assert.Equal(t, int32(0), peakFloor(gpa, 2, start))
+	assert.Nil(t, gpa.Status.PeakRecommendations)
+}
+
+func TestPeakFloorCompaction(t *testing.T) {
+	start := time.Now().Truncate(time.Hour)
+	percent := int32(50)
+	gpa := peakFloorGPA(24*3600, &percent)
+
+	// a sync every minute for a day keeps a peak per hour
+	for i := 0; i < 24*60; i++ {
+		peakFloor(gpa, int32(i%60), start.Add(time.Duration(i)*time.Minute))
+	}
+	assert.Len(t, gpa.Status.PeakRecommendations, peakFloorBuckets)
+	for _, peak := range gpa.Status.PeakRecommendations {
+		assert.Equal(t, int32(59), peak.Replicas)
+	}
+	// half of the latest peak
+	assert.Equal(t, int32(30), peakFloor(gpa, 0, start.Add(24*time.Hour)))
+}
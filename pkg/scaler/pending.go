@@ -0,0 +1,66 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// pendingPods returns the number of the pods in the Pending phase, the pods being deleted are not counted
+func pendingPods(pods []*v1.Pod) int32 {
+	var pending int32
+	for _, pod := range pods {
+		if pod.DeletionTimestamp == nil && pod.Status.Phase == v1.PodPending {
+			pending++
+		}
+	}
+	return pending
+}
+
+// limitByPendingPods records the pending pods of the target in the status, and holds a scale up of the target
+// at the current replicas while at least spec.maxPendingPods of them are pending. It sets the ScalingLimited
+// condition if the scale up is held. The scale downs are never held by it.
+func (a *DecisionEngine) limitByPendingPods(gpa *autoscaling.GeneralPodAutoscaler, selector string,
+	currentReplicas, desiredReplicas int32) int32 {
+	if gpa.Spec.MaxPendingPods == nil {
+		gpa.Status.PendingReplicas = 0
+		return desiredReplicas
+	}
+	podSelector, err := labels.Parse(selector)
+	if err != nil {
+		klog.Warningf("Parse selector of gpa %s/%s failed, ignore pending pods: %v", gpa.Namespace, gpa.Name, err)
+		return desiredReplicas
+	}
+	pods, err := a.replicaCalc.podLister.Pods(gpa.Namespace).List(podSelector)
+	if err != nil {
+		klog.Warningf("List pods of gpa %s/%s failed, ignore pending pods: %v", gpa.Namespace, gpa.Name, err)
+		return desiredReplicas
+	}
+	pending := pendingPods(pods)
+	gpa.Status.PendingReplicas = pending
+	if desiredReplicas <= currentReplicas || pending < *gpa.Spec.MaxPendingPods {
+		return desiredReplicas
+	}
+	decisionLog(gpa, 2).Infof("Desired replicas %d of gpa %s/%s are held at %d by %d pending pods",
+		desiredReplicas, gpa.Namespace, gpa.Name, currentReplicas, pending)
+	setCondition(gpa, autoscaling.ScalingLimited, v1.ConditionTrue, "PendingPodsLimited",
+		"the desired replica count %d is held at %d while %d pods of the target are pending",
+		desiredReplicas, currentReplicas, pending)
+	return currentReplicas
+}
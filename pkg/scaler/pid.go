@@ -0,0 +1,63 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"math"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// pidRecommendation moves the current replicas towards the recommendation by the proportional-integral
+// controller of spec.behavior.pid, and records the integral in the status. To keep the integral from winding
+// up, it is bounded so that the integral term alone never exceeds the range of the replicas, and it is not
+// updated while the output is saturated at the min or max replicas.
+func pidRecommendation(gpa *autoscaling.GeneralPodAutoscaler, currentReplicas, recommendation, minReplicas int32,
+	now time.Time) int32 {
+	if gpa.Spec.Behavior == nil || gpa.Spec.Behavior.PID == nil {
+		gpa.Status.PID = nil
+		return recommendation
+	}
+	pid := gpa.Spec.Behavior.PID
+	errorReplicas := float64(recommendation - currentReplicas)
+	previous := 0.0
+	integral := 0.0
+	if state := gpa.Status.PID; state != nil {
+		previous = state.Integral
+		integral = previous
+		if elapsed := now.Sub(state.LastUpdateTime.Time); elapsed > 0 {
+			integral += errorReplicas * elapsed.Minutes()
+		}
+	}
+	if pid.Ki > 0 {
+		bound := float64(gpa.Spec.MaxReplicas-minReplicas) / pid.Ki
+		integral = math.Max(-bound, math.Min(bound, integral))
+	}
+
+	// the output is clamped before it is converted, a large kp would overflow the int32
+	output := math.Round(float64(currentReplicas) + pid.Kp*errorReplicas + pid.Ki*integral)
+	if output > float64(gpa.Spec.MaxReplicas) || output < float64(minReplicas) {
+		integral = previous
+		output = math.Max(float64(minReplicas), math.Min(output, float64(gpa.Spec.MaxReplicas)))
+	}
+	desired := int32(output)
+	gpa.Status.PID = &autoscaling.PIDStatus{Integral: integral, LastUpdateTime: metav1.NewTime(now)}
+	decisionLog(gpa, 4).Infof("GPA %s/%s: recommendation %d controlled to %d with error %v and integral %.3f",
+		gpa.Namespace, gpa.Name, recommendation, desired, errorReplicas, integral)
+	return desired
+}
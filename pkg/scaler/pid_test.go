@@ -0,0 +1,88 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func pidGPA(maxReplicas int32, kp, ki float64) *autoscalingv1alpha1.GeneralPodAutoscaler {
+	return &autoscalingv1alpha1.GeneralPodAutoscaler{
+		Spec: autoscalingv1alpha1.GeneralPodAutoscalerSpec{
+			MaxReplicas: maxReplicas,
+			Behavior: &autoscalingv1alpha1.GeneralPodAutoscalerBehavior{
+				PID: &autoscalingv1alpha1.PIDController{Kp: kp, Ki: ki},
+			},
+		},
+	}
+}
+
+// runPID syncs the GPA once per minute, the replicas follow the output of the controller while the ratio
+// scaling always recommends the ideal replicas
+func runPID(gpa *autoscalingv1alpha1.GeneralPodAutoscaler, start time.Time, replicas, ideal int32, syncs int) []int32 {
+	var history []int32
+	for i := 0; i < syncs; i++ {
+		replicas = pidRecommendation(gpa, replicas, ideal, 1, start.Add(time.Duration(i)*time.Minute))
+		history = append(history, replicas)
+	}
+	return history
+}
+
+func TestPIDRecommendationConverges(t *testing.T) {
+	start := time.Now()
+	gpa := pidGPA(20, 0.5, 0.1)
+	history := runPID(gpa, start, 2, 10, 30)
+	assert.Equal(t, []int32{6, 8, 10, 11}, history[:4])
+	for _, replicas := range history {
+		// the overshoot is bounded to a replica
+		assert.True(t, replicas <= 11, "overshoot to %d replicas", replicas)
+	}
+	assert.Equal(t, int32(10), history[len(history)-1])
+	assert.NotNil(t, gpa.Status.PID)
+
+	// kp 1 and ki 0 is the same as the ratio scaling
+	assert.Equal(t, []int32{10, 10}, runPID(pidGPA(20, 1, 0), start, 2, 10, 2))
+
+	// the state is dropped once the controller is removed
+	gpa.Spec.Behavior.PID = nil
+	assert.Equal(t, int32(3), pidRecommendation(gpa, 10, 3, 1, start))
+	assert.Nil(t, gpa.Status.PID)
+}
+
+func TestPIDRecommendationAntiWindup(t *testing.T) {
+	start := time.Now()
+	gpa := pidGPA(8, 0.5, 0.1)
+	// the ideal replicas are above the max replicas for an hour, the output is saturated
+	history := runPID(gpa, start, 2, 10, 60)
+	assert.Equal(t, int32(8), history[len(history)-1])
+	integral := gpa.Status.PID.Integral
+	assert.True(t, integral <= 7/0.1, "integral wound up to %v", integral)
+
+	// once the load drops, the replicas follow without being held by the integral
+	history = runPID(gpa, start.Add(time.Hour), 8, 4, 5)
+	assert.Equal(t, int32(4), history[len(history)-1])
+}
+
+func TestPIDRecommendationLargeGains(t *testing.T) {
+	// the output of a large kp is clamped to the max replicas rather than overflowing
+	gpa := pidGPA(20, 1e12, 0)
+	assert.Equal(t, int32(20), pidRecommendation(gpa, 2, 10, 1, time.Now()))
+	assert.Equal(t, int32(1), pidRecommendation(gpa, 10, 2, 1, time.Now()))
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"time"
+
+	autoscalinginternal "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// oldestPod returns the oldest of the pods not being deleted, nil if there is none
+func oldestPod(pods []*v1.Pod) *v1.Pod {
+	var oldest *v1.Pod
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if oldest == nil || pod.CreationTimestamp.Before(&oldest.CreationTimestamp) {
+			oldest = pod
+		}
+	}
+	return oldest
+}
+
+// bufferForPodAge adds the buffer replicas of podAgeBuffer to the desired replicas while the oldest pod of the
+// target is older than maxAgeSeconds, so that the old pods can be recycled without falling short of the desired
+// replicas. The buffer is removed once all the pods older than maxAgeSeconds are gone.
+func (a *DecisionEngine) bufferForPodAge(gpa *autoscaling.GeneralPodAutoscaler,
+	scale *autoscalinginternal.Scale, desiredReplicas int32) int32 {
+	buffer := gpa.Spec.PodAgeBuffer
+	if buffer == nil {
+		return desiredReplicas
+	}
+	selector, err := labels.Parse(scale.Status.Selector)
+	if err != nil {
+		klog.Warningf("Parse selector of gpa %s/%s failed, ignore pod age: %v", gpa.Namespace, gpa.Name, err)
+		return desiredReplicas
+	}
+	pods, err := a.replicaCalc.podLister.Pods(gpa.Namespace).List(selector)
+	if err != nil {
+		klog.Warningf("List pods of gpa %s/%s failed, ignore pod age: %v", gpa.Namespace, gpa.Name, err)
+		return desiredReplicas
+	}
+	maxAge := time.Duration(buffer.MaxAgeSeconds) * time.Second
+	oldest := oldestPod(pods)
+	if oldest == nil || a.clock.Since(oldest.CreationTimestamp.Time) <= maxAge {
+		setCondition(gpa, autoscaling.PodAgeBuffered, v1.ConditionFalse, "NoAgedPod",
+			"no pod of the target is older than %v", maxAge)
+		return desiredReplicas
+	}
+	age := a.clock.Since(oldest.CreationTimestamp.Time).Truncate(time.Second)
+	setCondition(gpa, autoscaling.PodAgeBuffered, v1.ConditionTrue, "BufferApplied",
+		"added %d buffer replicas since the pod %s is %v old, older than %v", buffer.Replicas, oldest.Name, age, maxAge)
+	decisionLog(gpa, 4).Infof("GPA %s/%s adds %d buffer replicas to %d desired replicas, oldest pod %s is %v old",
+		gpa.Namespace, gpa.Name, buffer.Replicas, desiredReplicas, oldest.Name, age)
+	return desiredReplicas + buffer.Replicas
+}
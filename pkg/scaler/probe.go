@@ -0,0 +1,174 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+const (
+	defaultProbePeriodSeconds  = 10
+	defaultProbeTimeoutSeconds = 5
+	defaultProbeWindowSeconds  = 60
+	// probePercentile is the percentile of the probed latencies compared to the target latency
+	probePercentile = 0.95
+	// probeBackoffInitial is the backoff of an url after its probe failed for the first time, it doubles on each
+	// failure until probeBackoffMax
+	probeBackoffInitial = 10 * time.Second
+	probeBackoffMax     = 5 * time.Minute
+)
+
+// probeLatency probes the url with a GET request of the header and returns the latency, the timeout is
+// returned if the probe fails or the endpoint does not respond with a 2xx status.
+func probeLatency(url string, header http.Header, timeout time.Duration) (time.Duration, error) {
+	client := http.Client{Timeout: timeout}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return timeout, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	start := time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		return timeout, err
+	}
+	defer res.Body.Close()
+	if _, err := io.Copy(ioutil.Discard, res.Body); err != nil {
+		return timeout, err
+	}
+	latency := time.Since(start)
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return timeout, fmt.Errorf("bad status code %d from %s", res.StatusCode, url)
+	}
+	return latency, nil
+}
+
+// probe probes the url and returns the latency, the timeout if the probe fails. Once the probe of the url fails,
+// it is not probed again until its backoff elapses and counts as the timeout meanwhile, so that an unreachable url
+// does not hold the other GPAs for the timeout on each sync.
+func (a *DecisionEngine) probe(gpa *autoscaling.GeneralPodAutoscaler, url string, header http.Header,
+	timeout time.Duration) time.Duration {
+	if a.probeBackoff.IsInBackOffSinceUpdate(url, a.clock.Now()) {
+		decisionLog(gpa, 2).Infof("GPA %s/%s does not probe %s backed off for %v after failures, count it as %v",
+			gpa.Namespace, gpa.Name, url, a.probeBackoff.Get(url), timeout)
+		return timeout
+	}
+	latency, err := probeLatency(url, header, timeout)
+	if err != nil {
+		decisionLog(gpa, 2).Infof("GPA %s/%s failed to probe %s, count it as %v: %v",
+			gpa.Namespace, gpa.Name, url, timeout, err)
+		a.probeBackoff.Next(url, a.clock.Now())
+		return latency
+	}
+	a.probeBackoff.Reset(url)
+	// drop the backoff of the urls no longer probed by any GPA
+	a.probeBackoff.GC()
+	return latency
+}
+
+// latencyPercentile returns the percentile of the latencies of the samples in milliseconds
+func latencyPercentile(samples []timestampedMetricSample, percentile float64) int64 {
+	latencies := make([]int64, 0, len(samples))
+	for _, sample := range samples {
+		latencies = append(latencies, sample.value)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	index := int(math.Ceil(percentile*float64(len(latencies)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return latencies[index]
+}
+
+// computeStatusForProbeMetric computes the desired number of replicas for the specified metric of type
+// ProbeMetricSourceType, by comparing the p95 of the probed latencies to the target latency.
+func (a *DecisionEngine) computeStatusForProbeMetric(specReplicas int32, metricSpec autoscaling.MetricSpec,
+	gpa *autoscaling.GeneralPodAutoscaler, selector labels.Selector, status *autoscaling.MetricStatus) (replicaCountProposal int32,
+	timestampProposal time.Time, metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
+	src := metricSpec.Probe
+	if src.TargetLatency.Duration < time.Millisecond {
+		err = fmt.Errorf("invalid probe metric source: the target latency must be at least 1ms")
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetProbeMetric", err)
+		return 0, time.Time{}, "", condition, err
+	}
+	period := time.Duration(defaultProbePeriodSeconds) * time.Second
+	if src.PeriodSeconds != nil {
+		period = time.Duration(*src.PeriodSeconds) * time.Second
+	}
+	timeout := time.Duration(defaultProbeTimeoutSeconds) * time.Second
+	if src.TimeoutSeconds != nil {
+		timeout = time.Duration(*src.TimeoutSeconds) * time.Second
+	}
+	window := time.Duration(defaultProbeWindowSeconds) * time.Second
+	if src.WindowSeconds != nil {
+		window = time.Duration(*src.WindowSeconds) * time.Second
+	}
+
+	key := gpa.Namespace + "/" + gpa.Name
+	metricNameProposal = fmt.Sprintf("probe latency of %s", src.URL)
+	now := a.clock.Now()
+	samples := a.metricSamples[key][metricNameProposal]
+	if n := len(samples); n == 0 || now.Sub(samples[n-1].timestamp) >= period {
+		header := http.Header{}
+		if src.TokenAuth != nil {
+			if a.projectedTokens == nil {
+				err = fmt.Errorf("the projected tokens are not configured by --projected-token-dir")
+			} else {
+				err = a.projectedTokens.SetAuthHeader(header, src.TokenAuth)
+			}
+			if err != nil {
+				condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetProbeMetric", err)
+				return 0, time.Time{}, "", condition, fmt.Errorf("failed to get token of %s: %v", metricNameProposal, err)
+			}
+		}
+		latency := a.probe(gpa, src.URL, header, timeout)
+		samples = a.recordMetricSample(key, metricNameProposal,
+			timestampedMetricSample{value: latency.Milliseconds(), timestamp: now}, window)
+	}
+	current := latencyPercentile(samples, probePercentile)
+	decisionLog(gpa, 4).Infof("GPA %s/%s %s p95: %dms with %d samples, target: %v",
+		gpa.Namespace, gpa.Name, metricNameProposal, current, len(samples), src.TargetLatency.Duration)
+
+	usageRatio := float64(current) / float64(src.TargetLatency.Milliseconds())
+	replicaCountProposal, _, err = a.replicaCalc.getUsageRatioReplicaCount(specReplicas, usageRatio, gpa.Namespace, selector)
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetProbeMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get probe metric of %s: %v", src.URL, err)
+	}
+	*status = autoscaling.MetricStatus{
+		Type: autoscaling.ProbeMetricSourceType,
+		Probe: &autoscaling.ProbeMetricStatus{
+			URL: src.URL,
+			Current: autoscaling.MetricValueStatus{
+				Value: resource.NewMilliQuantity(current, resource.DecimalSI),
+			},
+		},
+	}
+	return replicaCountProposal, samples[len(samples)-1].timestamp, metricNameProposal,
+		autoscaling.GeneralPodAutoscalerCondition{}, nil
+}
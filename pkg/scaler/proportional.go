@@ -0,0 +1,87 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// AddNodeInformer lets the GPAs in cluster-proportional mode count the nodes listed by the informer, and
+// reconciles them as soon as a node is added, removed, cordoned or uncordoned, or its allocatable cpu changes.
+// It must be called before the informer is started.
+func (a *GeneralController) AddNodeInformer(nodeInformer coreinformers.NodeInformer) {
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			a.enqueueClusterProportionalGPAs()
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			if nodeChanged(old, cur) {
+				a.enqueueClusterProportionalGPAs()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			a.enqueueClusterProportionalGPAs()
+		},
+	})
+	a.SetClusterNodeLister(nodeInformer.Lister())
+	a.nodeListerSynced = nodeInformer.Informer().HasSynced
+}
+
+// enqueueClusterProportionalGPAs adds the GPAs in cluster-proportional mode to the queue immediately
+func (a *GeneralController) enqueueClusterProportionalGPAs() {
+	gpas, err := a.gpaLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't list gpa: %v", err))
+		return
+	}
+	for _, gpa := range gpas {
+		if gpa.Spec.ClusterProportionalMode == nil {
+			continue
+		}
+		key, err := cache.MetaNamespaceKeyFunc(gpa)
+		if err != nil {
+			utilruntime.HandleError(fmt.Errorf("couldn't get key for object %+v: %v", gpa, err))
+			continue
+		}
+		klog.V(4).Infof("Nodes changed, enqueue gpa %v", key)
+		a.queue.Add(key)
+	}
+}
+
+// nodeChanged returns true if the node is cordoned or uncordoned, or its allocatable cpu changed, updates
+// of other fields like the heartbeats are ignored.
+func nodeChanged(old, cur interface{}) bool {
+	oldNode, ok := old.(*v1.Node)
+	if !ok {
+		return true
+	}
+	curNode, ok := cur.(*v1.Node)
+	if !ok {
+		return true
+	}
+	if oldNode.Spec.Unschedulable != curNode.Spec.Unschedulable {
+		return true
+	}
+	oldCPU := oldNode.Status.Allocatable[v1.ResourceCPU]
+	curCPU := curNode.Status.Allocatable[v1.ResourceCPU]
+	return oldCPU.Cmp(curCPU) != 0
+}
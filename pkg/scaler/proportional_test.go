@@ -0,0 +1,107 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	autoscalinglisters "github.com/ocgi/general-pod-autoscaler/pkg/client/listers/autoscaling/v1alpha1"
+)
+
+func TestNodeChangeEnqueuesClusterProportionalGPA(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+
+	gpaIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, gpa := range []*autoscalingv1alpha1.GeneralPodAutoscaler{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "dns-gpa", Namespace: "kube-system"},
+			Spec: autoscalingv1alpha1.GeneralPodAutoscalerSpec{
+				AutoScalingDrivenMode: autoscalingv1alpha1.AutoScalingDrivenMode{
+					ClusterProportionalMode: &autoscalingv1alpha1.ClusterProportionalMode{NodesPerReplica: 4},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-gpa", Namespace: "default"},
+			Spec: autoscalingv1alpha1.GeneralPodAutoscalerSpec{
+				AutoScalingDrivenMode: autoscalingv1alpha1.AutoScalingDrivenMode{
+					MetricMode: &autoscalingv1alpha1.MetricMode{},
+				},
+			},
+		},
+	} {
+		assert.NoError(t, gpaIndexer.Add(gpa))
+	}
+
+	controller := &GeneralController{
+		DecisionEngine: &DecisionEngine{},
+		gpaLister:      autoscalinglisters.NewGeneralPodAutoscalerLister(gpaIndexer),
+		queue:          workqueue.NewRateLimitingQueue(NewDefaultGPARateLimiter(time.Hour)),
+	}
+	defer controller.queue.ShutDown()
+
+	factory := informers.NewSharedInformerFactory(kubeClient, 0)
+	controller.AddNodeInformer(factory.Core().V1().Nodes())
+	stop := make(chan struct{})
+	defer close(stop)
+	factory.Start(stop)
+	cache.WaitForCacheSync(stop, controller.nodeListerSynced)
+	assert.NotNil(t, controller.clusterNodeLister)
+
+	// a new node enqueues the gpa in cluster-proportional mode only
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-0"},
+		Status:     v1.NodeStatus{Allocatable: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")}},
+	}
+	_, err := kubeClient.CoreV1().Nodes().Create(node)
+	assert.NoError(t, err)
+	waitForKey(t, controller.queue, "kube-system/dns-gpa")
+	assert.Equal(t, 0, controller.queue.Len())
+
+	// so does its removal
+	assert.NoError(t, kubeClient.CoreV1().Nodes().Delete(node.Name, nil))
+	waitForKey(t, controller.queue, "kube-system/dns-gpa")
+	assert.Equal(t, 0, controller.queue.Len())
+}
+
+func TestNodeChanged(t *testing.T) {
+	old := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{ResourceVersion: "1"},
+		Status:     v1.NodeStatus{Allocatable: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")}},
+	}
+	heartbeat := old.DeepCopy()
+	heartbeat.ResourceVersion = "2"
+	heartbeat.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}
+	assert.False(t, nodeChanged(old, heartbeat))
+
+	cordoned := old.DeepCopy()
+	cordoned.Spec.Unschedulable = true
+	assert.True(t, nodeChanged(old, cordoned))
+
+	resized := old.DeepCopy()
+	resized.Status.Allocatable[v1.ResourceCPU] = resource.MustParse("3500m")
+	assert.True(t, nodeChanged(old, resized))
+}
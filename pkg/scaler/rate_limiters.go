@@ -16,6 +16,7 @@
 package scaler
 
 import (
+	"sync"
 	"time"
 
 	"k8s.io/client-go/util/workqueue"
@@ -23,6 +24,7 @@ import (
 
 // FixedItemIntervalRateLimiter limits items to a fixed-rate interval
 type FixedItemIntervalRateLimiter struct {
+	lock     sync.RWMutex
 	interval time.Duration
 }
 
@@ -37,9 +39,18 @@ func NewFixedItemIntervalRateLimiter(interval time.Duration) workqueue.RateLimit
 
 // When returns the interval of the rate limiter
 func (r *FixedItemIntervalRateLimiter) When(item interface{}) time.Duration {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
 	return r.interval
 }
 
+// SetInterval changes the interval of the rate limiter
+func (r *FixedItemIntervalRateLimiter) SetInterval(interval time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.interval = interval
+}
+
 // NumRequeues returns back how many failures the item has had
 func (r *FixedItemIntervalRateLimiter) NumRequeues(item interface{}) int {
 	return 1
@@ -54,3 +65,84 @@ func (r *FixedItemIntervalRateLimiter) Forget(item interface{}) {
 func NewDefaultGPARateLimiter(interval time.Duration) workqueue.RateLimiter {
 	return NewFixedItemIntervalRateLimiter(interval)
 }
+
+// AdaptiveItemIntervalRateLimiter limits each item to its own interval bounded by a min and a max interval. The
+// interval of an item is shortened to the min once it is active, and doubled up to the max while it is stable.
+// The items not adapted yet are limited to the base interval.
+type AdaptiveItemIntervalRateLimiter struct {
+	lock        sync.Mutex
+	interval    time.Duration
+	minInterval time.Duration
+	maxInterval time.Duration
+	intervals   map[interface{}]time.Duration
+}
+
+var _ workqueue.RateLimiter = &AdaptiveItemIntervalRateLimiter{}
+
+// NewAdaptiveItemIntervalRateLimiter creates a new instance of a RateLimiter adapting the interval of each item
+// between minInterval and maxInterval, starting from the base interval
+func NewAdaptiveItemIntervalRateLimiter(interval, minInterval, maxInterval time.Duration) *AdaptiveItemIntervalRateLimiter {
+	return &AdaptiveItemIntervalRateLimiter{
+		interval:    interval,
+		minInterval: minInterval,
+		maxInterval: maxInterval,
+		intervals:   map[interface{}]time.Duration{},
+	}
+}
+
+// When returns the interval of the item
+func (r *AdaptiveItemIntervalRateLimiter) When(item interface{}) time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.when(item)
+}
+
+func (r *AdaptiveItemIntervalRateLimiter) when(item interface{}) time.Duration {
+	if interval, ok := r.intervals[item]; ok {
+		return interval
+	}
+	if r.interval < r.minInterval {
+		return r.minInterval
+	}
+	if r.interval > r.maxInterval {
+		return r.maxInterval
+	}
+	return r.interval
+}
+
+// SetInterval changes the base interval of the items not adapted yet
+func (r *AdaptiveItemIntervalRateLimiter) SetInterval(interval time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.interval = interval
+}
+
+// Shorten shortens the interval of the item to the min interval
+func (r *AdaptiveItemIntervalRateLimiter) Shorten(item interface{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.intervals[item] = r.minInterval
+}
+
+// Lengthen doubles the interval of the item up to the max interval
+func (r *AdaptiveItemIntervalRateLimiter) Lengthen(item interface{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	interval := 2 * r.when(item)
+	if interval > r.maxInterval {
+		interval = r.maxInterval
+	}
+	r.intervals[item] = interval
+}
+
+// NumRequeues returns back how many failures the item has had
+func (r *AdaptiveItemIntervalRateLimiter) NumRequeues(item interface{}) int {
+	return 1
+}
+
+// Forget drops the interval of the item, it is limited to the base interval again
+func (r *AdaptiveItemIntervalRateLimiter) Forget(item interface{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.intervals, item)
+}
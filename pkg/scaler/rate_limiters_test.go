@@ -0,0 +1,45 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveItemIntervalRateLimiter(t *testing.T) {
+	limiter := NewAdaptiveItemIntervalRateLimiter(30*time.Second, 10*time.Second, 2*time.Minute)
+	assert.Equal(t, 30*time.Second, limiter.When("a"), "the items start from the base interval")
+
+	// stable items slow down up to the max interval
+	limiter.Lengthen("a")
+	assert.Equal(t, time.Minute, limiter.When("a"))
+	limiter.Lengthen("a")
+	limiter.Lengthen("a")
+	assert.Equal(t, 2*time.Minute, limiter.When("a"))
+
+	// active items speed up to the min interval at once
+	limiter.Shorten("a")
+	assert.Equal(t, 10*time.Second, limiter.When("a"))
+	assert.Equal(t, 30*time.Second, limiter.When("b"), "the items are adapted independently")
+
+	// the base interval is bounded too
+	limiter.SetInterval(time.Hour)
+	assert.Equal(t, 2*time.Minute, limiter.When("b"))
+	limiter.Forget("a")
+	assert.Equal(t, 2*time.Minute, limiter.When("a"))
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	metricsclient "github.com/ocgi/general-pod-autoscaler/pkg/metrics"
+)
+
+// ratioReplicas returns the replicas keeping the ratio of the sums of the numerator and the denominator at the
+// target percent, and the ratio in percent. Only the pods reporting both metrics are counted, and the replicas
+// are kept if the ratio is within the tolerance of the target. The ratio is undefined if the denominator sums
+// to 0, e.g. the pods report no worker threads at all, an error is returned then.
+func ratioReplicas(numerator, denominator metricsclient.PodMetricsInfo, currentReplicas, targetUtilization int32,
+	tolerance float64) (int32, float64, error) {
+	var used, total int64
+	pods := 0
+	for pod, metric := range numerator {
+		whole, ok := denominator[pod]
+		if !ok {
+			continue
+		}
+		used += metric.Value
+		total += whole.Value
+		pods++
+	}
+	if pods == 0 {
+		return 0, 0, fmt.Errorf("no pods report both metrics of the ratio")
+	}
+	if total <= 0 {
+		return 0, 0, fmt.Errorf("the denominator of the ratio is %d over %d pods", total, pods)
+	}
+	percent := float64(used) * 100 / float64(total)
+	usageRatio := percent / float64(targetUtilization)
+	if math.Abs(1.0-usageRatio) <= tolerance {
+		return currentReplicas, percent, nil
+	}
+	return int32(math.Ceil(usageRatio * float64(pods))), percent, nil
+}
+
+// computeStatusForRatioMetric computes the desired number of replicas for the specified metric of type
+// RatioMetricSourceType, by keeping the ratio of the sums of the numerator and the denominator over the pods
+// at the target utilization.
+func (a *DecisionEngine) computeStatusForRatioMetric(currentReplicas int32, metricSpec autoscaling.MetricSpec,
+	gpa *autoscaling.GeneralPodAutoscaler, selector labels.Selector, status *autoscaling.MetricStatus) (replicaCountProposal int32,
+	timestampProposal time.Time, metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
+	src := metricSpec.Ratio
+	if src.Target.AverageUtilization == nil || *src.Target.AverageUtilization <= 0 {
+		err = fmt.Errorf("invalid ratio metric source: the target average utilization must be greater than 0")
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetRatioMetric", err)
+		return 0, time.Time{}, "", condition, err
+	}
+	metricNameProposal = fmt.Sprintf("ratio of pods metric %s to %s", src.Numerator.Name, src.Denominator.Name)
+	var metrics [2]metricsclient.PodMetricsInfo
+	for i, identifier := range []autoscaling.MetricIdentifier{src.Numerator, src.Denominator} {
+		metricSelector, err := metav1.LabelSelectorAsSelector(identifier.Selector)
+		if err != nil {
+			condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetRatioMetric", err)
+			return 0, time.Time{}, "", condition, fmt.Errorf("failed to get pods metric %s: %v", identifier.Name, err)
+		}
+		var timestamp time.Time
+		metrics[i], timestamp, err = a.replicaCalc.metricsClient.GetRawMetric(identifier.Name, gpa.Namespace, selector, metricSelector)
+		if err != nil {
+			condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetRatioMetric", err)
+			return 0, time.Time{}, "", condition, fmt.Errorf("failed to get pods metric %s: %v", identifier.Name, err)
+		}
+		if timestampProposal.IsZero() || timestamp.Before(timestampProposal) {
+			timestampProposal = timestamp
+		}
+	}
+	replicaCountProposal, percent, err := ratioReplicas(metrics[0], metrics[1], currentReplicas,
+		*src.Target.AverageUtilization, a.replicaCalc.tolerance)
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetRatioMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get %s: %v", metricNameProposal, err)
+	}
+	decisionLog(gpa, 4).Infof("GPA %s/%s %s: %.1f%%, target: %d%%",
+		gpa.Namespace, gpa.Name, metricNameProposal, percent, *src.Target.AverageUtilization)
+	utilization := int32(math.Round(percent))
+	*status = autoscaling.MetricStatus{
+		Type: autoscaling.RatioMetricSourceType,
+		Ratio: &autoscaling.RatioMetricStatus{
+			Numerator:   src.Numerator,
+			Denominator: src.Denominator,
+			Current: autoscaling.MetricValueStatus{
+				AverageUtilization: &utilization,
+			},
+		},
+	}
+	return replicaCountProposal, timestampProposal, metricNameProposal, autoscaling.GeneralPodAutoscalerCondition{}, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metricsclient "github.com/ocgi/general-pod-autoscaler/pkg/metrics"
+)
+
+// workers returns the pod metrics of the pods by their names, the values are in milli units
+func workers(values map[string]int64) metricsclient.PodMetricsInfo {
+	metrics := metricsclient.PodMetricsInfo{}
+	for pod, value := range values {
+		metrics[pod] = metricsclient.PodMetric{Value: value * 1000}
+	}
+	return metrics
+}
+
+func TestRatioReplicas(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		busy     map[string]int64
+		total    map[string]int64
+		replicas int32
+		percent  float64
+		err      bool
+	}{
+		{
+			name:     "busy above the target",
+			busy:     map[string]int64{"a": 9, "b": 7},
+			total:    map[string]int64{"a": 10, "b": 10},
+			replicas: 4,
+			percent:  80,
+		},
+		{
+			name:     "busy below the target",
+			busy:     map[string]int64{"a": 1, "b": 2, "c": 3, "d": 2},
+			total:    map[string]int64{"a": 10, "b": 10, "c": 10, "d": 10},
+			replicas: 2,
+			percent:  20,
+		},
+		{
+			name:     "within the tolerance",
+			busy:     map[string]int64{"a": 5, "b": 5},
+			total:    map[string]int64{"a": 10, "b": 9},
+			replicas: 2,
+			percent:  100 * 10.0 / 19,
+		},
+		{
+			name:     "pods missing the total are not counted",
+			busy:     map[string]int64{"a": 6, "b": 6, "c": 10},
+			total:    map[string]int64{"a": 10, "b": 10},
+			replicas: 3,
+			percent:  60,
+		},
+		{
+			name:  "no total",
+			busy:  map[string]int64{"a": 0, "b": 0},
+			total: map[string]int64{"a": 0, "b": 0},
+			err:   true,
+		},
+		{
+			name:  "no pods reporting both",
+			busy:  map[string]int64{"a": 1},
+			total: map[string]int64{"b": 10},
+			err:   true,
+		},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			// a target of 50% with the current 2 replicas
+			replicas, percent, err := ratioReplicas(workers(c.busy), workers(c.total), 2, 50, 0.1)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.replicas, replicas)
+			assert.InDelta(t, c.percent, percent, 0.001)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	emapi "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+)
+
+const (
+	// ExternalMetricsPath is the path of the external metrics API served by RecommendationsHandler
+	ExternalMetricsPath = "/apis/external.metrics.k8s.io/v1beta1"
+	// DesiredReplicasMetricName is the external metric of the replicas recommended for the targets of the GPAs
+	DesiredReplicasMetricName = "gpa_desired_replicas"
+	// recommendationGPALabel is the label of the metric values naming their GPA
+	recommendationGPALabel = "gpa"
+)
+
+// recommendation is the last replicas recommended for the target of a GPA
+type recommendation struct {
+	replicas  int32
+	timestamp time.Time
+}
+
+// recommendationStore keeps the last recommendation of each GPA
+type recommendationStore struct {
+	lock   sync.RWMutex
+	values map[string]recommendation
+}
+
+func newRecommendationStore() *recommendationStore {
+	return &recommendationStore{values: map[string]recommendation{}}
+}
+
+func (s *recommendationStore) set(key string, value recommendation) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.values[key] = value
+}
+
+func (s *recommendationStore) forget(key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.values, key)
+}
+
+// list returns the recommendations of the GPAs in the namespace by their names
+func (s *recommendationStore) list(namespace string) map[string]recommendation {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	values := map[string]recommendation{}
+	for key, value := range s.values {
+		ns, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil || ns != namespace {
+			continue
+		}
+		values[name] = value
+	}
+	return values
+}
+
+// SetRecommendationMetrics keeps the last recommendation of each GPA, which are served by RecommendationsHandler.
+func (a *GeneralController) SetRecommendationMetrics() {
+	a.recommendationMetrics = newRecommendationStore()
+}
+
+// recordRecommendation records the replicas recommended for the target of the GPA if the recommendations are kept
+func (a *GeneralController) recordRecommendation(key string, replicas int32) {
+	if a.recommendationMetrics == nil {
+		return
+	}
+	a.recommendationMetrics.set(key, recommendation{replicas: replicas, timestamp: a.clock.Now()})
+}
+
+// RecommendationsHandler serves the last recommendation of each GPA in the shape of the external metrics API, so
+// that other controllers, e.g. an HPA with an External metric, read them through an APIService of
+// external.metrics.k8s.io. The recommendations of the GPAs in a namespace are served on
+// <ExternalMetricsPath>/namespaces/<namespace>/gpa_desired_replicas, each labeled gpa=<name>, and filtered by the
+// labelSelector query parameter.
+func (a *GeneralController) RecommendationsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, ExternalMetricsPath), "/")
+		if path == "" {
+			writeJSON(w, &metav1.APIResourceList{
+				TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+				GroupVersion: emapi.SchemeGroupVersion.String(),
+				APIResources: []metav1.APIResource{{
+					Name:       DesiredReplicasMetricName,
+					Namespaced: true,
+					Kind:       "ExternalMetricValueList",
+					Verbs:      metav1.Verbs{"get"},
+				}},
+			})
+			return
+		}
+		parts := strings.Split(path, "/")
+		if len(parts) != 3 || parts[0] != "namespaces" || parts[2] != DesiredReplicasMetricName {
+			http.Error(w, "only "+ExternalMetricsPath+"/namespaces/<namespace>/"+DesiredReplicasMetricName+" is served",
+				http.StatusNotFound)
+			return
+		}
+		if a.recommendationMetrics == nil {
+			http.Error(w, "recommendation metrics are disabled", http.StatusNotFound)
+			return
+		}
+		selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		list := &emapi.ExternalMetricValueList{
+			TypeMeta: metav1.TypeMeta{Kind: "ExternalMetricValueList", APIVersion: emapi.SchemeGroupVersion.String()},
+			Items:    []emapi.ExternalMetricValue{},
+		}
+		for name, value := range a.recommendationMetrics.list(parts[1]) {
+			metricLabels := map[string]string{recommendationGPALabel: name}
+			if !selector.Matches(labels.Set(metricLabels)) {
+				continue
+			}
+			list.Items = append(list.Items, emapi.ExternalMetricValue{
+				MetricName:   DesiredReplicasMetricName,
+				MetricLabels: metricLabels,
+				Timestamp:    metav1.NewTime(value.timestamp),
+				Value:        *resource.NewQuantity(int64(value.replicas), resource.DecimalSI),
+			})
+		}
+		sort.Slice(list.Items, func(i, j int) bool {
+			return list.Items[i].MetricLabels[recommendationGPALabel] < list.Items[j].MetricLabels[recommendationGPALabel]
+		})
+		writeJSON(w, list)
+	})
+}
+
+// writeJSON writes the object as JSON
+func writeJSON(w http.ResponseWriter, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
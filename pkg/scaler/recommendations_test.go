@@ -0,0 +1,106 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	emapi "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+)
+
+func queryRecommendations(t *testing.T, controller *GeneralController, path string) (int, *emapi.ExternalMetricValueList) {
+	recorder := httptest.NewRecorder()
+	controller.RecommendationsHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ExternalMetricsPath+path, nil))
+	list := &emapi.ExternalMetricValueList{}
+	if recorder.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), list))
+	}
+	return recorder.Code, list
+}
+
+func TestRecommendationsHandler(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	controller := &GeneralController{DecisionEngine: &DecisionEngine{clock: fakeClock}}
+	code, _ := queryRecommendations(t, controller, "/namespaces/default/gpa_desired_replicas")
+	assert.Equal(t, http.StatusNotFound, code, "the recommendation metrics are disabled")
+
+	controller.SetRecommendationMetrics()
+	controller.recordRecommendation("default/web", 3)
+	controller.recordRecommendation("default/api", 7)
+	controller.recordRecommendation("other/web", 9)
+	fakeClock.Step(time.Minute)
+	controller.recordRecommendation("default/web", 5)
+
+	code, list := queryRecommendations(t, controller, "/namespaces/default/gpa_desired_replicas")
+	assert.Equal(t, http.StatusOK, code)
+	require.Len(t, list.Items, 2)
+	assert.Equal(t, "api", list.Items[0].MetricLabels["gpa"])
+	assert.Equal(t, int64(7), list.Items[0].Value.Value())
+	// the last recommendation of a GPA is served
+	assert.Equal(t, DesiredReplicasMetricName, list.Items[1].MetricName)
+	assert.Equal(t, "web", list.Items[1].MetricLabels["gpa"])
+	assert.Equal(t, int64(5), list.Items[1].Value.Value())
+	assert.WithinDuration(t, fakeClock.Now(), list.Items[1].Timestamp.Time, time.Second)
+
+	code, list = queryRecommendations(t, controller, "/namespaces/default/gpa_desired_replicas?labelSelector=gpa%3Dweb")
+	assert.Equal(t, http.StatusOK, code)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, int64(5), list.Items[0].Value.Value())
+
+	code, _ = queryRecommendations(t, controller, "/namespaces/default/other_metric")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = queryRecommendations(t, controller, "/namespaces/default/gpa_desired_replicas?labelSelector=gpa%3D%3D%3D")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	recorder := httptest.NewRecorder()
+	controller.RecommendationsHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ExternalMetricsPath, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	resources := &metav1.APIResourceList{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), resources))
+	require.Len(t, resources.APIResources, 1)
+	assert.Equal(t, DesiredReplicasMetricName, resources.APIResources[0].Name)
+}
+
+func TestRecommendationServedAfterReconcile(t *testing.T) {
+	tc := testCase{
+		minReplicas:             2,
+		maxReplicas:             6,
+		specReplicas:            3,
+		statusReplicas:          3,
+		expectedDesiredReplicas: 5,
+		CPUTarget:               30,
+		reportedLevels:          []uint64{300, 500, 700},
+		reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+		useMetricsAPI:           true,
+	}
+	controller, informerFactory, scalerFactory := tc.setupController(t)
+	controller.SetRecommendationMetrics()
+	tc.runTestWithController(t, controller, informerFactory, scalerFactory)
+
+	code, list := queryRecommendations(t, controller,
+		"/namespaces/test-namespace/gpa_desired_replicas?labelSelector=gpa%3Dtest-gpa")
+	assert.Equal(t, http.StatusOK, code)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, int64(tc.expectedDesiredReplicas), list.Items[0].Value.Value())
+}
@@ -54,6 +54,8 @@ type resourceInfo struct {
 	levels   []int64
 	// only applies to pod names returned from "heapster"
 	podNames []string
+	// normalizePerPod averages the utilization of each pod against its own request
+	normalizePerPod bool
 
 	targetUtilization   int32
 	expectedUtilization int32
@@ -76,6 +78,8 @@ type metricInfo struct {
 	singleObject *autoscalingv1alpha1.CrossVersionObjectReference
 	selector     *metav1.LabelSelector
 	metricType   metricType
+	// only applies to pod metrics, the pod of each level, several levels may share a pod
+	podNames []string
 
 	targetUtilization       int64
 	perPodTargetUtilization int64
@@ -242,10 +246,14 @@ func (tc *replicaCalcTestCase) prepareTestCMClient(t *testing.T) *cmfake.FakeCus
 			assert.Equal(t, "pods", getForAction.GetResource().Resource, "the type of object that we requested multiple metrics for should have been pods")
 
 			for i, level := range tc.metric.levels {
+				podName := fmt.Sprintf("%s-%d", podNamePrefix, i)
+				if len(tc.metric.podNames) > i {
+					podName = tc.metric.podNames[i]
+				}
 				podMetric := cmapi.MetricValue{
 					DescribedObject: v1.ObjectReference{
 						Kind:      "Pod",
-						Name:      fmt.Sprintf("%s-%d", podNamePrefix, i),
+						Name:      podName,
 						Namespace: testNamespace,
 					},
 					Timestamp: metav1.Time{Time: tc.timestamp},
@@ -364,7 +372,7 @@ func (tc *replicaCalcTestCase) runTest(t *testing.T) {
 	}
 
 	if tc.resource != nil {
-		outReplicas, outUtilization, outRawValue, outTimestamp, err := replicaCalc.GetResourceReplicas(tc.currentReplicas, tc.resource.targetUtilization, tc.resource.name, testNamespace, selector, "", false)
+		outReplicas, outUtilization, outRawValue, outTimestamp, err := replicaCalc.GetResourceReplicas(tc.currentReplicas, tc.resource.targetUtilization, tc.resource.name, testNamespace, selector, "", false, tc.resource.normalizePerPod)
 
 		if tc.expectedError != nil {
 			require.Error(t, err, "there should be an error calculating the replica count")
@@ -460,6 +468,62 @@ func TestReplicaCalcScaleUp(t *testing.T) {
 	tc.runTest(t)
 }
 
+func TestReplicaCalcScaleUpNormalizedPerPod(t *testing.T) {
+	// the total usage is 36% of the total requests, but the small pods are at 90%
+	tc := replicaCalcTestCase{
+		currentReplicas:  3,
+		expectedReplicas: 4,
+		resource: &resourceInfo{
+			name:            v1.ResourceCPU,
+			requests:        []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("4.0")},
+			levels:          []int64{900, 900, 400},
+			normalizePerPod: true,
+
+			targetUtilization:   50,
+			expectedUtilization: 63,
+			expectedValue:       numContainersPerPod * 733,
+		},
+	}
+	tc.runTest(t)
+}
+
+func TestReplicaCalcNotNormalizedPerPod(t *testing.T) {
+	tc := replicaCalcTestCase{
+		currentReplicas:  3,
+		expectedReplicas: 3,
+		resource: &resourceInfo{
+			name:     v1.ResourceCPU,
+			requests: []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("4.0")},
+			levels:   []int64{900, 900, 400},
+
+			targetUtilization:   50,
+			expectedUtilization: 36,
+			expectedValue:       numContainersPerPod * 733,
+		},
+	}
+	tc.runTest(t)
+}
+
+func TestReplicaCalcScaleDownNormalizedPerPod(t *testing.T) {
+	// the total usage is 55% of the total requests, but only the large pod is busy
+	tc := replicaCalcTestCase{
+		currentReplicas:  4,
+		expectedReplicas: 3,
+		resource: &resourceInfo{
+			name: v1.ResourceCPU,
+			requests: []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0"),
+				resource.MustParse("4.0")},
+			levels:          []int64{100, 100, 100, 3600},
+			normalizePerPod: true,
+
+			targetUtilization:   50,
+			expectedUtilization: 30,
+			expectedValue:       numContainersPerPod * 975,
+		},
+	}
+	tc.runTest(t)
+}
+
 func TestReplicaCalcScaleUpUnreadyLessScale(t *testing.T) {
 	tc := replicaCalcTestCase{
 		currentReplicas:  3,
@@ -595,6 +659,39 @@ func TestReplicaCalcScaleUpCM(t *testing.T) {
 	tc.runTest(t)
 }
 
+func TestReplicaCalcScaleUpCMGPUUtilization(t *testing.T) {
+	tc := replicaCalcTestCase{
+		currentReplicas:  4,
+		expectedReplicas: 5,
+		metric: &metricInfo{
+			name: "DCGM_FI_DEV_GPU_UTIL",
+			// the last pod has no GPU sample yet, it is treated as idle on a scale-up
+			levels:              []int64{90000, 80000, 100000},
+			targetUtilization:   60000,
+			expectedUtilization: 90000,
+			metricType:          podMetric,
+		},
+	}
+	tc.runTest(t)
+}
+
+func TestReplicaCalcScaleUpCMMultiGPUUtilization(t *testing.T) {
+	tc := replicaCalcTestCase{
+		currentReplicas:  2,
+		expectedReplicas: 4,
+		metric: &metricInfo{
+			name: "DCGM_FI_DEV_GPU_UTIL",
+			// one sample per GPU, the first pod has two GPUs
+			levels:              []int64{100000, 60000, 80000},
+			podNames:            []string{podNamePrefix + "-0", podNamePrefix + "-0", podNamePrefix + "-1"},
+			targetUtilization:   40000,
+			expectedUtilization: 80000,
+			metricType:          podMetric,
+		},
+	}
+	tc.runTest(t)
+}
+
 func TestReplicaCalcScaleUpCMUnreadyHotCpuNoLessScale(t *testing.T) {
 	tc := replicaCalcTestCase{
 		currentReplicas:  3,
@@ -784,6 +881,22 @@ func TestReplicaCalcScaleDownCM(t *testing.T) {
 	tc.runTest(t)
 }
 
+func TestReplicaCalcScaleDownCMGPUUtilization(t *testing.T) {
+	tc := replicaCalcTestCase{
+		currentReplicas:  4,
+		expectedReplicas: 2,
+		metric: &metricInfo{
+			name: "DCGM_FI_DEV_GPU_UTIL",
+			// the last pod has no GPU sample yet, it is treated as at the target on a scale-down
+			levels:              []int64{20000, 20000, 20000},
+			targetUtilization:   60000,
+			expectedUtilization: 20000,
+			metricType:          podMetric,
+		},
+	}
+	tc.runTest(t)
+}
+
 func TestReplicaCalcScaleDownPerPodCMObject(t *testing.T) {
 	tc := replicaCalcTestCase{
 		currentReplicas:  5,
@@ -1686,7 +1799,7 @@ func TestGroupPods(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			readyPodCount, ignoredPods, missingPods, _ := groupPods(tc.pods, tc.metrics, tc.resource, defaultTestingCPUInitializationPeriod,
-				defaultTestingDelayOfInitialReadinessStatus)
+				defaultTestingDelayOfInitialReadinessStatus, time.Now())
 			if readyPodCount != tc.expectReadyPodCount {
 				t.Errorf("%s got readyPodCount %d, expected %d", tc.name, readyPodCount, tc.expectReadyPodCount)
 			}
@@ -0,0 +1,50 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+)
+
+// SetAdaptiveResync adapts the resync interval of each GPA between minInterval and maxInterval: it is shortened to
+// minInterval once the target is scaled or the GPA is at its max replicas, and doubled up to maxInterval on each
+// reconcile the GPA is stable. It must be called before the controller runs, the resync period is used if any of
+// the intervals is not greater than 0.
+func (a *GeneralController) SetAdaptiveResync(minInterval, maxInterval time.Duration) {
+	if minInterval <= 0 || maxInterval <= 0 {
+		return
+	}
+	limiter := NewAdaptiveItemIntervalRateLimiter(a.resyncPeriod, minInterval, maxInterval)
+	a.queue.ShutDown()
+	a.queue = workqueue.NewNamedRateLimitingQueue(limiter, "podautoscaler")
+	a.rateLimiter = limiter
+}
+
+// adaptResync shortens the resync interval of the GPA if it is active, or lengthens it otherwise
+func (a *GeneralController) adaptResync(key string, active bool) {
+	limiter, ok := a.rateLimiter.(*AdaptiveItemIntervalRateLimiter)
+	if !ok {
+		return
+	}
+	if active {
+		limiter.Shorten(key)
+	} else {
+		limiter.Lengthen(key)
+	}
+	klog.V(4).Infof("GPA %s is resynced in %v", key, limiter.When(key))
+}
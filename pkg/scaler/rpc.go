@@ -0,0 +1,135 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/recommender"
+)
+
+var _ recommender.RecommendationServer = &GeneralController{}
+
+// SetRecommendationService keeps the last decision of each GPA, which is served by GetRecommendation.
+func (a *GeneralController) SetRecommendationService() {
+	a.latestDecisions = newDecisionHistory(1)
+}
+
+// GetRecommendation returns the last decision of the DecisionEngine for the GPA, i.e. the replicas it computed and
+// the inputs they were computed from. The GPA is never reconciled on request, so the decision is at most one resync
+// old, and a controller which is not the leader has no decision of any GPA.
+func (a *GeneralController) GetRecommendation(ctx context.Context,
+	req *recommender.GetRecommendationRequest) (*recommender.GetRecommendationResponse, error) {
+	if a.latestDecisions == nil {
+		return nil, status.Error(codes.Unavailable, "recommendation service is disabled")
+	}
+	if req.Namespace == "" || req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "namespace and name are required")
+	}
+	if _, err := a.gpaLister.GeneralPodAutoscalers(req.Namespace).Get(req.Name); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, status.Errorf(codes.NotFound, "GPA %s/%s not found", req.Namespace, req.Name)
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	records, _ := a.latestDecisions.get(req.Namespace + "/" + req.Name)
+	if len(records) == 0 {
+		return nil, status.Errorf(codes.NotFound, "GPA %s/%s has not been reconciled yet", req.Namespace, req.Name)
+	}
+	record := records[len(records)-1]
+	timestamp, err := ptypes.TimestampProto(record.Timestamp)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	metricStatuses := make([]*recommender.MetricStatus, 0, len(record.MetricStatuses))
+	for _, metricStatus := range record.MetricStatuses {
+		metricStatuses = append(metricStatuses, metricStatusMessage(metricStatus))
+	}
+	return &recommender.GetRecommendationResponse{
+		Timestamp:           timestamp,
+		CurrentReplicas:     record.CurrentReplicas,
+		MinReplicas:         record.MinReplicas,
+		MaxReplicas:         record.MaxReplicas,
+		MetricName:          record.MetricName,
+		MetricStatuses:      metricStatuses,
+		RecommendedReplicas: record.RecommendedReplicas,
+		DesiredReplicas:     record.DesiredReplicas,
+		Reason:              record.Reason,
+		Error:               record.Error,
+	}, nil
+}
+
+// metricStatusMessage returns the type, the name and the current values of the metric as a message of the service
+func metricStatusMessage(metricStatus autoscaling.MetricStatus) *recommender.MetricStatus {
+	var name string
+	var current autoscaling.MetricValueStatus
+	switch {
+	case metricStatus.Resource != nil:
+		name, current = string(metricStatus.Resource.Name), metricStatus.Resource.Current
+	case metricStatus.ContainerResource != nil:
+		name = fmt.Sprintf("%s of container %s", metricStatus.ContainerResource.Name,
+			metricStatus.ContainerResource.Container)
+		current = metricStatus.ContainerResource.Current
+	case metricStatus.Pods != nil:
+		name, current = metricStatus.Pods.Metric.Name, metricStatus.Pods.Current
+	case metricStatus.Object != nil:
+		name = fmt.Sprintf("%s of %s/%s", metricStatus.Object.Metric.Name, metricStatus.Object.DescribedObject.Kind,
+			metricStatus.Object.DescribedObject.Name)
+		current = metricStatus.Object.Current
+	case metricStatus.External != nil:
+		name, current = metricStatus.External.Metric.Name, metricStatus.External.Current
+	case metricStatus.Derivative != nil:
+		name, current = metricStatus.Derivative.Metric.Name, metricStatus.Derivative.Current
+	case metricStatus.Probe != nil:
+		name, current = metricStatus.Probe.URL, metricStatus.Probe.Current
+	case metricStatus.KafkaLag != nil:
+		name = fmt.Sprintf("group %s on topic %s", metricStatus.KafkaLag.ConsumerGroup, metricStatus.KafkaLag.Topic)
+		current = metricStatus.KafkaLag.Current
+	case metricStatus.CounterDelta != nil:
+		name, current = metricStatus.CounterDelta.Metric.Name, metricStatus.CounterDelta.Current
+	case metricStatus.Ratio != nil:
+		name = fmt.Sprintf("%s of %s", metricStatus.Ratio.Numerator.Name, metricStatus.Ratio.Denominator.Name)
+		current = metricStatus.Ratio.Current
+	case metricStatus.Concurrency != nil:
+		name, current = metricStatus.Concurrency.Metric.Name, metricStatus.Concurrency.Current
+	case metricStatus.Scrape != nil:
+		name, current = metricStatus.Scrape.MetricName, metricStatus.Scrape.Current
+	case metricStatus.BucketTable != nil:
+		name, current = metricStatus.BucketTable.Metric.Name, metricStatus.BucketTable.Current
+	}
+	message := &recommender.MetricStatus{
+		Type:    string(metricStatus.Type),
+		Name:    name,
+		Current: &recommender.MetricValueStatus{},
+	}
+	if current.Value != nil {
+		message.Current.Value = current.Value.String()
+	}
+	if current.AverageValue != nil {
+		message.Current.AverageValue = current.AverageValue.String()
+	}
+	if current.AverageUtilization != nil {
+		message.Current.AverageUtilization = &wrappers.Int32Value{Value: *current.AverageUtilization}
+	}
+	return message
+}
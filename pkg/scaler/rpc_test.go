@@ -0,0 +1,125 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/recommender"
+)
+
+func TestRecommendationService(t *testing.T) {
+	tc := testCase{
+		minReplicas:             2,
+		maxReplicas:             6,
+		specReplicas:            3,
+		statusReplicas:          3,
+		expectedDesiredReplicas: 5,
+		CPUTarget:               30,
+		reportedLevels:          []uint64{300, 500, 700},
+		reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+		useMetricsAPI:           true,
+	}
+	gpaController, informerFactory, scalerFactory := tc.setupController(t)
+	gpaController.SetRecommendationService()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	scalerFactory.Start(stop)
+	informerFactory.Start(stop)
+	if !cache.WaitForCacheSync(stop, scalerFactory.Autoscaling().V1alpha1().GeneralPodAutoscalers().Informer().HasSynced) {
+		t.Fatal("failed to sync gpas")
+	}
+	addr, err := recommender.Serve("127.0.0.1:0", gpaController, stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr.String(), grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	assert.Contains(t, listServices(t, ctx, conn), "autoscaling.ocgi.io.v1alpha1.Recommendation")
+	client := recommender.NewRecommendationClient(conn)
+	request := &recommender.GetRecommendationRequest{Namespace: "test-namespace", Name: "test-gpa"}
+
+	_, err = client.GetRecommendation(ctx, request)
+	assert.Equal(t, codes.NotFound, status.Code(err), "the GPA has not been reconciled yet")
+	_, err = client.GetRecommendation(ctx, &recommender.GetRecommendationRequest{Namespace: "test-namespace", Name: "other"})
+	assert.Equal(t, codes.NotFound, status.Code(err), "the GPA does not exist")
+	_, err = client.GetRecommendation(ctx, &recommender.GetRecommendationRequest{Name: "test-gpa"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	if _, err := gpaController.reconcileKey("test-namespace/test-gpa"); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	response, err := client.GetRecommendation(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int32(3), response.CurrentReplicas)
+	assert.Equal(t, int32(2), response.MinReplicas)
+	assert.Equal(t, int32(6), response.MaxReplicas)
+	assert.Equal(t, int32(5), response.RecommendedReplicas)
+	assert.Equal(t, int32(5), response.DesiredReplicas)
+	assert.Equal(t, "cpu resource utilization (percentage of request)", response.MetricName)
+	if assert.Len(t, response.MetricStatuses, 1) {
+		assert.Equal(t, "Resource", response.MetricStatuses[0].Type)
+		assert.Equal(t, "cpu", response.MetricStatuses[0].Name)
+		assert.Equal(t, int32(50), response.MetricStatuses[0].Current.GetAverageUtilization().GetValue())
+	}
+}
+
+// listServices lists the services of the server by the reflection service, as grpcurl does
+func listServices(t *testing.T, ctx context.Context, conn *grpc.ClientConn) []string {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.CloseSend()
+	if err := stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	response, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var services []string
+	for _, service := range response.GetListServicesResponse().GetService() {
+		services = append(services, service.Name)
+	}
+	return services
+}
+
+func TestRecommendationServiceDisabled(t *testing.T) {
+	controller := &GeneralController{}
+	_, err := controller.GetRecommendation(context.Background(),
+		&recommender.GetRecommendationRequest{Namespace: "default", Name: "web"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
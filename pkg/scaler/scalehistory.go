@@ -0,0 +1,52 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// recordScaleHistory appends the scale of the target to status.scaleHistory, keeping the last
+// spec.scaleHistoryLimit scales only
+func recordScaleHistory(gpa *autoscaling.GeneralPodAutoscaler, oldReplicas, newReplicas int32, reason string,
+	now time.Time) {
+	if gpa.Spec.ScaleHistoryLimit == nil {
+		return
+	}
+	gpa.Status.ScaleHistory = append(gpa.Status.ScaleHistory, autoscaling.ScaleRecord{
+		Time:        metav1.NewTime(now),
+		OldReplicas: oldReplicas,
+		NewReplicas: newReplicas,
+		Reason:      reason,
+	})
+	gpa.Status.ScaleHistory = scaleHistory(gpa)
+}
+
+// scaleHistory returns the last spec.scaleHistoryLimit scales of status.scaleHistory, nil if the limit is not
+// set, so that lowering or removing the limit drops the older scales
+func scaleHistory(gpa *autoscaling.GeneralPodAutoscaler) []autoscaling.ScaleRecord {
+	if gpa.Spec.ScaleHistoryLimit == nil || *gpa.Spec.ScaleHistoryLimit <= 0 {
+		return nil
+	}
+	history := gpa.Status.ScaleHistory
+	if limit := int(*gpa.Spec.ScaleHistoryLimit); len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return history
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// DefaultScaleUpdateRetries is how many times a scale update failing transiently is retried
+	DefaultScaleUpdateRetries = 3
+	// DefaultScaleUpdateBackoff is the wait before the first retry of a scale update, doubled on each retry
+	DefaultScaleUpdateBackoff = 200 * time.Millisecond
+)
+
+// SetScaleUpdateRetry sets how many times a scale update failing transiently, e.g. on a timeout or throttling, is
+// retried within the reconcile and the wait before the first retry, doubled on each retry. The decision is dropped
+// and the GPA requeued only once the retries are exhausted, 0 retries to requeue on the first failure.
+func (a *GeneralController) SetScaleUpdateRetry(retries int, backoff time.Duration) {
+	a.scaleUpdateRetries = retries
+	a.scaleUpdateBackoff = backoff
+}
+
+// scaleUpdateRetry returns the backoff of the scale updates, the update is tried once if no retry is set
+func (a *GeneralController) scaleUpdateRetry() wait.Backoff {
+	steps := a.scaleUpdateRetries + 1
+	if steps < 1 {
+		steps = 1
+	}
+	return wait.Backoff{
+		Steps:    steps,
+		Duration: a.scaleUpdateBackoff,
+		Factor:   2,
+		Jitter:   0.1,
+	}
+}
+
+// isTransientScaleError returns true if the scale update may succeed once retried as is, the conflicts are
+// retried with the latest scale instead.
+func isTransientScaleError(err error) bool {
+	return errors.IsTimeout(err) || errors.IsServerTimeout(err) || errors.IsTooManyRequests(err) ||
+		errors.IsServiceUnavailable(err) || errors.IsInternalError(err)
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// blendGPA keeps the cpu under 70% and the queue of each pod under 100 by the blend of the function
+func blendGPA(function autoscaling.MetricBlendFunction, cpuWeight float64) *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	utilization := int32(70)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: Namespace},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    20,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{
+					Blend: &autoscaling.MetricBlend{Function: function},
+					Metrics: []autoscaling.MetricSpec{
+						{
+							Type:   autoscaling.ResourceMetricSourceType,
+							Weight: &cpuWeight,
+							Resource: &autoscaling.ResourceMetricSource{
+								Name: v1.ResourceCPU,
+								Target: autoscaling.MetricTarget{
+									Type:               autoscaling.UtilizationMetricType,
+									AverageUtilization: &utilization,
+								},
+							},
+						},
+						{
+							Type: autoscaling.PodsMetricSourceType,
+							Pods: &autoscaling.PodsMetricSource{
+								Metric: autoscaling.MetricIdentifier{Name: "queue"},
+								Target: autoscaling.MetricTarget{
+									Type:         autoscaling.AverageValueMetricType,
+									AverageValue: resource.NewQuantity(100, resource.DecimalSI),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// setQueue sets the queue of each pod
+func setQueue(h *Harness, pods []string, queue int64) {
+	values := map[string]int64{}
+	for _, pod := range pods {
+		values[pod] = queue * 1000
+	}
+	h.Metrics.SetPodsMetric("queue", values)
+}
+
+func TestMaxBlendScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "web"}
+	requests := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+	pods := h.AddPods("web", 4, podLabels, requests)
+	scale := Scale("web", 4, podLabels)
+	gpa := blendGPA(autoscaling.MaxMetricBlend, 1)
+
+	// the cpu is at the target while the queue is half of it
+	setCPU(h, pods, 700)
+	setQueue(h, pods, 50)
+	recommendation := h.AssertRecommendation(t, gpa, scale, time.Minute, 4)
+	assert.Contains(t, recommendation.MetricName, "cpu")
+
+	// the queue doubles its target and dominates
+	setQueue(h, pods, 200)
+	recommendation = h.AssertRecommendation(t, gpa, scale, time.Minute, 8)
+	assert.Contains(t, recommendation.MetricName, "queue")
+
+	// the new pods drain the queue, but the cpu doubles its target and dominates in turn
+	pods = append(pods, h.AddPods("web", 4, podLabels, requests)...)
+	setCPU(h, pods, 1400)
+	setQueue(h, pods, 100)
+	recommendation = h.AssertRecommendation(t, gpa, scale, time.Minute, 16)
+	assert.Contains(t, recommendation.MetricName, "cpu")
+}
+
+func TestAverageBlendScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "web"}
+	requests := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+	pods := h.AddPods("web", 4, podLabels, requests)
+	scale := Scale("web", 4, podLabels)
+	gpa := blendGPA(autoscaling.AverageMetricBlend, 1)
+
+	// the cpu is 150% of its target and the queue 50%, they average to the target
+	setCPU(h, pods, 1050)
+	setQueue(h, pods, 50)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 4)
+
+	// the queue is 250% of its target, the average doubles the replicas
+	setQueue(h, pods, 250)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 8)
+
+	// the cpu weighs 3 times the queue, (3 * 1.5 + 0.5) / 4 = 1.25 of the target
+	pods = append(pods, h.AddPods("web", 4, podLabels, requests)...)
+	setCPU(h, pods, 1050)
+	setQueue(h, pods, 50)
+	h.AssertRecommendation(t, blendGPA(autoscaling.AverageMetricBlend, 3), scale, time.Minute, 10)
+}
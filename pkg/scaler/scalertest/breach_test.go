@@ -0,0 +1,60 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func TestBreachDurationScenario(t *testing.T) {
+	// no stabilization window, so that only the breach duration holds the replicas
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "web"}
+	pods := h.AddPods("web", 3, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("web", 3, podLabels)
+	gpa := multiMetricGPA(nil)
+	duration := int32(120)
+	gpa.Spec.MetricMode.BreachDurationSeconds = &duration
+
+	// the cpu is at the target, the queue proposes 60/30 = 2 replicas
+	setCPU(h, pods, 500)
+	h.Metrics.SetExternalMetric("queue_length", 60000)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 3)
+	assert.Nil(t, gpa.Status.MetricBreach)
+
+	// a brief spike of the queue to 180/30 = 6 replicas is held
+	h.Metrics.SetExternalMetric("queue_length", 180000)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 3)
+	if assert.NotNil(t, gpa.Status.MetricBreach) {
+		assert.Equal(t, autoscaling.MetricBreachUp, gpa.Status.MetricBreach.Direction)
+	}
+	// and the breach is dropped once the queue is back
+	h.Metrics.SetExternalMetric("queue_length", 60000)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 3)
+	assert.Nil(t, gpa.Status.MetricBreach)
+
+	// a sustained breach scales the target once it lasts the duration
+	h.Metrics.SetExternalMetric("queue_length", 180000)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 3)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 3)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+}
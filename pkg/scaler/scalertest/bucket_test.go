@@ -0,0 +1,108 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func bucketTableGPA(buckets ...autoscaling.MetricBucket) *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: Namespace},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    20,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{
+						{
+							Type: autoscaling.BucketTableMetricSourceType,
+							BucketTable: &autoscaling.BucketTableMetricSource{
+								Metric:  autoscaling.MetricIdentifier{Name: "qps"},
+								Buckets: buckets,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func bucket(from string, to string, replicas int32) autoscaling.MetricBucket {
+	b := autoscaling.MetricBucket{From: resource.MustParse(from), Replicas: replicas}
+	if to != "" {
+		upper := resource.MustParse(to)
+		b.To = &upper
+	}
+	return b
+}
+
+func TestBucketTableMetricScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "web"}
+	h.AddPods("web", 2, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("web", 2, podLabels)
+	gpa := bucketTableGPA(bucket("0", "100", 2), bucket("100", "500", 5), bucket("500", "", 10))
+
+	// the lower bound of a bucket is in it, the upper bound is in the next one. The replicas of the bucket are
+	// proposed as is, while the behavior still limits how fast the target is scaled to them.
+	for _, c := range []struct {
+		milliQPS int64
+		expected int32
+		bucket   int32
+	}{
+		{milliQPS: 0, expected: 2, bucket: 0},
+		{milliQPS: 99999, expected: 2, bucket: 0},
+		{milliQPS: 100000, expected: 5, bucket: 1},
+		{milliQPS: 499999, expected: 5, bucket: 1},
+		{milliQPS: 500000, expected: 10, bucket: 2},
+		{milliQPS: 100000000, expected: 10, bucket: 2},
+		{milliQPS: 99000, expected: 2, bucket: 0},
+	} {
+		h.Metrics.SetExternalMetric("qps", c.milliQPS)
+		recommendation := h.Step(t, gpa, scale, time.Minute)
+		assert.Equal(t, c.expected, recommendation.ProposedReplicas, "qps %dm", c.milliQPS)
+		status := recommendation.MetricStatuses[0].BucketTable
+		assert.Equal(t, c.bucket, status.Bucket, "qps %dm", c.milliQPS)
+		assert.Equal(t, c.milliQPS, status.Current.Value.MilliValue())
+	}
+}
+
+func TestBucketTableMetricOutsideOfBuckets(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "web"}
+	h.AddPods("web", 3, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("web", 3, podLabels)
+	gpa := bucketTableGPA(bucket("10", "100", 2), bucket("100", "500", 5))
+
+	for _, milliQPS := range []int64{9999, 500000} {
+		h.Metrics.SetExternalMetric("qps", milliQPS)
+		_, err := h.Engine.Recommend(gpa, gpa.Namespace+"/"+gpa.Name, scale)
+		assert.Error(t, err, "qps %dm is outside of the buckets", milliQPS)
+	}
+	h.Metrics.SetExternalMetric("qps", 10000)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 2)
+}
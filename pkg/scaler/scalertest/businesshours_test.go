@@ -0,0 +1,92 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// businessHoursGPA scales by the queue length with 30 per pod on weekdays from 9:00 to 17:59, and pins the target to
+// 2 replicas off hours
+func businessHoursGPA() *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: Namespace},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "worker"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    10,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{
+						{
+							Type: autoscaling.ExternalMetricSourceType,
+							External: &autoscaling.ExternalMetricSource{
+								Metric: autoscaling.MetricIdentifier{Name: "queue_length"},
+								Target: autoscaling.MetricTarget{
+									Type:         autoscaling.AverageValueMetricType,
+									AverageValue: resource.NewQuantity(30, resource.DecimalSI),
+								},
+							},
+						},
+					},
+					Window: &autoscaling.MetricWindow{Schedule: "* 9-17 * * 1-5", OffHoursReplicas: 2},
+				},
+			},
+		},
+	}
+}
+
+func TestBusinessHoursScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	// a tuesday
+	h.Clock.SetTime(time.Date(2021, 6, 1, 10, 30, 0, 0, time.Local))
+	podLabels := map[string]string{"app": "worker"}
+	h.AddPods("worker", 3, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("worker", 3, podLabels)
+	gpa := businessHoursGPA()
+
+	// in the business hours, the metrics drive the scaling
+	h.Metrics.SetExternalMetric("queue_length", 180000)
+	recommendation := h.AssertRecommendation(t, gpa, scale, time.Second, 6)
+	assert.Contains(t, recommendation.MetricName, "queue_length")
+
+	// off hours, the target is pinned whatever the metrics are
+	h.Metrics.SetExternalMetric("queue_length", 300000)
+	h.Clock.SetTime(time.Date(2021, 6, 1, 18, 0, 0, 0, time.Local))
+	recommendation = h.AssertRecommendation(t, gpa, scale, time.Second, 2)
+	assert.Contains(t, recommendation.MetricName, "off-hours")
+	for _, cond := range gpa.Status.Conditions {
+		if cond.Type == autoscaling.ScalingActive {
+			assert.Equal(t, "OutOfMetricWindow", cond.Reason)
+		}
+	}
+
+	// and on the weekends
+	h.Clock.SetTime(time.Date(2021, 6, 5, 11, 0, 0, 0, time.Local))
+	h.AssertRecommendation(t, gpa, scale, time.Second, 2)
+
+	// back in the business hours on monday, the scale up is limited to twice the 2 replicas
+	h.Clock.SetTime(time.Date(2021, 6, 7, 9, 0, 0, 0, time.Local))
+	h.AssertRecommendation(t, gpa, scale, time.Second, 4)
+}
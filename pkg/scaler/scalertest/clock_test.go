@@ -0,0 +1,56 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func TestTimeRangeAcrossFakeClock(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	h.Clock.SetTime(time.Date(2021, 6, 1, 9, 58, 0, 0, time.Local))
+	podLabels := map[string]string{"app": "worker"}
+	h.AddPods("worker", 2, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("worker", 2, podLabels)
+	minReplicas := int32(2)
+	gpa := &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: Namespace, CreationTimestamp: metav1.NewTime(h.Clock.Now().Add(-time.Hour))},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "worker"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    10,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				TimeMode: &autoscaling.TimeMode{
+					TimeRanges: []autoscaling.TimeRange{{Schedule: "*/1 10-11 * * *", DesiredReplicas: 4}},
+				},
+			},
+		},
+	}
+
+	// before the range the min replicas apply
+	h.AssertRecommendation(t, gpa, scale, time.Second, 2)
+	// the clock crosses into the range
+	h.AssertRecommendation(t, gpa, scale, 2*time.Minute, 4)
+	h.AssertRecommendation(t, gpa, scale, time.Hour, 4)
+	// and out of it
+	h.AssertRecommendation(t, gpa, scale, time.Hour, 2)
+}
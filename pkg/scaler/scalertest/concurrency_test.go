@@ -0,0 +1,104 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func meshGPA() *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: Namespace},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "frontend"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    20,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{
+						{
+							Type: autoscaling.ConcurrencyMetricSourceType,
+							Concurrency: &autoscaling.ConcurrencyMetricSource{
+								Metric: autoscaling.MetricIdentifier{Name: "envoy_active_requests"},
+								Target: autoscaling.MetricTarget{
+									Type:         autoscaling.AverageValueMetricType,
+									AverageValue: resource.NewQuantity(10, resource.DecimalSI),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// setActiveRequests sets the in-flight requests of each pod
+func setActiveRequests(h *Harness, pods []string, requests int64) {
+	values := map[string]int64{}
+	for _, pod := range pods {
+		values[pod] = requests * 1000
+	}
+	h.Metrics.SetPodsMetric("envoy_active_requests", values)
+}
+
+func TestConcurrencyMetricScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "frontend"}
+	pods := h.AddPods("frontend", 4, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("frontend", 4, podLabels)
+	gpa := meshGPA()
+
+	// 15 in-flight requests per pod against the target of 10, scale up to 60 / 10 replicas
+	setActiveRequests(h, pods, 15)
+	recommendation := h.AssertRecommendation(t, gpa, scale, time.Second, 6)
+	status := recommendation.MetricStatuses[0]
+	assert.Equal(t, autoscaling.ConcurrencyMetricSourceType, status.Type)
+	assert.Equal(t, int32(4), status.Concurrency.ReadyPods)
+	assert.Equal(t, int64(60), status.Concurrency.Current.Value.Value())
+	assert.Equal(t, int64(15), status.Concurrency.Current.AverageValue.Value())
+	assert.Contains(t, recommendation.MetricName, "envoy_active_requests")
+
+	// the new pods are up and the requests are spread at the target, the replicas are kept
+	pods = append(pods, h.AddPods("frontend", 2, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})...)
+	setActiveRequests(h, pods, 10)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+
+	// two pods turn unready and still report stale requests, only the 4 ready pods are counted
+	h.SetPodReady(pods[4], false)
+	h.SetPodReady(pods[5], false)
+	setActiveRequests(h, pods, 20)
+	recommendation = h.AssertRecommendation(t, gpa, scale, time.Minute, 8)
+	assert.Equal(t, int32(4), recommendation.MetricStatuses[0].Concurrency.ReadyPods)
+
+	// no pod is ready, the requests cannot be computed
+	for _, pod := range pods {
+		h.SetPodReady(pod, false)
+	}
+	h.Clock.Step(time.Minute)
+	_, err := h.Engine.Recommend(gpa, gpa.Namespace+"/"+gpa.Name, scale)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no ready pods")
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// conflictGPA scales by the queue length with 30 per pod, and to 5 replicas from 10:00 to 12:59
+func conflictGPA(policy autoscaling.ConflictPolicy, created time.Time) *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: Namespace, CreationTimestamp: metav1.NewTime(created)},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "worker"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    10,
+			ConflictPolicy: policy,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{
+						{
+							Type: autoscaling.ExternalMetricSourceType,
+							External: &autoscaling.ExternalMetricSource{
+								Metric: autoscaling.MetricIdentifier{Name: "queue_length"},
+								Target: autoscaling.MetricTarget{
+									Type:         autoscaling.AverageValueMetricType,
+									AverageValue: resource.NewQuantity(30, resource.DecimalSI),
+								},
+							},
+						},
+					},
+				},
+				TimeMode: &autoscaling.TimeMode{
+					TimeRanges: []autoscaling.TimeRange{{Schedule: "*/1 10-12 * * *", DesiredReplicas: 5}},
+				},
+			},
+		},
+	}
+}
+
+func TestConflictPolicyScenario(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   autoscaling.ConflictPolicy
+		queue    int64
+		expected int32
+		winner   autoscaling.ConflictWinner
+	}{
+		{name: "no policy ignores the time range", queue: 180000, expected: 6},
+		{name: "cron wins", policy: autoscaling.CronWinsConflictPolicy, queue: 180000, expected: 5,
+			winner: autoscaling.CronConflictWinner},
+		{name: "metric wins", policy: autoscaling.MetricWinsConflictPolicy, queue: 120000, expected: 4,
+			winner: autoscaling.MetricConflictWinner},
+		{name: "max of a larger metric", policy: autoscaling.MaxConflictPolicy, queue: 180000, expected: 6,
+			winner: autoscaling.MetricConflictWinner},
+		{name: "max of a larger time range", policy: autoscaling.MaxConflictPolicy, queue: 120000, expected: 5,
+			winner: autoscaling.CronConflictWinner},
+		{name: "min of a larger metric", policy: autoscaling.MinConflictPolicy, queue: 180000, expected: 5,
+			winner: autoscaling.CronConflictWinner},
+		{name: "min of a larger time range", policy: autoscaling.MinConflictPolicy, queue: 120000, expected: 4,
+			winner: autoscaling.MetricConflictWinner},
+		{name: "no conflict on the same replicas", policy: autoscaling.CronWinsConflictPolicy, queue: 150000, expected: 5},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHarness(0.1, 0)
+			h.Clock.SetTime(time.Date(2021, 6, 1, 10, 30, 0, 0, time.Local))
+			podLabels := map[string]string{"app": "worker"}
+			h.AddPods("worker", 3, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+			scale := Scale("worker", 3, podLabels)
+			gpa := conflictGPA(tc.policy, h.Clock.Now().Add(-time.Hour))
+
+			h.Metrics.SetExternalMetric("queue_length", tc.queue)
+			recommendation := h.AssertRecommendation(t, gpa, scale, time.Second, tc.expected)
+			assert.Equal(t, tc.winner, gpa.Status.ConflictWinner)
+			if tc.winner == autoscaling.CronConflictWinner {
+				assert.Contains(t, recommendation.MetricName, "time range")
+			} else {
+				assert.Contains(t, recommendation.MetricName, "queue_length")
+			}
+		})
+	}
+}
+
+func TestConflictPolicyOutOfTimeRange(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	h.Clock.SetTime(time.Date(2021, 6, 1, 14, 0, 0, 0, time.Local))
+	podLabels := map[string]string{"app": "worker"}
+	h.AddPods("worker", 3, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("worker", 3, podLabels)
+	gpa := conflictGPA(autoscaling.CronWinsConflictPolicy, h.Clock.Now().Add(-5*time.Hour))
+	gpa.Status.ConflictWinner = autoscaling.CronConflictWinner
+
+	// the metrics apply out of the time range, and the last winner is cleared
+	h.Metrics.SetExternalMetric("queue_length", 180000)
+	h.AssertRecommendation(t, gpa, scale, time.Second, 6)
+	assert.Empty(t, gpa.Status.ConflictWinner)
+}
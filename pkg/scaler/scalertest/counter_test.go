@@ -0,0 +1,99 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func counterDeltaGPA() *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	interval := int32(60)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: Namespace},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "worker"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    20,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{
+						{
+							Type: autoscaling.CounterDeltaMetricSourceType,
+							CounterDelta: &autoscaling.CounterDeltaMetricSource{
+								Metric: autoscaling.MetricIdentifier{Name: "jobs_submitted_total"},
+								Target: autoscaling.MetricTarget{
+									Type:         autoscaling.AverageValueMetricType,
+									AverageValue: resource.NewQuantity(10, resource.DecimalSI),
+								},
+								IntervalSeconds: &interval,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestCounterDeltaMetricScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "worker"}
+	h.AddPods("worker", 4, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("worker", 4, podLabels)
+	gpa := counterDeltaGPA()
+
+	// the counter is sampled once, the replicas are kept
+	h.Metrics.SetExternalMetric("jobs_submitted_total", 1000000)
+	h.AssertRecommendation(t, gpa, scale, time.Second, 4)
+
+	// 60 jobs were submitted in the last minute, scale up to 60 / 10 replicas
+	h.Metrics.SetExternalMetric("jobs_submitted_total", 1060000)
+	recommendation := h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+	status := recommendation.MetricStatuses[0].CounterDelta
+	assert.Equal(t, int64(60), status.Current.Value.Value())
+	assert.Equal(t, int64(15), status.Current.AverageValue.Value())
+
+	// only the increase within the last minute counts
+	h.Metrics.SetExternalMetric("jobs_submitted_total", 1100000)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 4)
+}
+
+func TestCounterDeltaMetricReset(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "worker"}
+	h.AddPods("worker", 2, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("worker", 2, podLabels)
+	gpa := counterDeltaGPA()
+
+	h.Metrics.SetExternalMetric("jobs_submitted_total", 1000000)
+	h.AssertRecommendation(t, gpa, scale, time.Second, 2)
+
+	// the counter restarted from 0, the 30 jobs since the restart are counted instead of a negative delta
+	h.Metrics.SetExternalMetric("jobs_submitted_total", 30000)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 3)
+
+	// the counter increases from the restarted value
+	h.Metrics.SetExternalMetric("jobs_submitted_total", 80000)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 5)
+}
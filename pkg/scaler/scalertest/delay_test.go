@@ -0,0 +1,64 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func TestScaleDownDelayAfterScaleUpScenario(t *testing.T) {
+	delay := int32(300)
+	for _, c := range []struct {
+		name  string
+		delay *int32
+		// expected are the replicas one and five minutes after the dip
+		expected []int32
+	}{
+		{name: "no delay", expected: []int32{3, 3}},
+		{name: "delayed", delay: &delay, expected: []int32{6, 3}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			// no stabilization window, so that only the delay holds the replicas
+			h := NewHarness(0.1, 0)
+			podLabels := map[string]string{"app": "web"}
+			requests := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+			pods := h.AddPods("web", 3, podLabels, requests)
+			scale := Scale("web", 3, podLabels)
+			gpa := multiMetricGPA(&autoscaling.GeneralPodAutoscalerBehavior{
+				ScaleDownDelayAfterScaleUpSeconds: c.delay,
+			})
+
+			// the queue proposes 180/30 = 6 replicas
+			setCPU(h, pods, 500)
+			h.Metrics.SetExternalMetric("queue_length", 180000)
+			h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+			assert.NotNil(t, gpa.Status.LastScaleUpTime)
+
+			// the load dips right after the scale-up, both metrics propose 3 replicas
+			pods = append(pods, h.AddPods("web", 3, podLabels, requests)...)
+			setCPU(h, pods, 200)
+			h.Metrics.SetExternalMetric("queue_length", 60000)
+			h.AssertRecommendation(t, gpa, scale, time.Minute, c.expected[0])
+			h.AssertRecommendation(t, gpa, scale, 4*time.Minute, c.expected[1])
+		})
+	}
+}
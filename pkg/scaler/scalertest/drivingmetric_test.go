@@ -0,0 +1,71 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestDrivingMetricScenario(t *testing.T) {
+	h := NewHarness(0.1, 5*time.Minute)
+	podLabels := map[string]string{"app": "web"}
+	requests := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+	pods := h.AddPods("web", 3, podLabels, requests)
+	scale := Scale("web", 3, podLabels)
+	gpa := multiMetricGPA(nil)
+	gpa.Annotations = map[string]string{"autoscaling.ocgi.io/record-driving-metric": "true"}
+
+	// cpu at 90% proposes 3*90/50 = 6 replicas, the queue only 30/30 = 1
+	setCPU(h, pods, 900)
+	h.Metrics.SetExternalMetric("queue_length", 30000)
+	recommendation := h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+	driving := gpa.Status.DrivingMetric
+	if assert.NotNil(t, driving) {
+		assert.Equal(t, int32(0), driving.Index)
+		assert.Equal(t, recommendation.MetricName, driving.Name)
+		assert.Equal(t, int32(6), driving.Replicas)
+		assert.Equal(t, h.Clock.Now(), driving.LastRecordTime.Time)
+		assert.Equal(t, *recommendation.MetricStatuses[0].Resource.Current.AverageUtilization,
+			*driving.Current.AverageUtilization)
+		assert.Equal(t, int32(90), *driving.Current.AverageUtilization)
+		assert.Equal(t, int64(900), driving.Current.AverageValue.MilliValue())
+		assert.Equal(t, int32(50), *driving.Target.AverageUtilization)
+	}
+
+	// the queue of 360 over the 6 pods proposes 12 replicas, above the cpu at the target
+	pods = append(pods, h.AddPods("web", 3, podLabels, requests)...)
+	setCPU(h, pods, 500)
+	h.Metrics.SetExternalMetric("queue_length", 360000)
+	recommendation = h.AssertRecommendation(t, gpa, scale, time.Minute, 10)
+	driving = gpa.Status.DrivingMetric
+	if assert.NotNil(t, driving) {
+		assert.Equal(t, int32(1), driving.Index)
+		assert.Contains(t, driving.Name, "queue_length")
+		assert.Equal(t, int32(12), driving.Replicas)
+		assert.True(t, recommendation.MetricStatuses[1].External.Current.AverageValue.Equal(*driving.Current.AverageValue))
+		assert.Equal(t, int64(60), driving.Current.AverageValue.Value())
+		assert.Equal(t, int64(30), driving.Target.AverageValue.Value())
+	}
+
+	// once the annotation is removed the driving metric is cleared
+	gpa.Annotations = nil
+	h.Step(t, gpa, scale, time.Minute)
+	assert.Nil(t, gpa.Status.DrivingMetric)
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// cpuGPA scales by the cpu utilization with 50% per pod, smoothed with the half-life if it is set
+func cpuGPA(name string, halfLifeSeconds *int32) *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	utilization := int32(50)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: Namespace},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    10,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{
+						{
+							Type: autoscaling.ResourceMetricSourceType,
+							Resource: &autoscaling.ResourceMetricSource{
+								Name: v1.ResourceCPU,
+								Target: autoscaling.MetricTarget{
+									Type:               autoscaling.UtilizationMetricType,
+									AverageUtilization: &utilization,
+								},
+								HalfLifeSeconds: halfLifeSeconds,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestEWMAUtilizationScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "web"}
+	pods := h.AddPods("web", 3, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	halfLife := int32(300)
+	instant, instantScale := cpuGPA("instant", nil), Scale("web", 3, podLabels)
+	smoothed, smoothedScale := cpuGPA("smoothed", &halfLife), Scale("web", 3, podLabels)
+	step := func(elapsed time.Duration, instantReplicas, smoothedReplicas int32) {
+		t.Helper()
+		h.AssertRecommendation(t, instant, instantScale, elapsed, instantReplicas)
+		h.AssertRecommendation(t, smoothed, smoothedScale, 0, smoothedReplicas)
+	}
+
+	// the first usage is the average as is
+	setCPU(h, pods, 400)
+	step(time.Minute, 3, 3)
+
+	// a one minute spike to 90% doubles the instantaneous replicas, while the average only moves by 13% of it:
+	// 400 + (1 - 2^(-60/300)) * 500 = 465m, within the tolerance of the target
+	setCPU(h, pods, 900)
+	recommendation := h.Step(t, instant, instantScale, time.Minute)
+	assert.Equal(t, int32(6), recommendation.DesiredReplicas)
+	recommendation = h.Step(t, smoothed, smoothedScale, 0)
+	assert.Equal(t, int32(3), recommendation.DesiredReplicas)
+	if assert.Len(t, recommendation.MetricStatuses, 1) {
+		assert.Equal(t, int32(46), *recommendation.MetricStatuses[0].Resource.Current.AverageUtilization)
+	}
+
+	// the spike is over, the average decays back
+	setCPU(h, pods, 400)
+	step(time.Minute, 3, 3)
+	step(time.Minute, 3, 3)
+
+	// a sustained load is still followed within a fraction of the half-life, the average decayed to 450m is
+	// 508m after a minute, then 559m beyond the tolerance of the target
+	setCPU(h, pods, 900)
+	step(time.Minute, 6, 3)
+	step(time.Minute, 6, 4)
+	step(time.Minute, 6, 4)
+}
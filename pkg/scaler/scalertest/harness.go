@@ -0,0 +1,217 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scalertest drives the decisions of the GPA controller through scenarios with fake metrics,
+// pods and clock, so that metric sources and behaviors can be tested without a cluster.
+package scalertest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	autoscalinginternal "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	autoscalinglisters "github.com/ocgi/general-pod-autoscaler/pkg/client/listers/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scaler"
+)
+
+// Namespace is the namespace of the pods and GPAs of the scenarios
+const Namespace = "default"
+
+// Harness runs a scaler.DecisionEngine with a FakeMetricsClient, fake pod, node and GPA listers and a fake clock.
+type Harness struct {
+	Clock   *clock.FakeClock
+	Metrics *FakeMetricsClient
+	Engine  *scaler.DecisionEngine
+
+	pods  cache.Indexer
+	nodes cache.Indexer
+	gpas  cache.Indexer
+}
+
+// NewHarness creates a Harness with the tolerance and the downscale stabilization window of the controller.
+func NewHarness(tolerance float64, downscaleStabilisationWindow time.Duration) *Harness {
+	fakeClock := clock.NewFakeClock(time.Now())
+	metrics := NewFakeMetricsClient(fakeClock)
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	engine := scaler.NewDecisionEngine(metrics, corelisters.NewPodLister(pods), nil, &record.FakeRecorder{}, fakeClock,
+		tolerance, downscaleStabilisationWindow, 0, 0)
+	nodes := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	engine.SetClusterNodeLister(corelisters.NewNodeLister(nodes))
+	gpas := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	engine.SetGPALister(autoscalinglisters.NewGeneralPodAutoscalerLister(gpas))
+	return &Harness{
+		Clock:   fakeClock,
+		Metrics: metrics,
+		Engine:  engine,
+		pods:    pods,
+		nodes:   nodes,
+		gpas:    gpas,
+	}
+}
+
+// AddPods adds count running and ready pods with the labels and the requests, created and started an hour ago.
+// The pods are named <prefix>-<index> and their names are returned.
+func (h *Harness) AddPods(prefix string, count int, podLabels map[string]string, requests v1.ResourceList) []string {
+	startTime := metav1.NewTime(h.Clock.Now().Add(-time.Hour))
+	var names []string
+	for i := 0; len(names) < count; i++ {
+		if _, exists, _ := h.pods.GetByKey(fmt.Sprintf("%s/%s-%d", Namespace, prefix, i)); exists {
+			continue
+		}
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              fmt.Sprintf("%s-%d", prefix, i),
+				Namespace:         Namespace,
+				Labels:            podLabels,
+				CreationTimestamp: startTime,
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{{
+					Name:      "container",
+					Resources: v1.ResourceRequirements{Requests: requests},
+				}},
+			},
+			Status: v1.PodStatus{
+				Phase:     v1.PodRunning,
+				StartTime: &startTime,
+				Conditions: []v1.PodCondition{{
+					Type:               v1.PodReady,
+					Status:             v1.ConditionTrue,
+					LastTransitionTime: startTime,
+				}},
+			},
+		}
+		h.pods.Add(pod)
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+// RemovePod removes the pod of the name, e.g. when it is recycled.
+func (h *Harness) RemovePod(name string) {
+	h.pods.Delete(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: Namespace}})
+}
+
+// SetPodReady sets the ready condition of the pod of the name.
+func (h *Harness) SetPodReady(name string, ready bool) {
+	obj, exists, _ := h.pods.GetByKey(fmt.Sprintf("%s/%s", Namespace, name))
+	if !exists {
+		return
+	}
+	pod := obj.(*v1.Pod).DeepCopy()
+	pod.Status.Conditions[0].Status = v1.ConditionFalse
+	if ready {
+		pod.Status.Conditions[0].Status = v1.ConditionTrue
+	}
+	pod.Status.Conditions[0].LastTransitionTime = metav1.NewTime(h.Clock.Now())
+	h.pods.Update(pod)
+}
+
+// SetPodPort sets the IP of the pod of the name, and adds the port to its container.
+func (h *Harness) SetPodPort(name, ip string, port v1.ContainerPort) {
+	obj, exists, _ := h.pods.GetByKey(fmt.Sprintf("%s/%s", Namespace, name))
+	if !exists {
+		return
+	}
+	pod := obj.(*v1.Pod).DeepCopy()
+	pod.Status.PodIP = ip
+	pod.Spec.Containers[0].Ports = append(pod.Spec.Containers[0].Ports, port)
+	h.pods.Update(pod)
+}
+
+// AddNodes adds count schedulable nodes with the allocatable resources, the nodes are named <prefix>-<index>
+// and their names are returned.
+func (h *Harness) AddNodes(prefix string, count int, allocatable v1.ResourceList) []string {
+	var names []string
+	for i := 0; len(names) < count; i++ {
+		name := fmt.Sprintf("%s-%d", prefix, i)
+		if _, exists, _ := h.nodes.GetByKey(name); exists {
+			continue
+		}
+		h.nodes.Add(&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     v1.NodeStatus{Allocatable: allocatable},
+		})
+		names = append(names, name)
+	}
+	return names
+}
+
+// RemoveNode removes the node of the name.
+func (h *Harness) RemoveNode(name string) {
+	h.nodes.Delete(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+}
+
+// SetGPA adds the GPA to be mirrored by the GPAs in mirror mode, or replaces it by the name.
+func (h *Harness) SetGPA(gpa *autoscaling.GeneralPodAutoscaler) {
+	h.gpas.Update(gpa)
+}
+
+// RemoveGPA removes the GPA of the name.
+func (h *Harness) RemoveGPA(name string) {
+	h.gpas.Delete(&autoscaling.GeneralPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: Namespace}})
+}
+
+// Scale returns the scale of a target with the replicas, the target selects the pods by the labels.
+func Scale(name string, replicas int32, podLabels map[string]string) *autoscalinginternal.Scale {
+	return &autoscalinginternal.Scale{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: Namespace},
+		Spec:       autoscalinginternal.ScaleSpec{Replicas: replicas},
+		Status: autoscalinginternal.ScaleStatus{
+			Replicas: replicas,
+			Selector: labels.SelectorFromSet(podLabels).String(),
+		},
+	}
+}
+
+// Step advances the clock by elapsed and recommends the replicas of the GPA. If the recommendation
+// changes the replicas, the scale is updated and the scale event is recorded as the controller does.
+func (h *Harness) Step(t *testing.T, gpa *autoscaling.GeneralPodAutoscaler, scale *autoscalinginternal.Scale,
+	elapsed time.Duration) scaler.Recommendation {
+	t.Helper()
+	h.Clock.Step(elapsed)
+	key := gpa.Namespace + "/" + gpa.Name
+	recommendation, err := h.Engine.Recommend(gpa, key, scale)
+	if err != nil {
+		t.Fatalf("GPA %s failed to recommend replicas: %v", key, err)
+	}
+	if recommendation.DesiredReplicas != scale.Spec.Replicas {
+		h.Engine.RecordScale(gpa, key, scale.Spec.Replicas, recommendation.DesiredReplicas)
+		scale.Spec.Replicas = recommendation.DesiredReplicas
+		scale.Status.Replicas = recommendation.DesiredReplicas
+	}
+	return recommendation
+}
+
+// AssertRecommendation runs a Step and asserts the desired replicas of the recommendation.
+func (h *Harness) AssertRecommendation(t *testing.T, gpa *autoscaling.GeneralPodAutoscaler,
+	scale *autoscalinginternal.Scale, elapsed time.Duration, expected int32) scaler.Recommendation {
+	t.Helper()
+	recommendation := h.Step(t, gpa, scale, elapsed)
+	if recommendation.DesiredReplicas != expected {
+		t.Errorf("GPA %s/%s recommended %d replicas (%s), expected %d", gpa.Namespace, gpa.Name,
+			recommendation.DesiredReplicas, recommendation.MetricName, expected)
+	}
+	return recommendation
+}
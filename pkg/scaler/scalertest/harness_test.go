@@ -0,0 +1,188 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func multiMetricGPA(behavior *autoscaling.GeneralPodAutoscalerBehavior) *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	utilization := int32(50)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: Namespace},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    10,
+			Behavior:       behavior,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{
+						{
+							Type: autoscaling.ResourceMetricSourceType,
+							Resource: &autoscaling.ResourceMetricSource{
+								Name: v1.ResourceCPU,
+								Target: autoscaling.MetricTarget{
+									Type:               autoscaling.UtilizationMetricType,
+									AverageUtilization: &utilization,
+								},
+							},
+						},
+						{
+							Type: autoscaling.ExternalMetricSourceType,
+							External: &autoscaling.ExternalMetricSource{
+								Metric: autoscaling.MetricIdentifier{Name: "queue_length"},
+								Target: autoscaling.MetricTarget{
+									Type:         autoscaling.AverageValueMetricType,
+									AverageValue: resource.NewQuantity(30, resource.DecimalSI),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// setCPU sets the cpu usage of each pod in milli cores
+func setCPU(h *Harness, pods []string, milliCores int64) {
+	values := map[string]int64{}
+	for _, pod := range pods {
+		values[pod] = milliCores
+	}
+	h.Metrics.SetResourceMetric(v1.ResourceCPU, values)
+}
+
+func TestMultiMetricScenario(t *testing.T) {
+	h := NewHarness(0.1, 5*time.Minute)
+	podLabels := map[string]string{"app": "web"}
+	requests := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+	pods := h.AddPods("web", 3, podLabels, requests)
+	scale := Scale("web", 3, podLabels)
+	gpa := multiMetricGPA(nil)
+
+	// cpu is at the target, the queue proposes 180/30 = 6 replicas
+	setCPU(h, pods, 500)
+	h.Metrics.SetExternalMetric("queue_length", 180000)
+	recommendation := h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+	assert.Contains(t, recommendation.MetricName, "queue_length")
+	assert.Len(t, recommendation.MetricStatuses, 2)
+
+	// the new pods are started, both metrics drop but the scale down is stabilized
+	pods = append(pods, h.AddPods("web", 3, podLabels, requests)...)
+	setCPU(h, pods, 200)
+	h.Metrics.SetExternalMetric("queue_length", 60000)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+	h.AssertRecommendation(t, gpa, scale, 2*time.Minute, 6)
+
+	// once the stabilization window passes, the highest proposal wins, cpu proposes 6*200/500 = 3 replicas
+	h.AssertRecommendation(t, gpa, scale, 4*time.Minute, 3)
+}
+
+func TestMultiMetricScenarioWithBehavior(t *testing.T) {
+	h := NewHarness(0.1, 5*time.Minute)
+	podLabels := map[string]string{"app": "web"}
+	requests := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+	pods := h.AddPods("web", 2, podLabels, requests)
+	scale := Scale("web", 2, podLabels)
+	selectMax := autoscaling.MaxPolicySelect
+	stabilization := int32(0)
+	gpa := multiMetricGPA(&autoscaling.GeneralPodAutoscalerBehavior{
+		ScaleUp: &autoscaling.GPAScalingRules{
+			StabilizationWindowSeconds: &stabilization,
+			SelectPolicy:               &selectMax,
+			Policies:                   []autoscaling.GPAScalingPolicy{{Type: autoscaling.PodsScalingPolicy, Value: 2, PeriodSeconds: 60}},
+		},
+		ScaleDown: &autoscaling.GPAScalingRules{
+			StabilizationWindowSeconds: &stabilization,
+			SelectPolicy:               &selectMax,
+			Policies:                   []autoscaling.GPAScalingPolicy{{Type: autoscaling.PodsScalingPolicy, Value: 1, PeriodSeconds: 60}},
+		},
+	})
+
+	// cpu proposes 2*1000/500 = 4 replicas and the queue proposes 300/30 = 10, scale up by 2 pods per minute
+	setCPU(h, pods, 1000)
+	h.Metrics.SetExternalMetric("queue_length", 300000)
+	h.AssertRecommendation(t, gpa, scale, time.Second, 4)
+	// the scale up is limited within the same period
+	h.AssertRecommendation(t, gpa, scale, 30*time.Second, 4)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+
+	// the queue is drained, scale down by 1 pod per minute
+	pods = append(pods, h.AddPods("web", 4, podLabels, requests)...)
+	setCPU(h, pods, 100)
+	h.Metrics.SetExternalMetric("queue_length", 0)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 5)
+	h.AssertRecommendation(t, gpa, scale, 30*time.Second, 5)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 4)
+}
+
+func TestPartialMetricScenario(t *testing.T) {
+	h := NewHarness(0.1, 5*time.Minute)
+	podLabels := map[string]string{"app": "web"}
+	pods := h.AddPods("web", 3, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("web", 3, podLabels)
+	gpa := multiMetricGPA(nil)
+	gpa.Spec.MetricMode.Metrics = append(gpa.Spec.MetricMode.Metrics, autoscaling.MetricSpec{
+		Type: autoscaling.PodsMetricSourceType,
+		Pods: &autoscaling.PodsMetricSource{
+			Metric: autoscaling.MetricIdentifier{Name: "qps"},
+			Target: autoscaling.MetricTarget{
+				Type:         autoscaling.AverageValueMetricType,
+				AverageValue: resource.NewQuantity(10, resource.DecimalSI),
+			},
+		},
+	})
+	partialCondition := func() *autoscaling.GeneralPodAutoscalerCondition {
+		for i := range gpa.Status.Conditions {
+			if gpa.Status.Conditions[i].Type == autoscaling.PartialMetrics {
+				return &gpa.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	// the external metrics are down, cpu is at the target while the qps propose 3*20/10 = 6 replicas
+	setCPU(h, pods, 500)
+	qps := map[string]int64{}
+	for _, pod := range pods {
+		qps[pod] = 20000
+	}
+	h.Metrics.SetPodsMetric("qps", qps)
+	recommendation := h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+	assert.Contains(t, recommendation.MetricName, "qps")
+	if condition := partialCondition(); assert.NotNil(t, condition) {
+		assert.Equal(t, v1.ConditionTrue, condition.Status)
+		assert.Contains(t, condition.Message, "metric 1 (External) failed")
+		assert.Contains(t, condition.Message, "queue_length")
+	}
+
+	// once the external metrics are back, all the metrics are used again
+	h.Metrics.SetExternalMetric("queue_length", 300000)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 10)
+	if condition := partialCondition(); assert.NotNil(t, condition) {
+		assert.Equal(t, v1.ConditionFalse, condition.Status)
+	}
+}
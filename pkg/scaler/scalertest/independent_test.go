@@ -0,0 +1,104 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// queueGPA scales the deployment of the name by the queue metric with 30 per pod, it scales up by at most 2 pods
+// a minute and stabilizes the scale downs for 5 minutes
+func queueGPA(name, queue string) *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	selectMax := autoscaling.MaxPolicySelect
+	noStabilization := int32(0)
+	stabilization := int32(300)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: Namespace},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: name},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    10,
+			Behavior: &autoscaling.GeneralPodAutoscalerBehavior{
+				ScaleUp: &autoscaling.GPAScalingRules{
+					StabilizationWindowSeconds: &noStabilization,
+					SelectPolicy:               &selectMax,
+					Policies:                   []autoscaling.GPAScalingPolicy{{Type: autoscaling.PodsScalingPolicy, Value: 2, PeriodSeconds: 60}},
+				},
+				ScaleDown: &autoscaling.GPAScalingRules{
+					StabilizationWindowSeconds: &stabilization,
+					SelectPolicy:               &selectMax,
+					Policies:                   []autoscaling.GPAScalingPolicy{{Type: autoscaling.PercentScalingPolicy, Value: 100, PeriodSeconds: 15}},
+				},
+			},
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{
+						{
+							Type: autoscaling.ExternalMetricSourceType,
+							External: &autoscaling.ExternalMetricSource{
+								Metric: autoscaling.MetricIdentifier{Name: queue},
+								Target: autoscaling.MetricTarget{
+									Type:         autoscaling.AverageValueMetricType,
+									AverageValue: resource.NewQuantity(30, resource.DecimalSI),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestIndependentTargetsScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	requests := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+	h.AddPods("orders", 4, map[string]string{"app": "orders"}, requests)
+	h.AddPods("payments", 4, map[string]string{"app": "payments"}, requests)
+	orders, ordersScale := queueGPA("orders", "orders_queue"), Scale("orders", 4, map[string]string{"app": "orders"})
+	payments, paymentsScale := queueGPA("payments", "payments_queue"), Scale("payments", 4, map[string]string{"app": "payments"})
+
+	// payments is at its target, its recommendations leave the stabilization window
+	h.Metrics.SetExternalMetric("payments_queue", 120000)
+	h.AssertRecommendation(t, payments, paymentsScale, 0, 4)
+	h.Clock.Step(6 * time.Minute)
+
+	// orders proposes 240/30 = 8 replicas, limited to 2 more pods in a minute
+	h.Metrics.SetExternalMetric("orders_queue", 240000)
+	h.AssertRecommendation(t, orders, ordersScale, 0, 6)
+
+	// payments proposes 60/30 = 2 replicas, the higher recommendations of orders do not stabilize its scale down
+	h.Metrics.SetExternalMetric("payments_queue", 60000)
+	h.AssertRecommendation(t, payments, paymentsScale, 0, 2)
+
+	// payments proposes 120/30 = 4 replicas, the scale up of orders does not count against its scale up limit
+	h.Metrics.SetExternalMetric("payments_queue", 120000)
+	h.AssertRecommendation(t, payments, paymentsScale, 0, 4)
+
+	// while the scale up of orders is still limited by its own scale up
+	h.AssertRecommendation(t, orders, ordersScale, 0, 6)
+
+	// orders proposes 30/30 = 1 replica, its scale down is stabilized by its own recommendations
+	h.Metrics.SetExternalMetric("orders_queue", 30000)
+	h.AssertRecommendation(t, orders, ordersScale, time.Minute, 8)
+}
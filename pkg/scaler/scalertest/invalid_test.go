@@ -0,0 +1,56 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func TestInvalidMetricValueScenario(t *testing.T) {
+	for _, value := range []float64{math.NaN(), math.Inf(1)} {
+		h := NewHarness(0.1, 0)
+		podLabels := map[string]string{"app": "web"}
+		pods := h.AddPods("web", 3, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+		scale := Scale("web", 3, podLabels)
+		gpa := multiMetricGPA(nil)
+		gpa.Spec.MetricMode.Metrics = gpa.Spec.MetricMode.Metrics[1:]
+		key := gpa.Namespace + "/" + gpa.Name
+
+		// the only metric is unavailable, the target is not scaled
+		h.Metrics.SetExternalMetricFloat("queue_length", 90, value)
+		h.Clock.Step(time.Minute)
+		_, err := h.Engine.Recommend(gpa, key, scale)
+		assert.Error(t, err, "value %v", value)
+		condition := gpa.Status.Conditions[len(gpa.Status.Conditions)-1]
+		assert.Equal(t, autoscaling.ScalingActive, condition.Type)
+		assert.Equal(t, v1.ConditionFalse, condition.Status)
+		assert.Equal(t, "InvalidMetricValue", condition.Reason)
+		assert.Contains(t, condition.Message, "NaN")
+
+		// the other metrics still apply, cpu at 80% of 50% proposes 5 replicas
+		gpa = multiMetricGPA(nil)
+		setCPU(h, pods, 800)
+		recommendation := h.AssertRecommendation(t, gpa, scale, time.Minute, 5)
+		assert.Contains(t, recommendation.MetricName, "cpu")
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+const (
+	kafkaTopic = "orders"
+	kafkaGroup = "order-processor"
+)
+
+// newKafkaBroker starts a mock broker leading both partitions of the topic, the newest offsets of the partitions
+// are 600 and 400, while the group only committed the offset 100 of the first partition.
+func newKafkaBroker(t *testing.T, addr string) *sarama.MockBroker {
+	var broker *sarama.MockBroker
+	if addr == "" {
+		broker = sarama.NewMockBroker(t, 1)
+	} else {
+		broker = sarama.NewMockBrokerAddr(t, 1, addr)
+	}
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()).
+			SetLeader(kafkaTopic, 0, broker.BrokerID()).
+			SetLeader(kafkaTopic, 1, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).SetVersion(1).
+			SetOffset(kafkaTopic, 0, sarama.OffsetNewest, 600).
+			SetOffset(kafkaTopic, 1, sarama.OffsetNewest, 400),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, kafkaGroup, broker),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset(kafkaGroup, kafkaTopic, 0, 100, "", sarama.ErrNoError),
+	})
+	return broker
+}
+
+func kafkaLagGPA(broker string) *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	target := resource.MustParse("100")
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "consumer", Namespace: Namespace},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "consumer"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    20,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{
+						{
+							Type: autoscaling.KafkaLagMetricSourceType,
+							KafkaLag: &autoscaling.KafkaLagMetricSource{
+								Brokers:       []string{broker},
+								Topic:         kafkaTopic,
+								ConsumerGroup: kafkaGroup,
+								Target: autoscaling.MetricTarget{
+									Type:         autoscaling.AverageValueMetricType,
+									AverageValue: &target,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestKafkaLagMetricScenario(t *testing.T) {
+	broker := newKafkaBroker(t, "")
+	defer broker.Close()
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "consumer"}
+	h.AddPods("consumer", 5, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("consumer", 5, podLabels)
+
+	// the partitions lag by 500 and 400, scale up to 900 / 100 replicas
+	recommendation := h.AssertRecommendation(t, kafkaLagGPA(broker.Addr()), scale, time.Second, 9)
+	status := recommendation.MetricStatuses[0]
+	assert.Equal(t, autoscaling.KafkaLagMetricSourceType, status.Type)
+	assert.Equal(t, kafkaGroup, status.KafkaLag.ConsumerGroup)
+	assert.Equal(t, int64(900), status.KafkaLag.Current.Value.Value())
+	assert.Equal(t, int64(180), status.KafkaLag.Current.AverageValue.Value())
+	assert.Contains(t, recommendation.MetricName, kafkaTopic)
+}
+
+func TestKafkaLagMetricBrokersBackoff(t *testing.T) {
+	broker := newKafkaBroker(t, "")
+	addr := broker.Addr()
+	broker.Close()
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "consumer"}
+	h.AddPods("consumer", 5, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("consumer", 5, podLabels)
+	gpa := kafkaLagGPA(addr)
+	key := gpa.Namespace + "/" + gpa.Name
+
+	// the brokers are down
+	_, err := h.Engine.Recommend(gpa, key, scale)
+	assert.Error(t, err)
+
+	// the brokers are back, but they are not dialed until the backoff elapses
+	broker = newKafkaBroker(t, addr)
+	defer broker.Close()
+	h.Clock.Step(5 * time.Second)
+	_, err = h.Engine.Recommend(gpa, key, scale)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "backed off")
+	}
+	assert.Empty(t, broker.History())
+
+	h.AssertRecommendation(t, gpa, scale, 10*time.Second, 9)
+}
+
+func TestKafkaLagMetricTopicBackoff(t *testing.T) {
+	broker := newKafkaBroker(t, "")
+	defer broker.Close()
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "consumer"}
+	h.AddPods("consumer", 5, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("consumer", 5, podLabels)
+	typo := kafkaLagGPA(broker.Addr())
+	typo.Name = "typo"
+	typo.Spec.MetricMode.Metrics[0].KafkaLag.Topic = "ordres"
+
+	// the topic does not exist
+	_, err := h.Engine.Recommend(typo, typo.Namespace+"/"+typo.Name, scale)
+	assert.Error(t, err)
+	_, err = h.Engine.Recommend(typo, typo.Namespace+"/"+typo.Name, scale)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "on topic ordres is backed off")
+	}
+
+	// the other topics of the same brokers are not backed off
+	h.AssertRecommendation(t, kafkaLagGPA(broker.Addr()), scale, time.Second, 9)
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	metricsclient "github.com/ocgi/general-pod-autoscaler/pkg/metrics"
+)
+
+// metricWindow is the window of the pod metrics returned by FakeMetricsClient
+const metricWindow = time.Minute
+
+// FakeMetricsClient is a metrics.MetricsClient serving the metric values set by the test, all values are
+// milli-values. The metrics are identified by their names only, the selectors and described objects are
+// ignored, and the timestamps of the metrics are the current time of the clock.
+type FakeMetricsClient struct {
+	lock      sync.Mutex
+	clock     clock.Clock
+	resources map[v1.ResourceName]map[string]int64
+	pods      map[string]map[string]int64
+	objects   map[string]int64
+	externals map[string][]int64
+	// externalErrs fail the external metrics set with NaN or infinite values
+	externalErrs map[string]error
+}
+
+var _ metricsclient.MetricsClient = &FakeMetricsClient{}
+
+// NewFakeMetricsClient creates a FakeMetricsClient without any metrics.
+func NewFakeMetricsClient(clock clock.Clock) *FakeMetricsClient {
+	return &FakeMetricsClient{
+		clock:        clock,
+		resources:    map[v1.ResourceName]map[string]int64{},
+		pods:         map[string]map[string]int64{},
+		objects:      map[string]int64{},
+		externals:    map[string][]int64{},
+		externalErrs: map[string]error{},
+	}
+}
+
+// SetResourceMetric sets the usage of the resource of each pod by the pod name
+func (c *FakeMetricsClient) SetResourceMetric(resource v1.ResourceName, values map[string]int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.resources[resource] = values
+}
+
+// SetPodsMetric sets the value of the metric of each pod by the pod name
+func (c *FakeMetricsClient) SetPodsMetric(name string, values map[string]int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.pods[name] = values
+}
+
+// SetObjectMetric sets the value of the object metric
+func (c *FakeMetricsClient) SetObjectMetric(name string, value int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.objects[name] = value
+}
+
+// SetExternalMetric sets the values of the external metric
+func (c *FakeMetricsClient) SetExternalMetric(name string, values ...int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.externals[name] = values
+	delete(c.externalErrs, name)
+}
+
+// SetExternalMetricFloat sets the values of the external metric as an adapter serving floats does, the metric
+// fails if any of the values is NaN or infinite
+func (c *FakeMetricsClient) SetExternalMetricFloat(name string, values ...float64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.externalErrs, name)
+	milliValues := make([]int64, 0, len(values))
+	for _, value := range values {
+		milliValue, err := metricsclient.MilliValue(name, value)
+		if err != nil {
+			c.externalErrs[name] = err
+		}
+		milliValues = append(milliValues, milliValue)
+	}
+	c.externals[name] = milliValues
+}
+
+// GetResourceMetric implements metrics.MetricsClient
+func (c *FakeMetricsClient) GetResourceMetric(resource v1.ResourceName, namespace string, selector labels.Selector,
+	container string) (metricsclient.PodMetricsInfo, time.Time, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	values, ok := c.resources[resource]
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("no %s resource metric", resource)
+	}
+	return c.podMetrics(values), c.clock.Now(), nil
+}
+
+// GetRawMetric implements metrics.MetricsClient
+func (c *FakeMetricsClient) GetRawMetric(metricName string, namespace string, selector labels.Selector,
+	metricSelector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	values, ok := c.pods[metricName]
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("no pods metric %s", metricName)
+	}
+	return c.podMetrics(values), c.clock.Now(), nil
+}
+
+// GetObjectMetric implements metrics.MetricsClient
+func (c *FakeMetricsClient) GetObjectMetric(metricName string, namespace string,
+	objectRef *autoscaling.CrossVersionObjectReference, metricSelector labels.Selector) (int64, time.Time, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	value, ok := c.objects[metricName]
+	if !ok {
+		return 0, time.Time{}, fmt.Errorf("no object metric %s", metricName)
+	}
+	return value, c.clock.Now(), nil
+}
+
+// GetExternalMetric implements metrics.MetricsClient
+func (c *FakeMetricsClient) GetExternalMetric(metricName string, namespace string,
+	selector labels.Selector) ([]int64, time.Time, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	values, ok := c.externals[metricName]
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("no external metric %s", metricName)
+	}
+	if err := c.externalErrs[metricName]; err != nil {
+		return nil, time.Time{}, err
+	}
+	return append([]int64(nil), values...), c.clock.Now(), nil
+}
+
+// podMetrics returns a copy of the values, as the replica calculator modifies the returned metrics
+func (c *FakeMetricsClient) podMetrics(values map[string]int64) metricsclient.PodMetricsInfo {
+	metrics := make(metricsclient.PodMetricsInfo, len(values))
+	for name, value := range values {
+		metrics[name] = metricsclient.PodMetric{
+			Timestamp: c.clock.Now(),
+			Window:    metricWindow,
+			Value:     value,
+		}
+	}
+	return metrics
+}
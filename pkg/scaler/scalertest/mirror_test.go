@@ -0,0 +1,78 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func mirrorGPA(mode *autoscaling.MirrorMode) *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: Namespace},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "worker"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    20,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MirrorMode: mode,
+			},
+		},
+	}
+}
+
+// setDesiredReplicas sets the desired replicas of the mirrored GPA as its controller does
+func setDesiredReplicas(h *Harness, name string, replicas int32) {
+	h.SetGPA(&autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: Namespace},
+		Status:     autoscaling.GeneralPodAutoscalerStatus{DesiredReplicas: replicas},
+	})
+}
+
+func TestMirrorScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	scale := Scale("worker", 2, map[string]string{"app": "worker"})
+	factor := 0.5
+	gpa := mirrorGPA(&autoscaling.MirrorMode{Name: "api", Factor: &factor, Offset: 1})
+
+	setDesiredReplicas(h, "api", 2)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 2)
+
+	// the api scales up, so does the worker
+	setDesiredReplicas(h, "api", 6)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 4)
+
+	// and down along with it
+	setDesiredReplicas(h, "api", 4)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 3)
+
+	// the api GPA is gone, the worker keeps its replicas
+	h.RemoveGPA("api")
+	h.Clock.Step(time.Minute)
+	_, err := h.Engine.Recommend(gpa, Namespace+"/"+gpa.Name, scale)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "not found")
+	}
+	assert.Equal(t, int32(3), scale.Spec.Replicas)
+
+	setDesiredReplicas(h, "api", 8)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 5)
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scaler"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scalercore"
+)
+
+func TestModeBehaviorScenario(t *testing.T) {
+	zero := int32(0)
+	selectMax := autoscaling.MaxPolicySelect
+	testCases := []struct {
+		name     string
+		queue    int64
+		expected int32
+		mode     string
+	}{
+		// the metric recommends 6 replicas, but its scale up is limited to a pod per minute
+		{name: "metric decision by the metric rules", queue: 180000, expected: 4, mode: scaler.MetricDecisionMode},
+		// the time range recommends 5 replicas, it is not limited by the rules of the metric
+		{name: "time decision by the shared rules", queue: 120000, expected: 5, mode: scalercore.Cron},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHarness(0.1, 0)
+			h.Clock.SetTime(time.Date(2021, 6, 1, 10, 30, 0, 0, time.Local))
+			podLabels := map[string]string{"app": "worker"}
+			h.AddPods("worker", 3, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+			scale := Scale("worker", 3, podLabels)
+			gpa := conflictGPA(autoscaling.MaxConflictPolicy, h.Clock.Now().Add(-time.Hour))
+			gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{
+				Metric: &autoscaling.ModeScalingRules{
+					ScaleUp: &autoscaling.GPAScalingRules{
+						StabilizationWindowSeconds: &zero,
+						SelectPolicy:               &selectMax,
+						Policies:                   []autoscaling.GPAScalingPolicy{{Type: autoscaling.PodsScalingPolicy, Value: 1, PeriodSeconds: 60}},
+					},
+					ScaleDown: &autoscaling.GPAScalingRules{
+						StabilizationWindowSeconds: &zero,
+						SelectPolicy:               &selectMax,
+						Policies:                   []autoscaling.GPAScalingPolicy{{Type: autoscaling.PercentScalingPolicy, Value: 100, PeriodSeconds: 60}},
+					},
+				},
+			}
+
+			h.Metrics.SetExternalMetric("queue_length", tc.queue)
+			recommendation := h.AssertRecommendation(t, gpa, scale, time.Second, tc.expected)
+			assert.Equal(t, tc.mode, recommendation.Mode)
+		})
+	}
+}
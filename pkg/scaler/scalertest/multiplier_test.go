@@ -0,0 +1,59 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestMultiplierScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "web"}
+	requests := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+	pods := h.AddPods("web", 3, podLabels, requests)
+	gpa, scale := cpuGPA("campaign", nil), Scale("web", 3, podLabels)
+
+	// the usage is on the target
+	setCPU(h, pods, 500)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 3)
+	assert.Nil(t, gpa.Status.Multiplier)
+
+	// the campaign doubles the replicas for ten minutes
+	until := h.Clock.Now().Add(10 * time.Minute).Truncate(time.Second)
+	gpa.Annotations = map[string]string{
+		"autoscaling.ocgi.io/multiplier":       "2",
+		"autoscaling.ocgi.io/multiplier-until": until.Format(time.RFC3339),
+	}
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+	if assert.NotNil(t, gpa.Status.Multiplier) {
+		assert.Equal(t, 2.0, gpa.Status.Multiplier.Factor)
+		assert.True(t, until.Equal(gpa.Status.Multiplier.Until.Time))
+	}
+
+	// the load spreads over the new pods, the metrics recommend 3 replicas which are still doubled
+	pods = append(pods, h.AddPods("web", 3, podLabels, requests)...)
+	setCPU(h, pods, 250)
+	recommendation := h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+	assert.Equal(t, int32(6), recommendation.ProposedReplicas)
+
+	// the window ends, the recommendation of the metrics applies as is
+	h.AssertRecommendation(t, gpa, scale, 10*time.Minute, 3)
+	assert.Nil(t, gpa.Status.Multiplier)
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func podAgeBuffered(gpa *autoscaling.GeneralPodAutoscaler) v1.ConditionStatus {
+	for _, condition := range gpa.Status.Conditions {
+		if condition.Type == autoscaling.PodAgeBuffered {
+			return condition.Status
+		}
+	}
+	return v1.ConditionUnknown
+}
+
+func TestPodAgeBufferScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "web"}
+	requests := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+	pods := h.AddPods("web", 3, podLabels, requests)
+	gpa, scale := cpuGPA("recycled", nil), Scale("web", 3, podLabels)
+	gpa.Spec.PodAgeBuffer = &autoscaling.PodAgeBuffer{MaxAgeSeconds: 7200, Replicas: 1}
+
+	// the pods are an hour old, within the max age
+	setCPU(h, pods, 500)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 3)
+	assert.Equal(t, v1.ConditionFalse, podAgeBuffered(gpa))
+
+	// the pods are past the max age, a buffer replica is added for them to be recycled
+	h.AssertRecommendation(t, gpa, scale, time.Hour, 4)
+	assert.Equal(t, v1.ConditionTrue, podAgeBuffered(gpa))
+
+	// the aged pods are replaced by new ones sharing the load, the buffer is removed
+	for _, pod := range pods {
+		h.RemovePod(pod)
+	}
+	setCPU(h, h.AddPods("fresh", 4, podLabels, requests), 375)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 3)
+	assert.Equal(t, v1.ConditionFalse, podAgeBuffered(gpa))
+}
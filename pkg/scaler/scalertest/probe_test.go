@@ -0,0 +1,194 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scalercore"
+)
+
+// latencyServer is an endpoint responding after the latency set by the test, or failing if status is set
+type latencyServer struct {
+	*httptest.Server
+	latency int64
+	status  int32
+	probes  int32
+	// authorization is the Authorization header of the last probe
+	authorization atomic.Value
+}
+
+func newLatencyServer() *latencyServer {
+	s := &latencyServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.probes, 1)
+		s.authorization.Store(r.Header.Get("Authorization"))
+		time.Sleep(time.Duration(atomic.LoadInt64(&s.latency)))
+		if status := atomic.LoadInt32(&s.status); status != 0 {
+			w.WriteHeader(int(status))
+		}
+	}))
+	return s
+}
+
+func (s *latencyServer) setLatency(latency time.Duration) {
+	atomic.StoreInt64(&s.latency, int64(latency))
+}
+
+func probeGPA(url string) *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	timeout := int32(1)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: Namespace},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    10,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{
+						{
+							Type: autoscaling.ProbeMetricSourceType,
+							Probe: &autoscaling.ProbeMetricSource{
+								URL:            url,
+								TargetLatency:  metav1.Duration{Duration: 100 * time.Millisecond},
+								TimeoutSeconds: &timeout,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestProbeMetricScenario(t *testing.T) {
+	server := newLatencyServer()
+	defer server.Close()
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "web"}
+	requests := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+	h.AddPods("web", 4, podLabels, requests)
+	scale := Scale("web", 4, podLabels)
+	gpa := probeGPA(server.URL)
+
+	// the latency is more than 1.5 times the target, scale 4 pods up to 7
+	server.setLatency(160 * time.Millisecond)
+	recommendation := h.AssertRecommendation(t, gpa, scale, time.Second, 7)
+	assert.Contains(t, recommendation.MetricName, server.URL)
+	assert.Equal(t, autoscaling.ProbeMetricSourceType, recommendation.MetricStatuses[0].Type)
+	assert.True(t, recommendation.MetricStatuses[0].Probe.Current.Value.MilliValue() >= 160)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.probes))
+	h.AddPods("web", 3, podLabels, requests)
+
+	// the endpoint is probed at most once per period
+	server.setLatency(0)
+	h.Step(t, gpa, scale, 5*time.Second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.probes))
+
+	// the slow probe leaves the window, scale down to the min replicas
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 1)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&server.probes))
+}
+
+func TestProbeMetricFailureCountsAsTimeout(t *testing.T) {
+	server := newLatencyServer()
+	defer server.Close()
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "web"}
+	h.AddPods("web", 5, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("web", 5, podLabels)
+
+	// a failed probe counts as the timeout of 1s, which is 10 times the target, scale up to the max replicas
+	atomic.StoreInt32(&server.status, http.StatusServiceUnavailable)
+	recommendation := h.AssertRecommendation(t, probeGPA(server.URL), scale, time.Second, 10)
+	assert.Equal(t, int64(1000), recommendation.MetricStatuses[0].Probe.Current.Value.MilliValue())
+}
+
+func TestProbeMetricFailureBackoff(t *testing.T) {
+	server := newLatencyServer()
+	defer server.Close()
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "web"}
+	h.AddPods("web", 5, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("web", 5, podLabels)
+	gpa := probeGPA(server.URL)
+
+	atomic.StoreInt32(&server.status, http.StatusServiceUnavailable)
+	h.AssertRecommendation(t, gpa, scale, time.Second, 10)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.probes))
+
+	// the backoff of 10s elapsed, the url fails again and is backed off for 20s
+	h.AssertRecommendation(t, gpa, scale, 10*time.Second, 10)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&server.probes))
+
+	// the url is not probed while backed off, which counts as the timeout
+	atomic.StoreInt32(&server.status, 0)
+	recommendation := h.AssertRecommendation(t, gpa, scale, 10*time.Second, 10)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&server.probes))
+	assert.Equal(t, int64(1000), recommendation.MetricStatuses[0].Probe.Current.Value.MilliValue())
+
+	h.Step(t, gpa, scale, 10*time.Second)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&server.probes))
+}
+
+func TestProbeMetricProjectedToken(t *testing.T) {
+	server := newLatencyServer()
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "tokens")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "metrics-token")
+	require.NoError(t, ioutil.WriteFile(path, []byte("first"), 0600))
+
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "web"}
+	h.AddPods("web", 1, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("web", 1, podLabels)
+	gpa := probeGPA(server.URL)
+	gpa.Spec.MetricMode.Metrics[0].Probe.TokenAuth = &autoscaling.ProjectedTokenAuth{TokenFile: "metrics-token"}
+	key := gpa.Namespace + "/" + gpa.Name
+
+	// the tokens are not configured
+	_, err = h.Engine.Recommend(gpa, key, scale)
+	assert.Error(t, err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&server.probes))
+
+	h.Engine.SetProjectedTokens(scalercore.NewProjectedTokens(dir))
+	h.Step(t, gpa, scale, time.Minute)
+	assert.Equal(t, "Bearer first", server.authorization.Load())
+
+	// the token is rotated, the next probe sends the new one
+	require.NoError(t, ioutil.WriteFile(path, []byte("second"), 0600))
+	rotated := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, rotated, rotated))
+	h.Step(t, gpa, scale, time.Minute)
+	assert.Equal(t, "Bearer second", server.authorization.Load())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&server.probes))
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func clusterProportionalGPA(mode *autoscaling.ClusterProportionalMode) *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "dns", Namespace: Namespace},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "dns"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    20,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				ClusterProportionalMode: mode,
+			},
+		},
+	}
+}
+
+func TestClusterProportionalScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "dns"}
+	scale := Scale("dns", 1, podLabels)
+	gpa := clusterProportionalGPA(&autoscaling.ClusterProportionalMode{CoresPerReplica: 16, NodesPerReplica: 4})
+	fourCores := v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")}
+
+	// 3 nodes with 12 cores, a replica by both the nodes and the cores
+	names := h.AddNodes("node", 3, fourCores)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 1)
+
+	// 9 nodes need 3 replicas, while the 36 cores need 3 as well
+	h.AddNodes("node", 6, fourCores)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 3)
+
+	// a bigger node needs more replicas by the cores than by the nodes
+	h.AddNodes("large", 1, v1.ResourceList{v1.ResourceCPU: resource.MustParse("32")})
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 5)
+
+	// removing the nodes scales down
+	h.RemoveNode("large-0")
+	for _, name := range names {
+		h.RemoveNode(name)
+	}
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 2)
+}
+
+func TestClusterProportionalLadderScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	scale := Scale("dns", 1, map[string]string{"app": "dns"})
+	gpa := clusterProportionalGPA(&autoscaling.ClusterProportionalMode{
+		Ladder: &autoscaling.ClusterProportionalLadder{
+			NodesToReplicas: []autoscaling.ClusterProportionalStep{
+				{Size: 1, Replicas: 1},
+				{Size: 5, Replicas: 3},
+				{Size: 20, Replicas: 6},
+			},
+		},
+		PreventSinglePointFailure: true,
+	})
+	oneCore := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+
+	h.AddNodes("node", 1, oneCore)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 1)
+
+	// the first step recommends a replica, but 2 nodes must not be served by a single replica
+	h.AddNodes("node", 1, oneCore)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 2)
+
+	h.AddNodes("node", 3, oneCore)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 3)
+
+	h.AddNodes("node", 20, oneCore)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func workerThreadsGPA() *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	target := int32(50)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "server", Namespace: Namespace},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "server"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    20,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{
+						{
+							Type: autoscaling.RatioMetricSourceType,
+							Ratio: &autoscaling.RatioMetricSource{
+								Numerator:   autoscaling.MetricIdentifier{Name: "busy_workers"},
+								Denominator: autoscaling.MetricIdentifier{Name: "total_workers"},
+								Target: autoscaling.MetricTarget{
+									Type:               autoscaling.UtilizationMetricType,
+									AverageUtilization: &target,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// setWorkers sets the busy and the total worker threads of each pod
+func setWorkers(h *Harness, pods []string, busy, total int64) {
+	busyWorkers, totalWorkers := map[string]int64{}, map[string]int64{}
+	for _, pod := range pods {
+		busyWorkers[pod] = busy * 1000
+		totalWorkers[pod] = total * 1000
+	}
+	h.Metrics.SetPodsMetric("busy_workers", busyWorkers)
+	h.Metrics.SetPodsMetric("total_workers", totalWorkers)
+}
+
+func TestRatioMetricScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "server"}
+	pods := h.AddPods("server", 4, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("server", 4, podLabels)
+	gpa := workerThreadsGPA()
+
+	// 15 of 20 threads are busy in each pod, 75% of the 50% target, scale up to 4 * 1.5 replicas
+	setWorkers(h, pods, 15, 20)
+	recommendation := h.AssertRecommendation(t, gpa, scale, time.Second, 6)
+	status := recommendation.MetricStatuses[0]
+	assert.Equal(t, autoscaling.RatioMetricSourceType, status.Type)
+	assert.Equal(t, int32(75), *status.Ratio.Current.AverageUtilization)
+	assert.Contains(t, recommendation.MetricName, "busy_workers")
+
+	// the new pods are up and half of the threads are busy, the replicas are kept
+	pods = append(pods, h.AddPods("server", 2, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})...)
+	setWorkers(h, pods, 10, 20)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+
+	// a quarter of the threads are busy, scale down to 6 * 0.5 replicas once the stabilization passes
+	setWorkers(h, pods, 5, 20)
+	h.AssertRecommendation(t, gpa, scale, 6*time.Minute, 3)
+
+	// the pods report no threads at all, the ratio is undefined
+	setWorkers(h, pods, 0, 0)
+	h.Clock.Step(time.Minute)
+	_, err := h.Engine.Recommend(gpa, gpa.Namespace+"/"+gpa.Name, scale)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "denominator")
+	}
+}
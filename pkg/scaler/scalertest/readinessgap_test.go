@@ -0,0 +1,62 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func readinessGapBuffered(gpa *autoscaling.GeneralPodAutoscaler) *autoscaling.GeneralPodAutoscalerCondition {
+	for i, condition := range gpa.Status.Conditions {
+		if condition.Type == autoscaling.ReadinessGapBuffered {
+			return &gpa.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func TestReadinessGapBufferScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	// the gap is timed by the clock of the engine, far from the wall clock
+	h.Clock.SetTime(time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC))
+	podLabels := map[string]string{"app": "web"}
+	pods := h.AddPods("web", 3, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	gapSeconds := int32(60)
+	gpa, scale := cpuGPA("slow-start", nil), Scale("web", 3, podLabels)
+	gpa.Spec.ReadinessGapBuffer = &autoscaling.ReadinessGapBuffer{Replicas: 2, GapSeconds: &gapSeconds}
+
+	setCPU(h, pods, 500)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 3)
+	assert.Equal(t, v1.ConditionFalse, readinessGapBuffered(gpa).Status)
+
+	// a pod turns unready, the gap is observed at the time of the engine
+	h.SetPodReady(pods[2], false)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 3)
+	condition := readinessGapBuffered(gpa)
+	assert.Equal(t, v1.ConditionUnknown, condition.Status)
+	assert.Equal(t, h.Clock.Now(), condition.LastTransitionTime.Time)
+	h.AssertRecommendation(t, gpa, scale, 30*time.Second, 3)
+
+	// the gap persists for gapSeconds, the buffer is added
+	h.AssertRecommendation(t, gpa, scale, 31*time.Second, 5)
+	assert.Equal(t, v1.ConditionTrue, readinessGapBuffered(gpa).Status)
+}
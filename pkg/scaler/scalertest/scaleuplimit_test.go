@@ -0,0 +1,71 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func TestScaleUpMaxFactorScenario(t *testing.T) {
+	factor2, factor3 := 2.0, 3.0
+	absolute6 := int32(6)
+	for _, c := range []struct {
+		name        string
+		maxFactor   *float64
+		maxAbsolute *int32
+		replicas    int32
+		expected    int32
+	}{
+		{name: "only limited by the policies", replicas: 1, expected: 10},
+		{name: "max absolute defaults to 4", maxFactor: &factor2, replicas: 1, expected: 4},
+		{name: "max absolute is more permissive", maxFactor: &factor2, maxAbsolute: &absolute6, replicas: 1, expected: 6},
+		{name: "max factor is more permissive", maxFactor: &factor3, maxAbsolute: &absolute6, replicas: 3, expected: 9},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			h := NewHarness(0.1, 0)
+			podLabels := map[string]string{"app": "web"}
+			pods := h.AddPods("web", int(c.replicas), podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+			scale := Scale("web", c.replicas, podLabels)
+			selectMax := autoscaling.MaxPolicySelect
+			stabilization := int32(0)
+			// the policy allows scaling up by 10 times in a minute
+			gpa := multiMetricGPA(&autoscaling.GeneralPodAutoscalerBehavior{
+				ScaleUp: &autoscaling.GPAScalingRules{
+					StabilizationWindowSeconds: &stabilization,
+					SelectPolicy:               &selectMax,
+					Policies:                   []autoscaling.GPAScalingPolicy{{Type: autoscaling.PercentScalingPolicy, Value: 900, PeriodSeconds: 60}},
+					MaxFactor:                  c.maxFactor,
+					MaxAbsolute:                c.maxAbsolute,
+				},
+				ScaleDown: &autoscaling.GPAScalingRules{
+					StabilizationWindowSeconds: &stabilization,
+					SelectPolicy:               &selectMax,
+					Policies:                   []autoscaling.GPAScalingPolicy{{Type: autoscaling.PodsScalingPolicy, Value: 1, PeriodSeconds: 60}},
+				},
+			})
+
+			// the queue proposes 300/30 = 10 replicas
+			setCPU(h, pods, 500)
+			h.Metrics.SetExternalMetric("queue_length", 300000)
+			h.AssertRecommendation(t, gpa, scale, time.Minute, c.expected)
+		})
+	}
+}
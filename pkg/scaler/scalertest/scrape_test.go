@@ -0,0 +1,148 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// gaugeServer is the metrics endpoint of a pod exposing the queue_depth gauge set by the test
+type gaugeServer struct {
+	*httptest.Server
+	depth int64
+}
+
+func newGaugeServer(t *testing.T) *gaugeServer {
+	s := &gaugeServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, "# HELP queue_depth The jobs waiting in the queue.\n# TYPE queue_depth gauge\n")
+		fmt.Fprintf(w, "queue_depth %d\n", atomic.LoadInt64(&s.depth))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// port returns the loopback IP and the port of the server
+func (s *gaugeServer) port(t *testing.T) (string, int32) {
+	host, port, err := net.SplitHostPort(s.Listener.Addr().String())
+	require.NoError(t, err)
+	number, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return host, int32(number)
+}
+
+func scrapeGPA() *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	timeout := int32(1)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: Namespace},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "worker"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    10,
+			AutoScalingDrivenMode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{
+						{
+							Type: autoscaling.ScrapeMetricSourceType,
+							Scrape: &autoscaling.ScrapeMetricSource{
+								Port:       intstr.FromString("metrics"),
+								MetricName: "queue_depth",
+								Target: autoscaling.MetricTarget{
+									Type:         autoscaling.AverageValueMetricType,
+									AverageValue: resource.NewQuantity(10, resource.DecimalSI),
+								},
+								TimeoutSeconds: &timeout,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestScrapeMetricScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "worker"}
+	pods := h.AddPods("worker", 3, podLabels, nil)
+	scale := Scale("worker", 3, podLabels)
+	gpa := scrapeGPA()
+	// each pod serves its metrics on its own loopback port, named metrics like in the pod spec
+	servers := make([]*gaugeServer, len(pods))
+	for i, pod := range pods {
+		servers[i] = newGaugeServer(t)
+		ip, port := servers[i].port(t)
+		h.SetPodPort(pod, ip, v1.ContainerPort{Name: "metrics", ContainerPort: port})
+	}
+	setDepth := func(depths ...int64) {
+		for i, depth := range depths {
+			atomic.StoreInt64(&servers[i].depth, depth)
+		}
+	}
+
+	// 20 jobs per pod propose 3*20/10 = 6 replicas
+	setDepth(20, 20, 20)
+	recommendation := h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+	if assert.Len(t, recommendation.MetricStatuses, 1) {
+		status := recommendation.MetricStatuses[0].Scrape
+		assert.Equal(t, "queue_depth", status.MetricName)
+		assert.Equal(t, int64(20), status.Current.AverageValue.Value())
+		assert.Equal(t, int32(3), status.ScrapedPods)
+		assert.Equal(t, int32(0), status.FailedPods)
+	}
+
+	// an unreachable pod is taken as missing the metric, i.e. as 0 jobs on a scale up: (30+30+0)/3 = 20 per pod
+	servers[2].Close()
+	setDepth(30, 30)
+	recommendation = h.AssertRecommendation(t, gpa, scale, time.Minute, 6)
+	if assert.Len(t, recommendation.MetricStatuses, 1) {
+		status := recommendation.MetricStatuses[0].Scrape
+		assert.Equal(t, int64(30), status.Current.AverageValue.Value())
+		assert.Equal(t, int32(2), status.ScrapedPods)
+		assert.Equal(t, int32(1), status.FailedPods)
+	}
+
+	// and as at the target on a scale down: (2+2+10)/3 is about 4.7 per pod
+	setDepth(2, 2)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 2)
+
+	// the metric fails once no pod can be scraped
+	servers[0].Close()
+	servers[1].Close()
+	h.Clock.Step(time.Minute)
+	_, err := h.Engine.Recommend(gpa, gpa.Namespace+"/"+gpa.Name, scale)
+	assert.Error(t, err)
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func TestReplicaStepScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "web"}
+	requests := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+	pods := h.AddPods("web", 3, podLabels, requests)
+	scale := Scale("web", 3, podLabels)
+	step := int32(4)
+	gpa := cpuGPA("web", nil)
+	gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{ReplicaStep: &step}
+
+	// 3 pods at 90% propose 6 replicas, half a step rounded up to 8
+	setCPU(h, pods, 900)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 8)
+
+	// 8 pods at 90% propose 15 replicas, capped to 8, the highest multiple below the max replicas of 10
+	pods = append(pods, h.AddPods("web", 5, podLabels, requests)...)
+	setCPU(h, pods, 900)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 8)
+
+	// 8 pods at 10% propose 2 replicas, raised to 4, the lowest multiple above the min replicas of 1
+	setCPU(h, pods, 100)
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 4)
+}
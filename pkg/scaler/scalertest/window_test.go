@@ -0,0 +1,56 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestWindowedMetricScenario(t *testing.T) {
+	h := NewHarness(0.1, 0)
+	podLabels := map[string]string{"app": "web"}
+	h.AddPods("web", 3, podLabels, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+	scale := Scale("web", 3, podLabels)
+	latestScale := Scale("web", 3, podLabels)
+	window := int32(300)
+	gpa := multiMetricGPA(nil)
+	gpa.Spec.MetricMode.Metrics = gpa.Spec.MetricMode.Metrics[1:]
+	gpa.Spec.MetricMode.Metrics[0].External.WindowSeconds = &window
+	latest := multiMetricGPA(nil)
+	latest.Name = "web-latest"
+	latest.Spec.MetricMode.Metrics = latest.Spec.MetricMode.Metrics[1:]
+
+	h.Metrics.SetExternalMetric("queue_length", 90000)
+	h.AssertRecommendation(t, gpa, scale, 0, 3)
+	h.AssertRecommendation(t, latest, latestScale, 0, 3)
+
+	// the average of 90 and 180 is 135, 135/30 rounds up to 5 replicas, while the latest value proposes 6
+	h.Metrics.SetExternalMetric("queue_length", 180000)
+	recommendation := h.AssertRecommendation(t, gpa, scale, time.Minute, 5)
+	assert.Equal(t, int64(45), recommendation.MetricStatuses[0].External.Current.AverageValue.Value())
+	h.AssertRecommendation(t, latest, latestScale, 0, 6)
+
+	// the average of 90, 180 and 180 is 150, at the target of 5 replicas
+	h.AssertRecommendation(t, gpa, scale, time.Minute, 5)
+
+	// the samples out of the window are dropped, the average of 180 and 300 is 240
+	h.Metrics.SetExternalMetric("queue_length", 300000)
+	h.AssertRecommendation(t, gpa, scale, 5*time.Minute, 8)
+}
@@ -0,0 +1,199 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	metricsclient "github.com/ocgi/general-pod-autoscaler/pkg/metrics"
+)
+
+const (
+	defaultScrapePath           = "/metrics"
+	defaultScrapeTimeoutSeconds = 2
+)
+
+// scrapeGauge gets the metrics in the Prometheus text format from the url and returns the sum of the series of
+// the gauge. A metric without a type is taken as a gauge.
+func scrapeGauge(client *http.Client, url, metricName string) (float64, error) {
+	res, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return 0, fmt.Errorf("bad status code %d from %s", res.StatusCode, url)
+	}
+	families, err := (&expfmt.TextParser{}).TextToMetricFamilies(res.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse the metrics of %s: %v", url, err)
+	}
+	family, ok := families[metricName]
+	if !ok || len(family.GetMetric()) == 0 {
+		return 0, fmt.Errorf("%s does not expose %s", url, metricName)
+	}
+	var sum float64
+	for _, metric := range family.GetMetric() {
+		switch family.GetType() {
+		case dto.MetricType_GAUGE:
+			sum += metric.GetGauge().GetValue()
+		case dto.MetricType_UNTYPED:
+			sum += metric.GetUntyped().GetValue()
+		default:
+			return 0, fmt.Errorf("%s of %s is a %s, not a gauge", metricName, url, family.GetType())
+		}
+	}
+	if math.IsNaN(sum) || math.IsInf(sum, 0) {
+		return 0, fmt.Errorf("%s of %s is %v", metricName, url, sum)
+	}
+	return sum, nil
+}
+
+// podMetricsURL returns the url of the metrics of the pod on the port, a named port is looked up in the container
+// ports of the pod.
+func podMetricsURL(pod *v1.Pod, port intstr.IntOrString, path string) (string, error) {
+	if pod.Status.PodIP == "" {
+		return "", fmt.Errorf("pod %s has no IP", pod.Name)
+	}
+	number := port.IntValue()
+	if port.Type == intstr.String {
+		number = 0
+		for _, container := range pod.Spec.Containers {
+			for _, containerPort := range container.Ports {
+				if containerPort.Name == port.StrVal {
+					number = int(containerPort.ContainerPort)
+				}
+			}
+		}
+		if number == 0 {
+			return "", fmt.Errorf("pod %s has no port %s", pod.Name, port.StrVal)
+		}
+	}
+	return fmt.Sprintf("http://%s%s", net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(number)), path), nil
+}
+
+// scrapePods scrapes the gauge from the running pods matching the selector in parallel, and returns the values of
+// the pods (as milli-values) and the number of the pods which could not be scraped.
+func (a *DecisionEngine) scrapePods(gpa *autoscaling.GeneralPodAutoscaler, src *autoscaling.ScrapeMetricSource,
+	selector labels.Selector) (metricsclient.PodMetricsInfo, int32, error) {
+	podList, err := a.replicaCalc.podLister.Pods(gpa.Namespace).List(selector)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to get pods while scraping %s: %v", src.MetricName, err)
+	}
+	path := src.Path
+	if path == "" {
+		path = defaultScrapePath
+	}
+	timeout := time.Duration(defaultScrapeTimeoutSeconds) * time.Second
+	if src.TimeoutSeconds != nil {
+		timeout = time.Duration(*src.TimeoutSeconds) * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+	now := a.clock.Now()
+
+	var (
+		lock    sync.Mutex
+		wg      sync.WaitGroup
+		failed  int32
+		metrics = metricsclient.PodMetricsInfo{}
+	)
+	for _, pod := range podList {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		wg.Add(1)
+		go func(pod *v1.Pod) {
+			defer wg.Done()
+			url, err := podMetricsURL(pod, src.Port, path)
+			var value float64
+			if err == nil {
+				value, err = scrapeGauge(client, url, src.MetricName)
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				failed++
+				decisionLog(gpa, 2).Infof("GPA %s/%s failed to scrape %s from pod %s: %v",
+					gpa.Namespace, gpa.Name, src.MetricName, pod.Name, err)
+				return
+			}
+			metrics[pod.Name] = metricsclient.PodMetric{
+				Value:     int64(math.Round(value * 1000)),
+				Timestamp: now,
+			}
+		}(pod)
+	}
+	wg.Wait()
+	return metrics, failed, nil
+}
+
+// computeStatusForScrapeMetric computes the desired number of replicas for the specified metric of type
+// ScrapeMetricSourceType, by comparing the average of the gauge scraped from the pods to the target like the
+// "pods" source. The pods which could not be scraped are handled as the pods missing the metric.
+func (a *DecisionEngine) computeStatusForScrapeMetric(currentReplicas int32, metricSpec autoscaling.MetricSpec,
+	gpa *autoscaling.GeneralPodAutoscaler, selector labels.Selector, status *autoscaling.MetricStatus) (replicaCountProposal int32,
+	timestampProposal time.Time, metricNameProposal string, condition autoscaling.GeneralPodAutoscalerCondition, err error) {
+	src := metricSpec.Scrape
+	if src.Target.AverageValue == nil || src.Target.AverageValue.MilliValue() <= 0 {
+		err = fmt.Errorf("invalid scrape metric source: the target average value must be greater than 0")
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetScrapeMetric", err)
+		return 0, time.Time{}, "", condition, err
+	}
+	metricNameProposal = fmt.Sprintf("scraped pods metric %s on port %s", src.MetricName, src.Port.String())
+	metrics, failed, err := a.scrapePods(gpa, src, selector)
+	if err == nil && len(metrics) == 0 {
+		err = fmt.Errorf("none of the %d running pods could be scraped", failed)
+	}
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetScrapeMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get %s: %v", metricNameProposal, err)
+	}
+	scraped := int32(len(metrics))
+	targetMilli := src.Target.AverageValue.MilliValue()
+	replicaCountProposal, average, err := a.replicaCalc.calcPlainMetricReplicas(metrics, currentReplicas, targetMilli,
+		gpa.Namespace, selector, v1.ResourceName(""))
+	if err != nil {
+		condition = a.getUnableComputeReplicaCountCondition(gpa, "FailedGetScrapeMetric", err)
+		return 0, time.Time{}, "", condition, fmt.Errorf("failed to get %s: %v", metricNameProposal, err)
+	}
+	decisionLog(gpa, 4).Infof("GPA %s/%s %s: %dm per pod over %d scraped pods, %d failed, target: %dm",
+		gpa.Namespace, gpa.Name, metricNameProposal, average, scraped, failed, targetMilli)
+	*status = autoscaling.MetricStatus{
+		Type: autoscaling.ScrapeMetricSourceType,
+		Scrape: &autoscaling.ScrapeMetricStatus{
+			MetricName: src.MetricName,
+			Current: autoscaling.MetricValueStatus{
+				AverageValue: resource.NewMilliQuantity(average, resource.DecimalSI),
+			},
+			ScrapedPods: scraped,
+			FailedPods:  failed,
+		},
+	}
+	return replicaCountProposal, a.clock.Now(), metricNameProposal, autoscaling.GeneralPodAutoscalerCondition{}, nil
+}
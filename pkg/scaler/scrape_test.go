@@ -0,0 +1,124 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestScrapeGauge(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		status  int
+		metrics string
+		value   float64
+		err     bool
+	}{
+		{
+			name:    "gauge",
+			metrics: "# TYPE queue_depth gauge\nqueue_depth 12.5\n",
+			value:   12.5,
+		},
+		{
+			name:    "series are summed",
+			metrics: "# TYPE queue_depth gauge\nqueue_depth{queue=\"a\"} 3\nqueue_depth{queue=\"b\"} 4\nother 100\n",
+			value:   7,
+		},
+		{
+			name:    "untyped",
+			metrics: "queue_depth 5\n",
+			value:   5,
+		},
+		{
+			name:    "counter",
+			metrics: "# TYPE queue_depth counter\nqueue_depth 5\n",
+			err:     true,
+		},
+		{
+			name:    "missing gauge",
+			metrics: "# TYPE other gauge\nother 5\n",
+			err:     true,
+		},
+		{
+			name:    "not a number",
+			metrics: "# TYPE queue_depth gauge\nqueue_depth NaN\n",
+			err:     true,
+		},
+		{
+			name:    "invalid format",
+			metrics: "queue_depth{ 5\n",
+			err:     true,
+		},
+		{
+			name:   "bad status",
+			status: http.StatusServiceUnavailable,
+			err:    true,
+		},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if c.status != 0 {
+					w.WriteHeader(c.status)
+				}
+				io.WriteString(w, c.metrics)
+			}))
+			defer server.Close()
+			value, err := scrapeGauge(server.Client(), server.URL+"/metrics", "queue_depth")
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.value, value)
+		})
+	}
+}
+
+func TestPodMetricsURL(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-0"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Name: "app", Ports: []v1.ContainerPort{{Name: "http", ContainerPort: 8080}}},
+				{Name: "exporter", Ports: []v1.ContainerPort{{Name: "metrics", ContainerPort: 9100}}},
+			},
+		},
+		Status: v1.PodStatus{PodIP: "10.0.0.1"},
+	}
+
+	url, err := podMetricsURL(pod, intstr.FromInt(9090), "/metrics")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://10.0.0.1:9090/metrics", url)
+
+	url, err = podMetricsURL(pod, intstr.FromString("metrics"), "/stats")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://10.0.0.1:9100/stats", url)
+
+	_, err = podMetricsURL(pod, intstr.FromString("admin"), "/metrics")
+	assert.Error(t, err)
+
+	pod.Status.PodIP = ""
+	_, err = podMetricsURL(pod, intstr.FromInt(9090), "/metrics")
+	assert.Error(t, err)
+}
@@ -0,0 +1,37 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	autoscalinginformer "github.com/ocgi/general-pod-autoscaler/pkg/client/informers/externalversions"
+)
+
+// GPASelectorOption returns the option of the informer factory listing and watching only the GPAs matching the
+// label selector, the others are ignored entirely, e.g. while they are reconciled by another controller. An
+// empty selector matches all the GPAs.
+func GPASelectorOption(selector string) (autoscalinginformer.SharedInformerOption, error) {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gpa selector %q: %v", selector, err)
+	}
+	return autoscalinginformer.WithTweakListOptions(func(options *metav1.ListOptions) {
+		options.LabelSelector = parsed.String()
+	}), nil
+}
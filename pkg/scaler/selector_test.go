@@ -0,0 +1,96 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	scalefake "k8s.io/client-go/scale/fake"
+	"k8s.io/client-go/tools/cache"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	autoscalingfake "github.com/ocgi/general-pod-autoscaler/pkg/client/clientset/versioned/fake"
+	autoscalinginformer "github.com/ocgi/general-pod-autoscaler/pkg/client/informers/externalversions"
+)
+
+func selectorGPA(name string, labels map[string]string) *autoscalingv1alpha1.GeneralPodAutoscaler {
+	return &autoscalingv1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace", Labels: labels},
+		Spec: autoscalingv1alpha1.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv1alpha1.CrossVersionObjectReference{Kind: "Deployment", Name: name},
+			MaxReplicas:    10,
+		},
+	}
+}
+
+func TestGPASelectorOption(t *testing.T) {
+	_, err := GPASelectorOption("shard in (a")
+	assert.Error(t, err)
+
+	gpaClient := autoscalingfake.NewSimpleClientset(
+		selectorGPA("matching", map[string]string{"shard": "a"}),
+		selectorGPA("other-shard", map[string]string{"shard": "b"}),
+		selectorGPA("unlabeled", nil),
+	)
+	option, err := GPASelectorOption("shard=a")
+	require.NoError(t, err)
+	scalerFactory := autoscalinginformer.NewSharedInformerFactoryWithOptions(gpaClient, 0, option)
+	client := fake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	gpaController := NewGeneralController(
+		client.CoreV1(),
+		client.CoreV1(),
+		&scalefake.FakeScaleClient{},
+		gpaClient.AutoscalingV1alpha1(),
+		testrestmapper.TestOnlyStaticRESTMapper(testScheme()),
+		nil,
+		scalerFactory.Autoscaling().V1alpha1().GeneralPodAutoscalers(),
+		informerFactory.Core().V1().Pods(),
+		0,
+		5*time.Minute,
+		defaultTestingTolerance,
+		defaultTestingCPUInitializationPeriod,
+		defaultTestingDelayOfInitialReadinessStatus,
+	)
+	defer gpaController.queue.ShutDown()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	scalerFactory.Start(stop)
+	require.True(t, cache.WaitForCacheSync(stop, gpaController.gpaListerSynced))
+
+	// only the matching GPA is listed and reconciled
+	gpas, err := gpaController.gpaLister.List(labels.Everything())
+	require.NoError(t, err)
+	if assert.Len(t, gpas, 1) {
+		assert.Equal(t, "matching", gpas[0].Name)
+	}
+	require.NoError(t, wait.PollImmediate(10*time.Millisecond, time.Second, func() (bool, error) {
+		return gpaController.queue.Len() > 0, nil
+	}))
+	key, _ := gpaController.queue.Get()
+	assert.Equal(t, "test-namespace/matching", key)
+	gpaController.queue.Done(key)
+	assert.Equal(t, 0, gpaController.queue.Len())
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+)
+
+// DefaultShutdownTimeout is how long the controller waits for the reconciles in progress once it is stopped
+const DefaultShutdownTimeout = 30 * time.Second
+
+// SetShutdownTimeout sets how long the controller waits for the reconciles in progress to write their scales and
+// statuses once it is stopped, 0 to stop without waiting for them.
+func (a *GeneralController) SetShutdownTimeout(timeout time.Duration) {
+	a.shutdownTimeout = timeout
+}
+
+// stopping returns true once the controller is stopped, the workers take no new GPA from the queue then
+func stopping(stopCh <-chan struct{}) bool {
+	select {
+	case <-stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// drain shuts the queue down and waits for the workers to finish the reconciles in progress, at most for the
+// shutdown timeout.
+func (a *GeneralController) drain(workers *sync.WaitGroup) {
+	a.queue.ShutDown()
+	drained := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		klog.Infof("GPA controller drained the reconciles in progress")
+	case <-time.After(a.shutdownTimeout):
+		klog.Warningf("GPA controller stopped before the reconciles in progress finished in %v", a.shutdownTimeout)
+	}
+}
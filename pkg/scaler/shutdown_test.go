@@ -0,0 +1,106 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	core "k8s.io/client-go/testing"
+)
+
+func TestShutdownDrainsReconcileInProgress(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		timeout  time.Duration
+		finished bool
+	}{
+		{name: "drained", timeout: time.Minute, finished: true},
+		{name: "timed out", timeout: 100 * time.Millisecond, finished: false},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			tc := testCase{
+				minReplicas:             2,
+				maxReplicas:             6,
+				specReplicas:            3,
+				statusReplicas:          3,
+				expectedDesiredReplicas: 5,
+				CPUTarget:               30,
+				verifyCPUCurrent:        true,
+				reportedLevels:          []uint64{300, 500, 700},
+				reportedCPURequests:     []resource.Quantity{resource.MustParse("1.0"), resource.MustParse("1.0"), resource.MustParse("1.0")},
+				useMetricsAPI:           true,
+			}
+			_, _, _, _, scaleClient, _ := tc.prepareTestClient(t)
+			// the scale write blocks until it is released
+			started, release := make(chan struct{}), make(chan struct{})
+			var once sync.Once
+			scaleClient.PrependReactor("update", "*", func(core.Action) (bool, runtime.Object, error) {
+				once.Do(func() { close(started) })
+				<-release
+				return false, nil, nil
+			})
+			defer close(release)
+			tc.testScaleClient = scaleClient
+			gpaController, informerFactory, scalerFactory := tc.setupController(t)
+			gpaController.SetShutdownTimeout(c.timeout)
+
+			informersStop, stop := make(chan struct{}), make(chan struct{})
+			defer close(informersStop)
+			scalerFactory.Start(informersStop)
+			informerFactory.Start(informersStop)
+			returned := make(chan struct{})
+			go func() {
+				gpaController.Run(stop)
+				close(returned)
+			}()
+			select {
+			case <-started:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("the scale was not written")
+			}
+			close(stop)
+
+			if !c.finished {
+				select {
+				case <-returned:
+				case <-time.After(5 * time.Second):
+					t.Fatalf("the controller was not stopped after the shutdown timeout")
+				}
+				tc.Lock()
+				assert.False(t, tc.scaleUpdated)
+				tc.Unlock()
+				return
+			}
+			select {
+			case <-returned:
+				t.Fatalf("the controller was stopped before the scale was written")
+			case <-time.After(200 * time.Millisecond):
+			}
+			release <- struct{}{}
+			select {
+			case <-returned:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("the controller was not stopped after the reconcile finished")
+			}
+			tc.verifyResults(t)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// quantizeReplicas rounds the desired replicas to the nearest multiple of spec.behavior.replicaStep, a half
+// step up, within the multiples between the min replicas and the max replicas. The desired replicas are kept
+// as is if no multiple is within them, e.g. when the min replicas are raised by the peak floor.
+func quantizeReplicas(gpa *autoscaling.GeneralPodAutoscaler, desiredReplicas, minReplicas int32) int32 {
+	if gpa.Spec.Behavior == nil || gpa.Spec.Behavior.ReplicaStep == nil || *gpa.Spec.Behavior.ReplicaStep <= 0 {
+		return desiredReplicas
+	}
+	step := *gpa.Spec.Behavior.ReplicaStep
+	lowest := (minReplicas + step - 1) / step * step
+	highest := gpa.Spec.MaxReplicas / step * step
+	if lowest > highest {
+		return desiredReplicas
+	}
+	quantized := max(lowest, min((desiredReplicas+step/2)/step*step, highest))
+	if quantized != desiredReplicas {
+		decisionLog(gpa, 4).Infof("GPA %s/%s: desired replicas %d quantized to %d by the replica step %d",
+			gpa.Namespace, gpa.Name, desiredReplicas, quantized, step)
+	}
+	return quantized
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func TestQuantizeReplicas(t *testing.T) {
+	for _, c := range []struct {
+		name        string
+		step        int32
+		minReplicas int32
+		maxReplicas int32
+		desired     int32
+		expected    int32
+	}{
+		{name: "no step", minReplicas: 1, maxReplicas: 20, desired: 7, expected: 7},
+		{name: "multiple", step: 4, minReplicas: 1, maxReplicas: 20, desired: 8, expected: 8},
+		{name: "rounded down", step: 4, minReplicas: 1, maxReplicas: 20, desired: 9, expected: 8},
+		{name: "half a step rounded up", step: 4, minReplicas: 1, maxReplicas: 20, desired: 10, expected: 12},
+		{name: "rounded up", step: 4, minReplicas: 1, maxReplicas: 20, desired: 11, expected: 12},
+		{name: "raised to the lowest multiple above min", step: 4, minReplicas: 3, maxReplicas: 20, desired: 3, expected: 4},
+		{name: "rounded down to the highest multiple below max", step: 4, minReplicas: 1, maxReplicas: 18, desired: 18, expected: 16},
+		{name: "scaled to zero", step: 4, minReplicas: 0, maxReplicas: 20, desired: 0, expected: 0},
+		{name: "no multiple within the bounds", step: 8, minReplicas: 3, maxReplicas: 6, desired: 5, expected: 5},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			behavior := &autoscalingv1alpha1.GeneralPodAutoscalerBehavior{}
+			if c.step > 0 {
+				behavior.ReplicaStep = &c.step
+			}
+			gpa := &autoscalingv1alpha1.GeneralPodAutoscaler{
+				Spec: autoscalingv1alpha1.GeneralPodAutoscalerSpec{
+					MaxReplicas: c.maxReplicas,
+					Behavior:    behavior,
+				},
+			}
+			assert.Equal(t, c.expected, quantizeReplicas(gpa, c.desired, c.minReplicas))
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// AddTargetInformer watches scalable resources of the given kind with the informer, changes of a
+// resource enqueue the GPAs targeting it, so that external edits are reconciled without waiting
+// for the next resync. It must be called before the informer is started.
+func (a *GeneralController) AddTargetInformer(kind string, informer cache.SharedIndexInformer) {
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			a.enqueueGPAsForTarget(kind, obj)
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			if targetChanged(old, cur) {
+				a.enqueueGPAsForTarget(kind, cur)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			a.enqueueGPAsForTarget(kind, obj)
+		},
+	})
+	a.targetListersSynced = append(a.targetListersSynced, informer.HasSynced)
+}
+
+// enqueueGPAsForTarget adds the GPAs whose scaleTargetRef points to obj to the queue immediately
+func (a *GeneralController) enqueueGPAsForTarget(kind string, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	target, err := apimeta.Accessor(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get object meta for %+v: %v", obj, err))
+		return
+	}
+	gpas, err := a.gpaLister.GeneralPodAutoscalers(target.GetNamespace()).List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't list gpa in namespace %v: %v", target.GetNamespace(), err))
+		return
+	}
+	for _, gpa := range gpas {
+		ref := gpa.Spec.ScaleTargetRef
+		if ref.Kind != kind || ref.Name != target.GetName() {
+			continue
+		}
+		key, err := cache.MetaNamespaceKeyFunc(gpa)
+		if err != nil {
+			utilruntime.HandleError(fmt.Errorf("couldn't get key for object %+v: %v", gpa, err))
+			continue
+		}
+		klog.V(4).Infof("%v %s/%s changed, enqueue gpa %v", kind, target.GetNamespace(), target.GetName(), key)
+		a.queue.Add(key)
+	}
+}
+
+// targetChanged returns true if the spec or the replicas of the target changed, updates of
+// other status fields are ignored to avoid reconciling on every status heartbeat.
+func targetChanged(old, cur interface{}) bool {
+	oldMeta, err := apimeta.Accessor(old)
+	if err != nil {
+		return true
+	}
+	curMeta, err := apimeta.Accessor(cur)
+	if err != nil {
+		return true
+	}
+	if oldMeta.GetGeneration() != curMeta.GetGeneration() {
+		return true
+	}
+	oldReplicas, oldReady, ok := targetReplicas(old)
+	if !ok {
+		return oldMeta.GetResourceVersion() != curMeta.GetResourceVersion()
+	}
+	curReplicas, curReady, _ := targetReplicas(cur)
+	return oldReplicas != curReplicas || oldReady != curReady
+}
+
+// targetReplicas returns the status replicas and ready replicas of known workloads
+func targetReplicas(obj interface{}) (replicas, readyReplicas int32, ok bool) {
+	switch t := obj.(type) {
+	case *appsv1.Deployment:
+		return t.Status.Replicas, t.Status.ReadyReplicas, true
+	case *appsv1.StatefulSet:
+		return t.Status.Replicas, t.Status.ReadyReplicas, true
+	case *appsv1.ReplicaSet:
+		return t.Status.Replicas, t.Status.ReadyReplicas, true
+	}
+	return 0, 0, false
+}
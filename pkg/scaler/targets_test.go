@@ -0,0 +1,117 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	autoscalingv1alpha1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	autoscalinglisters "github.com/ocgi/general-pod-autoscaler/pkg/client/listers/autoscaling/v1alpha1"
+)
+
+func TestTargetChangeEnqueuesGPA(t *testing.T) {
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Generation: 1},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	kubeClient := fake.NewSimpleClientset(deployment)
+
+	gpaIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, gpa := range []*autoscalingv1alpha1.GeneralPodAutoscaler{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-gpa", Namespace: "default"},
+			Spec: autoscalingv1alpha1.GeneralPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv1alpha1.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-gpa", Namespace: "default"},
+			Spec: autoscalingv1alpha1.GeneralPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv1alpha1.CrossVersionObjectReference{Kind: "Deployment", Name: "other"},
+			},
+		},
+	} {
+		assert.NoError(t, gpaIndexer.Add(gpa))
+	}
+
+	controller := &GeneralController{
+		gpaLister: autoscalinglisters.NewGeneralPodAutoscalerLister(gpaIndexer),
+		queue:     workqueue.NewRateLimitingQueue(NewDefaultGPARateLimiter(time.Hour)),
+	}
+	defer controller.queue.ShutDown()
+
+	factory := informers.NewSharedInformerFactory(kubeClient, 0)
+	controller.AddTargetInformer("Deployment", factory.Apps().V1().Deployments().Informer())
+	stop := make(chan struct{})
+	defer close(stop)
+	factory.Start(stop)
+	cache.WaitForCacheSync(stop, controller.targetListersSynced...)
+
+	// drain the enqueue of the initial list
+	waitForKey(t, controller.queue, "default/web-gpa")
+	assert.Equal(t, 0, controller.queue.Len())
+
+	// an edit of the replicas enqueues the gpa of the deployment
+	updated := deployment.DeepCopy()
+	newReplicas := int32(5)
+	updated.Spec.Replicas = &newReplicas
+	updated.Generation = 2
+	_, err := kubeClient.AppsV1().Deployments("default").Update(updated)
+	assert.NoError(t, err)
+	waitForKey(t, controller.queue, "default/web-gpa")
+	assert.Equal(t, 0, controller.queue.Len())
+}
+
+func TestTargetChanged(t *testing.T) {
+	old := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Generation: 1, ResourceVersion: "1"},
+		Status:     appsv1.DeploymentStatus{Replicas: 2, ReadyReplicas: 2},
+	}
+	heartbeat := old.DeepCopy()
+	heartbeat.ResourceVersion = "2"
+	heartbeat.Status.ObservedGeneration = 1
+	assert.False(t, targetChanged(old, heartbeat))
+
+	crashed := old.DeepCopy()
+	crashed.Status.ReadyReplicas = 1
+	assert.True(t, targetChanged(old, crashed))
+
+	edited := old.DeepCopy()
+	edited.Generation = 2
+	assert.True(t, targetChanged(old, edited))
+}
+
+func waitForKey(t *testing.T, queue workqueue.RateLimitingInterface, expected string) {
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return queue.Len() > 0, nil
+	})
+	if err != nil {
+		t.Fatalf("gpa %v was not enqueued", expected)
+	}
+	key, _ := queue.Get()
+	queue.Done(key)
+	assert.Equal(t, expected, key)
+}
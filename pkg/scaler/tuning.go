@@ -0,0 +1,128 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/validation"
+)
+
+const (
+	// overrideToleranceKey overrides the tolerance of the controller for a GPA while it is set
+	overrideToleranceKey = "autoscaling.ocgi.io/override-tolerance"
+	// overrideScaleUpStabilizationKey overrides the scale up stabilization windows of a GPA while it is set
+	overrideScaleUpStabilizationKey = "autoscaling.ocgi.io/override-scale-up-stabilization-seconds"
+	// overrideScaleDownStabilizationKey overrides the scale down stabilization windows of a GPA while it is set
+	overrideScaleDownStabilizationKey = "autoscaling.ocgi.io/override-scale-down-stabilization-seconds"
+)
+
+// parseToleranceOverride returns the tolerance of the override-tolerance annotation, nil if it is not set
+func parseToleranceOverride(gpa *autoscaling.GeneralPodAutoscaler) (*float64, error) {
+	value, ok := gpa.Annotations[overrideToleranceKey]
+	if !ok {
+		return nil, nil
+	}
+	tolerance, err := strconv.ParseFloat(value, 64)
+	if err != nil || tolerance < 0 || tolerance >= 1 {
+		return nil, fmt.Errorf("invalid %s %q, must be a number in [0, 1)", overrideToleranceKey, value)
+	}
+	return &tolerance, nil
+}
+
+// parseStabilizationOverride returns the seconds of the stabilization override annotation, nil if it is not set
+func parseStabilizationOverride(gpa *autoscaling.GeneralPodAutoscaler, key string) (*int32, error) {
+	value, ok := gpa.Annotations[key]
+	if !ok {
+		return nil, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || seconds < 0 || seconds > int64(validation.MaxStabilizationWindowSeconds) {
+		return nil, fmt.Errorf("invalid %s %q, must be an integer in [0, %d]", key, value,
+			validation.MaxStabilizationWindowSeconds)
+	}
+	override := int32(seconds)
+	return &override, nil
+}
+
+// applyTuningOverride replaces the tolerance and the stabilization windows of the behavior by the
+// override-tolerance and override-*-stabilization-seconds annotations, so that the decisions of a GPA can be
+// tuned during an experiment without editing the spec. It returns the tolerance of this decision, the given
+// tolerance unless overridden. The overrides are reported in the status and by the TuningOverridden condition,
+// the tolerance and the windows of the spec apply again once the annotations are removed. Invalid overrides are
+// ignored altogether.
+func (a *DecisionEngine) applyTuningOverride(gpa *autoscaling.GeneralPodAutoscaler, tolerance float64) float64 {
+	gpa.Status.TuningOverride = nil
+	override, err := parseToleranceOverride(gpa)
+	var scaleUp, scaleDown *int32
+	if err == nil {
+		scaleUp, err = parseStabilizationOverride(gpa, overrideScaleUpStabilizationKey)
+	}
+	if err == nil {
+		scaleDown, err = parseStabilizationOverride(gpa, overrideScaleDownStabilizationKey)
+	}
+	if err != nil {
+		klog.Warningf("Ignore the tuning override of gpa %s/%s: %v", gpa.Namespace, gpa.Name, err)
+		a.eventRecorder.Event(gpa, v1.EventTypeWarning, "InvalidTuningOverride", err.Error())
+		setCondition(gpa, autoscaling.TuningOverridden, v1.ConditionFalse, "InvalidOverride",
+			"the tuning override is ignored: %v", err)
+		return tolerance
+	}
+	if override == nil && scaleUp == nil && scaleDown == nil {
+		// only reported once the tuning was overridden, to tell that it is restored
+		if getCondition(gpa.Status.Conditions, autoscaling.TuningOverridden) != nil {
+			setCondition(gpa, autoscaling.TuningOverridden, v1.ConditionFalse, "NoOverride",
+				"the tolerance and the stabilization windows of the controller and the spec apply")
+		}
+		return tolerance
+	}
+	if override != nil {
+		tolerance = *override
+	}
+	if scaleUp != nil {
+		for _, rules := range allScalingRules(gpa.Spec.Behavior, true) {
+			rules.StabilizationWindowSeconds = scaleUp
+		}
+	}
+	if scaleDown != nil {
+		for _, rules := range allScalingRules(gpa.Spec.Behavior, false) {
+			rules.StabilizationWindowSeconds = scaleDown
+		}
+	}
+	gpa.Status.TuningOverride = &autoscaling.TuningOverride{
+		Tolerance:                           override,
+		ScaleUpStabilizationWindowSeconds:   scaleUp,
+		ScaleDownStabilizationWindowSeconds: scaleDown,
+	}
+	setCondition(gpa, autoscaling.TuningOverridden, v1.ConditionTrue, "OverriddenByAnnotations",
+		"the tolerance is %v and the stabilization windows are overridden by the %s, %s and %s annotations",
+		tolerance, overrideToleranceKey, overrideScaleUpStabilizationKey, overrideScaleDownStabilizationKey)
+	return tolerance
+}
+
+// downscaleStabilisationWindowFor returns the scale down stabilization window of the GPA without scaling rules,
+// the window of the controller unless overridden by the annotation.
+func (a *DecisionEngine) downscaleStabilisationWindowFor(gpa *autoscaling.GeneralPodAutoscaler) time.Duration {
+	if override := gpa.Status.TuningOverride; override != nil && override.ScaleDownStabilizationWindowSeconds != nil {
+		return time.Duration(*override.ScaleDownStabilizationWindowSeconds) * time.Second
+	}
+	return a.downscaleStabilisationWindow
+}
@@ -18,6 +18,7 @@ package scaler
 import (
 	"encoding/json"
 
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 )
@@ -79,3 +80,21 @@ func CreateMergePatch(original, new interface{}) ([]byte, error) {
 	}
 	return patch, nil
 }
+
+// IsDeploymentRollingOut returns true if the deployment has not finished rolling out the latest spec,
+// i.e. the spec is not observed yet, or not all replicas are updated and available.
+func IsDeploymentRollingOut(deployment *appsv1.Deployment) bool {
+	if deployment.Spec.Paused {
+		return false
+	}
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return true
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	return status.UpdatedReplicas < replicas || status.Replicas > status.UpdatedReplicas ||
+		status.AvailableReplicas < status.UpdatedReplicas
+}
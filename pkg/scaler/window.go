@@ -0,0 +1,116 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	metricsclient "github.com/ocgi/general-pod-autoscaler/pkg/metrics"
+)
+
+// windowedMetricsClient serves the averages of the pods, object and external metrics over the window instead of
+// their latest values. The metrics APIs only serve the latest values, so the values got on the syncs of the GPA
+// are recorded as its metric samples, and the average of the samples within the window is served.
+type windowedMetricsClient struct {
+	metricsclient.MetricsClient
+	engine *DecisionEngine
+	key    string
+	window time.Duration
+}
+
+// windowedReplicaCalc returns the replica calculator getting the metrics of the GPA averaged over the window,
+// or the replica calculator of the engine if the window is not set.
+func (a *DecisionEngine) windowedReplicaCalc(gpa *autoscaling.GeneralPodAutoscaler, windowSeconds *int32) *ReplicaCalculator {
+	if windowSeconds == nil || *windowSeconds <= 0 {
+		return a.replicaCalc
+	}
+	calc := *a.replicaCalc
+	calc.metricsClient = &windowedMetricsClient{
+		MetricsClient: a.replicaCalc.metricsClient,
+		engine:        a,
+		key:           gpa.Namespace + "/" + gpa.Name,
+		window:        time.Duration(*windowSeconds) * time.Second,
+	}
+	return &calc
+}
+
+// average records the value as a sample of the metric and returns the average of the samples within the window
+func (c *windowedMetricsClient) average(metricName string, value int64, timestamp time.Time) int64 {
+	if timestamp.IsZero() {
+		timestamp = c.engine.clock.Now()
+	}
+	samples := c.engine.recordMetricSample(c.key, metricName,
+		timestampedMetricSample{value: value, timestamp: timestamp}, c.window)
+	var sum int64
+	for _, sample := range samples {
+		sum += sample.value
+	}
+	return sum / int64(len(samples))
+}
+
+// GetRawMetric implements metrics.MetricsClient, the samples of the pods which are gone are dropped
+func (c *windowedMetricsClient) GetRawMetric(metricName string, namespace string, selector labels.Selector,
+	metricSelector labels.Selector) (metricsclient.PodMetricsInfo, time.Time, error) {
+	metrics, timestamp, err := c.MetricsClient.GetRawMetric(metricName, namespace, selector, metricSelector)
+	if err != nil {
+		return metrics, timestamp, err
+	}
+	prefix := fmt.Sprintf("windowed pods metric %s(%s)/", metricName, metricSelector)
+	for pod, metric := range metrics {
+		metric.Value = c.average(prefix+pod, metric.Value, metric.Timestamp)
+		metrics[pod] = metric
+	}
+	for name := range c.engine.metricSamples[c.key] {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if _, ok := metrics[strings.TrimPrefix(name, prefix)]; !ok {
+			delete(c.engine.metricSamples[c.key], name)
+		}
+	}
+	return metrics, timestamp, nil
+}
+
+// GetObjectMetric implements metrics.MetricsClient
+func (c *windowedMetricsClient) GetObjectMetric(metricName string, namespace string,
+	objectRef *autoscaling.CrossVersionObjectReference, metricSelector labels.Selector) (int64, time.Time, error) {
+	value, timestamp, err := c.MetricsClient.GetObjectMetric(metricName, namespace, objectRef, metricSelector)
+	if err != nil {
+		return value, timestamp, err
+	}
+	name := fmt.Sprintf("windowed object metric %s(%s) of %s %s", metricName, metricSelector, objectRef.Kind, objectRef.Name)
+	return c.average(name, value, timestamp), timestamp, nil
+}
+
+// GetExternalMetric implements metrics.MetricsClient, the values of the metric are summed up to a single value
+// as the replica calculator does, and its average is served.
+func (c *windowedMetricsClient) GetExternalMetric(metricName string, namespace string,
+	selector labels.Selector) ([]int64, time.Time, error) {
+	values, timestamp, err := c.MetricsClient.GetExternalMetric(metricName, namespace, selector)
+	if err != nil {
+		return values, timestamp, err
+	}
+	var sum int64
+	for _, value := range values {
+		sum += value
+	}
+	name := fmt.Sprintf("windowed external metric %s(%s)", metricName, selector)
+	return []int64{c.average(name, sum, timestamp)}, timestamp, nil
+}
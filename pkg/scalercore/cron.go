@@ -15,9 +15,13 @@
 package scalercore
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/robfig/cron"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/klog"
 
 	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
@@ -26,26 +30,142 @@ import (
 var _ Scaler = &CronScaler{}
 var recordScheduleName = ""
 
+// scheduleFields are the fields of the standard crontab format, seconds are not supported
+var scheduleFields = []string{"minute", "hour", "day of month", "month", "day of week"}
+
+// ParseSchedule parses the schedule of a time range in the standard crontab format
+// (minute, hour, day of month, month, day of week) or a descriptor such as "@every 1h".
+// If the schedule is malformed, the error points to the field which can not be parsed.
+func ParseSchedule(schedule string) (cron.Schedule, error) {
+	sched, err := cron.ParseStandard(schedule)
+	if err == nil {
+		return sched, nil
+	}
+	if strings.HasPrefix(strings.TrimSpace(schedule), "@") {
+		return nil, err
+	}
+	fields := strings.Fields(schedule)
+	if len(fields) != len(scheduleFields) {
+		return nil, fmt.Errorf("expected %d fields (%s), found %d", len(scheduleFields),
+			strings.Join(scheduleFields, ", "), len(fields))
+	}
+	for i, f := range fields {
+		probe := []string{"*", "*", "*", "*", "*"}
+		probe[i] = f
+		if _, fieldErr := cron.ParseStandard(strings.Join(probe, " ")); fieldErr != nil {
+			return nil, fmt.Errorf("invalid %s field %q at position %d: %v", scheduleFields[i], f, i+1, fieldErr)
+		}
+	}
+	return nil, err
+}
+
+// ScheduleMatches returns true if the minute of now matches the schedule
+func ScheduleMatches(schedule string, now time.Time) (bool, error) {
+	sched, err := ParseSchedule(schedule)
+	if err != nil {
+		return false, err
+	}
+	minute := now.Truncate(time.Minute)
+	return sched.Next(minute.Add(-time.Second)).Equal(minute), nil
+}
+
+// NextSchedule returns the next time after now a range is scheduled, and the desired replicas of the range.
+// If several ranges are scheduled at that time, the largest desired replicas is returned. The ranges which
+// can not be parsed are skipped, nil is returned if no range is scheduled.
+func NextSchedule(ranges []v1alpha1.TimeRange, now time.Time) (*time.Time, int32) {
+	var next *time.Time
+	var replicas int32
+	for _, t := range ranges {
+		sched, err := ParseSchedule(t.Schedule)
+		if err != nil {
+			klog.Errorf("Skip invalid schedule %q: %v", t.Schedule, err)
+			continue
+		}
+		scheduled := sched.Next(now)
+		if scheduled.IsZero() {
+			continue
+		}
+		switch {
+		case next == nil || scheduled.Before(*next):
+			next = &scheduled
+			replicas = t.DesiredReplicas
+		case scheduled.Equal(*next) && t.DesiredReplicas > replicas:
+			replicas = t.DesiredReplicas
+		}
+	}
+	return next, replicas
+}
+
 // CronScaler is a crontab GPA
 type CronScaler struct {
-	ranges []v1alpha1.TimeRange
-	name   string
-	now    time.Time
+	ranges     []v1alpha1.TimeRange
+	exceptions *v1alpha1.TimeExceptions
+	// configMapNamespacer is used to get the ConfigMap of the exception dates
+	configMapNamespacer v1core.ConfigMapsGetter
+	name                string
+	now                 time.Time
 }
 
 // NewCronScaler initializer crontab GPA
-func NewCronScaler(ranges []v1alpha1.TimeRange) Scaler {
-	return &CronScaler{ranges: ranges, name: Cron, now: time.Now()}
+func NewCronScaler(mode *v1alpha1.TimeMode, configMapNamespacer v1core.ConfigMapsGetter) Scaler {
+	return NewCronScalerAt(mode, configMapNamespacer, time.Now())
+}
+
+// NewCronScalerAt initializer crontab GPA matching the time ranges at now
+func NewCronScalerAt(mode *v1alpha1.TimeMode, configMapNamespacer v1core.ConfigMapsGetter, now time.Time) *CronScaler {
+	return &CronScaler{ranges: mode.TimeRanges, exceptions: mode.Exceptions, configMapNamespacer: configMapNamespacer,
+		name: Cron, now: now}
 }
 
 // GetReplicas return replicas  recommend by crontab GPA
 func (s *CronScaler) GetReplicas(gpa *v1alpha1.GeneralPodAutoscaler, currentReplicas int32) (int32, error) {
+	exception, err := s.isExceptionDate(gpa.Namespace)
+	if err != nil {
+		return currentReplicas, err
+	}
+	if exception {
+		// the schedules are skipped, the min replicas apply
+		minReplicas := int32(1)
+		if gpa.Spec.MinReplicas != nil {
+			minReplicas = *gpa.Spec.MinReplicas
+		}
+		klog.Infof("GPA %s/%s: %s is an exception date, skip the schedules and recommend %v replicas",
+			gpa.Namespace, gpa.Name, s.now.Format(v1alpha1.TimeExceptionDateLayout), minReplicas)
+		return minReplicas, nil
+	}
+	max, err := s.scheduledReplicas(gpa)
+	if err != nil {
+		klog.Error(err)
+		return currentReplicas, nil
+	}
+	if max == 0 {
+		klog.Info("Recommend 0 replicas, use current replicas number")
+		max = gpa.Status.DesiredReplicas
+	}
+	return max, nil
+}
+
+// ActiveReplicas returns the desired replicas of the time ranges active now, active is false if no range is
+// active or now is an exception date.
+func (s *CronScaler) ActiveReplicas(gpa *v1alpha1.GeneralPodAutoscaler) (replicas int32, active bool, err error) {
+	exception, err := s.isExceptionDate(gpa.Namespace)
+	if err != nil || exception {
+		return 0, false, err
+	}
+	replicas, err = s.scheduledReplicas(gpa)
+	if err != nil {
+		return 0, false, err
+	}
+	return replicas, replicas > 0, nil
+}
+
+// scheduledReplicas returns the largest desired replicas of the time ranges active now, 0 if none is active
+func (s *CronScaler) scheduledReplicas(gpa *v1alpha1.GeneralPodAutoscaler) (int32, error) {
 	var max int32 = 0
 	for _, t := range s.ranges {
 		misMatch, finalMatch, err := s.getFinalMatchAndMisMatch(gpa, t.Schedule)
 		if err != nil {
-			klog.Error(err)
-			return currentReplicas, nil
+			return 0, err
 		}
 		klog.Infof("firstMisMatch: %v, finalMatch: %v", misMatch, finalMatch)
 		if finalMatch == nil {
@@ -57,10 +177,6 @@ func (s *CronScaler) GetReplicas(gpa *v1alpha1.GeneralPodAutoscaler, currentRepl
 		}
 		klog.Infof("Schedule %v recommend %v replicas, desire: %v", t.Schedule, max, t.DesiredReplicas)
 	}
-	if max == 0 {
-		klog.Info("Recommend 0 replicas, use current replicas number")
-		max = gpa.Status.DesiredReplicas
-	}
 	return max, nil
 }
 
@@ -69,8 +185,55 @@ func (s *CronScaler) ScalerName() string {
 	return s.name
 }
 
+// isExceptionDate returns true if now is on one of the exception dates
+func (s *CronScaler) isExceptionDate(namespace string) (bool, error) {
+	if s.exceptions == nil {
+		return false, nil
+	}
+	dates := s.exceptions.Dates
+	if ref := s.exceptions.ConfigMapKeyRef; ref != nil {
+		if s.configMapNamespacer == nil {
+			return false, fmt.Errorf("unable to get the exception dates of ConfigMap %s/%s", namespace, ref.Name)
+		}
+		cm, err := s.configMapNamespacer.ConfigMaps(namespace).Get(ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("get the exception dates of ConfigMap %s/%s failed: %v", namespace, ref.Name, err)
+		}
+		data, ok := cm.Data[ref.Key]
+		if !ok {
+			return false, fmt.Errorf("key %s not found in ConfigMap %s/%s", ref.Key, namespace, ref.Name)
+		}
+		dates = append(ParseExceptionDates(data), dates...)
+	}
+	year, month, day := s.now.Date()
+	for _, d := range dates {
+		date, err := time.ParseInLocation(v1alpha1.TimeExceptionDateLayout, d, s.now.Location())
+		if err != nil {
+			return false, fmt.Errorf("invalid exception date %q: %v", d, err)
+		}
+		if y, m, d := date.Date(); y == year && m == month && d == day {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ParseExceptionDates returns the exception dates listed by the data of a ConfigMap, a date per line,
+// empty lines and lines starting with '#' are ignored
+func ParseExceptionDates(data string) []string {
+	var dates []string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		dates = append(dates, line)
+	}
+	return dates
+}
+
 func (s *CronScaler) getFinalMatchAndMisMatch(gpa *v1alpha1.GeneralPodAutoscaler, schedule string) (*time.Time, *time.Time, error) {
-	sched, err := cron.ParseStandard(schedule)
+	sched, err := ParseSchedule(schedule)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -18,7 +18,9 @@ import (
 	"testing"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
 )
@@ -221,3 +223,184 @@ func Test_GetReplicas(t *testing.T) {
 		})
 	}
 }
+
+func TestGetReplicasOnExceptionDates(t *testing.T) {
+	// 2020-12-17 17:04:41 in UTC, the dates are parsed in the time zone of the schedules
+	now := time.Date(2020, 12, 18, 1, 4, 41, 0, time.FixedZone("UTC+8", 8*3600))
+	minReplicas := int32(2)
+	gpa := &v1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "gpa",
+			Namespace:         "default",
+			CreationTimestamp: metav1.Time{Time: now.Add(-60 * time.Minute)},
+		},
+		Spec: v1alpha1.GeneralPodAutoscalerSpec{MinReplicas: &minReplicas},
+	}
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "holidays", Namespace: "default"},
+		Data:       map[string]string{"dates": "# new year\n2020-12-31\n\n2020-12-18\n"},
+	})
+	for _, c := range []struct {
+		name       string
+		exceptions *v1alpha1.TimeExceptions
+		desired    int32
+		expectErr  bool
+	}{
+		{
+			name:    "no exceptions",
+			desired: 3,
+		},
+		{
+			name:       "inline exception date",
+			exceptions: &v1alpha1.TimeExceptions{Dates: []string{"2020-12-18"}},
+			desired:    2,
+		},
+		{
+			name:       "the date in UTC is not an exception",
+			exceptions: &v1alpha1.TimeExceptions{Dates: []string{"2020-12-17", "2020-12-19"}},
+			desired:    3,
+		},
+		{
+			name: "exception date of configmap",
+			exceptions: &v1alpha1.TimeExceptions{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "holidays"},
+				Key:                  "dates",
+			}},
+			desired: 2,
+		},
+		{
+			name: "missing configmap",
+			exceptions: &v1alpha1.TimeExceptions{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "missing"},
+				Key:                  "dates",
+			}},
+			expectErr: true,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			mode := &v1alpha1.TimeMode{
+				TimeRanges: []v1alpha1.TimeRange{{Schedule: "*/1 1-3 * * *", DesiredReplicas: 3}},
+				Exceptions: c.exceptions,
+			}
+			cron := NewCronScaler(mode, client.CoreV1()).(*CronScaler)
+			cron.now = now
+			actual, err := cron.GetReplicas(gpa, 1)
+			if c.expectErr {
+				if err == nil {
+					t.Errorf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			if actual != c.desired {
+				t.Errorf("desired: %v, actual: %v", c.desired, actual)
+			}
+		})
+	}
+}
+
+func TestNextSchedule(t *testing.T) {
+	now := time.Date(2020, 12, 18, 9, 4, 41, 0, time.UTC)
+	for _, c := range []struct {
+		name     string
+		ranges   []v1alpha1.TimeRange
+		next     *time.Time
+		replicas int32
+	}{
+		{
+			name:   "no ranges",
+			ranges: nil,
+		},
+		{
+			name:     "single range",
+			ranges:   []v1alpha1.TimeRange{{Schedule: "30 10 * * *", DesiredReplicas: 4}},
+			next:     timePtr(time.Date(2020, 12, 18, 10, 30, 0, 0, time.UTC)),
+			replicas: 4,
+		},
+		{
+			name: "earliest range wins",
+			ranges: []v1alpha1.TimeRange{
+				{Schedule: "0 12 * * *", DesiredReplicas: 8},
+				{Schedule: "*/15 9-18 * * *", DesiredReplicas: 2},
+				{Schedule: "0 0 * * MON", DesiredReplicas: 1},
+			},
+			next:     timePtr(time.Date(2020, 12, 18, 9, 15, 0, 0, time.UTC)),
+			replicas: 2,
+		},
+		{
+			name: "largest replicas at the same time",
+			ranges: []v1alpha1.TimeRange{
+				{Schedule: "0 12 * * *", DesiredReplicas: 3},
+				{Schedule: "0 */6 * * *", DesiredReplicas: 6},
+			},
+			next:     timePtr(time.Date(2020, 12, 18, 12, 0, 0, 0, time.UTC)),
+			replicas: 6,
+		},
+		{
+			name: "next day",
+			ranges: []v1alpha1.TimeRange{
+				{Schedule: "0 8 * * *", DesiredReplicas: 5},
+			},
+			next:     timePtr(time.Date(2020, 12, 19, 8, 0, 0, 0, time.UTC)),
+			replicas: 5,
+		},
+		{
+			name: "invalid schedule is skipped",
+			ranges: []v1alpha1.TimeRange{
+				{Schedule: "61 * * * *", DesiredReplicas: 9},
+				{Schedule: "0 10 * * *", DesiredReplicas: 3},
+			},
+			next:     timePtr(time.Date(2020, 12, 18, 10, 0, 0, 0, time.UTC)),
+			replicas: 3,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			next, replicas := NextSchedule(c.ranges, now)
+			if c.next == nil {
+				if next != nil {
+					t.Errorf("expected no next schedule, got: %v", next)
+				}
+				return
+			}
+			if next == nil || !next.Equal(*c.next) {
+				t.Errorf("desired next schedule: %v, got: %v", c.next, next)
+			}
+			if replicas != c.replicas {
+				t.Errorf("desired next replicas: %v, got: %v", c.replicas, replicas)
+			}
+		})
+	}
+}
+
+func TestScheduleMatches(t *testing.T) {
+	// a friday
+	now := time.Date(2020, 12, 18, 9, 4, 41, 0, time.UTC)
+	for _, c := range []struct {
+		schedule string
+		now      time.Time
+		matches  bool
+	}{
+		{schedule: "* 9-17 * * 1-5", now: now, matches: true},
+		{schedule: "* 9-17 * * 1-5", now: now.Add(-5 * time.Minute), matches: false},
+		{schedule: "* 9-17 * * 1-5", now: now.Add(24 * time.Hour), matches: false},
+		{schedule: "4 9 * * *", now: now, matches: true},
+		{schedule: "5 9 * * *", now: now, matches: false},
+	} {
+		matches, err := ScheduleMatches(c.schedule, c.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matches != c.matches {
+			t.Errorf("schedule %q at %v: desired matches %v, got: %v", c.schedule, c.now, c.matches, matches)
+		}
+	}
+	if _, err := ScheduleMatches("* 9-17 * *", now); err == nil {
+		t.Errorf("expected an error on the invalid schedule")
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalercore
+
+import (
+	"fmt"
+	"math"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	autoscalingv1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	autoscalinglisters "github.com/ocgi/general-pod-autoscaler/pkg/client/listers/autoscaling/v1alpha1"
+)
+
+var _ Scaler = &MirrorScaler{}
+
+// MirrorScaler recommends the replicas from the desired replicas of another GPA
+type MirrorScaler struct {
+	mode      *autoscalingv1.MirrorMode
+	gpaLister autoscalinglisters.GeneralPodAutoscalerLister
+}
+
+// NewMirrorScaler creates a MirrorScaler getting the mirrored GPA from the lister
+func NewMirrorScaler(mode *autoscalingv1.MirrorMode, gpaLister autoscalinglisters.GeneralPodAutoscalerLister) Scaler {
+	return &MirrorScaler{mode: mode, gpaLister: gpaLister}
+}
+
+// GetReplicas returns the replicas of the desired replicas of the mirrored GPA, the GPA failing to get, e.g. not
+// existing, is an error so that the current replicas are kept.
+func (s *MirrorScaler) GetReplicas(gpa *autoscalingv1.GeneralPodAutoscaler, currentReplicas int32) (int32, error) {
+	if s.gpaLister == nil {
+		return 0, fmt.Errorf("the GPAs are not watched by the controller")
+	}
+	if s.mode.Name == gpa.Name {
+		return 0, fmt.Errorf("GPA %s/%s can not mirror itself", gpa.Namespace, gpa.Name)
+	}
+	source, err := s.gpaLister.GeneralPodAutoscalers(gpa.Namespace).Get(s.mode.Name)
+	if errors.IsNotFound(err) {
+		return 0, fmt.Errorf("mirrored GPA %s/%s not found", gpa.Namespace, s.mode.Name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get mirrored GPA %s/%s failed: %v", gpa.Namespace, s.mode.Name, err)
+	}
+	return MirrorReplicas(s.mode, source.Status.DesiredReplicas), nil
+}
+
+// ScalerName returns the name of the scaler
+func (s *MirrorScaler) ScalerName() string {
+	return Mirror
+}
+
+// MirrorReplicas returns ceil(factor * desiredReplicas) + offset of the mode, never less than 0
+func MirrorReplicas(mode *autoscalingv1.MirrorMode, desiredReplicas int32) int32 {
+	factor := 1.0
+	if mode.Factor != nil {
+		factor = *mode.Factor
+	}
+	// the epsilon keeps e.g. 0.1 * 30 from being rounded up to 4
+	replicas := int32(math.Ceil(factor*float64(desiredReplicas)-1e-9)) + mode.Offset
+	return max32(replicas, 0)
+}
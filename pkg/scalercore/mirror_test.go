@@ -0,0 +1,102 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalercore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	autoscalingv1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	autoscalinglisters "github.com/ocgi/general-pod-autoscaler/pkg/client/listers/autoscaling/v1alpha1"
+)
+
+func TestMirrorReplicas(t *testing.T) {
+	half := 0.5
+	tenth := 0.1
+	for _, c := range []struct {
+		name     string
+		mode     autoscalingv1.MirrorMode
+		desired  int32
+		expected int32
+	}{
+		{
+			name:     "same replicas by default",
+			mode:     autoscalingv1.MirrorMode{Name: "api"},
+			desired:  7,
+			expected: 7,
+		},
+		{
+			name:     "factor rounded up",
+			mode:     autoscalingv1.MirrorMode{Name: "api", Factor: &half},
+			desired:  7,
+			expected: 4,
+		},
+		{
+			name:     "factor without float error",
+			mode:     autoscalingv1.MirrorMode{Name: "api", Factor: &tenth},
+			desired:  30,
+			expected: 3,
+		},
+		{
+			name:     "offset",
+			mode:     autoscalingv1.MirrorMode{Name: "api", Factor: &half, Offset: 2},
+			desired:  4,
+			expected: 4,
+		},
+		{
+			name:     "negative offset never below zero",
+			mode:     autoscalingv1.MirrorMode{Name: "api", Offset: -3},
+			desired:  2,
+			expected: 0,
+		},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, MirrorReplicas(&c.mode, c.desired))
+		})
+	}
+}
+
+func TestMirrorScalerGetReplicas(t *testing.T) {
+	gpas := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	lister := autoscalinglisters.NewGeneralPodAutoscalerLister(gpas)
+	gpa := &autoscalingv1.GeneralPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "default"}}
+	scaler := NewMirrorScaler(&autoscalingv1.MirrorMode{Name: "api", Offset: 1}, lister)
+
+	_, err := scaler.GetReplicas(gpa, 3)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "not found")
+	}
+
+	gpas.Add(&autoscalingv1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Status:     autoscalingv1.GeneralPodAutoscalerStatus{DesiredReplicas: 5},
+	})
+	replicas, err := scaler.GetReplicas(gpa, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(6), replicas)
+
+	// the GPAs of other namespaces are never mirrored
+	other := gpa.DeepCopy()
+	other.Namespace = "other"
+	_, err = scaler.GetReplicas(other, 3)
+	assert.Error(t, err)
+
+	_, err = NewMirrorScaler(&autoscalingv1.MirrorMode{Name: "worker"}, lister).GetReplicas(gpa, 3)
+	assert.Error(t, err)
+}
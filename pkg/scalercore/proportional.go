@@ -0,0 +1,114 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalercore
+
+import (
+	"fmt"
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	autoscalingv1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+var _ Scaler = &ClusterProportionalScaler{}
+
+// ClusterProportionalScaler recommends the replicas in proportion to the nodes and the allocatable cores
+type ClusterProportionalScaler struct {
+	mode       *autoscalingv1.ClusterProportionalMode
+	nodeLister corelisters.NodeLister
+}
+
+// NewClusterProportionalScaler creates a ClusterProportionalScaler counting the nodes listed by the lister
+func NewClusterProportionalScaler(mode *autoscalingv1.ClusterProportionalMode, nodeLister corelisters.NodeLister) Scaler {
+	return &ClusterProportionalScaler{mode: mode, nodeLister: nodeLister}
+}
+
+// GetReplicas returns the replicas of the current size of the cluster
+func (s *ClusterProportionalScaler) GetReplicas(gpa *autoscalingv1.GeneralPodAutoscaler, currentReplicas int32) (int32, error) {
+	if s.nodeLister == nil {
+		return 0, fmt.Errorf("the nodes are not watched by the controller")
+	}
+	nodes, err := s.nodeLister.List(labels.Everything())
+	if err != nil {
+		return 0, fmt.Errorf("list nodes failed: %v", err)
+	}
+	nodeCount, cores := ClusterSize(nodes, s.mode.IncludeUnschedulableNodes)
+	return ClusterProportionalReplicas(s.mode, nodeCount, cores), nil
+}
+
+// ScalerName returns the name of the scaler
+func (s *ClusterProportionalScaler) ScalerName() string {
+	return ClusterProportional
+}
+
+// ClusterSize returns the number of the nodes and their allocatable cores, the cordoned nodes are only counted
+// if includeUnschedulable is true.
+func ClusterSize(nodes []*v1.Node, includeUnschedulable bool) (int32, float64) {
+	var count int32
+	var cores float64
+	for _, node := range nodes {
+		if node.Spec.Unschedulable && !includeUnschedulable {
+			continue
+		}
+		count++
+		if cpu, ok := node.Status.Allocatable[v1.ResourceCPU]; ok {
+			cores += float64(cpu.MilliValue()) / 1000
+		}
+	}
+	return count, cores
+}
+
+// ClusterProportionalReplicas returns the larger of the replicas by the cores and by the nodes of the mode
+func ClusterProportionalReplicas(mode *autoscalingv1.ClusterProportionalMode, nodes int32, cores float64) int32 {
+	var replicas int32
+	if mode.Ladder != nil {
+		replicas = max32(ladderReplicas(mode.Ladder.CoresToReplicas, cores),
+			ladderReplicas(mode.Ladder.NodesToReplicas, float64(nodes)))
+	} else {
+		if mode.CoresPerReplica > 0 {
+			replicas = int32(math.Ceil(cores / mode.CoresPerReplica))
+		}
+		if mode.NodesPerReplica > 0 {
+			replicas = max32(replicas, int32(math.Ceil(float64(nodes)/mode.NodesPerReplica)))
+		}
+	}
+	if mode.PreventSinglePointFailure && nodes > 1 {
+		replicas = max32(replicas, 2)
+	}
+	return replicas
+}
+
+// ladderReplicas returns the replicas of the largest step not larger than the size
+func ladderReplicas(steps []autoscalingv1.ClusterProportionalStep, size float64) int32 {
+	var replicas int32
+	largest := int32(-1)
+	for _, step := range steps {
+		if float64(step.Size) <= size && step.Size > largest {
+			largest = step.Size
+			replicas = step.Replicas
+		}
+	}
+	return replicas
+}
+
+func max32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
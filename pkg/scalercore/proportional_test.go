@@ -0,0 +1,103 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalercore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscalingv1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func TestClusterSize(t *testing.T) {
+	nodes := []*v1.Node{
+		{Status: v1.NodeStatus{Allocatable: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")}}},
+		{Status: v1.NodeStatus{Allocatable: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1500m")}}},
+		{
+			Spec:   v1.NodeSpec{Unschedulable: true},
+			Status: v1.NodeStatus{Allocatable: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")}},
+		},
+	}
+	count, cores := ClusterSize(nodes, false)
+	assert.Equal(t, int32(2), count)
+	assert.Equal(t, 5.5, cores)
+
+	count, cores = ClusterSize(nodes, true)
+	assert.Equal(t, int32(3), count)
+	assert.Equal(t, 13.5, cores)
+}
+
+func TestClusterProportionalReplicas(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		mode     autoscalingv1.ClusterProportionalMode
+		nodes    int32
+		cores    float64
+		expected int32
+	}{
+		{
+			name:     "by cores",
+			mode:     autoscalingv1.ClusterProportionalMode{CoresPerReplica: 8, NodesPerReplica: 10},
+			nodes:    4,
+			cores:    33,
+			expected: 5,
+		},
+		{
+			name:     "by nodes",
+			mode:     autoscalingv1.ClusterProportionalMode{CoresPerReplica: 8, NodesPerReplica: 2},
+			nodes:    15,
+			cores:    30,
+			expected: 8,
+		},
+		{
+			name:     "single node never prevented",
+			mode:     autoscalingv1.ClusterProportionalMode{NodesPerReplica: 4, PreventSinglePointFailure: true},
+			nodes:    1,
+			expected: 1,
+		},
+		{
+			name: "ladder below the first step",
+			mode: autoscalingv1.ClusterProportionalMode{
+				CoresPerReplica: 1,
+				Ladder: &autoscalingv1.ClusterProportionalLadder{
+					CoresToReplicas: []autoscalingv1.ClusterProportionalStep{{Size: 64, Replicas: 3}, {Size: 16, Replicas: 2}},
+				},
+			},
+			nodes:    2,
+			cores:    8,
+			expected: 0,
+		},
+		{
+			name: "ladder of cores and nodes",
+			mode: autoscalingv1.ClusterProportionalMode{
+				Ladder: &autoscalingv1.ClusterProportionalLadder{
+					CoresToReplicas: []autoscalingv1.ClusterProportionalStep{{Size: 64, Replicas: 3}, {Size: 16, Replicas: 2}},
+					NodesToReplicas: []autoscalingv1.ClusterProportionalStep{{Size: 0, Replicas: 1}, {Size: 10, Replicas: 4}},
+				},
+			},
+			nodes:    8,
+			cores:    64,
+			expected: 3,
+		},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, ClusterProportionalReplicas(&c.mode, c.nodes, c.cores))
+		})
+	}
+}
@@ -22,6 +22,10 @@ const (
 	Webhook = "Webhook"
 	Event   = "Event"
 	Cron    = "Cron"
+	// ClusterProportional is the name of the ClusterProportionalScaler
+	ClusterProportional = "ClusterProportional"
+	// Mirror is the name of the MirrorScaler
+	Mirror = "Mirror"
 )
 
 type Scaler interface {
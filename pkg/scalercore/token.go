@@ -0,0 +1,113 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalercore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	autoscalingv1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// DefaultTokenHeader is the header the projected token is set in if not specified
+const DefaultTokenHeader = "Authorization"
+
+// DefaultTokenScheme prefixes the projected token in DefaultTokenHeader if the scheme is not specified
+const DefaultTokenScheme = "Bearer"
+
+// ProjectedTokens reads the token files projected into a directory of the controller, e.g. by a projected
+// volume of service account tokens. A token is read once and reused until its file is rotated, i.e. the
+// modification time or the size of the file changes.
+type ProjectedTokens struct {
+	dir string
+	// readFile reads the token files, it is replaced by the tests to count the reads
+	readFile func(filename string) ([]byte, error)
+
+	lock   sync.Mutex
+	tokens map[string]projectedToken
+}
+
+// projectedToken is a token read from the file of the modification time and size
+type projectedToken struct {
+	modTime time.Time
+	size    int64
+	token   string
+}
+
+// NewProjectedTokens creates a ProjectedTokens reading the token files in dir
+func NewProjectedTokens(dir string) *ProjectedTokens {
+	return &ProjectedTokens{
+		dir:      dir,
+		readFile: ioutil.ReadFile,
+		tokens:   map[string]projectedToken{},
+	}
+}
+
+// Token returns the token of the file of the name in the directory, the name must not refer to any file out of
+// the directory.
+func (p *ProjectedTokens) Token(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+		return "", fmt.Errorf("invalid token file %q, it must be the name of a file in the projected token directory", name)
+	}
+	path := filepath.Join(p.dir, name)
+	// the projected volumes rotate the files by swapping the symlink of the directory, the stat follows it
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("stat token file %s failed: %v", path, err)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if cached, ok := p.tokens[name]; ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.token, nil
+	}
+	raw, err := p.readFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read token file %s failed: %v", path, err)
+	}
+	token := strings.TrimSpace(string(raw))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", path)
+	}
+	p.tokens[name] = projectedToken{modTime: info.ModTime(), size: info.Size(), token: token}
+	return token, nil
+}
+
+// SetAuthHeader sets the token of the auth in the header of a request
+func (p *ProjectedTokens) SetAuthHeader(header http.Header, auth *autoscalingv1.ProjectedTokenAuth) error {
+	token, err := p.Token(auth.TokenFile)
+	if err != nil {
+		return err
+	}
+	name := auth.Header
+	if name == "" {
+		name = DefaultTokenHeader
+	}
+	scheme := auth.Scheme
+	if scheme == "" && http.CanonicalHeaderKey(name) == DefaultTokenHeader {
+		scheme = DefaultTokenScheme
+	}
+	if scheme != "" {
+		token = scheme + " " + token
+	}
+	header.Set(name, token)
+	return nil
+}
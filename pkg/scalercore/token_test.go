@@ -0,0 +1,149 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalercore
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	autoscalingv1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+// countingTokens returns ProjectedTokens of the dir counting the reads of the token files
+func countingTokens(dir string, reads *int) *ProjectedTokens {
+	tokens := NewProjectedTokens(dir)
+	tokens.readFile = func(filename string) ([]byte, error) {
+		*reads++
+		return ioutil.ReadFile(filename)
+	}
+	return tokens
+}
+
+func TestProjectedTokenRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tokens")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(path, []byte("first\n"), 0600))
+
+	var reads int
+	tokens := countingTokens(dir, &reads)
+	for i := 0; i < 3; i++ {
+		token, err := tokens.Token("token")
+		require.NoError(t, err)
+		assert.Equal(t, "first", token)
+	}
+	// the token is reused until the file is rotated
+	assert.Equal(t, 1, reads)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("second"), 0600))
+	rotated := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, rotated, rotated))
+	token, err := tokens.Token("token")
+	require.NoError(t, err)
+	assert.Equal(t, "second", token)
+	_, err = tokens.Token("token")
+	require.NoError(t, err)
+	assert.Equal(t, 2, reads)
+
+	// the rotated token file is removed
+	require.NoError(t, os.Remove(path))
+	_, err = tokens.Token("token")
+	assert.Error(t, err)
+}
+
+func TestProjectedTokenSymlinkRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tokens")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// the projected volumes link the files to the current data directory, which is swapped on rotations
+	for _, data := range []string{"..v1", "..v2"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, data), 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, data, "token"), []byte(data+"-token"), 0600))
+	}
+	require.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "token"), filepath.Join(dir, "token")))
+
+	var reads int
+	tokens := countingTokens(dir, &reads)
+	token, err := tokens.Token("token")
+	require.NoError(t, err)
+	assert.Equal(t, "..v1-token", token)
+
+	rotated := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "..v2", "token"), rotated, rotated))
+	require.NoError(t, os.Symlink("..v2", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+	token, err = tokens.Token("token")
+	require.NoError(t, err)
+	assert.Equal(t, "..v2-token", token)
+	assert.Equal(t, 2, reads)
+}
+
+func TestProjectedTokenInvalidNames(t *testing.T) {
+	tokens := NewProjectedTokens(os.TempDir())
+	for _, name := range []string{"", ".", "..", "../token", "/var/run/secrets/kubernetes.io/serviceaccount/token"} {
+		_, err := tokens.Token(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestProjectedTokenSetAuthHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tokens")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "token"), []byte("secret"), 0600))
+	tokens := NewProjectedTokens(dir)
+
+	for _, c := range []struct {
+		name     string
+		auth     autoscalingv1.ProjectedTokenAuth
+		header   string
+		expected string
+	}{
+		{
+			name:     "bearer by default",
+			auth:     autoscalingv1.ProjectedTokenAuth{TokenFile: "token"},
+			header:   "Authorization",
+			expected: "Bearer secret",
+		},
+		{
+			name:     "scheme",
+			auth:     autoscalingv1.ProjectedTokenAuth{TokenFile: "token", Scheme: "Token"},
+			header:   "Authorization",
+			expected: "Token secret",
+		},
+		{
+			name:     "other header without a scheme",
+			auth:     autoscalingv1.ProjectedTokenAuth{TokenFile: "token", Header: "X-Goog-Iam-Authorization-Token"},
+			header:   "X-Goog-Iam-Authorization-Token",
+			expected: "secret",
+		},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			header := http.Header{}
+			require.NoError(t, tokens.SetAuthHeader(header, &c.auth))
+			assert.Equal(t, c.expected, header.Get(c.header))
+		})
+	}
+}
@@ -15,18 +15,27 @@
 package scalercore
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog"
 
 	autoscalingv1 "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
 	"github.com/ocgi/general-pod-autoscaler/pkg/requests"
@@ -36,23 +45,78 @@ var client = http.Client{
 	Timeout: 15 * time.Second,
 }
 
+// caClients caches the clients trusting a CA bundle by the bundle, so that each webhook only trusts its own CAs
+var caClients sync.Map
+
+// DefaultHMACHeader is the header the request signature is set in if not specified
+const DefaultHMACHeader = "X-GPA-Signature"
+
 var _ Scaler = &WebhookScaler{}
 
 type WebhookScaler struct {
-	modeConfig *autoscalingv1.WebhookMode
-	name       string
+	modeConfig       *autoscalingv1.WebhookMode
+	secretNamespacer v1core.SecretsGetter
+	name             string
 }
 
-func NewWebhookScaler(modeConfig *autoscalingv1.WebhookMode) Scaler {
-	return &WebhookScaler{modeConfig: modeConfig, name: Webhook}
+func NewWebhookScaler(modeConfig *autoscalingv1.WebhookMode, secretNamespacer v1core.SecretsGetter) Scaler {
+	return &WebhookScaler{modeConfig: modeConfig, secretNamespacer: secretNamespacer, name: Webhook}
 }
 
+// GetReplicas queries the webhook servers and combines their replicas by the select policy, the servers failing
+// are skipped, an error is returned only if none of them succeeds.
 func (s *WebhookScaler) GetReplicas(gpa *autoscalingv1.GeneralPodAutoscaler, currentReplicas int32) (int32, error) {
 	if s.modeConfig == nil {
 		return 0, errors.New("webhookPolicy parameter must not be nil")
 	}
+	policy := autoscalingv1.FirstSuccessWebhookSelect
+	if s.modeConfig.SelectPolicy != nil {
+		policy = *s.modeConfig.SelectPolicy
+	}
+	endpoints := []*autoscalingv1.WebhookMode{s.modeConfig}
+	for i := range s.modeConfig.Endpoints {
+		endpoints = append(endpoints, &s.modeConfig.Endpoints[i])
+	}
+
+	var (
+		replicas int32
+		found    bool
+		errs     []error
+	)
+	for i, endpoint := range endpoints {
+		endpointReplicas, err := s.getEndpointReplicas(endpoint, gpa, currentReplicas)
+		if err != nil {
+			if len(endpoints) == 1 {
+				return 0, err
+			}
+			klog.Warningf("Webhook endpoint %d of gpa %s/%s failed: %v", i, gpa.Namespace, gpa.Name, err)
+			errs = append(errs, fmt.Errorf("endpoint %d: %v", i, err))
+			continue
+		}
+		switch {
+		case policy == autoscalingv1.MaxWebhookSelect:
+			if !found || endpointReplicas > replicas {
+				replicas = endpointReplicas
+			}
+		case policy == autoscalingv1.MinWebhookSelect:
+			if !found || endpointReplicas < replicas {
+				replicas = endpointReplicas
+			}
+		default:
+			return endpointReplicas, nil
+		}
+		found = true
+	}
+	if !found {
+		return 0, utilerrors.NewAggregate(errs)
+	}
+	return replicas, nil
+}
 
-	u, err := s.buildURLFromWebhookPolicy()
+// getEndpointReplicas requests the replicas from the webhook server of the endpoint
+func (s *WebhookScaler) getEndpointReplicas(endpoint *autoscalingv1.WebhookMode, gpa *autoscalingv1.GeneralPodAutoscaler,
+	currentReplicas int32) (int32, error) {
+	u, err := buildURLFromWebhookPolicy(endpoint)
 	if err != nil {
 		return 0, err
 	}
@@ -62,8 +126,9 @@ func (s *WebhookScaler) GetReplicas(gpa *autoscalingv1.GeneralPodAutoscaler, cur
 			Name: gpa.Spec.ScaleTargetRef.Name,
 			// gpa and workload must deploy in the same namespace
 			Namespace:       gpa.Namespace,
-			Parameters:      s.modeConfig.Parameters,
+			Parameters:      endpoint.Parameters,
 			CurrentReplicas: currentReplicas,
+			Metrics:         metricValues(gpa.Status.CurrentMetrics),
 		},
 		Response: nil,
 	}
@@ -73,11 +138,28 @@ func (s *WebhookScaler) GetReplicas(gpa *autoscalingv1.GeneralPodAutoscaler, cur
 		return 0, err
 	}
 
-	res, err := client.Post(
-		u.String(),
-		"application/json",
-		strings.NewReader(string(b)),
-	)
+	httpReq, err := http.NewRequest(http.MethodPost, u.String(), strings.NewReader(string(b)))
+	if err != nil {
+		return 0, err
+	}
+	httpClient, err := s.httpClient(endpoint, gpa.Namespace)
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if endpoint.HMACSecretRef != nil {
+		key, err := s.getHMACKey(endpoint, gpa.Namespace)
+		if err != nil {
+			return 0, err
+		}
+		header := endpoint.HMACHeader
+		if header == "" {
+			header = DefaultHMACHeader
+		}
+		httpReq.Header.Set(header, sign(key, b))
+	}
+
+	res, err := httpClient.Do(httpReq)
 	if err != nil {
 		return 0, err
 	}
@@ -114,24 +196,137 @@ func (s *WebhookScaler) GetReplicas(gpa *autoscalingv1.GeneralPodAutoscaler, cur
 
 }
 
+// metricValues converts the metric statuses of the GPA to the metric readings sent to the webhook servers
+func metricValues(statuses []autoscalingv1.MetricStatus) []requests.MetricValue {
+	var values []requests.MetricValue
+	for _, status := range statuses {
+		value := requests.MetricValue{Type: string(status.Type)}
+		var current autoscalingv1.MetricValueStatus
+		switch {
+		case status.Object != nil:
+			value.Name, current = status.Object.Metric.Name, status.Object.Current
+		case status.Pods != nil:
+			value.Name, current = status.Pods.Metric.Name, status.Pods.Current
+		case status.Resource != nil:
+			value.Name, current = string(status.Resource.Name), status.Resource.Current
+		case status.ContainerResource != nil:
+			value.Name, current = string(status.ContainerResource.Name), status.ContainerResource.Current
+			value.Container = status.ContainerResource.Container
+		case status.External != nil:
+			value.Name, current = status.External.Metric.Name, status.External.Current
+		case status.Derivative != nil:
+			value.Name, current = status.Derivative.Metric.Name, status.Derivative.Current
+		case status.Probe != nil:
+			value.Name, current = status.Probe.URL, status.Probe.Current
+		case status.KafkaLag != nil:
+			value.Name, current = status.KafkaLag.Topic, status.KafkaLag.Current
+		case status.CounterDelta != nil:
+			value.Name, current = status.CounterDelta.Metric.Name, status.CounterDelta.Current
+		case status.Ratio != nil:
+			value.Name, current = status.Ratio.Numerator.Name, status.Ratio.Current
+		case status.Concurrency != nil:
+			value.Name, current = status.Concurrency.Metric.Name, status.Concurrency.Current
+		case status.Scrape != nil:
+			value.Name, current = status.Scrape.MetricName, status.Scrape.Current
+		case status.BucketTable != nil:
+			value.Name, current = status.BucketTable.Metric.Name, status.BucketTable.Current
+		default:
+			continue
+		}
+		value.Value = current.Value
+		value.AverageValue = current.AverageValue
+		value.AverageUtilization = current.AverageUtilization
+		values = append(values, value)
+	}
+	return values
+}
+
 func (s *WebhookScaler) ScalerName() string {
 	return s.name
 }
 
-// buildURLFromWebhookPolicy - build URL for Webhook and set CARoot for client Transport
-func (s *WebhookScaler) buildURLFromWebhookPolicy() (u *url.URL, err error) {
-	w := s.modeConfig
+// getHMACKey reads the key referenced by HMACSecretRef of the endpoint from the secret in the given namespace
+func (s *WebhookScaler) getHMACKey(endpoint *autoscalingv1.WebhookMode, namespace string) ([]byte, error) {
+	return s.getSecretKey(namespace, endpoint.HMACSecretRef, "hmac")
+}
+
+// getSecretKey reads the key referenced by ref from the secret in the given namespace, usage describes
+// what the secret is used for in the errors
+func (s *WebhookScaler) getSecretKey(namespace string, ref *v1.SecretKeySelector, usage string) ([]byte, error) {
+	return GetSecretKey(s.secretNamespacer, namespace, ref, usage)
+}
+
+// GetSecretKey reads the key referenced by ref from the secret in the given namespace with the secretNamespacer,
+// usage describes what the secret is used for in the errors
+func GetSecretKey(secretNamespacer v1core.SecretsGetter, namespace string, ref *v1.SecretKeySelector,
+	usage string) ([]byte, error) {
+	if secretNamespacer == nil {
+		return nil, fmt.Errorf("secret client is required to read the %s secret", usage)
+	}
+	secret, err := secretNamespacer.Secrets(namespace).Get(ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "get %s secret %s/%s failed", usage, namespace, ref.Name)
+	}
+	key, ok := secret.Data[ref.Key]
+	if !ok || len(key) == 0 {
+		return nil, fmt.Errorf("key %s not found in %s secret %s/%s", ref.Key, usage, namespace, ref.Name)
+	}
+	return key, nil
+}
+
+// httpClient returns the client of the endpoint, which only trusts the CA bundles of the endpoint if any,
+// the CA bundle of CABundleSecretRef is read from the secret in the given namespace
+func (s *WebhookScaler) httpClient(endpoint *autoscalingv1.WebhookMode, namespace string) (*http.Client, error) {
+	caBundle := endpoint.CABundle
+	if endpoint.CABundleSecretRef != nil {
+		secretBundle, err := s.getSecretKey(namespace, endpoint.CABundleSecretRef, "ca bundle")
+		if err != nil {
+			return nil, err
+		}
+		caBundle = append(append([]byte{}, caBundle...), '\n')
+		caBundle = append(caBundle, secretBundle...)
+	}
+	if len(caBundle) == 0 {
+		return &client, nil
+	}
+	if cached, ok := caClients.Load(string(caBundle)); ok {
+		return cached.(*http.Client), nil
+	}
+	rootCAs := x509.NewCertPool()
+	if ok := rootCAs.AppendCertsFromPEM(caBundle); !ok {
+		return nil, errors.New("no certs were appended from caBundle")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs: rootCAs,
+	}
+	caClient := &http.Client{
+		Timeout:   client.Timeout,
+		Transport: transport,
+	}
+	cached, _ := caClients.LoadOrStore(string(caBundle), caClient)
+	return cached.(*http.Client), nil
+}
+
+// sign computes the HMAC-SHA256 signature of body
+func sign(key, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// buildURLFromWebhookPolicy - build URL for Webhook
+func buildURLFromWebhookPolicy(w *autoscalingv1.WebhookMode) (u *url.URL, err error) {
+	if w.WebhookClientConfig == nil {
+		return nil, errors.New("either URL or Service must be provided")
+	}
 	if w.URL != nil && w.Service != nil {
 		return nil, errors.New("service and URL cannot be used simultaneously")
 	}
 
 	scheme := "http"
-	if w.CABundle != nil {
+	if w.CABundle != nil || w.CABundleSecretRef != nil {
 		scheme = "https"
-
-		if err := setCABundle(w.CABundle); err != nil {
-			return nil, err
-		}
 	}
 
 	if w.URL != nil {
@@ -175,18 +370,3 @@ func createURL(scheme, name, namespace, path string, port *int32) *url.URL {
 		Path:   path,
 	}
 }
-
-func setCABundle(caBundle []byte) error {
-	// We can have multiple fleetautoscalers with different CABundles defined,
-	// so we switch client.Transport before each POST request
-	rootCAs := x509.NewCertPool()
-	if ok := rootCAs.AppendCertsFromPEM(caBundle); !ok {
-		return errors.New("no certs were appended from caBundle")
-	}
-	client.Transport = &http.Transport{
-		TLSClientConfig: &tls.Config{
-			RootCAs: rootCAs,
-		},
-	}
-	return nil
-}
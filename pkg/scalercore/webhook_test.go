@@ -0,0 +1,395 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalercore
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	admregv1b "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/requests"
+)
+
+func TestWebhookHMACSignature(t *testing.T) {
+	secretKey := []byte("webhook-secret")
+	for _, c := range []struct {
+		name   string
+		header string
+		expect string
+	}{
+		{
+			name:   "default header",
+			expect: DefaultHMACHeader,
+		},
+		{
+			name:   "custom header",
+			header: "X-Custom-Signature",
+			expect: "X-Custom-Signature",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var body []byte
+			var signature string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var err error
+				body, err = ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				signature = r.Header.Get(c.expect)
+				review := requests.AutoscaleReview{
+					Response: &requests.AutoscaleResponse{Scale: true, Replicas: 5},
+				}
+				if err := json.NewEncoder(w).Encode(review); err != nil {
+					t.Fatal(err)
+				}
+			}))
+			defer server.Close()
+
+			kubeClient := fake.NewSimpleClientset(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "hmac", Namespace: "default"},
+				Data:       map[string][]byte{"key": secretKey},
+			})
+			url := server.URL
+			mode := &v1alpha1.WebhookMode{
+				WebhookClientConfig: &admregv1b.WebhookClientConfig{URL: &url},
+				HMACSecretRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "hmac"},
+					Key:                  "key",
+				},
+				HMACHeader: c.header,
+			}
+			gpa := &v1alpha1.GeneralPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Name: "gpa", Namespace: "default"},
+			}
+
+			replicas, err := NewWebhookScaler(mode, kubeClient.CoreV1()).GetReplicas(gpa, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if replicas != 5 {
+				t.Errorf("desired replicas: %v, got: %v", 5, replicas)
+			}
+			mac := hmac.New(sha256.New, secretKey)
+			mac.Write(body)
+			expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+			if signature != expected {
+				t.Errorf("desired signature: %v, got: %v", expected, signature)
+			}
+		})
+	}
+}
+
+func TestWebhookHMACSecretMissing(t *testing.T) {
+	url := "http://127.0.0.1:1"
+	mode := &v1alpha1.WebhookMode{
+		WebhookClientConfig: &admregv1b.WebhookClientConfig{URL: &url},
+		HMACSecretRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "missing"},
+			Key:                  "key",
+		},
+	}
+	gpa := &v1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpa", Namespace: "default"},
+	}
+	if _, err := NewWebhookScaler(mode, fake.NewSimpleClientset().CoreV1()).GetReplicas(gpa, 2); err == nil {
+		t.Errorf("expected error when the hmac secret does not exist")
+	}
+}
+
+func TestWebhookRequestMetrics(t *testing.T) {
+	var review requests.AutoscaleReview
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			t.Error(err)
+		}
+		review.Response = &requests.AutoscaleResponse{Scale: true, Replicas: 4}
+		if err := json.NewEncoder(w).Encode(review); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	url := server.URL
+	mode := &v1alpha1.WebhookMode{WebhookClientConfig: &admregv1b.WebhookClientConfig{URL: &url}}
+	utilization := int32(80)
+	queue := resource.MustParse("30")
+	gpa := &v1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpa", Namespace: "default"},
+		Status: v1alpha1.GeneralPodAutoscalerStatus{
+			CurrentMetrics: []v1alpha1.MetricStatus{
+				{
+					Type: v1alpha1.ResourceMetricSourceType,
+					Resource: &v1alpha1.ResourceMetricStatus{
+						Name:    corev1.ResourceCPU,
+						Current: v1alpha1.MetricValueStatus{AverageUtilization: &utilization},
+					},
+				},
+				{
+					Type: v1alpha1.ExternalMetricSourceType,
+					External: &v1alpha1.ExternalMetricStatus{
+						Metric:  v1alpha1.MetricIdentifier{Name: "queue"},
+						Current: v1alpha1.MetricValueStatus{Value: &queue},
+					},
+				},
+			},
+		},
+	}
+
+	replicas, err := NewWebhookScaler(mode, nil).GetReplicas(gpa, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replicas != 4 {
+		t.Errorf("desired replicas: %v, got: %v", 4, replicas)
+	}
+	request := review.Request
+	if request.CurrentReplicas != 3 {
+		t.Errorf("desired current replicas: %v, got: %v", 3, request.CurrentReplicas)
+	}
+	if len(request.Metrics) != 2 {
+		t.Fatalf("desired 2 metrics, got: %+v", request.Metrics)
+	}
+	cpu, external := request.Metrics[0], request.Metrics[1]
+	if cpu.Type != "Resource" || cpu.Name != "cpu" || cpu.AverageUtilization == nil || *cpu.AverageUtilization != 80 {
+		t.Errorf("unexpected cpu metric: %+v", cpu)
+	}
+	if external.Type != "External" || external.Name != "queue" || external.Value == nil || external.Value.Value() != 30 {
+		t.Errorf("unexpected external metric: %+v", external)
+	}
+}
+
+// newTLSWebhook starts a webhook serving with a certificate signed by its own CA, and returns the PEM encoded CA
+func newTLSWebhook(t *testing.T, replicas int32) (*httptest.Server, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "webhook"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		review := requests.AutoscaleReview{
+			Response: &requests.AutoscaleResponse{Scale: true, Replicas: replicas},
+		}
+		if err := json.NewEncoder(w).Encode(review); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	server.StartTLS()
+	return server, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestWebhookCABundlePerEndpoint(t *testing.T) {
+	serverA, caA := newTLSWebhook(t, 3)
+	defer serverA.Close()
+	serverB, caB := newTLSWebhook(t, 4)
+	defer serverB.Close()
+
+	kubeClient := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "default"},
+		Data:       map[string][]byte{"ca.crt": caB},
+	})
+	gpa := &v1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpa", Namespace: "default"},
+	}
+	inline := func(url string, caBundle []byte) *v1alpha1.WebhookMode {
+		return &v1alpha1.WebhookMode{
+			WebhookClientConfig: &admregv1b.WebhookClientConfig{URL: &url, CABundle: caBundle},
+		}
+	}
+	fromSecret := func(url string) *v1alpha1.WebhookMode {
+		return &v1alpha1.WebhookMode{
+			WebhookClientConfig: &admregv1b.WebhookClientConfig{URL: &url},
+			CABundleSecretRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "ca"},
+				Key:                  "ca.crt",
+			},
+		}
+	}
+	for _, c := range []struct {
+		name     string
+		mode     *v1alpha1.WebhookMode
+		replicas int32
+		fail     bool
+	}{
+		{
+			name:     "inline ca of the endpoint",
+			mode:     inline(serverA.URL, caA),
+			replicas: 3,
+		},
+		{
+			name:     "secret ca of the endpoint",
+			mode:     fromSecret(serverB.URL),
+			replicas: 4,
+		},
+		{
+			name: "ca of another endpoint",
+			mode: inline(serverB.URL, caA),
+			fail: true,
+		},
+		{
+			name: "secret ca of another endpoint",
+			mode: fromSecret(serverA.URL),
+			fail: true,
+		},
+		{
+			name: "no ca",
+			mode: inline(serverA.URL, nil),
+			fail: true,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			replicas, err := NewWebhookScaler(c.mode, kubeClient.CoreV1()).GetReplicas(gpa, 2)
+			if c.fail {
+				if err == nil {
+					t.Errorf("expected the certificate of the endpoint to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if replicas != c.replicas {
+				t.Errorf("desired replicas: %v, got: %v", c.replicas, replicas)
+			}
+		})
+	}
+}
+
+// newReplicasWebhook starts a webhook recommending the replicas, or failing if replicas is negative
+func newReplicasWebhook(t *testing.T, replicas int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if replicas < 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		review := requests.AutoscaleReview{
+			Response: &requests.AutoscaleResponse{Scale: true, Replicas: replicas},
+		}
+		if err := json.NewEncoder(w).Encode(review); err != nil {
+			t.Fatal(err)
+		}
+	}))
+}
+
+func TestWebhookEndpointsSelectPolicy(t *testing.T) {
+	failing := newReplicasWebhook(t, -1)
+	defer failing.Close()
+	three := newReplicasWebhook(t, 3)
+	defer three.Close()
+	five := newReplicasWebhook(t, 5)
+	defer five.Close()
+
+	gpa := &v1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpa", Namespace: "default"},
+	}
+	endpoint := func(url string) v1alpha1.WebhookMode {
+		return v1alpha1.WebhookMode{WebhookClientConfig: &admregv1b.WebhookClientConfig{URL: &url}}
+	}
+	mode := func(policy v1alpha1.WebhookSelectPolicy, primary string, endpoints ...string) *v1alpha1.WebhookMode {
+		m := endpoint(primary)
+		if policy != "" {
+			m.SelectPolicy = &policy
+		}
+		for _, url := range endpoints {
+			m.Endpoints = append(m.Endpoints, endpoint(url))
+		}
+		return &m
+	}
+	for _, c := range []struct {
+		name     string
+		mode     *v1alpha1.WebhookMode
+		replicas int32
+		fail     bool
+	}{
+		{
+			name:     "primary succeeds",
+			mode:     mode("", three.URL, five.URL),
+			replicas: 3,
+		},
+		{
+			name:     "fallback on primary failure",
+			mode:     mode(v1alpha1.FirstSuccessWebhookSelect, failing.URL, five.URL, three.URL),
+			replicas: 5,
+		},
+		{
+			name:     "max of the endpoints",
+			mode:     mode(v1alpha1.MaxWebhookSelect, three.URL, failing.URL, five.URL),
+			replicas: 5,
+		},
+		{
+			name:     "min of the endpoints",
+			mode:     mode(v1alpha1.MinWebhookSelect, five.URL, failing.URL, three.URL),
+			replicas: 3,
+		},
+		{
+			name: "all endpoints fail",
+			mode: mode(v1alpha1.MaxWebhookSelect, failing.URL, failing.URL),
+			fail: true,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			replicas, err := NewWebhookScaler(c.mode, nil).GetReplicas(gpa, 2)
+			if c.fail {
+				if err == nil {
+					t.Errorf("expected error when all the endpoints fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if replicas != c.replicas {
+				t.Errorf("desired replicas: %v, got: %v", c.replicas, replicas)
+			}
+		})
+	}
+}
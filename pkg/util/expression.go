@@ -0,0 +1,134 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Knetic/govaluate"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// ExpressionCurrentReplicas is the variable of the current replicas of the target in an expression
+const ExpressionCurrentReplicas = "currentReplicas"
+
+// expressionFunctions are the only functions allowed in an expression
+var expressionFunctions = map[string]govaluate.ExpressionFunction{
+	"ceil":  unaryFunction("ceil", math.Ceil),
+	"floor": unaryFunction("floor", math.Floor),
+	"round": unaryFunction("round", math.Round),
+	"abs":   unaryFunction("abs", math.Abs),
+	"min":   reduceFunction("min", math.Min),
+	"max":   reduceFunction("max", math.Max),
+}
+
+// allowedTokenKinds are the kinds of tokens allowed in an expression, strings, patterns, times and accessors
+// are rejected.
+var allowedTokenKinds = map[govaluate.TokenKind]bool{
+	govaluate.PREFIX:       true,
+	govaluate.NUMERIC:      true,
+	govaluate.BOOLEAN:      true,
+	govaluate.VARIABLE:     true,
+	govaluate.FUNCTION:     true,
+	govaluate.SEPARATOR:    true,
+	govaluate.COMPARATOR:   true,
+	govaluate.LOGICALOP:    true,
+	govaluate.MODIFIER:     true,
+	govaluate.CLAUSE:       true,
+	govaluate.CLAUSE_CLOSE: true,
+	govaluate.TERNARY:      true,
+}
+
+// ParseExpression parses the expression, which may only use numbers, the variables, arithmetic, comparison,
+// logical and ternary operators, and the functions ceil, floor, round, abs, min and max.
+func ParseExpression(expression string, variables sets.String) (*govaluate.EvaluableExpression, error) {
+	parsed, err := govaluate.NewEvaluableExpressionWithFunctions(expression, expressionFunctions)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range parsed.Tokens() {
+		if !allowedTokenKinds[token.Kind] {
+			return nil, fmt.Errorf("%v %v is not allowed", token.Kind, token.Value)
+		}
+		if token.Kind == govaluate.COMPARATOR && (token.Value == "=~" || token.Value == "!~") {
+			return nil, fmt.Errorf("regular expression comparator %v is not allowed", token.Value)
+		}
+		if token.Kind == govaluate.VARIABLE && !variables.Has(token.Value.(string)) {
+			return nil, fmt.Errorf("undefined variable %v", token.Value)
+		}
+	}
+	return parsed, nil
+}
+
+// EvaluateExpression evaluates the expression with the values of the variables, the result must be a finite number.
+func EvaluateExpression(expression string, values map[string]float64) (float64, error) {
+	variables := sets.NewString()
+	parameters := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		variables.Insert(name)
+		parameters[name] = value
+	}
+	parsed, err := ParseExpression(expression, variables)
+	if err != nil {
+		return 0, err
+	}
+	result, err := parsed.Evaluate(parameters)
+	if err != nil {
+		return 0, err
+	}
+	number, ok := result.(float64)
+	if !ok {
+		return 0, fmt.Errorf("expression %q returns %v, not a number", expression, result)
+	}
+	if math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("expression %q returns %v, not a finite number", expression, number)
+	}
+	return number, nil
+}
+
+func unaryFunction(name string, f func(float64) float64) govaluate.ExpressionFunction {
+	return func(args ...interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s expects 1 argument, got %d", name, len(args))
+		}
+		value, ok := args[0].(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s expects a number, got %v", name, args[0])
+		}
+		return f(value), nil
+	}
+}
+
+func reduceFunction(name string, f func(float64, float64) float64) govaluate.ExpressionFunction {
+	return func(args ...interface{}) (interface{}, error) {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%s expects at least 1 argument", name)
+		}
+		var result float64
+		for i, arg := range args {
+			value, ok := arg.(float64)
+			if !ok {
+				return nil, fmt.Errorf("%s expects numbers, got %v", name, arg)
+			}
+			if i == 0 {
+				result = value
+			} else {
+				result = f(result, value)
+			}
+		}
+		return result, nil
+	}
+}
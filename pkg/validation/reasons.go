@@ -0,0 +1,193 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Reason is the stable machine code of a validation failure. The codes are documented in
+// docs/validation-reasons.md, and must never be renumbered.
+type Reason string
+
+const (
+	// ReasonInvalid is the reason of the failures not covered by a specific reason
+	ReasonInvalid Reason = "GPA000-Invalid"
+	// ReasonMinGreaterThanMax means spec.minReplicas is greater than spec.maxReplicas
+	ReasonMinGreaterThanMax Reason = "GPA001-MinGreaterThanMax"
+	// ReasonInvalidMinReplicas means spec.minReplicas is out of range
+	ReasonInvalidMinReplicas Reason = "GPA002-InvalidMinReplicas"
+	// ReasonInvalidMaxReplicas means spec.maxReplicas is out of range
+	ReasonInvalidMaxReplicas Reason = "GPA003-InvalidMaxReplicas"
+	// ReasonInvalidScaleTargetRef means spec.scaleTargetRef is invalid
+	ReasonInvalidScaleTargetRef Reason = "GPA004-InvalidScaleTargetRef"
+	// ReasonInvalidMetric means a metric of spec.metrics is invalid
+	ReasonInvalidMetric Reason = "GPA005-InvalidMetric"
+	// ReasonScaleToZeroMetricRequired means spec.minReplicas is 0 without an Object or External metric
+	ReasonScaleToZeroMetricRequired Reason = "GPA006-ScaleToZeroMetricRequired"
+	// ReasonInvalidWebhook means spec.webhook is invalid
+	ReasonInvalidWebhook Reason = "GPA007-InvalidWebhook"
+	// ReasonInvalidTimeRange means a range of spec.time is invalid, e.g. the schedule can not be parsed
+	ReasonInvalidTimeRange Reason = "GPA008-InvalidTimeRange"
+	// ReasonInvalidEvent means spec.event is invalid
+	ReasonInvalidEvent Reason = "GPA009-InvalidEvent"
+	// ReasonInvalidBehavior means spec.behavior is invalid
+	ReasonInvalidBehavior Reason = "GPA010-InvalidBehavior"
+	// ReasonInvalidReadinessGapBuffer means spec.readinessGapBuffer is invalid
+	ReasonInvalidReadinessGapBuffer Reason = "GPA011-InvalidReadinessGapBuffer"
+	// ReasonInvalidMetadata means the metadata of the GPA is invalid
+	ReasonInvalidMetadata Reason = "GPA012-InvalidMetadata"
+	// ReasonInvalidStatus means the status of the GPA is invalid
+	ReasonInvalidStatus Reason = "GPA013-InvalidStatus"
+	// ReasonInvalidExpression means spec.metric.expression can not be parsed or refers to undefined variables
+	ReasonInvalidExpression Reason = "GPA014-InvalidExpression"
+	// ReasonInvalidRecoverFromZero means spec.recoverFromZero is invalid
+	ReasonInvalidRecoverFromZero Reason = "GPA015-InvalidRecoverFromZero"
+	// ReasonTargetConflict means spec.scaleTargetRef is already scaled by another GPA
+	ReasonTargetConflict Reason = "GPA016-TargetConflict"
+	// ReasonInvalidOnTargetMissing means spec.onTargetMissing is not a known policy
+	ReasonInvalidOnTargetMissing Reason = "GPA017-InvalidOnTargetMissing"
+	// ReasonMissingResourceRequests means the pods of the target lack the requests of a utilization target
+	ReasonMissingResourceRequests Reason = "GPA018-MissingResourceRequests"
+	// ReasonInvalidConflictPolicy means spec.conflictPolicy is not a known policy
+	ReasonInvalidConflictPolicy Reason = "GPA019-InvalidConflictPolicy"
+	// ReasonInvalidWarmup means spec.warmupSeconds is negative
+	ReasonInvalidWarmup Reason = "GPA020-InvalidWarmup"
+	// ReasonInvalidMaxPendingPods means spec.maxPendingPods is not positive
+	ReasonInvalidMaxPendingPods Reason = "GPA021-InvalidMaxPendingPods"
+	// ReasonInvalidMetricWindow means spec.metric.window has an invalid schedule or off-hours replicas
+	ReasonInvalidMetricWindow Reason = "GPA022-InvalidMetricWindow"
+	// ReasonInvalidMinReadySeconds means spec.minReadySeconds is negative
+	ReasonInvalidMinReadySeconds Reason = "GPA023-InvalidMinReadySeconds"
+	// ReasonInvalidClusterProportional means spec.clusterProportional has negative coefficients or steps, or none
+	ReasonInvalidClusterProportional Reason = "GPA024-InvalidClusterProportional"
+	// ReasonScaleToZeroTriggerRequired means spec.minReplicas is 0 without any mode scaling the target up from zero
+	ReasonScaleToZeroTriggerRequired Reason = "GPA025-ScaleToZeroTriggerRequired"
+	// ReasonInvalidImpersonateServiceAccount means spec.impersonateServiceAccount is not a valid name
+	ReasonInvalidImpersonateServiceAccount Reason = "GPA026-InvalidImpersonateServiceAccount"
+	// ReasonInvalidBreachDuration means spec.metric.breachDurationSeconds is out of range
+	ReasonInvalidBreachDuration Reason = "GPA027-InvalidBreachDuration"
+	// ReasonInvalidScaleHistoryLimit means spec.scaleHistoryLimit is out of range
+	ReasonInvalidScaleHistoryLimit Reason = "GPA028-InvalidScaleHistoryLimit"
+	// ReasonInvalidMirror means spec.mirror has no valid name of another GPA, or a factor not greater than 0
+	ReasonInvalidMirror Reason = "GPA029-InvalidMirror"
+	// ReasonInvalidMetricBlend means spec.metric.blend has an unknown function, or is set with the expression
+	ReasonInvalidMetricBlend Reason = "GPA030-InvalidMetricBlend"
+	// ReasonMaxReplicasAboveCeiling means spec.maxReplicas exceeds the ceiling of the cluster policy
+	ReasonMaxReplicasAboveCeiling Reason = "GPA031-MaxReplicasAboveCeiling"
+	// ReasonDuplicateMetric means spec.metrics lists the same metric source more than once
+	ReasonDuplicateMetric Reason = "GPA032-DuplicateMetric"
+	// ReasonMissingSecret means a secret referenced by the GPA, or the referenced key of it, does not exist
+	ReasonMissingSecret Reason = "GPA033-MissingSecret"
+	// ReasonInvalidPodAgeBuffer means spec.podAgeBuffer is invalid
+	ReasonInvalidPodAgeBuffer Reason = "GPA034-InvalidPodAgeBuffer"
+	// ReasonMaxReplicasFarBelowCurrent means spec.maxReplicas is far below the current replicas of the target
+	ReasonMaxReplicasFarBelowCurrent Reason = "GPA035-MaxReplicasFarBelowCurrent"
+)
+
+// minGreaterThanMaxDetail is the detail of the error when minReplicas is greater than maxReplicas
+const minGreaterThanMaxDetail = "must be greater than or equal to `minReplicas`"
+
+// scaleToZeroTriggerDetail is the detail of the error when minReplicas is 0 without any mode scaling up from zero
+const scaleToZeroTriggerDetail = "must be greater than 0 unless the target is scaled up from zero replicas by an event, webhook, time, " +
+	"cluster-proportional or mirror mode, or an Object or External metric"
+
+// maxReplicasCeilingDetail prefixes the details of the errors when maxReplicas exceeds the ceiling
+const maxReplicasCeilingDetail = "must be less than or equal to the ceiling"
+
+// maxReplicasDropDetail prefixes the details of the errors when maxReplicas is far below the current replicas
+const maxReplicasDropDetail = "is far below the current replicas"
+
+// missingRequestsDetail prefixes the details of the errors when the pods of the target lack the requests
+const missingRequestsDetail = "the pods of the target must set"
+
+// missingSecretDetail prefixes the details of the errors when a referenced secret or its key does not exist
+const missingSecretDetail = "secret"
+
+// reasonRules maps the errors to the reasons, the first matched rule wins
+var reasonRules = []struct {
+	path   string
+	match  func(err *field.Error) bool
+	reason Reason
+}{
+	{path: "spec", match: func(err *field.Error) bool { return strings.HasPrefix(err.Detail, missingSecretDetail+" ") },
+		reason: ReasonMissingSecret},
+	{path: "spec.maxReplicas", match: func(err *field.Error) bool { return err.Detail == minGreaterThanMaxDetail },
+		reason: ReasonMinGreaterThanMax},
+	{path: "spec.minReplicas", match: func(err *field.Error) bool { return err.Detail == scaleToZeroTriggerDetail },
+		reason: ReasonScaleToZeroTriggerRequired},
+	{path: "spec.maxReplicas", match: func(err *field.Error) bool { return strings.HasPrefix(err.Detail, maxReplicasCeilingDetail) },
+		reason: ReasonMaxReplicasAboveCeiling},
+	{path: "spec.maxReplicas", match: func(err *field.Error) bool { return strings.HasPrefix(err.Detail, maxReplicasDropDetail) },
+		reason: ReasonMaxReplicasFarBelowCurrent},
+	{path: "spec.minReplicas", reason: ReasonInvalidMinReplicas},
+	{path: "spec.maxReplicas", reason: ReasonInvalidMaxReplicas},
+	{path: "spec.scaleTargetRef", match: func(err *field.Error) bool { return err.Type == field.ErrorTypeForbidden },
+		reason: ReasonTargetConflict},
+	{path: "spec.scaleTargetRef", reason: ReasonInvalidScaleTargetRef},
+	{path: "spec.metrics", match: func(err *field.Error) bool { return err.Field == "spec.metrics" },
+		reason: ReasonScaleToZeroMetricRequired},
+	{path: "spec.metrics", match: func(err *field.Error) bool { return strings.HasPrefix(err.Detail, missingRequestsDetail) },
+		reason: ReasonMissingResourceRequests},
+	{path: "spec.metrics", match: func(err *field.Error) bool { return err.Type == field.ErrorTypeDuplicate },
+		reason: ReasonDuplicateMetric},
+	{path: "spec.metrics", reason: ReasonInvalidMetric},
+	{path: "spec.metric.expression", reason: ReasonInvalidExpression},
+	{path: "spec.metric.window", reason: ReasonInvalidMetricWindow},
+	{path: "spec.metric.breachDurationSeconds", reason: ReasonInvalidBreachDuration},
+	{path: "spec.metric.blend", reason: ReasonInvalidMetricBlend},
+	{path: "spec.webhook", reason: ReasonInvalidWebhook},
+	{path: "spec.time", reason: ReasonInvalidTimeRange},
+	{path: "spec.event", reason: ReasonInvalidEvent},
+	{path: "spec.clusterProportional", reason: ReasonInvalidClusterProportional},
+	{path: "spec.mirror", reason: ReasonInvalidMirror},
+	{path: "spec.behavior", reason: ReasonInvalidBehavior},
+	{path: "spec.readinessGapBuffer", reason: ReasonInvalidReadinessGapBuffer},
+	{path: "spec.podAgeBuffer", reason: ReasonInvalidPodAgeBuffer},
+	{path: "spec.recoverFromZero", reason: ReasonInvalidRecoverFromZero},
+	{path: "spec.onTargetMissing", reason: ReasonInvalidOnTargetMissing},
+	{path: "spec.conflictPolicy", reason: ReasonInvalidConflictPolicy},
+	{path: "spec.warmupSeconds", reason: ReasonInvalidWarmup},
+	{path: "spec.maxPendingPods", reason: ReasonInvalidMaxPendingPods},
+	{path: "spec.minReadySeconds", reason: ReasonInvalidMinReadySeconds},
+	{path: "spec.impersonateServiceAccount", reason: ReasonInvalidImpersonateServiceAccount},
+	{path: "spec.scaleHistoryLimit", reason: ReasonInvalidScaleHistoryLimit},
+	{path: "metadata", reason: ReasonInvalidMetadata},
+	{path: "status", reason: ReasonInvalidStatus},
+}
+
+// ReasonForError returns the reason of the validation error
+func ReasonForError(err *field.Error) Reason {
+	for _, rule := range reasonRules {
+		if err.Field != rule.path && !strings.HasPrefix(err.Field, rule.path+".") &&
+			!strings.HasPrefix(err.Field, rule.path+"[") {
+			continue
+		}
+		if rule.match == nil || rule.match(err) {
+			return rule.reason
+		}
+	}
+	return ReasonInvalid
+}
+
+// DocsURL returns the documentation link of the reason under the base docs URL
+func DocsURL(baseURL string, reason Reason) string {
+	if baseURL == "" {
+		return ""
+	}
+	return baseURL + "#" + strings.ToLower(string(reason))
+}
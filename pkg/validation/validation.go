@@ -17,16 +17,29 @@ package validation
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 
-	"github.com/robfig/cron"
 	"k8s.io/api/admissionregistration/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimachineryvalidation "k8s.io/apimachinery/pkg/api/validation"
 	pathvalidation "k8s.io/apimachinery/pkg/api/validation/path"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apiserver/pkg/util/webhook"
 
 	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/scalercore"
+	"github.com/ocgi/general-pod-autoscaler/pkg/util"
 )
 
 const (
@@ -34,6 +47,20 @@ const (
 	MaxPeriodSeconds int32 = 1800
 	// MaxStabilizationWindowSeconds is the largest allowed stabilization window (in seconds)
 	MaxStabilizationWindowSeconds int32 = 3600
+	// MaxPeakFloorWindowSeconds is the largest allowed window of the peak floor (in seconds)
+	MaxPeakFloorWindowSeconds int32 = 7 * 24 * 3600
+	// MaxBreachDurationSeconds is the largest allowed breach duration of the metrics (in seconds)
+	MaxBreachDurationSeconds int32 = 3600
+	// MaxScaleHistoryLimit is the largest allowed number of the scales kept in the status
+	MaxScaleHistoryLimit int32 = 100
+	// AllowSharedTargetAnnotation allows a GPA to scale a target which is already scaled by another GPA
+	AllowSharedTargetAnnotation = "autoscaling.ocgi.io/allow-shared-target"
+	// AllowMaxReplicasDropAnnotation allows a GPA to set a maxReplicas far below the current replicas of its target
+	AllowMaxReplicasDropAnnotation = "autoscaling.ocgi.io/allow-max-replicas-drop"
+	// ComputeByLimitsAnnotation computes the resource utilization of a GPA against the limits instead of the requests
+	ComputeByLimitsAnnotation = "compute-by-limits"
+	// maxMissingRequestsPods is the max number of pods named in the errors of the missing requests
+	maxMissingRequestsPods = 3
 )
 
 // ValidateHorizontalPodAutoscalerName can be used to check whether the given autoscaler name is valid.
@@ -52,18 +79,34 @@ func validateHorizontalPodAutoscalerSpec(autoscaler autoscaling.GeneralPodAutosc
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxReplicas"), autoscaler.MaxReplicas, "must be greater than 0"))
 	}
 	if autoscaler.MinReplicas != nil && autoscaler.MaxReplicas < *autoscaler.MinReplicas {
-		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxReplicas"), autoscaler.MaxReplicas, "must be greater than or equal to `minReplicas`"))
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxReplicas"), autoscaler.MaxReplicas, minGreaterThanMaxDetail))
 	}
 	if refErrs := ValidateCrossVersionObjectReference(autoscaler.ScaleTargetRef, fldPath.Child("scaleTargetRef")); len(refErrs) > 0 {
 		allErrs = append(allErrs, refErrs...)
 	}
 	if autoscaler.AutoScalingDrivenMode.MetricMode != nil {
-		if refErrs := validateMetrics(autoscaler.AutoScalingDrivenMode.MetricMode.Metrics, fldPath.Child("metrics"), autoscaler.MinReplicas); len(refErrs) > 0 {
+		if refErrs := validateMetrics(autoscaler.AutoScalingDrivenMode.MetricMode.Metrics, fldPath.Child("metrics")); len(refErrs) > 0 {
+			allErrs = append(allErrs, refErrs...)
+		}
+		if refErrs := validateExpression(autoscaler.AutoScalingDrivenMode.MetricMode, fldPath); len(refErrs) > 0 {
+			allErrs = append(allErrs, refErrs...)
+		}
+		if refErrs := validateMetricWindow(autoscaler.AutoScalingDrivenMode.MetricMode.Window,
+			fldPath.Child("metric", "window")); len(refErrs) > 0 {
+			allErrs = append(allErrs, refErrs...)
+		}
+		if duration := autoscaler.AutoScalingDrivenMode.MetricMode.BreachDurationSeconds; duration != nil &&
+			(*duration <= 0 || *duration > MaxBreachDurationSeconds) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("metric", "breachDurationSeconds"), *duration,
+				fmt.Sprintf("must be greater than 0 and less than or equal to %d", MaxBreachDurationSeconds)))
+		}
+		if refErrs := validateMetricBlend(autoscaler.AutoScalingDrivenMode.MetricMode,
+			fldPath.Child("metric", "blend")); len(refErrs) > 0 {
 			allErrs = append(allErrs, refErrs...)
 		}
 	}
 	if autoscaler.AutoScalingDrivenMode.WebhookMode != nil {
-		if refErrs := validateWebhook(autoscaler.AutoScalingDrivenMode.WebhookMode.WebhookClientConfig, fldPath.Child("webhook")); len(refErrs) > 0 {
+		if refErrs := validateWebhookMode(autoscaler.AutoScalingDrivenMode.WebhookMode, fldPath.Child("webhook")); len(refErrs) > 0 {
 			allErrs = append(allErrs, refErrs...)
 		}
 	}
@@ -71,15 +114,191 @@ func validateHorizontalPodAutoscalerSpec(autoscaler autoscaling.GeneralPodAutosc
 		if refErrs := validateTime(autoscaler.AutoScalingDrivenMode.TimeMode.TimeRanges, fldPath.Child("time")); len(refErrs) > 0 {
 			allErrs = append(allErrs, refErrs...)
 		}
+		if refErrs := validateTimeExceptions(autoscaler.AutoScalingDrivenMode.TimeMode.Exceptions,
+			fldPath.Child("time", "exceptions")); len(refErrs) > 0 {
+			allErrs = append(allErrs, refErrs...)
+		}
 	}
 	if autoscaler.AutoScalingDrivenMode.EventMode != nil {
 		if refErrs := validateEvent(autoscaler.AutoScalingDrivenMode.EventMode.Triggers, fldPath.Child("event")); len(refErrs) > 0 {
 			allErrs = append(allErrs, refErrs...)
 		}
 	}
+	if autoscaler.AutoScalingDrivenMode.ClusterProportionalMode != nil {
+		if refErrs := validateClusterProportionalMode(autoscaler.AutoScalingDrivenMode.ClusterProportionalMode,
+			fldPath.Child("clusterProportional")); len(refErrs) > 0 {
+			allErrs = append(allErrs, refErrs...)
+		}
+	}
+	if autoscaler.AutoScalingDrivenMode.MirrorMode != nil {
+		if refErrs := validateMirrorMode(autoscaler.AutoScalingDrivenMode.MirrorMode, fldPath.Child("mirror")); len(refErrs) > 0 {
+			allErrs = append(allErrs, refErrs...)
+		}
+	}
+	if name := autoscaler.ImpersonateServiceAccount; name != "" {
+		for _, msg := range apimachineryvalidation.NameIsDNSSubdomain(name, false) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("impersonateServiceAccount"), name, msg))
+		}
+	}
+	if refErrs := validateScaleToZero(autoscaler, fldPath); len(refErrs) > 0 {
+		allErrs = append(allErrs, refErrs...)
+	}
 	if refErrs := validateBehavior(autoscaler.Behavior, fldPath.Child("behavior")); len(refErrs) > 0 {
 		allErrs = append(allErrs, refErrs...)
 	}
+	if refErrs := validateReplicaStep(autoscaler, fldPath.Child("behavior", "replicaStep")); len(refErrs) > 0 {
+		allErrs = append(allErrs, refErrs...)
+	}
+	if refErrs := validateReadinessGapBuffer(autoscaler.ReadinessGapBuffer, fldPath.Child("readinessGapBuffer")); len(refErrs) > 0 {
+		allErrs = append(allErrs, refErrs...)
+	}
+	if refErrs := validatePodAgeBuffer(autoscaler.PodAgeBuffer, fldPath.Child("podAgeBuffer")); len(refErrs) > 0 {
+		allErrs = append(allErrs, refErrs...)
+	}
+	if refErrs := validateRecoverFromZero(autoscaler, fldPath.Child("recoverFromZero")); len(refErrs) > 0 {
+		allErrs = append(allErrs, refErrs...)
+	}
+	if autoscaler.OnTargetMissing != nil && !targetMissingPolicies.Has(string(*autoscaler.OnTargetMissing)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("onTargetMissing"), *autoscaler.OnTargetMissing,
+			targetMissingPolicies.List()))
+	}
+	if autoscaler.ConflictPolicy != "" && !conflictPolicies.Has(string(autoscaler.ConflictPolicy)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("conflictPolicy"), autoscaler.ConflictPolicy,
+			conflictPolicies.List()))
+	}
+	if autoscaler.WarmupSeconds != nil && *autoscaler.WarmupSeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("warmupSeconds"), *autoscaler.WarmupSeconds,
+			"must be greater than or equal to 0"))
+	}
+	if autoscaler.MaxPendingPods != nil && *autoscaler.MaxPendingPods <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxPendingPods"), *autoscaler.MaxPendingPods,
+			"must be greater than 0"))
+	}
+	if autoscaler.MinReadySeconds != nil && *autoscaler.MinReadySeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("minReadySeconds"), *autoscaler.MinReadySeconds,
+			"must be greater than or equal to 0"))
+	}
+	if limit := autoscaler.ScaleHistoryLimit; limit != nil && (*limit < 0 || *limit > MaxScaleHistoryLimit) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("scaleHistoryLimit"), *limit,
+			fmt.Sprintf("must be greater than or equal to 0 and less than or equal to %d", MaxScaleHistoryLimit)))
+	}
+	return allErrs
+}
+
+var conflictPolicies = sets.NewString(string(autoscaling.CronWinsConflictPolicy), string(autoscaling.MetricWinsConflictPolicy),
+	string(autoscaling.MaxConflictPolicy), string(autoscaling.MinConflictPolicy))
+
+var targetMissingPolicies = sets.NewString(string(autoscaling.IgnoreTargetMissing), string(autoscaling.ErrorTargetMissing),
+	string(autoscaling.DeleteSelfTargetMissing))
+
+var metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// validateExpression validates the names of the metrics and the expression referring to them
+func validateExpression(mode *autoscaling.MetricMode, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	names := sets.NewString(util.ExpressionCurrentReplicas)
+	for i, metric := range mode.Metrics {
+		if metric.Name == "" {
+			continue
+		}
+		namePath := fldPath.Child("metrics").Index(i).Child("name")
+		if !metricNameRegexp.MatchString(metric.Name) {
+			allErrs = append(allErrs, field.Invalid(namePath, metric.Name,
+				"must consist of alphanumeric characters or '_', and must not start with a digit"))
+			continue
+		}
+		if names.Has(metric.Name) {
+			allErrs = append(allErrs, field.Duplicate(namePath, metric.Name))
+			continue
+		}
+		names.Insert(metric.Name)
+	}
+	if mode.Expression == "" {
+		return allErrs
+	}
+	if _, err := util.ParseExpression(mode.Expression, names); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("metric", "expression"), mode.Expression, err.Error()))
+	}
+	return allErrs
+}
+
+var validMetricBlendFunctions = sets.NewString(string(autoscaling.MaxMetricBlend), string(autoscaling.AverageMetricBlend))
+
+// validateMetricBlend validates the function of the blend, which replaces the expression
+func validateMetricBlend(mode *autoscaling.MetricMode, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if mode.Blend == nil {
+		return allErrs
+	}
+	if mode.Expression != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "must not be set with the expression"))
+	}
+	if function := mode.Blend.Function; function != "" && !validMetricBlendFunctions.Has(string(function)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("function"), function, validMetricBlendFunctions.List()))
+	}
+	return allErrs
+}
+
+// validateMetricWindow validates the schedule and the off-hours replicas of the window of the metrics
+func validateMetricWindow(window *autoscaling.MetricWindow, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if window == nil {
+		return allErrs
+	}
+	if len(window.Schedule) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("schedule"), "must be set"))
+	} else if _, err := scalercore.ParseSchedule(window.Schedule); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("schedule"), window.Schedule, err.Error()))
+	}
+	if window.OffHoursReplicas < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("offHoursReplicas"), window.OffHoursReplicas,
+			"must be greater than or equal to 0"))
+	}
+	return allErrs
+}
+
+func validateReadinessGapBuffer(buffer *autoscaling.ReadinessGapBuffer, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if buffer == nil {
+		return allErrs
+	}
+	if buffer.Replicas <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("replicas"), buffer.Replicas, "must be greater than 0"))
+	}
+	if buffer.GapSeconds != nil && *buffer.GapSeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("gapSeconds"), *buffer.GapSeconds, "must be greater than or equal to zero"))
+	}
+	return allErrs
+}
+
+func validatePodAgeBuffer(buffer *autoscaling.PodAgeBuffer, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if buffer == nil {
+		return allErrs
+	}
+	if buffer.MaxAgeSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxAgeSeconds"), buffer.MaxAgeSeconds, "must be greater than 0"))
+	}
+	if buffer.Replicas <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("replicas"), buffer.Replicas, "must be greater than 0"))
+	}
+	return allErrs
+}
+
+func validateRecoverFromZero(autoscaler autoscaling.GeneralPodAutoscalerSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if autoscaler.RecoverFromZero == nil || autoscaler.RecoverFromZero.BootstrapReplicas == nil {
+		return allErrs
+	}
+	bootstrapReplicas := *autoscaler.RecoverFromZero.BootstrapReplicas
+	minReplicas := int32(1)
+	if autoscaler.MinReplicas != nil {
+		minReplicas = *autoscaler.MinReplicas
+	}
+	if bootstrapReplicas < minReplicas || bootstrapReplicas < 1 || bootstrapReplicas > autoscaler.MaxReplicas {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("bootstrapReplicas"), bootstrapReplicas,
+			"must be within `minReplicas` and `maxReplicas`, and greater than 0"))
+	}
 	return allErrs
 }
 
@@ -117,6 +336,7 @@ func ValidateHorizontalPodAutoscaler(autoscaler *autoscaling.GeneralPodAutoscale
 	var minReplicasLowerBound int32
 
 	allErrs = append(allErrs, validateHorizontalPodAutoscalerSpec(autoscaler.Spec, field.NewPath("spec"), minReplicasLowerBound)...)
+	allErrs = append(allErrs, validateMirrorSelf(autoscaler)...)
 	return allErrs
 }
 
@@ -129,9 +349,221 @@ func ValidateHorizontalPodAutoscalerUpdate(newAutoscaler, oldAutoscaler *autosca
 	// 0 when GPA scale-to-zero feature is enabled or GPA object already has minReplicas=0
 	var minReplicasLowerBound int32
 	allErrs = append(allErrs, validateHorizontalPodAutoscalerSpec(newAutoscaler.Spec, field.NewPath("spec"), minReplicasLowerBound)...)
+	allErrs = append(allErrs, validateMirrorSelf(newAutoscaler)...)
+	return allErrs
+}
+
+// ValidateScaleTargetConflict validates that no other active GPA of the existing GPAs in the namespace of the GPA
+// scales the target of the GPA, unless the GPA is annotated with AllowSharedTargetAnnotation.
+func ValidateScaleTargetConflict(autoscaler *autoscaling.GeneralPodAutoscaler,
+	existing []*autoscaling.GeneralPodAutoscaler) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if autoscaler.Annotations[AllowSharedTargetAnnotation] == "true" {
+		return allErrs
+	}
+	ref := autoscaler.Spec.ScaleTargetRef
+	for _, other := range existing {
+		if other.Name == autoscaler.Name || other.DeletionTimestamp != nil {
+			continue
+		}
+		otherRef := other.Spec.ScaleTargetRef
+		if otherRef.Kind != ref.Kind || otherRef.Name != ref.Name || apiGroup(otherRef.APIVersion) != apiGroup(ref.APIVersion) {
+			continue
+		}
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "scaleTargetRef"),
+			fmt.Sprintf("%s %s is already scaled by GPA %s, set annotation %s to \"true\" to allow it",
+				ref.Kind, ref.Name, other.Name, AllowSharedTargetAnnotation)))
+	}
+	return allErrs
+}
+
+// ValidateMaxReplicasCeiling validates that the maxReplicas of the GPA does not exceed the ceiling of the cluster
+// policy, the ceiling is disabled if it is not greater than 0.
+func ValidateMaxReplicasCeiling(autoscaler *autoscaling.GeneralPodAutoscaler, ceiling int32) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if ceiling > 0 && autoscaler.Spec.MaxReplicas > ceiling {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "maxReplicas"), autoscaler.Spec.MaxReplicas,
+			fmt.Sprintf("%s %d of the cluster policy", maxReplicasCeilingDetail, ceiling)))
+	}
+	return allErrs
+}
+
+// ValidateMaxReplicasDrop validates that the maxReplicas of the GPA is not below the ratio of the current replicas of
+// its target, which the next reconcile would scale the target down from at once, unless the GPA is annotated with
+// AllowMaxReplicasDropAnnotation. The check is disabled if the ratio is not greater than 0.
+func ValidateMaxReplicasDrop(autoscaler *autoscaling.GeneralPodAutoscaler, currentReplicas int32, ratio float64) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if ratio <= 0 || autoscaler.Annotations[AllowMaxReplicasDropAnnotation] == "true" {
+		return allErrs
+	}
+	if float64(autoscaler.Spec.MaxReplicas) < float64(currentReplicas)*ratio {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "maxReplicas"), autoscaler.Spec.MaxReplicas,
+			fmt.Sprintf("%s %d of the target, set annotation %s to \"true\" if it is intended",
+				maxReplicasDropDetail, currentReplicas, AllowMaxReplicasDropAnnotation)))
+	}
+	return allErrs
+}
+
+// ValidateResourceRequests validates that the pods of the target of the GPA set the requests of the resources of its
+// utilization targets, or the limits if the GPA is annotated with ComputeByLimitsAnnotation. The utilization of the
+// pods lacking them can not be computed, so the GPA would never scale on it.
+func ValidateResourceRequests(autoscaler *autoscaling.GeneralPodAutoscaler, pods []*v1.Pod) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if autoscaler.Spec.MetricMode == nil {
+		return allErrs
+	}
+	byLimits := autoscaler.Annotations[ComputeByLimitsAnnotation] == "true"
+	kind := "requests"
+	if byLimits {
+		kind = "limits"
+	}
+	fldPath := field.NewPath("spec", "metrics")
+	for i, metric := range autoscaler.Spec.MetricMode.Metrics {
+		var (
+			resource  v1.ResourceName
+			container string
+			target    autoscaling.MetricTarget
+			path      *field.Path
+		)
+		switch {
+		case metric.Type == autoscaling.ResourceMetricSourceType && metric.Resource != nil:
+			resource, target, path = metric.Resource.Name, metric.Resource.Target, fldPath.Index(i).Child("resource")
+		case metric.Type == autoscaling.ContainerResourceMetricSourceType && metric.ContainerResource != nil:
+			resource, target = metric.ContainerResource.Name, metric.ContainerResource.Target
+			container, path = metric.ContainerResource.Container, fldPath.Index(i).Child("containerResource")
+		default:
+			continue
+		}
+		if target.Type != autoscaling.UtilizationMetricType {
+			continue
+		}
+		var missing []string
+		for _, pod := range pods {
+			if !podSetsResource(pod, resource, container, byLimits) {
+				missing = append(missing, pod.Name)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		names := strings.Join(missing, ", ")
+		if len(missing) > maxMissingRequestsPods {
+			names = fmt.Sprintf("%s and %d more", strings.Join(missing[:maxMissingRequestsPods], ", "),
+				len(missing)-maxMissingRequestsPods)
+		}
+		allErrs = append(allErrs, field.Forbidden(path.Child("target", "averageUtilization"),
+			fmt.Sprintf("%s the %s of %s for the utilization, pods %s do not", missingRequestsDetail, kind, resource, names)))
+	}
 	return allErrs
 }
 
+// SecretGetter returns the secret of the name in the namespace of the GPA
+type SecretGetter func(name string) (*v1.Secret, error)
+
+// ValidateSecretReferences validates that the secrets referenced by the GPA exist and have the referenced keys, so
+// that the GPA does not fail to authenticate to its webhooks and metric sources once it is synced. err is returned
+// if a secret can not be got for another reason than it is not found.
+func ValidateSecretReferences(autoscaler *autoscaling.GeneralPodAutoscaler, getSecret SecretGetter) (field.ErrorList, error) {
+	allErrs := field.ErrorList{}
+	secrets := map[string]*v1.Secret{}
+	for _, ref := range secretKeyReferences(autoscaler) {
+		secret, got := secrets[ref.selector.Name]
+		if !got {
+			var err error
+			secret, err = getSecret(ref.selector.Name)
+			if apierrors.IsNotFound(err) {
+				secret = nil
+			} else if err != nil {
+				return nil, fmt.Errorf("get secret %s failed: %v", ref.selector.Name, err)
+			}
+			secrets[ref.selector.Name] = secret
+		}
+		if secret == nil {
+			allErrs = append(allErrs, field.Invalid(ref.path.Child("name"), ref.selector.Name,
+				fmt.Sprintf("%s %s does not exist in namespace %s", missingSecretDetail, ref.selector.Name, autoscaler.Namespace)))
+			continue
+		}
+		if _, ok := secret.Data[ref.selector.Key]; !ok {
+			allErrs = append(allErrs, field.Invalid(ref.path.Child("key"), ref.selector.Key,
+				fmt.Sprintf("%s %s has no key %s", missingSecretDetail, ref.selector.Name, ref.selector.Key)))
+		}
+	}
+	return allErrs, nil
+}
+
+// secretKeyReference is a reference to a key of a secret and the path of it in the GPA
+type secretKeyReference struct {
+	path     *field.Path
+	selector v1.SecretKeySelector
+}
+
+// secretKeyReferences returns the references to the keys of the secrets of the webhooks and the metric sources of
+// the GPA
+func secretKeyReferences(autoscaler *autoscaling.GeneralPodAutoscaler) []secretKeyReference {
+	var refs []secretKeyReference
+	add := func(path *field.Path, selector *v1.SecretKeySelector) {
+		if selector != nil {
+			refs = append(refs, secretKeyReference{path: path, selector: *selector})
+		}
+	}
+	if mode := autoscaler.Spec.WebhookMode; mode != nil {
+		fldPath := field.NewPath("spec", "webhook")
+		add(fldPath.Child("hmacSecretRef"), mode.HMACSecretRef)
+		add(fldPath.Child("caBundleSecretRef"), mode.CABundleSecretRef)
+		for i := range mode.Endpoints {
+			add(fldPath.Child("endpoints").Index(i).Child("hmacSecretRef"), mode.Endpoints[i].HMACSecretRef)
+			add(fldPath.Child("endpoints").Index(i).Child("caBundleSecretRef"), mode.Endpoints[i].CABundleSecretRef)
+		}
+	}
+	if mode := autoscaler.Spec.MetricMode; mode != nil {
+		for i, metric := range mode.Metrics {
+			if metric.KafkaLag == nil {
+				continue
+			}
+			fldPath := field.NewPath("spec", "metrics").Index(i).Child("kafkaLag")
+			if tls := metric.KafkaLag.TLS; tls != nil {
+				add(fldPath.Child("tls", "caSecretRef"), tls.CASecretRef)
+				add(fldPath.Child("tls", "certSecretRef"), tls.CertSecretRef)
+				add(fldPath.Child("tls", "keySecretRef"), tls.KeySecretRef)
+			}
+			if sasl := metric.KafkaLag.SASL; sasl != nil {
+				add(fldPath.Child("sasl", "usernameSecretRef"), &sasl.UsernameSecretRef)
+				add(fldPath.Child("sasl", "passwordSecretRef"), &sasl.PasswordSecretRef)
+			}
+		}
+	}
+	return refs
+}
+
+// podSetsResource returns whether every container of the pod, or the named container, sets the request or
+// the limit of the resource
+func podSetsResource(pod *v1.Pod, resource v1.ResourceName, container string, byLimits bool) bool {
+	found := false
+	for _, c := range pod.Spec.Containers {
+		if container != "" && c.Name != container {
+			continue
+		}
+		found = true
+		list := c.Resources.Requests
+		if byLimits {
+			list = c.Resources.Limits
+		}
+		if _, ok := list[resource]; !ok {
+			return false
+		}
+	}
+	return found
+}
+
+// apiGroup returns the group of the api version, the versions of a group scale the same targets
+func apiGroup(apiVersion string) string {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return apiVersion
+	}
+	return gv.Group
+}
+
 // ValidateHorizontalPodAutoscalerStatusUpdate validates an update to status on a HorizontalPodAutoscaler and
 // returns an ErrorList with any errors.
 func ValidateHorizontalPodAutoscalerStatusUpdate(newAutoscaler, oldAutoscaler *autoscaling.GeneralPodAutoscaler) field.ErrorList {
@@ -142,37 +574,205 @@ func ValidateHorizontalPodAutoscalerStatusUpdate(newAutoscaler, oldAutoscaler *a
 	return allErrs
 }
 
-func validateMetrics(metrics []autoscaling.MetricSpec, fldPath *field.Path, minReplicas *int32) field.ErrorList {
+func validateMetrics(metrics []autoscaling.MetricSpec, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
-	hasObjectMetrics := false
-	hasExternalMetrics := false
-
 	for i, metricSpec := range metrics {
 		idxPath := fldPath.Index(i)
 		if targetErrs := validateMetricSpec(metricSpec, idxPath); len(targetErrs) > 0 {
 			allErrs = append(allErrs, targetErrs...)
 		}
-		if metricSpec.Type == autoscaling.ObjectMetricSourceType {
-			hasObjectMetrics = true
+	}
+	allErrs = append(allErrs, validateDuplicateMetrics(metrics, fldPath)...)
+	return allErrs
+}
+
+// validateDuplicateMetrics forbids the metrics reading the same source as an earlier metric, e.g. the same
+// resource or the same metric name and selector of the same type. They are counted twice by the averages of
+// the blend, and never change the replicas otherwise.
+func validateDuplicateMetrics(metrics []autoscaling.MetricSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	seen := map[string]int{}
+	for i, metricSpec := range metrics {
+		key, ok := metricSourceKey(metricSpec)
+		if !ok {
+			continue
+		}
+		if first, ok := seen[key]; ok {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i),
+				fmt.Sprintf("%s, the same source as metrics[%d]", key, first)))
+			continue
+		}
+		seen[key] = i
+	}
+	return allErrs
+}
+
+// metricSourceKey returns the type and the identifier of the source of the metric, it returns false if the source
+// is not set or its selector is invalid, which is reported by validateMetricSpec.
+func metricSourceKey(spec autoscaling.MetricSpec) (string, bool) {
+	identifier := func(metric autoscaling.MetricIdentifier) (string, bool) {
+		selector, err := metav1.LabelSelectorAsSelector(metric.Selector)
+		if err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("%s(%s)", metric.Name, selector), true
+	}
+	var (
+		id string
+		ok = true
+	)
+	switch {
+	case spec.Type == autoscaling.ResourceMetricSourceType && spec.Resource != nil:
+		id = string(spec.Resource.Name)
+	case spec.Type == autoscaling.ContainerResourceMetricSourceType && spec.ContainerResource != nil:
+		id = fmt.Sprintf("%s of container %s", spec.ContainerResource.Name, spec.ContainerResource.Container)
+	case spec.Type == autoscaling.PodsMetricSourceType && spec.Pods != nil:
+		id, ok = identifier(spec.Pods.Metric)
+	case spec.Type == autoscaling.ObjectMetricSourceType && spec.Object != nil:
+		id, ok = identifier(spec.Object.Metric)
+		ref := spec.Object.DescribedObject
+		id = fmt.Sprintf("%s of %s %s/%s", id, apiGroup(ref.APIVersion), ref.Kind, ref.Name)
+	case spec.Type == autoscaling.ExternalMetricSourceType && spec.External != nil:
+		id, ok = identifier(spec.External.Metric)
+	case spec.Type == autoscaling.DerivativeMetricSourceType && spec.Derivative != nil:
+		id, ok = identifier(spec.Derivative.Metric)
+	case spec.Type == autoscaling.CounterDeltaMetricSourceType && spec.CounterDelta != nil:
+		id, ok = identifier(spec.CounterDelta.Metric)
+	case spec.Type == autoscaling.RatioMetricSourceType && spec.Ratio != nil:
+		numerator, numeratorOK := identifier(spec.Ratio.Numerator)
+		denominator, denominatorOK := identifier(spec.Ratio.Denominator)
+		id, ok = numerator+" / "+denominator, numeratorOK && denominatorOK
+	case spec.Type == autoscaling.ConcurrencyMetricSourceType && spec.Concurrency != nil:
+		id, ok = identifier(spec.Concurrency.Metric)
+	case spec.Type == autoscaling.BucketTableMetricSourceType && spec.BucketTable != nil:
+		id, ok = identifier(spec.BucketTable.Metric)
+	case spec.Type == autoscaling.ScrapeMetricSourceType && spec.Scrape != nil:
+		id = fmt.Sprintf("%s on port %s", spec.Scrape.MetricName, spec.Scrape.Port.String())
+	case spec.Type == autoscaling.ProbeMetricSourceType && spec.Probe != nil:
+		id = spec.Probe.URL
+	case spec.Type == autoscaling.KafkaLagMetricSourceType && spec.KafkaLag != nil:
+		id = fmt.Sprintf("group %s on topic %s", spec.KafkaLag.ConsumerGroup, spec.KafkaLag.Topic)
+	default:
+		return "", false
+	}
+	return fmt.Sprintf("%s metric %s", spec.Type, id), ok
+}
+
+// validateScaleToZero forbids minReplicas 0 unless the spec has a trigger scaling the target up from zero
+// replicas. The metrics of the pods cannot be computed without any pod, so only Object and External metrics
+// wake the target up in metric mode, while the event, webhook, time, cluster-proportional and mirror modes do not
+// depend on the pods.
+func validateScaleToZero(autoscaler autoscaling.GeneralPodAutoscalerSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if autoscaler.MinReplicas == nil || *autoscaler.MinReplicas != 0 {
+		return allErrs
+	}
+	mode := autoscaler.AutoScalingDrivenMode
+	if mode.EventMode != nil || mode.WebhookMode != nil || mode.TimeMode != nil || mode.ClusterProportionalMode != nil ||
+		mode.MirrorMode != nil {
+		return allErrs
+	}
+	if mode.MetricMode == nil {
+		return append(allErrs, field.Forbidden(fldPath.Child("minReplicas"), scaleToZeroTriggerDetail))
+	}
+	for _, metricSpec := range mode.MetricMode.Metrics {
+		if metricSpec.Type == autoscaling.ObjectMetricSourceType || metricSpec.Type == autoscaling.ExternalMetricSourceType {
+			return allErrs
 		}
-		if metricSpec.Type == autoscaling.ExternalMetricSourceType {
-			hasExternalMetrics = true
+	}
+	return append(allErrs, field.Forbidden(fldPath.Child("metrics"),
+		"must specify at least one Object or External metric, or an event, webhook or time mode, to support scaling to zero replicas"))
+}
+
+var validWebhookSelectPolicies = sets.NewString(
+	string(autoscaling.FirstSuccessWebhookSelect),
+	string(autoscaling.MaxWebhookSelect),
+	string(autoscaling.MinWebhookSelect))
+
+func validateWebhookMode(mode *autoscaling.WebhookMode, fldPath *field.Path) field.ErrorList {
+	allErrs := validateWebhook(mode.WebhookClientConfig, fldPath)
+	for i, endpoint := range mode.Endpoints {
+		allErrs = append(allErrs, validateWebhook(endpoint.WebhookClientConfig, fldPath.Child("endpoints").Index(i))...)
+	}
+	if mode.SelectPolicy != nil && !validWebhookSelectPolicies.Has(string(*mode.SelectPolicy)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("selectPolicy"), *mode.SelectPolicy,
+			validWebhookSelectPolicies.List()))
+	}
+	return allErrs
+}
+
+func validateClusterProportionalMode(mode *autoscaling.ClusterProportionalMode, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if mode.CoresPerReplica < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("coresPerReplica"), mode.CoresPerReplica,
+			"must be greater than or equal to 0"))
+	}
+	if mode.NodesPerReplica < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("nodesPerReplica"), mode.NodesPerReplica,
+			"must be greater than or equal to 0"))
+	}
+	if mode.Ladder == nil {
+		if mode.CoresPerReplica == 0 && mode.NodesPerReplica == 0 {
+			allErrs = append(allErrs, field.Required(fldPath,
+				"must specify at least one of coresPerReplica, nodesPerReplica and ladder"))
 		}
+		return allErrs
+	}
+	if len(mode.Ladder.CoresToReplicas) == 0 && len(mode.Ladder.NodesToReplicas) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("ladder"),
+			"must specify at least one step of coresToReplicas or nodesToReplicas"))
 	}
+	allErrs = append(allErrs, validateClusterProportionalSteps(mode.Ladder.CoresToReplicas,
+		fldPath.Child("ladder", "coresToReplicas"))...)
+	allErrs = append(allErrs, validateClusterProportionalSteps(mode.Ladder.NodesToReplicas,
+		fldPath.Child("ladder", "nodesToReplicas"))...)
+	return allErrs
+}
 
-	if minReplicas != nil && *minReplicas == 0 {
-		if !hasObjectMetrics && !hasExternalMetrics {
-			allErrs = append(allErrs, field.Forbidden(fldPath, "must specify at least one Object or External metric to support scaling to zero replicas"))
+func validateMirrorMode(mode *autoscaling.MirrorMode, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if mode.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "must specify the name of the mirrored GPA"))
+	} else {
+		for _, msg := range ValidateHorizontalPodAutoscalerName(mode.Name, false) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), mode.Name, msg))
 		}
 	}
+	if mode.Factor != nil && *mode.Factor <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("factor"), *mode.Factor, "must be greater than 0"))
+	}
+	return allErrs
+}
+
+// validateMirrorSelf forbids a GPA to mirror itself
+func validateMirrorSelf(autoscaler *autoscaling.GeneralPodAutoscaler) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if mode := autoscaler.Spec.MirrorMode; mode != nil && mode.Name == autoscaler.Name {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "mirror", "name"), mode.Name,
+			"must not be the name of the GPA itself"))
+	}
+	return allErrs
+}
 
+func validateClusterProportionalSteps(steps []autoscaling.ClusterProportionalStep, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for i, step := range steps {
+		if step.Size < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("size"), step.Size,
+				"must be greater than or equal to 0"))
+		}
+		if step.Replicas < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("replicas"), step.Replicas,
+				"must be greater than or equal to 0"))
+		}
+	}
 	return allErrs
 }
 
 func validateWebhook(wc *v1beta1.WebhookClientConfig, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	if wc == nil {
-		allErrs = append(allErrs, field.Forbidden(fldPath, "webhook config should not be empty"))
+		return append(allErrs, field.Forbidden(fldPath, "webhook config should not be empty"))
 	}
 	switch {
 	case wc.Service == nil && wc.URL == nil:
@@ -191,21 +791,45 @@ func validateWebhook(wc *v1beta1.WebhookClientConfig, fldPath *field.Path) field
 	return allErrs
 }
 
+func validateTimeExceptions(exceptions *autoscaling.TimeExceptions, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if exceptions == nil {
+		return allErrs
+	}
+	for i, date := range exceptions.Dates {
+		if _, err := time.Parse(autoscaling.TimeExceptionDateLayout, date); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("dates").Index(i), date,
+				"must be a date in the layout of "+autoscaling.TimeExceptionDateLayout))
+		}
+	}
+	if ref := exceptions.ConfigMapKeyRef; ref != nil {
+		if len(ref.Name) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("configMapKeyRef", "name"), ""))
+		}
+		if len(ref.Key) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("configMapKeyRef", "key"), ""))
+		}
+	}
+	return allErrs
+}
+
 func validateTime(timeRanges []autoscaling.TimeRange, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	if len(timeRanges) == 0 {
-		allErrs = append(allErrs, field.Forbidden(fldPath.Child("timeRanges"), "at least one timeRanges should set"))
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("ranges"), "at least one timeRanges should set"))
 	}
-	for _, timeRange := range timeRanges {
+	for i, timeRange := range timeRanges {
+		rangePath := fldPath.Child("ranges").Index(i)
 		if timeRange.DesiredReplicas == 0 {
-			allErrs = append(allErrs, field.Forbidden(fldPath.Child("desiredReplicas"), "should not 0"))
+			allErrs = append(allErrs, field.Forbidden(rangePath.Child("desiredReplicas"), "should not 0"))
 		}
 		if len(timeRange.Schedule) == 0 {
-			allErrs = append(allErrs, field.Forbidden(fldPath.Child("schedule"), "should not empty"))
+			allErrs = append(allErrs, field.Forbidden(rangePath.Child("schedule"), "should not empty"))
 		} else {
-			_, err := cron.Parse(timeRange.Schedule)
+			// parse with the same parser as the controller
+			_, err := scalercore.ParseSchedule(timeRange.Schedule)
 			if err != nil {
-				allErrs = append(allErrs, field.Forbidden(fldPath.Child("schedule"), err.Error()))
+				allErrs = append(allErrs, field.Invalid(rangePath.Child("schedule"), timeRange.Schedule, err.Error()))
 			}
 		}
 	}
@@ -232,19 +856,96 @@ func validateEvent(triggers []autoscaling.ScaleTriggers, fldPath *field.Path) fi
 func validateBehavior(behavior *autoscaling.GeneralPodAutoscalerBehavior, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	if behavior != nil {
-		if scaleUpErrs := validateScalingRules(behavior.ScaleUp, fldPath.Child("scaleUp")); len(scaleUpErrs) > 0 {
-			allErrs = append(allErrs, scaleUpErrs...)
+		allErrs = append(allErrs, validateDirectionRules(behavior.ScaleUp, behavior.ScaleDown, fldPath)...)
+		for _, mode := range []struct {
+			name  string
+			rules *autoscaling.ModeScalingRules
+		}{
+			{"metric", behavior.Metric},
+			{"webhook", behavior.Webhook},
+			{"time", behavior.Time},
+			{"clusterProportional", behavior.ClusterProportional},
+			{"mirror", behavior.Mirror},
+		} {
+			if mode.rules != nil {
+				allErrs = append(allErrs, validateDirectionRules(mode.rules.ScaleUp, mode.rules.ScaleDown,
+					fldPath.Child(mode.name))...)
+			}
+		}
+		if behavior.EWMAAlpha != nil && (*behavior.EWMAAlpha <= 0 || *behavior.EWMAAlpha > 1) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("ewmaAlpha"), *behavior.EWMAAlpha,
+				"must be greater than 0 and less than or equal to 1"))
 		}
-		if scaleDownErrs := validateScalingRules(behavior.ScaleDown, fldPath.Child("scaleDown")); len(scaleDownErrs) > 0 {
-			allErrs = append(allErrs, scaleDownErrs...)
+		if pid := behavior.PID; pid != nil {
+			if pid.Kp < 0 {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("pid", "kp"), pid.Kp, "must not be negative"))
+			}
+			if pid.Ki < 0 {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("pid", "ki"), pid.Ki, "must not be negative"))
+			}
+			if pid.Kp == 0 && pid.Ki == 0 {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("pid"), pid.Kp, "kp and ki must not both be 0"))
+			}
+		}
+		if delay := behavior.ScaleDownDelayAfterScaleUpSeconds; delay != nil &&
+			(*delay < 0 || *delay > MaxStabilizationWindowSeconds) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("scaleDownDelayAfterScaleUpSeconds"), *delay,
+				fmt.Sprintf("must be greater than or equal to zero and less than or equal to %d", MaxStabilizationWindowSeconds)))
+		}
+		if floor := behavior.PeakFloor; floor != nil {
+			if floor.WindowSeconds <= 0 || floor.WindowSeconds > MaxPeakFloorWindowSeconds {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("peakFloor", "windowSeconds"), floor.WindowSeconds,
+					fmt.Sprintf("must be greater than zero and less than or equal to %d", MaxPeakFloorWindowSeconds)))
+			}
+			if floor.Percent != nil && (*floor.Percent <= 0 || *floor.Percent > 100) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("peakFloor", "percent"), *floor.Percent,
+					"must be greater than 0 and less than or equal to 100"))
+			}
 		}
 	}
 	return allErrs
 }
 
+// validateReplicaStep validates the step of the replicas has a multiple within the min and max replicas
+func validateReplicaStep(autoscaler autoscaling.GeneralPodAutoscalerSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if autoscaler.Behavior == nil || autoscaler.Behavior.ReplicaStep == nil {
+		return allErrs
+	}
+	step := *autoscaler.Behavior.ReplicaStep
+	if step <= 0 {
+		return append(allErrs, field.Invalid(fldPath, step, "must be greater than zero"))
+	}
+	minReplicas := int32(1)
+	if autoscaler.MinReplicas != nil {
+		minReplicas = *autoscaler.MinReplicas
+	}
+	if (minReplicas+step-1)/step*step > autoscaler.MaxReplicas {
+		allErrs = append(allErrs, field.Invalid(fldPath, step,
+			fmt.Sprintf("must have a multiple within minReplicas %d and maxReplicas %d", minReplicas,
+				autoscaler.MaxReplicas)))
+	}
+	return allErrs
+}
+
 var validSelectPolicyTypes = sets.NewString(string(autoscaling.MaxPolicySelect), string(autoscaling.MinPolicySelect), string(autoscaling.DisabledPolicySelect))
 var validSelectPolicyTypesList = validSelectPolicyTypes.List()
 
+// validateDirectionRules validates the scaling rules of both directions, shared by the modes or of a mode
+func validateDirectionRules(scaleUp, scaleDown *autoscaling.GPAScalingRules, fldPath *field.Path) field.ErrorList {
+	allErrs := validateScalingRules(scaleUp, fldPath.Child("scaleUp"))
+	allErrs = append(allErrs, validateScalingRules(scaleDown, fldPath.Child("scaleDown"))...)
+	if scaleDown != nil {
+		if scaleDown.MaxFactor != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("scaleDown", "maxFactor"), "only supported for scale up"))
+		}
+		if scaleDown.MaxAbsolute != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("scaleDown", "maxAbsolute"), "only supported for scale up"))
+		}
+	}
+	return allErrs
+}
+
 func validateScalingRules(rules *autoscaling.GPAScalingRules, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	if rules != nil {
@@ -258,9 +959,27 @@ func validateScalingRules(rules *autoscaling.GPAScalingRules, fldPath *field.Pat
 		if rules.SelectPolicy != nil && !validSelectPolicyTypes.Has(string(*rules.SelectPolicy)) {
 			allErrs = append(allErrs, field.NotSupported(fldPath.Child("selectPolicy"), rules.SelectPolicy, validSelectPolicyTypesList))
 		}
+		if rules.MaxFactor != nil && *rules.MaxFactor < 1 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("maxFactor"), *rules.MaxFactor, "must be greater than or equal to 1"))
+		}
+		if rules.MaxAbsolute != nil && *rules.MaxAbsolute <= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("maxAbsolute"), *rules.MaxAbsolute, "must be greater than zero"))
+		}
 		policiesPath := fldPath.Child("policies")
-		if len(rules.Policies) == 0 {
-			allErrs = append(allErrs, field.Required(policiesPath, "must specify at least one Policy"))
+		disabled := rules.SelectPolicy != nil && *rules.SelectPolicy == autoscaling.DisabledPolicySelect
+		switch {
+		case disabled && len(rules.Policies) > 0:
+			allErrs = append(allErrs, field.Forbidden(policiesPath,
+				"must be empty when selectPolicy is Disabled, the policies never apply to a disabled direction"))
+		case !disabled && len(rules.Policies) == 0:
+			allErrs = append(allErrs, field.Required(policiesPath,
+				"must specify at least one Policy unless selectPolicy is Disabled"))
+		}
+		if disabled && rules.MaxFactor != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("maxFactor"), "must not be set when selectPolicy is Disabled"))
+		}
+		if disabled && rules.MaxAbsolute != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("maxAbsolute"), "must not be set when selectPolicy is Disabled"))
 		}
 		for i, policy := range rules.Policies {
 			idxPath := policiesPath.Index(i)
@@ -283,12 +1002,9 @@ func validateScalingPolicy(policy autoscaling.GPAScalingPolicy, fldPath *field.P
 	if policy.Value <= 0 {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("value"), policy.Value, "must be greater than zero"))
 	}
-	if policy.PeriodSeconds <= 0 {
-		allErrs = append(allErrs, field.Invalid(fldPath.Child("periodSeconds"), policy.PeriodSeconds, "must be greater than zero"))
-	}
-	if policy.PeriodSeconds > MaxPeriodSeconds {
+	if policy.PeriodSeconds <= 0 || policy.PeriodSeconds > MaxPeriodSeconds {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("periodSeconds"), policy.PeriodSeconds,
-			fmt.Sprintf("must be less than or equal to %v", MaxPeriodSeconds)))
+			fmt.Sprintf("must be between 1 and %d seconds", MaxPeriodSeconds)))
 	}
 	return allErrs
 }
@@ -298,12 +1014,24 @@ var validMetricSourceTypes = sets.NewString(
 	string(autoscaling.PodsMetricSourceType),
 	string(autoscaling.ResourceMetricSourceType),
 	string(autoscaling.ContainerResourceMetricSourceType),
-	string(autoscaling.ExternalMetricSourceType))
+	string(autoscaling.ExternalMetricSourceType),
+	string(autoscaling.DerivativeMetricSourceType),
+	string(autoscaling.ProbeMetricSourceType),
+	string(autoscaling.KafkaLagMetricSourceType),
+	string(autoscaling.CounterDeltaMetricSourceType),
+	string(autoscaling.RatioMetricSourceType),
+	string(autoscaling.ConcurrencyMetricSourceType),
+	string(autoscaling.ScrapeMetricSourceType),
+	string(autoscaling.BucketTableMetricSourceType))
 var validMetricSourceTypesList = validMetricSourceTypes.List()
 
 func validateMetricSpec(spec autoscaling.MetricSpec, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 
+	if spec.Weight != nil && *spec.Weight <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("weight"), *spec.Weight, "must be greater than 0"))
+	}
+
 	if len(string(spec.Type)) == 0 {
 		allErrs = append(allErrs, field.Required(fldPath.Child("type"), "must specify a metric source type"))
 	}
@@ -327,6 +1055,62 @@ func validateMetricSpec(spec autoscaling.MetricSpec, fldPath *field.Path) field.
 		}
 	}
 
+	if spec.Derivative != nil {
+		typesPresent.Insert("derivative")
+		if typesPresent.Len() == 1 {
+			allErrs = append(allErrs, validateDerivativeSource(spec.Derivative, fldPath.Child("derivative"))...)
+		}
+	}
+
+	if spec.Probe != nil {
+		typesPresent.Insert("probe")
+		if typesPresent.Len() == 1 {
+			allErrs = append(allErrs, validateProbeSource(spec.Probe, fldPath.Child("probe"))...)
+		}
+	}
+
+	if spec.KafkaLag != nil {
+		typesPresent.Insert("kafkaLag")
+		if typesPresent.Len() == 1 {
+			allErrs = append(allErrs, validateKafkaLagSource(spec.KafkaLag, fldPath.Child("kafkaLag"))...)
+		}
+	}
+
+	if spec.CounterDelta != nil {
+		typesPresent.Insert("counterDelta")
+		if typesPresent.Len() == 1 {
+			allErrs = append(allErrs, validateCounterDeltaSource(spec.CounterDelta, fldPath.Child("counterDelta"))...)
+		}
+	}
+
+	if spec.Ratio != nil {
+		typesPresent.Insert("ratio")
+		if typesPresent.Len() == 1 {
+			allErrs = append(allErrs, validateRatioSource(spec.Ratio, fldPath.Child("ratio"))...)
+		}
+	}
+
+	if spec.Concurrency != nil {
+		typesPresent.Insert("concurrency")
+		if typesPresent.Len() == 1 {
+			allErrs = append(allErrs, validateConcurrencySource(spec.Concurrency, fldPath.Child("concurrency"))...)
+		}
+	}
+
+	if spec.Scrape != nil {
+		typesPresent.Insert("scrape")
+		if typesPresent.Len() == 1 {
+			allErrs = append(allErrs, validateScrapeSource(spec.Scrape, fldPath.Child("scrape"))...)
+		}
+	}
+
+	if spec.BucketTable != nil {
+		typesPresent.Insert("bucketTable")
+		if typesPresent.Len() == 1 {
+			allErrs = append(allErrs, validateBucketTableSource(spec.BucketTable, fldPath.Child("bucketTable"))...)
+		}
+	}
+
 	if spec.Pods != nil {
 		typesPresent.Insert("pods")
 		if typesPresent.Len() == 1 {
@@ -341,6 +1125,13 @@ func validateMetricSpec(spec autoscaling.MetricSpec, fldPath *field.Path) field.
 		}
 	}
 
+	if spec.ContainerResource != nil {
+		typesPresent.Insert("containerResource")
+		if typesPresent.Len() == 1 {
+			allErrs = append(allErrs, validateContainerResourceSource(spec.ContainerResource, fldPath.Child("containerResource"))...)
+		}
+	}
+
 	var expectedField string
 	switch spec.Type {
 
@@ -369,6 +1160,46 @@ func validateMetricSpec(spec autoscaling.MetricSpec, fldPath *field.Path) field.
 			allErrs = append(allErrs, field.Required(fldPath.Child("external"), "must populate information for the given metric source"))
 		}
 		expectedField = "external"
+	case autoscaling.DerivativeMetricSourceType:
+		if spec.Derivative == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("derivative"), "must populate information for the given metric source"))
+		}
+		expectedField = "derivative"
+	case autoscaling.ProbeMetricSourceType:
+		if spec.Probe == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("probe"), "must populate information for the given metric source"))
+		}
+		expectedField = "probe"
+	case autoscaling.KafkaLagMetricSourceType:
+		if spec.KafkaLag == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("kafkaLag"), "must populate information for the given metric source"))
+		}
+		expectedField = "kafkaLag"
+	case autoscaling.CounterDeltaMetricSourceType:
+		if spec.CounterDelta == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("counterDelta"), "must populate information for the given metric source"))
+		}
+		expectedField = "counterDelta"
+	case autoscaling.RatioMetricSourceType:
+		if spec.Ratio == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("ratio"), "must populate information for the given metric source"))
+		}
+		expectedField = "ratio"
+	case autoscaling.ConcurrencyMetricSourceType:
+		if spec.Concurrency == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("concurrency"), "must populate information for the given metric source"))
+		}
+		expectedField = "concurrency"
+	case autoscaling.ScrapeMetricSourceType:
+		if spec.Scrape == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("scrape"), "must populate information for the given metric source"))
+		}
+		expectedField = "scrape"
+	case autoscaling.BucketTableMetricSourceType:
+		if spec.BucketTable == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("bucketTable"), "must populate information for the given metric source"))
+		}
+		expectedField = "bucketTable"
 	default:
 		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), spec.Type, validMetricSourceTypesList))
 	}
@@ -394,6 +1225,10 @@ func validateObjectSource(src *autoscaling.ObjectMetricSource, fldPath *field.Pa
 		allErrs = append(allErrs, field.Required(fldPath.Child("target").Child("averageValue"), "must set either a target value or averageValue"))
 	}
 
+	if src.WindowSeconds != nil && *src.WindowSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("windowSeconds"), *src.WindowSeconds, "must be greater than 0"))
+	}
+
 	return allErrs
 }
 
@@ -411,6 +1246,246 @@ func validateExternalSource(src *autoscaling.ExternalMetricSource, fldPath *fiel
 		allErrs = append(allErrs, field.Forbidden(fldPath.Child("target").Child("value"), "may not set both a target value for metric and a per-pod target"))
 	}
 
+	if src.WindowSeconds != nil && *src.WindowSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("windowSeconds"), *src.WindowSeconds, "must be greater than 0"))
+	}
+
+	return allErrs
+}
+
+func validateDerivativeSource(src *autoscaling.DerivativeMetricSource, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, validateMetricIdentifier(src.Metric, fldPath.Child("metric"))...)
+	allErrs = append(allErrs, validateMetricTarget(src.Target, fldPath.Child("target"))...)
+
+	if src.Target.Value == nil && src.Target.AverageValue == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("target").Child("averageValue"), "must set either a target value for metric or a per-pod target"))
+	}
+
+	if src.Target.Value != nil && src.Target.AverageValue != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("target").Child("value"), "may not set both a target value for metric and a per-pod target"))
+	}
+
+	if src.WindowSeconds != nil && *src.WindowSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("windowSeconds"), *src.WindowSeconds, "must be greater than 0"))
+	}
+
+	if src.LookaheadSeconds != nil && *src.LookaheadSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("lookaheadSeconds"), *src.LookaheadSeconds, "must be greater than 0"))
+	}
+
+	if src.SmoothingSeconds != nil && *src.SmoothingSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("smoothingSeconds"), *src.SmoothingSeconds, "must be greater than 0"))
+	}
+
+	if src.SeasonSeconds != nil {
+		// the default lookaheadSeconds of the derivative source
+		lookahead := int32(180)
+		if src.LookaheadSeconds != nil {
+			lookahead = *src.LookaheadSeconds
+		}
+		if *src.SeasonSeconds <= lookahead {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("seasonSeconds"), *src.SeasonSeconds, "must be greater than lookaheadSeconds"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateCounterDeltaSource(src *autoscaling.CounterDeltaMetricSource, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, validateMetricIdentifier(src.Metric, fldPath.Child("metric"))...)
+
+	if src.Target.AverageValue == nil || src.Target.AverageValue.Sign() <= 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("target").Child("averageValue"), "must specify a positive target increase per pod"))
+	}
+
+	if src.IntervalSeconds != nil && *src.IntervalSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("intervalSeconds"), *src.IntervalSeconds, "must be greater than 0"))
+	}
+
+	return allErrs
+}
+
+func validateRatioSource(src *autoscaling.RatioMetricSource, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, validateMetricIdentifier(src.Numerator, fldPath.Child("numerator"))...)
+	allErrs = append(allErrs, validateMetricIdentifier(src.Denominator, fldPath.Child("denominator"))...)
+
+	if src.Target.AverageUtilization == nil || *src.Target.AverageUtilization <= 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("target").Child("averageUtilization"), "must specify a positive target ratio in percent"))
+	}
+
+	return allErrs
+}
+
+func validateConcurrencySource(src *autoscaling.ConcurrencyMetricSource, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, validateMetricIdentifier(src.Metric, fldPath.Child("metric"))...)
+
+	if src.Target.AverageValue == nil || src.Target.AverageValue.Sign() <= 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("target").Child("averageValue"), "must specify a positive target of in-flight requests per pod"))
+	}
+
+	return allErrs
+}
+
+func validateScrapeSource(src *autoscaling.ScrapeMetricSource, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if src.Port.Type == intstr.String {
+		for _, msg := range utilvalidation.IsValidPortName(src.Port.StrVal) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("port"), src.Port.StrVal, msg))
+		}
+	} else {
+		for _, msg := range utilvalidation.IsValidPortNum(src.Port.IntValue()) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("port"), src.Port.IntValue(), msg))
+		}
+	}
+
+	if src.Path != "" && !strings.HasPrefix(src.Path, "/") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("path"), src.Path, "must be an absolute path"))
+	}
+
+	if len(src.MetricName) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("metricName"), "must specify the name of the gauge"))
+	}
+
+	if src.Target.AverageValue == nil || src.Target.AverageValue.Sign() <= 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("target").Child("averageValue"), "must specify a positive target value per pod"))
+	}
+
+	if src.TimeoutSeconds != nil && *src.TimeoutSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeoutSeconds"), *src.TimeoutSeconds, "must be greater than 0"))
+	}
+
+	return allErrs
+}
+
+// validateBucketTableSource validates that the buckets are contiguous, i.e. neither overlap nor leave gaps, so
+// that each value from the first bucket on selects exactly one bucket
+func validateBucketTableSource(src *autoscaling.BucketTableMetricSource, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, validateMetricIdentifier(src.Metric, fldPath.Child("metric"))...)
+
+	if len(src.Buckets) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("buckets"), "must specify at least one bucket"))
+	}
+	for i, bucket := range src.Buckets {
+		idxPath := fldPath.Child("buckets").Index(i)
+		if bucket.Replicas < 0 {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("replicas"), bucket.Replicas, "must be greater than or equal to 0"))
+		}
+		if bucket.To == nil && i < len(src.Buckets)-1 {
+			allErrs = append(allErrs, field.Required(idxPath.Child("to"), "must be set unless it is the last bucket"))
+		}
+		if bucket.To != nil && bucket.To.Cmp(bucket.From) <= 0 {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("to"), bucket.To.String(),
+				fmt.Sprintf("must be greater than from %s", bucket.From.String())))
+		}
+		if i > 0 {
+			previous := src.Buckets[i-1]
+			if previous.To != nil && previous.To.Cmp(bucket.From) != 0 {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("from"), bucket.From.String(),
+					fmt.Sprintf("must be equal to the upper bound %s of the previous bucket, the buckets must be contiguous",
+						previous.To.String())))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func validateProbeSource(src *autoscaling.ProbeMetricSource, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(src.URL) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("url"), "must specify the url to probe"))
+	} else if u, err := url.Parse(src.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("url"), src.URL, "must be an absolute http or https url"))
+	}
+
+	// the latencies are probed in milliseconds
+	if src.TargetLatency.Duration < time.Millisecond {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("targetLatency"), src.TargetLatency.Duration.String(), "must be at least 1ms"))
+	}
+
+	if src.PeriodSeconds != nil && *src.PeriodSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("periodSeconds"), *src.PeriodSeconds, "must be greater than 0"))
+	}
+
+	if src.TimeoutSeconds != nil && *src.TimeoutSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeoutSeconds"), *src.TimeoutSeconds, "must be greater than 0"))
+	}
+
+	if src.WindowSeconds != nil && *src.WindowSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("windowSeconds"), *src.WindowSeconds, "must be greater than 0"))
+	}
+
+	if auth := src.TokenAuth; auth != nil {
+		if name := auth.TokenFile; name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("tokenAuth", "tokenFile"), name,
+				"must be the name of a file in the projected token directory of the controller"))
+		}
+		if auth.Header != "" {
+			for _, msg := range utilvalidation.IsHTTPHeaderName(auth.Header) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("tokenAuth", "header"), auth.Header, msg))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+var validKafkaSASLMechanisms = sets.NewString(string(autoscaling.KafkaSASLPlain))
+
+func validateKafkaLagSource(src *autoscaling.KafkaLagMetricSource, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(src.Brokers) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("brokers"), "must specify at least one broker"))
+	}
+	for i, broker := range src.Brokers {
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("brokers").Index(i), broker, "must be a host:port address"))
+		}
+	}
+
+	if len(src.Topic) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("topic"), "must specify the topic"))
+	}
+
+	if len(src.ConsumerGroup) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("consumerGroup"), "must specify the consumer group"))
+	}
+
+	allErrs = append(allErrs, validateMetricTarget(src.Target, fldPath.Child("target"))...)
+	if src.Target.Type != autoscaling.AverageValueMetricType || src.Target.AverageValue == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("target").Child("averageValue"), "must set a per-pod target of the lag"))
+	}
+
+	if src.TLS != nil && (src.TLS.CertSecretRef == nil) != (src.TLS.KeySecretRef == nil) {
+		allErrs = append(allErrs, field.Required(fldPath.Child("tls"), "must set both certSecretRef and keySecretRef, or neither"))
+	}
+
+	if src.SASL != nil {
+		saslPath := fldPath.Child("sasl")
+		if len(src.SASL.Mechanism) > 0 && !validKafkaSASLMechanisms.Has(string(src.SASL.Mechanism)) {
+			allErrs = append(allErrs, field.NotSupported(saslPath.Child("mechanism"), src.SASL.Mechanism, validKafkaSASLMechanisms.List()))
+		}
+		if len(src.SASL.UsernameSecretRef.Name) == 0 || len(src.SASL.UsernameSecretRef.Key) == 0 {
+			allErrs = append(allErrs, field.Required(saslPath.Child("usernameSecretRef"), "must specify the name and key of the secret"))
+		}
+		if len(src.SASL.PasswordSecretRef.Name) == 0 || len(src.SASL.PasswordSecretRef.Key) == 0 {
+			allErrs = append(allErrs, field.Required(saslPath.Child("passwordSecretRef"), "must specify the name and key of the secret"))
+		}
+	}
+
 	return allErrs
 }
 
@@ -424,6 +1499,10 @@ func validatePodsSource(src *autoscaling.PodsMetricSource, fldPath *field.Path)
 		allErrs = append(allErrs, field.Required(fldPath.Child("target").Child("averageValue"), "must specify a positive target averageValue"))
 	}
 
+	if src.WindowSeconds != nil && *src.WindowSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("windowSeconds"), *src.WindowSeconds, "must be greater than 0"))
+	}
+
 	return allErrs
 }
 
@@ -444,6 +1523,38 @@ func validateResourceSource(src *autoscaling.ResourceMetricSource, fldPath *fiel
 		allErrs = append(allErrs, field.Forbidden(fldPath.Child("target").Child("averageValue"), "may not set both a target raw value and a target utilization"))
 	}
 
+	if src.HalfLifeSeconds != nil && *src.HalfLifeSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("halfLifeSeconds"), *src.HalfLifeSeconds, "must be greater than 0"))
+	}
+
+	return allErrs
+}
+
+func validateContainerResourceSource(src *autoscaling.ContainerResourceMetricSource, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(src.Name) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "must specify a resource name"))
+	}
+
+	if len(src.Container) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("container"), "must specify a container"))
+	} else {
+		for _, msg := range utilvalidation.IsDNS1123Label(src.Container) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("container"), src.Container, msg))
+		}
+	}
+
+	allErrs = append(allErrs, validateMetricTarget(src.Target, fldPath.Child("target"))...)
+
+	if src.Target.AverageUtilization == nil && src.Target.AverageValue == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("target").Child("averageUtilization"), "must set either a target raw value or a target utilization"))
+	}
+
+	if src.Target.AverageUtilization != nil && src.Target.AverageValue != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("target").Child("averageValue"), "may not set both a target raw value and a target utilization"))
+	}
+
 	return allErrs
 }
 
@@ -482,12 +1593,22 @@ func validateMetricTarget(mt autoscaling.MetricTarget, fldPath *field.Path) fiel
 func validateMetricIdentifier(id autoscaling.MetricIdentifier, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 
-	if len(id.Name) == 0 {
+	if len(strings.TrimSpace(id.Name)) == 0 {
 		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "must specify a metric name"))
 	} else {
 		for _, msg := range pathvalidation.IsValidPathSegmentName(id.Name) {
 			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), id.Name, msg))
 		}
 	}
+	// the metric is queried by the name and the selector, an invalid selector would only fail on each sync
+	if id.Selector != nil {
+		selectorErrs := metav1validation.ValidateLabelSelector(id.Selector, fldPath.Child("selector"))
+		allErrs = append(allErrs, selectorErrs...)
+		if len(selectorErrs) == 0 {
+			if _, err := metav1.LabelSelectorAsSelector(id.Selector); err != nil {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("selector"), id.Selector.String(), err.Error()))
+			}
+		}
+	}
 	return allErrs
 }
@@ -0,0 +1,1401 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/api/admissionregistration/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	autoscaling "github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func newTestGPA() *autoscaling.GeneralPodAutoscaler {
+	minReplicas := int32(1)
+	return &autoscaling.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpa", Namespace: "default"},
+		Spec: autoscaling.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       "web",
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: 10,
+		},
+	}
+}
+
+func TestValidateTimeSchedule(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		schedule string
+		errMsg   string
+	}{
+		{
+			name:     "every minute",
+			schedule: "* * * * *",
+		},
+		{
+			name:     "ranges and steps",
+			schedule: "*/5 9-18 * * MON-FRI",
+		},
+		{
+			name:     "descriptor",
+			schedule: "@every 1h",
+		},
+		{
+			name:     "seconds field is not supported",
+			schedule: "0 */5 * * * *",
+			errMsg:   "expected 5 fields",
+		},
+		{
+			name:     "too few fields",
+			schedule: "* * *",
+			errMsg:   "found 3",
+		},
+		{
+			name:     "invalid hour",
+			schedule: "0 25 * * *",
+			errMsg:   "invalid hour field \"25\" at position 2",
+		},
+		{
+			name:     "invalid day of week",
+			schedule: "0 0 * * FOO",
+			errMsg:   "invalid day of week field \"FOO\" at position 5",
+		},
+		{
+			name:     "invalid descriptor",
+			schedule: "@sometimes",
+			errMsg:   "Unrecognized descriptor",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			gpa := newTestGPA()
+			gpa.Spec.TimeMode = &autoscaling.TimeMode{
+				TimeRanges: []autoscaling.TimeRange{
+					{Schedule: "* * * * *", DesiredReplicas: 2},
+					{Schedule: c.schedule, DesiredReplicas: 3},
+				},
+			}
+			errs := ValidateHorizontalPodAutoscaler(gpa)
+			if c.errMsg == "" {
+				if len(errs) != 0 {
+					t.Errorf("expected no error, got: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got: %v", errs)
+			}
+			if errs[0].Field != "spec.time.ranges[1].schedule" {
+				t.Errorf("expected error on spec.time.ranges[1].schedule, got: %v", errs[0].Field)
+			}
+			if !strings.Contains(errs[0].Error(), c.errMsg) {
+				t.Errorf("expected error containing %q, got: %v", c.errMsg, errs[0].Error())
+			}
+		})
+	}
+}
+
+func TestValidationReasons(t *testing.T) {
+	zero := int32(0)
+	three := int32(3)
+	negative := int32(-1)
+	for _, c := range []struct {
+		name   string
+		modify func(gpa *autoscaling.GeneralPodAutoscaler)
+		reason Reason
+	}{
+		{
+			name:   "min greater than max",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Spec.MinReplicas = &three; gpa.Spec.MaxReplicas = 2 },
+			reason: ReasonMinGreaterThanMax,
+		},
+		{
+			name:   "negative min replicas",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Spec.MinReplicas = &negative },
+			reason: ReasonInvalidMinReplicas,
+		},
+		{
+			name: "zero max replicas",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MinReplicas = nil
+				gpa.Spec.MaxReplicas = 0
+			},
+			reason: ReasonInvalidMaxReplicas,
+		},
+		{
+			name:   "missing scale target name",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Spec.ScaleTargetRef.Name = "" },
+			reason: ReasonInvalidScaleTargetRef,
+		},
+		{
+			name: "metric without source",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{Type: autoscaling.PodsMetricSourceType}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "container resource without container",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				utilization := int32(50)
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.ContainerResourceMetricSourceType,
+						ContainerResource: &autoscaling.ContainerResourceMetricSource{
+							Name: "cpu",
+							Target: autoscaling.MetricTarget{
+								Type:               autoscaling.UtilizationMetricType,
+								AverageUtilization: &utilization,
+							},
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "kafka lag with a utilization target",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				utilization := int32(50)
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.KafkaLagMetricSourceType,
+						KafkaLag: &autoscaling.KafkaLagMetricSource{
+							Brokers:       []string{"kafka:9092"},
+							Topic:         "orders",
+							ConsumerGroup: "order-processor",
+							Target: autoscaling.MetricTarget{
+								Type:               autoscaling.UtilizationMetricType,
+								AverageUtilization: &utilization,
+							},
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "counter delta with a value target",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.CounterDeltaMetricSourceType,
+						CounterDelta: &autoscaling.CounterDeltaMetricSource{
+							Metric: autoscaling.MetricIdentifier{Name: "jobs_submitted_total"},
+							Target: autoscaling.MetricTarget{
+								Type:  autoscaling.ValueMetricType,
+								Value: resource.NewQuantity(30, resource.DecimalSI),
+							},
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "ratio without a utilization target",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.RatioMetricSourceType,
+						Ratio: &autoscaling.RatioMetricSource{
+							Numerator:   autoscaling.MetricIdentifier{Name: "busy_workers"},
+							Denominator: autoscaling.MetricIdentifier{Name: "total_workers"},
+							Target: autoscaling.MetricTarget{
+								Type:         autoscaling.AverageValueMetricType,
+								AverageValue: resource.NewQuantity(30, resource.DecimalSI),
+							},
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "concurrency without an average value target",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.ConcurrencyMetricSourceType,
+						Concurrency: &autoscaling.ConcurrencyMetricSource{
+							Metric: autoscaling.MetricIdentifier{Name: "envoy_active_requests"},
+							Target: autoscaling.MetricTarget{
+								Type:  autoscaling.ValueMetricType,
+								Value: resource.NewQuantity(30, resource.DecimalSI),
+							},
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "scrape metric with an invalid port name",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.ScrapeMetricSourceType,
+						Scrape: &autoscaling.ScrapeMetricSource{
+							Port:       intstr.FromString("Metrics_Port"),
+							MetricName: "queue_depth",
+							Target: autoscaling.MetricTarget{
+								Type:         autoscaling.AverageValueMetricType,
+								AverageValue: resource.NewQuantity(10, resource.DecimalSI),
+							},
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "bucket table metric with overlapping buckets",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.BucketTableMetricSourceType,
+						BucketTable: &autoscaling.BucketTableMetricSource{
+							Metric: autoscaling.MetricIdentifier{Name: "qps"},
+							Buckets: []autoscaling.MetricBucket{
+								{From: resource.MustParse("0"), To: &[]resource.Quantity{resource.MustParse("100")}[0], Replicas: 2},
+								{From: resource.MustParse("50"), Replicas: 5},
+							},
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "bucket table metric with a gap between the buckets",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.BucketTableMetricSourceType,
+						BucketTable: &autoscaling.BucketTableMetricSource{
+							Metric: autoscaling.MetricIdentifier{Name: "qps"},
+							Buckets: []autoscaling.MetricBucket{
+								{From: resource.MustParse("0"), To: &[]resource.Quantity{resource.MustParse("100")}[0], Replicas: 2},
+								{From: resource.MustParse("200"), Replicas: 5},
+							},
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "bucket table metric with an unbounded bucket before the last",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.BucketTableMetricSourceType,
+						BucketTable: &autoscaling.BucketTableMetricSource{
+							Metric: autoscaling.MetricIdentifier{Name: "qps"},
+							Buckets: []autoscaling.MetricBucket{
+								{From: resource.MustParse("0"), Replicas: 2},
+								{From: resource.MustParse("100"), Replicas: 5},
+							},
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "scrape metric without a metric name",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.ScrapeMetricSourceType,
+						Scrape: &autoscaling.ScrapeMetricSource{
+							Port: intstr.FromInt(9090),
+							Target: autoscaling.MetricTarget{
+								Type:         autoscaling.AverageValueMetricType,
+								AverageValue: resource.NewQuantity(10, resource.DecimalSI),
+							},
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "external metric with a zero window",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.ExternalMetricSourceType,
+						External: &autoscaling.ExternalMetricSource{
+							Metric: autoscaling.MetricIdentifier{Name: "queue_length"},
+							Target: autoscaling.MetricTarget{
+								Type:  autoscaling.ValueMetricType,
+								Value: resource.NewQuantity(30, resource.DecimalSI),
+							},
+							WindowSeconds: &zero,
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "derivative metric with a season shorter than the lookahead",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.DerivativeMetricSourceType,
+						Derivative: &autoscaling.DerivativeMetricSource{
+							Metric: autoscaling.MetricIdentifier{Name: "queue_length"},
+							Target: autoscaling.MetricTarget{
+								Type:  autoscaling.ValueMetricType,
+								Value: resource.NewQuantity(30, resource.DecimalSI),
+							},
+							SeasonSeconds: &three,
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "external metric with an empty name",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.ExternalMetricSourceType,
+						External: &autoscaling.ExternalMetricSource{
+							Metric: autoscaling.MetricIdentifier{Name: " "},
+							Target: autoscaling.MetricTarget{
+								Type:  autoscaling.ValueMetricType,
+								Value: resource.NewQuantity(30, resource.DecimalSI),
+							},
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "external metric with an invalid selector",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.ExternalMetricSourceType,
+						External: &autoscaling.ExternalMetricSource{
+							Metric: autoscaling.MetricIdentifier{
+								Name: "queue_length",
+								Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+									{Key: "queue", Operator: metav1.LabelSelectorOpIn},
+								}},
+							},
+							Target: autoscaling.MetricTarget{
+								Type:  autoscaling.ValueMetricType,
+								Value: resource.NewQuantity(30, resource.DecimalSI),
+							},
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "scale to zero without object or external metric",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MinReplicas = &zero
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{}
+			},
+			reason: ReasonScaleToZeroMetricRequired,
+		},
+		{
+			name:   "scale to zero without any mode",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Spec.MinReplicas = &zero },
+			reason: ReasonScaleToZeroTriggerRequired,
+		},
+		{
+			name: "undefined variable in expression",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{Expression: "ceil(qps / 50)"}
+			},
+			reason: ReasonInvalidExpression,
+		},
+		{
+			name: "invalid metric window schedule",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Window: &autoscaling.MetricWindow{Schedule: "* 9-17 * *", OffHoursReplicas: 2},
+				}
+			},
+			reason: ReasonInvalidMetricWindow,
+		},
+		{
+			name: "zero breach duration",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{BreachDurationSeconds: &zero}
+			},
+			reason: ReasonInvalidBreachDuration,
+		},
+		{
+			name: "webhook without url and service",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.WebhookMode = &autoscaling.WebhookMode{WebhookClientConfig: &v1beta1.WebhookClientConfig{}}
+			},
+			reason: ReasonInvalidWebhook,
+		},
+		{
+			name: "webhook endpoint without url and service",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				url := "https://recommender.example.com"
+				gpa.Spec.WebhookMode = &autoscaling.WebhookMode{
+					WebhookClientConfig: &v1beta1.WebhookClientConfig{URL: &url},
+					Endpoints:           []autoscaling.WebhookMode{{}},
+				}
+			},
+			reason: ReasonInvalidWebhook,
+		},
+		{
+			name: "invalid schedule",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.TimeMode = &autoscaling.TimeMode{
+					TimeRanges: []autoscaling.TimeRange{{Schedule: "* *", DesiredReplicas: 2}},
+				}
+			},
+			reason: ReasonInvalidTimeRange,
+		},
+		{
+			name: "invalid exception date",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.TimeMode = &autoscaling.TimeMode{
+					TimeRanges: []autoscaling.TimeRange{{Schedule: "0 9 * * *", DesiredReplicas: 2}},
+					Exceptions: &autoscaling.TimeExceptions{Dates: []string{"12/25"}},
+				}
+			},
+			reason: ReasonInvalidTimeRange,
+		},
+		{
+			name:   "event without triggers",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Spec.EventMode = &autoscaling.EventMode{} },
+			reason: ReasonInvalidEvent,
+		},
+		{
+			name: "negative stabilization window",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{
+					ScaleUp: &autoscaling.GPAScalingRules{StabilizationWindowSeconds: &negative},
+				}
+			},
+			reason: ReasonInvalidBehavior,
+		},
+		{
+			name: "scale up max factor below 1",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				factor := 0.5
+				gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{
+					ScaleUp: &autoscaling.GPAScalingRules{
+						MaxFactor: &factor,
+						Policies:  []autoscaling.GPAScalingPolicy{{Type: autoscaling.PodsScalingPolicy, Value: 4, PeriodSeconds: 60}},
+					},
+				}
+			},
+			reason: ReasonInvalidBehavior,
+		},
+		{
+			name: "scale down max absolute",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				absolute := int32(4)
+				gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{
+					ScaleDown: &autoscaling.GPAScalingRules{
+						MaxAbsolute: &absolute,
+						Policies:    []autoscaling.GPAScalingPolicy{{Type: autoscaling.PodsScalingPolicy, Value: 4, PeriodSeconds: 60}},
+					},
+				}
+			},
+			reason: ReasonInvalidBehavior,
+		},
+		{
+			name: "webhook scale down max factor",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				factor := 2.0
+				gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{
+					Webhook: &autoscaling.ModeScalingRules{
+						ScaleDown: &autoscaling.GPAScalingRules{
+							MaxFactor: &factor,
+							Policies:  []autoscaling.GPAScalingPolicy{{Type: autoscaling.PodsScalingPolicy, Value: 4, PeriodSeconds: 60}},
+						},
+					},
+				}
+			},
+			reason: ReasonInvalidBehavior,
+		},
+		{
+			name: "negative scale down delay after scale up",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{ScaleDownDelayAfterScaleUpSeconds: &negative}
+			},
+			reason: ReasonInvalidBehavior,
+		},
+		{
+			name: "negative pid gain",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{
+					PID: &autoscaling.PIDController{Kp: -0.5, Ki: 0.1},
+				}
+			},
+			reason: ReasonInvalidBehavior,
+		},
+		{
+			name: "zero pid gains",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{PID: &autoscaling.PIDController{}}
+			},
+			reason: ReasonInvalidBehavior,
+		},
+		{
+			name: "zero buffer replicas",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.ReadinessGapBuffer = &autoscaling.ReadinessGapBuffer{}
+			},
+			reason: ReasonInvalidReadinessGapBuffer,
+		},
+		{
+			name: "zero pod max age",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.PodAgeBuffer = &autoscaling.PodAgeBuffer{Replicas: 1}
+			},
+			reason: ReasonInvalidPodAgeBuffer,
+		},
+		{
+			name: "zero bootstrap replicas",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.RecoverFromZero = &autoscaling.RecoverFromZero{BootstrapReplicas: &zero}
+			},
+			reason: ReasonInvalidRecoverFromZero,
+		},
+		{
+			name: "unknown target missing policy",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				policy := autoscaling.TargetMissingPolicy("Retry")
+				gpa.Spec.OnTargetMissing = &policy
+			},
+			reason: ReasonInvalidOnTargetMissing,
+		},
+		{
+			name:   "unknown conflict policy",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Spec.ConflictPolicy = "CronFirst" },
+			reason: ReasonInvalidConflictPolicy,
+		},
+		{
+			name:   "negative warmup",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Spec.WarmupSeconds = &negative },
+			reason: ReasonInvalidWarmup,
+		},
+		{
+			name:   "zero max pending pods",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Spec.MaxPendingPods = &zero },
+			reason: ReasonInvalidMaxPendingPods,
+		},
+		{
+			name:   "negative min ready seconds",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Spec.MinReadySeconds = &negative },
+			reason: ReasonInvalidMinReadySeconds,
+		},
+		{
+			name:   "negative scale history limit",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Spec.ScaleHistoryLimit = &negative },
+			reason: ReasonInvalidScaleHistoryLimit,
+		},
+		{
+			name: "negative cluster proportional step",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.ClusterProportionalMode = &autoscaling.ClusterProportionalMode{
+					Ladder: &autoscaling.ClusterProportionalLadder{
+						NodesToReplicas: []autoscaling.ClusterProportionalStep{{Size: 1, Replicas: negative}},
+					},
+				}
+			},
+			reason: ReasonInvalidClusterProportional,
+		},
+		{
+			name: "cluster proportional without coefficients",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.ClusterProportionalMode = &autoscaling.ClusterProportionalMode{}
+			},
+			reason: ReasonInvalidClusterProportional,
+		},
+		{
+			name: "unknown blend function",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{Blend: &autoscaling.MetricBlend{Function: "Median"}}
+			},
+			reason: ReasonInvalidMetricBlend,
+		},
+		{
+			name: "blend with an expression",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{Expression: "currentReplicas", Blend: &autoscaling.MetricBlend{}}
+			},
+			reason: ReasonInvalidMetricBlend,
+		},
+		{
+			name: "non-positive metric weight",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				weight := 0.0
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.ResourceMetricSourceType,
+						Resource: &autoscaling.ResourceMetricSource{
+							Name: v1.ResourceCPU,
+							Target: autoscaling.MetricTarget{
+								Type:               autoscaling.UtilizationMetricType,
+								AverageUtilization: &[]int32{80}[0],
+							},
+						},
+						Weight: &weight,
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "probe target latency below 1ms",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.ProbeMetricSourceType,
+						Probe: &autoscaling.ProbeMetricSource{
+							URL:           "https://metrics.example.com/health",
+							TargetLatency: metav1.Duration{Duration: 500 * time.Microsecond},
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "probe token file out of the projected token directory",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.ProbeMetricSourceType,
+						Probe: &autoscaling.ProbeMetricSource{
+							URL:           "https://metrics.example.com/health",
+							TargetLatency: metav1.Duration{Duration: 100 * time.Millisecond},
+							TokenAuth:     &autoscaling.ProjectedTokenAuth{TokenFile: "../serviceaccount/token"},
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "non-positive derivative smoothing",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				smoothing := int32(0)
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{
+					Metrics: []autoscaling.MetricSpec{{
+						Type: autoscaling.DerivativeMetricSourceType,
+						Derivative: &autoscaling.DerivativeMetricSource{
+							Metric:           autoscaling.MetricIdentifier{Name: "queue_length"},
+							Target:           autoscaling.MetricTarget{Type: autoscaling.AverageValueMetricType, AverageValue: resource.NewQuantity(100, resource.DecimalSI)},
+							SmoothingSeconds: &smoothing,
+						},
+					}},
+				}
+			},
+			reason: ReasonInvalidMetric,
+		},
+		{
+			name: "mirror without a name",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MirrorMode = &autoscaling.MirrorMode{}
+			},
+			reason: ReasonInvalidMirror,
+		},
+		{
+			name: "mirror itself",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.MirrorMode = &autoscaling.MirrorMode{Name: gpa.Name}
+			},
+			reason: ReasonInvalidMirror,
+		},
+		{
+			name: "peak floor without a window",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{PeakFloor: &autoscaling.PeakFloor{}}
+			},
+			reason: ReasonInvalidBehavior,
+		},
+		{
+			name: "zero replica step",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				step := int32(0)
+				gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{ReplicaStep: &step}
+			},
+			reason: ReasonInvalidBehavior,
+		},
+		{
+			name: "replica step without a multiple within the bounds",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				step := gpa.Spec.MaxReplicas + 1
+				gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{ReplicaStep: &step}
+			},
+			reason: ReasonInvalidBehavior,
+		},
+		{
+			name:   "invalid impersonated service account",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Spec.ImpersonateServiceAccount = "Invalid_Name" },
+			reason: ReasonInvalidImpersonateServiceAccount,
+		},
+		{
+			name:   "invalid name",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Name = "Invalid_Name" },
+			reason: ReasonInvalidMetadata,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			gpa := newTestGPA()
+			c.modify(gpa)
+			errs := ValidateHorizontalPodAutoscaler(gpa)
+			if len(errs) == 0 {
+				t.Fatalf("expected errors, got none")
+			}
+			for _, err := range errs {
+				if reason := ReasonForError(err); reason != c.reason {
+					t.Errorf("expected reason %v of %v, got: %v", c.reason, err, reason)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateScaleToZero(t *testing.T) {
+	zero := int32(0)
+	utilization := int32(50)
+	for _, c := range []struct {
+		name  string
+		mode  autoscaling.AutoScalingDrivenMode
+		field string
+	}{
+		{
+			name: "event mode",
+			mode: autoscaling.AutoScalingDrivenMode{
+				EventMode: &autoscaling.EventMode{Triggers: []autoscaling.ScaleTriggers{
+					{Type: "kafka", Metadata: map[string]string{"topic": "orders"}},
+				}},
+			},
+		},
+		{
+			name: "external metric",
+			mode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{Metrics: []autoscaling.MetricSpec{{
+					Type: autoscaling.ExternalMetricSourceType,
+					External: &autoscaling.ExternalMetricSource{
+						Metric: autoscaling.MetricIdentifier{Name: "queue_length"},
+						Target: autoscaling.MetricTarget{
+							Type:  autoscaling.ValueMetricType,
+							Value: resource.NewQuantity(30, resource.DecimalSI),
+						},
+					},
+				}}},
+			},
+		},
+		{
+			name: "time mode",
+			mode: autoscaling.AutoScalingDrivenMode{
+				TimeMode: &autoscaling.TimeMode{TimeRanges: []autoscaling.TimeRange{
+					{Schedule: "* 9-18 * * *", DesiredReplicas: 2},
+				}},
+			},
+		},
+		{
+			name: "resource metric only",
+			mode: autoscaling.AutoScalingDrivenMode{
+				MetricMode: &autoscaling.MetricMode{Metrics: []autoscaling.MetricSpec{{
+					Type: autoscaling.ResourceMetricSourceType,
+					Resource: &autoscaling.ResourceMetricSource{
+						Name:   "cpu",
+						Target: autoscaling.MetricTarget{Type: autoscaling.UtilizationMetricType, AverageUtilization: &utilization},
+					},
+				}}},
+			},
+			field: "spec.metrics",
+		},
+		{
+			name:  "no mode",
+			field: "spec.minReplicas",
+		},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			gpa := newTestGPA()
+			gpa.Spec.MinReplicas = &zero
+			gpa.Spec.AutoScalingDrivenMode = c.mode
+			errs := ValidateHorizontalPodAutoscaler(gpa)
+			if c.field == "" {
+				if len(errs) != 0 {
+					t.Errorf("expected no error, got: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != c.field {
+				t.Errorf("expected an error on %s, got: %v", c.field, errs)
+			}
+		})
+	}
+}
+
+func TestValidateScalingPolicyPeriod(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		period int32
+		valid  bool
+	}{
+		{name: "negative", period: -60},
+		{name: "zero", period: 0},
+		{name: "min", period: 1, valid: true},
+		{name: "max", period: MaxPeriodSeconds, valid: true},
+		{name: "above max", period: MaxPeriodSeconds + 1},
+		{name: "huge", period: 86400},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			policy := autoscaling.GPAScalingPolicy{Type: autoscaling.PodsScalingPolicy, Value: 4, PeriodSeconds: c.period}
+			for _, direction := range []string{"scaleUp", "scaleDown"} {
+				gpa := newTestGPA()
+				rules := &autoscaling.GPAScalingRules{Policies: []autoscaling.GPAScalingPolicy{policy}}
+				gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{}
+				if direction == "scaleUp" {
+					gpa.Spec.Behavior.ScaleUp = rules
+				} else {
+					gpa.Spec.Behavior.ScaleDown = rules
+				}
+				errs := ValidateHorizontalPodAutoscaler(gpa)
+				if c.valid {
+					if len(errs) > 0 {
+						t.Errorf("%s: unexpected errors: %v", direction, errs)
+					}
+					continue
+				}
+				if len(errs) != 1 {
+					t.Fatalf("%s: expected 1 error, got: %v", direction, errs)
+				}
+				path := "spec.behavior." + direction + ".policies[0].periodSeconds"
+				if errs[0].Field != path || errs[0].Detail != "must be between 1 and 1800 seconds" {
+					t.Errorf("%s: unexpected error: %v", direction, errs[0])
+				}
+				if reason := ReasonForError(errs[0]); reason != ReasonInvalidBehavior {
+					t.Errorf("%s: expected reason %v, got: %v", direction, ReasonInvalidBehavior, reason)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateDuplicateMetrics(t *testing.T) {
+	resourceMetric := func(name v1.ResourceName) autoscaling.MetricSpec {
+		return autoscaling.MetricSpec{
+			Type: autoscaling.ResourceMetricSourceType,
+			Resource: &autoscaling.ResourceMetricSource{
+				Name:   name,
+				Target: autoscaling.MetricTarget{Type: autoscaling.UtilizationMetricType, AverageUtilization: &[]int32{80}[0]},
+			},
+		}
+	}
+	external := func(name string, labels map[string]string) autoscaling.MetricSpec {
+		return autoscaling.MetricSpec{
+			Type: autoscaling.ExternalMetricSourceType,
+			External: &autoscaling.ExternalMetricSource{
+				Metric: autoscaling.MetricIdentifier{Name: name, Selector: &metav1.LabelSelector{MatchLabels: labels}},
+				Target: autoscaling.MetricTarget{Type: autoscaling.AverageValueMetricType, AverageValue: resource.NewQuantity(10, resource.DecimalSI)},
+			},
+		}
+	}
+	for _, c := range []struct {
+		name    string
+		metrics []autoscaling.MetricSpec
+		field   string
+	}{
+		{
+			name: "distinct sources",
+			metrics: []autoscaling.MetricSpec{
+				resourceMetric(v1.ResourceCPU),
+				resourceMetric(v1.ResourceMemory),
+				external("queue", map[string]string{"queue": "a"}),
+				external("queue", map[string]string{"queue": "b"}),
+			},
+		},
+		{
+			name:    "duplicate resource metrics",
+			metrics: []autoscaling.MetricSpec{resourceMetric(v1.ResourceCPU), resourceMetric(v1.ResourceMemory), resourceMetric(v1.ResourceCPU)},
+			field:   "spec.metrics[2]",
+		},
+		{
+			name: "duplicate external metrics",
+			metrics: []autoscaling.MetricSpec{
+				external("queue", map[string]string{"queue": "a", "region": "eu"}),
+				external("queue", map[string]string{"region": "eu", "queue": "a"}),
+			},
+			field: "spec.metrics[1]",
+		},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			gpa := newTestGPA()
+			gpa.Spec.MetricMode = &autoscaling.MetricMode{Metrics: c.metrics}
+			errs := ValidateHorizontalPodAutoscaler(gpa)
+			if c.field == "" {
+				if len(errs) != 0 {
+					t.Errorf("expected no error, got: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got: %v", errs)
+			}
+			if errs[0].Field != c.field {
+				t.Errorf("expected field %s, got: %s", c.field, errs[0].Field)
+			}
+			if reason := ReasonForError(errs[0]); reason != ReasonDuplicateMetric {
+				t.Errorf("expected reason %s, got: %s", ReasonDuplicateMetric, reason)
+			}
+		})
+	}
+}
+
+func TestValidateBehaviorConsistency(t *testing.T) {
+	disabled := autoscaling.DisabledPolicySelect
+	max := autoscaling.MaxPolicySelect
+	factor := 2.0
+	absolute := int32(10)
+	policies := []autoscaling.GPAScalingPolicy{{Type: autoscaling.PodsScalingPolicy, Value: 4, PeriodSeconds: 60}}
+	for _, c := range []struct {
+		name   string
+		rules  autoscaling.GPAScalingRules
+		path   string
+		detail string
+	}{
+		{
+			name:  "disabled without policies",
+			rules: autoscaling.GPAScalingRules{SelectPolicy: &disabled},
+		},
+		{
+			name:   "disabled with policies",
+			rules:  autoscaling.GPAScalingRules{SelectPolicy: &disabled, Policies: policies},
+			path:   "spec.behavior.scaleUp.policies",
+			detail: "must be empty when selectPolicy is Disabled, the policies never apply to a disabled direction",
+		},
+		{
+			name:   "max without policies",
+			rules:  autoscaling.GPAScalingRules{SelectPolicy: &max},
+			path:   "spec.behavior.scaleUp.policies",
+			detail: "must specify at least one Policy unless selectPolicy is Disabled",
+		},
+		{
+			name:   "default select without policies",
+			rules:  autoscaling.GPAScalingRules{},
+			path:   "spec.behavior.scaleUp.policies",
+			detail: "must specify at least one Policy unless selectPolicy is Disabled",
+		},
+		{
+			name:   "disabled with max factor",
+			rules:  autoscaling.GPAScalingRules{SelectPolicy: &disabled, MaxFactor: &factor},
+			path:   "spec.behavior.scaleUp.maxFactor",
+			detail: "must not be set when selectPolicy is Disabled",
+		},
+		{
+			name:   "disabled with max absolute",
+			rules:  autoscaling.GPAScalingRules{SelectPolicy: &disabled, MaxAbsolute: &absolute},
+			path:   "spec.behavior.scaleUp.maxAbsolute",
+			detail: "must not be set when selectPolicy is Disabled",
+		},
+	} {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			gpa := newTestGPA()
+			gpa.Spec.Behavior = &autoscaling.GeneralPodAutoscalerBehavior{ScaleUp: &c.rules}
+			errs := ValidateHorizontalPodAutoscaler(gpa)
+			if c.path == "" {
+				if len(errs) > 0 {
+					t.Errorf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got: %v", errs)
+			}
+			if errs[0].Field != c.path || errs[0].Detail != c.detail {
+				t.Errorf("unexpected error: %v", errs[0])
+			}
+			if reason := ReasonForError(errs[0]); reason != ReasonInvalidBehavior {
+				t.Errorf("expected reason %v, got: %v", ReasonInvalidBehavior, reason)
+			}
+		})
+	}
+}
+
+func TestValidateScaleTargetConflict(t *testing.T) {
+	existing := newTestGPA()
+	existing.Name = "web-cpu"
+	deleting := newTestGPA()
+	deleting.Name = "web-old"
+	deleting.DeletionTimestamp = &metav1.Time{}
+	for _, c := range []struct {
+		name     string
+		modify   func(gpa *autoscaling.GeneralPodAutoscaler)
+		conflict bool
+	}{
+		{
+			name:     "same target",
+			modify:   func(gpa *autoscaling.GeneralPodAutoscaler) {},
+			conflict: true,
+		},
+		{
+			name:     "another version of the group",
+			modify:   func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Spec.ScaleTargetRef.APIVersion = "apps/v1beta2" },
+			conflict: true,
+		},
+		{
+			name:   "another group",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Spec.ScaleTargetRef.APIVersion = "extensions/v1beta1" },
+		},
+		{
+			name:   "another kind",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Spec.ScaleTargetRef.Kind = "StatefulSet" },
+		},
+		{
+			name:   "the gpa itself",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) { gpa.Name = existing.Name },
+		},
+		{
+			name: "override annotation",
+			modify: func(gpa *autoscaling.GeneralPodAutoscaler) {
+				gpa.Annotations = map[string]string{AllowSharedTargetAnnotation: "true"}
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			gpa := newTestGPA()
+			c.modify(gpa)
+			errs := ValidateScaleTargetConflict(gpa, []*autoscaling.GeneralPodAutoscaler{existing, deleting})
+			if !c.conflict {
+				if len(errs) != 0 {
+					t.Errorf("expected no conflict, got: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 conflict, got: %v", errs)
+			}
+			if reason := ReasonForError(errs[0]); reason != ReasonTargetConflict {
+				t.Errorf("expected reason %v, got: %v", ReasonTargetConflict, reason)
+			}
+			if !strings.Contains(errs[0].Error(), "web-cpu") {
+				t.Errorf("expected the conflicting gpa in the error, got: %v", errs[0])
+			}
+		})
+	}
+}
+
+func TestValidateResourceRequests(t *testing.T) {
+	utilization := int32(50)
+	target := autoscaling.MetricTarget{Type: autoscaling.UtilizationMetricType, AverageUtilization: &utilization}
+	pod := func(name string, containers ...v1.Container) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: v1.PodSpec{Containers: containers}}
+	}
+	requested := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+	app := v1.Container{Name: "app", Resources: v1.ResourceRequirements{Requests: requested, Limits: requested}}
+	sidecar := v1.Container{Name: "sidecar"}
+	for _, c := range []struct {
+		name    string
+		metric  autoscaling.MetricSpec
+		limits  bool
+		pods    []*v1.Pod
+		missing string
+	}{
+		{
+			name:    "a container without requests",
+			metric:  autoscaling.MetricSpec{Type: autoscaling.ResourceMetricSourceType, Resource: &autoscaling.ResourceMetricSource{Name: v1.ResourceCPU, Target: target}},
+			pods:    []*v1.Pod{pod("web-0", app), pod("web-1", app, sidecar)},
+			missing: "pods web-1 do not",
+		},
+		{
+			name:   "only the container of the container resource",
+			metric: autoscaling.MetricSpec{Type: autoscaling.ContainerResourceMetricSourceType, ContainerResource: &autoscaling.ContainerResourceMetricSource{Name: v1.ResourceCPU, Container: "app", Target: target}},
+			pods:   []*v1.Pod{pod("web-0", app, sidecar)},
+		},
+		{
+			name:    "the container is missing",
+			metric:  autoscaling.MetricSpec{Type: autoscaling.ContainerResourceMetricSourceType, ContainerResource: &autoscaling.ContainerResourceMetricSource{Name: v1.ResourceCPU, Container: "app", Target: target}},
+			pods:    []*v1.Pod{pod("web-0", app), pod("web-1", sidecar)},
+			missing: "web-1",
+		},
+		{
+			name:    "the limits",
+			metric:  autoscaling.MetricSpec{Type: autoscaling.ResourceMetricSourceType, Resource: &autoscaling.ResourceMetricSource{Name: v1.ResourceCPU, Target: target}},
+			limits:  true,
+			pods:    []*v1.Pod{pod("web-0", v1.Container{Name: "app", Resources: v1.ResourceRequirements{Requests: requested}})},
+			missing: "must set the limits of cpu",
+		},
+		{
+			name:    "many pods",
+			metric:  autoscaling.MetricSpec{Type: autoscaling.ResourceMetricSourceType, Resource: &autoscaling.ResourceMetricSource{Name: v1.ResourceCPU, Target: target}},
+			pods:    []*v1.Pod{pod("web-0", sidecar), pod("web-1", sidecar), pod("web-2", sidecar), pod("web-3", sidecar), pod("web-4", sidecar)},
+			missing: "pods web-0, web-1, web-2 and 2 more do not",
+		},
+		{
+			name: "value targets",
+			metric: autoscaling.MetricSpec{Type: autoscaling.ResourceMetricSourceType, Resource: &autoscaling.ResourceMetricSource{Name: v1.ResourceCPU,
+				Target: autoscaling.MetricTarget{Type: autoscaling.AverageValueMetricType, AverageValue: resource.NewQuantity(1, resource.DecimalSI)}}},
+			pods: []*v1.Pod{pod("web-0", sidecar)},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			gpa := newTestGPA()
+			gpa.Spec.MetricMode = &autoscaling.MetricMode{Metrics: []autoscaling.MetricSpec{c.metric}}
+			if c.limits {
+				gpa.Annotations = map[string]string{ComputeByLimitsAnnotation: "true"}
+			}
+			errs := ValidateResourceRequests(gpa, c.pods)
+			if c.missing == "" {
+				if len(errs) != 0 {
+					t.Errorf("expected no errors, got: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got: %v", errs)
+			}
+			if reason := ReasonForError(errs[0]); reason != ReasonMissingResourceRequests {
+				t.Errorf("expected reason %v, got: %v", ReasonMissingResourceRequests, reason)
+			}
+			if !strings.Contains(errs[0].Error(), c.missing) {
+				t.Errorf("expected %q in the error, got: %v", c.missing, errs[0])
+			}
+		})
+	}
+}
+
+func TestValidateSecretReferences(t *testing.T) {
+	secrets := map[string]*v1.Secret{
+		"webhook": {ObjectMeta: metav1.ObjectMeta{Name: "webhook"}, Data: map[string][]byte{"hmac": []byte("key")}},
+	}
+	getSecret := func(name string) (*v1.Secret, error) {
+		if secret, ok := secrets[name]; ok {
+			return secret, nil
+		}
+		if name == "forbidden" {
+			return nil, apierrors.NewForbidden(v1.Resource("secrets"), name, nil)
+		}
+		return nil, apierrors.NewNotFound(v1.Resource("secrets"), name)
+	}
+	ref := func(name, key string) *v1.SecretKeySelector {
+		return &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: name}, Key: key}
+	}
+	for _, c := range []struct {
+		name    string
+		webhook *autoscaling.WebhookMode
+		kafka   *autoscaling.KafkaLagMetricSource
+		field   string
+		errMsg  string
+		failed  bool
+	}{
+		{
+			name:    "existing key",
+			webhook: &autoscaling.WebhookMode{HMACSecretRef: ref("webhook", "hmac")},
+		},
+		{
+			name:    "missing secret",
+			webhook: &autoscaling.WebhookMode{HMACSecretRef: ref("webhook", "hmac"), CABundleSecretRef: ref("ca", "ca.crt")},
+			field:   "spec.webhook.caBundleSecretRef.name",
+			errMsg:  "secret ca does not exist in namespace default",
+		},
+		{
+			name: "missing key of an endpoint",
+			webhook: &autoscaling.WebhookMode{Endpoints: []autoscaling.WebhookMode{
+				{HMACSecretRef: ref("webhook", "hmac")}, {HMACSecretRef: ref("webhook", "token")},
+			}},
+			field:  "spec.webhook.endpoints[1].hmacSecretRef.key",
+			errMsg: "secret webhook has no key token",
+		},
+		{
+			name: "missing key of kafka",
+			kafka: &autoscaling.KafkaLagMetricSource{SASL: &autoscaling.KafkaSASL{
+				UsernameSecretRef: *ref("webhook", "hmac"), PasswordSecretRef: *ref("webhook", "password"),
+			}},
+			field:  "spec.metrics[0].kafkaLag.sasl.passwordSecretRef.key",
+			errMsg: "secret webhook has no key password",
+		},
+		{
+			name:    "failed to get",
+			webhook: &autoscaling.WebhookMode{HMACSecretRef: ref("forbidden", "hmac")},
+			failed:  true,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			gpa := newTestGPA()
+			gpa.Spec.WebhookMode = c.webhook
+			if c.kafka != nil {
+				gpa.Spec.MetricMode = &autoscaling.MetricMode{Metrics: []autoscaling.MetricSpec{
+					{Type: autoscaling.KafkaLagMetricSourceType, KafkaLag: c.kafka},
+				}}
+			}
+			errs, err := ValidateSecretReferences(gpa, getSecret)
+			if (err != nil) != c.failed {
+				t.Fatalf("expected failed %v, got: %v", c.failed, err)
+			}
+			if c.errMsg == "" {
+				if len(errs) != 0 {
+					t.Errorf("expected no errors, got: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got: %v", errs)
+			}
+			if errs[0].Field != c.field {
+				t.Errorf("expected field %v, got: %v", c.field, errs[0].Field)
+			}
+			if !strings.Contains(errs[0].Error(), c.errMsg) {
+				t.Errorf("expected %q in the error, got: %v", c.errMsg, errs[0])
+			}
+			if reason := ReasonForError(errs[0]); reason != ReasonMissingSecret {
+				t.Errorf("expected reason %v, got: %v", ReasonMissingSecret, reason)
+			}
+		})
+	}
+}
+
+func TestDocsURL(t *testing.T) {
+	url := DocsURL("https://example.com/reasons.md", ReasonMinGreaterThanMax)
+	if url != "https://example.com/reasons.md#gpa001-mingreaterthanmax" {
+		t.Errorf("unexpected docs url: %v", url)
+	}
+	if url := DocsURL("", ReasonMinGreaterThanMax); url != "" {
+		t.Errorf("expected no docs url without base url, got: %v", url)
+	}
+}
+
+func TestValidateExpression(t *testing.T) {
+	for _, c := range []struct {
+		name       string
+		names      []string
+		expression string
+		field      string
+		errMsg     string
+	}{
+		{
+			name:       "valid",
+			names:      []string{"qps", "cpu"},
+			expression: "max(ceil(qps / 50) + 1, currentReplicas * cpu / 80)",
+		},
+		{
+			name:       "undefined variable",
+			names:      []string{"qps"},
+			expression: "ceil(rps / 50)",
+			field:      "spec.metric.expression",
+			errMsg:     "undefined variable rps",
+		},
+		{
+			name:       "unnamed metric",
+			names:      []string{""},
+			expression: "ceil(qps / 50)",
+			field:      "spec.metric.expression",
+			errMsg:     "undefined variable qps",
+		},
+		{
+			name:       "string",
+			names:      []string{"qps"},
+			expression: "qps > 10 ? 'a' : 2",
+			field:      "spec.metric.expression",
+			errMsg:     "is not allowed",
+		},
+		{
+			name:       "regular expression",
+			names:      []string{"qps"},
+			expression: "qps =~ '.*'",
+			field:      "spec.metric.expression",
+			errMsg:     "is not allowed",
+		},
+		{
+			name:       "unknown function",
+			names:      []string{"qps"},
+			expression: "exec(qps)",
+			field:      "spec.metric.expression",
+		},
+		{
+			name:   "invalid metric name",
+			names:  []string{"qps-total"},
+			field:  "spec.metrics[0].name",
+			errMsg: "alphanumeric",
+		},
+		{
+			name:   "duplicate metric name",
+			names:  []string{"qps", "qps"},
+			field:  "spec.metrics[1].name",
+			errMsg: "Duplicate value",
+		},
+		{
+			name:   "reserved metric name",
+			names:  []string{"currentReplicas"},
+			field:  "spec.metrics[0].name",
+			errMsg: "Duplicate value",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			gpa := newTestGPA()
+			mode := &autoscaling.MetricMode{Expression: c.expression}
+			// the metrics read different resources, so that they are not duplicates of each other
+			resources := []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory}
+			for i, name := range c.names {
+				mode.Metrics = append(mode.Metrics, autoscaling.MetricSpec{
+					Name: name,
+					Type: autoscaling.ResourceMetricSourceType,
+					Resource: &autoscaling.ResourceMetricSource{
+						Name:   resources[i],
+						Target: autoscaling.MetricTarget{Type: autoscaling.UtilizationMetricType, AverageUtilization: &[]int32{80}[0]},
+					},
+				})
+			}
+			gpa.Spec.MetricMode = mode
+			errs := ValidateHorizontalPodAutoscaler(gpa)
+			if c.field == "" {
+				if len(errs) != 0 {
+					t.Errorf("expected no error, got: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got: %v", errs)
+			}
+			if errs[0].Field != c.field {
+				t.Errorf("expected error on %v, got: %v", c.field, errs[0].Field)
+			}
+			if !strings.Contains(errs[0].Error(), c.errMsg) {
+				t.Errorf("expected error containing %q, got: %v", c.errMsg, errs[0].Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+)
+
+const (
+	// auditQueueSize is the number of records buffered for the audit webhook, the records are dropped once it is full
+	auditQueueSize = 1024
+	// auditTimeout is the timeout of posting a record to the audit webhook
+	auditTimeout = 10 * time.Second
+
+	// AuditDecisionAllowed is the decision of an allowed admission request
+	AuditDecisionAllowed = "Allowed"
+	// AuditDecisionDenied is the decision of a denied admission request
+	AuditDecisionDenied = "Denied"
+)
+
+// AuditRecord is posted to the audit webhook for each admission decision
+type AuditRecord struct {
+	Timestamp metav1.Time `json:"timestamp"`
+	UID       types.UID   `json:"uid"`
+	Operation string      `json:"operation"`
+	Group     string      `json:"group"`
+	Kind      string      `json:"kind"`
+	Namespace string      `json:"namespace"`
+	Name      string      `json:"name"`
+	User      string      `json:"user"`
+	// Decision is either Allowed or Denied
+	Decision string `json:"decision"`
+	// Reason is the reason code of the first validation error of a denial
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// auditSink posts the records to the audit webhook in the background, so that the admission responses are never
+// blocked or failed by the audit webhook.
+type auditSink struct {
+	url     string
+	client  http.Client
+	records chan AuditRecord
+}
+
+// SetAuditWebhook posts a record of each admission decision to the url, until stopCh is closed
+func (whsvr *webhookServer) SetAuditWebhook(url string, stopCh <-chan struct{}) {
+	sink := &auditSink{
+		url:     url,
+		client:  http.Client{Timeout: auditTimeout},
+		records: make(chan AuditRecord, auditQueueSize),
+	}
+	go sink.run(stopCh)
+	whsvr.audit = sink
+}
+
+// recordAudit queues the record of the decision of the request, it is dropped if the queue is full
+func (whsvr *webhookServer) recordAudit(req *v1beta1.AdmissionRequest, resp *v1beta1.AdmissionResponse) {
+	if whsvr.audit == nil || req == nil || resp == nil {
+		return
+	}
+	record := AuditRecord{
+		Timestamp: metav1.Now(),
+		UID:       req.UID,
+		Operation: string(req.Operation),
+		Group:     req.Kind.Group,
+		Kind:      req.Kind.Kind,
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		User:      req.UserInfo.Username,
+		Decision:  AuditDecisionAllowed,
+	}
+	if !resp.Allowed {
+		record.Decision = AuditDecisionDenied
+		if resp.Result != nil {
+			record.Reason = string(resp.Result.Reason)
+			record.Message = resp.Result.Message
+		}
+	}
+	select {
+	case whsvr.audit.records <- record:
+	default:
+		klog.Warningf("Audit queue is full, drop the record of %s %s/%s", record.Kind, record.Namespace, record.Name)
+	}
+}
+
+func (s *auditSink) run(stopCh <-chan struct{}) {
+	for {
+		select {
+		case record := <-s.records:
+			if err := s.post(record); err != nil {
+				klog.Errorf("Post audit record of %s %s/%s failed: %v", record.Kind, record.Namespace, record.Name, err)
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (s *auditSink) post(record AuditRecord) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	res, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("bad status code %d from %s", res.StatusCode, s.url)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/validation"
+)
+
+func TestAuditRecordPostedAfterDecision(t *testing.T) {
+	records := make(chan AuditRecord, 1)
+	// the first post fails, which must not fail the admissions
+	failed := false
+	audit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !failed {
+			failed = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var record AuditRecord
+		if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+			t.Errorf("decode audit record failed: %v", err)
+		}
+		records <- record
+	}))
+	defer audit.Close()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	whsvr := NewWebhookServer("", nil)
+	whsvr.SetAuditWebhook(audit.URL, stopCh)
+
+	minReplicas := int32(3)
+	gpa := v1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpa", Namespace: "default"},
+		Spec: v1alpha1.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    2,
+		},
+	}
+	raw, err := json.Marshal(gpa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	review := func() *v1beta1.AdmissionReview {
+		body, err := json.Marshal(v1beta1.AdmissionReview{
+			Request: &v1beta1.AdmissionRequest{
+				UID:       "uid",
+				Kind:      metav1.GroupVersionKind{Group: "autoscaling.ocgi.dev", Version: "v1alpha1", Kind: "GeneralPodAutoscaler"},
+				Name:      gpa.Name,
+				Namespace: gpa.Namespace,
+				Operation: v1beta1.Create,
+				UserInfo:  authenticationv1.UserInfo{Username: "alice"},
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/mutate", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		whsvr.Serve(recorder, req)
+		result := &v1beta1.AdmissionReview{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	for i := 0; i < 2; i++ {
+		if result := review(); result.Response == nil || result.Response.Allowed {
+			t.Fatalf("expected the gpa to be denied, got: %+v", result.Response)
+		}
+	}
+	select {
+	case record := <-records:
+		if record.Decision != AuditDecisionDenied {
+			t.Errorf("expected decision %v, got: %v", AuditDecisionDenied, record.Decision)
+		}
+		if record.Reason != string(validation.ReasonMinGreaterThanMax) {
+			t.Errorf("expected reason %v, got: %v", validation.ReasonMinGreaterThanMax, record.Reason)
+		}
+		if record.User != "alice" || record.Kind != "GeneralPodAutoscaler" || record.Namespace != "default" ||
+			record.Name != "gpa" || record.Operation != "CREATE" {
+			t.Errorf("unexpected audit record: %+v", record)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("audit record was not posted")
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/validation"
+)
+
+// MaxReplicasCeilingPolicy is what the webhook does with the GPAs whose maxReplicas exceeds the ceiling
+type MaxReplicasCeilingPolicy string
+
+const (
+	// RejectAboveCeiling denies the GPAs
+	RejectAboveCeiling MaxReplicasCeilingPolicy = "Reject"
+	// ClampToCeiling admits the GPAs, but patches their maxReplicas down to the ceiling
+	ClampToCeiling MaxReplicasCeilingPolicy = "Clamp"
+)
+
+// SetMaxReplicasCeiling enforces the ceiling no maxReplicas of the GPAs may exceed by the policy, the ceiling is
+// disabled if it is not greater than 0
+func (whsvr *webhookServer) SetMaxReplicasCeiling(ceiling int32, policy MaxReplicasCeilingPolicy) {
+	whsvr.maxReplicasCeiling = ceiling
+	whsvr.maxReplicasCeilingPolicy = policy
+}
+
+// enforceMaxReplicasCeiling returns the errors of the maxReplicas of the GPA exceeding the ceiling if the policy is
+// Reject. If the policy is Clamp, the maxReplicas of the GPA is lowered to the ceiling and the patch doing the
+// same is returned instead, so that the GPA is validated as it is stored.
+func (whsvr *webhookServer) enforceMaxReplicasCeiling(gpa *v1alpha1.GeneralPodAutoscaler,
+	namespace string) ([]jsonPatchOperation, field.ErrorList) {
+	errs := validation.ValidateMaxReplicasCeiling(gpa, whsvr.maxReplicasCeiling)
+	if len(errs) == 0 || whsvr.maxReplicasCeilingPolicy != ClampToCeiling {
+		return nil, errs
+	}
+	klog.Warningf("GPA %s/%s: clamp maxReplicas %d to the ceiling %d", namespace, gpa.Name,
+		gpa.Spec.MaxReplicas, whsvr.maxReplicasCeiling)
+	gpa.Spec.MaxReplicas = whsvr.maxReplicasCeiling
+	return []jsonPatchOperation{{Op: "replace", Path: "/spec/maxReplicas", Value: whsvr.maxReplicasCeiling}}, nil
+}
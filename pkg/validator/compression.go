@@ -0,0 +1,102 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxRequestBytes is the default limit of the decoded body of an admission review
+const DefaultMaxRequestBytes = 10 << 20
+
+// SetCompression sets the limit of the body of the admission reviews after they are decompressed, 0 for
+// DefaultMaxRequestBytes, and whether the responses are gzipped for the clients accepting it
+func (whsvr *webhookServer) SetCompression(maxRequestBytes int64, gzipResponses bool) {
+	whsvr.maxRequestBytes = maxRequestBytes
+	whsvr.gzipResponses = gzipResponses
+}
+
+// readBody reads the body of the request, decompressing it by its Content-Encoding. The body is read up to
+// the limit only, so that a small gzipped body can not be inflated to exhaust the memory. The status code of
+// the error is returned along with it.
+func (whsvr *webhookServer) readBody(r *http.Request) ([]byte, int, error) {
+	if r.Body == nil {
+		return nil, 0, nil
+	}
+	limit := whsvr.maxRequestBytes
+	if limit <= 0 {
+		limit = DefaultMaxRequestBytes
+	}
+	var reader io.Reader = r.Body
+	switch encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+	case "gzip":
+		decompressor, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid gzip body: %v", err)
+		}
+		defer decompressor.Close()
+		reader = decompressor
+	default:
+		return nil, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported Content-Encoding %q, expect gzip", encoding)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("read body failed: %v", err)
+	}
+	if int64(len(body)) > limit {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("body exceeds %d bytes", limit)
+	}
+	return body, 0, nil
+}
+
+// acceptsGzip returns if the client accepts the gzipped responses by the Accept-Encoding header
+func acceptsGzip(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(value, ",") {
+			parts := strings.Split(coding, ";")
+			if strings.ToLower(strings.TrimSpace(parts[0])) != "gzip" {
+				continue
+			}
+			// gzip;q=0 refuses it
+			if len(parts) > 1 && strings.Replace(strings.TrimSpace(parts[1]), " ", "", -1) == "q=0" {
+				return false
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// writeResponse writes the encoded response, gzipped if it is enabled and accepted by the client
+func (whsvr *webhookServer) writeResponse(w http.ResponseWriter, r *http.Request, resp []byte) error {
+	w.Header().Set("Content-Type", "application/json")
+	if !whsvr.gzipResponses || !acceptsGzip(r) {
+		_, err := w.Write(resp)
+		return err
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Add("Vary", "Accept-Encoding")
+	compressor := gzip.NewWriter(w)
+	if _, err := compressor.Write(resp); err != nil {
+		return err
+	}
+	return compressor.Close()
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+)
+
+func gzipped(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	compressor := gzip.NewWriter(&buf)
+	if _, err := compressor.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := compressor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func reviewBody(t *testing.T) []byte {
+	minReplicas := int32(3)
+	raw, err := json.Marshal(v1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: v1alpha1.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    2,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(v1beta1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1beta1", Kind: "AdmissionReview"},
+		Request: &v1beta1.AdmissionRequest{
+			UID:       "gzipped",
+			Kind:      metav1.GroupVersionKind{Group: "autoscaling.ocgi.dev", Version: "v1alpha1", Kind: "GeneralPodAutoscaler"},
+			Name:      "web",
+			Namespace: "default",
+			Operation: v1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func serve(whsvr *webhookServer, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/mutate", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		r.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	whsvr.Serve(w, r)
+	return w
+}
+
+func decodeReview(t *testing.T, data []byte) v1beta1.AdmissionReview {
+	var review v1beta1.AdmissionReview
+	if err := json.Unmarshal(data, &review); err != nil {
+		t.Fatalf("decode response %q failed: %v", data, err)
+	}
+	return review
+}
+
+func TestGzipRequest(t *testing.T) {
+	body := reviewBody(t)
+	whsvr := NewWebhookServer("", nil)
+	w := serve(whsvr, gzipped(t, body), map[string]string{"Content-Encoding": "gzip"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected the gzipped review to be served, got %d: %s", w.Code, w.Body.String())
+	}
+	review := decodeReview(t, w.Body.Bytes())
+	if review.Response == nil || review.Response.UID != "gzipped" || review.Response.Allowed {
+		t.Errorf("expected the gpa of the gzipped review to be denied, got: %+v", review.Response)
+	}
+
+	// the responses are not gzipped unless enabled
+	w = serve(whsvr, body, map[string]string{"Accept-Encoding": "gzip"})
+	if w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("expected the response not to be gzipped")
+	}
+
+	whsvr.SetCompression(0, true)
+	w = serve(whsvr, body, map[string]string{"Accept-Encoding": "deflate, gzip;q=0.5"})
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected the response to be gzipped, got headers %v", w.Header())
+	}
+	decompressor, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(decompressor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if review := decodeReview(t, data); review.Response == nil || review.Response.UID != "gzipped" {
+		t.Errorf("unexpected gzipped response: %+v", review.Response)
+	}
+	w = serve(whsvr, body, map[string]string{"Accept-Encoding": "gzip;q=0"})
+	if w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("expected the response not to be gzipped for gzip;q=0")
+	}
+}
+
+func TestGzipRequestRejected(t *testing.T) {
+	body := reviewBody(t)
+	whsvr := NewWebhookServer("", nil)
+	whsvr.SetCompression(int64(len(body)-1), false)
+	for name, c := range map[string]struct {
+		body     []byte
+		encoding string
+		code     int
+	}{
+		// a bomb inflates far beyond the size of the compressed body
+		"bomb":            {body: gzipped(t, make([]byte, 64<<20)), encoding: "gzip", code: http.StatusRequestEntityTooLarge},
+		"too large":       {body: gzipped(t, body), encoding: "gzip", code: http.StatusRequestEntityTooLarge},
+		"plain too large": {body: body, code: http.StatusRequestEntityTooLarge},
+		"not gzip":        {body: body, encoding: "gzip", code: http.StatusBadRequest},
+		"unknown":         {body: body, encoding: "br", code: http.StatusUnsupportedMediaType},
+	} {
+		w := serve(whsvr, c.body, map[string]string{"Content-Encoding": c.encoding})
+		if w.Code != c.code {
+			t.Errorf("%s: expected %d, got %d: %s", name, c.code, w.Code, w.Body.String())
+		}
+	}
+}
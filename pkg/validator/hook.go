@@ -17,19 +17,22 @@ package webhook
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"k8s.io/api/admission/v1beta1"
 	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/klog"
 
 	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	listers "github.com/ocgi/general-pod-autoscaler/pkg/client/listers/autoscaling/v1alpha1"
 	"github.com/ocgi/general-pod-autoscaler/pkg/validation"
 )
 
@@ -41,16 +44,79 @@ var (
 
 type webhookServer struct {
 	*http.Server
+	// docsBaseURL is the base url of the documentation of the denial reasons
+	docsBaseURL string
+	// gpaLister lists the existing GPAs to reject the GPAs scaling an already scaled target, the check is
+	// disabled if it is nil
+	gpaLister listers.GeneralPodAutoscalerLister
+	// audit posts the admission decisions to the audit webhook, set by SetAuditWebhook
+	audit *auditSink
+	// missingRequestsPolicy and targetPods check the requests of the pods of the targets of the utilization
+	// targets, set by SetMissingRequestsPolicy
+	missingRequestsPolicy MissingRequestsPolicy
+	targetPods            TargetPodsLister
+	// internalErrorPolicy is what the webhook decides when the request can not be handled, set by
+	// SetInternalErrorPolicy
+	internalErrorPolicy InternalErrorPolicy
+	// maxRequestBytes limits the decompressed body of the requests and gzipResponses gzips the responses to
+	// the clients accepting it, set by SetCompression
+	maxRequestBytes int64
+	gzipResponses   bool
+	// maxReplicasCeiling is the ceiling of the maxReplicas of the GPAs enforced by maxReplicasCeilingPolicy,
+	// set by SetMaxReplicasCeiling
+	maxReplicasCeiling       int32
+	maxReplicasCeilingPolicy MaxReplicasCeilingPolicy
+	// secrets gets the secrets referenced by the GPAs to check they exist, set by SetSecretValidation
+	secrets SecretGetter
+	// maxReplicasDropPolicy and maxReplicasDropRatio check the maxReplicas of the GPAs against the current
+	// replicas of their targets, set by SetMaxReplicasDropPolicy
+	maxReplicasDropPolicy MaxReplicasDropPolicy
+	maxReplicasDropRatio  float64
 }
 
+// InternalErrorPolicy is what the webhook decides when it fails to handle a request, e.g. the object can not
+// be decoded, regardless of the validation of the GPA
+type InternalErrorPolicy string
+
+const (
+	// AllowOnInternalError admits the requests the webhook fails to handle, i.e. fails open
+	AllowOnInternalError InternalErrorPolicy = "allow"
+	// DenyOnInternalError denies the requests the webhook fails to handle, i.e. fails closed
+	DenyOnInternalError InternalErrorPolicy = "deny"
+)
+
 func init() {
 	_ = corev1.AddToScheme(runtimeScheme)
 	_ = admissionregistrationv1beta1.AddToScheme(runtimeScheme)
 	runtimeScheme.AddKnownTypes(v1alpha1.SchemeGroupVersion)
 }
 
-func NewWebhookServer() *webhookServer {
-	return &webhookServer{}
+// NewWebhookServer returns a webhook server, the denials link to the reasons under docsBaseURL.
+// If gpaLister is not nil, the GPAs scaling a target which is already scaled by another GPA are denied.
+func NewWebhookServer(docsBaseURL string, gpaLister listers.GeneralPodAutoscalerLister) *webhookServer {
+	return &webhookServer{docsBaseURL: docsBaseURL, gpaLister: gpaLister}
+}
+
+// SetInternalErrorPolicy sets the decision on the requests the webhook fails to handle, they are denied by default
+func (whsvr *webhookServer) SetInternalErrorPolicy(policy InternalErrorPolicy) {
+	whsvr.internalErrorPolicy = policy
+}
+
+// internalErrorResponse returns the response to a request the webhook failed to handle by err
+func (whsvr *webhookServer) internalErrorResponse(result *metav1.Status, err error) *v1beta1.AdmissionResponse {
+	result.Code = 400
+	result.Message = err.Error()
+	if whsvr.internalErrorPolicy == AllowOnInternalError {
+		klog.Warningf("Allow the request on internal error: %v", err)
+		return &v1beta1.AdmissionResponse{
+			Allowed: true,
+			Result:  result,
+		}
+	}
+	return &v1beta1.AdmissionResponse{
+		Allowed: false,
+		Result:  result,
+	}
 }
 
 // validate deployments and services
@@ -61,10 +127,10 @@ func (whsvr *webhookServer) mutate(ar *v1beta1.AdmissionReview) *v1beta1.Admissi
 		req.Kind, req.Namespace, req.Name, req.UID, req.Operation, req.UserInfo)
 	var err error
 	var patch []byte
-	var causes []metav1.StatusCause
+	var errs field.ErrorList
 	switch req.Kind.Kind {
 	case "GeneralPodAutoscaler":
-		patch, causes, err = forGPA(req)
+		patch, errs, err = whsvr.forGPA(req)
 
 	default:
 		return &v1beta1.AdmissionResponse{
@@ -83,9 +149,11 @@ func (whsvr *webhookServer) mutate(ar *v1beta1.AdmissionReview) *v1beta1.Admissi
 	}
 	if err != nil {
 		klog.Error(err)
-		result.Code = 400
-		result.Message = err.Error()
-		result.Details.Causes = causes
+		return whsvr.internalErrorResponse(&result, err)
+	}
+	if len(errs) > 0 {
+		whsvr.setDenial(&result, errs)
+		klog.Error(result.Message)
 		return &v1beta1.AdmissionResponse{
 			Allowed: false,
 			Result:  &result,
@@ -102,14 +170,14 @@ func (whsvr *webhookServer) mutate(ar *v1beta1.AdmissionReview) *v1beta1.Admissi
 
 // Serve method for webhook server
 func (whsvr *webhookServer) Serve(w http.ResponseWriter, r *http.Request) {
-	var body []byte
-	if r.Body != nil {
-		if data, err := ioutil.ReadAll(r.Body); err == nil {
-			body = data
-		}
-	}
+	body, code, err := whsvr.readBody(r)
 	klog.Info(r.URL.RawPath)
 	klog.V(6).Infof("Receive request: %+v", *r)
+	if err != nil {
+		klog.Errorf("Can't read body: %v", err)
+		http.Error(w, err.Error(), code)
+		return
+	}
 	if len(body) == 0 {
 		klog.Error("empty body")
 		http.Error(w, "empty body", http.StatusBadRequest)
@@ -128,15 +196,12 @@ func (whsvr *webhookServer) Serve(w http.ResponseWriter, r *http.Request) {
 	ar := v1beta1.AdmissionReview{}
 	if _, _, err := deserializer.Decode(body, nil, &ar); err != nil {
 		klog.Errorf("Can't decode body: %v", err)
-		admissionResponse = &v1beta1.AdmissionResponse{
-			Result: &metav1.Status{
-				Message: err.Error(),
-			},
-		}
+		admissionResponse = whsvr.internalErrorResponse(&metav1.Status{}, err)
 	} else {
 		fmt.Println(r.URL.Path)
 		if r.URL.Path == "/mutate" {
 			admissionResponse = whsvr.mutate(&ar)
+			whsvr.recordAudit(ar.Request, admissionResponse)
 		}
 	}
 
@@ -153,50 +218,119 @@ func (whsvr *webhookServer) Serve(w http.ResponseWriter, r *http.Request) {
 		klog.Errorf("Can't encode response: %v", err)
 		http.Error(w, fmt.Sprintf("could not encode response: %v", err), http.StatusInternalServerError)
 	}
-	if _, err := w.Write(resp); err != nil {
+	if err := whsvr.writeResponse(w, r, resp); err != nil {
 		klog.Errorf("Can't write response: %v", err)
 		http.Error(w, fmt.Sprintf("could not write response: %v", err), http.StatusInternalServerError)
 	}
 }
 
-func forGPA(req *v1beta1.AdmissionRequest) ([]byte, []metav1.StatusCause, error) {
-	var errs field.ErrorList
-	causes := make([]metav1.StatusCause, 0)
-	defer func() {
-		if len(errs) == 0 {
-			return
+// setDenial sets the reason code and the documentation link of each validation error to the result.
+// The reason of the result is the reason of the first error.
+func (whsvr *webhookServer) setDenial(result *metav1.Status, errs field.ErrorList) {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		reason := validation.ReasonForError(err)
+		message := fmt.Sprintf("%s: %s", reason, err.ErrorBody())
+		if url := validation.DocsURL(whsvr.docsBaseURL, reason); url != "" {
+			message = fmt.Sprintf("%s, see %s", message, url)
 		}
-		for i := range errs {
-			err := errs[i]
-			causes = append(causes, metav1.StatusCause{
-				Type:    metav1.CauseType(err.Type),
-				Message: err.ErrorBody(),
-				Field:   err.Field,
-			})
-		}
-	}()
+		result.Details.Causes = append(result.Details.Causes, metav1.StatusCause{
+			Type:    metav1.CauseType(reason),
+			Message: message,
+			Field:   err.Field,
+		})
+		messages = append(messages, fmt.Sprintf("%s: %s", err.Field, message))
+	}
+	result.Code = 400
+	result.Reason = metav1.StatusReason(validation.ReasonForError(errs[0]))
+	result.Message = strings.Join(messages, "; ")
+}
+
+// forGPA returns the validation errors of the GPA, err is returned if the request can not be decoded.
+func (whsvr *webhookServer) forGPA(req *v1beta1.AdmissionRequest) ([]byte, field.ErrorList, error) {
 	var gpa, oldGPA v1alpha1.GeneralPodAutoscaler
 	if err := json.Unmarshal(req.Object.Raw, &gpa); err != nil {
 		klog.Errorf("Could not unmarshal raw object: %v", err)
 		return nil, nil, err
 	}
 	if req.Operation == v1beta1.Create {
-		// validate
-		errs = validation.ValidateHorizontalPodAutoscaler(&gpa)
+		patch, errs := whsvr.enforceMaxReplicasCeiling(&gpa, req.Namespace)
 		if len(errs) > 0 {
-			return nil, causes, errs.ToAggregate()
+			return nil, errs, nil
 		}
+		// validate
+		errs = ValidateGPA(nil, &gpa)
+		if len(errs) == 0 {
+			errs = whsvr.validateSecretReferences(&gpa, req.Namespace)
+		}
+		conflicts, err := whsvr.validateTargetConflict(&gpa, req.Namespace)
+		errs = append(errs, conflicts...)
+		if len(errs) > 0 || err != nil {
+			return nil, errs, err
+		}
+		return whsvr.validateTarget(&gpa, req.Namespace, patch)
 	}
 	if req.Operation == v1beta1.Update {
 		if err := json.Unmarshal(req.OldObject.Raw, &oldGPA); err != nil {
 			klog.Errorf("Could not unmarshal old raw object: %v", err)
 			return nil, nil, err
 		}
+		// the ceiling is only enforced once the spec changes, so that the updates of the metadata of the GPAs
+		// predating it are never denied
+		var patch []jsonPatchOperation
+		if !apiequality.Semantic.DeepEqual(gpa.Spec, oldGPA.Spec) {
+			var errs field.ErrorList
+			if patch, errs = whsvr.enforceMaxReplicasCeiling(&gpa, req.Namespace); len(errs) > 0 {
+				return nil, errs, nil
+			}
+		}
 		// validate
-		errs = validation.ValidateHorizontalPodAutoscalerUpdate(&gpa, &oldGPA)
-		if len(errs) > 0 {
-			return nil, causes, errs.ToAggregate()
+		errs := ValidateGPA(&oldGPA, &gpa)
+		// the secrets are only checked once the spec changes, so that the GPAs whose secrets are deleted can still
+		// be updated, e.g. to be labeled
+		if len(errs) == 0 && !apiequality.Semantic.DeepEqual(gpa.Spec, oldGPA.Spec) {
+			errs = whsvr.validateSecretReferences(&gpa, req.Namespace)
 		}
+		if gpa.Spec.ScaleTargetRef != oldGPA.Spec.ScaleTargetRef {
+			conflicts, err := whsvr.validateTargetConflict(&gpa, req.Namespace)
+			errs = append(errs, conflicts...)
+			if err != nil {
+				return nil, errs, err
+			}
+		}
+		// the target is only checked once the spec changes, so that the updates of the metadata are never denied
+		if len(errs) > 0 || apiequality.Semantic.DeepEqual(gpa.Spec, oldGPA.Spec) {
+			return nil, errs, nil
+		}
+		return whsvr.validateTarget(&gpa, req.Namespace, patch)
 	}
 	return nil, nil, nil
 }
+
+// validateTarget checks the GPA against the pods of its target, i.e. their requests and their number, and returns
+// the patch of the GPA appended to the given patch
+func (whsvr *webhookServer) validateTarget(gpa *v1alpha1.GeneralPodAutoscaler, namespace string,
+	patch []jsonPatchOperation) ([]byte, field.ErrorList, error) {
+	requestsPatch, errs := whsvr.validateResourceRequests(gpa, namespace)
+	dropPatch, dropErrs := whsvr.validateMaxReplicasDrop(gpa, namespace)
+	errs = append(errs, dropErrs...)
+	if len(errs) > 0 {
+		return nil, errs, nil
+	}
+	patch = append(append(patch, requestsPatch...), dropPatch...)
+	return marshalPatch(gpa, namespace, patch), nil, nil
+}
+
+// validateTargetConflict returns the errors if the target of the GPA is already scaled by other GPAs
+// in the namespace of the request
+func (whsvr *webhookServer) validateTargetConflict(gpa *v1alpha1.GeneralPodAutoscaler,
+	namespace string) (field.ErrorList, error) {
+	if whsvr.gpaLister == nil {
+		return nil, nil
+	}
+	existing, err := whsvr.gpaLister.GeneralPodAutoscalers(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("list gpas of namespace %v failed: %v", namespace, err)
+	}
+	return validation.ValidateScaleTargetConflict(gpa, existing), nil
+}
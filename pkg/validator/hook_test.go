@@ -0,0 +1,556 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"k8s.io/api/admission/v1beta1"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	listers "github.com/ocgi/general-pod-autoscaler/pkg/client/listers/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/validation"
+)
+
+func TestDenialCarriesReason(t *testing.T) {
+	minReplicas := int32(3)
+	gpa := v1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpa", Namespace: "default"},
+		Spec: v1alpha1.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    2,
+		},
+	}
+	raw, err := json.Marshal(gpa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	whsvr := NewWebhookServer("https://example.com/reasons.md", nil)
+	resp := whsvr.mutate(&v1beta1.AdmissionReview{
+		Request: &v1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Group: "autoscaling.ocgi.dev", Version: "v1alpha1", Kind: "GeneralPodAutoscaler"},
+			Name:      gpa.Name,
+			Namespace: gpa.Namespace,
+			Operation: v1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	if resp.Allowed {
+		t.Fatalf("expected the gpa to be denied")
+	}
+	if resp.Result.Reason != metav1.StatusReason(validation.ReasonMinGreaterThanMax) {
+		t.Errorf("expected reason %v, got: %v", validation.ReasonMinGreaterThanMax, resp.Result.Reason)
+	}
+	if len(resp.Result.Details.Causes) != 1 {
+		t.Fatalf("expected 1 cause, got: %v", resp.Result.Details.Causes)
+	}
+	cause := resp.Result.Details.Causes[0]
+	if cause.Type != metav1.CauseType(validation.ReasonMinGreaterThanMax) || cause.Field != "spec.maxReplicas" {
+		t.Errorf("unexpected cause: %+v", cause)
+	}
+	url := "https://example.com/reasons.md#gpa001-mingreaterthanmax"
+	if !strings.Contains(cause.Message, url) || !strings.Contains(resp.Result.Message, url) {
+		t.Errorf("expected the docs url %v in the messages, got: %v, %v", url, cause.Message, resp.Result.Message)
+	}
+}
+
+func TestDenyTargetConflict(t *testing.T) {
+	minReplicas := int32(1)
+	newGPA := func(name string) *v1alpha1.GeneralPodAutoscaler {
+		return &v1alpha1.GeneralPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: v1alpha1.GeneralPodAutoscalerSpec{
+				ScaleTargetRef: v1alpha1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+				MinReplicas:    &minReplicas,
+				MaxReplicas:    2,
+			},
+		}
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := indexer.Add(newGPA("web-cpu")); err != nil {
+		t.Fatal(err)
+	}
+	whsvr := NewWebhookServer("", listers.NewGeneralPodAutoscalerLister(indexer))
+	create := func(gpa *v1alpha1.GeneralPodAutoscaler) *v1beta1.AdmissionResponse {
+		raw, err := json.Marshal(gpa)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return whsvr.mutate(&v1beta1.AdmissionReview{
+			Request: &v1beta1.AdmissionRequest{
+				Kind:      metav1.GroupVersionKind{Group: "autoscaling.ocgi.dev", Version: "v1alpha1", Kind: "GeneralPodAutoscaler"},
+				Name:      gpa.Name,
+				Namespace: gpa.Namespace,
+				Operation: v1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		})
+	}
+
+	resp := create(newGPA("web-qps"))
+	if resp.Allowed {
+		t.Fatalf("expected the gpa to be denied")
+	}
+	if resp.Result.Reason != metav1.StatusReason(validation.ReasonTargetConflict) {
+		t.Errorf("expected reason %v, got: %v", validation.ReasonTargetConflict, resp.Result.Reason)
+	}
+	if !strings.Contains(resp.Result.Message, "web-cpu") {
+		t.Errorf("expected the conflicting gpa in the message, got: %v", resp.Result.Message)
+	}
+
+	overridden := newGPA("web-qps")
+	overridden.Annotations = map[string]string{validation.AllowSharedTargetAnnotation: "true"}
+	if resp := create(overridden); !resp.Allowed {
+		t.Errorf("expected the annotated gpa to be allowed, got: %v", resp.Result.Message)
+	}
+
+	other := newGPA("api")
+	other.Spec.ScaleTargetRef.Name = "api"
+	if resp := create(other); !resp.Allowed {
+		t.Errorf("expected the gpa of another target to be allowed, got: %v", resp.Result.Message)
+	}
+}
+
+func TestMissingRequestsPolicy(t *testing.T) {
+	utilization := int32(50)
+	gpa := &v1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: v1alpha1.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+			MaxReplicas:    5,
+			AutoScalingDrivenMode: v1alpha1.AutoScalingDrivenMode{
+				MetricMode: &v1alpha1.MetricMode{
+					Metrics: []v1alpha1.MetricSpec{{
+						Type: v1alpha1.ResourceMetricSourceType,
+						Resource: &v1alpha1.ResourceMetricSource{
+							Name:   corev1.ResourceCPU,
+							Target: v1alpha1.MetricTarget{Type: v1alpha1.UtilizationMetricType, AverageUtilization: &utilization},
+						},
+					}},
+				},
+			},
+		},
+	}
+	pod := func(name string, requests corev1.ResourceList) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:      "app",
+				Resources: corev1.ResourceRequirements{Requests: requests},
+			}}},
+		}
+	}
+	requested := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+	mutate := func(policy MissingRequestsPolicy, gpa *v1alpha1.GeneralPodAutoscaler, pods ...*corev1.Pod) *v1beta1.AdmissionResponse {
+		whsvr := NewWebhookServer("", nil)
+		whsvr.SetMissingRequestsPolicy(policy, func(namespace string, ref v1alpha1.CrossVersionObjectReference) ([]*corev1.Pod, error) {
+			if namespace != "default" || ref.Name != "web" {
+				t.Errorf("unexpected target %s/%s", namespace, ref.Name)
+			}
+			return pods, nil
+		})
+		raw, err := json.Marshal(gpa)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return whsvr.mutate(&v1beta1.AdmissionReview{
+			Request: &v1beta1.AdmissionRequest{
+				Kind:      metav1.GroupVersionKind{Group: "autoscaling.ocgi.dev", Version: "v1alpha1", Kind: "GeneralPodAutoscaler"},
+				Name:      gpa.Name,
+				Namespace: gpa.Namespace,
+				Operation: v1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		})
+	}
+	// the pods are not checked by default
+	if resp := mutate(IgnoreMissingRequests, gpa, pod("web-0", nil)); !resp.Allowed || resp.Patch != nil {
+		t.Errorf("expected the gpa to be allowed without patch, got: %+v", resp)
+	}
+
+	resp := mutate(DenyMissingRequests, gpa, pod("web-0", requested), pod("web-1", nil))
+	if resp.Allowed {
+		t.Fatalf("expected the gpa to be denied")
+	}
+	if resp.Result.Reason != metav1.StatusReason(validation.ReasonMissingResourceRequests) {
+		t.Errorf("expected reason %v, got: %v", validation.ReasonMissingResourceRequests, resp.Result.Reason)
+	}
+	if !strings.Contains(resp.Result.Message, "web-1") || strings.Contains(resp.Result.Message, "web-0") {
+		t.Errorf("expected only web-1 in the message, got: %v", resp.Result.Message)
+	}
+	if resp := mutate(DenyMissingRequests, gpa, pod("web-0", requested)); !resp.Allowed {
+		t.Errorf("expected the gpa to be allowed, got: %v", resp.Result.Message)
+	}
+
+	// the warning is annotated, and removed once the pods request the cpu
+	var patch []jsonPatchOperation
+	resp = mutate(WarnMissingRequests, gpa, pod("web-1", nil))
+	if !resp.Allowed {
+		t.Fatalf("expected the gpa to be allowed, got: %v", resp.Result.Message)
+	}
+	if err := json.Unmarshal(resp.Patch, &patch); err != nil {
+		t.Fatal(err)
+	}
+	if len(patch) != 2 || patch[1].Path != "/metadata/annotations/autoscaling.ocgi.io~1missing-requests" ||
+		!strings.Contains(patch[1].Value.(string), "web-1") {
+		t.Errorf("unexpected patch: %s", resp.Patch)
+	}
+	annotated := gpa.DeepCopy()
+	annotated.Annotations = map[string]string{MissingRequestsAnnotation: "..."}
+	resp = mutate(WarnMissingRequests, annotated, pod("web-1", requested))
+	if string(resp.Patch) != `[{"op":"remove","path":"/metadata/annotations/autoscaling.ocgi.io~1missing-requests"}]` {
+		t.Errorf("unexpected patch: %s", resp.Patch)
+	}
+}
+
+func TestInternalErrorPolicy(t *testing.T) {
+	request := &v1beta1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Group: "autoscaling.ocgi.dev", Version: "v1alpha1", Kind: "GeneralPodAutoscaler"},
+		Name:      "web",
+		Namespace: "default",
+		Operation: v1beta1.Create,
+		Object:    runtime.RawExtension{Raw: []byte(`{"spec": "web"}`)},
+	}
+	for _, c := range []struct {
+		policy  InternalErrorPolicy
+		allowed bool
+	}{
+		{policy: "", allowed: false},
+		{policy: DenyOnInternalError, allowed: false},
+		{policy: AllowOnInternalError, allowed: true},
+	} {
+		whsvr := NewWebhookServer("", nil)
+		whsvr.SetInternalErrorPolicy(c.policy)
+		resp := whsvr.mutate(&v1beta1.AdmissionReview{Request: request})
+		if resp.Allowed != c.allowed {
+			t.Errorf("policy %q: expected allowed %v on the decode error, got: %+v", c.policy, c.allowed, resp)
+		}
+		if resp.Result == nil || resp.Result.Message == "" {
+			t.Errorf("policy %q: expected the decode error in the result, got: %+v", c.policy, resp.Result)
+		}
+	}
+
+	// the validation errors are still denied when failing open
+	minReplicas := int32(3)
+	raw, err := json.Marshal(v1alpha1.GeneralPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: v1alpha1.GeneralPodAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    2,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := *request
+	invalid.Object = runtime.RawExtension{Raw: raw}
+	whsvr := NewWebhookServer("", nil)
+	whsvr.SetInternalErrorPolicy(AllowOnInternalError)
+	if resp := whsvr.mutate(&v1beta1.AdmissionReview{Request: &invalid}); resp.Allowed {
+		t.Errorf("expected the invalid gpa to be denied")
+	}
+}
+
+func TestMaxReplicasCeiling(t *testing.T) {
+	utilization := int32(50)
+	newGPA := func(minReplicas, maxReplicas int32) *v1alpha1.GeneralPodAutoscaler {
+		return &v1alpha1.GeneralPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", ResourceVersion: "1"},
+			Spec: v1alpha1.GeneralPodAutoscalerSpec{
+				ScaleTargetRef: v1alpha1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+				MinReplicas:    &minReplicas,
+				MaxReplicas:    maxReplicas,
+				AutoScalingDrivenMode: v1alpha1.AutoScalingDrivenMode{
+					MetricMode: &v1alpha1.MetricMode{
+						Metrics: []v1alpha1.MetricSpec{{
+							Type: v1alpha1.ResourceMetricSourceType,
+							Resource: &v1alpha1.ResourceMetricSource{
+								Name:   corev1.ResourceCPU,
+								Target: v1alpha1.MetricTarget{Type: v1alpha1.UtilizationMetricType, AverageUtilization: &utilization},
+							},
+						}},
+					},
+				},
+			},
+		}
+	}
+	mutate := func(policy MaxReplicasCeilingPolicy, gpa, oldGPA *v1alpha1.GeneralPodAutoscaler) *v1beta1.AdmissionResponse {
+		whsvr := NewWebhookServer("", nil)
+		whsvr.SetMaxReplicasCeiling(20, policy)
+		request := &v1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Group: "autoscaling.ocgi.dev", Version: "v1alpha1", Kind: "GeneralPodAutoscaler"},
+			Name:      gpa.Name,
+			Namespace: gpa.Namespace,
+			Operation: v1beta1.Create,
+		}
+		raw, err := json.Marshal(gpa)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Object = runtime.RawExtension{Raw: raw}
+		if oldGPA != nil {
+			if raw, err = json.Marshal(oldGPA); err != nil {
+				t.Fatal(err)
+			}
+			request.Operation = v1beta1.Update
+			request.OldObject = runtime.RawExtension{Raw: raw}
+		}
+		return whsvr.mutate(&v1beta1.AdmissionReview{Request: request})
+	}
+
+	for _, policy := range []MaxReplicasCeilingPolicy{RejectAboveCeiling, ClampToCeiling} {
+		if resp := mutate(policy, newGPA(1, 20), nil); !resp.Allowed || resp.Patch != nil {
+			t.Errorf("policy %s: expected the gpa at the ceiling to be allowed without patch, got: %+v", policy, resp)
+		}
+		// the GPAs predating the ceiling may still be updated, as long as their spec is not changed
+		if resp := mutate(policy, newGPA(1, 50), newGPA(1, 50)); !resp.Allowed || resp.Patch != nil {
+			t.Errorf("policy %s: expected the unchanged gpa to be allowed without patch, got: %+v", policy, resp)
+		}
+	}
+
+	for _, oldGPA := range []*v1alpha1.GeneralPodAutoscaler{nil, newGPA(1, 10)} {
+		resp := mutate(RejectAboveCeiling, newGPA(1, 50), oldGPA)
+		if resp.Allowed {
+			t.Fatalf("expected the gpa above the ceiling to be denied")
+		}
+		if resp.Result.Reason != metav1.StatusReason(validation.ReasonMaxReplicasAboveCeiling) {
+			t.Errorf("expected reason %v, got: %v", validation.ReasonMaxReplicasAboveCeiling, resp.Result.Reason)
+		}
+
+		resp = mutate(ClampToCeiling, newGPA(1, 50), oldGPA)
+		if !resp.Allowed {
+			t.Fatalf("expected the clamped gpa to be allowed, got: %v", resp.Result.Message)
+		}
+		if string(resp.Patch) != `[{"op":"replace","path":"/spec/maxReplicas","value":20}]` {
+			t.Errorf("unexpected patch: %s", resp.Patch)
+		}
+	}
+
+	// the clamped GPA is validated as it is stored
+	resp := mutate(ClampToCeiling, newGPA(30, 50), nil)
+	if resp.Allowed {
+		t.Fatalf("expected the gpa with minReplicas above the ceiling to be denied")
+	}
+	if resp.Result.Reason != metav1.StatusReason(validation.ReasonMinGreaterThanMax) {
+		t.Errorf("expected reason %v, got: %v", validation.ReasonMinGreaterThanMax, resp.Result.Reason)
+	}
+}
+
+func TestSecretValidation(t *testing.T) {
+	newGPA := func(key string) *v1alpha1.GeneralPodAutoscaler {
+		return &v1alpha1.GeneralPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", ResourceVersion: "1"},
+			Spec: v1alpha1.GeneralPodAutoscalerSpec{
+				ScaleTargetRef: v1alpha1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+				MaxReplicas:    10,
+				AutoScalingDrivenMode: v1alpha1.AutoScalingDrivenMode{
+					WebhookMode: &v1alpha1.WebhookMode{
+						WebhookClientConfig: &admissionregistrationv1beta1.WebhookClientConfig{URL: &[]string{"https://recommender"}[0]},
+						HMACSecretRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "recommender"},
+							Key:                  key,
+						},
+					},
+				},
+			},
+		}
+	}
+	secrets := func(namespace, name string) (*corev1.Secret, error) {
+		if namespace == "default" && name == "recommender" {
+			return &corev1.Secret{Data: map[string][]byte{"hmac": []byte("key")}}, nil
+		}
+		return nil, apierrors.NewNotFound(corev1.Resource("secrets"), name)
+	}
+	mutate := func(secrets SecretGetter, gpa, oldGPA *v1alpha1.GeneralPodAutoscaler) *v1beta1.AdmissionResponse {
+		whsvr := NewWebhookServer("", nil)
+		whsvr.SetSecretValidation(secrets)
+		request := &v1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Group: "autoscaling.ocgi.dev", Version: "v1alpha1", Kind: "GeneralPodAutoscaler"},
+			Name:      gpa.Name,
+			Namespace: gpa.Namespace,
+			Operation: v1beta1.Create,
+		}
+		raw, err := json.Marshal(gpa)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Object = runtime.RawExtension{Raw: raw}
+		if oldGPA != nil {
+			if raw, err = json.Marshal(oldGPA); err != nil {
+				t.Fatal(err)
+			}
+			request.Operation = v1beta1.Update
+			request.OldObject = runtime.RawExtension{Raw: raw}
+		}
+		return whsvr.mutate(&v1beta1.AdmissionReview{Request: request})
+	}
+
+	if resp := mutate(secrets, newGPA("hmac"), nil); !resp.Allowed {
+		t.Errorf("expected the gpa referencing an existing key to be allowed, got: %v", resp.Result.Message)
+	}
+	for _, oldGPA := range []*v1alpha1.GeneralPodAutoscaler{nil, newGPA("hmac")} {
+		resp := mutate(secrets, newGPA("token"), oldGPA)
+		if resp.Allowed {
+			t.Fatalf("expected the gpa referencing a missing key to be denied")
+		}
+		if resp.Result.Reason != metav1.StatusReason(validation.ReasonMissingSecret) {
+			t.Errorf("expected reason %v, got: %v", validation.ReasonMissingSecret, resp.Result.Reason)
+		}
+		if !strings.Contains(resp.Result.Message, "spec.webhook.hmacSecretRef.key") ||
+			!strings.Contains(resp.Result.Message, "secret recommender has no key token") {
+			t.Errorf("unexpected message: %v", resp.Result.Message)
+		}
+	}
+	missing := func(namespace, name string) (*corev1.Secret, error) {
+		return nil, apierrors.NewNotFound(corev1.Resource("secrets"), name)
+	}
+	if resp := mutate(missing, newGPA("hmac"), nil); resp.Allowed ||
+		!strings.Contains(resp.Result.Message, "secret recommender does not exist in namespace default") {
+		t.Errorf("expected the gpa referencing a missing secret to be denied, got: %+v", resp.Result)
+	}
+	// the GPAs whose secrets are deleted may still be updated, as long as their spec is not changed
+	if resp := mutate(missing, newGPA("hmac"), newGPA("hmac")); !resp.Allowed {
+		t.Errorf("expected the unchanged gpa to be allowed, got: %v", resp.Result.Message)
+	}
+	if resp := mutate(nil, newGPA("token"), nil); !resp.Allowed {
+		t.Errorf("expected the secrets not to be checked when disabled, got: %v", resp.Result.Message)
+	}
+	forbidden := func(namespace, name string) (*corev1.Secret, error) {
+		return nil, apierrors.NewForbidden(corev1.Resource("secrets"), name, nil)
+	}
+	if resp := mutate(forbidden, newGPA("token"), nil); !resp.Allowed {
+		t.Errorf("expected the gpa to be allowed when the secrets can not be got, got: %v", resp.Result.Message)
+	}
+}
+
+func TestMaxReplicasDropPolicy(t *testing.T) {
+	utilization := int32(50)
+	newGPA := func(maxReplicas int32, annotations map[string]string) *v1alpha1.GeneralPodAutoscaler {
+		return &v1alpha1.GeneralPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", ResourceVersion: "1", Annotations: annotations},
+			Spec: v1alpha1.GeneralPodAutoscalerSpec{
+				ScaleTargetRef: v1alpha1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+				MaxReplicas:    maxReplicas,
+				AutoScalingDrivenMode: v1alpha1.AutoScalingDrivenMode{
+					MetricMode: &v1alpha1.MetricMode{
+						Metrics: []v1alpha1.MetricSpec{{
+							Type: v1alpha1.ResourceMetricSourceType,
+							Resource: &v1alpha1.ResourceMetricSource{
+								Name:   corev1.ResourceCPU,
+								Target: v1alpha1.MetricTarget{Type: v1alpha1.UtilizationMetricType, AverageUtilization: &utilization},
+							},
+						}},
+					},
+				},
+			},
+		}
+	}
+	// the target runs 40 pods, one of them being deleted
+	var pods []*corev1.Pod
+	for i := 0; i < 40; i++ {
+		pods = append(pods, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-" + strconv.Itoa(i), Namespace: "default"}})
+	}
+	pods[0].DeletionTimestamp = &metav1.Time{}
+	listed := func(namespace string, ref v1alpha1.CrossVersionObjectReference) ([]*corev1.Pod, error) {
+		return pods, nil
+	}
+	mutate := func(policy MaxReplicasDropPolicy, targetPods TargetPodsLister, gpa, oldGPA *v1alpha1.GeneralPodAutoscaler) *v1beta1.AdmissionResponse {
+		whsvr := NewWebhookServer("", nil)
+		whsvr.SetMaxReplicasDropPolicy(policy, 0.5, targetPods)
+		request := &v1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Group: "autoscaling.ocgi.dev", Version: "v1alpha1", Kind: "GeneralPodAutoscaler"},
+			Name:      gpa.Name,
+			Namespace: gpa.Namespace,
+			Operation: v1beta1.Create,
+		}
+		raw, err := json.Marshal(gpa)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Object = runtime.RawExtension{Raw: raw}
+		if oldGPA != nil {
+			if raw, err = json.Marshal(oldGPA); err != nil {
+				t.Fatal(err)
+			}
+			request.Operation = v1beta1.Update
+			request.OldObject = runtime.RawExtension{Raw: raw}
+		}
+		return whsvr.mutate(&v1beta1.AdmissionReview{Request: request})
+	}
+
+	if resp := mutate(IgnoreMaxReplicasDrop, listed, newGPA(5, nil), nil); !resp.Allowed || resp.Patch != nil {
+		t.Errorf("expected the gpa to be allowed without patch by default, got: %+v", resp)
+	}
+	for _, oldGPA := range []*v1alpha1.GeneralPodAutoscaler{nil, newGPA(50, nil)} {
+		resp := mutate(DenyMaxReplicasDrop, listed, newGPA(5, nil), oldGPA)
+		if resp.Allowed {
+			t.Fatalf("expected the gpa far below the current replicas to be denied")
+		}
+		if resp.Result.Reason != metav1.StatusReason(validation.ReasonMaxReplicasFarBelowCurrent) {
+			t.Errorf("expected reason %v, got: %v", validation.ReasonMaxReplicasFarBelowCurrent, resp.Result.Reason)
+		}
+		if !strings.Contains(resp.Result.Message, "current replicas 39") {
+			t.Errorf("expected the 39 running pods in the message, got: %v", resp.Result.Message)
+		}
+	}
+	for _, gpa := range []*v1alpha1.GeneralPodAutoscaler{
+		newGPA(20, nil),
+		newGPA(5, map[string]string{validation.AllowMaxReplicasDropAnnotation: "true"}),
+	} {
+		if resp := mutate(DenyMaxReplicasDrop, listed, gpa, nil); !resp.Allowed {
+			t.Errorf("expected the gpa with maxReplicas %d to be allowed, got: %v", gpa.Spec.MaxReplicas, resp.Result.Message)
+		}
+	}
+	// the GPAs may still be updated, as long as their spec is not changed
+	if resp := mutate(DenyMaxReplicasDrop, listed, newGPA(5, nil), newGPA(5, nil)); !resp.Allowed {
+		t.Errorf("expected the unchanged gpa to be allowed, got: %v", resp.Result.Message)
+	}
+	failed := func(namespace string, ref v1alpha1.CrossVersionObjectReference) ([]*corev1.Pod, error) {
+		return nil, apierrors.NewForbidden(corev1.Resource("pods"), "", nil)
+	}
+	if resp := mutate(DenyMaxReplicasDrop, failed, newGPA(5, nil), nil); !resp.Allowed {
+		t.Errorf("expected the gpa to be allowed when the pods can not be listed, got: %v", resp.Result.Message)
+	}
+
+	// the warning is annotated, and removed once maxReplicas is raised
+	resp := mutate(WarnMaxReplicasDrop, listed, newGPA(5, nil), nil)
+	if !resp.Allowed {
+		t.Fatalf("expected the gpa to be allowed, got: %v", resp.Result.Message)
+	}
+	var patch []jsonPatchOperation
+	if err := json.Unmarshal(resp.Patch, &patch); err != nil {
+		t.Fatal(err)
+	}
+	if len(patch) != 2 || patch[1].Path != "/metadata/annotations/autoscaling.ocgi.io~1max-replicas-drop" ||
+		!strings.Contains(patch[1].Value.(string), "current replicas 39") {
+		t.Errorf("unexpected patch: %s", resp.Patch)
+	}
+	resp = mutate(WarnMaxReplicasDrop, listed, newGPA(20, map[string]string{MaxReplicasDropAnnotation: "..."}), nil)
+	if string(resp.Patch) != `[{"op":"remove","path":"/metadata/annotations/autoscaling.ocgi.io~1max-replicas-drop"}]` {
+		t.Errorf("unexpected patch: %s", resp.Patch)
+	}
+}
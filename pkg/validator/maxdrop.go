@@ -0,0 +1,104 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/validation"
+)
+
+// MaxReplicasDropPolicy is what the webhook does with the GPAs whose maxReplicas is far below the current replicas
+// of their targets
+type MaxReplicasDropPolicy string
+
+const (
+	// IgnoreMaxReplicasDrop admits the GPAs without checking the targets
+	IgnoreMaxReplicasDrop MaxReplicasDropPolicy = "Ignore"
+	// WarnMaxReplicasDrop admits the GPAs, but annotates them with MaxReplicasDropAnnotation
+	WarnMaxReplicasDrop MaxReplicasDropPolicy = "Warn"
+	// DenyMaxReplicasDrop denies the GPAs
+	DenyMaxReplicasDrop MaxReplicasDropPolicy = "Deny"
+
+	// MaxReplicasDropAnnotation is set by WarnMaxReplicasDrop to the drop of the replicas, it is removed once
+	// maxReplicas is raised
+	MaxReplicasDropAnnotation = "autoscaling.ocgi.io/max-replicas-drop"
+)
+
+// SetMaxReplicasDropPolicy checks the maxReplicas of the GPAs against the current replicas of their targets, counted
+// by the pods listed by targetPods, by the policy. The maxReplicas below the ratio of the current replicas is
+// reported, the check is disabled if the ratio is not greater than 0.
+func (whsvr *webhookServer) SetMaxReplicasDropPolicy(policy MaxReplicasDropPolicy, ratio float64,
+	targetPods TargetPodsLister) {
+	whsvr.maxReplicasDropPolicy = policy
+	whsvr.maxReplicasDropRatio = ratio
+	whsvr.targetPods = targetPods
+}
+
+// validateMaxReplicasDrop returns the errors of the maxReplicas of the GPA far below the current replicas of its
+// target if the policy is Deny. If the policy is Warn, the patch of the annotation of the GPA is returned instead.
+// Like the requests, the target is only checked at best effort, failing to list its pods never fails the admission.
+func (whsvr *webhookServer) validateMaxReplicasDrop(gpa *v1alpha1.GeneralPodAutoscaler,
+	namespace string) ([]jsonPatchOperation, field.ErrorList) {
+	if whsvr.targetPods == nil || whsvr.maxReplicasDropPolicy == "" || whsvr.maxReplicasDropPolicy == IgnoreMaxReplicasDrop {
+		return nil, nil
+	}
+	pods, err := whsvr.targetPods(namespace, gpa.Spec.ScaleTargetRef)
+	if err != nil {
+		klog.Warningf("List pods of %s %s/%s failed, skip checking the max replicas: %v",
+			gpa.Spec.ScaleTargetRef.Kind, namespace, gpa.Spec.ScaleTargetRef.Name, err)
+		return nil, nil
+	}
+	var current int32
+	for _, pod := range pods {
+		if pod.DeletionTimestamp == nil {
+			current++
+		}
+	}
+	errs := validation.ValidateMaxReplicasDrop(gpa, current, whsvr.maxReplicasDropRatio)
+	if whsvr.maxReplicasDropPolicy == DenyMaxReplicasDrop {
+		return nil, errs
+	}
+	var message string
+	if len(errs) > 0 {
+		message = errs[0].Error()
+		klog.Warningf("GPA %s/%s: %s", namespace, gpa.Name, message)
+	}
+	return annotationPatch(gpa, MaxReplicasDropAnnotation, message), nil
+}
+
+// annotationPatch returns the patch setting the annotation of the GPA to the message, or removing it if the message
+// is empty. The annotations of the GPA are initialized along with the patch, so that the patches of several
+// annotations do not reset each other.
+func annotationPatch(gpa *v1alpha1.GeneralPodAutoscaler, key, message string) []jsonPatchOperation {
+	path := "/metadata/annotations/" + strings.Replace(key, "/", "~1", -1)
+	_, annotated := gpa.Annotations[key]
+	var patch []jsonPatchOperation
+	switch {
+	case message != "":
+		if gpa.Annotations == nil {
+			patch = append(patch, jsonPatchOperation{Op: "add", Path: "/metadata/annotations", Value: map[string]string{}})
+			gpa.Annotations = map[string]string{}
+		}
+		patch = append(patch, jsonPatchOperation{Op: "add", Path: path, Value: message})
+	case annotated:
+		patch = append(patch, jsonPatchOperation{Op: "remove", Path: path})
+	}
+	return patch
+}
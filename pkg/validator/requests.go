@@ -0,0 +1,105 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/validation"
+)
+
+// MissingRequestsPolicy is what the webhook does with the GPAs whose utilization targets lack the resource
+// requests in the pods of their targets
+type MissingRequestsPolicy string
+
+const (
+	// IgnoreMissingRequests admits the GPAs without checking the pods
+	IgnoreMissingRequests MissingRequestsPolicy = "Ignore"
+	// WarnMissingRequests admits the GPAs, but annotates them with MissingRequestsAnnotation
+	WarnMissingRequests MissingRequestsPolicy = "Warn"
+	// DenyMissingRequests denies the GPAs
+	DenyMissingRequests MissingRequestsPolicy = "Deny"
+
+	// MissingRequestsAnnotation is set by WarnMissingRequests to the pods lacking the requests, it is removed
+	// once the pods set them
+	MissingRequestsAnnotation = "autoscaling.ocgi.io/missing-requests"
+)
+
+// TargetPodsLister lists the pods of the scale target referenced in the namespace
+type TargetPodsLister func(namespace string, ref v1alpha1.CrossVersionObjectReference) ([]*corev1.Pod, error)
+
+// jsonPatchOperation is an operation of a JSON patch
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// SetMissingRequestsPolicy checks the pods of the targets listed by targetPods against the utilization targets
+// of the GPAs by the policy
+func (whsvr *webhookServer) SetMissingRequestsPolicy(policy MissingRequestsPolicy, targetPods TargetPodsLister) {
+	whsvr.missingRequestsPolicy = policy
+	whsvr.targetPods = targetPods
+}
+
+// validateResourceRequests returns the errors of the pods lacking the requests if the policy is Deny. If the
+// policy is Warn, the patch of the annotation of the GPA is returned instead. Failing to list the pods never
+// fails the admission, the pods, which may not exist yet, are only checked at best effort.
+func (whsvr *webhookServer) validateResourceRequests(gpa *v1alpha1.GeneralPodAutoscaler,
+	namespace string) ([]jsonPatchOperation, field.ErrorList) {
+	if whsvr.targetPods == nil || whsvr.missingRequestsPolicy == "" || whsvr.missingRequestsPolicy == IgnoreMissingRequests {
+		return nil, nil
+	}
+	pods, err := whsvr.targetPods(namespace, gpa.Spec.ScaleTargetRef)
+	if err != nil {
+		klog.Warningf("List pods of %s %s/%s failed, skip checking the requests: %v",
+			gpa.Spec.ScaleTargetRef.Kind, namespace, gpa.Spec.ScaleTargetRef.Name, err)
+		return nil, nil
+	}
+	errs := validation.ValidateResourceRequests(gpa, pods)
+	if whsvr.missingRequestsPolicy == DenyMissingRequests {
+		return nil, errs
+	}
+
+	var message string
+	if len(errs) > 0 {
+		messages := make([]string, 0, len(errs))
+		for _, err := range errs {
+			messages = append(messages, err.Error())
+		}
+		message = strings.Join(messages, "; ")
+		klog.Warningf("GPA %s/%s: %s", namespace, gpa.Name, message)
+	}
+	return annotationPatch(gpa, MissingRequestsAnnotation, message), nil
+}
+
+// marshalPatch returns the JSON patch of the operations on the GPA, nil if there are none
+func marshalPatch(gpa *v1alpha1.GeneralPodAutoscaler, namespace string, patch []jsonPatchOperation) []byte {
+	if len(patch) == 0 {
+		return nil
+	}
+	raw, err := json.Marshal(patch)
+	if err != nil {
+		klog.Errorf("Marshal patch of GPA %s/%s failed: %v", namespace, gpa.Name, err)
+		return nil
+	}
+	return raw
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/validation"
+)
+
+// SecretGetter gets the secret of the name in the namespace
+type SecretGetter func(namespace, name string) (*corev1.Secret, error)
+
+// SetSecretValidation checks that the secrets referenced by the GPAs, got by secrets, exist and have the referenced
+// keys. It is disabled if secrets is nil, e.g. when the secrets are created after the GPAs referencing them.
+func (whsvr *webhookServer) SetSecretValidation(secrets SecretGetter) {
+	whsvr.secrets = secrets
+}
+
+// validateSecretReferences returns the errors of the secrets referenced by the GPA which do not exist or lack the
+// referenced keys. Failing to get the secrets for another reason never fails the admission.
+func (whsvr *webhookServer) validateSecretReferences(gpa *v1alpha1.GeneralPodAutoscaler, namespace string) field.ErrorList {
+	if whsvr.secrets == nil {
+		return nil
+	}
+	errs, err := validation.ValidateSecretReferences(gpa, func(name string) (*corev1.Secret, error) {
+		return whsvr.secrets(namespace, name)
+	})
+	if err != nil {
+		klog.Warningf("Get secrets of GPA %s/%s failed, skip checking the secret references: %v", namespace, gpa.Name, err)
+		return nil
+	}
+	return errs
+}
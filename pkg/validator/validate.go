@@ -0,0 +1,34 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/validation"
+)
+
+// ValidateGPA returns the errors of the GPA as the webhook validates it, for the admission servers embedding the
+// validation of the GPAs. oldGPA is nil on a create, and the stored GPA on an update. Only the GPA itself is
+// validated: the checks against the cluster, e.g. of the conflicting GPAs, of the referenced secrets or of the
+// requests of the pods, and the max replicas ceiling are left to the webhook server as they need its options
+// and listers. The errors carry the denial reasons of validation.ReasonForError.
+func ValidateGPA(oldGPA, newGPA *v1alpha1.GeneralPodAutoscaler) field.ErrorList {
+	if oldGPA == nil {
+		return validation.ValidateHorizontalPodAutoscaler(newGPA)
+	}
+	return validation.ValidateHorizontalPodAutoscalerUpdate(newGPA, oldGPA)
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 The OCGI Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/ocgi/general-pod-autoscaler/pkg/apis/autoscaling/v1alpha1"
+	"github.com/ocgi/general-pod-autoscaler/pkg/validation"
+)
+
+func TestValidateGPA(t *testing.T) {
+	newGPA := func(modify func(gpa *v1alpha1.GeneralPodAutoscaler)) *v1alpha1.GeneralPodAutoscaler {
+		minReplicas := int32(1)
+		gpa := &v1alpha1.GeneralPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "gpa", Namespace: "default", ResourceVersion: "1"},
+			Spec: v1alpha1.GeneralPodAutoscalerSpec{
+				ScaleTargetRef: v1alpha1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+				MinReplicas:    &minReplicas,
+				MaxReplicas:    10,
+			},
+		}
+		if modify != nil {
+			modify(gpa)
+		}
+		return gpa
+	}
+	for _, c := range []struct {
+		name   string
+		oldGPA *v1alpha1.GeneralPodAutoscaler
+		newGPA *v1alpha1.GeneralPodAutoscaler
+		field  string
+		reason validation.Reason
+	}{
+		{
+			name:   "valid create",
+			newGPA: newGPA(nil),
+		},
+		{
+			name:   "create with min greater than max",
+			newGPA: newGPA(func(gpa *v1alpha1.GeneralPodAutoscaler) { *gpa.Spec.MinReplicas = 11 }),
+			field:  "spec.maxReplicas",
+			reason: validation.ReasonMinGreaterThanMax,
+		},
+		{
+			name:   "valid update",
+			oldGPA: newGPA(nil),
+			newGPA: newGPA(func(gpa *v1alpha1.GeneralPodAutoscaler) { gpa.Spec.MaxReplicas = 20 }),
+		},
+		{
+			name:   "update with an invalid spec",
+			oldGPA: newGPA(nil),
+			newGPA: newGPA(func(gpa *v1alpha1.GeneralPodAutoscaler) { gpa.Spec.ScaleTargetRef.Name = "" }),
+			field:  "spec.scaleTargetRef.name",
+			reason: validation.ReasonInvalidScaleTargetRef,
+		},
+		{
+			name:   "update renaming the gpa",
+			oldGPA: newGPA(nil),
+			newGPA: newGPA(func(gpa *v1alpha1.GeneralPodAutoscaler) { gpa.Name = "renamed" }),
+			field:  "metadata.name",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			errs := ValidateGPA(c.oldGPA, c.newGPA)
+			if c.field == "" {
+				if len(errs) != 0 {
+					t.Fatalf("expected no errors, got: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != c.field {
+				t.Fatalf("expected an error of %s, got: %v", c.field, errs)
+			}
+			if c.reason != "" && validation.ReasonForError(errs[0]) != c.reason {
+				t.Errorf("expected reason %v, got: %v", c.reason, validation.ReasonForError(errs[0]))
+			}
+		})
+	}
+}